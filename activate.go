@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 )
@@ -29,7 +30,34 @@ type ActivationOptions struct {
 	HTTPClient       *http.Client
 	AllowSystemTrust bool
 	PinnedSPKIHashes []string
-	UserAgent        string
+	// Transport sets proxy and TLS options for the activation request, as
+	// Config.Transport does for the guard.
+	Transport TransportConfig
+	UserAgent string
+	// Headers are added to the activation request, e.g. for an API gateway
+	// in front of the license server.
+	Headers http.Header
+	// Retry retries the request after transient failures. The zero value
+	// sends it once. Every attempt carries the same Idempotency-Key so a
+	// server that already redeemed the code can answer the repeat.
+	Retry RetryPolicy
+	// IncludeMachineInfo sends this machine's fingerprint, hostname, OS and
+	// architecture so the server can record where the code was redeemed.
+	IncludeMachineInfo bool
+	// FingerprintConfig limits the aux signals sent with IncludeMachineInfo,
+	// as Config.Fingerprint does for the guard.
+	FingerprintConfig FingerprintConfig
+}
+
+type activateRequestBody struct {
+	Code         string            `json:"code"`
+	Organization string            `json:"organization"`
+	Email        string            `json:"email,omitempty"`
+	MachineID    string            `json:"machine_id,omitempty"`
+	AuxSignals   map[string]string `json:"aux_signals,omitempty"`
+	Hostname     string            `json:"hostname,omitempty"`
+	OS           string            `json:"os,omitempty"`
+	Arch         string            `json:"arch,omitempty"`
 }
 
 // Activate sends a CDK activation request to the server.
@@ -78,6 +106,7 @@ func ActivateWithOptions(opts ActivationOptions) (*ActivationResult, error) {
 			ServerURL:        serverURL,
 			AllowSystemTrust: opts.AllowSystemTrust,
 			PinnedSPKIHashes: opts.PinnedSPKIHashes,
+			Transport:        opts.Transport,
 		})
 		if err != nil {
 			return nil, err
@@ -86,29 +115,50 @@ func ActivateWithOptions(opts ActivationOptions) (*ActivationResult, error) {
 		client = pinnedClient
 	}
 
-	payload := map[string]string{
-		"code":         opts.Code,
-		"organization": opts.Organization,
+	payload := activateRequestBody{
+		Code:         opts.Code,
+		Organization: opts.Organization,
+		Email:        opts.Email,
 	}
-	if opts.Email != "" {
-		payload["email"] = opts.Email
+	if opts.IncludeMachineInfo {
+		fp, err := collectFingerprint()
+		if err != nil {
+			return nil, fmt.Errorf("collect fingerprint: %w", err)
+		}
+		fp = fp.withPrivacy(opts.FingerprintConfig)
+		payload.MachineID = fp.MachineID()
+		payload.AuxSignals = fp.AuxSignals()
+		payload.Hostname = hostname()
+		payload.OS = fp.auxSignals["os"]
+		payload.Arch = fp.auxSignals["arch"]
 	}
 
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL+"/api/v1/activate", bytes.NewReader(data))
+	idempotencyKey, err := randomNonce()
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", activationUserAgent(opts.UserAgent))
 
-	resp, err := client.Do(req)
+	resp, err := doActivationRequest(ctx, client, activationRetryPolicy(opts.Retry), func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL+"/api/v1/activate", bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		for name, values := range opts.Headers {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", activationUserAgent(opts.UserAgent))
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrNetworkError, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -128,6 +178,58 @@ func ActivateWithOptions(opts ActivationOptions) (*ActivationResult, error) {
 	return &result, nil
 }
 
+// doActivationRequest is Guard.doRequest for package-level calls that have no
+// guard: it retries transient failures per policy.
+func doActivationRequest(ctx context.Context, client *http.Client, policy RetryPolicy, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrNetworkError, err)
+			}
+			return resp, nil
+		}
+		wait, ok := retryDelay(policy, attempt, resp, err)
+		if !ok {
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrNetworkError, err)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxAPIErrorBodyBytes))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %v", ErrNetworkError, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// activationRetryPolicy fills in backoff defaults. Unlike Config.Retry, a
+// zero MaxAttempts means a single attempt.
+func activationRetryPolicy(policy RetryPolicy) RetryPolicy {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 500 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 10 * time.Second
+	}
+	return policy
+}
+
 func activationUserAgent(userAgent string) string {
 	if userAgent != "" {
 		return userAgent
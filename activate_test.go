@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 type activateCtxKey string
@@ -216,3 +217,67 @@ func TestActivateWithOptions_PreservesStructuredAPIErrors(t *testing.T) {
 		t.Fatalf("unexpected API error payload: %#v", apiErr)
 	}
 }
+
+func TestActivateWithOptions_RetriesWithSameIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if got := r.Header.Get("X-Gateway-Token"); got != "gw-secret" {
+			t.Errorf("expected custom header, got %q", got)
+		}
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body activateRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body.MachineID == "" || body.OS == "" {
+			t.Errorf("expected machine info in request, got %#v", body)
+		}
+		_ = json.NewEncoder(w).Encode(ActivationResult{LicenseKey: "key"})
+	}))
+	defer server.Close()
+
+	result, err := ActivateWithOptions(ActivationOptions{
+		ServerURL:          server.URL,
+		Code:               "CDK-TEST-CODE",
+		Organization:       "Acme Corp",
+		AllowSystemTrust:   true,
+		Headers:            http.Header{"X-Gateway-Token": []string{"gw-secret"}},
+		Retry:              RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		IncludeMachineInfo: true,
+	})
+	if err != nil {
+		t.Fatalf("ActivateWithOptions failed: %v", err)
+	}
+	if result.LicenseKey != "key" {
+		t.Fatalf("unexpected license key: %q", result.LicenseKey)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("expected two attempts with one idempotency key, got %q", keys)
+	}
+}
+
+func TestActivateWithOptions_DoesNotRetryByDefault(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := ActivateWithOptions(ActivationOptions{
+		ServerURL:        server.URL,
+		Code:             "CDK-TEST-CODE",
+		Organization:     "Acme Corp",
+		AllowSystemTrust: true,
+	})
+	if err == nil {
+		t.Fatal("expected activation error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls)
+	}
+}
@@ -0,0 +1,226 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// announcementsReadFileName is the cache entry listing the IDs of the
+// announcements marked read.
+const announcementsReadFileName = "announcements_read.json"
+
+// maxReadAnnouncements bounds the read IDs kept in the cache; the oldest are
+// dropped first.
+const maxReadAnnouncements = 256
+
+// AnnouncementSeverity ranks an announcement for display.
+type AnnouncementSeverity string
+
+const (
+	AnnouncementInfo     AnnouncementSeverity = "info"
+	AnnouncementWarning  AnnouncementSeverity = "warning"
+	AnnouncementCritical AnnouncementSeverity = "critical"
+)
+
+// Announcement is a message from the vendor for in-app banners, such as a
+// maintenance notice, an end-of-life warning or a promotion.
+type Announcement struct {
+	ID       string               `json:"id"`
+	Title    string               `json:"title"`
+	Body     string               `json:"body"`
+	Severity AnnouncementSeverity `json:"severity"`
+	// Category is a free-form kind, e.g. "maintenance", "eol" or "promotion".
+	Category string `json:"category,omitempty"`
+	URL      string `json:"url,omitempty"`
+	// MinVersion and MaxVersion limit the announcement to component versions
+	// in that inclusive range; Tiers limits it to license tiers.
+	MinVersion string   `json:"min_version,omitempty"`
+	MaxVersion string   `json:"max_version,omitempty"`
+	Tiers      []string `json:"tiers,omitempty"`
+	// StartsAt and EndsAt (RFC 3339) bound when it is shown.
+	StartsAt string `json:"starts_at,omitempty"`
+	EndsAt   string `json:"ends_at,omitempty"`
+	// Read is tracked locally by MarkAnnouncementRead.
+	Read bool `json:"-"`
+}
+
+type announcementsResponse struct {
+	Announcements []Announcement `json:"announcements"`
+}
+
+// FetchAnnouncements asks the server for the announcements targeted at this
+// license and component version, and replaces the ones known to the guard.
+// Announcements first seen here are passed to Config.OnAnnouncement.
+func (g *Guard) FetchAnnouncements(ctx context.Context) ([]Announcement, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+	if err := g.requireCapability(CapabilityAnnouncements); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	g.setLicenseQuery(query)
+	query.Set("project_slug", g.cfg.ProjectSlug)
+	query.Set("component_slug", g.cfg.ComponentSlug)
+	query.Set("version", g.currentVersion())
+
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
+	raw, err := g.getJSON(ctx, "/api/v1/announcements", query)
+	if err != nil {
+		return nil, fmt.Errorf("fetch announcements: %w", g.capabilityErr(CapabilityAnnouncements, err))
+	}
+	var resp announcementsResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	g.applyAnnouncements(resp.Announcements, true)
+	return g.Announcements(), nil
+}
+
+// Announcements returns the announcements known from the last fetch and
+// heartbeats that target this guard and are currently shown, with their
+// local read state.
+func (g *Guard) Announcements() []Announcement {
+	g.loadAnnouncementsRead()
+	version, tier, now := g.currentVersion(), g.leaseTier(), time.Now()
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var visible []Announcement
+	for _, a := range g.announcements {
+		if !a.targets(version, tier, now) {
+			continue
+		}
+		a.Read = slices.Contains(g.announcementsRead, a.ID)
+		visible = append(visible, a)
+	}
+	return visible
+}
+
+// MarkAnnouncementRead records that the user has seen the announcement id.
+// The read state is kept in the cache across restarts.
+func (g *Guard) MarkAnnouncementRead(id string) {
+	g.loadAnnouncementsRead()
+	g.mu.Lock()
+	if slices.Contains(g.announcementsRead, id) {
+		g.mu.Unlock()
+		return
+	}
+	g.announcementsRead = append(g.announcementsRead, id)
+	if len(g.announcementsRead) > maxReadAnnouncements {
+		g.announcementsRead = g.announcementsRead[len(g.announcementsRead)-maxReadAnnouncements:]
+	}
+	data, _ := json.Marshal(g.announcementsRead)
+	g.mu.Unlock()
+
+	if err := g.sealedEntries().SaveEntry(announcementsReadFileName, data); err != nil {
+		g.logger.Warn("save announcement read state failed", "announcement", id, "error", err)
+	}
+}
+
+// announcementsDigest binds the announcements pushed on a heartbeat to the
+// response signature, so OnAnnouncement only sees what the server sent. It is
+// empty when none were sent so older servers keep verifying.
+func announcementsDigest(announcements []Announcement) string {
+	if len(announcements) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(announcements)
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		canonical = raw
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// applyAnnouncements merges announcements from a heartbeat, or replaces the
+// known set after a full fetch, and hands unread ones not seen before to
+// Config.OnAnnouncement.
+func (g *Guard) applyAnnouncements(announcements []Announcement, replace bool) {
+	if len(announcements) == 0 && !replace {
+		return
+	}
+	g.loadAnnouncementsRead()
+	version, tier, now := g.currentVersion(), g.leaseTier(), time.Now()
+
+	var fresh []Announcement
+	g.mu.Lock()
+	if replace {
+		g.announcements = nil
+	}
+	if g.announcementsSeen == nil {
+		g.announcementsSeen = make(map[string]struct{})
+	}
+	for _, a := range announcements {
+		if a.ID == "" {
+			continue
+		}
+		if i := slices.IndexFunc(g.announcements, func(known Announcement) bool { return known.ID == a.ID }); i >= 0 {
+			g.announcements[i] = a
+		} else {
+			g.announcements = append(g.announcements, a)
+		}
+		if _, seen := g.announcementsSeen[a.ID]; seen || slices.Contains(g.announcementsRead, a.ID) || !a.targets(version, tier, now) {
+			continue
+		}
+		g.announcementsSeen[a.ID] = struct{}{}
+		fresh = append(fresh, a)
+	}
+	g.mu.Unlock()
+
+	if g.cfg.OnAnnouncement == nil {
+		return
+	}
+	for _, a := range fresh {
+		g.cfg.OnAnnouncement(a)
+	}
+}
+
+func (g *Guard) loadAnnouncementsRead() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.announcementsRead != nil {
+		return
+	}
+	g.announcementsRead = []string{}
+	if data, err := g.sealedEntries().LoadEntry(announcementsReadFileName); err == nil {
+		_ = json.Unmarshal(data, &g.announcementsRead)
+	}
+}
+
+func (g *Guard) leaseTier() string {
+	if state := g.currentLeaseState(); state != nil && state.Lease != nil {
+		return state.Lease.Tier
+	}
+	return ""
+}
+
+// targets reports whether a is meant for a guard running version under a
+// license of tier at now. The server already targets announcements; this
+// keeps ones delivered earlier from outliving an update or their window.
+func (a Announcement) targets(version, tier string, now time.Time) bool {
+	if a.MinVersion != "" && IsNewer(version, a.MinVersion) {
+		return false
+	}
+	if a.MaxVersion != "" && IsNewer(a.MaxVersion, version) {
+		return false
+	}
+	if len(a.Tiers) > 0 && !slices.Contains(a.Tiers, tier) {
+		return false
+	}
+	if start, err := time.Parse(time.RFC3339, a.StartsAt); err == nil && now.Before(start) {
+		return false
+	}
+	if end, err := time.Parse(time.RFC3339, a.EndsAt); err == nil && !now.Before(end) {
+		return false
+	}
+	return true
+}
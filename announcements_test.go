@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAnnouncementTargets(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		a    Announcement
+		want bool
+	}{
+		{"untargeted", Announcement{}, true},
+		{"in version range", Announcement{MinVersion: "1.0.0", MaxVersion: "1.9.9"}, true},
+		{"below range", Announcement{MinVersion: "1.6.0"}, false},
+		{"above range, e.g. an EOL notice fixed by an update", Announcement{MaxVersion: "1.4.9"}, false},
+		{"other tier", Announcement{Tiers: []string{"enterprise"}}, false},
+		{"matching tier", Announcement{Tiers: []string{"commercial", "enterprise"}}, true},
+		{"not started", Announcement{StartsAt: "2026-03-02T00:00:00Z"}, false},
+		{"ended", Announcement{EndsAt: "2026-03-01T12:00:00Z"}, false},
+		{"within window", Announcement{StartsAt: "2026-02-01T00:00:00Z", EndsAt: "2026-04-01T00:00:00Z"}, true},
+	}
+	for _, tc := range cases {
+		if got := tc.a.targets("1.5.0", "commercial", now); got != tc.want {
+			t.Errorf("%s: targets = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHeartbeat_TamperedAnnouncementsFailVerification(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	var announced []Announcement
+	guard.cfg.OnAnnouncement = func(a Announcement) { announced = append(announced, a) }
+
+	server := newTamperedHeartbeatServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{
+			Status:         "ok",
+			Lease:          leaseJSON,
+			LeaseSignature: sig,
+			Announcements:  []Announcement{{ID: "maintenance", Title: "Maintenance on Sunday"}},
+		}
+	}, func(resp *heartbeatResponse) {
+		resp.Announcements = append(resp.Announcements, Announcement{ID: "phish", Title: "Renew at evil.example"})
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); !errors.Is(err, ErrHeartbeatInvalid) {
+		t.Fatalf("expected ErrHeartbeatInvalid, got %v", err)
+	}
+	if len(announced) != 0 || len(guard.Announcements()) != 0 {
+		t.Fatalf("tampered announcements were applied: %+v", announced)
+	}
+}
@@ -11,13 +11,44 @@ import (
 
 const maxAPIErrorBodyBytes = 64 * 1024
 
-// APIError preserves structured server error details for non-2xx SDK API
-// responses while still unwrapping to stable SDK sentinel errors.
+// routeNotFoundCode is the code of a 404 that carries no structured error
+// code, which is how a server answers for a route it does not have. A server
+// may also send it explicitly.
+const routeNotFoundCode = "route_not_found"
+
+// APIError preserves structured server error details for SDK API responses
+// that report an error, either as a non-2xx status or as an error code in a
+// 2xx body, while still unwrapping to stable SDK sentinel errors.
 type APIError struct {
 	StatusCode int
 	Code       string
 	Message    string
-	Cause      error
+	// RequestID identifies the request in server logs, from the X-Request-ID
+	// header or the body's request_id; quote it when contacting support.
+	RequestID string
+	// Retryable reports whether the same request may succeed later (rate
+	// limiting or a transient server failure). Idempotent calls have already
+	// been retried per Config.Retry by the time the error is returned.
+	Retryable bool
+	Cause     error
+}
+
+// newAPIError builds an APIError for code, mapping it to its SDK sentinel.
+func newAPIError(statusCode int, code, message, requestID string) *APIError {
+	if code == "" {
+		code = "request_failed"
+		if statusCode == http.StatusNotFound {
+			code = routeNotFoundCode
+		}
+	}
+	return &APIError{
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		RequestID:  requestID,
+		Retryable:  retryableStatus(statusCode),
+		Cause:      sdkErrorForAPIErrorCode(code, statusCode),
+	}
 }
 
 func (e *APIError) Error() string {
@@ -28,10 +59,14 @@ func (e *APIError) Error() string {
 	if code == "" {
 		code = "request_failed"
 	}
+	msg := fmt.Sprintf("api error (%d:%s)", e.StatusCode, code)
 	if e.Message != "" {
-		return fmt.Sprintf("api error (%d:%s): %s", e.StatusCode, code, e.Message)
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request_id " + e.RequestID + ")"
 	}
-	return fmt.Sprintf("api error (%d:%s)", e.StatusCode, code)
+	return msg
 }
 
 func (e *APIError) Unwrap() []error {
@@ -54,9 +89,10 @@ func IsAPIError(err error, code string) bool {
 
 func decodeAPIErrorResponse(resp *http.Response) error {
 	type errorEnvelope struct {
-		Error   string `json:"error"`
-		Reason  string `json:"reason"`
-		Message string `json:"message"`
+		Error     string `json:"error"`
+		Reason    string `json:"reason"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id"`
 	}
 
 	raw, truncated, readErr := readAPIErrorBody(resp.Body)
@@ -69,9 +105,6 @@ func decodeAPIErrorResponse(resp *http.Response) error {
 	if code == "" {
 		code = envelope.Reason
 	}
-	if code == "" {
-		code = "request_failed"
-	}
 
 	message := envelope.Message
 	if message == "" {
@@ -87,12 +120,11 @@ func decodeAPIErrorResponse(resp *http.Response) error {
 		message = fmt.Sprintf("%s [error body read failed: %v]", message, readErr)
 	}
 
-	return &APIError{
-		StatusCode: resp.StatusCode,
-		Code:       code,
-		Message:    message,
-		Cause:      sdkErrorForAPIErrorCode(code, resp.StatusCode),
+	requestID := resp.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = envelope.RequestID
 	}
+	return newAPIError(resp.StatusCode, code, message, requestID)
 }
 
 func readAPIErrorBody(body io.Reader) ([]byte, bool, error) {
@@ -131,6 +163,12 @@ func sdkErrorForAPIErrorCode(code string, statusCode int) error {
 		return ErrMachineBanned
 	case "machine_not_registered":
 		return ErrMachineNotRegistered
+	case "machine_mismatch":
+		return ErrMachineMismatch
+	case "rebind_rejected":
+		return ErrRebindRejected
+	case "seats_exhausted":
+		return ErrSeatsExhausted
 	case "binary_not_recognized":
 		return ErrBinaryNotRecognized
 	case "timestamp_expired":
@@ -157,6 +195,10 @@ func sdkErrorForAPIErrorCode(code string, statusCode int) error {
 		return ErrCDKAlreadyUsed
 	case "cdk_revoked":
 		return ErrCDKRevoked
+	case "trial_already_used":
+		return ErrTrialAlreadyUsed
+	case "trial_not_available":
+		return ErrTrialUnavailable
 	case "license_creation_failed":
 		return ErrLicenseCreationFailed
 	case "invalid_form_data", "invalid_file_key":
@@ -219,6 +219,7 @@ func TestDecodeAPIErrorResponseLimitsBodySize(t *testing.T) {
 		PublicKeyPEM:  pemEncodePublicKey(pubKey),
 		ProjectSlug:   "project",
 		ComponentSlug: "backend",
+		Retry:         RetryPolicy{MaxAttempts: 1},
 	})
 	if err != nil {
 		t.Fatalf("new guard: %v", err)
@@ -303,3 +304,51 @@ func TestPostJSONLimitsSuccessfulResponseBody(t *testing.T) {
 		t.Fatalf("expected body size error, got %v", err)
 	}
 }
+
+func TestAPIErrorCarriesRequestIDAndRetryability(t *testing.T) {
+	var status int
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-42")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(testAPIErrorEnvelope{Error: "internal_error"})
+	})
+	g.cfg.Retry.MaxAttempts = 1
+
+	status = http.StatusServiceUnavailable
+	_, err := g.postJSON(context.Background(), "/api/v1/heartbeat", []byte(`{}`))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID != "req-42" || !apiErr.Retryable {
+		t.Fatalf("unexpected APIError: %#v", apiErr)
+	}
+	if !strings.Contains(err.Error(), "req-42") {
+		t.Fatalf("request id missing from message: %v", err)
+	}
+
+	status = http.StatusNotImplemented
+	_, err = g.postJSON(context.Background(), "/api/v1/heartbeat", []byte(`{}`))
+	if !errors.As(err, &apiErr) || apiErr.Retryable {
+		t.Fatalf("501 must not be retryable: %#v", apiErr)
+	}
+}
+
+func TestErrorBodiesAndDownloadFailuresReturnAPIError(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/update/download" {
+			_ = json.NewEncoder(w).Encode(testAPIErrorEnvelope{Error: "update_frozen", Message: "frozen"})
+			return
+		}
+		w.WriteHeader(http.StatusGone)
+		_ = json.NewEncoder(w).Encode(testAPIErrorEnvelope{Error: "download_token_invalid_or_expired"})
+	})
+
+	_, err := g.requestDownloadMeta(context.Background(), "backend", "2.0.0", "linux", "amd64")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || apiErr.Message != "frozen" || !errors.Is(err, ErrUpdateFrozen) {
+		t.Fatalf("error body: %v (%#v)", err, apiErr)
+	}
+
+	_, _, err = g.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: "/download/test.bin"}, "", 1024)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusGone || !errors.Is(err, ErrUpdateDownload) {
+		t.Fatalf("download failure: %v (%#v)", err, apiErr)
+	}
+}
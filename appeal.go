@@ -0,0 +1,136 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AppealStatus is the review state of an unban appeal filed with RequestUnban.
+type AppealStatus string
+
+const (
+	AppealNone     AppealStatus = ""
+	AppealPending  AppealStatus = "pending"
+	AppealApproved AppealStatus = "approved"
+	AppealRejected AppealStatus = "rejected"
+)
+
+// heartbeatAppeal is the appeal review state echoed back on heartbeats.
+type heartbeatAppeal struct {
+	ID      string       `json:"id"`
+	Status  AppealStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
+
+// appealDigest binds the appeal review state to the heartbeat response
+// signature. It is empty when no appeal was sent so older servers keep
+// verifying.
+func appealDigest(appeal *heartbeatAppeal) string {
+	if appeal == nil {
+		return ""
+	}
+	raw, _ := json.Marshal(appeal)
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		canonical = raw
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestUnban files an appeal for a machine that was banned in error. It is
+// submitted through the feedback channel with the FeedbackUnbanAppeal
+// category; heartbeats keep running while the appeal is pending so the review
+// outcome shows up in Status().AppealStatus, and an approved appeal restores
+// the guard once the server issues a fresh lease.
+func (g *Guard) RequestUnban(ctx context.Context, message string) (*FeedbackItem, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, fmt.Errorf("%w: message", ErrMissingParameter)
+	}
+
+	machineID := g.fingerprint.MachineID()
+	item, err := g.SubmitFeedback(ctx, SubmitFeedbackRequest{
+		UserID:     "machine:" + machineID,
+		UserName:   hostname(),
+		Category:   FeedbackUnbanAppeal,
+		Title:      "Unban appeal for machine " + machineID,
+		Content:    message,
+		AppVersion: g.currentVersion(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	g.appealID = item.ID
+	g.appealStatus = AppealPending
+	g.mu.Unlock()
+	g.logger.Info("unban appeal submitted", "appeal_id", item.ID)
+
+	if g.sm.Current() == StateBanned {
+		g.resumeHeartbeatForAppeal()
+	}
+	return item, nil
+}
+
+// AppealStatus reports the state of the most recent unban appeal.
+func (g *Guard) AppealStatus() AppealStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.appealStatus
+}
+
+func (g *Guard) appealPending() bool {
+	return g.AppealStatus() == AppealPending
+}
+
+// applyAppealStatus records the review state reported by a heartbeat for the
+// appeal this guard filed.
+func (g *Guard) applyAppealStatus(appeal *heartbeatAppeal) {
+	if appeal == nil || appeal.Status == AppealNone {
+		return
+	}
+	g.mu.Lock()
+	if g.appealID != "" && appeal.ID != "" && appeal.ID != g.appealID {
+		g.mu.Unlock()
+		return
+	}
+	changed := g.appealStatus != appeal.Status
+	g.appealStatus = appeal.Status
+	g.mu.Unlock()
+	if changed {
+		g.logger.Info("unban appeal status changed", "appeal_id", appeal.ID, "status", string(appeal.Status), "message", appeal.Message)
+	}
+}
+
+// resumeHeartbeatForAppeal restarts the heartbeat loop after a ban stopped it
+// so the appeal outcome can be observed.
+func (g *Guard) resumeHeartbeatForAppeal() {
+	g.lifecycleMu.Lock()
+	defer g.lifecycleMu.Unlock()
+	if g.running {
+		return
+	}
+	parent := g.startCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	if parent.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	g.cancel = cancel
+	g.heartbeatDone = done
+	g.running = true
+	g.startHeartbeat(ctx, done)
+}
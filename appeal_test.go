@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestUnban_SubmitsAppealFeedback(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+
+	var got submitFeedbackBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/feedbacks" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode feedback body: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(FeedbackItem{ID: "appeal-1", Category: got.Category, Status: FeedbackPending})
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if _, err := guard.RequestUnban(context.Background(), "  "); !errors.Is(err, ErrMissingParameter) {
+		t.Fatalf("expected ErrMissingParameter for empty message, got %v", err)
+	}
+
+	item, err := guard.RequestUnban(context.Background(), "replaced motherboard, same license")
+	if err != nil {
+		t.Fatalf("RequestUnban: %v", err)
+	}
+	if item.ID != "appeal-1" || got.Category != FeedbackUnbanAppeal || got.MachineID != guard.fingerprint.MachineID() {
+		t.Fatalf("unexpected appeal submission: item=%#v body=%#v", item, got)
+	}
+	if status := guard.Status(); status.AppealStatus != AppealPending {
+		t.Fatalf("expected pending appeal, got %q", status.AppealStatus)
+	}
+}
+
+func TestHeartbeat_ApprovedAppealLiftsBan(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.sm.OnKill()
+	guard.appealID = "appeal-1"
+	guard.appealStatus = AppealPending
+
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{
+			Status:         "ok",
+			Lease:          leaseJSON,
+			LeaseSignature: sig,
+			Appeal:         &heartbeatAppeal{ID: "appeal-1", Status: AppealApproved},
+		}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if err := guard.Check(); err != nil {
+		t.Fatalf("expected ban lifted, got %v", err)
+	}
+	if guard.AppealStatus() != AppealApproved {
+		t.Fatalf("expected approved appeal, got %q", guard.AppealStatus())
+	}
+}
+
+func TestHeartbeat_TamperedAppealFailsVerification(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.sm.OnKill()
+	guard.appealID = "appeal-1"
+	guard.appealStatus = AppealPending
+
+	server := newTamperedHeartbeatServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{
+			Status:         "ok",
+			Lease:          leaseJSON,
+			LeaseSignature: sig,
+			Appeal:         &heartbeatAppeal{ID: "appeal-1", Status: AppealPending},
+		}
+	}, func(resp *heartbeatResponse) { resp.Appeal.Status = AppealRejected })
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); !errors.Is(err, ErrHeartbeatInvalid) {
+		t.Fatalf("expected ErrHeartbeatInvalid, got %v", err)
+	}
+	if guard.AppealStatus() != AppealPending {
+		t.Fatalf("a tampered appeal changed the status to %q", guard.AppealStatus())
+	}
+}
+
+func TestApplyAppealStatus_IgnoresOtherAppeals(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.appealID = "appeal-1"
+	guard.appealStatus = AppealPending
+
+	guard.applyAppealStatus(&heartbeatAppeal{ID: "appeal-2", Status: AppealRejected})
+	if guard.AppealStatus() != AppealPending {
+		t.Fatalf("foreign appeal changed status to %q", guard.AppealStatus())
+	}
+	guard.applyAppealStatus(&heartbeatAppeal{ID: "appeal-1", Status: AppealRejected})
+	if guard.AppealStatus() != AppealRejected {
+		t.Fatalf("expected rejected, got %q", guard.AppealStatus())
+	}
+}
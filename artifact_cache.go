@@ -0,0 +1,174 @@
+package sdk
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const artifactCacheDirName = "artifacts"
+
+// ArtifactCacheConfig keeps verified update artifacts on disk by digest,
+// so reinstalling or rolling forward to a version seen before, or several
+// components shipping the same archive, needs no second download. The
+// least recently used artifacts are removed once the cache exceeds
+// MaxBytes. LANSharing serves peers from the same cache.
+type ArtifactCacheConfig struct {
+	Enabled bool
+	// MaxBytes bounds the cache (default 1GB).
+	MaxBytes int64
+}
+
+// ArtifactCacheUsage describes the artifact cache.
+type ArtifactCacheUsage struct {
+	Entries int
+	Bytes   int64
+}
+
+func artifactCacheDir(cfg Config) string {
+	return filepath.Join(guardCacheDir(cfg), artifactCacheDirName)
+}
+
+func (g *Guard) artifactCacheEnabled() bool {
+	return g.cfg.OTA.ArtifactCache.Enabled || g.cfg.OTA.LANSharing.Enabled
+}
+
+// cachedArtifact copies the cached artifact with the given digest into a
+// staging file in dir. An entry whose content no longer matches is removed.
+func (g *Guard) cachedArtifact(algorithm, digest, dir string, maxBytes int64) (tmpPath string, ok bool) {
+	path := filepath.Join(artifactCacheDir(g.cfg), artifactKey(algorithm, digest))
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	tmpPath, actual, err := spoolArtifact(f, algorithm, dir, maxBytes)
+	if err != nil {
+		return "", false
+	}
+	if actual != digest {
+		g.logger.Warn("removing corrupt cached artifact", "digest", artifactKey(algorithm, digest))
+		os.Remove(tmpPath)
+		os.Remove(path)
+		return "", false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return tmpPath, true
+}
+
+// cacheArtifact stores a verified artifact and trims the cache to
+// ArtifactCache.MaxBytes. Failures only cost a later download.
+func (g *Guard) cacheArtifact(path, algorithm, digest string) {
+	dir := artifactCacheDir(g.cfg)
+	target := filepath.Join(dir, artifactKey(algorithm, digest))
+	if _, err := os.Stat(target); err == nil {
+		now := time.Now()
+		_ = os.Chtimes(target, now, now)
+		return
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		g.logger.Warn("failed to create artifact cache", "error", err)
+		return
+	}
+	if err := copyFileAtomic(path, target); err != nil {
+		g.logger.Warn("failed to cache artifact", "digest", artifactKey(algorithm, digest), "error", err)
+		return
+	}
+	if _, err := g.PruneArtifactCache(g.cfg.OTA.ArtifactCache.MaxBytes); err != nil {
+		g.logger.Warn("failed to trim artifact cache", "error", err)
+	}
+}
+
+type cachedArtifactFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// artifactCacheEntries lists the cache, most recently used first.
+func artifactCacheEntries(dir string) ([]cachedArtifactFile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []cachedArtifactFile
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if _, _, ok := parseArtifactKey(entry.Name()); err != nil || !ok || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, cachedArtifactFile{filepath.Join(dir, entry.Name()), info.Size(), info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	return files, nil
+}
+
+// ArtifactCacheUsage reports how many artifacts the cache holds and their
+// total size.
+func (g *Guard) ArtifactCacheUsage() (ArtifactCacheUsage, error) {
+	files, err := artifactCacheEntries(artifactCacheDir(g.cfg))
+	if err != nil {
+		return ArtifactCacheUsage{}, err
+	}
+	usage := ArtifactCacheUsage{Entries: len(files)}
+	for _, f := range files {
+		usage.Bytes += f.size
+	}
+	return usage, nil
+}
+
+// PruneArtifactCache removes the least recently used cached artifacts until
+// the cache is at most maxBytes, and returns the bytes freed. Zero empties
+// the cache.
+func (g *Guard) PruneArtifactCache(maxBytes int64) (int64, error) {
+	files, err := artifactCacheEntries(artifactCacheDir(g.cfg))
+	if err != nil {
+		return 0, err
+	}
+	var kept, freed int64
+	var errs []error
+	for _, f := range files {
+		if kept+f.size <= maxBytes {
+			kept += f.size
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		freed += f.size
+	}
+	return freed, errors.Join(errs...)
+}
+
+func copyFileAtomic(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := createStagingFile(filepath.Dir(dst), "artifact")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,96 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArtifactCache_ReusesVerifiedArtifacts(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	artifact := []byte("release 2.0.0")
+	sum := sha256Hex(artifact)
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write(artifact)
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.cfg.CacheDir = t.TempDir()
+	g.cfg.OTA.ArtifactCache = ArtifactCacheConfig{Enabled: true, MaxBytes: 1 << 20}
+	req := FetchRequest{Component: "worker", Version: "2.0.0", DownloadURL: "/download/worker", SHA256: sum, Signature: signUpdateHash(t, privKey, sum)}
+
+	for i := 0; i < 2; i++ {
+		path, got, err := g.fetchArtifact(context.Background(), req, "", 1<<20)
+		if err != nil || got != sum {
+			t.Fatalf("fetch %d = %s, %v", i, got, err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != string(artifact) {
+			t.Fatalf("fetch %d content = %q", i, data)
+		}
+		os.Remove(path)
+	}
+	if downloads != 1 {
+		t.Fatalf("downloads = %d, want the second fetch served from the cache", downloads)
+	}
+
+	// A corrupted entry is dropped and downloaded again.
+	if err := os.WriteFile(filepath.Join(artifactCacheDir(g.cfg), sum), []byte("bit rot"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path, got, err := g.fetchArtifact(context.Background(), req, "", 1<<20)
+	if err != nil || got != sum {
+		t.Fatalf("fetch after corruption = %s, %v", got, err)
+	}
+	os.Remove(path)
+	if downloads != 2 {
+		t.Fatalf("downloads = %d, want a fresh download for a corrupt entry", downloads)
+	}
+}
+
+func TestArtifactCache_PrunesLeastRecentlyUsed(t *testing.T) {
+	g := newLifecycleTestGuard(t, "", nil, "1.0.0")
+	g.cfg.CacheDir = t.TempDir()
+	g.cfg.OTA.ArtifactCache = ArtifactCacheConfig{Enabled: true, MaxBytes: 8}
+
+	src := filepath.Join(t.TempDir(), "artifact")
+	var sums []string
+	for _, body := range []string{"aaaa", "bbbb", "cccc"} {
+		if err := os.WriteFile(src, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		sums = append(sums, sha256Hex([]byte(body)))
+		g.cacheArtifact(src, DigestSHA256, sums[len(sums)-1])
+		if len(sums) == 2 {
+			// Using the first entry again makes the second the oldest.
+			time.Sleep(10 * time.Millisecond)
+			if path, ok := g.cachedArtifact(DigestSHA256, sums[0], "", 1<<20); ok {
+				os.Remove(path)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(filepath.Join(artifactCacheDir(g.cfg), sums[1])); !os.IsNotExist(err) {
+		t.Fatal("least recently used artifact kept beyond MaxBytes")
+	}
+	usage, err := g.ArtifactCacheUsage()
+	if err != nil || usage != (ArtifactCacheUsage{Entries: 2, Bytes: 8}) {
+		t.Fatalf("usage = %+v, %v", usage, err)
+	}
+
+	freed, err := g.PruneArtifactCache(0)
+	if err != nil || freed != 8 {
+		t.Fatalf("PruneArtifactCache(0) = %d, %v", freed, err)
+	}
+	if usage, _ := g.ArtifactCacheUsage(); usage.Entries != 0 {
+		t.Fatalf("usage after prune = %+v", usage)
+	}
+}
@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// assetManifestsFileName is the cache entry keeping the asset manifest of
+// every updated frontend component.
+const assetManifestsFileName = "asset_manifests.json"
+
+// AssetManifest lists the files of an installed frontend version and their
+// content hashes, for cache busting after an update.
+type AssetManifest struct {
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	// Assets maps slash-separated paths relative to the component Dir to
+	// the SHA-256 hex of their content.
+	Assets map[string]string `json:"assets"`
+}
+
+// Changed returns the paths whose content differs from prev, including
+// paths added or removed, sorted.
+func (m AssetManifest) Changed(prev AssetManifest) []string {
+	var changed []string
+	for path, hash := range m.Assets {
+		if prev.Assets[path] != hash {
+			changed = append(changed, path)
+		}
+	}
+	for path := range prev.Assets {
+		if _, ok := m.Assets[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// CacheInvalidation describes a frontend update to a
+// ManagedComponent.CacheInvalidate hook.
+type CacheInvalidation struct {
+	Component  string
+	OldVersion string
+	NewVersion string
+	Manifest   AssetManifest
+	// Changed lists the paths added, modified or removed since the previous
+	// version; every path when no earlier manifest is known.
+	Changed []string
+}
+
+// FrontendManifest returns the asset manifest generated when the frontend
+// component slug was last updated. Manifests are kept in the cache, so they
+// survive restarts; ok is false before the first update through the guard.
+func (g *Guard) FrontendManifest(slug string) (manifest AssetManifest, ok bool) {
+	g.loadAssetManifests()
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	manifest, ok = g.assetManifests[slug]
+	return manifest, ok
+}
+
+func (g *Guard) loadAssetManifests() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.assetManifests != nil {
+		return
+	}
+	g.assetManifests = make(map[string]AssetManifest)
+	if data, err := g.sealedEntries().LoadEntry(assetManifestsFileName); err == nil {
+		_ = json.Unmarshal(data, &g.assetManifests)
+	}
+}
+
+// storeAssetManifest records manifest as the current one of its component
+// and returns the one it replaces.
+func (g *Guard) storeAssetManifest(manifest AssetManifest) AssetManifest {
+	g.loadAssetManifests()
+	g.mu.Lock()
+	prev := g.assetManifests[manifest.Component]
+	g.assetManifests[manifest.Component] = manifest
+	data, _ := json.Marshal(g.assetManifests)
+	g.mu.Unlock()
+
+	if err := g.sealedEntries().SaveEntry(assetManifestsFileName, data); err != nil {
+		g.logger.Warn("save asset manifest failed", "component", manifest.Component, "error", err)
+	}
+	return prev
+}
+
+// invalidateCaches stores the manifest of a freshly swapped frontend and
+// runs mc.CacheInvalidate with the changed paths. A failing hook is logged,
+// since the new files are already live and rolling back would not purge the
+// stale copies either.
+func (g *Guard) invalidateCaches(ctx context.Context, mc ManagedComponent, oldVersion string, manifest AssetManifest) {
+	prev := g.storeAssetManifest(manifest)
+	if mc.CacheInvalidate == nil {
+		return
+	}
+	inv := CacheInvalidation{
+		Component:  mc.Slug,
+		OldVersion: oldVersion,
+		NewVersion: manifest.Version,
+		Manifest:   manifest,
+		Changed:    manifest.Changed(prev),
+	}
+	if err := mc.CacheInvalidate(ctx, inv); err != nil {
+		g.logger.Error("cache invalidation failed", "component", mc.Slug, "changed", len(inv.Changed), "error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFrontendUpdateBuildsManifestAndInvalidatesChangedAssets(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	var archive []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			hashHex := sha256Hex(archive)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/frontend.tar.gz",
+				"sha256":       hashHex,
+				"signature":    signUpdateHash(t, privKey, hashHex),
+			})
+		case "/download/frontend.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryCacheStore()
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.cfg.CacheStore = store
+
+	var invalidations []CacheInvalidation
+	mc := ManagedComponent{
+		Slug: "frontend",
+		Dir:  filepath.Join(t.TempDir(), "live"),
+		CacheInvalidate: func(ctx context.Context, inv CacheInvalidation) error {
+			invalidations = append(invalidations, inv)
+			return errors.New("cdn unavailable")
+		},
+	}
+
+	archive = buildTarGz(t, map[string]string{"index.html": "v2", "assets/app.js": "app", "assets/old.css": "css"})
+	if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	archive = buildTarGz(t, map[string]string{"index.html": "v3", "assets/app.js": "app"})
+	if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "3.0.0"}); err != nil {
+		t.Fatalf("a failing CacheInvalidate must not fail the update: %v", err)
+	}
+
+	if len(invalidations) != 2 {
+		t.Fatalf("CacheInvalidate ran %d times, want 2", len(invalidations))
+	}
+	if got := invalidations[0].Changed; len(got) != 3 {
+		t.Fatalf("first update changed %v, want every asset", got)
+	}
+	if got, want := invalidations[1].Changed, []string{"assets/old.css", "index.html"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("second update changed %v, want %v", got, want)
+	}
+	if invalidations[1].OldVersion != "2.0.0" || invalidations[1].NewVersion != "3.0.0" {
+		t.Fatalf("invalidation versions = %s -> %s", invalidations[1].OldVersion, invalidations[1].NewVersion)
+	}
+
+	manifest, ok := g.FrontendManifest("frontend")
+	if !ok || manifest.Version != "3.0.0" || manifest.Assets["assets/app.js"] != sha256Hex([]byte("app")) {
+		t.Fatalf("manifest = %+v, ok = %v", manifest, ok)
+	}
+
+	restarted := newLifecycleTestGuard(t, server.URL, pubKey, "3.0.0")
+	restarted.cfg.CacheStore = store
+	if got, ok := restarted.FrontendManifest("frontend"); !ok || !reflect.DeepEqual(got, manifest) {
+		t.Fatalf("manifest after restart = %+v, ok = %v", got, ok)
+	}
+}
@@ -0,0 +1,227 @@
+package sdk
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	auditLogFile              = "audit.jsonl"
+	defaultAuditLogMaxBytes   = 1 << 20
+	defaultAuditLogMaxBackups = 5
+)
+
+// AuditConfig enables an append-only local log of security-relevant guard
+// events for compliance review. Entries are JSON lines; the active file is
+// rotated to Path.1, Path.2, ... once it reaches MaxBytes.
+type AuditConfig struct {
+	Enabled bool
+	// Path is the active log file (default: audit.jsonl in the guard's
+	// cache directory).
+	Path string
+	// MaxBytes is the size at which the active file is rotated (default
+	// 1 MiB).
+	MaxBytes int64
+	// MaxBackups is how many rotated files are kept (default 5); older ones
+	// are deleted.
+	MaxBackups int
+}
+
+// AuditKind identifies what an AuditEvent records.
+type AuditKind string
+
+const (
+	AuditVerification  AuditKind = "verification"
+	AuditStateChange   AuditKind = "state_change"
+	AuditUpdate        AuditKind = "update"
+	AuditRollback      AuditKind = "rollback"
+	AuditKillScheduled AuditKind = "kill_scheduled"
+	AuditKillExecuted  AuditKind = "kill_executed"
+	AuditDeactivation  AuditKind = "deactivation"
+)
+
+// Outcomes recorded in AuditEvent.Outcome.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent is one entry of the audit log. Detail is redacted like the
+// SDK's logs.
+type AuditEvent struct {
+	Time      time.Time `json:"time"`
+	Kind      AuditKind `json:"kind"`
+	Component string    `json:"component,omitempty"`
+	Outcome   string    `json:"outcome"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// audit appends an event when the audit log is enabled. Write failures are
+// logged, never returned to the operation being audited.
+func (g *Guard) audit(kind AuditKind, component, outcome, detail string) {
+	if !g.cfg.Audit.Enabled {
+		return
+	}
+	if g.redactor != nil {
+		detail = g.redactor.String(detail)
+	}
+	line, err := json.Marshal(AuditEvent{
+		Time:      time.Now().UTC(),
+		Kind:      kind,
+		Component: component,
+		Outcome:   outcome,
+		Detail:    detail,
+	})
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	g.auditMu.Lock()
+	defer g.auditMu.Unlock()
+	if err := g.appendAuditLine(line); err != nil {
+		g.logger.Error("failed to write audit log", "error", err)
+	}
+}
+
+func (g *Guard) auditOutcome(kind AuditKind, component string, err error, detail string) {
+	if err != nil {
+		if detail != "" {
+			detail += ": "
+		}
+		g.audit(kind, component, AuditOutcomeFailure, detail+err.Error())
+		return
+	}
+	g.audit(kind, component, AuditOutcomeSuccess, detail)
+}
+
+func (g *Guard) auditPath() string {
+	if g.cfg.Audit.Path != "" {
+		return g.cfg.Audit.Path
+	}
+	return filepath.Join(guardCacheDir(g.cfg), auditLogFile)
+}
+
+func (g *Guard) appendAuditLine(line []byte) error {
+	path := g.auditPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > g.auditMaxBytes() {
+		if err := g.rotateAuditLog(path); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotateAuditLog shifts path.N to path.N+1, dropping the oldest, and moves
+// the active file to path.1.
+func (g *Guard) rotateAuditLog(path string) error {
+	backups := g.auditMaxBackups()
+	if err := os.Remove(auditBackupPath(path, backups)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := backups - 1; i >= 1; i-- {
+		if err := os.Rename(auditBackupPath(path, i), auditBackupPath(path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(path, auditBackupPath(path, 1))
+}
+
+func auditBackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+func (g *Guard) auditMaxBytes() int64 {
+	if g.cfg.Audit.MaxBytes > 0 {
+		return g.cfg.Audit.MaxBytes
+	}
+	return defaultAuditLogMaxBytes
+}
+
+func (g *Guard) auditMaxBackups() int {
+	if g.cfg.Audit.MaxBackups > 0 {
+		return g.cfg.Audit.MaxBackups
+	}
+	return defaultAuditLogMaxBackups
+}
+
+// auditFiles lists the existing log files, oldest first.
+func (g *Guard) auditFiles() []string {
+	path := g.auditPath()
+	var files []string
+	for i := g.auditMaxBackups(); i >= 1; i-- {
+		if _, err := os.Stat(auditBackupPath(path, i)); err == nil {
+			files = append(files, auditBackupPath(path, i))
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// AuditEvents returns the retained audit log, oldest first. Lines that do
+// not parse, such as one torn by a crash mid-write, are skipped.
+func (g *Guard) AuditEvents() ([]AuditEvent, error) {
+	g.auditMu.Lock()
+	defer g.auditMu.Unlock()
+
+	events := []AuditEvent{}
+	for _, name := range g.auditFiles() {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var event AuditEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue
+			}
+			events = append(events, event)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// ExportAuditLog writes the retained audit log to w as JSON lines, oldest
+// first, e.g. to hand it to an auditor.
+func (g *Guard) ExportAuditLog(w io.Writer) error {
+	g.auditMu.Lock()
+	defer g.auditMu.Unlock()
+
+	for _, name := range g.auditFiles() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog_RecordsGuardEvents(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.cfg.Audit = AuditConfig{Enabled: true, Path: filepath.Join(t.TempDir(), "audit.jsonl")}
+
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{Status: "kill", KillAfter: 3600, Message: "license revoked", Lease: leaseJSON, LeaseSignature: sig}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	guard.sm.OnHeartbeatFail()
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	guard.finishUpdateStats(newUpdateStats("frontend", "1.0.0", "2.0.0"), time.Now(), ErrUpdateApply)
+
+	events, err := guard.AuditEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[AuditKind]AuditEvent)
+	for _, event := range events {
+		kinds[event.Kind] = event
+	}
+	if e, ok := kinds[AuditStateChange]; !ok || e.Detail != "ACTIVE -> GRACE (heartbeat_failed)" {
+		t.Fatalf("missing state change, got %#v", events)
+	}
+	if _, ok := kinds[AuditKillScheduled]; !ok {
+		t.Fatalf("missing scheduled kill, got %#v", events)
+	}
+	if e, ok := kinds[AuditUpdate]; !ok || e.Outcome != AuditOutcomeFailure || e.Component != "frontend" {
+		t.Fatalf("missing failed update, got %#v", events)
+	}
+
+	var exported bytes.Buffer
+	if err := guard.ExportAuditLog(&exported); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(exported.String(), "\n"); lines != len(events) {
+		t.Fatalf("exported %d lines for %d events", lines, len(events))
+	}
+}
+
+func TestAuditLog_RotatesAndKeepsBackups(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	guard.cfg.Audit = AuditConfig{Enabled: true, Path: path, MaxBytes: 300, MaxBackups: 2}
+
+	for i := 0; i < 20; i++ {
+		guard.audit(AuditVerification, "backend", AuditOutcomeSuccess, fmt.Sprintf("event %02d", i))
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected at most 2 backups, stat .3: %v", err)
+	}
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil || info.Size() > 300 {
+			t.Fatalf("%s: size limit not honoured (%v)", name, err)
+		}
+	}
+
+	events, err := guard.AuditEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) == 0 || events[len(events)-1].Detail != "event 19" {
+		t.Fatalf("expected the newest event last, got %#v", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Detail < events[i-1].Detail {
+			t.Fatalf("events out of order: %q before %q", events[i-1].Detail, events[i].Detail)
+		}
+	}
+}
+
+func TestAuditLog_DisabledWritesNothing(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.cfg.Audit.Path = filepath.Join(t.TempDir(), "audit.jsonl")
+	guard.audit(AuditVerification, "backend", AuditOutcomeSuccess, "")
+	if _, err := os.Stat(guard.cfg.Audit.Path); !os.IsNotExist(err) {
+		t.Fatalf("expected no audit file while disabled, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CacheStore persists the guard's small cache entries: the sealed license
+// state, the fingerprint binding, sealed secrets, the instance counter, the
+// recent update history, component start counts, unreported usage, frontend
+// asset manifests, version pins, announcement read state and the server's
+// remote config. Entries are sealed or signed by the guard, or signed by
+// the server, before they reach the store, so a store only needs to keep
+// bytes, and an entry edited in the store is discarded.
+//
+// Set Config.CacheStore to keep them somewhere other than files under
+// Config.CacheDir, e.g. NewMemoryCacheStore for read-only filesystems or a
+// custom store backed by a database. The audit log and the key-value store
+// are append-only files and always live under Config.CacheDir.
+type CacheStore interface {
+	// Load returns the entry, or an error matching os.ErrNotExist when it
+	// has never been saved or was deleted.
+	Load(name string) ([]byte, error)
+	// Save replaces the entry atomically.
+	Save(name string, data []byte) error
+	// Delete removes the entry; deleting a missing entry is not an error.
+	Delete(name string) error
+}
+
+// Names are slash-separated, e.g. "secrets/client-cert.bin".
+type fileCacheStore struct {
+	dir string
+}
+
+// NewFileCacheStore keeps each entry as a file under dir, created with
+// owner-only permissions on first save. It is the default store, rooted at
+// Config.CacheDir.
+func NewFileCacheStore(dir string) CacheStore {
+	return fileCacheStore{dir: dir}
+}
+
+func (s fileCacheStore) path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name))
+}
+
+func (s fileCacheStore) Load(name string) ([]byte, error) {
+	return os.ReadFile(s.path(name))
+}
+
+func (s fileCacheStore) Save(name string, data []byte) error {
+	path := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+func (s fileCacheStore) Delete(name string) error {
+	err := os.Remove(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+type memoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string][]byte
+}
+
+// NewMemoryCacheStore keeps entries in memory only, for containers and
+// services without a writable directory. Nothing survives a restart, so the
+// guard verifies online at every start and has no offline grace until then.
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{entries: make(map[string][]byte)}
+}
+
+func (s *memoryCacheStore) Load(name string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("cache entry %s: %w", name, os.ErrNotExist)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (s *memoryCacheStore) Save(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memoryCacheStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, name)
+	return nil
+}
+
+// cacheStoreFor returns the configured store, or files under the cache dir.
+func cacheStoreFor(cfg Config) CacheStore {
+	if cfg.CacheStore != nil {
+		return cfg.CacheStore
+	}
+	return NewFileCacheStore(guardCacheDir(cfg))
+}
+
+// guardCacheDir is Config.CacheDir, the directory NewForTesting picked, or
+// ~/.deploy-guard/<project>/<component>.
+func guardCacheDir(cfg Config) string {
+	if dir := strings.TrimSpace(cfg.CacheDir); dir != "" {
+		return dir
+	}
+	if cfg.cacheDir != "" {
+		return cfg.cacheDir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".deploy-guard", cfg.ProjectSlug, cfg.ComponentSlug)
+}
@@ -0,0 +1,145 @@
+package sdk
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheStores_RoundTrip(t *testing.T) {
+	stores := map[string]CacheStore{
+		"file":   NewFileCacheStore(t.TempDir()),
+		"memory": NewMemoryCacheStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.Load("secrets/a.bin"); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected ErrNotExist for a missing entry, got %v", err)
+			}
+			if err := store.Save("secrets/a.bin", []byte("one")); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			if err := store.Save("secrets/a.bin", []byte("two")); err != nil {
+				t.Fatalf("overwrite: %v", err)
+			}
+			if data, err := store.Load("secrets/a.bin"); err != nil || string(data) != "two" {
+				t.Fatalf("load = %q, %v", data, err)
+			}
+			if err := store.Delete("secrets/a.bin"); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if err := store.Delete("secrets/a.bin"); err != nil {
+				t.Fatalf("deleting a missing entry: %v", err)
+			}
+			if _, err := store.Load("secrets/a.bin"); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected ErrNotExist after delete, got %v", err)
+			}
+		})
+	}
+}
+
+func newCacheTestConfig(t *testing.T) Config {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Config{
+		ServerURL:        "https://example.invalid",
+		LicenseKey:       "test-license",
+		PublicKeyPEM:     pemEncodePublicKey(pubKey),
+		ProjectSlug:      "test-project",
+		ComponentSlug:    "backend",
+		AllowSystemTrust: true,
+	}
+}
+
+func TestConfig_CacheDirHoldsGuardState(t *testing.T) {
+	cfg := newCacheTestConfig(t)
+	cfg.CacheDir = filepath.Join(t.TempDir(), "state")
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if err := guard.store.Save(&persistedState{LockFlag: true}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.CacheDir, stateFileName)); err != nil {
+		t.Fatalf("expected state under CacheDir: %v", err)
+	}
+	home, _ := os.UserHomeDir()
+	if _, err := os.Stat(filepath.Join(home, ".deploy-guard")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected nothing under the home directory, got %v", err)
+	}
+}
+
+func TestConfig_CacheStoreKeepsStateAndHistory(t *testing.T) {
+	cfg := newCacheTestConfig(t)
+	cfg.CacheStore = NewMemoryCacheStore()
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if err := guard.store.Save(&persistedState{LockFlag: true}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+	stats := newUpdateStats("backend", "1.0.0", "1.1.0")
+	guard.finishUpdateStats(stats, time.Now(), nil)
+
+	for _, name := range []string{stateFileName, instanceCounterFileName, updateHistoryFileName} {
+		if _, err := cfg.CacheStore.Load(name); err != nil {
+			t.Fatalf("expected %s in the cache store: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(guardCacheDir(Config{ProjectSlug: cfg.ProjectSlug, ComponentSlug: cfg.ComponentSlug})); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no cache directory, got %v", err)
+	}
+
+	restarted, err := New(cfg)
+	if err != nil {
+		t.Fatalf("restart guard: %v", err)
+	}
+	if restarted.State() != StateLocked {
+		t.Fatalf("expected the locked state to survive a restart, got %s", restarted.State())
+	}
+	if len(restarted.updateHistory) != 1 || restarted.updateHistory[0].NewVersion != "1.1.0" {
+		t.Fatalf("expected update history to survive a restart, got %+v", restarted.updateHistory)
+	}
+}
+
+func TestNew_DiscardsStateOfAnotherLicenseKey(t *testing.T) {
+	cfg := newCacheTestConfig(t)
+	cfg.CacheDir = filepath.Join(t.TempDir(), "state")
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if err := guard.store.Save(&persistedState{BanFlag: true}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	same, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if state := same.store.Snapshot(); state == nil || !state.BanFlag {
+		t.Fatalf("state for the same key = %+v, want it kept", state)
+	}
+
+	cfg.LicenseKey = "other-license"
+	other, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if state := other.store.Snapshot(); state != nil {
+		t.Fatalf("state for another key = %+v, want none", state)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.CacheDir, stateFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the previous key's state to be wiped, got %v", err)
+	}
+}
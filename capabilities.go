@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Capability names an optional group of server endpoints. Self-hosted
+// servers older than the SDK may not expose all of them.
+type Capability string
+
+const (
+	// CapabilityPlugins covers the plugin catalog and plugin update endpoints.
+	CapabilityPlugins Capability = "plugins"
+	// CapabilityFeedback covers feedback, uploads, release notes and appeals.
+	CapabilityFeedback Capability = "feedback"
+	// CapabilityVersionResolve covers resolving the version from the binary hash.
+	CapabilityVersionResolve Capability = "version_resolve"
+	// CapabilityPush covers server-side release pushes delivered in heartbeats.
+	CapabilityPush Capability = "push"
+	// CapabilityAnnouncements covers fetching vendor announcements.
+	CapabilityAnnouncements Capability = "announcements"
+	// CapabilityHeaderAuth means the server authenticates requests by the
+	// Authorization header alone, so the license key is left out of bodies
+	// and query strings.
+	CapabilityHeaderAuth Capability = "header_auth"
+)
+
+type capabilitiesResponse struct {
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// probeCapabilities records which optional endpoints the server exposes. A
+// server that predates the probe leaves the set unknown; its features are
+// then tried and a missing route is reported as ErrFeatureUnsupportedByServer.
+func (g *Guard) probeCapabilities(parent context.Context) {
+	ctx, cancel := withTimeout(parent, g.cfg.Timeouts.API)
+	defer cancel()
+
+	raw, err := g.getJSON(ctx, "/api/v1/capabilities", nil)
+	if err != nil {
+		if !isRouteNotFound(err) {
+			g.logger.Warn("server capability probe failed", "error", err)
+		}
+		return
+	}
+	var resp capabilitiesResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		g.logger.Warn("server capability probe failed", "error", fmt.Errorf("%w: %v", ErrInvalidServerResponse, err))
+		return
+	}
+
+	caps := make(map[Capability]bool, len(resp.Capabilities))
+	for _, capability := range resp.Capabilities {
+		caps[capability] = true
+	}
+	g.mu.Lock()
+	g.capabilities = caps
+	g.mu.Unlock()
+	g.logger.Info("server capabilities negotiated", "capabilities", resp.Capabilities)
+}
+
+// ServerSupports reports whether the server offers capability. Before Start,
+// or against a server without the capability probe, it reports true unless a
+// request has already shown the endpoint to be missing.
+func (g *Guard) ServerSupports(capability Capability) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.capabilities == nil {
+		return true
+	}
+	return g.capabilities[capability]
+}
+
+// requireCapability fails fast when the server is known not to offer
+// capability.
+func (g *Guard) requireCapability(capability Capability) error {
+	if !g.ServerSupports(capability) {
+		return fmt.Errorf("%w: %s", ErrFeatureUnsupportedByServer, capability)
+	}
+	return nil
+}
+
+// capabilityErr turns a missing-route 404 from a capability's endpoint into
+// ErrFeatureUnsupportedByServer and remembers it, so later calls fail fast.
+func (g *Guard) capabilityErr(capability Capability, err error) error {
+	if !isRouteNotFound(err) {
+		return err
+	}
+	g.mu.Lock()
+	if g.capabilities == nil {
+		g.capabilities = map[Capability]bool{
+			CapabilityPlugins:        true,
+			CapabilityFeedback:       true,
+			CapabilityVersionResolve: true,
+			CapabilityPush:           true,
+		}
+	}
+	g.capabilities[capability] = false
+	g.mu.Unlock()
+	return fmt.Errorf("%w: %s", ErrFeatureUnsupportedByServer, capability)
+}
+
+// isRouteNotFound reports whether err is a 404 for a route the server does
+// not have (routeNotFoundCode). A 404 with a code such as plugin_not_found is
+// a real lookup miss.
+func isRouteNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && apiErr.Code == routeNotFoundCode
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbeCapabilitiesGatesUnsupportedFeatures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/capabilities" {
+			t.Errorf("gated feature must not contact the server: %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(capabilitiesResponse{Capabilities: []Capability{CapabilityFeedback}})
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, nil, "1.0.0")
+	g.probeCapabilities(context.Background())
+
+	if !g.ServerSupports(CapabilityFeedback) || g.ServerSupports(CapabilityPlugins) {
+		t.Fatalf("unexpected capabilities: %v", g.capabilities)
+	}
+	if _, err := g.GetPluginCatalog(context.Background(), true); !errors.Is(err, ErrFeatureUnsupportedByServer) {
+		t.Fatalf("expected ErrFeatureUnsupportedByServer for plugins, got %v", err)
+	}
+	if err := g.AutoResolveVersion(); !errors.Is(err, ErrFeatureUnsupportedByServer) {
+		t.Fatalf("expected ErrFeatureUnsupportedByServer for version resolve, got %v", err)
+	}
+	if err := g.WaitForUpdate(context.Background(), "frontend", "2.0.0"); !errors.Is(err, ErrFeatureUnsupportedByServer) {
+		t.Fatalf("expected ErrFeatureUnsupportedByServer for push, got %v", err)
+	}
+}
+
+func TestLegacyServerMissingRouteReportsUnsupported(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, nil, "1.0.0")
+	g.probeCapabilities(context.Background())
+	if !g.ServerSupports(CapabilityPlugins) {
+		t.Fatal("a server without the probe should leave capabilities unknown")
+	}
+
+	if _, err := g.GetPluginCatalog(context.Background(), true); !errors.Is(err, ErrFeatureUnsupportedByServer) {
+		t.Fatalf("expected ErrFeatureUnsupportedByServer, got %v", err)
+	}
+	seen := requests
+	if _, err := g.GetPluginCatalog(context.Background(), true); !errors.Is(err, ErrFeatureUnsupportedByServer) {
+		t.Fatalf("expected ErrFeatureUnsupportedByServer on retry, got %v", err)
+	}
+	if requests != seen {
+		t.Fatal("a known-missing capability must fail without a request")
+	}
+	if !g.ServerSupports(CapabilityFeedback) {
+		t.Fatal("only the failing capability should be marked unsupported")
+	}
+}
+
+func TestStructuredNotFoundIsNotUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "plugin_not_found"})
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, nil, "1.0.0")
+	g.cfg.OTA.OS, g.cfg.OTA.Arch = "linux", "amd64"
+	_, err := g.RequestPluginUpdate(context.Background(), "reports", PluginUpdateOptions{})
+	if !errors.Is(err, ErrPluginNotFound) || errors.Is(err, ErrFeatureUnsupportedByServer) {
+		t.Fatalf("expected ErrPluginNotFound, got %v", err)
+	}
+	if !g.ServerSupports(CapabilityPlugins) {
+		t.Fatal("a lookup miss must not mark plugins unsupported")
+	}
+}
+
+func TestIsRouteNotFoundNeedsTheRouteCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{newAPIError(http.StatusNotFound, "", "404 page not found", ""), true},
+		{newAPIError(http.StatusNotFound, routeNotFoundCode, "", ""), true},
+		{newAPIError(http.StatusNotFound, "request_failed", "", ""), false},
+		{newAPIError(http.StatusNotFound, "plugin_not_found", "", ""), false},
+		{newAPIError(http.StatusBadRequest, routeNotFoundCode, "", ""), false},
+	} {
+		if got := isRouteNotFound(tc.err); got != tc.want {
+			t.Errorf("isRouteNotFound(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const clientCertSecretName = "client-cert"
+
+type clientCertEnrollRequest struct {
+	LicenseKey    string `json:"license_key,omitempty"`
+	MachineID     string `json:"machine_id"`
+	ProjectSlug   string `json:"project_slug"`
+	ComponentSlug string `json:"component_slug"`
+	CSR           string `json:"csr"`
+}
+
+type clientCertEnrollResponse struct {
+	Certificate string `json:"certificate"`
+}
+
+type clientCertRecord struct {
+	CertificatePEM string `json:"certificate_pem"`
+	PrivateKeyPEM  string `json:"private_key_pem"`
+}
+
+// ClientCertificateExpiry returns when the enrolled mTLS client certificate
+// expires, or the zero time when none has been enrolled.
+func (g *Guard) ClientCertificateExpiry() time.Time {
+	cert := g.clientCert.Load()
+	if cert == nil || cert.Leaf == nil {
+		return time.Time{}
+	}
+	return cert.Leaf.NotAfter
+}
+
+// attachClientCertificate makes the transport present the enrolled
+// certificate on every TLS handshake once one is available.
+func (g *Guard) attachClientCertificate() {
+	var base http.RoundTripper = g.httpClient.Transport
+	if pinned, ok := base.(*pinEnforcingTransport); ok {
+		base = pinned.base
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		return
+	}
+	static := transport.TLSClientConfig.Certificates
+	transport.TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		if cert := g.clientCert.Load(); cert != nil {
+			return cert, nil
+		}
+		if len(static) > 0 {
+			return &static[0], nil
+		}
+		return &tls.Certificate{}, nil
+	}
+}
+
+// restoreClientCertificate loads a previously enrolled certificate from the
+// secret store; a missing or unreadable record simply triggers re-enrollment.
+func (g *Guard) restoreClientCertificate() {
+	data, err := g.secrets.Load(clientCertSecretName)
+	if err != nil {
+		return
+	}
+	var record clientCertRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return
+	}
+	cert, err := parseClientCertificate([]byte(record.CertificatePEM), []byte(record.PrivateKeyPEM))
+	if err != nil {
+		return
+	}
+	g.clientCert.Store(cert)
+}
+
+// ensureClientCertificate enrolls a client certificate when none is held and
+// renews it once it enters the renewal window. A still-valid certificate is
+// kept when renewal fails so the next heartbeat can retry.
+func (g *Guard) ensureClientCertificate(ctx context.Context) error {
+	if !g.cfg.ClientCert.Enabled {
+		return nil
+	}
+	now := time.Now()
+	current := g.clientCert.Load()
+	if current != nil && !clientCertRenewalDue(current.Leaf, g.cfg.ClientCert.RenewBefore, now) {
+		return nil
+	}
+
+	if err := g.enrollClientCertificate(ctx); err != nil {
+		if current != nil && now.Before(current.Leaf.NotAfter) {
+			g.logger.Warn("client certificate renewal failed", "expires_at", current.Leaf.NotAfter.UTC().Format(time.RFC3339), "error", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (g *Guard) enrollClientCertificate(parent context.Context) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("%w: generate key: %v", ErrClientCertEnrollment, err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:         g.fingerprint.MachineID(),
+			Organization:       []string{g.cfg.ProjectSlug},
+			OrganizationalUnit: []string{g.cfg.ComponentSlug},
+		},
+	}, key)
+	if err != nil {
+		return fmt.Errorf("%w: create csr: %v", ErrClientCertEnrollment, err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("%w: marshal key: %v", ErrClientCertEnrollment, err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	reqBody := clientCertEnrollRequest{
+		LicenseKey:    g.bodyLicenseKey(),
+		MachineID:     g.fingerprint.MachineID(),
+		ProjectSlug:   g.cfg.ProjectSlug,
+		ComponentSlug: g.cfg.ComponentSlug,
+		CSR:           string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})),
+	}
+	reqBodyJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	ctx, cancel := withTimeout(parent, g.cfg.Timeouts.API)
+	defer cancel()
+	raw, err := g.postJSON(ctx, "/api/v1/mtls/enroll", reqBodyJSON)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrNetworkError, err)
+	}
+	var resp clientCertEnrollResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+
+	cert, err := parseClientCertificate([]byte(resp.Certificate), keyPEM)
+	if err != nil {
+		return err
+	}
+	if !time.Now().Before(cert.Leaf.NotAfter) {
+		return fmt.Errorf("%w: issued certificate already expired", ErrClientCertEnrollment)
+	}
+
+	record, err := json.Marshal(clientCertRecord{
+		CertificatePEM: resp.Certificate,
+		PrivateKeyPEM:  string(keyPEM),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal client certificate: %w", err)
+	}
+	if err := g.secrets.Save(clientCertSecretName, record); err != nil {
+		g.logger.Error("failed to store client certificate", "error", err)
+	}
+	g.clientCert.Store(cert)
+
+	g.logger.Info("client certificate enrolled", "expires_at", cert.Leaf.NotAfter.UTC().Format(time.RFC3339))
+	return nil
+}
+
+func parseClientCertificate(certPEM, keyPEM []byte) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrClientCertEnrollment, err)
+	}
+	if cert.Leaf == nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrClientCertEnrollment, err)
+		}
+		cert.Leaf = leaf
+	}
+	return &cert, nil
+}
+
+// clientCertRenewalDue reports whether the certificate is within renewBefore
+// of expiry, capping the window at a third of its lifetime so short-lived
+// certificates are not renewed on every heartbeat.
+func clientCertRenewalDue(leaf *x509.Certificate, renewBefore time.Duration, now time.Time) bool {
+	if leaf == nil {
+		return true
+	}
+	window := renewBefore
+	if lifetime := leaf.NotAfter.Sub(leaf.NotBefore); lifetime > 0 && window > lifetime/3 {
+		window = lifetime / 3
+	}
+	return !now.Before(leaf.NotAfter.Add(-window))
+}
@@ -0,0 +1,180 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newClientCertTestServer signs every enrollment CSR with a throwaway CA,
+// issuing certificates valid for lifetime.
+func newClientCertTestServer(t *testing.T, lifetime time.Duration, enrollments *atomic.Int32) *httptest.Server {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/mtls/enroll" {
+			http.NotFound(w, r)
+			return
+		}
+		var body clientCertEnrollRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode enroll body: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		block, _ := pem.Decode([]byte(body.CSR))
+		if block == nil {
+			t.Error("enroll body has no CSR PEM")
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil || csr.CheckSignature() != nil {
+			t.Errorf("invalid CSR: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if csr.Subject.CommonName != body.MachineID {
+			t.Errorf("CSR common name = %q, want machine id %q", csr.Subject.CommonName, body.MachineID)
+		}
+		now := time.Now()
+		leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(now.UnixNano()),
+			Subject:      csr.Subject,
+			NotBefore:    now.Add(-time.Minute),
+			NotAfter:     now.Add(lifetime),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, caCert, csr.PublicKey, caKey)
+		if err != nil {
+			t.Errorf("sign CSR: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		enrollments.Add(1)
+		_ = json.NewEncoder(w).Encode(clientCertEnrollResponse{
+			Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})),
+		})
+	}))
+}
+
+func TestEnsureClientCertificate_EnrollsStoresAndRestores(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.cfg.ClientCert.Enabled = true
+
+	var enrollments atomic.Int32
+	server := newClientCertTestServer(t, 30*24*time.Hour, &enrollments)
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.ensureClientCertificate(context.Background()); err != nil {
+		t.Fatalf("enroll: %v", err)
+	}
+	expiry := guard.ClientCertificateExpiry()
+	if expiry.IsZero() || enrollments.Load() != 1 {
+		t.Fatalf("expected one enrollment with expiry, got %d enrollments, expiry %v", enrollments.Load(), expiry)
+	}
+
+	if err := guard.ensureClientCertificate(context.Background()); err != nil {
+		t.Fatalf("second ensure: %v", err)
+	}
+	if enrollments.Load() != 1 {
+		t.Fatalf("valid certificate should not be re-enrolled, got %d enrollments", enrollments.Load())
+	}
+
+	restored := &Guard{cfg: guard.cfg, fingerprint: guard.fingerprint, secrets: newSecretStore(guard.cfg, guard.fingerprint)}
+	restored.restoreClientCertificate()
+	if !restored.ClientCertificateExpiry().Equal(expiry) {
+		t.Fatalf("restored expiry = %v, want %v", restored.ClientCertificateExpiry(), expiry)
+	}
+}
+
+func TestEnsureClientCertificate_RenewsInsideWindow(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.cfg.ClientCert.Enabled = true
+	guard.cfg.ClientCert.RenewBefore = 72 * time.Hour
+
+	var enrollments atomic.Int32
+	server := newClientCertTestServer(t, 48*time.Hour, &enrollments)
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.ensureClientCertificate(context.Background()); err != nil {
+		t.Fatalf("enroll: %v", err)
+	}
+	// RenewBefore is capped to a third of the 48h lifetime, so a fresh
+	// certificate must not be renewed straight away.
+	if err := guard.ensureClientCertificate(context.Background()); err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	if enrollments.Load() != 1 {
+		t.Fatalf("fresh short-lived certificate renewed early: %d enrollments", enrollments.Load())
+	}
+
+	leaf := guard.clientCert.Load().Leaf
+	if !clientCertRenewalDue(leaf, 72*time.Hour, leaf.NotAfter.Add(-time.Hour)) {
+		t.Fatal("expected renewal to be due one hour before expiry")
+	}
+	if clientCertRenewalDue(leaf, 72*time.Hour, leaf.NotBefore.Add(time.Hour)) {
+		t.Fatal("renewal should not be due right after issuance")
+	}
+}
+
+func TestSecretStore_RejectsTamperedBlob(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	store := newSecretStore(guard.cfg, guard.fingerprint)
+	if err := store.Save("sample", []byte("top secret")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load("sample")
+	if err != nil || string(got) != "top secret" {
+		t.Fatalf("round trip = %q, %v", got, err)
+	}
+
+	cache := cacheStoreFor(guard.cfg)
+	data, err := cache.Load(secretEntryName("sample"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := cache.Save(secretEntryName("sample"), data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("sample"); !errors.Is(err, ErrStateTampered) {
+		t.Fatalf("expected ErrStateTampered, got %v", err)
+	}
+}
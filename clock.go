@@ -0,0 +1,67 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// clockTamperTolerance is how far the wall clock may fall behind the
+// monotonic clock or the persisted high-water mark before it counts as a
+// deliberate rollback rather than NTP adjustment.
+const clockTamperTolerance = defaultLeaseClockSkew
+
+// clockHighWaterStep limits how often advancing the high-water mark rewrites
+// the state file.
+const clockHighWaterStep = time.Minute
+
+// clockMonitor pairs a monotonic baseline taken in this process with the
+// persisted wall-clock high-water mark, so rolling the clock back can neither
+// stretch the grace period while running nor across restarts.
+type clockMonitor struct {
+	mu   sync.Mutex
+	base time.Time
+}
+
+func (c *clockMonitor) reset(now time.Time) {
+	c.mu.Lock()
+	c.base = now
+	c.mu.Unlock()
+}
+
+// rolledBack reports whether the wall clock has lost more than the tolerance
+// against the monotonic clock since the baseline.
+func (c *clockMonitor) rolledBack(now time.Time) bool {
+	c.mu.Lock()
+	base := c.base
+	c.mu.Unlock()
+	if base.IsZero() {
+		return false
+	}
+	monotonic := now.Sub(base)
+	wall := now.Round(0).Sub(base.Round(0))
+	return monotonic-wall > clockTamperTolerance
+}
+
+// observeClock checks now against the monotonic baseline and the persisted
+// high-water mark, advancing the mark when time moves forward. It returns
+// ErrClockRollback when the clock was moved backwards.
+func (g *Guard) observeClock(now time.Time) error {
+	if g.clock.rolledBack(now) {
+		return ErrClockRollback
+	}
+	state := g.currentLeaseState()
+	if state == nil {
+		return nil
+	}
+	wall := now.Round(0).UTC()
+	if highWater, err := parseRFC3339(state.ClockHighWater); err == nil {
+		if wall.Before(highWater.Add(-clockTamperTolerance)) {
+			return ErrClockRollback
+		}
+		if wall.Sub(highWater) < clockHighWaterStep {
+			return nil
+		}
+	}
+	state.ClockHighWater = wall.Format(time.RFC3339)
+	return g.store.Save(state)
+}
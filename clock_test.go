@@ -0,0 +1,43 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveClock_AdvancesHighWaterAndDetectsRollback(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+	if err := guard.acceptLease(mustParseLease(t, leaseJSON), sig, false); err != nil {
+		t.Fatal(err)
+	}
+
+	later := time.Now().Add(2 * time.Hour)
+	if err := guard.observeClock(later); err != nil {
+		t.Fatalf("forward clock should be accepted, got %v", err)
+	}
+	if got := guard.currentLeaseState().ClockHighWater; got != later.UTC().Format(time.RFC3339) {
+		t.Fatalf("high-water mark = %q, want %q", got, later.UTC().Format(time.RFC3339))
+	}
+
+	if err := guard.observeClock(later.Add(-time.Hour)); err != ErrClockRollback {
+		t.Fatalf("expected ErrClockRollback after moving the clock back, got %v", err)
+	}
+	if err := guard.validatePersistedLease(later.Add(-time.Hour)); err != ErrClockRollback {
+		t.Fatalf("restart with a rolled-back clock must not trust the cache, got %v", err)
+	}
+	if err := guard.observeClock(later.Add(-time.Minute)); err != nil {
+		t.Fatalf("small adjustments within tolerance should pass, got %v", err)
+	}
+}
+
+func TestOnClockTampered_LocksActiveGuard(t *testing.T) {
+	guard, _, _, _ := newActiveHeartbeatGuard(t)
+	var reason string
+	guard.OnStateChange(func(_, _ State, r string) { reason = r })
+
+	guard.sm.OnClockTampered()
+	if guard.State() != StateLocked || reason != TransitionClockTamper {
+		t.Fatalf("state=%v reason=%q", guard.State(), reason)
+	}
+}
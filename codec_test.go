@@ -0,0 +1,125 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	in := heartbeatRequestBody{
+		LicenseKey:    "LIC-1",
+		MachineID:     "sha256:abc",
+		ProjectSlug:   "proj",
+		ComponentSlug: "backend",
+		Components: []heartbeatComponent{
+			{Slug: "backend", Version: "1.2.3"},
+			{Slug: "frontend", Version: strings.Repeat("x", 300)},
+		},
+		Nonce:     "nonce",
+		Timestamp: -1700000000,
+	}
+
+	encoded, err := MsgpackCodec.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	asJSON, _ := json.Marshal(in)
+	if len(encoded) >= len(asJSON) {
+		t.Fatalf("msgpack payload (%d bytes) should be smaller than JSON (%d bytes)", len(encoded), len(asJSON))
+	}
+
+	var out heartbeatRequestBody
+	if err := MsgpackCodec.Unmarshal(encoded, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	outJSON, _ := json.Marshal(out)
+	if !bytes.Equal(asJSON, outJSON) {
+		t.Fatalf("round trip mismatch\nwant: %s\ngot:  %s", asJSON, outJSON)
+	}
+}
+
+func TestMsgpackCodecRejectsTruncatedInput(t *testing.T) {
+	encoded, err := MsgpackCodec.Marshal(map[string]string{"key": "value"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out map[string]string
+	if err := MsgpackCodec.Unmarshal(encoded[:len(encoded)-1], &out); err == nil {
+		t.Fatal("expected truncated input to fail")
+	}
+	if err := MsgpackCodec.Unmarshal(append(encoded, 0xc0), &out); err == nil {
+		t.Fatal("expected trailing bytes to fail")
+	}
+}
+
+func TestPostJSONNegotiatesMsgpack(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	var requestTypes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestTypes = append(requestTypes, r.Header.Get("Content-Type"))
+		if !strings.HasPrefix(r.Header.Get("Accept"), contentTypeMsgpack) {
+			t.Fatalf("expected msgpack in Accept header, got %q", r.Header.Get("Accept"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		var decoded map[string]string
+		if err := codecForContentType(r.Header.Get("Content-Type")).Unmarshal(body, &decoded); err != nil {
+			t.Fatalf("decode request body: %v", err)
+		}
+		if decoded["ping"] != "pong" {
+			t.Fatalf("unexpected request body: %#v", decoded)
+		}
+		payload, _ := MsgpackCodec.Marshal(map[string]any{"status": "ok", "count": 3})
+		w.Header().Set("Content-Type", contentTypeMsgpack)
+		_, _ = w.Write(payload)
+	}))
+	defer srv.Close()
+
+	g, err := New(Config{
+		ServerURL:     srv.URL,
+		LicenseKey:    "LIC-1",
+		PublicKeyPEM:  pemEncodePublicKey(pubKey),
+		ProjectSlug:   "proj",
+		ComponentSlug: "backend",
+		Codec:         MsgpackCodec,
+	})
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		raw, err := g.postJSON(context.Background(), "/api/v1/echo", []byte(`{"ping":"pong"}`))
+		if err != nil {
+			t.Fatalf("postJSON: %v", err)
+		}
+		var resp struct {
+			Status string `json:"status"`
+			Count  int    `json:"count"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			t.Fatalf("response should be transcoded to JSON: %v (%q)", err, raw)
+		}
+		if resp.Status != "ok" || resp.Count != 3 {
+			t.Fatalf("unexpected response: %#v", resp)
+		}
+	}
+
+	if len(requestTypes) != 2 || requestTypes[0] != contentTypeJSON || requestTypes[1] != contentTypeMsgpack {
+		t.Fatalf("expected JSON first then msgpack after negotiation, got %v", requestTypes)
+	}
+}
+
+func codecForContentType(contentType string) Codec {
+	if contentType == contentTypeMsgpack {
+		return MsgpackCodec
+	}
+	return JSONCodec
+}
@@ -0,0 +1,134 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+const maxPendingCommandResults = 50
+
+// Command is a fleet operation pushed by the server in a heartbeat response,
+// e.g. "refresh_license", "collect_diagnostics", "freeze_updates" or
+// "set_log_level".
+type Command struct {
+	ID   string
+	Name string
+	Args json.RawMessage
+}
+
+// CommandHandler executes one server-pushed command. Its outcome is reported
+// back to the server on the next heartbeat.
+type CommandHandler func(ctx context.Context, cmd Command) error
+
+type heartbeatCommand struct {
+	ID   string          `json:"id"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type commandResult struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	commandStatusOK          = "ok"
+	commandStatusFailed      = "failed"
+	commandStatusUnsupported = "unsupported"
+)
+
+// OnCommand registers the handler for a server-pushed command name, replacing
+// any previous one. A nil handler unregisters the name.
+func (g *Guard) OnCommand(name string, handler CommandHandler) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if handler == nil {
+		delete(g.commandHandlers, name)
+		return
+	}
+	if g.commandHandlers == nil {
+		g.commandHandlers = make(map[string]CommandHandler)
+	}
+	g.commandHandlers[name] = handler
+}
+
+// dispatchCommands runs commands in order and queues one result per command
+// for the next heartbeat. Commands without a handler are reported unsupported.
+func (g *Guard) dispatchCommands(ctx context.Context, commands []heartbeatCommand) {
+	for _, c := range commands {
+		g.mu.RLock()
+		handler := g.commandHandlers[c.Name]
+		g.mu.RUnlock()
+
+		result := commandResult{ID: c.ID, Name: c.Name, Status: commandStatusOK}
+		if handler == nil {
+			result.Status = commandStatusUnsupported
+			g.logger.Warn("no handler for server command", "command", c.Name, "command_id", c.ID)
+		} else if err := runCommandHandler(ctx, handler, Command{ID: c.ID, Name: c.Name, Args: c.Args}); err != nil {
+			result.Status = commandStatusFailed
+			result.Error = err.Error()
+			g.logger.Error("server command failed", "command", c.Name, "command_id", c.ID, "error", err)
+		} else {
+			g.logger.Info("server command executed", "command", c.Name, "command_id", c.ID)
+		}
+
+		g.mu.Lock()
+		g.pendingCommandResults = append(g.pendingCommandResults, result)
+		if len(g.pendingCommandResults) > maxPendingCommandResults {
+			g.pendingCommandResults = g.pendingCommandResults[len(g.pendingCommandResults)-maxPendingCommandResults:]
+		}
+		g.mu.Unlock()
+	}
+}
+
+func runCommandHandler(ctx context.Context, handler CommandHandler, cmd Command) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("command handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, cmd)
+}
+
+func (g *Guard) pendingCommandResultsSnapshot() []commandResult {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.pendingCommandResults) == 0 {
+		return nil
+	}
+	return append([]commandResult(nil), g.pendingCommandResults...)
+}
+
+// dropReportedCommandResults removes the first n results once the server has
+// accepted them.
+func (g *Guard) dropReportedCommandResults(n int) {
+	if n == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if n > len(g.pendingCommandResults) {
+		n = len(g.pendingCommandResults)
+	}
+	g.pendingCommandResults = append([]commandResult(nil), g.pendingCommandResults[n:]...)
+}
+
+// commandsDigest binds pushed commands to the heartbeat response signature.
+// It is empty when no commands were sent so older servers keep verifying.
+func commandsDigest(commands []heartbeatCommand) string {
+	if len(commands) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(commands)
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		canonical = raw
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
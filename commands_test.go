@@ -0,0 +1,130 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDispatchCommands_QueuesResultsPerCommand(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+
+	var level string
+	guard.OnCommand("set_log_level", func(_ context.Context, cmd Command) error {
+		var args struct {
+			Level string `json:"level"`
+		}
+		if err := json.Unmarshal(cmd.Args, &args); err != nil {
+			return err
+		}
+		level = args.Level
+		return nil
+	})
+	guard.OnCommand("collect_diagnostics", func(context.Context, Command) error {
+		return errors.New("disk full")
+	})
+	guard.OnCommand("freeze_updates", func(context.Context, Command) error {
+		panic("boom")
+	})
+
+	guard.dispatchCommands(context.Background(), []heartbeatCommand{
+		{ID: "c1", Name: "set_log_level", Args: json.RawMessage(`{"level":"debug"}`)},
+		{ID: "c2", Name: "collect_diagnostics"},
+		{ID: "c3", Name: "freeze_updates"},
+		{ID: "c4", Name: "reboot"},
+	})
+
+	if level != "debug" {
+		t.Fatalf("set_log_level handler not invoked with args, level=%q", level)
+	}
+	results := guard.pendingCommandResultsSnapshot()
+	want := []string{commandStatusOK, commandStatusFailed, commandStatusFailed, commandStatusUnsupported}
+	if len(results) != len(want) {
+		t.Fatalf("results = %#v", results)
+	}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Fatalf("result %d status = %q, want %q (%#v)", i, results[i].Status, status, results)
+		}
+	}
+	if results[1].Error != "disk full" {
+		t.Fatalf("expected handler error to be reported, got %#v", results[1])
+	}
+}
+
+func TestHeartbeat_DispatchesSignedCommandsAndReportsResults(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+
+	executed := make(chan string, 1)
+	guard.OnCommand("refresh_license", func(_ context.Context, cmd Command) error {
+		executed <- cmd.ID
+		return nil
+	})
+
+	var reported []commandResult
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+			return
+		}
+		reported = append(reported, body.CommandResults...)
+		resp := heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+		if len(body.CommandResults) == 0 {
+			resp.Commands = []heartbeatCommand{{ID: "cmd-1", Name: "refresh_license"}}
+		}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, resp, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	select {
+	case id := <-executed:
+		if id != "cmd-1" {
+			t.Fatalf("unexpected command id %q", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("command handler was not invoked")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(guard.pendingCommandResultsSnapshot()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("second heartbeat: %v", err)
+	}
+	if len(reported) != 1 || reported[0].ID != "cmd-1" || reported[0].Status != commandStatusOK {
+		t.Fatalf("expected command result on next heartbeat, got %#v", reported)
+	}
+	if pending := guard.pendingCommandResultsSnapshot(); len(pending) != 0 {
+		t.Fatalf("reported results should be dropped, got %#v", pending)
+	}
+}
+
+func TestHeartbeat_RejectsUnsignedCommands(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		resp := signHeartbeatResponse(t, privKey, heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}, body.Nonce)
+		resp.Commands = []heartbeatCommand{{ID: "injected", Name: "freeze_updates"}}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); !errors.Is(err, ErrHeartbeatInvalid) {
+		t.Fatalf("expected ErrHeartbeatInvalid for commands outside the signature, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configVersionsFileName is the cache entry keeping the configuration
+// version applied per component, so an older signed config cannot be
+// replayed after a restart.
+const configVersionsFileName = "config_versions.json"
+
+// componentConfig is a signed, versioned configuration blob attached to a
+// heartbeat response for one managed component.
+type componentConfig struct {
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	Content   string `json:"content"`
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"`
+}
+
+// ConfigVersion returns the configuration version most recently applied to a
+// managed component, or "" if none has been applied.
+func (g *Guard) ConfigVersion(slug string) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.configVersions[slug]
+}
+
+func (g *Guard) applyComponentConfigs(configs []componentConfig) {
+	for _, cfg := range configs {
+		mc, ok := g.findManagedComponent(cfg.Component)
+		if !ok || strings.TrimSpace(mc.ConfigPath) == "" {
+			g.logger.Warn("ignoring config for component without ConfigPath", "component", cfg.Component, "config_version", cfg.Version)
+			continue
+		}
+		if err := g.applyComponentConfig(mc, cfg); err != nil {
+			g.logger.Error("failed to apply component config", "component", cfg.Component, "config_version", cfg.Version, "error", err)
+		}
+	}
+}
+
+func (g *Guard) applyComponentConfig(mc ManagedComponent, cfg componentConfig) error {
+	current := g.ConfigVersion(mc.Slug)
+	if current == cfg.Version {
+		return nil
+	}
+	if current != "" && !IsNewer(current, cfg.Version) {
+		return fmt.Errorf("%w: config %s is not newer than %s", ErrUpdateDowngrade, cfg.Version, current)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(cfg.Content)
+	if err != nil {
+		return fmt.Errorf("%w: decode config: %v", ErrUpdateVerify, err)
+	}
+	sum := sha256.Sum256(content)
+	actual := hex.EncodeToString(sum[:])
+	if actual != cfg.SHA256 {
+		return fmt.Errorf("%w: config hash mismatch: expected %s, got %s", ErrUpdateVerify, cfg.SHA256, actual)
+	}
+	if err := g.verifySignature(componentConfigSignedData(cfg), cfg.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrUpdateVerify, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(mc.ConfigPath), 0o755); err != nil {
+		return fmt.Errorf("%w: %v", ErrUpdateApply, err)
+	}
+	if err := writeFileAtomic(mc.ConfigPath, content, 0o644); err != nil {
+		return fmt.Errorf("%w: %v", ErrUpdateApply, err)
+	}
+
+	g.mu.Lock()
+	if g.configVersions == nil {
+		g.configVersions = make(map[string]string)
+	}
+	g.configVersions[mc.Slug] = cfg.Version
+	data, _ := json.Marshal(g.configVersions)
+	g.mu.Unlock()
+	if err := g.sealedEntries().SaveEntry(configVersionsFileName, data); err != nil {
+		g.logger.Warn("save config versions failed", "component", mc.Slug, "error", err)
+	}
+
+	g.logger.Info("component config applied", "component", mc.Slug, "old_config_version", current, "new_config_version", cfg.Version)
+	return nil
+}
+
+// loadConfigVersions restores the configuration versions applied before the
+// last restart.
+func (g *Guard) loadConfigVersions() {
+	data, err := g.sealedEntries().LoadEntry(configVersionsFileName)
+	if err != nil {
+		return
+	}
+	var versions map[string]string
+	if json.Unmarshal(data, &versions) != nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for slug, version := range versions {
+		g.configVersions[slug] = version
+	}
+}
+
+// componentConfigSignedData binds the signature to component and version so a
+// blob signed for one component cannot be replayed onto another.
+func componentConfigSignedData(cfg componentConfig) string {
+	return cfg.Component + "|" + cfg.Version + "|" + cfg.SHA256
+}
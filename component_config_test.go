@@ -0,0 +1,95 @@
+package sdk
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func signedComponentConfig(t *testing.T, privKey ed25519.PrivateKey, component, version, content string) componentConfig {
+	t.Helper()
+	cfg := componentConfig{
+		Component: component,
+		Version:   version,
+		Content:   base64.StdEncoding.EncodeToString([]byte(content)),
+		SHA256:    sha256Hex([]byte(content)),
+	}
+	cfg.Signature = signUpdateHash(t, privKey, componentConfigSignedData(cfg))
+	return cfg
+}
+
+func TestApplyComponentConfigs_WritesAndReportsVersion(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	configPath := filepath.Join(t.TempDir(), "conf", "app.json")
+	guard.cfg.ManagedComponents = []ManagedComponent{{Slug: "worker", ConfigPath: configPath}}
+
+	guard.applyComponentConfigs([]componentConfig{
+		signedComponentConfig(t, privKey, "worker", "3", `{"threads":4}`),
+	})
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if string(data) != `{"threads":4}` {
+		t.Fatalf("unexpected config content: %s", data)
+	}
+	if got := guard.ConfigVersion("worker"); got != "3" {
+		t.Fatalf("expected config version 3, got %q", got)
+	}
+}
+
+func TestApplyComponentConfig_RejectsTamperingAndDowngrade(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	configPath := filepath.Join(t.TempDir(), "app.json")
+	mc := ManagedComponent{Slug: "worker", ConfigPath: configPath}
+	guard.cfg.ManagedComponents = []ManagedComponent{mc}
+
+	replayed := signedComponentConfig(t, privKey, "other", "1", "x")
+	replayed.Component = "worker"
+	if err := guard.applyComponentConfig(mc, replayed); !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("expected signature bound to component, got %v", err)
+	}
+
+	tampered := signedComponentConfig(t, privKey, "worker", "1", "x")
+	tampered.Content = base64.StdEncoding.EncodeToString([]byte("y"))
+	if err := guard.applyComponentConfig(mc, tampered); !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Fatalf("rejected config must not be written, stat err=%v", err)
+	}
+
+	if err := guard.applyComponentConfig(mc, signedComponentConfig(t, privKey, "worker", "2", "v2")); err != nil {
+		t.Fatalf("apply v2: %v", err)
+	}
+	if err := guard.applyComponentConfig(mc, signedComponentConfig(t, privKey, "worker", "1", "v1")); !errors.Is(err, ErrUpdateDowngrade) {
+		t.Fatalf("expected downgrade rejection, got %v", err)
+	}
+}
+
+func TestApplyComponentConfig_RejectsReplayAfterRestart(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	mc := ManagedComponent{Slug: "worker", ConfigPath: filepath.Join(t.TempDir(), "app.json")}
+	guard.cfg.ManagedComponents = []ManagedComponent{mc}
+	old := signedComponentConfig(t, privKey, "worker", "1", "old")
+	if err := guard.applyComponentConfig(mc, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.applyComponentConfig(mc, signedComponentConfig(t, privKey, "worker", "2", "new")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restart starts from the versions kept in the cache.
+	guard.configVersions = make(map[string]string)
+	guard.loadConfigVersions()
+	if got := guard.ConfigVersion("worker"); got != "2" {
+		t.Fatalf("config version after restart = %q, want 2", got)
+	}
+	if err := guard.applyComponentConfig(mc, old); !errors.Is(err, ErrUpdateDowngrade) {
+		t.Fatalf("replayed config after restart: %v, want ErrUpdateDowngrade", err)
+	}
+}
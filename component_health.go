@@ -0,0 +1,160 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// componentStartsFileName is the cache entry counting component starts, so
+// restarts are counted across process restarts too.
+const componentStartsFileName = "component_starts.json"
+
+// componentHealth is the health of one component in the heartbeat
+// components section.
+type componentHealth struct {
+	// Status is healthy, unhealthy or unknown when no probe is configured.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// UptimeS is the time since the component last started, in seconds.
+	UptimeS int64 `json:"uptime_s,omitempty"`
+	// Restarts counts the starts after the first one.
+	Restarts int `json:"restarts"`
+}
+
+// componentProbe is the result of one health check run.
+type componentProbe struct {
+	health string
+	err    string
+}
+
+// RecordComponentStart tells the guard that the managed or reported component
+// slug has (re)started, for the uptime and restart count reported in
+// heartbeats. The guard's own component is recorded when New runs.
+func (g *Guard) RecordComponentStart(slug string) error {
+	if err := g.requireState(); err != nil {
+		return err
+	}
+	if !g.knownComponent(slug) {
+		return fmt.Errorf("%w: %s", ErrComponentNotFound, slug)
+	}
+	g.recordComponentStart(slug, time.Now())
+	return nil
+}
+
+func (g *Guard) knownComponent(slug string) bool {
+	if slug == g.cfg.ComponentSlug {
+		return true
+	}
+	if _, ok := g.findManagedComponent(slug); ok {
+		return true
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.reportedVersions[slug]
+	return ok
+}
+
+// recordComponentStart stamps the start of slug and bumps its persisted start
+// count.
+func (g *Guard) recordComponentStart(slug string, now time.Time) {
+	store := g.sealedEntries()
+	starts := make(map[string]int)
+	if data, err := store.LoadEntry(componentStartsFileName); err == nil {
+		_ = json.Unmarshal(data, &starts)
+	}
+	starts[slug]++
+
+	g.mu.Lock()
+	if g.componentStarted == nil {
+		g.componentStarted = make(map[string]time.Time)
+		g.componentStarts = make(map[string]int)
+	}
+	g.componentStarted[slug] = now
+	g.componentStarts[slug] = starts[slug]
+	g.mu.Unlock()
+
+	data, _ := json.Marshal(starts)
+	if err := store.SaveEntry(componentStartsFileName, data); err != nil {
+		g.logger.Warn("save component starts failed", "error", err)
+	}
+}
+
+// probeComponents runs Config.HealthCheck and every ManagedComponent
+// HealthCheck concurrently under one pluginHealthTimeout deadline, keyed by
+// component slug. A check still running at the deadline is reported
+// unhealthy and left behind, so hung components cannot stack up delays.
+func (g *Guard) probeComponents(ctx context.Context) map[string]componentProbe {
+	checks := make(map[string]func(context.Context) error)
+	if g.cfg.HealthCheck != nil {
+		checks[g.cfg.ComponentSlug] = g.cfg.HealthCheck
+	}
+	for _, mc := range g.cfg.ManagedComponents {
+		if mc.HealthCheck != nil {
+			checks[mc.Slug] = mc.HealthCheck
+		}
+	}
+	probes := make(map[string]componentProbe, len(checks))
+	if len(checks) == 0 {
+		return probes
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pluginHealthTimeout)
+	defer cancel()
+	type result struct {
+		slug string
+		err  error
+	}
+	results := make(chan result, len(checks))
+	for slug, check := range checks {
+		go func() { results <- result{slug: slug, err: check(ctx)} }()
+	}
+	record := func(slug string, err error) {
+		if err != nil {
+			probes[slug] = componentProbe{health: pluginUnhealthy, err: g.redactErr(err).Error()}
+			return
+		}
+		probes[slug] = componentProbe{health: pluginHealthy}
+	}
+	for range checks {
+		select {
+		case r := <-results:
+			record(r.slug, r.err)
+		case <-ctx.Done():
+			for slug := range checks {
+				if _, done := probes[slug]; !done {
+					record(slug, ctx.Err())
+				}
+			}
+			return probes
+		}
+	}
+	return probes
+}
+
+// componentHealthReport returns the health of slug, or nil when neither a
+// start nor a probe result is known for it.
+func (g *Guard) componentHealthReport(slug string, probes map[string]componentProbe, now time.Time) *componentHealth {
+	g.mu.RLock()
+	started, hasStart := g.componentStarted[slug]
+	starts := g.componentStarts[slug]
+	g.mu.RUnlock()
+	probe, hasProbe := probes[slug]
+	if !hasStart && !hasProbe {
+		return nil
+	}
+
+	health := &componentHealth{Status: pluginHealthUnknown}
+	if hasProbe {
+		health.Status = probe.health
+		health.Error = probe.err
+	}
+	if hasStart {
+		health.UptimeS = int64(now.Sub(started) / time.Second)
+	}
+	if starts > 1 {
+		health.Restarts = starts - 1
+	}
+	return health
+}
@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatReportsComponentHealth(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.cfg.HealthCheck = func(ctx context.Context) error { return errors.New("db unreachable") }
+	guard.cfg.ManagedComponents = []ManagedComponent{
+		{Slug: "worker", HealthCheck: func(ctx context.Context) error { return nil }},
+		{Slug: "theme"},
+	}
+	if err := guard.RecordComponentStart("worker"); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.RecordComponentStart("worker"); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.RecordComponentStart("unknown"); !errors.Is(err, ErrComponentNotFound) {
+		t.Fatalf("expected ErrComponentNotFound, got %v", err)
+	}
+
+	var body heartbeatRequestBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+		}
+		resp := heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, resp, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	health := map[string]*componentHealth{}
+	for _, c := range body.Components {
+		health[c.Slug] = c.Health
+	}
+	if got := health[guard.cfg.ComponentSlug]; got == nil || got.Status != pluginUnhealthy || got.Error != "db unreachable" || got.Restarts != 0 {
+		t.Fatalf("main component health = %#v", got)
+	}
+	if got := health["worker"]; got == nil || got.Status != pluginHealthy || got.Restarts != 1 {
+		t.Fatalf("worker health = %#v", got)
+	}
+	if got := health["theme"]; got != nil {
+		t.Fatalf("theme without start or probe should report no health, got %#v", got)
+	}
+	for _, plugin := range body.Plugins {
+		if plugin.Slug == "worker" && plugin.Health != pluginHealthy {
+			t.Fatalf("plugin section health = %#v", plugin)
+		}
+	}
+}
+
+func TestComponentStartsPersistAcrossGuards(t *testing.T) {
+	store := NewMemoryCacheStore()
+	// New records the guard's own component start.
+	if _, err := NewForTesting(Config{CacheStore: store}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewForTesting(Config{CacheStore: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := second.componentHealthReport(second.cfg.ComponentSlug, nil, second.componentStarted[second.cfg.ComponentSlug])
+	if got == nil || got.Restarts != 1 || got.Status != pluginHealthUnknown {
+		t.Fatalf("health after restart = %#v", got)
+	}
+}
+
+func TestProbeComponentsSharesOneDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hung := func(context.Context) error { <-release; return nil }
+	g := &Guard{cfg: Config{
+		ComponentSlug: "backend",
+		HealthCheck:   func(context.Context) error { return nil },
+		ManagedComponents: []ManagedComponent{
+			{Slug: "worker", HealthCheck: hung},
+			{Slug: "indexer", HealthCheck: hung},
+		},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan map[string]componentProbe, 1)
+	go func() { done <- g.probeComponents(ctx) }()
+	select {
+	case probes := <-done:
+		if probes["backend"].health != pluginHealthy {
+			t.Fatalf("backend probe = %#v", probes["backend"])
+		}
+		for _, slug := range []string{"worker", "indexer"} {
+			if probes[slug].health != pluginUnhealthy {
+				t.Fatalf("%s probe = %#v, want unhealthy", slug, probes[slug])
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("hung health checks delayed the probe past the deadline")
+	}
+}
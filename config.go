@@ -1,7 +1,9 @@
 package sdk
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"runtime"
 	"strings"
@@ -21,17 +23,208 @@ type Config struct {
 	ProjectSlug   string
 	ComponentSlug string
 
-	HeartbeatInterval time.Duration
-	GracePolicy       GracePolicy
-	OTA               OTAConfig
-	ManagedComponents []ManagedComponent
-	AllowSystemTrust  bool
-	PinnedSPKIHashes  []string
+	HeartbeatInterval    time.Duration
+	HeartbeatMinInterval time.Duration
+	HeartbeatMaxInterval time.Duration
+	GracePolicy          GracePolicy
+	OTA                  OTAConfig
+	ManagedComponents    []ManagedComponent
+	AllowSystemTrust     bool
+	PinnedSPKIHashes     []string
+	Codec                Codec
+	ClientCert           ClientCertConfig
+	Debug                DebugConfig
+	Push                 PushConfig
+	Fingerprint          FingerprintConfig
+	// RedactPatterns are extra regular expressions masked in SDK logs and
+	// errors, on top of the license key, machine ID and URL credentials.
+	// A pattern with a capture group keeps the first group visible.
+	RedactPatterns []string
+	// LicenseCacheTTL is how long a lease confirmed online may be reused
+	// from the local cache at startup before verifying online again
+	// (default: GracePolicy.MaxOfflineDuration).
+	LicenseCacheTTL time.Duration
+	// CacheDir holds the license cache, secrets, audit log and key-value
+	// store (default: ~/.deploy-guard/<project>/<component>). Set it for
+	// systemd DynamicUser services, Windows services or containers with a
+	// read-only home, e.g. to $STATE_DIRECTORY.
+	CacheDir string
+	// CacheStore replaces the files under CacheDir for the license cache,
+	// binding, secrets and update history; see CacheStore.
+	CacheStore CacheStore
+	// HTTPClient replaces the client the guard builds for verify, heartbeat,
+	// download, feedback and marketplace calls. It is used as is, so
+	// Transport, AllowSystemTrust and PinnedSPKIHashes do not apply to it.
+	HTTPClient *http.Client
+	// Transport tunes the guard's own client when HTTPClient is nil.
+	Transport TransportConfig
+	// CustomTransport replaces the HTTP or gRPC transport of JSON API calls
+	// and, unless OTA.Fetcher is set, artifact downloads; see Transport.
+	CustomTransport Transport
+	// Retry controls retries of idempotent API calls on transient failures.
+	Retry RetryPolicy
+	// RateLimit throttles API calls on the client; see RateLimitPolicy.
+	RateLimit RateLimitPolicy
+	// Timeouts bounds verify, heartbeat, API, download and upload calls
+	// separately.
+	Timeouts TimeoutConfig
+	// VersionManifestPath is the local manifest AutoResolveVersion falls back
+	// to when the server does not know the binary hash (default: version.json
+	// next to the executable).
+	VersionManifestPath string
+	// DisableErrorReporting stops the guard from attaching deduplicated
+	// SDK failures, such as signature mismatches, failed OTA applies and a
+	// corrupt state cache, to heartbeats.
+	DisableErrorReporting bool
+	// Audit keeps a local, rotated log of verifications, state changes,
+	// updates, rollbacks and kill commands; see Guard.AuditEvents.
+	Audit AuditConfig
+
+	OnKillScheduled func(deadline time.Time, reason string)
+	// Enforcement acts on kills and locks: callbacks and an optional
+	// delayed process shutdown; see EnforcementConfig.
+	Enforcement EnforcementConfig
+	// OnGraceWarning fires while heartbeats fail, at most once per
+	// GracePolicy.WarningInterval, with the time left before locking.
+	OnGraceWarning func(remaining time.Duration)
+	// OnLocked fires once the offline grace period expires.
+	OnLocked func()
+	// OnUnlocked fires when a locked guard verifies online again and returns
+	// to ACTIVE.
+	OnUnlocked func()
+	// HealthCheck, if set, runs before every heartbeat and its result is
+	// reported as the health of ComponentSlug, like ManagedComponent
+	// HealthCheck for managed components.
+	HealthCheck func(ctx context.Context) error
+	// OnSeatsExhausted fires when AcquireSeat is refused because every seat
+	// of the license is taken.
+	OnSeatsExhausted func(userID string)
+	// OnLicenseExpiring fires from the heartbeat loop once for each
+	// LicenseExpiryWarnings threshold the remaining license time falls
+	// within. OnLicenseExpired fires once the expiry has passed. Both re-arm
+	// when a renewal moves the expiry.
+	OnLicenseExpiring func(remaining time.Duration)
+	OnLicenseExpired  func()
+	// LicenseExpiryWarnings are the OnLicenseExpiring thresholds (default
+	// 30 days, 7 days and 1 day).
+	LicenseExpiryWarnings []time.Duration
+	// OnFeedbackReply fires from the heartbeat loop for each new support
+	// reply to feedback filed from this machine.
+	OnFeedbackReply func(feedbackID string, reply FeedbackReply)
+	// OnAnnouncement fires once for each unread announcement that targets
+	// this guard, delivered by a heartbeat or FetchAnnouncements.
+	OnAnnouncement func(Announcement)
+	// FeedbackPollInterval is how often WatchFeedback polls (default 1
+	// minute).
+	FeedbackPollInterval time.Duration
+
+	// cacheDir overrides the per-user state directory; NewForTesting points
+	// it at a temporary directory.
+	cacheDir string
 }
 
 type GracePolicy struct {
 	MaxOfflineDuration time.Duration
 	WarningInterval    time.Duration
+
+	// Per-category offline budgets; zero falls back to MaxOfflineDuration.
+	// Within one offline episode the tightest budget seen applies, so a
+	// vendor outage followed by a revocation still locks on the license budget.
+	NetworkMaxOffline      time.Duration
+	ServerErrorMaxOffline  time.Duration
+	LicenseErrorMaxOffline time.Duration
+	// LicenseEscalateAfter is how many license failures within one offline
+	// episode are needed before LicenseErrorMaxOffline applies (default 1);
+	// other failures in between do not start the count over.
+	LicenseEscalateAfter int
+	// RecoveryInterval is how often a LOCKED guard retries online
+	// verification (default 30m); a negative value disables recovery and
+	// leaves the guard locked until restart.
+	RecoveryInterval time.Duration
+}
+
+// ClientCertConfig enables zero-trust mTLS: the guard generates a keypair,
+// enrolls a client certificate during Start and presents it on every call.
+type ClientCertConfig struct {
+	Enabled bool
+	// RenewBefore is how long before expiry the certificate is renewed on a
+	// heartbeat; it is capped at a third of the certificate lifetime.
+	RenewBefore time.Duration
+}
+
+// TransportConfig sets proxy and TLS options for the guard's HTTP client.
+// SPKI pinning is configured separately with Config.PinnedSPKIHashes.
+type TransportConfig struct {
+	// ProxyURL routes server calls through an http, https or socks5 proxy.
+	// Empty honours HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+	ProxyURL string
+	// RootCAsPEM replaces the system roots, e.g. for a self-hosted server
+	// behind a private CA.
+	RootCAsPEM []byte
+	// ClientCertPEM and ClientKeyPEM present a static mTLS client
+	// certificate. With ClientCert.Enabled the enrolled certificate takes
+	// precedence once one is held.
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+	// MinTLSVersion is the lowest accepted TLS version, tls.VersionTLS12
+	// (default) or tls.VersionTLS13.
+	MinTLSVersion uint16
+	// CipherSuites are the TLS 1.2 cipher suites offered (default: ECDHE
+	// key exchange with AES-GCM or ChaCha20-Poly1305). Suites listed in
+	// tls.InsecureCipherSuites are rejected; TLS 1.3 suites are fixed by Go.
+	// Renegotiation is always refused.
+	CipherSuites []uint16
+	// Protocol selects how the guard's JSON API calls (verify, heartbeat,
+	// update metadata, plugins, feedback) reach the server: TransportHTTP
+	// (default) or TransportGRPC. Uploads, downloads, activation and the
+	// push channel always use HTTP.
+	Protocol TransportProtocol
+	// GRPCTarget is the gRPC dial target, e.g. "dns:///license.internal:8443".
+	// Empty dials the host and port of ServerURL.
+	GRPCTarget string
+	// EndpointURLs sends paths starting with a key to another base URL, e.g.
+	// {"/download/": "https://cdn.example.com"} for a separate download
+	// host. The longest matching prefix wins. Requests to these hosts are
+	// signed like requests to ServerURL and carry the license key, so list
+	// only hosts you trust with it.
+	EndpointURLs map[string]string
+	// HostOverrides dials another address for a host name, e.g.
+	// {"license.example.com": "10.0.0.5"} for split-horizon DNS. A value may
+	// be a host, an IPv4 or IPv6 address, or either with a port. TLS still
+	// sends SNI for and verifies the original host name.
+	HostOverrides map[string]string
+}
+
+// TransportProtocol names the wire protocol of API calls.
+type TransportProtocol string
+
+const (
+	TransportHTTP TransportProtocol = "http"
+	TransportGRPC TransportProtocol = "grpc"
+)
+
+// DebugConfig holds field-diagnosis switches that are off by default.
+type DebugConfig struct {
+	// RecordResponses keeps the last MaxRecordedResponses heartbeat and verify
+	// replies, redacted, under the cache dir so a machine that crash-loops
+	// into LOCKED can be diagnosed afterwards. Read them with
+	// Guard.RecordedResponses.
+	RecordResponses      bool
+	MaxRecordedResponses int
+	// StrictDecoding fails heartbeats whose status this SDK does not know
+	// instead of treating them as ok, to catch server drift in staging.
+	StrictDecoding bool
+	// OnUnknownFields receives the top-level fields of a verify or heartbeat
+	// reply that this SDK does not decode, e.g. after a server upgrade.
+	OnUnknownFields func(endpoint string, fields []string)
+	// LogTraffic logs every API exchange at info level: method, path,
+	// status, latency and both bodies cut to MaxLoggedBodyBytes (default
+	// 2048). License keys, signatures, tokens and machine IDs are redacted.
+	// TrafficEndpoints limits logging to the listed endpoints, named as in
+	// metrics labels ("verify", "heartbeat", "update/download", ...).
+	LogTraffic         bool
+	MaxLoggedBodyBytes int
+	TrafficEndpoints   []string
 }
 
 type OTAConfig struct {
@@ -42,9 +235,41 @@ type OTAConfig struct {
 	Arch             string
 	DownloadTimeout  time.Duration
 	MaxArtifactBytes int64
+	PinnedVersions   map[string]string
+	IgnoredVersions  map[string][]string
 	OnUpdateProgress func(component, stage string, progress float64)
 	OnUpdateResult   func(component, oldVer, newVer string, success bool, err error)
 	OnUpdateFailure  func(component string, err error)
+	OnUpdateStats    func(stats UpdateStats)
+	// AllowDowngrade installs server-offered versions older than the
+	// installed one, e.g. when a release is pulled. By default they are
+	// rejected with ErrUpdateDowngrade; see Guard.IsUpdateDowngrade.
+	AllowDowngrade bool
+	// Migrate and MigrationFailure are the guard's own component's
+	// ManagedComponent.Migrate and MigrationFailure.
+	Migrate          func(ctx context.Context, fromVersion, toVersion string) error
+	MigrationFailure MigrationPolicy
+	// Notifier, when set, is told when an update becomes available, is
+	// installed or fails, e.g. to show a native desktop notification.
+	Notifier UpdateNotifier
+	// RequireSignedMetadata rejects update download metadata and plugin
+	// update packages unless the server signs the component, version,
+	// platform, URL and SHA-256 together, so an endpoint with a forged TLS
+	// certificate cannot swap in another artifact.
+	RequireSignedMetadata bool
+	// Fetcher, when set, supplies update artifact bytes instead of the
+	// SDK downloading them from the server, e.g. through a customer's
+	// artifact mirror. Hash and signature checks still apply.
+	Fetcher Fetcher
+	// ArtifactCache keeps verified artifacts by SHA-256 so the same
+	// artifact is never downloaded twice; see ArtifactCacheConfig.
+	ArtifactCache ArtifactCacheConfig
+	// LANSharing fetches artifacts from machines on the same network before
+	// the server and serves verified ones to them; see LANSharingConfig.
+	LANSharing LANSharingConfig
+	// Extraction bounds what a frontend archive may expand to; see
+	// ExtractionLimits.
+	Extraction ExtractionLimits
 }
 
 type UpdateStrategy int
@@ -59,6 +284,59 @@ type ManagedComponent struct {
 	Dir        string
 	Strategy   UpdateStrategy
 	PostUpdate func() error
+	ConfigPath string
+
+	// PreUpdate runs before any download starts; a non-nil error vetoes the
+	// update (e.g. "users are active").
+	PreUpdate func(ctx context.Context, event LifecycleEvent) error
+	// PostInstall runs after the first successful install of a component
+	// whose previous version was unknown.
+	PostInstall func(ctx context.Context, event LifecycleEvent) error
+	// PreUninstall runs before an uninstall request; a non-nil error vetoes it.
+	PreUninstall func(ctx context.Context, event LifecycleEvent) error
+	// OnRollback runs after a failed apply has restored the previous version.
+	OnRollback func(ctx context.Context, event LifecycleEvent, cause error)
+	// HealthCheck, if set, runs before every heartbeat; its result is reported
+	// as the component's health and in the heartbeat plugins section.
+	HealthCheck func(ctx context.Context) error
+	// CacheInvalidate, if set, runs for a frontend component right after its
+	// directory is swapped, e.g. to purge a CDN or reverse proxy cache. It
+	// gets the new asset manifest and the paths that changed; an error is
+	// logged but does not fail the update. See Guard.FrontendManifest.
+	CacheInvalidate func(ctx context.Context, inv CacheInvalidation) error
+	// Migrate, if set, runs in the "migrating" stage of a backend update,
+	// after the artifact is verified and before the binary is swapped, e.g.
+	// to apply schema changes the new version needs. It can report progress
+	// with ReportMigrationProgress. When it fails, MigrationFailure decides
+	// whether the update is aborted (the default) or installed anyway.
+	Migrate          func(ctx context.Context, fromVersion, toVersion string) error
+	MigrationFailure MigrationPolicy
+	// BlueGreen, for a frontend component, keeps each release in
+	// Dir/<version> and serves it through the Dir/current symlink, which an
+	// update repoints atomically, so live traffic never sees a missing
+	// directory. Point the web server at Dir/current. KeepVersions previous
+	// releases (default: 2) are kept for Guard.RollbackFrontend.
+	BlueGreen    bool
+	KeepVersions int
+
+	// Exec, if set, makes the guard supervise the component's process for a
+	// backend component: Start launches Exec with Args, and Env appended to
+	// the guard's environment, restarts it per RestartPolicy when it exits,
+	// and restarts it gracefully after every installed update. Exec is
+	// usually Dir. Stop terminates the process; StopAndWait waits for it.
+	Exec          string
+	Args          []string
+	Env           []string
+	RestartPolicy RestartPolicy
+
+	// SystemdUnit, if set, is the systemd unit running a backend component
+	// (e.g. "worker.service"). After the binary is replaced the guard
+	// restarts the unit over D-Bus and waits up to SystemdActivationTimeout
+	// (default: 1m) for it to become active; otherwise it restores the
+	// previous binary, restarts the unit on it and reports the update as
+	// failed. Linux only.
+	SystemdUnit              string
+	SystemdActivationTimeout time.Duration
 }
 
 func (c *Config) setDefaults() {
@@ -69,12 +347,58 @@ func (c *Config) setDefaults() {
 	if c.HeartbeatInterval <= 0 {
 		c.HeartbeatInterval = 1 * time.Hour
 	}
+	if c.HeartbeatMinInterval <= 0 {
+		c.HeartbeatMinInterval = 1 * time.Minute
+	}
+	if c.HeartbeatMaxInterval <= 0 {
+		c.HeartbeatMaxInterval = 24 * time.Hour
+	}
+	if c.HeartbeatMaxInterval < c.HeartbeatMinInterval {
+		c.HeartbeatMaxInterval = c.HeartbeatMinInterval
+	}
 	if c.GracePolicy.MaxOfflineDuration <= 0 {
 		c.GracePolicy.MaxOfflineDuration = 72 * time.Hour
 	}
 	if c.GracePolicy.WarningInterval <= 0 {
 		c.GracePolicy.WarningInterval = 4 * time.Hour
 	}
+	if c.LicenseCacheTTL <= 0 {
+		c.LicenseCacheTTL = c.GracePolicy.MaxOfflineDuration
+	}
+	if len(c.LicenseExpiryWarnings) == 0 {
+		c.LicenseExpiryWarnings = defaultLicenseExpiryWarnings
+	}
+	c.Push.setDefaults()
+	if c.FeedbackPollInterval <= 0 {
+		c.FeedbackPollInterval = time.Minute
+	}
+	if c.GracePolicy.RecoveryInterval == 0 {
+		c.GracePolicy.RecoveryInterval = 30 * time.Minute
+	}
+	if c.RateLimit.RequestsPerSecond == 0 {
+		c.RateLimit.RequestsPerSecond = 10
+	}
+	if c.RateLimit.Burst <= 0 {
+		c.RateLimit.Burst = 20
+	}
+	if c.Retry.MaxAttempts <= 0 {
+		c.Retry.MaxAttempts = 3
+	}
+	if c.Retry.InitialBackoff <= 0 {
+		c.Retry.InitialBackoff = 500 * time.Millisecond
+	}
+	if c.Retry.MaxBackoff <= 0 {
+		c.Retry.MaxBackoff = 10 * time.Second
+	}
+	if c.ClientCert.RenewBefore <= 0 {
+		c.ClientCert.RenewBefore = 72 * time.Hour
+	}
+	if c.Debug.MaxRecordedResponses <= 0 {
+		c.Debug.MaxRecordedResponses = defaultResponseLogMax
+	}
+	if c.Debug.MaxLoggedBodyBytes <= 0 {
+		c.Debug.MaxLoggedBodyBytes = defaultTrafficBodyBytes
+	}
 	if c.OTA.CheckInterval <= 0 {
 		c.OTA.CheckInterval = 6 * time.Hour
 	}
@@ -91,6 +415,17 @@ func (c *Config) setDefaults() {
 	if c.OTA.MaxArtifactBytes <= 0 {
 		c.OTA.MaxArtifactBytes = 500 * 1024 * 1024 // 500MB
 	}
+	if c.OTA.LANSharing.Port <= 0 {
+		c.OTA.LANSharing.Port = 47813
+	}
+	if c.OTA.LANSharing.DiscoveryTimeout <= 0 {
+		c.OTA.LANSharing.DiscoveryTimeout = time.Second
+	}
+	c.OTA.Extraction = c.OTA.Extraction.withDefaults()
+	if c.OTA.ArtifactCache.MaxBytes <= 0 {
+		c.OTA.ArtifactCache.MaxBytes = 1 << 30 // 1GB
+	}
+	c.Timeouts.setDefaults(c.OTA.DownloadTimeout)
 }
 
 func normalizeServerURL(raw string) (string, error) {
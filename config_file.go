@@ -0,0 +1,480 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts every environment variable LoadConfig reads.
+const EnvPrefix = "BANYANHUB_"
+
+// LoadConfig reads a Config from a YAML (.yaml, .yml), JSON (.json) or TOML
+// (.toml) file and applies BANYANHUB_* environment overrides on top, so a
+// deployment can keep the license key out of the file. With an empty path
+// only the environment is read.
+//
+// Keys use the snake_case names of the Config fields, e.g. server_url,
+// heartbeat_interval or ota.check_interval; unknown keys are rejected.
+// Durations are Go duration strings such as "90s" or "6h". public_key_file
+// and transport.root_cas_file are read from disk, relative to the config
+// file. Callbacks, HTTPClient and CustomTransport cannot be expressed in a
+// file; set them on the returned Config before calling New.
+//
+// The environment variables are:
+//
+//	BANYANHUB_SERVER_URL, BANYANHUB_LICENSE_KEY, BANYANHUB_PUBLIC_KEY_PEM,
+//	BANYANHUB_PUBLIC_KEY_FILE, BANYANHUB_PROJECT_SLUG, BANYANHUB_COMPONENT_SLUG,
+//	BANYANHUB_HEARTBEAT_INTERVAL, BANYANHUB_LICENSE_CACHE_TTL,
+//	BANYANHUB_GRACE_MAX_OFFLINE, BANYANHUB_ALLOW_SYSTEM_TRUST,
+//	BANYANHUB_PINNED_SPKI_HASHES, BANYANHUB_PROXY_URL,
+//	BANYANHUB_TRANSPORT_PROTOCOL, BANYANHUB_OTA_ENABLED,
+//	BANYANHUB_OTA_AUTO_UPDATE, BANYANHUB_OTA_CHECK_INTERVAL,
+//	BANYANHUB_MANAGED_COMPONENTS, BANYANHUB_VERSION_MANIFEST_PATH,
+//	BANYANHUB_CACHE_DIR
+//
+// Lists are comma separated. BANYANHUB_MANAGED_COMPONENTS replaces the
+// file's list with entries of the form slug[:strategy]=dir, e.g.
+// "api=/opt/app,web:frontend=/srv/www"; the strategy defaults to backend.
+func LoadConfig(path string) (Config, error) {
+	var fc fileConfig
+	baseDir := ""
+	if path != "" {
+		if err := decodeConfigFile(path, &fc); err != nil {
+			return Config{}, fmt.Errorf("load config %s: %w", path, err)
+		}
+		baseDir = filepath.Dir(path)
+	}
+	if err := fc.applyEnv(os.LookupEnv); err != nil {
+		return Config{}, fmt.Errorf("load config from environment: %w", err)
+	}
+	cfg, err := fc.config(baseDir)
+	if err != nil {
+		return Config{}, fmt.Errorf("load config: %w", err)
+	}
+	return cfg, nil
+}
+
+func decodeConfigFile(path string, fc *fileConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(fc); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(fc); err != nil {
+			return err
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), fc)
+		if err != nil {
+			return err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown key %q", undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("unsupported config format %q (want .yaml, .yml, .json or .toml)", ext)
+	}
+	return nil
+}
+
+// configDuration is a time.Duration written as a Go duration string.
+type configDuration time.Duration
+
+func (d *configDuration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return err
+	}
+	*d = configDuration(parsed)
+	return nil
+}
+
+// fileConfig is the on-disk form of Config.
+type fileConfig struct {
+	ServerURL             string   `json:"server_url" yaml:"server_url" toml:"server_url"`
+	LicenseKey            string   `json:"license_key" yaml:"license_key" toml:"license_key"`
+	PublicKeyPEM          string   `json:"public_key_pem" yaml:"public_key_pem" toml:"public_key_pem"`
+	PublicKeyFile         string   `json:"public_key_file" yaml:"public_key_file" toml:"public_key_file"`
+	LegacyPublicKeyFiles  []string `json:"legacy_public_key_files" yaml:"legacy_public_key_files" toml:"legacy_public_key_files"`
+	ProjectSlug           string   `json:"project_slug" yaml:"project_slug" toml:"project_slug"`
+	ComponentSlug         string   `json:"component_slug" yaml:"component_slug" toml:"component_slug"`
+	AllowSystemTrust      bool     `json:"allow_system_trust" yaml:"allow_system_trust" toml:"allow_system_trust"`
+	PinnedSPKIHashes      []string `json:"pinned_spki_hashes" yaml:"pinned_spki_hashes" toml:"pinned_spki_hashes"`
+	RedactPatterns        []string `json:"redact_patterns" yaml:"redact_patterns" toml:"redact_patterns"`
+	VersionManifestPath   string   `json:"version_manifest_path" yaml:"version_manifest_path" toml:"version_manifest_path"`
+	CacheDir              string   `json:"cache_dir" yaml:"cache_dir" toml:"cache_dir"`
+	DisableErrorReporting bool     `json:"disable_error_reporting" yaml:"disable_error_reporting" toml:"disable_error_reporting"`
+
+	HeartbeatInterval    configDuration `json:"heartbeat_interval" yaml:"heartbeat_interval" toml:"heartbeat_interval"`
+	HeartbeatMinInterval configDuration `json:"heartbeat_min_interval" yaml:"heartbeat_min_interval" toml:"heartbeat_min_interval"`
+	HeartbeatMaxInterval configDuration `json:"heartbeat_max_interval" yaml:"heartbeat_max_interval" toml:"heartbeat_max_interval"`
+	LicenseCacheTTL      configDuration `json:"license_cache_ttl" yaml:"license_cache_ttl" toml:"license_cache_ttl"`
+	FeedbackPollInterval configDuration `json:"feedback_poll_interval" yaml:"feedback_poll_interval" toml:"feedback_poll_interval"`
+
+	Grace             fileGracePolicy        `json:"grace" yaml:"grace" toml:"grace"`
+	OTA               fileOTAConfig          `json:"ota" yaml:"ota" toml:"ota"`
+	ManagedComponents []fileManagedComponent `json:"managed_components" yaml:"managed_components" toml:"managed_components"`
+	Push              filePushConfig         `json:"push" yaml:"push" toml:"push"`
+	Transport         fileTransportConfig    `json:"transport" yaml:"transport" toml:"transport"`
+	Retry             fileRetryPolicy        `json:"retry" yaml:"retry" toml:"retry"`
+	Timeouts          fileTimeoutConfig      `json:"timeouts" yaml:"timeouts" toml:"timeouts"`
+	Audit             fileAuditConfig        `json:"audit" yaml:"audit" toml:"audit"`
+	Enforcement       fileEnforcementConfig  `json:"enforcement" yaml:"enforcement" toml:"enforcement"`
+}
+
+type fileGracePolicy struct {
+	MaxOffline             configDuration `json:"max_offline" yaml:"max_offline" toml:"max_offline"`
+	WarningInterval        configDuration `json:"warning_interval" yaml:"warning_interval" toml:"warning_interval"`
+	NetworkMaxOffline      configDuration `json:"network_max_offline" yaml:"network_max_offline" toml:"network_max_offline"`
+	ServerErrorMaxOffline  configDuration `json:"server_error_max_offline" yaml:"server_error_max_offline" toml:"server_error_max_offline"`
+	LicenseErrorMaxOffline configDuration `json:"license_error_max_offline" yaml:"license_error_max_offline" toml:"license_error_max_offline"`
+	LicenseEscalateAfter   int            `json:"license_escalate_after" yaml:"license_escalate_after" toml:"license_escalate_after"`
+	RecoveryInterval       configDuration `json:"recovery_interval" yaml:"recovery_interval" toml:"recovery_interval"`
+}
+
+type fileOTAConfig struct {
+	Enabled               bool                `json:"enabled" yaml:"enabled" toml:"enabled"`
+	AutoUpdate            bool                `json:"auto_update" yaml:"auto_update" toml:"auto_update"`
+	CheckInterval         configDuration      `json:"check_interval" yaml:"check_interval" toml:"check_interval"`
+	OS                    string              `json:"os" yaml:"os" toml:"os"`
+	Arch                  string              `json:"arch" yaml:"arch" toml:"arch"`
+	DownloadTimeout       configDuration      `json:"download_timeout" yaml:"download_timeout" toml:"download_timeout"`
+	MaxArtifactBytes      int64               `json:"max_artifact_bytes" yaml:"max_artifact_bytes" toml:"max_artifact_bytes"`
+	PinnedVersions        map[string]string   `json:"pinned_versions" yaml:"pinned_versions" toml:"pinned_versions"`
+	IgnoredVersions       map[string][]string `json:"ignored_versions" yaml:"ignored_versions" toml:"ignored_versions"`
+	RequireSignedMetadata bool                `json:"require_signed_metadata" yaml:"require_signed_metadata" toml:"require_signed_metadata"`
+	AllowDowngrade        bool                `json:"allow_downgrade" yaml:"allow_downgrade" toml:"allow_downgrade"`
+}
+
+type fileManagedComponent struct {
+	Slug string `json:"slug" yaml:"slug" toml:"slug"`
+	Dir  string `json:"dir" yaml:"dir" toml:"dir"`
+	// Strategy is "backend" (the default) or "frontend".
+	Strategy   string `json:"strategy" yaml:"strategy" toml:"strategy"`
+	ConfigPath string `json:"config_path" yaml:"config_path" toml:"config_path"`
+	// Exec, Args, Env and Restart configure process supervision; Restart
+	// is "on-failure" (the default), "always" or "never".
+	Exec    string   `json:"exec" yaml:"exec" toml:"exec"`
+	Args    []string `json:"args" yaml:"args" toml:"args"`
+	Env     []string `json:"env" yaml:"env" toml:"env"`
+	Restart string   `json:"restart" yaml:"restart" toml:"restart"`
+	// SystemdUnit is restarted after each update; see
+	// ManagedComponent.SystemdUnit.
+	SystemdUnit              string         `json:"systemd_unit" yaml:"systemd_unit" toml:"systemd_unit"`
+	SystemdActivationTimeout configDuration `json:"systemd_activation_timeout" yaml:"systemd_activation_timeout" toml:"systemd_activation_timeout"`
+	// BlueGreen and KeepVersions select blue/green frontend releases.
+	BlueGreen    bool `json:"blue_green" yaml:"blue_green" toml:"blue_green"`
+	KeepVersions int  `json:"keep_versions" yaml:"keep_versions" toml:"keep_versions"`
+}
+
+type filePushConfig struct {
+	Enabled              bool           `json:"enabled" yaml:"enabled" toml:"enabled"`
+	Path                 string         `json:"path" yaml:"path" toml:"path"`
+	MinReconnectDelay    configDuration `json:"min_reconnect_delay" yaml:"min_reconnect_delay" toml:"min_reconnect_delay"`
+	MaxReconnectDelay    configDuration `json:"max_reconnect_delay" yaml:"max_reconnect_delay" toml:"max_reconnect_delay"`
+	MinHeartbeatInterval configDuration `json:"min_heartbeat_interval" yaml:"min_heartbeat_interval" toml:"min_heartbeat_interval"`
+}
+
+type fileTransportConfig struct {
+	ProxyURL    string `json:"proxy_url" yaml:"proxy_url" toml:"proxy_url"`
+	RootCAsFile string `json:"root_cas_file" yaml:"root_cas_file" toml:"root_cas_file"`
+	Protocol    string `json:"protocol" yaml:"protocol" toml:"protocol"`
+	GRPCTarget  string `json:"grpc_target" yaml:"grpc_target" toml:"grpc_target"`
+	// EndpointURLs and HostOverrides map path prefixes to base URLs and host
+	// names to dial addresses; see TransportConfig.
+	EndpointURLs  map[string]string `json:"endpoint_urls" yaml:"endpoint_urls" toml:"endpoint_urls"`
+	HostOverrides map[string]string `json:"host_overrides" yaml:"host_overrides" toml:"host_overrides"`
+}
+
+type fileRetryPolicy struct {
+	MaxAttempts    int            `json:"max_attempts" yaml:"max_attempts" toml:"max_attempts"`
+	InitialBackoff configDuration `json:"initial_backoff" yaml:"initial_backoff" toml:"initial_backoff"`
+	MaxBackoff     configDuration `json:"max_backoff" yaml:"max_backoff" toml:"max_backoff"`
+}
+
+type fileTimeoutConfig struct {
+	Verify    configDuration `json:"verify" yaml:"verify" toml:"verify"`
+	Heartbeat configDuration `json:"heartbeat" yaml:"heartbeat" toml:"heartbeat"`
+	API       configDuration `json:"api" yaml:"api" toml:"api"`
+	Download  configDuration `json:"download" yaml:"download" toml:"download"`
+	Upload    configDuration `json:"upload" yaml:"upload" toml:"upload"`
+}
+
+type fileAuditConfig struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled" toml:"enabled"`
+	Path       string `json:"path" yaml:"path" toml:"path"`
+	MaxBytes   int64  `json:"max_bytes" yaml:"max_bytes" toml:"max_bytes"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+}
+
+type fileEnforcementConfig struct {
+	ShutdownOnKill bool           `json:"shutdown_on_kill" yaml:"shutdown_on_kill" toml:"shutdown_on_kill"`
+	ShutdownOnLock bool           `json:"shutdown_on_lock" yaml:"shutdown_on_lock" toml:"shutdown_on_lock"`
+	ShutdownDelay  configDuration `json:"shutdown_delay" yaml:"shutdown_delay" toml:"shutdown_delay"`
+}
+
+// configEnvVars maps each environment override to the field it sets.
+var configEnvVars = []struct {
+	name  string
+	apply func(fc *fileConfig, value string) error
+}{
+	{"SERVER_URL", func(fc *fileConfig, v string) error { fc.ServerURL = v; return nil }},
+	{"LICENSE_KEY", func(fc *fileConfig, v string) error { fc.LicenseKey = v; return nil }},
+	{"PUBLIC_KEY_PEM", func(fc *fileConfig, v string) error { fc.PublicKeyPEM = v; return nil }},
+	{"PUBLIC_KEY_FILE", func(fc *fileConfig, v string) error { fc.PublicKeyFile = v; return nil }},
+	{"PROJECT_SLUG", func(fc *fileConfig, v string) error { fc.ProjectSlug = v; return nil }},
+	{"COMPONENT_SLUG", func(fc *fileConfig, v string) error { fc.ComponentSlug = v; return nil }},
+	{"HEARTBEAT_INTERVAL", func(fc *fileConfig, v string) error { return fc.HeartbeatInterval.UnmarshalText([]byte(v)) }},
+	{"LICENSE_CACHE_TTL", func(fc *fileConfig, v string) error { return fc.LicenseCacheTTL.UnmarshalText([]byte(v)) }},
+	{"GRACE_MAX_OFFLINE", func(fc *fileConfig, v string) error { return fc.Grace.MaxOffline.UnmarshalText([]byte(v)) }},
+	{"ALLOW_SYSTEM_TRUST", func(fc *fileConfig, v string) error { return parseEnvBool(v, &fc.AllowSystemTrust) }},
+	{"PINNED_SPKI_HASHES", func(fc *fileConfig, v string) error { fc.PinnedSPKIHashes = splitEnvList(v); return nil }},
+	{"PROXY_URL", func(fc *fileConfig, v string) error { fc.Transport.ProxyURL = v; return nil }},
+	{"TRANSPORT_PROTOCOL", func(fc *fileConfig, v string) error { fc.Transport.Protocol = v; return nil }},
+	{"OTA_ENABLED", func(fc *fileConfig, v string) error { return parseEnvBool(v, &fc.OTA.Enabled) }},
+	{"OTA_AUTO_UPDATE", func(fc *fileConfig, v string) error { return parseEnvBool(v, &fc.OTA.AutoUpdate) }},
+	{"OTA_CHECK_INTERVAL", func(fc *fileConfig, v string) error { return fc.OTA.CheckInterval.UnmarshalText([]byte(v)) }},
+	{"MANAGED_COMPONENTS", func(fc *fileConfig, v string) error {
+		components, err := parseEnvManagedComponents(v)
+		fc.ManagedComponents = components
+		return err
+	}},
+	{"VERSION_MANIFEST_PATH", func(fc *fileConfig, v string) error { fc.VersionManifestPath = v; return nil }},
+	{"CACHE_DIR", func(fc *fileConfig, v string) error { fc.CacheDir = v; return nil }},
+}
+
+func (fc *fileConfig) applyEnv(lookup func(string) (string, bool)) error {
+	for _, env := range configEnvVars {
+		value, ok := lookup(EnvPrefix + env.name)
+		if !ok {
+			continue
+		}
+		if err := env.apply(fc, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s%s: %w", EnvPrefix, env.name, err)
+		}
+	}
+	return nil
+}
+
+func parseEnvBool(value string, dst *bool) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*dst = parsed
+	return nil
+}
+
+func splitEnvList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseEnvManagedComponents parses "slug[:strategy]=dir" entries.
+func parseEnvManagedComponents(value string) ([]fileManagedComponent, error) {
+	var components []fileManagedComponent
+	for _, entry := range splitEnvList(value) {
+		name, dir, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(dir) == "" {
+			return nil, fmt.Errorf("managed component %q: want slug[:strategy]=dir", entry)
+		}
+		slug, strategy, _ := strings.Cut(name, ":")
+		components = append(components, fileManagedComponent{
+			Slug:     strings.TrimSpace(slug),
+			Dir:      strings.TrimSpace(dir),
+			Strategy: strings.TrimSpace(strategy),
+		})
+	}
+	return components, nil
+}
+
+func parseUpdateStrategy(value string) (UpdateStrategy, error) {
+	switch strings.ToLower(value) {
+	case "", "backend":
+		return UpdateBackend, nil
+	case "frontend":
+		return UpdateFrontend, nil
+	default:
+		return 0, fmt.Errorf("unknown update strategy %q (want backend or frontend)", value)
+	}
+}
+
+func parseRestartMode(value string) (RestartMode, error) {
+	switch strings.ToLower(value) {
+	case "", "on-failure":
+		return RestartOnFailure, nil
+	case "always":
+		return RestartAlways, nil
+	case "never":
+		return RestartNever, nil
+	default:
+		return 0, fmt.Errorf("unknown restart mode %q (want on-failure, always or never)", value)
+	}
+}
+
+// config converts the file form to a Config, reading key files relative to
+// baseDir.
+func (fc *fileConfig) config(baseDir string) (Config, error) {
+	cfg := Config{
+		ServerURL:             fc.ServerURL,
+		LicenseKey:            fc.LicenseKey,
+		ProjectSlug:           fc.ProjectSlug,
+		ComponentSlug:         fc.ComponentSlug,
+		AllowSystemTrust:      fc.AllowSystemTrust,
+		PinnedSPKIHashes:      fc.PinnedSPKIHashes,
+		RedactPatterns:        fc.RedactPatterns,
+		VersionManifestPath:   fc.VersionManifestPath,
+		CacheDir:              fc.CacheDir,
+		DisableErrorReporting: fc.DisableErrorReporting,
+		HeartbeatInterval:     time.Duration(fc.HeartbeatInterval),
+		HeartbeatMinInterval:  time.Duration(fc.HeartbeatMinInterval),
+		HeartbeatMaxInterval:  time.Duration(fc.HeartbeatMaxInterval),
+		LicenseCacheTTL:       time.Duration(fc.LicenseCacheTTL),
+		FeedbackPollInterval:  time.Duration(fc.FeedbackPollInterval),
+		GracePolicy: GracePolicy{
+			MaxOfflineDuration:     time.Duration(fc.Grace.MaxOffline),
+			WarningInterval:        time.Duration(fc.Grace.WarningInterval),
+			NetworkMaxOffline:      time.Duration(fc.Grace.NetworkMaxOffline),
+			ServerErrorMaxOffline:  time.Duration(fc.Grace.ServerErrorMaxOffline),
+			LicenseErrorMaxOffline: time.Duration(fc.Grace.LicenseErrorMaxOffline),
+			LicenseEscalateAfter:   fc.Grace.LicenseEscalateAfter,
+			RecoveryInterval:       time.Duration(fc.Grace.RecoveryInterval),
+		},
+		OTA: OTAConfig{
+			Enabled:               fc.OTA.Enabled,
+			AutoUpdate:            fc.OTA.AutoUpdate,
+			CheckInterval:         time.Duration(fc.OTA.CheckInterval),
+			OS:                    fc.OTA.OS,
+			Arch:                  fc.OTA.Arch,
+			DownloadTimeout:       time.Duration(fc.OTA.DownloadTimeout),
+			MaxArtifactBytes:      fc.OTA.MaxArtifactBytes,
+			PinnedVersions:        fc.OTA.PinnedVersions,
+			IgnoredVersions:       fc.OTA.IgnoredVersions,
+			RequireSignedMetadata: fc.OTA.RequireSignedMetadata,
+			AllowDowngrade:        fc.OTA.AllowDowngrade,
+		},
+		Push: PushConfig{
+			Enabled:              fc.Push.Enabled,
+			Path:                 fc.Push.Path,
+			MinReconnectDelay:    time.Duration(fc.Push.MinReconnectDelay),
+			MaxReconnectDelay:    time.Duration(fc.Push.MaxReconnectDelay),
+			MinHeartbeatInterval: time.Duration(fc.Push.MinHeartbeatInterval),
+		},
+		Transport: TransportConfig{
+			ProxyURL:      fc.Transport.ProxyURL,
+			Protocol:      TransportProtocol(fc.Transport.Protocol),
+			GRPCTarget:    fc.Transport.GRPCTarget,
+			EndpointURLs:  fc.Transport.EndpointURLs,
+			HostOverrides: fc.Transport.HostOverrides,
+		},
+		Retry: RetryPolicy{
+			MaxAttempts:    fc.Retry.MaxAttempts,
+			InitialBackoff: time.Duration(fc.Retry.InitialBackoff),
+			MaxBackoff:     time.Duration(fc.Retry.MaxBackoff),
+		},
+		Timeouts: TimeoutConfig{
+			Verify:    time.Duration(fc.Timeouts.Verify),
+			Heartbeat: time.Duration(fc.Timeouts.Heartbeat),
+			API:       time.Duration(fc.Timeouts.API),
+			Download:  time.Duration(fc.Timeouts.Download),
+			Upload:    time.Duration(fc.Timeouts.Upload),
+		},
+		Audit: AuditConfig{
+			Enabled:    fc.Audit.Enabled,
+			Path:       fc.Audit.Path,
+			MaxBytes:   fc.Audit.MaxBytes,
+			MaxBackups: fc.Audit.MaxBackups,
+		},
+		Enforcement: EnforcementConfig{
+			ShutdownOnKill: fc.Enforcement.ShutdownOnKill,
+			ShutdownOnLock: fc.Enforcement.ShutdownOnLock,
+			ShutdownDelay:  time.Duration(fc.Enforcement.ShutdownDelay),
+		},
+	}
+
+	readFile := func(name string) ([]byte, error) {
+		if !filepath.IsAbs(name) && baseDir != "" {
+			name = filepath.Join(baseDir, name)
+		}
+		return os.ReadFile(name)
+	}
+	switch {
+	case fc.PublicKeyPEM != "":
+		cfg.PublicKeyPEM = []byte(fc.PublicKeyPEM)
+	case fc.PublicKeyFile != "":
+		pem, err := readFile(fc.PublicKeyFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("public_key_file: %w", err)
+		}
+		cfg.PublicKeyPEM = pem
+	}
+	for _, name := range fc.LegacyPublicKeyFiles {
+		pem, err := readFile(name)
+		if err != nil {
+			return Config{}, fmt.Errorf("legacy_public_key_files: %w", err)
+		}
+		cfg.LegacyPublicKeysPEM = append(cfg.LegacyPublicKeysPEM, pem)
+	}
+	if fc.Transport.RootCAsFile != "" {
+		pem, err := readFile(fc.Transport.RootCAsFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("transport.root_cas_file: %w", err)
+		}
+		cfg.Transport.RootCAsPEM = pem
+	}
+
+	for i, mc := range fc.ManagedComponents {
+		if mc.Slug == "" || mc.Dir == "" {
+			return Config{}, fmt.Errorf("managed_components[%d]: slug and dir are required", i)
+		}
+		strategy, err := parseUpdateStrategy(mc.Strategy)
+		if err != nil {
+			return Config{}, fmt.Errorf("managed_components[%d]: %w", i, err)
+		}
+		restart, err := parseRestartMode(mc.Restart)
+		if err != nil {
+			return Config{}, fmt.Errorf("managed_components[%d]: %w", i, err)
+		}
+		cfg.ManagedComponents = append(cfg.ManagedComponents, ManagedComponent{
+			Slug:          mc.Slug,
+			Dir:           mc.Dir,
+			Strategy:      strategy,
+			ConfigPath:    mc.ConfigPath,
+			Exec:          mc.Exec,
+			Args:          mc.Args,
+			Env:           mc.Env,
+			RestartPolicy: RestartPolicy{Mode: restart},
+
+			SystemdUnit:              mc.SystemdUnit,
+			SystemdActivationTimeout: time.Duration(mc.SystemdActivationTimeout),
+			BlueGreen:                mc.BlueGreen,
+			KeepVersions:             mc.KeepVersions,
+		})
+	}
+	return cfg, nil
+}
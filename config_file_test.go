@@ -0,0 +1,149 @@
+package sdk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_Formats(t *testing.T) {
+	files := map[string]string{
+		"guard.yaml": `
+server_url: https://license.example.com
+license_key: LIC-FILE
+project_slug: demo-project
+component_slug: backend
+heartbeat_interval: 30m
+grace:
+  max_offline: 48h
+ota:
+  enabled: true
+  check_interval: 2h
+managed_components:
+  - slug: web
+    dir: /srv/www
+    strategy: frontend
+`,
+		"guard.json": `{
+  "server_url": "https://license.example.com",
+  "license_key": "LIC-FILE",
+  "project_slug": "demo-project",
+  "component_slug": "backend",
+  "heartbeat_interval": "30m",
+  "grace": {"max_offline": "48h"},
+  "ota": {"enabled": true, "check_interval": "2h"},
+  "managed_components": [{"slug": "web", "dir": "/srv/www", "strategy": "frontend"}]
+}`,
+		"guard.toml": `
+server_url = "https://license.example.com"
+license_key = "LIC-FILE"
+project_slug = "demo-project"
+component_slug = "backend"
+heartbeat_interval = "30m"
+
+[grace]
+max_offline = "48h"
+
+[ota]
+enabled = true
+check_interval = "2h"
+
+[[managed_components]]
+slug = "web"
+dir = "/srv/www"
+strategy = "frontend"
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfigFile(t, name, content))
+			if err != nil {
+				t.Fatalf("load config: %v", err)
+			}
+			if cfg.ServerURL != "https://license.example.com" || cfg.LicenseKey != "LIC-FILE" || cfg.ProjectSlug != "demo-project" {
+				t.Fatalf("unexpected identity fields %+v", cfg)
+			}
+			if cfg.HeartbeatInterval != 30*time.Minute || cfg.GracePolicy.MaxOfflineDuration != 48*time.Hour {
+				t.Fatalf("unexpected durations %v %v", cfg.HeartbeatInterval, cfg.GracePolicy.MaxOfflineDuration)
+			}
+			if !cfg.OTA.Enabled || cfg.OTA.CheckInterval != 2*time.Hour {
+				t.Fatalf("unexpected OTA config %+v", cfg.OTA)
+			}
+			if len(cfg.ManagedComponents) != 1 || cfg.ManagedComponents[0].Slug != "web" || cfg.ManagedComponents[0].Strategy != UpdateFrontend {
+				t.Fatalf("unexpected managed components %+v", cfg.ManagedComponents)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EnvOverridesFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "server.pem"), []byte("PEM"), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	path := filepath.Join(dir, "guard.yaml")
+	if err := os.WriteFile(path, []byte("license_key: LIC-FILE\npublic_key_file: server.pem\nheartbeat_interval: 30m\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("BANYANHUB_LICENSE_KEY", "LIC-ENV")
+	t.Setenv("BANYANHUB_HEARTBEAT_INTERVAL", "5m")
+	t.Setenv("BANYANHUB_OTA_ENABLED", "true")
+	t.Setenv("BANYANHUB_PINNED_SPKI_HASHES", "pin-a, pin-b")
+	t.Setenv("BANYANHUB_MANAGED_COMPONENTS", `api=/opt/app,web:frontend=C:\www`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.LicenseKey != "LIC-ENV" || cfg.HeartbeatInterval != 5*time.Minute || !cfg.OTA.Enabled {
+		t.Fatalf("env overrides not applied: %+v", cfg)
+	}
+	if string(cfg.PublicKeyPEM) != "PEM" {
+		t.Fatalf("expected public key read relative to the config file, got %q", cfg.PublicKeyPEM)
+	}
+	if len(cfg.PinnedSPKIHashes) != 2 || cfg.PinnedSPKIHashes[1] != "pin-b" {
+		t.Fatalf("unexpected pins %v", cfg.PinnedSPKIHashes)
+	}
+	mcs := cfg.ManagedComponents
+	if len(mcs) != 2 || mcs[0].Strategy != UpdateBackend || mcs[1].Slug != "web" || mcs[1].Dir != `C:\www` || mcs[1].Strategy != UpdateFrontend {
+		t.Fatalf("unexpected managed components %+v", mcs)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name, file, content, env, want string
+	}{
+		{"unknown key", "guard.yaml", "licence_key: typo\n", "", "licence_key"},
+		{"bad duration", "guard.json", `{"heartbeat_interval": "soon"}`, "", `invalid duration "soon"`},
+		{"bad strategy", "guard.toml", "[[managed_components]]\nslug = \"web\"\ndir = \"/srv\"\nstrategy = \"sidecar\"\n", "", "sidecar"},
+		{"bad format", "guard.ini", "", "", "unsupported config format"},
+		{"bad env duration", "", "", "BANYANHUB_OTA_CHECK_INTERVAL", "BANYANHUB_OTA_CHECK_INTERVAL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.file != "" {
+				path = writeConfigFile(t, tt.file, tt.content)
+			}
+			if tt.env != "" {
+				t.Setenv(tt.env, "later")
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package sdk
 import (
 	"errors"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,6 +16,10 @@ func TestConfig_SetDefaults(t *testing.T) {
 		t.Errorf("HeartbeatInterval: expected 1h, got %v", cfg.HeartbeatInterval)
 	}
 
+	if cfg.HeartbeatMinInterval != time.Minute || cfg.HeartbeatMaxInterval != 24*time.Hour {
+		t.Errorf("heartbeat clamps: expected 1m..24h, got %v..%v", cfg.HeartbeatMinInterval, cfg.HeartbeatMaxInterval)
+	}
+
 	if cfg.GracePolicy.MaxOfflineDuration != 72*time.Hour {
 		t.Errorf("MaxOfflineDuration: expected 72h, got %v", cfg.GracePolicy.MaxOfflineDuration)
 	}
@@ -202,3 +207,87 @@ func TestServerURLForPath(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_TimeoutDefaults(t *testing.T) {
+	cfg := Config{
+		OTA:      OTAConfig{DownloadTimeout: 20 * time.Minute},
+		Timeouts: TimeoutConfig{Heartbeat: 5 * time.Second},
+	}
+	cfg.setDefaults()
+
+	want := TimeoutConfig{
+		Verify:    30 * time.Second,
+		Heartbeat: 5 * time.Second,
+		API:       30 * time.Second,
+		Download:  20 * time.Minute,
+		Upload:    10 * time.Minute,
+	}
+	if cfg.Timeouts != want {
+		t.Fatalf("Timeouts = %+v, want %+v", cfg.Timeouts, want)
+	}
+}
+
+func validTestConfig() Config {
+	return Config{
+		LicenseKey:    "LIC-1",
+		PublicKeyPEM:  []byte("pem"),
+		ProjectSlug:   "demo-project",
+		ComponentSlug: "backend",
+	}
+}
+
+func TestConfig_ValidateAcceptsDefaults(t *testing.T) {
+	cfg := validTestConfig()
+	cfg.GracePolicy.RecoveryInterval = -1 // disables recovery
+	cfg.ManagedComponents = []ManagedComponent{
+		{Slug: "api", Dir: "/opt/app/api"},
+		{Slug: "web", Dir: "/opt/app/web", Strategy: UpdateFrontend},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+}
+
+func TestConfig_ValidateReportsEveryProblem(t *testing.T) {
+	cfg := validTestConfig()
+	cfg.LicenseKey = ""
+	cfg.ServerURL = "ftp://license.example.com"
+	cfg.HeartbeatInterval = -time.Minute
+	cfg.Timeouts.API = -time.Second
+	cfg.OTA.MaxArtifactBytes = 1 << 40
+	cfg.ManagedComponents = []ManagedComponent{
+		{Slug: "api"},
+		{Slug: "web", Dir: "/srv/www", Strategy: UpdateFrontend},
+		{Slug: "web", Dir: "/srv/www/assets", Strategy: UpdateFrontend},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	if !errors.Is(err, ErrInvalidServerURL) {
+		t.Fatalf("expected ErrInvalidServerURL in %v", err)
+	}
+	for _, want := range []string{
+		"license_key is required",
+		"heartbeat_interval must not be negative",
+		"timeouts.api must not be negative",
+		"ota.max_artifact_bytes",
+		"managed_components[0] (api): dir is required",
+		"managed_components[2] (web): slug also used by managed_components[1]",
+		"managed_components[2] (web): dir /srv/www/assets overlaps managed_components[1]",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestNew_RejectsInvalidConfig(t *testing.T) {
+	cfg := validTestConfig()
+	cfg.HeartbeatMinInterval = time.Hour
+	cfg.HeartbeatMaxInterval = time.Minute
+	if _, err := New(cfg); err == nil || !strings.Contains(err.Error(), "heartbeat_max_interval") {
+		t.Fatalf("expected heartbeat bounds error, got %v", err)
+	}
+}
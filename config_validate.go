@@ -0,0 +1,244 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxArtifactBytesLimit caps OTA.MaxArtifactBytes; an artifact is staged in a
+// temporary file before it is verified, so larger limits are almost
+// certainly a unit mistake.
+const maxArtifactBytesLimit = 16 << 30 // 16GB
+
+// Validate checks c for mistakes New would otherwise accept silently or
+// trip over later, and returns every problem found joined into one error.
+// Zero values are valid wherever a default applies. New calls Validate, so
+// calling it directly is only needed to report problems before building a
+// guard, for example after LoadConfig.
+func (c Config) Validate() error {
+	var errs []error
+	addf := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.LicenseKey == "" {
+		addf("license_key is required")
+	}
+	if c.PublicKeyPEM == nil {
+		addf("public_key_pem is required")
+	}
+	if c.ProjectSlug == "" {
+		addf("project_slug is required")
+	}
+	if c.ComponentSlug == "" {
+		addf("component_slug is required")
+	}
+	if c.ServerURL != "" {
+		if _, err := normalizeServerURL(c.ServerURL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for prefix, raw := range c.Transport.EndpointURLs {
+		if !strings.HasPrefix(prefix, "/") {
+			addf("transport.endpoint_urls: prefix %q must start with /", prefix)
+		}
+		if _, err := normalizeServerURL(raw); err != nil || strings.TrimSpace(raw) == "" {
+			addf("transport.endpoint_urls[%q]: %q is not an absolute http(s) URL", prefix, raw)
+		}
+	}
+	for host, target := range c.Transport.HostOverrides {
+		if strings.TrimSpace(host) == "" || strings.TrimSpace(target) == "" {
+			addf("transport.host_overrides: %q -> %q needs a host and a target", host, target)
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"heartbeat_interval", c.HeartbeatInterval},
+		{"heartbeat_min_interval", c.HeartbeatMinInterval},
+		{"heartbeat_max_interval", c.HeartbeatMaxInterval},
+		{"license_cache_ttl", c.LicenseCacheTTL},
+		{"feedback_poll_interval", c.FeedbackPollInterval},
+		{"grace.max_offline", c.GracePolicy.MaxOfflineDuration},
+		{"grace.warning_interval", c.GracePolicy.WarningInterval},
+		{"grace.network_max_offline", c.GracePolicy.NetworkMaxOffline},
+		{"grace.server_error_max_offline", c.GracePolicy.ServerErrorMaxOffline},
+		{"grace.license_error_max_offline", c.GracePolicy.LicenseErrorMaxOffline},
+		{"ota.check_interval", c.OTA.CheckInterval},
+		{"ota.download_timeout", c.OTA.DownloadTimeout},
+		{"push.min_reconnect_delay", c.Push.MinReconnectDelay},
+		{"push.max_reconnect_delay", c.Push.MaxReconnectDelay},
+		{"push.min_heartbeat_interval", c.Push.MinHeartbeatInterval},
+		{"retry.initial_backoff", c.Retry.InitialBackoff},
+		{"retry.max_backoff", c.Retry.MaxBackoff},
+		{"client_cert.renew_before", c.ClientCert.RenewBefore},
+		{"enforcement.shutdown_delay", c.Enforcement.ShutdownDelay},
+		{"timeouts.verify", c.Timeouts.Verify},
+		{"timeouts.heartbeat", c.Timeouts.Heartbeat},
+		{"timeouts.api", c.Timeouts.API},
+		{"timeouts.download", c.Timeouts.Download},
+		{"timeouts.upload", c.Timeouts.Upload},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			addf("%s must not be negative, got %s", d.name, d.value)
+		}
+	}
+	for _, w := range c.LicenseExpiryWarnings {
+		if w <= 0 {
+			addf("license_expiry_warnings must be positive, got %s", w)
+		}
+	}
+	if c.HeartbeatMinInterval > 0 && c.HeartbeatMaxInterval > 0 && c.HeartbeatMaxInterval < c.HeartbeatMinInterval {
+		addf("heartbeat_max_interval %s is below heartbeat_min_interval %s", c.HeartbeatMaxInterval, c.HeartbeatMinInterval)
+	}
+	if c.Push.MinReconnectDelay > 0 && c.Push.MaxReconnectDelay > 0 && c.Push.MaxReconnectDelay < c.Push.MinReconnectDelay {
+		addf("push.max_reconnect_delay %s is below push.min_reconnect_delay %s", c.Push.MaxReconnectDelay, c.Push.MinReconnectDelay)
+	}
+	if c.Retry.InitialBackoff > 0 && c.Retry.MaxBackoff > 0 && c.Retry.MaxBackoff < c.Retry.InitialBackoff {
+		addf("retry.max_backoff %s is below retry.initial_backoff %s", c.Retry.MaxBackoff, c.Retry.InitialBackoff)
+	}
+	if c.Retry.MaxAttempts < 0 {
+		addf("retry.max_attempts must not be negative, got %d", c.Retry.MaxAttempts)
+	}
+	if c.GracePolicy.LicenseEscalateAfter < 0 {
+		addf("grace.license_escalate_after must not be negative, got %d", c.GracePolicy.LicenseEscalateAfter)
+	}
+	if c.OTA.MigrationFailure != MigrationAbort && c.OTA.MigrationFailure != MigrationContinue {
+		addf("unknown ota.migration_failure policy %d", c.OTA.MigrationFailure)
+	}
+	if c.OTA.MaxArtifactBytes < 0 || c.OTA.MaxArtifactBytes > maxArtifactBytesLimit {
+		addf("ota.max_artifact_bytes must be between 0 and %d, got %d", int64(maxArtifactBytesLimit), c.OTA.MaxArtifactBytes)
+	}
+
+	switch c.Transport.Protocol {
+	case "", TransportHTTP, TransportGRPC:
+	default:
+		addf("unsupported transport protocol %q", c.Transport.Protocol)
+	}
+	if c.Transport.ProxyURL != "" {
+		if proxy, err := url.Parse(c.Transport.ProxyURL); err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			addf("transport.proxy_url %q must be an absolute URL", c.Transport.ProxyURL)
+		}
+	}
+
+	errs = append(errs, validateManagedComponents(c.ManagedComponents)...)
+	return errors.Join(errs...)
+}
+
+// validateManagedComponents reports components without a slug or target,
+// unknown strategies, and slugs or directories claimed twice. Directories
+// overlap when one contains the other, since updating the outer one would
+// replace the inner.
+func validateManagedComponents(components []ManagedComponent) []error {
+	var errs []error
+	slugs := make(map[string]int, len(components))
+	dirs := make([]string, len(components))
+	for i, mc := range components {
+		name := fmt.Sprintf("managed_components[%d]", i)
+		if mc.Slug != "" {
+			name += " (" + mc.Slug + ")"
+		}
+		slug := strings.TrimSpace(mc.Slug)
+		if slug == "" {
+			errs = append(errs, fmt.Errorf("%s: slug is required", name))
+		} else if j, ok := slugs[slug]; ok {
+			errs = append(errs, fmt.Errorf("%s: slug also used by managed_components[%d]", name, j))
+		} else {
+			slugs[slug] = i
+		}
+
+		switch mc.Strategy {
+		case UpdateBackend:
+			if strings.TrimSpace(mc.Dir) == "" {
+				errs = append(errs, fmt.Errorf("%s: dir is required for the backend strategy", name))
+			}
+		case UpdateFrontend:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown update strategy %d", name, mc.Strategy))
+		}
+		errs = append(errs, validateSupervision(name, mc)...)
+		if strings.TrimSpace(mc.SystemdUnit) != "" {
+			if mc.Strategy != UpdateBackend {
+				errs = append(errs, fmt.Errorf("%s: systemd_unit requires the backend strategy", name))
+			}
+			if strings.TrimSpace(mc.Exec) != "" {
+				errs = append(errs, fmt.Errorf("%s: exec and systemd_unit are mutually exclusive", name))
+			}
+		}
+		if mc.Migrate != nil && mc.Strategy != UpdateBackend {
+			errs = append(errs, fmt.Errorf("%s: migrate requires the backend strategy", name))
+		}
+		if mc.MigrationFailure != MigrationAbort && mc.MigrationFailure != MigrationContinue {
+			errs = append(errs, fmt.Errorf("%s: unknown migration failure policy %d", name, mc.MigrationFailure))
+		}
+		if mc.BlueGreen && mc.Strategy != UpdateFrontend {
+			errs = append(errs, fmt.Errorf("%s: blue_green requires the frontend strategy", name))
+		}
+		if mc.KeepVersions < 0 {
+			errs = append(errs, fmt.Errorf("%s: keep_versions must not be negative, got %d", name, mc.KeepVersions))
+		}
+		if mc.SystemdActivationTimeout < 0 {
+			errs = append(errs, fmt.Errorf("%s: systemd_activation_timeout must not be negative, got %s", name, mc.SystemdActivationTimeout))
+		}
+
+		if strings.TrimSpace(mc.Dir) == "" {
+			continue
+		}
+		dirs[i] = filepath.Clean(mc.Dir)
+		for j := 0; j < i; j++ {
+			if dirs[j] != "" && pathsOverlap(dirs[i], dirs[j]) {
+				errs = append(errs, fmt.Errorf("%s: dir %s overlaps managed_components[%d] dir %s", name, dirs[i], j, dirs[j]))
+			}
+		}
+	}
+	return errs
+}
+
+// validateSupervision reports process supervision set on a component the
+// guard cannot run, and invalid restart policies.
+func validateSupervision(name string, mc ManagedComponent) []error {
+	var errs []error
+	if strings.TrimSpace(mc.Exec) == "" {
+		if len(mc.Args) > 0 || len(mc.Env) > 0 {
+			errs = append(errs, fmt.Errorf("%s: args and env require exec", name))
+		}
+		return errs
+	}
+	if mc.Strategy != UpdateBackend {
+		errs = append(errs, fmt.Errorf("%s: exec requires the backend strategy", name))
+	}
+	policy := mc.RestartPolicy
+	switch policy.Mode {
+	case RestartOnFailure, RestartAlways, RestartNever:
+	default:
+		errs = append(errs, fmt.Errorf("%s: unknown restart mode %d", name, policy.Mode))
+	}
+	if policy.MaxRestarts < 0 {
+		errs = append(errs, fmt.Errorf("%s: restart_policy.max_restarts must not be negative, got %d", name, policy.MaxRestarts))
+	}
+	if policy.Delay < 0 {
+		errs = append(errs, fmt.Errorf("%s: restart_policy.delay must not be negative, got %s", name, policy.Delay))
+	}
+	if policy.StopTimeout < 0 {
+		errs = append(errs, fmt.Errorf("%s: restart_policy.stop_timeout must not be negative, got %s", name, policy.StopTimeout))
+	}
+	return errs
+}
+
+// pathsOverlap reports whether a and b are the same path or one contains
+// the other.
+func pathsOverlap(a, b string) bool {
+	return a == b || isWithin(a, b) || isWithin(b, a)
+}
+
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
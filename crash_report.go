@@ -0,0 +1,98 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	// maxCrashStackBytes bounds the stack trace sent with a crash report.
+	maxCrashStackBytes = 32 << 10
+	maxCrashTitleRunes = 120
+)
+
+// crashDiagnostics is appended to a crash report as JSON.
+type crashDiagnostics struct {
+	AppVersion    string              `json:"app_version"`
+	SDKVersion    string              `json:"sdk_version"`
+	GoVersion     string              `json:"go_version"`
+	State         string              `json:"state"`
+	UpdateHistory []updateStatsReport `json:"update_history"`
+	AuxSignals    map[string]string   `json:"aux_signals"`
+}
+
+// ReportPanic files a FeedbackCrash report for a recovered panic. The report
+// carries the panic value and stack, the app version, the recent update
+// attempts and the aux signals in hashed form; license keys, the machine ID
+// and Config.RedactPatterns matches are masked. A nil stack is taken from the
+// calling goroutine.
+func (g *Guard) ReportPanic(recovered any, stack []byte) (*FeedbackItem, error) {
+	if stack == nil {
+		stack = debug.Stack()
+	}
+	// Redact before truncating, so a cut cannot split a secret the redactor
+	// would no longer recognize.
+	trace := truncateUTF8(g.redactor.String(string(stack)), maxCrashStackBytes)
+	message := g.redactor.String(fmt.Sprint(recovered))
+
+	g.mu.RLock()
+	history := updateStatsReports(g.updateHistory)
+	g.mu.RUnlock()
+	diagnostics, err := json.MarshalIndent(crashDiagnostics{
+		AppVersion:    g.currentVersion(),
+		SDKVersion:    Version,
+		GoVersion:     runtime.Version(),
+		State:         g.State().String(),
+		UpdateHistory: history,
+		AuxSignals:    g.fingerprint.withPrivacy(FingerprintConfig{PrivacyMode: FingerprintPrivacyHashed}).AuxSignals(),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal diagnostics: %w", err)
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "panic: %s\n\n", message)
+	content.WriteString(trace)
+	content.WriteString("\n\ndiagnostics:\n")
+	content.Write(diagnostics)
+
+	title := []rune("Crash: " + firstLine(message))
+	if len(title) > maxCrashTitleRunes {
+		title = append(title[:maxCrashTitleRunes-1], '…')
+	}
+	return g.SubmitFeedback(context.Background(), SubmitFeedbackRequest{
+		UserID:     "machine:" + g.fingerprint.MachineID(),
+		UserName:   hostname(),
+		Category:   FeedbackCrash,
+		Title:      string(title),
+		Content:    content.String(),
+		AppVersion: g.currentVersion(),
+	})
+}
+
+// CapturePanics runs fn and, if it panics, files a crash report through
+// ReportPanic before re-panicking with the same value, so the process still
+// fails as it would have. Use it at the top of main and of long-lived
+// goroutines.
+func (g *Guard) CapturePanics(fn func()) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if _, err := g.ReportPanic(recovered, debug.Stack()); err != nil {
+				g.logger.Error("failed to submit crash report", "error", err)
+			}
+			panic(recovered)
+		}
+	}()
+	fn()
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package sdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestCapturePanicsSubmitsRedactedCrashReport(t *testing.T) {
+	var submitted submitFeedbackBody
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/feedbacks" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&submitted)
+		_ = json.NewEncoder(w).Encode(FeedbackItem{ID: "fb-crash", Category: FeedbackCrash})
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	guard.httpClient = srv.Client()
+	guard.finishUpdateStats(newUpdateStats("backend", "1.0.0", "1.1.0"), time.Now(), nil)
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered == nil {
+				t.Fatal("expected CapturePanics to re-panic")
+			}
+		}()
+		guard.CapturePanics(func() { panic("boom with LIC-FEEDBACK-001") })
+	}()
+
+	if submitted.Category != FeedbackCrash {
+		t.Fatalf("expected crash category, got %q", submitted.Category)
+	}
+	if strings.Contains(submitted.Content, "LIC-FEEDBACK-001") || strings.Contains(submitted.Title, "LIC-FEEDBACK-001") {
+		t.Fatal("expected the license key to be redacted")
+	}
+	if !strings.HasPrefix(submitted.Title, "Crash: boom with") {
+		t.Fatalf("unexpected title: %q", submitted.Title)
+	}
+	for _, want := range []string{"crash_report_test.go", `"new_version": "1.1.0"`, `"os": "`} {
+		if !strings.Contains(submitted.Content, want) {
+			t.Errorf("expected %q in crash report:\n%s", want, submitted.Content)
+		}
+	}
+	if _, ok := guard.fingerprint.AuxSignals()["cpu_cores"]; ok && !strings.Contains(submitted.Content, `"cpu_cores": "hmac:`) {
+		t.Error("expected aux signals other than os and arch to be hashed")
+	}
+}
+
+func TestReportPanicRedactsBeforeTruncatingStack(t *testing.T) {
+	var submitted submitFeedbackBody
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&submitted)
+		_ = json.NewEncoder(w).Encode(FeedbackItem{ID: "fb-crash", Category: FeedbackCrash})
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	guard.httpClient = srv.Client()
+	// The key straddles the stack limit, behind multi-byte runes.
+	padding := strings.Repeat("é", (maxCrashStackBytes-8)/2)
+	stack := []byte(padding + "LIC-FEEDBACK-001 in frame")
+	if _, err := guard.ReportPanic("boom", stack); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(submitted.Content, "LIC-FEED") {
+		t.Fatal("a truncated license key leaked into the crash report")
+	}
+	if !utf8.ValidString(submitted.Content) {
+		t.Fatal("crash report stack cut inside a rune")
+	}
+}
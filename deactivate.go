@@ -0,0 +1,189 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type deactivateRequestBody struct {
+	LicenseKey    string `json:"license_key,omitempty"`
+	MachineID     string `json:"machine_id"`
+	ProjectSlug   string `json:"project_slug"`
+	ComponentSlug string `json:"component_slug"`
+}
+
+// Deactivate releases this machine's seat on the server, for example when
+// the machine is decommissioned. On success it stops the heartbeat, wipes
+// the local license cache and moves the guard to DEACTIVATED, a terminal
+// state in which Check returns ErrDeactivated. The audit log and recorded
+// responses are kept. If the server call fails nothing local changes, so the
+// call can be retried.
+func (g *Guard) Deactivate(ctx context.Context) error {
+	if err := g.requireInitialized(true, true); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(deactivateRequestBody{
+		LicenseKey:    g.bodyLicenseKey(),
+		MachineID:     g.fingerprint.MachineID(),
+		ProjectSlug:   g.cfg.ProjectSlug,
+		ComponentSlug: g.cfg.ComponentSlug,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	reqCtx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
+	if _, err := g.postJSON(reqCtx, "/api/v1/deactivate", body); err != nil {
+		g.auditOutcome(AuditDeactivation, g.cfg.ComponentSlug, err, "")
+		return err
+	}
+
+	g.Stop()
+	g.cancelScheduledKill()
+	g.mu.Lock()
+	g.seats = nil
+	g.mu.Unlock()
+	wipeErr := g.store.Clear()
+	if err := wipeLicenseCache(g.cfg); err != nil && wipeErr == nil {
+		wipeErr = err
+	}
+	g.clientCert.Store(nil)
+	g.sm.OnDeactivated()
+	g.auditOutcome(AuditDeactivation, g.cfg.ComponentSlug, wipeErr, "")
+	if wipeErr != nil {
+		return fmt.Errorf("seat released but local license cache not wiped: %w", wipeErr)
+	}
+	return nil
+}
+
+// DeactivationOptions configures a package-level Deactivate call.
+type DeactivationOptions struct {
+	ServerURL     string
+	LicenseKey    string
+	ProjectSlug   string
+	ComponentSlug string
+	// Context bounds the request; the default times out after 30 seconds.
+	Context          context.Context
+	HTTPClient       *http.Client
+	AllowSystemTrust bool
+	PinnedSPKIHashes []string
+	Transport        TransportConfig
+	UserAgent        string
+	// CacheDir and CacheStore must match the guard's Config so the right
+	// license cache is wiped.
+	CacheDir   string
+	CacheStore CacheStore
+}
+
+// Deactivate releases this machine's seat without a Guard, e.g. from an
+// uninstaller, and wipes the local license cache for projectSlug and
+// componentSlug. If serverURL is empty, DefaultServerURL is used.
+func Deactivate(serverURL, licenseKey, projectSlug, componentSlug string) error {
+	return DeactivateWithOptions(DeactivationOptions{
+		ServerURL:        serverURL,
+		LicenseKey:       licenseKey,
+		ProjectSlug:      projectSlug,
+		ComponentSlug:    componentSlug,
+		AllowSystemTrust: true,
+	})
+}
+
+// DeactivateWithOptions is Deactivate with explicit transport and request
+// controls.
+func DeactivateWithOptions(opts DeactivationOptions) error {
+	if opts.LicenseKey == "" {
+		return fmt.Errorf("license key is required")
+	}
+	if opts.ProjectSlug == "" || opts.ComponentSlug == "" {
+		return fmt.Errorf("project and component slugs are required")
+	}
+	serverURL, err := normalizeServerURL(opts.ServerURL)
+	if err != nil {
+		return err
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), defaultActivationTimeout)
+		defer cancel()
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client, err = newPinnedHTTPClient(Config{
+			ServerURL:        serverURL,
+			AllowSystemTrust: opts.AllowSystemTrust,
+			PinnedSPKIHashes: opts.PinnedSPKIHashes,
+			Transport:        opts.Transport,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fp, err := collectFingerprint()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(deactivateRequestBody{
+		LicenseKey:    opts.LicenseKey,
+		MachineID:     fp.MachineID(),
+		ProjectSlug:   opts.ProjectSlug,
+		ComponentSlug: opts.ComponentSlug,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL+"/api/v1/deactivate", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", activationUserAgent(opts.UserAgent))
+	digest := sha256.Sum256(data)
+	setRequestAuthHeaders(req, requestKey{licenseKey: opts.LicenseKey}, hex.EncodeToString(digest[:]))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNetworkError, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decodeAPIErrorResponse(resp)
+	}
+
+	cfg := Config{
+		ProjectSlug:   opts.ProjectSlug,
+		ComponentSlug: opts.ComponentSlug,
+		CacheDir:      opts.CacheDir,
+		CacheStore:    opts.CacheStore,
+	}
+	if err := cacheStoreFor(cfg).Delete(stateFileName); err != nil {
+		return fmt.Errorf("seat released but local license cache not wiped: %w", err)
+	}
+	if err := wipeLicenseCache(cfg); err != nil {
+		return fmt.Errorf("seat released but local license cache not wiped: %w", err)
+	}
+	return nil
+}
+
+// wipeLicenseCache deletes the binding record and every sealed secret; the
+// state entry is cleared by the caller.
+func wipeLicenseCache(cfg Config) error {
+	store := cacheStoreFor(cfg)
+	var errs []error
+	errs = append(errs, store.Delete(bindingFileName))
+	for _, name := range secretNames {
+		errs = append(errs, store.Delete(secretEntryName(name)))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeactivateReleasesSeatAndWipesCache(t *testing.T) {
+	var got deactivateRequestBody
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/deactivate" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})
+	g.sm.OnVerifySuccess()
+	if err := g.store.Save(&persistedState{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Deactivate(context.Background()); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected one request, got %d", calls.Load())
+	}
+	if got.MachineID != testingMachineID || got.ProjectSlug != "test-project" || got.ComponentSlug != "test-component" {
+		t.Fatalf("unexpected request body: %#v", got)
+	}
+	if g.State() != StateDeactivated {
+		t.Fatalf("expected DEACTIVATED, got %s", g.State())
+	}
+	if err := g.Check(); !errors.Is(err, ErrDeactivated) {
+		t.Fatalf("expected ErrDeactivated, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(g.cfg.cacheDir, stateFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected state file to be removed, got %v", err)
+	}
+
+	g.sm.OnVerifySuccess()
+	if g.State() != StateDeactivated {
+		t.Fatalf("DEACTIVATED must be terminal, got %s", g.State())
+	}
+}
+
+func TestDeactivateServerErrorKeepsState(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":"license_revoked"}`))
+	})
+	g.sm.OnVerifySuccess()
+	if err := g.store.Save(&persistedState{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Deactivate(context.Background()); err == nil {
+		t.Fatal("expected an error from the server")
+	}
+	if g.State() != StateActive {
+		t.Fatalf("expected ACTIVE after a failed deactivation, got %s", g.State())
+	}
+	if _, err := os.Stat(filepath.Join(g.cfg.cacheDir, stateFileName)); err != nil {
+		t.Fatalf("expected state file to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// devModeWarning is logged by every call a development-mode guard bypasses.
+const devModeWarning = "licensing bypassed: guard is in development mode"
+
+// NewDevMode builds a Guard for running an application on a developer
+// machine without a license server. It is the explicit opt-in for bypassing
+// licensing and must never be reachable from a production build; gate the
+// call behind a build tag or a flag only developers set.
+//
+// The guard starts ACTIVE and Check always succeeds. Start launches no
+// heartbeat, push channel or OTA updates, and every server call, including
+// feedback, plugins and update downloads, fails with ErrDevMode. features
+// are reported as entitled by CheckFeatureMatrix. There is no lease, so
+// FeatureToken and Unseal fail.
+//
+// The guard logs through slog.Default until SetLogger is called: a warning
+// when it is built, on Start and on each bypassed server call, and a debug
+// record on each Check.
+func NewDevMode(cfg Config, features ...string) (*Guard, error) {
+	cfg.OTA.Enabled = false
+	cfg.Push.Enabled = false
+	g, err := NewForTesting(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	entitled := make(map[string]bool, len(features))
+	for _, name := range features {
+		entitled[name] = true
+	}
+	g.devMode = true
+	g.api = devModeTransport{g: g}
+	g.entitledFeatures = entitled
+	g.logger = newRedactingLogger(slog.Default(), g.redactor)
+	g.sm.OnVerifySuccess()
+	g.logger.Warn(devModeWarning, "project", g.cfg.ProjectSlug, "features", features)
+	return g, nil
+}
+
+// DevMode reports whether the guard was built with NewDevMode, so an
+// application can show that licensing is bypassed.
+func (g *Guard) DevMode() bool {
+	return g != nil && g.devMode
+}
+
+// devModeTransport refuses every server call of a development-mode guard.
+type devModeTransport struct {
+	g *Guard
+}
+
+func (t devModeTransport) Call(_ context.Context, req TransportRequest) ([]byte, error) {
+	t.g.logger.Warn(devModeWarning, "call", req.Method+" "+req.Path)
+	return nil, ErrDevMode
+}
+
+func (t devModeTransport) FetchArtifact(_ context.Context, url string) (io.ReadCloser, int64, error) {
+	t.g.logger.Warn(devModeWarning, "call", "download "+url)
+	return nil, 0, ErrDevMode
+}
@@ -0,0 +1,61 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewDevMode_BypassesLicensing(t *testing.T) {
+	guard, err := NewDevMode(Config{OTA: OTAConfig{Enabled: true}}, "reports")
+	if err != nil {
+		t.Fatalf("new dev mode guard: %v", err)
+	}
+	var logs bytes.Buffer
+	guard.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	if !guard.DevMode() {
+		t.Fatal("expected DevMode to report true")
+	}
+	if err := guard.Check(); err != nil {
+		t.Fatalf("check before start: %v", err)
+	}
+	if err := guard.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer guard.Stop()
+	if guard.State() != StateActive {
+		t.Fatalf("expected active guard, got %s", guard.State())
+	}
+	if guard.cfg.OTA.Enabled || guard.heartbeatDone != nil {
+		t.Fatal("expected OTA and heartbeats to be disabled")
+	}
+
+	matrix := guard.CheckFeatureMatrix("reports", "sso")
+	if !matrix["reports"].Enabled || matrix["sso"].Enabled {
+		t.Fatalf("unexpected feature matrix %+v", matrix)
+	}
+
+	if _, err := guard.GetFeedback(context.Background(), "fb-1"); !errors.Is(err, ErrDevMode) {
+		t.Fatalf("expected ErrDevMode from server call, got %v", err)
+	}
+	if _, err := guard.GetMarketplaceCatalog(context.Background(), MarketplaceBrowseOptions{}); !errors.Is(err, ErrDevMode) {
+		t.Fatalf("expected ErrDevMode from marketplace call, got %v", err)
+	}
+	if _, _, err := guard.DownloadPluginArtifact(context.Background(), "sso", "1.0.0"); !errors.Is(err, ErrDevMode) {
+		t.Fatalf("expected ErrDevMode from download, got %v", err)
+	}
+	if got := strings.Count(logs.String(), devModeWarning); got < 3 {
+		t.Fatalf("expected start and server calls to log the bypass, got:\n%s", logs.String())
+	}
+}
+
+func TestNew_IsNotDevMode(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	if guard.DevMode() {
+		t.Fatal("guards built with New must not be in development mode")
+	}
+}
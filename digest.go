@@ -0,0 +1,128 @@
+package sdk
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Digest algorithms for update artifacts. The SDK offers every algorithm it
+// can compute in download metadata requests and the server picks one, so
+// the platform can move off SHA-256 without breaking older SDKs, which only
+// ever receive SHA-256.
+const (
+	DigestSHA256 = "sha256"
+	DigestSHA512 = "sha512"
+	// DigestBLAKE3 is offered once an implementation is registered with
+	// RegisterDigestAlgorithm.
+	DigestBLAKE3 = "blake3"
+)
+
+var (
+	digestMu         sync.RWMutex
+	digestAlgorithms = map[string]func() hash.Hash{
+		DigestSHA256: sha256.New,
+		DigestSHA512: sha512.New,
+	}
+)
+
+// RegisterDigestAlgorithm makes another artifact digest algorithm available
+// for negotiation, e.g. BLAKE3 from a package of the application's choice:
+//
+//	sdk.RegisterDigestAlgorithm(sdk.DigestBLAKE3, func() hash.Hash { return blake3.New() })
+//
+// Call it before creating the guard.
+func RegisterDigestAlgorithm(name string, newHash func() hash.Hash) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || newHash == nil {
+		return
+	}
+	digestMu.Lock()
+	defer digestMu.Unlock()
+	digestAlgorithms[name] = newHash
+}
+
+// supportedDigestAlgorithms lists the algorithms offered to the server,
+// most preferred first: BLAKE3, SHA-512, other registered ones, SHA-256.
+func supportedDigestAlgorithms() []string {
+	digestMu.RLock()
+	defer digestMu.RUnlock()
+	names := make([]string, 0, len(digestAlgorithms))
+	for name := range digestAlgorithms {
+		names = append(names, name)
+	}
+	rank := func(name string) int {
+		switch name {
+		case DigestBLAKE3:
+			return 0
+		case DigestSHA512:
+			return 1
+		case DigestSHA256:
+			return 3
+		}
+		return 2
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra - rb
+		}
+		return strings.Compare(a, b)
+	})
+	return names
+}
+
+func newDigest(algorithm string) (hash.Hash, error) {
+	digestMu.RLock()
+	newHash, ok := digestAlgorithms[algorithm]
+	digestMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported digest algorithm %q", ErrUpdateVerify, algorithm)
+	}
+	return newHash(), nil
+}
+
+// isDigestHex reports whether value is a hex digest of the algorithm's
+// size.
+func isDigestHex(algorithm, value string) bool {
+	h, err := newDigest(algorithm)
+	if err != nil || len(value) != 2*h.Size() {
+		return false
+	}
+	_, err = hex.DecodeString(value)
+	return err == nil
+}
+
+// signedDigest is what an artifact signature covers: the bare hex digest
+// for SHA-256, as always, and "<algorithm>:<hex>" for the others, so a
+// signature cannot be replayed with a digest of another algorithm.
+func signedDigest(algorithm, digest string) string {
+	if algorithm == DigestSHA256 {
+		return digest
+	}
+	return algorithm + ":" + digest
+}
+
+// artifactKey names an artifact in the cache and on the LAN: the SHA-256
+// hex digest, or "<algorithm>-<hex>" for the other algorithms.
+func artifactKey(algorithm, digest string) string {
+	if algorithm == DigestSHA256 {
+		return digest
+	}
+	return algorithm + "-" + digest
+}
+
+// parseArtifactKey reverses artifactKey, rejecting anything that is not a
+// well-formed digest.
+func parseArtifactKey(key string) (algorithm, digest string, ok bool) {
+	algorithm, digest = DigestSHA256, key
+	// Hex never contains '-', algorithm names may.
+	if i := strings.LastIndex(key, "-"); i >= 0 {
+		algorithm, digest = key[:i], key[i+1:]
+	}
+	return algorithm, digest, isDigestHex(algorithm, digest)
+}
@@ -0,0 +1,108 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestRequestDownloadMeta_NegotiatesDigestAlgorithm(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	artifact := []byte("release 2.0.0")
+	sum := sha512.Sum512(artifact)
+	digest := hex.EncodeToString(sum[:])
+	var offered []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/update/download" {
+			_, _ = w.Write(artifact)
+			return
+		}
+		var body downloadMetaRequestBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		offered = body.DigestAlgorithms
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"download_url":     "/download/worker",
+			"digest_algorithm": DigestSHA512,
+			"digest":           digest,
+			"signature":        signUpdateHash(t, privKey, DigestSHA512+":"+digest),
+		})
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	meta, err := g.requestDownloadMeta(context.Background(), "worker", "2.0.0", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("requestDownloadMeta: %v", err)
+	}
+	if !slices.Contains(offered, DigestSHA512) || offered[len(offered)-1] != DigestSHA256 {
+		t.Fatalf("offered = %v, want sha512 first and sha256 last", offered)
+	}
+	if meta.Algorithm != DigestSHA512 || meta.Digest != digest {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if err := g.verifySignature(signedDigest(meta.Algorithm, meta.Digest), meta.Signature); err != nil {
+		t.Fatalf("verifySignature: %v", err)
+	}
+	if err := g.verifySignature(meta.Digest, meta.Signature); err == nil {
+		t.Fatal("signature over sha512 digest verified without its algorithm")
+	}
+
+	path, got, err := g.fetchArtifact(context.Background(), meta.fetchRequest("worker", "2.0.0", "linux", "amd64"), "", 1<<20)
+	if err != nil {
+		t.Fatalf("fetchArtifact: %v", err)
+	}
+	defer os.Remove(path)
+	if got != digest {
+		t.Fatalf("fetched digest = %s, want %s", got, digest)
+	}
+}
+
+func TestRequestDownloadMeta_RejectsDigestAlgorithmNotOffered(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"download_url":     "/download/worker",
+			"digest_algorithm": "crc32",
+			"digest":           "deadbeef",
+			"signature":        "c2ln",
+		})
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	_, err := g.requestDownloadMeta(context.Background(), "worker", "2.0.0", "linux", "amd64")
+	if !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("err = %v, want ErrUpdateVerify", err)
+	}
+}
+
+func TestRegisterDigestAlgorithm(t *testing.T) {
+	t.Cleanup(func() {
+		digestMu.Lock()
+		delete(digestAlgorithms, "md5-test")
+		digestMu.Unlock()
+	})
+	RegisterDigestAlgorithm("MD5-Test", md5.New)
+
+	algorithms := supportedDigestAlgorithms()
+	if !slices.Contains(algorithms, "md5-test") || algorithms[len(algorithms)-1] != DigestSHA256 {
+		t.Fatalf("supported = %v", algorithms)
+	}
+	key := artifactKey("md5-test", "0123456789abcdef0123456789abcdef")
+	if algorithm, digest, ok := parseArtifactKey(key); !ok || algorithm != "md5-test" || len(digest) != 32 {
+		t.Fatalf("parseArtifactKey(%q) = %s, %s, %v", key, algorithm, digest, ok)
+	}
+	if _, _, ok := parseArtifactKey("md5-test-short"); ok {
+		t.Fatal("parseArtifactKey accepted a malformed digest")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package sdk
+
+import "errors"
+
+func diskFreeBytes(string) (int64, error) {
+	return 0, errors.New("free disk space is not available on this platform")
+}
@@ -0,0 +1,17 @@
+package sdk
+
+import "golang.org/x/sys/windows"
+
+// diskFreeBytes reports the bytes available to this process on the volume
+// holding path.
+func diskFreeBytes(path string) (int64, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(name, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return int64(free), nil
+}
@@ -0,0 +1,150 @@
+package sdk
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DownloadArtifact streams an update artifact into w, for tools that keep
+// artifacts somewhere of their own (object storage, stdout, a custom
+// installer) instead of letting the guard install them.
+//
+// meta identifies the artifact either by DownloadURL, digest and Signature,
+// e.g. from a PluginUpdatePackage, or by Component and Version alone, in
+// which case the guard requests the download metadata itself (OS and Arch
+// default to OTAConfig). The signature is checked before any byte is
+// written; the digest can only be checked at the end, so on an
+// ErrUpdateVerify error the caller must discard what w received.
+// OTAConfig.Fetcher and MaxArtifactBytes apply. progress, if set, is called
+// after every write with the bytes written and the total, -1 when unknown;
+// see NewProgressBar.
+func (g *Guard) DownloadArtifact(ctx context.Context, meta FetchRequest, w io.Writer, progress func(written, total int64)) error {
+	if err := g.requireClient(); err != nil {
+		return err
+	}
+	if meta.DownloadURL == "" {
+		if meta.Component == "" || meta.Version == "" {
+			return fmt.Errorf("%w: artifact needs a download URL or a component and version", ErrInvalidRequest)
+		}
+		meta.OS, meta.Arch = g.resolveOTAPlatform(meta.OS, meta.Arch)
+		dm, err := g.requestDownloadMeta(ctx, meta.Component, meta.Version, meta.OS, meta.Arch)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+		}
+		meta = dm.fetchRequest(meta.Component, meta.Version, meta.OS, meta.Arch)
+	}
+
+	algorithm, want := meta.digest()
+	if want == "" {
+		return fmt.Errorf("%w: artifact has no digest", ErrUpdateVerify)
+	}
+	if err := g.verifySignature(signedDigest(algorithm, want), meta.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrUpdateVerify, err)
+	}
+	hasher, err := newDigest(algorithm)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, g.otaDownloadTimeout())
+	defer cancel()
+	body, total, err := g.openArtifact(ctx, meta)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+	}
+	defer body.Close()
+	maxBytes := g.otaMaxArtifactBytes()
+	if total > maxBytes {
+		return artifactTooLargeError(maxBytes)
+	}
+
+	dst := io.MultiWriter(w, hasher)
+	if progress != nil {
+		dst = &progressWriter{w: dst, total: total, onProgress: progress}
+	}
+	if _, err := io.Copy(dst, newArtifactLimitReader(body, maxBytes)); err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+	}
+	if got := hex.EncodeToString(hasher.Sum(nil)); got != want {
+		return fmt.Errorf("%w: %s mismatch: expected %s, got %s", ErrUpdateVerify, algorithm, want, got)
+	}
+	return nil
+}
+
+// openArtifact opens req's bytes, with their length or -1 when unknown. It
+// is the one place artifacts are read from: OTAConfig.Fetcher when set,
+// else the API transport's FetchArtifact for req.DownloadURL.
+func (g *Guard) openArtifact(ctx context.Context, req FetchRequest) (io.ReadCloser, int64, error) {
+	if g.cfg.OTA.Fetcher != nil {
+		body, err := g.cfg.OTA.Fetcher.Fetch(ctx, req)
+		if err != nil {
+			return nil, 0, g.redactErr(fmt.Errorf("fetch artifact: %w", err))
+		}
+		return body, -1, nil
+	}
+	body, size, err := g.apiTransport().FetchArtifact(ctx, g.serverLinkURL(req.DownloadURL))
+	if err != nil {
+		return nil, 0, g.redactErr(fmt.Errorf("download failed: %w", err))
+	}
+	return body, size, nil
+}
+
+type progressWriter struct {
+	w          io.Writer
+	written    int64
+	total      int64
+	onProgress func(written, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if n > 0 {
+		p.written += int64(n)
+		p.onProgress(p.written, p.total)
+	}
+	return n, err
+}
+
+// NewProgressBar returns a DownloadArtifact progress callback that draws a
+// one-line text bar on w, typically os.Stderr:
+//
+//	backend  [=========>          ]  47%  12.3/26.1 MB
+//
+// It redraws only when the shown value changes and ends the line once
+// total bytes are written.
+func NewProgressBar(w io.Writer, label string) func(written, total int64) {
+	const width = 20
+	var (
+		mu   sync.Mutex
+		last string
+	)
+	return func(written, total int64) {
+		var line string
+		if total > 0 {
+			filled := min(int(written*width/total), width)
+			bar := strings.Repeat("=", filled)
+			if filled < width {
+				bar += ">" + strings.Repeat(" ", width-filled-1)
+			}
+			line = fmt.Sprintf("%s  [%s] %3d%%  %.1f/%.1f MB", label, bar, written*100/total, megabytes(written), megabytes(total))
+		} else {
+			line = fmt.Sprintf("%s  %.1f MB", label, megabytes(written))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if line == last {
+			return
+		}
+		last = line
+		fmt.Fprintf(w, "\r%s", line)
+		if total > 0 && written >= total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func megabytes(n int64) float64 { return float64(n) / (1 << 20) }
@@ -0,0 +1,104 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newDownloadTestServer(t *testing.T, artifact []byte, hashHex, signature string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/cli.bin",
+				"sha256":       hashHex,
+				"signature":    signature,
+			})
+		case "/download/cli.bin":
+			w.Header().Set("Content-Length", strconv.Itoa(len(artifact)))
+			_, _ = w.Write(artifact)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadArtifact_StreamsVerifiedArtifact(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	artifact := bytes.Repeat([]byte("artifact"), 4096)
+	hashHex := sha256Hex(artifact)
+	server := newDownloadTestServer(t, artifact, hashHex, signUpdateHash(t, privKey, hashHex))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+
+	var out bytes.Buffer
+	var written, total int64
+	err := g.DownloadArtifact(context.Background(), FetchRequest{Component: "cli", Version: "2.0.0"}, &out, func(w, tot int64) {
+		written, total = w, tot
+	})
+	if err != nil {
+		t.Fatalf("DownloadArtifact: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), artifact) {
+		t.Fatalf("streamed %d bytes, want the %d byte artifact", out.Len(), len(artifact))
+	}
+	if written != int64(len(artifact)) || total != int64(len(artifact)) {
+		t.Fatalf("progress = %d/%d, want %d/%d", written, total, len(artifact), len(artifact))
+	}
+}
+
+func TestDownloadArtifact_RejectsBadSignatureBeforeWriting(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	artifact := []byte("artifact")
+	hashHex := sha256Hex(artifact)
+	server := newDownloadTestServer(t, artifact, hashHex, signUpdateHash(t, otherKey, hashHex))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+
+	var out bytes.Buffer
+	err := g.DownloadArtifact(context.Background(), FetchRequest{Component: "cli", Version: "2.0.0"}, &out, nil)
+	if !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("err = %v, want ErrUpdateVerify", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("%d bytes written despite a bad signature", out.Len())
+	}
+}
+
+func TestDownloadArtifact_DetectsDigestMismatch(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	signed := sha256Hex([]byte("genuine"))
+	server := newDownloadTestServer(t, []byte("tampered"), signed, signUpdateHash(t, privKey, signed))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+
+	req := FetchRequest{DownloadURL: "/download/cli.bin", SHA256: signed, Signature: signUpdateHash(t, privKey, signed)}
+	err := g.DownloadArtifact(context.Background(), req, &bytes.Buffer{}, nil)
+	if !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("err = %v, want ErrUpdateVerify", err)
+	}
+}
+
+func TestNewProgressBar(t *testing.T) {
+	var out strings.Builder
+	bar := NewProgressBar(&out, "cli")
+	bar(1<<20, 4<<20)
+	bar(1<<20, 4<<20)
+	bar(4<<20, 4<<20)
+
+	want := "\rcli  [=====>              ]  25%  1.0/4.0 MB" +
+		"\rcli  [====================] 100%  4.0/4.0 MB\n"
+	if out.String() != want {
+		t.Fatalf("progress bar output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,156 @@
+package sdk
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// serverEndpoints is Config.ServerURL and Transport.EndpointURLs parsed
+// once. It remembers the ServerURL it was built from so a changed config is
+// picked up.
+type serverEndpoints struct {
+	serverURL string
+	base      *url.URL
+	// routes are the EndpointURLs overrides, longest prefix first.
+	routes []endpointRoute
+}
+
+type endpointRoute struct {
+	prefix string
+	base   *url.URL
+}
+
+func newServerEndpoints(serverURL string, overrides map[string]string) *serverEndpoints {
+	e := &serverEndpoints{serverURL: serverURL}
+	if base, err := url.Parse(serverURL); err == nil && base.Host != "" {
+		e.base = base
+	}
+	for prefix, raw := range overrides {
+		normalized, err := normalizeServerURL(raw)
+		if err != nil || !strings.HasPrefix(prefix, "/") {
+			continue
+		}
+		base, _ := url.Parse(normalized)
+		e.routes = append(e.routes, endpointRoute{prefix: prefix, base: base})
+	}
+	sort.Slice(e.routes, func(i, j int) bool {
+		if len(e.routes[i].prefix) != len(e.routes[j].prefix) {
+			return len(e.routes[i].prefix) > len(e.routes[j].prefix)
+		}
+		return e.routes[i].prefix < e.routes[j].prefix
+	})
+	return e
+}
+
+// url returns the absolute URL of an SDK API path or an absolute link.
+// Absolute links are returned unchanged; paths go to the base of the longest
+// matching EndpointURLs prefix, else to ServerURL, keeping the base's path
+// prefix (https://host/banyan + /api/v1/x).
+func (e *serverEndpoints) url(path string) string {
+	return e.resolve(path, false)
+}
+
+// link is url for a link the server sent. Such links may already carry the
+// base's path prefix, so a link starting with it is not prefixed twice.
+func (e *serverEndpoints) link(path string) string {
+	return e.resolve(path, true)
+}
+
+func (e *serverEndpoints) resolve(path string, link bool) string {
+	path = strings.TrimSpace(path)
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return serverURLForPath(e.serverURL, path)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	base := e.base
+	for _, route := range e.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			base = route.base
+			break
+		}
+	}
+	if base == nil {
+		return serverURLForPath(e.serverURL, path)
+	}
+	prefix := strings.TrimRight(base.Path, "/")
+	if link && prefix != "" && (path == prefix || strings.HasPrefix(path, prefix+"/")) {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	root := *base
+	root.Path, root.RawPath = prefix, ""
+	return root.String() + path
+}
+
+// owns reports whether u points at ServerURL's host or an EndpointURLs host.
+func (e *serverEndpoints) owns(u *url.URL) bool {
+	if sameOrigin(u, e.base) {
+		return true
+	}
+	for _, route := range e.routes {
+		if sameOrigin(u, route.base) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameOrigin(u, base *url.URL) bool {
+	return u != nil && base != nil && strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+}
+
+// endpoints returns the parsed server URLs, rebuilding them when
+// Config.ServerURL has changed.
+func (g *Guard) endpoints() *serverEndpoints {
+	if e := g.serverEndpoints.Load(); e != nil && e.serverURL == g.cfg.ServerURL {
+		return e
+	}
+	e := newServerEndpoints(g.cfg.ServerURL, g.cfg.Transport.EndpointURLs)
+	g.serverEndpoints.Store(e)
+	return e
+}
+
+// serverURL returns the absolute URL of an SDK API path.
+func (g *Guard) serverURL(path string) string {
+	return g.endpoints().url(path)
+}
+
+// serverLinkURL returns the absolute URL of a link the server sent, such as
+// a download or upload URL.
+func (g *Guard) serverLinkURL(link string) string {
+	return g.endpoints().link(link)
+}
+
+// overrideHost applies TransportConfig.HostOverrides to a host and port. An
+// override may name a host, an IPv4 or IPv6 address, or either with a port.
+func overrideHost(overrides map[string]string, host, port string) (string, string) {
+	for name, target := range overrides {
+		if !strings.EqualFold(strings.Trim(name, "[]"), host) {
+			continue
+		}
+		target = strings.TrimSpace(target)
+		if h, p, err := net.SplitHostPort(target); err == nil {
+			return h, p
+		}
+		return strings.Trim(target, "[]"), port
+	}
+	return host, port
+}
+
+// overridingDialer dials the HostOverrides address of a host. TLS still
+// runs against the URL's host name, so SNI and certificate checks are
+// unchanged.
+func overridingDialer(overrides map[string]string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			addr = net.JoinHostPort(overrideHost(overrides, host, port))
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
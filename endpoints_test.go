@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestServerEndpoints_PrefixesOverridesAndIPv6(t *testing.T) {
+	e := newServerEndpoints("https://guard.example.com/banyan", map[string]string{
+		"/download/":             "https://cdn.example.com/files",
+		"/download/private/":     "https://private.example.com",
+		"/api/v1/feedbacks/blob": "not a url",
+	})
+	cases := map[string]string{
+		"/api/v1/heartbeat":              "https://guard.example.com/banyan/api/v1/heartbeat",
+		"api/v1/heartbeat":               "https://guard.example.com/banyan/api/v1/heartbeat",
+		"/download/a.tgz?token=x":        "https://cdn.example.com/files/download/a.tgz?token=x",
+		"/download/private/a.tgz":        "https://private.example.com/download/private/a.tgz",
+		"/api/v1/feedbacks/blob/1":       "https://guard.example.com/banyan/api/v1/feedbacks/blob/1",
+		"https://s3.example.com/a?sig=1": "https://s3.example.com/a?sig=1",
+	}
+	for path, want := range cases {
+		if got := e.url(path); got != want {
+			t.Errorf("url(%q) = %q, want %q", path, got, want)
+		}
+	}
+	for raw, want := range map[string]bool{
+		"https://cdn.example.com/x":   true,
+		"https://guard.example.com/y": true,
+		"http://guard.example.com/y":  false,
+		"https://s3.example.com/z":    false,
+	} {
+		u, _ := url.Parse(raw)
+		if got := e.owns(u); got != want {
+			t.Errorf("owns(%s) = %v, want %v", raw, got, want)
+		}
+	}
+
+	// Only links the server sent may already carry the base path.
+	if got := e.link("/banyan/api/v1/feedbacks/upload"); got != "https://guard.example.com/banyan/api/v1/feedbacks/upload" {
+		t.Errorf("link with base path = %q", got)
+	}
+	if got := e.link("/download/a.tgz"); got != "https://cdn.example.com/files/download/a.tgz" {
+		t.Errorf("link under override = %q", got)
+	}
+	api := newServerEndpoints("https://host/api", nil)
+	if got := api.url("/api/v1/verify"); got != "https://host/api/api/v1/verify" {
+		t.Errorf("url under /api base = %q", got)
+	}
+
+	v6 := newServerEndpoints("http://[::1]:8080", nil)
+	if got := v6.url("/api/v1/verify"); got != "http://[::1]:8080/api/v1/verify" {
+		t.Fatalf("IPv6 url = %q", got)
+	}
+}
+
+func TestHostOverrides_DialOverrideAddress(t *testing.T) {
+	for _, tc := range []struct {
+		target, host, port string
+	}{
+		{"10.0.0.5", "10.0.0.5", "443"},
+		{"10.0.0.5:8443", "10.0.0.5", "8443"},
+		{"[fd00::5]:8443", "fd00::5", "8443"},
+		{"fd00::5", "fd00::5", "443"},
+	} {
+		host, port := overrideHost(map[string]string{"License.Example.com": tc.target}, "license.example.com", "443")
+		if host != tc.host || port != tc.port {
+			t.Errorf("override %q = %s %s, want %s %s", tc.target, host, port, tc.host, tc.port)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "" || r.URL.Path != "/banyan/api/v1/capabilities" {
+			t.Errorf("request to %s %s", r.Host, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"capabilities":[]}`))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	guard, _ := newTestGuard(t, nil)
+	guard.cfg.ServerURL = "http://license.invalid:" + port + "/banyan"
+	guard.cfg.Transport.HostOverrides = map[string]string{"license.invalid": "127.0.0.1"}
+	transport, err := newBaseTransport(guard.cfg.Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard.httpClient = &http.Client{Transport: transport}
+	if _, err := guard.getJSON(context.Background(), "/api/v1/capabilities", nil); err != nil {
+		t.Fatalf("request through host override: %v", err)
+	}
+}
+
+func TestValidateRejectsBadEndpointOverrides(t *testing.T) {
+	cfg := validTestConfig()
+	cfg.Transport.EndpointURLs = map[string]string{"download/": "https://cdn.example.com", "/files/": "ftp://cdn.example.com"}
+	cfg.Transport.HostOverrides = map[string]string{"license.example.com": ""}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{`prefix "download/" must start with /`, `"ftp://cdn.example.com" is not an absolute http(s) URL`, "needs a host and a target"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownDelay is how long a killed or locked process keeps running
+// before Enforcement shuts it down, so the host can save work.
+const defaultShutdownDelay = 30 * time.Second
+
+// EnforcementConfig makes the guard act on a kill or lock itself, so every
+// product enforces the same way instead of relying on its own Check calls.
+// OnKill and OnFeatureLock run in the background, one at a time and in the
+// order of the state changes, so they may call Stop or Deactivate; a hook
+// calling StopAndWait would wait for itself.
+type EnforcementConfig struct {
+	// OnKill fires when the guard enters BANNED, whether from a kill
+	// command, a fatal license error or a scheduled kill reaching its
+	// deadline.
+	OnKill func(reason string)
+	// OnFeatureLock fires with true when the guard leaves ACTIVE or GRACE
+	// (LOCKED, BANNED or DEACTIVATED) and with false when it returns, so
+	// licensed features can be switched off and on in one place.
+	OnFeatureLock func(locked bool)
+
+	// ShutdownOnKill and ShutdownOnLock stop the process ShutdownDelay
+	// (default 30s) after the guard enters BANNED or LOCKED. A lock that
+	// recovers within the delay cancels the shutdown.
+	ShutdownOnKill bool
+	ShutdownOnLock bool
+	ShutdownDelay  time.Duration
+	// Shutdown stops the process. The default sends SIGTERM to the process
+	// so its usual signal handling runs, and exits with status 1 where that
+	// is not possible, as on Windows.
+	Shutdown func(reason string)
+}
+
+// enforceTransition runs the configured enforcement for a state change.
+func (g *Guard) enforceTransition(t StateTransition) {
+	cfg := g.cfg.Enforcement
+	wasLicensed := t.From == StateActive || t.From == StateGrace
+	isLicensed := t.To == StateActive || t.To == StateGrace
+	if cfg.OnFeatureLock != nil && wasLicensed != isLicensed && t.From != StateInit {
+		g.queueEnforcementHook(func() { cfg.OnFeatureLock(!isLicensed) })
+	}
+
+	switch t.To {
+	case StateBanned:
+		reason := g.killReasonSnapshot()
+		if reason == "" {
+			reason = "license killed by server"
+		}
+		if cfg.OnKill != nil {
+			g.queueEnforcementHook(func() { cfg.OnKill(reason) })
+		}
+		if cfg.ShutdownOnKill {
+			g.scheduleShutdown(reason, nil)
+		}
+	case StateLocked:
+		if cfg.ShutdownOnLock {
+			g.scheduleShutdown("license locked: "+t.Reason, func() bool { return g.sm.Current() == StateLocked })
+		}
+	}
+}
+
+// queueEnforcementHook runs fn in the background after the hooks queued
+// before it.
+func (g *Guard) queueEnforcementHook(fn func()) {
+	g.enforceMu.Lock()
+	g.enforceHooks = append(g.enforceHooks, fn)
+	if g.enforceDraining {
+		g.enforceMu.Unlock()
+		return
+	}
+	g.enforceDraining = true
+	g.enforceMu.Unlock()
+
+	g.goBackground(func() {
+		for {
+			g.enforceMu.Lock()
+			if len(g.enforceHooks) == 0 {
+				g.enforceDraining = false
+				g.enforceMu.Unlock()
+				return
+			}
+			next := g.enforceHooks[0]
+			g.enforceHooks = g.enforceHooks[1:]
+			g.enforceMu.Unlock()
+			next()
+		}
+	})
+}
+
+// scheduleShutdown stops the process after the shutdown delay unless still
+// reports false by then. It replaces a shutdown already scheduled;
+// cancelShutdown drops it.
+func (g *Guard) scheduleShutdown(reason string, still func() bool) {
+	delay := g.cfg.Enforcement.ShutdownDelay
+	if delay <= 0 {
+		delay = defaultShutdownDelay
+	}
+	g.logger.Warn("process shutdown scheduled by license enforcement", "reason", reason, "in", delay.String())
+	timer := time.AfterFunc(delay, func() {
+		if still != nil && !still() {
+			g.logger.Info("license enforcement shutdown canceled", "reason", reason)
+			return
+		}
+		g.logger.Error("license enforcement shutting down process", "reason", reason)
+		if g.cfg.Enforcement.Shutdown != nil {
+			g.cfg.Enforcement.Shutdown(reason)
+			return
+		}
+		shutdownProcess()
+	})
+	g.enforceMu.Lock()
+	if g.shutdownTimer != nil {
+		g.shutdownTimer.Stop()
+	}
+	g.shutdownTimer = timer
+	g.enforceMu.Unlock()
+}
+
+// cancelShutdown stops a scheduled enforcement shutdown; Stop calls it, so
+// a stopped or deactivated guard no longer ends the process.
+func (g *Guard) cancelShutdown() {
+	g.enforceMu.Lock()
+	defer g.enforceMu.Unlock()
+	if g.shutdownTimer != nil {
+		g.shutdownTimer.Stop()
+		g.shutdownTimer = nil
+	}
+}
+
+func shutdownProcess() {
+	if proc, err := os.FindProcess(os.Getpid()); err == nil && proc.Signal(syscall.SIGTERM) == nil {
+		return
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,152 @@
+package sdk
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type enforcementRecorder struct {
+	mu        sync.Mutex
+	kills     []string
+	locks     []bool
+	shutdowns chan string
+}
+
+func newEnforcementRecorder() *enforcementRecorder {
+	return &enforcementRecorder{shutdowns: make(chan string, 4)}
+}
+
+func (r *enforcementRecorder) config(onKill, onLock bool) EnforcementConfig {
+	return EnforcementConfig{
+		OnKill: func(reason string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.kills = append(r.kills, reason)
+		},
+		OnFeatureLock: func(locked bool) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.locks = append(r.locks, locked)
+		},
+		ShutdownOnKill: onKill,
+		ShutdownOnLock: onLock,
+		ShutdownDelay:  20 * time.Millisecond,
+		Shutdown:       func(reason string) { r.shutdowns <- reason },
+	}
+}
+
+func TestEnforcementRunsKillHooksAndShutsDown(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	rec := newEnforcementRecorder()
+	guard.cfg.Enforcement = rec.config(true, false)
+
+	guard.sm.OnVerifySuccess()
+	guard.mu.Lock()
+	guard.killReason = "chargeback"
+	guard.mu.Unlock()
+	guard.sm.OnKill()
+
+	select {
+	case reason := <-rec.shutdowns:
+		if reason != "chargeback" {
+			t.Fatalf("shutdown reason = %q, want chargeback", reason)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("process shutdown was not triggered after kill")
+	}
+
+	guard.workers.Wait()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.kills) != 1 || rec.kills[0] != "chargeback" {
+		t.Fatalf("OnKill calls = %v, want [chargeback]", rec.kills)
+	}
+	if len(rec.locks) != 1 || !rec.locks[0] {
+		t.Fatalf("OnFeatureLock calls = %v, want [true]", rec.locks)
+	}
+}
+
+func TestEnforcementCancelsLockShutdownOnRecovery(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	rec := newEnforcementRecorder()
+	guard.cfg.Enforcement = rec.config(false, true)
+	guard.cfg.Enforcement.ShutdownDelay = 50 * time.Millisecond
+
+	guard.sm.OnVerifySuccess()
+	guard.sm.OnGracePeriodExpired()
+	guard.sm.OnVerifySuccess()
+
+	select {
+	case reason := <-rec.shutdowns:
+		t.Fatalf("shutdown ran after the lock recovered: %q", reason)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	guard.workers.Wait()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.locks) != 2 || !rec.locks[0] || rec.locks[1] {
+		t.Fatalf("OnFeatureLock calls = %v, want [true false]", rec.locks)
+	}
+	if len(rec.kills) != 0 {
+		t.Fatalf("OnKill calls = %v, want none", rec.kills)
+	}
+}
+
+func TestEnforcementShutsDownWhileStillLocked(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	rec := newEnforcementRecorder()
+	guard.cfg.Enforcement = rec.config(false, true)
+
+	guard.sm.OnVerifySuccess()
+	guard.sm.OnClockTampered()
+
+	select {
+	case <-rec.shutdowns:
+	case <-time.After(2 * time.Second):
+		t.Fatal("process shutdown was not triggered while locked")
+	}
+}
+
+func TestEnforcementHooksMayStopTheGuard(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	// The kill arrives on the heartbeat loop, which Stop waits for.
+	heartbeat := make(chan struct{})
+	guard.running, guard.heartbeatDone = true, heartbeat
+	stopped := make(chan struct{})
+	guard.cfg.Enforcement = EnforcementConfig{
+		OnKill: func(string) {
+			guard.Stop()
+			close(stopped)
+		},
+	}
+
+	guard.sm.OnVerifySuccess()
+	go func() {
+		defer close(heartbeat)
+		guard.sm.OnKill()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnKill calling Stop deadlocked with the heartbeat loop")
+	}
+}
+
+func TestEnforcementStopCancelsScheduledShutdown(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	rec := newEnforcementRecorder()
+	guard.cfg.Enforcement = rec.config(true, false)
+	guard.cfg.Enforcement.ShutdownDelay = 50 * time.Millisecond
+
+	guard.sm.OnVerifySuccess()
+	guard.sm.OnKill()
+	guard.Stop()
+
+	select {
+	case reason := <-rec.shutdowns:
+		t.Fatalf("shutdown ran after Stop: %q", reason)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
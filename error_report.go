@@ -0,0 +1,151 @@
+package sdk
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Error kinds reported to the server. They cover failures that point at a
+// systemic problem in the field rather than at a single unlucky request.
+const (
+	errorKindSignatureMismatch = "signature_mismatch"
+	errorKindApplyFailed       = "apply_failed"
+	errorKindCacheCorrupt      = "cache_corrupt"
+	errorKindMigrationFailed   = "migration_failed"
+)
+
+const (
+	// maxPendingErrorReports caps the distinct errors waiting for a
+	// heartbeat; further distinct errors are dropped until one is sent.
+	maxPendingErrorReports = 20
+	// maxNewErrorReportsPerWindow caps how many distinct errors may be
+	// queued per errorReportWindow. Repeats of a queued error only bump its
+	// count and never count against the cap.
+	maxNewErrorReportsPerWindow = 10
+	errorReportWindow           = time.Hour
+	maxErrorReportMessage       = 256
+)
+
+type errorReport struct {
+	Kind      string `json:"kind"`
+	Component string `json:"component,omitempty"`
+	Message   string `json:"message"`
+	Count     int    `json:"count"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// errorReporter aggregates SDK failures by kind, component and message so a
+// failure repeated on every heartbeat reaches the server as one entry with a
+// count instead of a flood.
+type errorReporter struct {
+	mu          sync.Mutex
+	pending     map[string]*errorReport
+	order       []string
+	windowStart time.Time
+	newInWindow int
+}
+
+func errorReportKey(kind, component, message string) string {
+	return kind + "\x00" + component + "\x00" + message
+}
+
+func (r *errorReporter) record(kind, component, message string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamp := now.UTC().Format(time.RFC3339)
+	key := errorReportKey(kind, component, message)
+	if report, ok := r.pending[key]; ok {
+		report.Count++
+		report.LastSeen = stamp
+		return
+	}
+
+	if now.Sub(r.windowStart) >= errorReportWindow {
+		r.windowStart = now
+		r.newInWindow = 0
+	}
+	if r.newInWindow >= maxNewErrorReportsPerWindow || len(r.pending) >= maxPendingErrorReports {
+		return
+	}
+	if r.pending == nil {
+		r.pending = make(map[string]*errorReport)
+	}
+	r.newInWindow++
+	r.pending[key] = &errorReport{
+		Kind:      kind,
+		Component: component,
+		Message:   message,
+		Count:     1,
+		FirstSeen: stamp,
+		LastSeen:  stamp,
+	}
+	r.order = append(r.order, key)
+}
+
+func (r *errorReporter) snapshot() []errorReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.order) == 0 {
+		return nil
+	}
+	reports := make([]errorReport, 0, len(r.order))
+	for _, key := range r.order {
+		reports = append(reports, *r.pending[key])
+	}
+	return reports
+}
+
+// drop removes what the server accepted. Occurrences recorded after the
+// snapshot was taken stay queued with the remaining count.
+func (r *errorReporter) drop(sent []errorReport) {
+	if len(sent) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range sent {
+		key := errorReportKey(s.Kind, s.Component, s.Message)
+		report, ok := r.pending[key]
+		if !ok {
+			continue
+		}
+		report.Count -= s.Count
+		if report.Count <= 0 {
+			delete(r.pending, key)
+		}
+	}
+	order := r.order[:0]
+	for _, key := range r.order {
+		if _, ok := r.pending[key]; ok {
+			order = append(order, key)
+		}
+	}
+	r.order = order
+}
+
+// reportError queues err for the next heartbeat unless error reporting is
+// disabled. The message is redacted and truncated before it is stored.
+func (g *Guard) reportError(kind, component string, err error) {
+	if err == nil || g.cfg.DisableErrorReporting {
+		return
+	}
+	message := g.redactErr(err).Error()
+	message = truncateUTF8(message, maxErrorReportMessage)
+	g.errorReports.record(kind, component, message, time.Now())
+}
+
+// reportUpdateFailure classifies a failed OTA attempt; failures that are not
+// worth a vendor's attention, such as download errors, are not reported.
+func (g *Guard) reportUpdateFailure(component string, err error) {
+	switch {
+	case errors.Is(err, ErrUpdateVerify):
+		g.reportError(errorKindSignatureMismatch, component, err)
+	case errors.Is(err, ErrUpdateApply), errors.Is(err, ErrUpdateRollback):
+		g.reportError(errorKindApplyFailed, component, err)
+	case errors.Is(err, ErrUpdateMigrate):
+		g.reportError(errorKindMigrationFailed, component, err)
+	}
+}
@@ -0,0 +1,121 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestErrorReporter_DeduplicatesAndCaps(t *testing.T) {
+	var r errorReporter
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		r.record(errorKindApplyFailed, "frontend", "disk full", now)
+	}
+	reports := r.snapshot()
+	if len(reports) != 1 || reports[0].Count != 5 {
+		t.Fatalf("expected one entry with count 5, got %#v", reports)
+	}
+
+	for i := 0; i < 2*maxNewErrorReportsPerWindow; i++ {
+		r.record(errorKindCacheCorrupt, "", fmt.Sprintf("corrupt %d", i), now)
+	}
+	if got := len(r.snapshot()); got != maxNewErrorReportsPerWindow {
+		t.Fatalf("expected %d distinct entries per window, got %d", maxNewErrorReportsPerWindow, got)
+	}
+
+	r.drop(r.snapshot())
+	r.record(errorKindCacheCorrupt, "", "still capped", now.Add(time.Minute))
+	if got := r.snapshot(); got != nil {
+		t.Fatalf("expected the window cap to hold after a flush, got %#v", got)
+	}
+	r.record(errorKindCacheCorrupt, "", "next window", now.Add(errorReportWindow))
+	if got := r.snapshot(); len(got) != 1 {
+		t.Fatalf("expected a new entry in the next window, got %#v", got)
+	}
+}
+
+func TestErrorReporter_DropKeepsLaterOccurrences(t *testing.T) {
+	var r errorReporter
+	now := time.Now()
+	r.record(errorKindSignatureMismatch, "backend", "bad signature", now)
+	sent := r.snapshot()
+	r.record(errorKindSignatureMismatch, "backend", "bad signature", now)
+
+	r.drop(sent)
+	reports := r.snapshot()
+	if len(reports) != 1 || reports[0].Count != 1 {
+		t.Fatalf("expected the occurrence after the snapshot to stay queued, got %#v", reports)
+	}
+}
+
+func TestHeartbeat_SendsAndClearsErrorReports(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.reportUpdateFailure("frontend", fmt.Errorf("%w: rename failed", ErrUpdateApply))
+	guard.reportUpdateFailure("frontend", fmt.Errorf("%w: rename failed", ErrUpdateApply))
+	guard.reportUpdateFailure("frontend", fmt.Errorf("%w: timeout", ErrUpdateDownload))
+
+	var received []errorReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+			return
+		}
+		received = body.Errors
+		resp := heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, resp, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if len(received) != 1 || received[0].Kind != errorKindApplyFailed || received[0].Count != 2 {
+		t.Fatalf("expected one deduplicated apply failure, got %#v", received)
+	}
+	if reports := guard.errorReports.snapshot(); len(reports) != 0 {
+		t.Fatalf("expected reported errors to be cleared, got %#v", reports)
+	}
+}
+
+func TestReportError_DisabledAndRedacted(t *testing.T) {
+	guard, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	guard.reportError(errorKindCacheCorrupt, "", errors.New("state for test-license unreadable"))
+	reports := guard.errorReports.snapshot()
+	if len(reports) != 1 || reports[0].Message == "state for test-license unreadable" {
+		t.Fatalf("expected a redacted report, got %#v", reports)
+	}
+
+	guard.cfg.DisableErrorReporting = true
+	guard.reportError(errorKindCacheCorrupt, "", errors.New("another failure"))
+	if got := len(guard.errorReports.snapshot()); got != 1 {
+		t.Fatalf("expected no new report while disabled, got %d entries", got)
+	}
+}
+
+func TestReportError_TruncatesAtRuneBoundary(t *testing.T) {
+	guard, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	// "a" shifts the three-byte runes so the byte limit falls inside one.
+	guard.reportError(errorKindCacheCorrupt, "", errors.New("a"+strings.Repeat("错", maxErrorReportMessage)))
+	reports := guard.errorReports.snapshot()
+	if len(reports) != 1 {
+		t.Fatalf("expected one report, got %#v", reports)
+	}
+	message := reports[0].Message
+	if len(message) > maxErrorReportMessage || !utf8.ValidString(message) {
+		t.Fatalf("message is %d bytes, valid UTF-8 = %v", len(message), utf8.ValidString(message))
+	}
+}
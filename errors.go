@@ -23,17 +23,23 @@ var (
 	ErrNotFound                   = errors.New("resource not found")
 	ErrMissingParameter           = errors.New("missing required parameter")
 	ErrNotActivated               = errors.New("guard not activated")
+	ErrAlreadyStarted             = errors.New("guard already started")
+	ErrGuardNotInitialized        = errors.New("guard not initialized")
 	ErrLocked                     = errors.New("system locked: offline grace period expired")
 	ErrBanned                     = errors.New("system banned")
 	ErrStateTampered              = errors.New("state tampered")
 	ErrClockRollback              = errors.New("clock rollback detected")
 	ErrLeaseBindingMismatch       = errors.New("lease machine binding mismatch")
 	ErrLeaseUnavailable           = errors.New("valid lease unavailable")
+	ErrLicenseCacheStale          = errors.New("cached license verification is stale")
 	ErrHeartbeatInvalid           = errors.New("heartbeat response signature invalid")
 	ErrHeartbeatNonceMismatch     = errors.New("heartbeat response nonce mismatch")
+	ErrVerifyResponseInvalid      = errors.New("verify response signature invalid")
+	ErrResponseStale              = errors.New("server response is stale")
 	ErrTLSPinMismatch             = errors.New("tls spki pin mismatch")
 	ErrTLSPinNotConfigured        = errors.New("tls spki pin not configured")
 	ErrHardBindingUnavailable     = errors.New("hard binding unavailable")
+	ErrClientCertEnrollment       = errors.New("client certificate enrollment failed")
 	ErrCDKNotFound                = errors.New("activation code not found")
 	ErrCDKAlreadyUsed             = errors.New("activation code already used")
 	ErrCDKRevoked                 = errors.New("activation code revoked")
@@ -41,17 +47,36 @@ var (
 	ErrUpdateDownload             = errors.New("update download failed")
 	ErrUpdateVerify               = errors.New("update verification failed")
 	ErrUpdateApply                = errors.New("update apply failed")
+	ErrUpdateMigrate              = errors.New("update migration failed")
 	ErrUpdateRollback             = errors.New("update rollback failed")
 	ErrUpdateDowngrade            = errors.New("ota target is not strictly newer than current version")
 	ErrUpdateConcurrent           = errors.New("concurrent update not allowed")
+	ErrExtractionLimit            = errors.New("archive extraction limit exceeded")
+	ErrInsufficientDiskSpace      = errors.New("insufficient disk space")
+	ErrNoPreviousVersion          = errors.New("no previous version kept")
+	ErrInvalidVersion             = errors.New("invalid semantic version")
+	ErrDeactivated                = errors.New("machine deactivated")
+	ErrActivationResponseInvalid  = errors.New("activation response does not answer a pending request")
+	ErrTrialAlreadyUsed           = errors.New("trial already used")
+	ErrTrialUnavailable           = errors.New("trial not available")
+	ErrMachineMismatch            = errors.New("machine fingerprint changed; rebind required")
+	ErrRebindRejected             = errors.New("rebind rejected by server")
+	ErrSeatsExhausted             = errors.New("no seats available")
+	ErrSeatNotHeld                = errors.New("seat not held")
+	ErrHookVetoed                 = errors.New("lifecycle hook vetoed operation")
 	ErrPluginNotFound             = errors.New("plugin not found")
 	ErrPluginNotManaged           = errors.New("plugin is not managed locally")
 	ErrNoPluginUpdate             = errors.New("no plugin update available")
 	ErrPluginOTADisabled          = errors.New("plugin ota is disabled")
+	ErrPluginVersionPinned        = errors.New("plugin version pinned")
+	ErrPluginVersionIgnored       = errors.New("plugin version ignored")
 	ErrComponentNotFound          = errors.New("component not found")
+	ErrFeatureUnsupportedByServer = errors.New("feature not supported by server")
+	ErrFeatureNotEntitled         = errors.New("feature not entitled")
 	ErrUploadInvalid              = errors.New("upload invalid")
 	ErrMarketplaceIncompatible    = errors.New("marketplace item incompatible")
 	ErrMarketplaceInstallRequired = errors.New("marketplace install required")
 	ErrMarketplaceNotInstalled    = errors.New("marketplace item not installed")
 	ErrMarketplaceConfigInvalid   = errors.New("marketplace configuration invalid")
+	ErrDevMode                    = errors.New("licensing bypassed: guard is in development mode")
 )
@@ -0,0 +1,150 @@
+// Command cli shows a command-line tool that activates itself with a CDK code
+// on first use, stores the issued license key, and verifies it on every run.
+//
+//	cli activate -code XXXX-XXXX -org "Acme Inc" -email ops@acme.example
+//	cli run
+//
+// An https server needs its SPKI pins in GUARD_PINNED_SPKI (comma separated)
+// or, to trust the system roots instead, GUARD_ALLOW_SYSTEM_TRUST=1.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "activate":
+		err = activate(os.Args[2:])
+	case "run":
+		err = run(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cli activate -code <cdk> -org <organization> [-email <email>] | cli run")
+	os.Exit(2)
+}
+
+func activate(args []string) error {
+	fs := flag.NewFlagSet("activate", flag.ExitOnError)
+	code := fs.String("code", "", "activation code (CDK)")
+	org := fs.String("org", "", "organization name")
+	email := fs.String("email", "", "contact email")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	result, err := sdk.ActivateWithOptions(sdk.ActivationOptions{
+		ServerURL:        os.Getenv("GUARD_SERVER_URL"),
+		Code:             *code,
+		Organization:     *org,
+		Email:            *email,
+		Context:          ctx,
+		PinnedSPKIHashes: splitPins(os.Getenv("GUARD_PINNED_SPKI")),
+		AllowSystemTrust: os.Getenv("GUARD_ALLOW_SYSTEM_TRUST") == "1",
+	})
+	switch {
+	case errors.Is(err, sdk.ErrCDKNotFound):
+		return fmt.Errorf("unknown activation code")
+	case errors.Is(err, sdk.ErrCDKAlreadyUsed):
+		return fmt.Errorf("activation code was already used")
+	case err != nil:
+		return err
+	}
+
+	path, err := licenseFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(result.LicenseKey+"\n"), 0o600); err != nil {
+		return err
+	}
+	fmt.Printf("activated %s, license stored in %s\n", result.ProjectSlug, path)
+	return nil
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	publicKeyPath := fs.String("public-key", "public_key.pem", "server public key")
+	_ = fs.Parse(args)
+
+	path, err := licenseFile()
+	if err != nil {
+		return err
+	}
+	licenseKey, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("not activated yet, run: cli activate -code <cdk> -org <organization>")
+	}
+	if err != nil {
+		return err
+	}
+	publicKeyPEM, err := os.ReadFile(*publicKeyPath)
+	if err != nil {
+		return err
+	}
+
+	guard, err := sdk.New(sdk.Config{
+		ServerURL:        os.Getenv("GUARD_SERVER_URL"),
+		LicenseKey:       strings.TrimSpace(string(licenseKey)),
+		PublicKeyPEM:     publicKeyPEM,
+		ProjectSlug:      os.Getenv("GUARD_PROJECT_SLUG"),
+		ComponentSlug:    "cli",
+		PinnedSPKIHashes: splitPins(os.Getenv("GUARD_PINNED_SPKI")),
+		AllowSystemTrust: os.Getenv("GUARD_ALLOW_SYSTEM_TRUST") == "1",
+	})
+	if err != nil {
+		return err
+	}
+	if err := guard.Start(context.Background()); err != nil {
+		return fmt.Errorf("license verification failed: %w", err)
+	}
+	defer guard.Stop()
+	if err := guard.Check(); err != nil {
+		return err
+	}
+
+	fmt.Println("license ok, doing the actual work")
+	return nil
+}
+
+func licenseFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "banyanhub-cli-example", "license.key"), nil
+}
+
+func splitPins(value string) []string {
+	var pins []string
+	for _, pin := range strings.Split(value, ",") {
+		if pin = strings.TrimSpace(pin); pin != "" {
+			pins = append(pins, pin)
+		}
+	}
+	return pins
+}
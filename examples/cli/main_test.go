@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+	"github.com/iwen-conf/BanyanHub-SDK/sdktest"
+)
+
+// storeLicense points the CLI at serverURL and stores key as if activate had
+// run.
+func storeLicense(t *testing.T, serverURL, key string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GUARD_SERVER_URL", serverURL)
+	t.Setenv("GUARD_PROJECT_SLUG", sdktest.DefaultProjectSlug)
+	path, err := licenseFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func writePublicKey(t *testing.T, srv *sdktest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "public_key.pem")
+	if err := os.WriteFile(path, srv.Signer.PublicKeyPEM(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunVerifiesStoredLicense(t *testing.T) {
+	srv := sdktest.NewServer(t)
+	srv.AddLicense(sdktest.License{Key: "LIC-TEST"})
+	storeLicense(t, srv.URL, "LIC-TEST")
+
+	if err := run([]string{"-public-key", writePublicKey(t, srv)}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
+
+func TestRunRejectsUnknownLicense(t *testing.T) {
+	srv := sdktest.NewServer(t)
+	storeLicense(t, srv.URL, "LIC-UNKNOWN")
+
+	if err := run([]string{"-public-key", writePublicKey(t, srv)}); err == nil {
+		t.Fatal("run succeeded with a license the server does not know")
+	}
+}
+
+func TestRunWithoutActivation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(os.Getenv("HOME"), ".config"))
+
+	err := run(nil)
+	if err == nil || !strings.Contains(err.Error(), "not activated") {
+		t.Fatalf("err = %v, want a hint to activate", err)
+	}
+}
+
+func TestRunNeedsPinsOrSystemTrustForHTTPS(t *testing.T) {
+	srv := sdktest.NewServer(t)
+	storeLicense(t, "https://guard.example.com", "LIC-TEST")
+
+	if err := run([]string{"-public-key", writePublicKey(t, srv)}); !errors.Is(err, sdk.ErrTLSPinNotConfigured) {
+		t.Fatalf("err = %v, want ErrTLSPinNotConfigured", err)
+	}
+}
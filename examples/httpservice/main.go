@@ -0,0 +1,135 @@
+// Command httpservice shows an HTTP API that refuses business requests unless
+// the license is ACTIVE or in GRACE, and exposes the guard status for probes.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+func main() {
+	publicKeyPEM, err := os.ReadFile(envOr("GUARD_PUBLIC_KEY", "public_key.pem"))
+	if err != nil {
+		log.Fatalf("read public key: %v", err)
+	}
+
+	guard, err := newGuard(publicKeyPEM)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := guard.Start(ctx); err != nil {
+		log.Fatalf("guard start failed: %v", err)
+	}
+	defer guard.Stop()
+
+	server := &http.Server{Addr: envOr("LISTEN_ADDR", ":8080"), Handler: newMux(guard), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on %s", server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// newGuard builds the guard from the environment. An https server needs its
+// SPKI pins in GUARD_PINNED_SPKI (comma separated) or, to trust the system
+// roots instead, GUARD_ALLOW_SYSTEM_TRUST=1.
+func newGuard(publicKeyPEM []byte) (*sdk.Guard, error) {
+	guard, err := sdk.New(sdk.Config{
+		ServerURL:        os.Getenv("GUARD_SERVER_URL"),
+		LicenseKey:       os.Getenv("GUARD_LICENSE_KEY"),
+		PublicKeyPEM:     publicKeyPEM,
+		ProjectSlug:      os.Getenv("GUARD_PROJECT_SLUG"),
+		ComponentSlug:    envOr("GUARD_COMPONENT_SLUG", "backend"),
+		PinnedSPKIHashes: splitPins(os.Getenv("GUARD_PINNED_SPKI")),
+		AllowSystemTrust: os.Getenv("GUARD_ALLOW_SYSTEM_TRUST") == "1",
+		OnGraceWarning: func(remaining time.Duration) {
+			log.Printf("license server unreachable, locking in %s", remaining.Round(time.Minute))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	guard.OnStateChange(func(old, new sdk.State, reason string) {
+		log.Printf("license state %s -> %s (%s)", old, new, reason)
+	})
+	return guard, nil
+}
+
+func newMux(guard *sdk.Guard) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", statusHandler(guard))
+	mux.Handle("/api/", requireLicense(guard, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"hello": "licensed world"})
+	})))
+	return mux
+}
+
+// requireLicense rejects requests while Check fails. Check is lock-free, so
+// it is cheap enough to call on every request.
+func requireLicense(guard *sdk.Guard, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := guard.Check(); err != nil {
+			code := http.StatusServiceUnavailable
+			if errors.Is(err, sdk.ErrBanned) {
+				code = http.StatusForbidden
+			}
+			http.Error(w, "license unavailable: "+err.Error(), code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func statusHandler(guard *sdk.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := guard.Status()
+		body := map[string]any{"state": status.State.String()}
+		if status.Grace != nil {
+			body["grace_remaining_s"] = int(status.Grace.Remaining.Seconds())
+		}
+		if !status.KillDeadline.IsZero() {
+			body["kill_deadline"] = status.KillDeadline.UTC().Format(time.RFC3339)
+		}
+		if guard.Check() != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func splitPins(value string) []string {
+	var pins []string
+	for _, pin := range strings.Split(value, ",") {
+		if pin = strings.TrimSpace(pin); pin != "" {
+			pins = append(pins, pin)
+		}
+	}
+	return pins
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+	"github.com/iwen-conf/BanyanHub-SDK/sdktest"
+)
+
+func setGuardEnv(t *testing.T, serverURL string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GUARD_SERVER_URL", serverURL)
+	t.Setenv("GUARD_LICENSE_KEY", "LIC-TEST")
+	t.Setenv("GUARD_PROJECT_SLUG", sdktest.DefaultProjectSlug)
+	t.Setenv("GUARD_COMPONENT_SLUG", sdktest.DefaultComponentSlug)
+}
+
+func TestServiceGatesAPIOnLicense(t *testing.T) {
+	srv := sdktest.NewServer(t)
+	srv.AddLicense(sdktest.License{Key: "LIC-TEST"})
+	setGuardEnv(t, srv.URL)
+
+	guard, err := newGuard(srv.Signer.PublicKeyPEM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(guard.Stop)
+	mux := newMux(guard)
+
+	for _, path := range []string{"/api/hello", "/healthz"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+
+	if err := guard.Deactivate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/hello", nil))
+	if rec.Code == http.StatusOK {
+		t.Fatal("API served after the license was deactivated")
+	}
+}
+
+func TestServiceNeedsPinsOrSystemTrustForHTTPS(t *testing.T) {
+	setGuardEnv(t, "https://guard.example.com")
+	srv := sdktest.NewServer(t)
+
+	if _, err := newGuard(srv.Signer.PublicKeyPEM()); !errors.Is(err, sdk.ErrTLSPinNotConfigured) {
+		t.Fatalf("err = %v, want ErrTLSPinNotConfigured", err)
+	}
+	t.Setenv("GUARD_PINNED_SPKI", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=, ")
+	if _, err := newGuard(srv.Signer.PublicKeyPEM()); err != nil {
+		t.Fatalf("with pins: %v", err)
+	}
+	t.Setenv("GUARD_PINNED_SPKI", "")
+	t.Setenv("GUARD_ALLOW_SYSTEM_TRUST", "1")
+	if _, err := newGuard(srv.Signer.PublicKeyPEM()); err != nil {
+		t.Fatalf("with system trust: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Command kiosk shows an unattended kiosk that serves a licensed web UI and
+// lets the SDK replace that UI over the air. Updates are deferred while a
+// visitor session is active, and the UI is locked behind Check.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+const uiComponent = "kiosk-ui"
+
+var lastVisit atomic.Int64
+
+func main() {
+	publicKeyPEM, err := os.ReadFile(envOr("GUARD_PUBLIC_KEY", "public_key.pem"))
+	if err != nil {
+		log.Fatalf("read public key: %v", err)
+	}
+	uiDir := envOr("KIOSK_UI_DIR", "./www")
+
+	guard, err := newGuard(publicKeyPEM, uiDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := guard.Start(ctx); err != nil {
+		log.Fatalf("guard start failed: %v", err)
+	}
+	defer guard.Stop()
+
+	server := &http.Server{Addr: envOr("LISTEN_ADDR", ":8080"), Handler: newHandler(guard, uiDir), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("serving %s on %s", uiDir, server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// newGuard builds the guard from the environment, managing the UI in uiDir.
+// An https server needs its SPKI pins in GUARD_PINNED_SPKI (comma separated)
+// or, to trust the system roots instead, GUARD_ALLOW_SYSTEM_TRUST=1.
+func newGuard(publicKeyPEM []byte, uiDir string) (*sdk.Guard, error) {
+	guard, err := sdk.New(sdk.Config{
+		ServerURL:        os.Getenv("GUARD_SERVER_URL"),
+		LicenseKey:       os.Getenv("GUARD_LICENSE_KEY"),
+		PublicKeyPEM:     publicKeyPEM,
+		ProjectSlug:      os.Getenv("GUARD_PROJECT_SLUG"),
+		ComponentSlug:    envOr("GUARD_COMPONENT_SLUG", "kiosk"),
+		PinnedSPKIHashes: splitPins(os.Getenv("GUARD_PINNED_SPKI")),
+		AllowSystemTrust: os.Getenv("GUARD_ALLOW_SYSTEM_TRUST") == "1",
+		OTA: sdk.OTAConfig{
+			Enabled:    true,
+			AutoUpdate: true,
+			OnUpdateProgress: func(component, stage string, progress float64) {
+				log.Printf("%s: %s %.0f%%", component, stage, progress*100)
+			},
+			OnUpdateResult: func(component, oldVer, newVer string, success bool, err error) {
+				if success {
+					log.Printf("%s updated %s -> %s", component, oldVer, newVer)
+					return
+				}
+				log.Printf("%s update %s -> %s failed: %v", component, oldVer, newVer, err)
+			},
+		},
+		ManagedComponents: []sdk.ManagedComponent{{
+			Slug:     uiComponent,
+			Dir:      uiDir,
+			Strategy: sdk.UpdateFrontend,
+			PreUpdate: func(ctx context.Context, event sdk.LifecycleEvent) error {
+				if time.Since(time.Unix(0, lastVisit.Load())) < 2*time.Minute {
+					return errors.New("visitor session active")
+				}
+				return nil
+			},
+			HealthCheck: func(ctx context.Context) error {
+				_, err := os.Stat(filepath.Join(uiDir, "index.html"))
+				return err
+			},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if version, err := os.ReadFile(filepath.Join(uiDir, "VERSION")); err == nil {
+		guard.SetManagedVersion(uiComponent, strings.TrimSpace(string(version)))
+	}
+	return guard, nil
+}
+
+// newHandler serves uiDir while the kiosk is licensed and records visits.
+func newHandler(guard *sdk.Guard, uiDir string) http.Handler {
+	files := http.FileServer(http.Dir(uiDir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := guard.Check(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "This kiosk is not licensed. Please contact the operator.")
+			return
+		}
+		lastVisit.Store(time.Now().UnixNano())
+		files.ServeHTTP(w, r)
+	})
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func splitPins(value string) []string {
+	var pins []string
+	for _, pin := range strings.Split(value, ",") {
+		if pin = strings.TrimSpace(pin); pin != "" {
+			pins = append(pins, pin)
+		}
+	}
+	return pins
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+	"github.com/iwen-conf/BanyanHub-SDK/sdktest"
+)
+
+func setGuardEnv(t *testing.T, serverURL string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GUARD_SERVER_URL", serverURL)
+	t.Setenv("GUARD_LICENSE_KEY", "LIC-TEST")
+	t.Setenv("GUARD_PROJECT_SLUG", sdktest.DefaultProjectSlug)
+	t.Setenv("GUARD_COMPONENT_SLUG", sdktest.DefaultComponentSlug)
+}
+
+func TestKioskServesUIWhileLicensed(t *testing.T) {
+	srv := sdktest.NewServer(t)
+	srv.AddLicense(sdktest.License{Key: "LIC-TEST"})
+	setGuardEnv(t, srv.URL)
+	uiDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(uiDir, "index.html"), []byte("welcome"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(uiDir, "VERSION"), []byte("1.2.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	guard, err := newGuard(srv.Signer.PublicKeyPEM(), uiDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(guard.Stop)
+	handler := newHandler(guard, uiDir)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "welcome" {
+		t.Fatalf("GET / = %d %q", rec.Code, rec.Body)
+	}
+	if lastVisit.Load() == 0 {
+		t.Fatal("visit not recorded")
+	}
+
+	if err := guard.Deactivate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET / after deactivation = %d", rec.Code)
+	}
+}
+
+func TestKioskNeedsPinsOrSystemTrustForHTTPS(t *testing.T) {
+	setGuardEnv(t, "https://guard.example.com")
+	srv := sdktest.NewServer(t)
+
+	if _, err := newGuard(srv.Signer.PublicKeyPEM(), t.TempDir()); !errors.Is(err, sdk.ErrTLSPinNotConfigured) {
+		t.Fatalf("err = %v, want ErrTLSPinNotConfigured", err)
+	}
+	t.Setenv("GUARD_ALLOW_SYSTEM_TRUST", "1")
+	if _, err := newGuard(srv.Signer.PublicKeyPEM(), t.TempDir()); err != nil {
+		t.Fatalf("with system trust: %v", err)
+	}
+}
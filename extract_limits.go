@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"archive/tar"
+	"fmt"
+	"strings"
+)
+
+// ExtractionLimits bound what a frontend archive may expand to on disk.
+// MaxArtifactBytes only limits the compressed download, so a small tar.gz
+// could otherwise fill the disk. Zero selects the default; a negative
+// value disables that limit.
+type ExtractionLimits struct {
+	// MaxFileBytes bounds a single extracted file (default 512MB).
+	MaxFileBytes int64
+	// MaxTotalBytes bounds all extracted files together (default 2GB).
+	MaxTotalBytes int64
+	// MaxFiles bounds the number of files and directories (default 100000).
+	MaxFiles int
+	// MaxDepth bounds how many directories deep an entry may be nested
+	// (default 32).
+	MaxDepth int
+}
+
+// Extraction limit names reported in ExtractionLimitError.Limit.
+const (
+	ExtractionLimitFileBytes  = "file_bytes"
+	ExtractionLimitTotalBytes = "total_bytes"
+	ExtractionLimitFiles      = "files"
+	ExtractionLimitDepth      = "depth"
+)
+
+// ExtractionLimitError reports an archive entry that exceeded one of the
+// ExtractionLimits. It matches ErrExtractionLimit with errors.Is; update
+// failures wrap it together with ErrUpdateVerify.
+type ExtractionLimitError struct {
+	// Limit is one of the ExtractionLimit* names.
+	Limit string
+	// Path is the archive entry that crossed the limit.
+	Path string
+	// Value is the size, count or depth the entry would have reached.
+	Value int64
+	Max   int64
+}
+
+func (e *ExtractionLimitError) Error() string {
+	return fmt.Sprintf("%s: %s reached %d at %q, limit %d", ErrExtractionLimit, e.Limit, e.Value, e.Path, e.Max)
+}
+
+func (e *ExtractionLimitError) Is(target error) bool { return target == ErrExtractionLimit }
+
+func (l ExtractionLimits) withDefaults() ExtractionLimits {
+	if l.MaxFileBytes == 0 {
+		l.MaxFileBytes = 512 << 20
+	}
+	if l.MaxTotalBytes == 0 {
+		l.MaxTotalBytes = 2 << 30
+	}
+	if l.MaxFiles == 0 {
+		l.MaxFiles = 100000
+	}
+	if l.MaxDepth == 0 {
+		l.MaxDepth = 32
+	}
+	return l
+}
+
+// extractionBudget tracks one archive's extraction against its limits.
+type extractionBudget struct {
+	limits     ExtractionLimits
+	files      int
+	totalBytes int64
+}
+
+func newExtractionBudget(limits ExtractionLimits) *extractionBudget {
+	return &extractionBudget{limits: limits.withDefaults()}
+}
+
+// admit charges an entry to the budget before anything is written. rel is
+// the entry's cleaned path relative to the extraction directory. tar.Reader
+// never yields more than hdr.Size bytes for an entry, so checking the
+// declared size is enough.
+func (b *extractionBudget) admit(hdr *tar.Header, rel string) error {
+	exceeded := func(limit string, value, max int64) error {
+		return &ExtractionLimitError{Limit: limit, Path: hdr.Name, Value: value, Max: max}
+	}
+	if max := b.limits.MaxDepth; max > 0 {
+		if depth := strings.Count(rel, "/"); depth > max {
+			return exceeded(ExtractionLimitDepth, int64(depth), int64(max))
+		}
+	}
+	b.files++
+	if max := b.limits.MaxFiles; max > 0 && b.files > max {
+		return exceeded(ExtractionLimitFiles, int64(b.files), int64(max))
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil
+	}
+	if max := b.limits.MaxFileBytes; max > 0 && hdr.Size > max {
+		return exceeded(ExtractionLimitFileBytes, hdr.Size, max)
+	}
+	b.totalBytes += hdr.Size
+	if max := b.limits.MaxTotalBytes; max > 0 && b.totalBytes > max {
+		return exceeded(ExtractionLimitTotalBytes, b.totalBytes, max)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateFrontend_EnforcesExtractionLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		limits ExtractionLimits
+		want   string
+	}{
+		{"file bytes", map[string]string{"app.js": strings.Repeat("x", 2048)}, ExtractionLimits{MaxFileBytes: 1024}, ExtractionLimitFileBytes},
+		{"total bytes", map[string]string{"a.js": strings.Repeat("a", 600), "b.js": strings.Repeat("b", 600)}, ExtractionLimits{MaxTotalBytes: 1000}, ExtractionLimitTotalBytes},
+		{"files", map[string]string{"a": "1", "b": "2", "c": "3"}, ExtractionLimits{MaxFiles: 2}, ExtractionLimitFiles},
+		{"depth", map[string]string{"a/b/c/d/index.html": "deep"}, ExtractionLimits{MaxDepth: 3}, ExtractionLimitDepth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+			archive := buildTarGz(t, tt.files)
+			hashHex := sha256Hex(archive)
+			g := newFetcherTestGuard(t, pubKey, hashHex, signUpdateHash(t, privKey, hashHex))
+			g.cfg.OTA.Extraction = tt.limits
+			g.cfg.OTA.Fetcher = fetcherFunc(func(ctx context.Context, req FetchRequest) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(archive)), nil
+			})
+
+			dir := filepath.Join(t.TempDir(), "live")
+			err := g.updateFrontend(context.Background(), ManagedComponent{Slug: "frontend", Dir: dir}, updateInfo{Component: "frontend", Latest: "2.0.0"})
+			var limitErr *ExtractionLimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != tt.want {
+				t.Fatalf("err = %v, want %s limit error", err, tt.want)
+			}
+			if !errors.Is(err, ErrExtractionLimit) || !errors.Is(err, ErrUpdateVerify) {
+				t.Fatalf("err = %v, want ErrExtractionLimit and ErrUpdateVerify", err)
+			}
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Fatalf("live dir must not be created, stat err = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateFrontend_NegativeExtractionLimitDisablesIt(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	archive := buildTarGz(t, map[string]string{"a/b/c/index.html": "deep"})
+	hashHex := sha256Hex(archive)
+	g := newFetcherTestGuard(t, pubKey, hashHex, signUpdateHash(t, privKey, hashHex))
+	g.cfg.OTA.Extraction = ExtractionLimits{MaxDepth: -1, MaxFiles: 1}
+	g.cfg.OTA.Fetcher = fetcherFunc(func(ctx context.Context, req FetchRequest) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(archive)), nil
+	})
+
+	dir := filepath.Join(t.TempDir(), "live")
+	if err := g.updateFrontend(context.Background(), ManagedComponent{Slug: "frontend", Dir: dir}, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+		t.Fatalf("updateFrontend: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "a", "b", "c", "index.html")); err != nil || string(data) != "deep" {
+		t.Fatalf("index.html = %q, %v", data, err)
+	}
+}
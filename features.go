@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// FeatureReason explains why CheckFeatureMatrix enabled or disabled a feature.
+type FeatureReason string
+
+const (
+	// FeatureEntitled means the lease grants the feature.
+	FeatureEntitled FeatureReason = "entitled"
+	// FeatureFlagOn means a remote flag enables the feature.
+	FeatureFlagOn FeatureReason = "flag_on"
+	// FeatureFlagOff means a remote flag disables the feature even though the
+	// lease may grant it.
+	FeatureFlagOff FeatureReason = "flag_off"
+	// FeatureNotEntitled means neither the lease nor a remote flag grants it.
+	FeatureNotEntitled FeatureReason = "not_entitled"
+	// FeatureGuardInactive means the guard is not ACTIVE or GRACE, so every
+	// feature is off.
+	FeatureGuardInactive FeatureReason = "guard_inactive"
+)
+
+// FeatureStatus is the evaluated enablement of one feature or flag.
+type FeatureStatus struct {
+	Enabled bool
+	Reason  FeatureReason
+}
+
+// CheckFeatureMatrix evaluates several features in one pass against the guard
+// state, the lease entitlements and the remote flags from the last heartbeat.
+// The entitlement set is cached and rebuilt when a heartbeat or verification
+// delivers a new lease or new flags, so this is cheap enough for UI startup.
+func (g *Guard) CheckFeatureMatrix(names ...string) map[string]FeatureStatus {
+	matrix := make(map[string]FeatureStatus, len(names))
+	if g.Check() != nil {
+		for _, name := range names {
+			matrix[name] = FeatureStatus{Reason: FeatureGuardInactive}
+		}
+		return matrix
+	}
+
+	entitled, flags := g.featureSet()
+	for _, name := range names {
+		flag, flagged := flags[name]
+		switch {
+		case flagged && !flag:
+			matrix[name] = FeatureStatus{Reason: FeatureFlagOff}
+		case entitled[name]:
+			matrix[name] = FeatureStatus{Enabled: true, Reason: FeatureEntitled}
+		case flagged:
+			matrix[name] = FeatureStatus{Enabled: true, Reason: FeatureFlagOn}
+		default:
+			matrix[name] = FeatureStatus{Reason: FeatureNotEntitled}
+		}
+	}
+	return matrix
+}
+
+// featureSet returns the cached lease entitlements and remote flags, building
+// the entitlement set on first use after an invalidation.
+func (g *Guard) featureSet() (map[string]bool, map[string]bool) {
+	g.mu.RLock()
+	entitled, flags := g.entitledFeatures, g.featureFlags
+	g.mu.RUnlock()
+	if entitled != nil {
+		return entitled, flags
+	}
+
+	entitled = make(map[string]bool)
+	if state := g.currentLeaseState(); state != nil && state.Lease != nil {
+		for _, name := range state.Lease.Features {
+			entitled[name] = true
+		}
+	}
+	g.mu.Lock()
+	g.entitledFeatures = entitled
+	flags = g.featureFlags
+	g.mu.Unlock()
+	return entitled, flags
+}
+
+// adoptFeatureFlags replaces the remote flags with those from a verified
+// heartbeat; a heartbeat without flags clears them.
+func (g *Guard) adoptFeatureFlags(flags map[string]bool) {
+	g.mu.Lock()
+	g.featureFlags = flags
+	g.entitledFeatures = nil
+	g.mu.Unlock()
+}
+
+// invalidateFeatureCache drops the cached entitlement set after the lease
+// changes.
+func (g *Guard) invalidateFeatureCache() {
+	g.mu.Lock()
+	g.entitledFeatures = nil
+	g.mu.Unlock()
+}
+
+func flagsDigest(flags map[string]bool) string {
+	if len(flags) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(flags)
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		canonical = raw
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,46 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckFeatureMatrix_CombinesLeaseFlagsAndState(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{
+			Status:         "ok",
+			Lease:          leaseJSON,
+			LeaseSignature: sig,
+			Flags:          map[string]bool{"beta-ui": true, "reports": false},
+		}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if got := guard.CheckFeatureMatrix("reports")["reports"]; !got.Enabled || got.Reason != FeatureEntitled {
+		t.Fatalf("lease feature before heartbeat = %#v", got)
+	}
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+
+	matrix := guard.CheckFeatureMatrix("reports", "beta-ui", "export")
+	want := map[string]FeatureStatus{
+		"reports": {Reason: FeatureFlagOff},
+		"beta-ui": {Enabled: true, Reason: FeatureFlagOn},
+		"export":  {Reason: FeatureNotEntitled},
+	}
+	for name, status := range want {
+		if matrix[name] != status {
+			t.Fatalf("%s = %#v, want %#v", name, matrix[name], status)
+		}
+	}
+
+	guard.sm.set(StateLocked)
+	if got := guard.CheckFeatureMatrix("beta-ui")["beta-ui"]; got.Enabled || got.Reason != FeatureGuardInactive {
+		t.Fatalf("locked guard should disable every feature, got %#v", got)
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -22,6 +25,10 @@ const (
 	FeedbackBug        FeedbackCategory = "bug"
 	FeedbackSuggestion FeedbackCategory = "suggestion"
 	FeedbackQuestion   FeedbackCategory = "question"
+	// FeedbackUnbanAppeal marks an appeal filed through Guard.RequestUnban.
+	FeedbackUnbanAppeal FeedbackCategory = "unban_appeal"
+	// FeedbackCrash marks a bug report filed through Guard.ReportPanic.
+	FeedbackCrash FeedbackCategory = "crash"
 )
 
 // FeedbackStatus represents the processing state of a feedback item.
@@ -47,7 +54,7 @@ type SubmitFeedbackRequest struct {
 }
 
 type submitFeedbackBody struct {
-	LicenseKey  string               `json:"license_key"`
+	LicenseKey  string               `json:"license_key,omitempty"`
 	MachineID   string               `json:"machine_id"`
 	ProjectSlug string               `json:"project_slug"`
 	UserID      string               `json:"user_id"`
@@ -102,6 +109,70 @@ type FeedbackReply struct {
 	CreatedAt  string `json:"created_at"`
 }
 
+// FeedbackSort orders a feedback list.
+type FeedbackSort string
+
+const (
+	FeedbackSortNewest          FeedbackSort = "created_at_desc"
+	FeedbackSortOldest          FeedbackSort = "created_at_asc"
+	FeedbackSortRecentlyUpdated FeedbackSort = "updated_at_desc"
+)
+
+// FeedbackQuery filters and pages ListMyFeedbackWithQuery. Zero fields are
+// not sent, leaving the server defaults.
+type FeedbackQuery struct {
+	UserID   string
+	Page     int
+	PageSize int
+	// Statuses and Categories match any of the listed values.
+	Statuses   []FeedbackStatus
+	Categories []FeedbackCategory
+	// CreatedAfter and CreatedBefore bound the creation time.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Search matches the title and content.
+	Search string
+	Sort   FeedbackSort
+}
+
+func (q FeedbackQuery) encode(query url.Values) {
+	if q.UserID != "" {
+		query.Set("user_id", q.UserID)
+	}
+	if q.Page > 0 {
+		query.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(q.PageSize))
+	}
+	if len(q.Statuses) > 0 {
+		statuses := make([]string, len(q.Statuses))
+		for i, status := range q.Statuses {
+			statuses[i] = string(status)
+		}
+		query.Set("status", strings.Join(statuses, ","))
+	}
+	if len(q.Categories) > 0 {
+		categories := make([]string, len(q.Categories))
+		for i, category := range q.Categories {
+			categories[i] = string(category)
+		}
+		query.Set("category", strings.Join(categories, ","))
+	}
+	if !q.CreatedAfter.IsZero() {
+		query.Set("created_after", q.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	if !q.CreatedBefore.IsZero() {
+		query.Set("created_before", q.CreatedBefore.UTC().Format(time.RFC3339))
+	}
+	if q.Search != "" {
+		query.Set("q", q.Search)
+	}
+	if q.Sort != "" {
+		query.Set("sort", string(q.Sort))
+	}
+}
+
 // FeedbackListResponse wraps a paginated list of feedback items.
 type FeedbackListResponse struct {
 	Feedbacks  []FeedbackItem         `json:"data"`
@@ -127,11 +198,14 @@ type UploadURLResponse struct {
 }
 
 type prepareFeedbackUploadBody struct {
-	LicenseKey  string `json:"license_key"`
+	LicenseKey  string `json:"license_key,omitempty"`
 	ProjectSlug string `json:"project_slug"`
 	FileName    string `json:"file_name"`
 }
 
+// releaseNotesPageSize is the page size ReleaseNotesSince fetches with.
+const releaseNotesPageSize = 50
+
 // ReleaseNoteEntry represents a single version's release notes.
 type ReleaseNoteEntry struct {
 	ComponentSlug     string             `json:"component_slug,omitempty"`
@@ -150,7 +224,35 @@ type ResolvedFeedback struct {
 
 // ReleaseNotesResponse wraps the list of release note entries.
 type ReleaseNotesResponse struct {
-	Entries []ReleaseNoteEntry `json:"entries"`
+	Entries    []ReleaseNoteEntry     `json:"entries"`
+	Pagination FeedbackListPagination `json:"pagination"`
+}
+
+// ReleaseNotesQuery pages and filters FetchReleaseNotesWithQuery. Zero
+// fields are not sent, leaving the server defaults.
+type ReleaseNotesQuery struct {
+	Page     int
+	PageSize int
+	// Locale asks for notes in a BCP 47 language, e.g. "zh-CN"; the server
+	// falls back to the default language where no translation exists.
+	Locale string
+	// SinceVersion keeps only versions newer than it.
+	SinceVersion string
+}
+
+func (q ReleaseNotesQuery) encode(query url.Values) {
+	if q.Page > 0 {
+		query.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(q.PageSize))
+	}
+	if q.Locale != "" {
+		query.Set("locale", q.Locale)
+	}
+	if q.SinceVersion != "" {
+		query.Set("since_version", q.SinceVersion)
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -159,8 +261,16 @@ type ReleaseNotesResponse struct {
 
 // SubmitFeedback submits a new feedback item to BanyanHub.
 func (g *Guard) SubmitFeedback(ctx context.Context, req SubmitFeedbackRequest) (*FeedbackItem, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
+	if err := g.requireCapability(CapabilityFeedback); err != nil {
+		return nil, err
+	}
+
 	body := submitFeedbackBody{
-		LicenseKey:  g.cfg.LicenseKey,
+		LicenseKey:  g.bodyLicenseKey(),
 		MachineID:   g.fingerprint.MachineID(),
 		ProjectSlug: g.cfg.ProjectSlug,
 		UserID:      req.UserID,
@@ -178,9 +288,11 @@ func (g *Guard) SubmitFeedback(ctx context.Context, req SubmitFeedbackRequest) (
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
 	raw, err := g.postJSON(ctx, "/api/v1/feedbacks", bodyJSON)
 	if err != nil {
-		return nil, fmt.Errorf("submit feedback: %w", err)
+		return nil, fmt.Errorf("submit feedback: %w", g.capabilityErr(CapabilityFeedback, err))
 	}
 	if err := json.Unmarshal(raw, &item); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
@@ -190,17 +302,30 @@ func (g *Guard) SubmitFeedback(ctx context.Context, req SubmitFeedbackRequest) (
 
 // ListMyFeedback returns a paginated list of feedback items for the given user.
 func (g *Guard) ListMyFeedback(ctx context.Context, userID string, page, pageSize int) (*FeedbackListResponse, error) {
+	return g.ListMyFeedbackWithQuery(ctx, FeedbackQuery{UserID: userID, Page: page, PageSize: pageSize})
+}
+
+// ListMyFeedbackWithQuery returns the page of feedback items matching q.
+func (g *Guard) ListMyFeedbackWithQuery(ctx context.Context, q FeedbackQuery) (*FeedbackListResponse, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	query := url.Values{}
-	query.Set("license_key", g.cfg.LicenseKey)
+	g.setLicenseQuery(query)
 	query.Set("project_slug", g.cfg.ProjectSlug)
-	query.Set("user_id", userID)
-	query.Set("page", strconv.Itoa(page))
-	query.Set("page_size", strconv.Itoa(pageSize))
+	q.encode(query)
+
+	if err := g.requireCapability(CapabilityFeedback); err != nil {
+		return nil, err
+	}
 
 	var resp FeedbackListResponse
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
 	raw, err := g.getJSON(ctx, "/api/v1/feedbacks", query)
 	if err != nil {
-		return nil, fmt.Errorf("list feedback: %w", err)
+		return nil, fmt.Errorf("list feedback: %w", g.capabilityErr(CapabilityFeedback, err))
 	}
 	if err := json.Unmarshal(raw, &resp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
@@ -208,10 +333,25 @@ func (g *Guard) ListMyFeedback(ctx context.Context, userID string, page, pageSiz
 	return &resp, nil
 }
 
+// CountMyFeedback returns how many feedback items match q, ignoring its
+// paging, e.g. to badge the open items in a feedback center.
+func (g *Guard) CountMyFeedback(ctx context.Context, q FeedbackQuery) (int, error) {
+	q.Page, q.PageSize = 1, 1
+	resp, err := g.ListMyFeedbackWithQuery(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Total(), nil
+}
+
 // UploadFeedbackFile uploads an attachment for use in a feedback submission.
 // The returned UploadURLResponse contains the file_key to reference in
 // SubmitFeedbackRequest.Attachments.
 func (g *Guard) UploadFeedbackFile(ctx context.Context, fileName string, contentType string, data io.Reader) (*UploadURLResponse, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	uploadTarget, err := g.prepareFeedbackUpload(ctx, fileName)
 	if err != nil {
 		return nil, err
@@ -227,7 +367,9 @@ func (g *Guard) UploadFeedbackFile(ctx context.Context, fileName string, content
 		var err error
 		defer func() { pw.CloseWithError(err) }()
 
-		_ = writer.WriteField("license_key", g.cfg.LicenseKey)
+		if key := g.bodyLicenseKey(); key != "" {
+			_ = writer.WriteField("license_key", key)
+		}
 		_ = writer.WriteField("project_slug", g.cfg.ProjectSlug)
 		_ = writer.WriteField("file_key", uploadTarget.FileKey)
 		if contentType != "" {
@@ -246,6 +388,8 @@ func (g *Guard) UploadFeedbackFile(ctx context.Context, fileName string, content
 		err = writer.Close()
 	}()
 
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.Upload)
+	defer cancel()
 	fullURL := g.feedbackUploadURL(uploadTarget.UploadURL)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, pr)
 	if err != nil {
@@ -253,10 +397,13 @@ func (g *Guard) UploadFeedbackFile(ctx context.Context, fileName string, content
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
+	g.signRequestDigest(req, unsignedPayload)
 
+	start := time.Now()
 	resp, err := g.httpClient.Do(req)
+	g.observeRequest(req.URL, start)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrNetworkError, err)
+		return nil, g.redactErr(fmt.Errorf("%w: %v", ErrNetworkError, err))
 	}
 	defer resp.Body.Close()
 
@@ -282,8 +429,12 @@ func (g *Guard) UploadFeedbackFile(ctx context.Context, fileName string, content
 }
 
 func (g *Guard) prepareFeedbackUpload(ctx context.Context, fileName string) (*UploadURLResponse, error) {
+	if err := g.requireCapability(CapabilityFeedback); err != nil {
+		return nil, err
+	}
+
 	body := prepareFeedbackUploadBody{
-		LicenseKey:  g.cfg.LicenseKey,
+		LicenseKey:  g.bodyLicenseKey(),
 		ProjectSlug: g.cfg.ProjectSlug,
 		FileName:    fileName,
 	}
@@ -293,9 +444,11 @@ func (g *Guard) prepareFeedbackUpload(ctx context.Context, fileName string) (*Up
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
 	raw, err := g.postJSON(ctx, "/api/v1/feedbacks/upload-url", bodyJSON)
 	if err != nil {
-		return nil, fmt.Errorf("prepare feedback upload: %w", err)
+		return nil, fmt.Errorf("prepare feedback upload: %w", g.capabilityErr(CapabilityFeedback, err))
 	}
 	if err := json.Unmarshal(raw, &resp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
@@ -310,29 +463,130 @@ func (g *Guard) feedbackUploadURL(uploadURL string) string {
 	if uploadURL == "" {
 		uploadURL = "/api/v1/feedbacks/upload"
 	}
-	return serverURLForPath(g.cfg.ServerURL, uploadURL)
+	return g.serverLinkURL(uploadURL)
 }
 
 // FetchReleaseNotes retrieves the release notes grouped by version.
 func (g *Guard) FetchReleaseNotes(ctx context.Context) (*ReleaseNotesResponse, error) {
+	return g.FetchReleaseNotesWithQuery(ctx, ReleaseNotesQuery{})
+}
+
+// FetchReleaseNotesWithQuery retrieves one page of release notes matching q.
+// SinceVersion is also applied to the returned page, for servers that ignore
+// it.
+func (g *Guard) FetchReleaseNotesWithQuery(ctx context.Context, q ReleaseNotesQuery) (*ReleaseNotesResponse, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	query := url.Values{}
-	query.Set("license_key", g.cfg.LicenseKey)
+	g.setLicenseQuery(query)
 	query.Set("project_slug", g.cfg.ProjectSlug)
+	q.encode(query)
+
+	if err := g.requireCapability(CapabilityFeedback); err != nil {
+		return nil, err
+	}
 
 	var wire releaseNotesWireResponse
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
 	raw, err := g.getJSON(ctx, "/api/v1/feedbacks/release-notes", query)
 	if err != nil {
-		return nil, fmt.Errorf("fetch release notes: %w", err)
+		return nil, fmt.Errorf("fetch release notes: %w", g.capabilityErr(CapabilityFeedback, err))
 	}
 	if err := json.Unmarshal(raw, &wire); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
-	return wire.toSDKResponse(), nil
+	resp := wire.toSDKResponse()
+	if q.SinceVersion != "" {
+		entries := resp.Entries[:0]
+		for _, entry := range resp.Entries {
+			if IsNewer(q.SinceVersion, entry.Version) {
+				entries = append(entries, entry)
+			}
+		}
+		resp.Entries = entries
+	}
+	return resp, nil
+}
+
+// ReleaseNotesSince returns the notes for this component's versions newer
+// than lastSeenVersion, newest first, fetching every page. When the guard
+// runs a semver version, later versions are left out. Pass the version whose
+// notes the user last saw, e.g. to show "what's new" once after an update.
+func (g *Guard) ReleaseNotesSince(ctx context.Context, lastSeenVersion, locale string) ([]ReleaseNoteEntry, error) {
+	current := g.currentVersion()
+	_, err := ParseSemVer(current)
+	bounded := err == nil
+	var entries []ReleaseNoteEntry
+	for page := 1; ; page++ {
+		resp, err := g.FetchReleaseNotesWithQuery(ctx, ReleaseNotesQuery{
+			Page:         page,
+			PageSize:     releaseNotesPageSize,
+			Locale:       locale,
+			SinceVersion: lastSeenVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range resp.Entries {
+			if entry.ComponentSlug != "" && entry.ComponentSlug != g.cfg.ComponentSlug {
+				continue
+			}
+			if bounded && IsNewer(current, entry.Version) {
+				continue
+			}
+			// Servers that ignore page and page_size repeat the same
+			// entries on every page, so each version is kept once.
+			if containsReleaseNote(entries, entry.Version) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if resp.Pagination.Total == 0 || page*releaseNotesPageSize >= resp.Pagination.Total {
+			break
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return releaseNoteNewer(entries[i], entries[j]) })
+	return entries, nil
+}
+
+func containsReleaseNote(entries []ReleaseNoteEntry, version string) bool {
+	for _, entry := range entries {
+		if SameVersion(entry.Version, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseNoteNewer orders release notes newest first. Semver versions come
+// first in version order; other tags follow, newest CreatedAt first, with the
+// tag itself breaking ties so the order is total.
+func releaseNoteNewer(a, b ReleaseNoteEntry) bool {
+	av, aErr := ParseSemVer(a.Version)
+	bv, bErr := ParseSemVer(b.Version)
+	if (aErr == nil) != (bErr == nil) {
+		return aErr == nil
+	}
+	if aErr == nil {
+		if c := av.Compare(bv); c != 0 {
+			return c > 0
+		}
+	}
+	at, _ := parseRFC3339(a.CreatedAt)
+	bt, _ := parseRFC3339(b.CreatedAt)
+	if !at.Equal(bt) {
+		return at.After(bt)
+	}
+	return normalizeVersionTag(a.Version) > normalizeVersionTag(b.Version)
 }
 
 type releaseNotesWireResponse struct {
-	Entries  []ReleaseNoteEntry     `json:"entries"`
-	Releases []releaseNotesWireItem `json:"releases"`
+	Entries    []ReleaseNoteEntry     `json:"entries"`
+	Releases   []releaseNotesWireItem `json:"releases"`
+	Pagination FeedbackListPagination `json:"pagination"`
 }
 
 type releaseNotesWireItem struct {
@@ -346,7 +600,7 @@ type releaseNotesWireItem struct {
 
 func (r releaseNotesWireResponse) toSDKResponse() *ReleaseNotesResponse {
 	if len(r.Entries) > 0 || len(r.Releases) == 0 {
-		return &ReleaseNotesResponse{Entries: r.Entries}
+		return &ReleaseNotesResponse{Entries: r.Entries, Pagination: r.Pagination}
 	}
 
 	entries := make([]ReleaseNoteEntry, 0, len(r.Releases))
@@ -369,5 +623,5 @@ func (r releaseNotesWireResponse) toSDKResponse() *ReleaseNotesResponse {
 			CreatedAt:         release.CreatedAt,
 		})
 	}
-	return &ReleaseNotesResponse{Entries: entries}
+	return &ReleaseNotesResponse{Entries: entries, Pagination: r.Pagination}
 }
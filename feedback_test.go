@@ -10,7 +10,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func newFeedbackTestGuard(t *testing.T, serverURL string) *Guard {
@@ -220,6 +223,50 @@ func TestListMyFeedback_SendsProjectSlug(t *testing.T) {
 	}
 }
 
+func TestCountMyFeedback_SendsFilters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		want := map[string]string{
+			"user_id":        "user-001",
+			"page":           "1",
+			"page_size":      "1",
+			"status":         "pending,processing",
+			"category":       "bug",
+			"created_after":  "2026-01-01T00:00:00Z",
+			"created_before": "",
+			"q":              "slow start",
+			"sort":           "updated_at_desc",
+		}
+		for key, value := range want {
+			if got := query.Get(key); got != value {
+				t.Errorf("query %s = %q, want %q", key, got, value)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(FeedbackListResponse{
+			Feedbacks:  []FeedbackItem{{ID: "fb-1"}},
+			Pagination: FeedbackListPagination{Total: 7, Page: 1, PageSize: 1},
+		})
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	count, err := guard.CountMyFeedback(context.Background(), FeedbackQuery{
+		UserID:       "user-001",
+		PageSize:     50,
+		Statuses:     []FeedbackStatus{FeedbackPending, FeedbackProcessing},
+		Categories:   []FeedbackCategory{FeedbackBug},
+		CreatedAfter: time.Date(2026, 1, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600)),
+		Search:       "slow start",
+		Sort:         FeedbackSortRecentlyUpdated,
+	})
+	if err != nil {
+		t.Fatalf("count feedback: %v", err)
+	}
+	if count != 7 {
+		t.Fatalf("expected 7, got %d", count)
+	}
+}
+
 func TestFetchReleaseNotes_WorkerReleasesShape(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/v1/feedbacks/release-notes" {
@@ -298,3 +345,129 @@ func TestFetchReleaseNotes_LegacyEntriesShape(t *testing.T) {
 		t.Fatalf("unexpected legacy release notes: %#v", notes.Entries)
 	}
 }
+
+func TestWatchFeedbackDeliversNewReplies(t *testing.T) {
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/feedbacks/fb-1" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		item := FeedbackItem{ID: "fb-1", Status: FeedbackProcessing, Replies: []FeedbackReply{{ID: "r-1", Content: "looking into it"}}}
+		switch n := polls.Add(1); {
+		case n == 2:
+			item.Replies = append(item.Replies, FeedbackReply{ID: "r-2", Content: "fixed in 1.2.0"})
+		case n > 2:
+			item.Status = FeedbackClosed
+			item.Replies = append(item.Replies, FeedbackReply{ID: "r-2", Content: "fixed in 1.2.0"})
+		}
+		_ = json.NewEncoder(w).Encode(item)
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	guard.httpClient = srv.Client()
+	guard.cfg.FeedbackPollInterval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	replies, err := guard.WatchFeedback(ctx, "fb-1")
+	if err != nil {
+		t.Fatalf("WatchFeedback: %v", err)
+	}
+	var got []string
+	for reply := range replies {
+		got = append(got, reply.ID)
+	}
+	if len(got) != 1 || got[0] != "r-2" {
+		t.Fatalf("expected only the new reply r-2, got %v", got)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expected the channel to close once the feedback was closed")
+	}
+}
+
+func TestApplyFeedbackRepliesFiresOncePerReply(t *testing.T) {
+	guard := newFeedbackTestGuard(t, "http://example.invalid")
+	var got []string
+	guard.cfg.OnFeedbackReply = func(feedbackID string, reply FeedbackReply) {
+		got = append(got, feedbackID+"/"+reply.ID)
+	}
+
+	replies := []heartbeatFeedbackReply{{FeedbackID: "fb-1", Reply: FeedbackReply{ID: "r-1"}}}
+	guard.applyFeedbackReplies(replies)
+	guard.applyFeedbackReplies(append(replies, heartbeatFeedbackReply{FeedbackID: "fb-2", Reply: FeedbackReply{ID: "r-9"}}))
+	if len(got) != 2 || got[0] != "fb-1/r-1" || got[1] != "fb-2/r-9" {
+		t.Fatalf("unexpected callbacks: %v", got)
+	}
+}
+
+func TestReleaseNotesSince_PagesAndBoundsByCurrentVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("locale") != "zh-CN" || query.Get("since_version") != "1.0.0" || query.Get("page_size") != "50" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		// The server ignores since_version, so the SDK has to filter.
+		var entries []ReleaseNoteEntry
+		switch query.Get("page") {
+		case "1":
+			entries = []ReleaseNoteEntry{{Version: "1.3.0"}, {Version: "1.1.0"}, {Version: "1.2.0", ComponentSlug: "frontend"}}
+		case "2":
+			entries = []ReleaseNoteEntry{{Version: "1.0.0"}, {Version: "1.2.0", ComponentSlug: "backend"}}
+		default:
+			t.Errorf("unexpected page %s", query.Get("page"))
+		}
+		_ = json.NewEncoder(w).Encode(ReleaseNotesResponse{
+			Entries:    entries,
+			Pagination: FeedbackListPagination{Total: 60, PageSize: 50},
+		})
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	guard.SetVersion("1.2.0")
+	entries, err := guard.ReleaseNotesSince(context.Background(), "1.0.0", "zh-CN")
+	if err != nil {
+		t.Fatalf("release notes since: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Version != "1.2.0" || entries[1].Version != "1.1.0" {
+		t.Fatalf("unexpected entries: %#v", entries)
+	}
+}
+
+func TestReleaseNotesSince_ServerIgnoringPagesListsEachVersionOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ReleaseNotesResponse{
+			Entries:    []ReleaseNoteEntry{{Version: "1.1.0"}, {Version: "v1.2.0"}},
+			Pagination: FeedbackListPagination{Total: 120, PageSize: 50},
+		})
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	guard.SetVersion("1.2.0")
+	entries, err := guard.ReleaseNotesSince(context.Background(), "1.0.0", "")
+	if err != nil {
+		t.Fatalf("release notes since: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Version != "v1.2.0" || entries[1].Version != "1.1.0" {
+		t.Fatalf("unexpected entries: %#v", entries)
+	}
+}
+
+func TestReleaseNoteNewer_IsATotalOrder(t *testing.T) {
+	entries := []ReleaseNoteEntry{
+		{Version: "build-7", CreatedAt: "2026-03-01T00:00:00Z"},
+		{Version: "1.0.0"},
+		{Version: "build-9", CreatedAt: "2026-05-01T00:00:00Z"},
+		{Version: "2.0.0"},
+		{Version: "build-8", CreatedAt: "2026-05-01T00:00:00Z"},
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return releaseNoteNewer(entries[i], entries[j]) })
+	want := []string{"2.0.0", "1.0.0", "build-9", "build-8", "build-7"}
+	for i, entry := range entries {
+		if entry.Version != want[i] {
+			t.Fatalf("order = %v, want %v", entries, want)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package sdk
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const gzipContentType = "application/gzip"
+
+// FeedbackUpload is one file for UploadFeedbackFiles.
+type FeedbackUpload struct {
+	FileName string
+	// ContentType defaults to the type registered for the file extension.
+	ContentType string
+	Data        io.Reader
+	// Size is the length of Data, checked against the server limits before
+	// anything is sent and reported as the progress total; 0 means unknown.
+	// Compressed files are checked while streaming instead.
+	Size int64
+	// Kind is copied to the returned FeedbackAttachment (default "file").
+	Kind string
+}
+
+// FeedbackUploadOptions configures UploadFeedbackFiles.
+type FeedbackUploadOptions struct {
+	// OnProgress fires as each file streams, with the bytes read from Data
+	// so far and FeedbackUpload.Size.
+	OnProgress func(fileName string, sent, total int64)
+	// DisableCompression sends text files as they are. By default text/*
+	// files, .log and .txt are gzipped and sent as name.gz when the server
+	// accepts application/gzip.
+	DisableCompression bool
+}
+
+// FeedbackUploadLimits are the attachment limits the server announces when
+// an upload is prepared. Zero values are unlimited.
+type FeedbackUploadLimits struct {
+	MaxFileBytes        int64    `json:"max_file_bytes"`
+	MaxTotalBytes       int64    `json:"max_total_bytes"`
+	MaxFiles            int      `json:"max_files"`
+	AllowedContentTypes []string `json:"allowed_content_types"`
+}
+
+type prepareFeedbackUploadFile struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type,omitempty"`
+	SizeBytes   int64  `json:"size_bytes,omitempty"`
+}
+
+type prepareFeedbackUploadsBody struct {
+	LicenseKey  string                      `json:"license_key,omitempty"`
+	ProjectSlug string                      `json:"project_slug"`
+	Files       []prepareFeedbackUploadFile `json:"files"`
+}
+
+type prepareFeedbackUploadsResponse struct {
+	UploadURL string                  `json:"upload_url"`
+	Files     []feedbackUploadFileKey `json:"files"`
+	Limits    FeedbackUploadLimits    `json:"limits"`
+}
+
+type feedbackUploadFileKey struct {
+	FileName string `json:"file_name,omitempty"`
+	FileKey  string `json:"file_key"`
+}
+
+// feedbackUploadPart is a file as it goes on the wire.
+type feedbackUploadPart struct {
+	upload      FeedbackUpload
+	fileName    string
+	contentType string
+	gzip        bool
+	fileKey     string
+	// sent is the number of bytes that went on the wire.
+	sent int64
+}
+
+// UploadFeedbackFiles streams several attachments in one multipart request
+// and returns them ready for SubmitFeedbackRequest.Attachments. Sizes and
+// content types are checked against the limits the server announces;
+// violations fail with ErrUploadInvalid before any file is sent.
+func (g *Guard) UploadFeedbackFiles(ctx context.Context, files []FeedbackUpload, opts FeedbackUploadOptions) ([]FeedbackAttachment, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: files", ErrMissingParameter)
+	}
+
+	parts := make([]*feedbackUploadPart, len(files))
+	prepare := prepareFeedbackUploadsBody{LicenseKey: g.bodyLicenseKey(), ProjectSlug: g.cfg.ProjectSlug}
+	for i, file := range files {
+		if file.FileName == "" || file.Data == nil {
+			return nil, fmt.Errorf("%w: file name and data", ErrMissingParameter)
+		}
+		if file.ContentType == "" {
+			file.ContentType = mime.TypeByExtension(filepath.Ext(file.FileName))
+		}
+		if file.ContentType == "" {
+			file.ContentType = "application/octet-stream"
+		}
+		parts[i] = &feedbackUploadPart{upload: file, fileName: file.FileName, contentType: file.ContentType}
+		prepare.Files = append(prepare.Files, prepareFeedbackUploadFile{
+			FileName:    file.FileName,
+			ContentType: file.ContentType,
+			SizeBytes:   file.Size,
+		})
+	}
+
+	target, err := g.prepareFeedbackUploads(ctx, prepare)
+	if err != nil {
+		return nil, err
+	}
+	if len(target.Files) != len(parts) {
+		return nil, ErrInvalidServerResponse
+	}
+	for i, part := range parts {
+		if target.Files[i].FileKey == "" {
+			return nil, ErrInvalidServerResponse
+		}
+		part.fileKey = target.Files[i].FileKey
+		if !opts.DisableCompression && isTextUpload(part) && contentTypeAllowed(target.Limits, gzipContentType) {
+			part.gzip = true
+			part.fileName += ".gz"
+			part.contentType = gzipContentType
+		}
+	}
+	if err := validateFeedbackUploads(parts, target.Limits); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	streamErr := make(chan error, 1)
+	go func() {
+		err := g.writeFeedbackUploads(writer, parts, target.Limits, opts.OnProgress)
+		streamErr <- err
+		pw.CloseWithError(err)
+	}()
+
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.Upload)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.feedbackUploadURL(target.UploadURL), pr)
+	if err != nil {
+		pr.Close()
+		return nil, fmt.Errorf("create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
+	g.signRequestDigest(req, unsignedPayload)
+
+	start := time.Now()
+	resp, err := g.httpClient.Do(req)
+	g.observeRequest(req.URL, start)
+	if err != nil {
+		select {
+		case werr := <-streamErr:
+			if errors.Is(werr, ErrUploadInvalid) {
+				return nil, werr
+			}
+		default:
+		}
+		return nil, g.redactErr(fmt.Errorf("%w: %v", ErrNetworkError, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAPIErrorResponse(resp)
+	}
+	if _, err := readAPIJSONResponse(resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	if err := <-streamErr; err != nil {
+		return nil, err
+	}
+
+	attachments := make([]FeedbackAttachment, len(parts))
+	for i, part := range parts {
+		kind := part.upload.Kind
+		if kind == "" {
+			kind = "file"
+		}
+		attachments[i] = FeedbackAttachment{
+			Kind:        kind,
+			FileKey:     part.fileKey,
+			FileName:    part.fileName,
+			ContentType: part.contentType,
+			SizeBytes:   part.sent,
+		}
+	}
+	return attachments, nil
+}
+
+func (g *Guard) prepareFeedbackUploads(ctx context.Context, body prepareFeedbackUploadsBody) (*prepareFeedbackUploadsResponse, error) {
+	if err := g.requireCapability(CapabilityFeedback); err != nil {
+		return nil, err
+	}
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
+	raw, err := g.postJSON(ctx, "/api/v1/feedbacks/upload-url", bodyJSON)
+	if err != nil {
+		return nil, fmt.Errorf("prepare feedback upload: %w", g.capabilityErr(CapabilityFeedback, err))
+	}
+	var resp prepareFeedbackUploadsResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	return &resp, nil
+}
+
+func (g *Guard) writeFeedbackUploads(writer *multipart.Writer, parts []*feedbackUploadPart, limits FeedbackUploadLimits, onProgress func(string, int64, int64)) error {
+	if key := g.bodyLicenseKey(); key != "" {
+		if err := writer.WriteField("license_key", key); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteField("project_slug", g.cfg.ProjectSlug); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if err := writer.WriteField("file_key", part.fileKey); err != nil {
+			return err
+		}
+	}
+
+	var total int64
+	for _, part := range parts {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": part.fileName}))
+		header.Set("Content-Type", part.contentType)
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		limited := &uploadLimitWriter{w: w, name: part.upload.FileName, fileLimit: limits.MaxFileBytes, totalLimit: limits.MaxTotalBytes, total: &total}
+		var dst io.Writer = limited
+		var zw *gzip.Writer
+		if part.gzip {
+			zw = gzip.NewWriter(limited)
+			dst = zw
+		}
+		src := &progressReader{r: part.upload.Data, name: part.upload.FileName, total: part.upload.Size, onProgress: onProgress}
+		if _, err := io.Copy(dst, src); err != nil {
+			return err
+		}
+		if zw != nil {
+			if err := zw.Close(); err != nil {
+				return err
+			}
+		}
+		part.sent = limited.written
+	}
+	return writer.Close()
+}
+
+// validateFeedbackUploads checks the files against the server limits before
+// anything is sent. Unknown and compressed sizes are enforced while streaming
+// instead.
+func validateFeedbackUploads(parts []*feedbackUploadPart, limits FeedbackUploadLimits) error {
+	if limits.MaxFiles > 0 && len(parts) > limits.MaxFiles {
+		return fmt.Errorf("%w: %d files exceed the limit of %d", ErrUploadInvalid, len(parts), limits.MaxFiles)
+	}
+	var total int64
+	for _, part := range parts {
+		if !contentTypeAllowed(limits, part.contentType) {
+			return fmt.Errorf("%w: %s: content type %s not allowed", ErrUploadInvalid, part.fileName, part.contentType)
+		}
+		if part.gzip {
+			continue
+		}
+		if limits.MaxFileBytes > 0 && part.upload.Size > limits.MaxFileBytes {
+			return fmt.Errorf("%w: %s: %d bytes exceed the limit of %d", ErrUploadInvalid, part.fileName, part.upload.Size, limits.MaxFileBytes)
+		}
+		total += part.upload.Size
+	}
+	if limits.MaxTotalBytes > 0 && total > limits.MaxTotalBytes {
+		return fmt.Errorf("%w: %d bytes exceed the total limit of %d", ErrUploadInvalid, total, limits.MaxTotalBytes)
+	}
+	return nil
+}
+
+func contentTypeAllowed(limits FeedbackUploadLimits, contentType string) bool {
+	if len(limits.AllowedContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	for _, allowed := range limits.AllowedContentTypes {
+		if allowed == mediaType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTextUpload(part *feedbackUploadPart) bool {
+	switch strings.ToLower(filepath.Ext(part.fileName)) {
+	case ".log", ".txt":
+		return true
+	}
+	return strings.HasPrefix(part.contentType, "text/")
+}
+
+type progressReader struct {
+	r          io.Reader
+	name       string
+	sent       int64
+	total      int64
+	onProgress func(string, int64, int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		if p.onProgress != nil {
+			p.onProgress(p.name, p.sent, p.total)
+		}
+	}
+	return n, err
+}
+
+// uploadLimitWriter stops a stream once it exceeds the server limits, for
+// files whose size was not known up front.
+type uploadLimitWriter struct {
+	w          io.Writer
+	name       string
+	written    int64
+	fileLimit  int64
+	totalLimit int64
+	total      *int64
+}
+
+func (u *uploadLimitWriter) Write(b []byte) (int, error) {
+	u.written += int64(len(b))
+	*u.total += int64(len(b))
+	if u.fileLimit > 0 && u.written > u.fileLimit {
+		return 0, fmt.Errorf("%w: %s exceeds the limit of %d bytes", ErrUploadInvalid, u.name, u.fileLimit)
+	}
+	if u.totalLimit > 0 && *u.total > u.totalLimit {
+		return 0, fmt.Errorf("%w: attachments exceed the total limit of %d bytes", ErrUploadInvalid, u.totalLimit)
+	}
+	return u.w.Write(b)
+}
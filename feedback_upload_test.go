@@ -0,0 +1,121 @@
+package sdk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUploadFeedbackFiles_StreamsAllFilesInOneRequest(t *testing.T) {
+	logText := strings.Repeat("INFO started\n", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/feedbacks/upload-url":
+			var body prepareFeedbackUploadsBody
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if len(body.Files) != 2 || body.Files[0].ContentType != "image/png" || body.Files[1].SizeBytes != int64(len(logText)) {
+				t.Errorf("unexpected prepare body: %#v", body)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"files":  []map[string]string{{"file_key": "k-1"}, {"file_key": "k-2"}},
+				"limits": map[string]any{"max_file_bytes": 1 << 20, "allowed_content_types": []string{"image/*", "text/plain", "application/gzip"}},
+			})
+		case "/api/v1/feedbacks/upload":
+			reader, err := r.MultipartReader()
+			if err != nil {
+				t.Fatalf("multipart: %v", err)
+			}
+			var keys, names []string
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("next part: %v", err)
+				}
+				data, _ := io.ReadAll(part)
+				switch part.FormName() {
+				case "file_key":
+					keys = append(keys, string(data))
+				case "file":
+					names = append(names, part.FileName())
+					if part.FileName() == "app.log.gz" {
+						zr, err := gzip.NewReader(bytes.NewReader(data))
+						if err != nil {
+							t.Fatalf("expected gzipped log: %v", err)
+						}
+						if plain, _ := io.ReadAll(zr); string(plain) != logText {
+							t.Error("gzipped log does not round-trip")
+						}
+					}
+				}
+			}
+			if strings.Join(keys, ",") != "k-1,k-2" || strings.Join(names, ",") != "shot.png,app.log.gz" {
+				t.Errorf("unexpected parts: keys %v names %v", keys, names)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	progress := map[string]int64{}
+	attachments, err := guard.UploadFeedbackFiles(context.Background(), []FeedbackUpload{
+		{FileName: "shot.png", Data: strings.NewReader("png-bytes"), Size: 9, Kind: "screenshot"},
+		{FileName: "app.log", Data: strings.NewReader(logText), Size: int64(len(logText))},
+	}, FeedbackUploadOptions{OnProgress: func(name string, sent, total int64) {
+		if sent > total {
+			t.Errorf("progress %d beyond total %d", sent, total)
+		}
+		progress[name] = sent
+	}})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if len(attachments) != 2 || attachments[0].Kind != "screenshot" || attachments[1].FileName != "app.log.gz" || attachments[1].FileKey != "k-2" || attachments[0].SizeBytes != 9 {
+		t.Fatalf("unexpected attachments: %#v", attachments)
+	}
+	if progress["shot.png"] != 9 || progress["app.log"] != int64(len(logText)) {
+		t.Fatalf("unexpected progress: %v", progress)
+	}
+}
+
+func TestUploadFeedbackFiles_RejectsFilesOverServerLimits(t *testing.T) {
+	var uploads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/feedbacks/upload" {
+			uploads.Add(1)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"files":  []map[string]string{{"file_key": "k-1"}},
+			"limits": map[string]any{"max_file_bytes": 4, "allowed_content_types": []string{"image/png"}},
+		})
+	}))
+	defer srv.Close()
+
+	guard := newFeedbackTestGuard(t, srv.URL)
+	cases := []FeedbackUpload{
+		{FileName: "shot.png", Data: strings.NewReader("too-large"), Size: 9},
+		{FileName: "clip.mp4", Data: strings.NewReader("mp4"), Size: 3},
+		{FileName: "shot.png", Data: strings.NewReader("too-large")},
+	}
+	for _, file := range cases {
+		if _, err := guard.UploadFeedbackFiles(context.Background(), []FeedbackUpload{file}, FeedbackUploadOptions{}); !errors.Is(err, ErrUploadInvalid) {
+			t.Fatalf("%s: expected ErrUploadInvalid, got %v", file.FileName, err)
+		}
+	}
+	if uploads.Load() > 1 {
+		t.Fatalf("expected only the unknown-size file to reach the upload endpoint, got %d uploads", uploads.Load())
+	}
+}
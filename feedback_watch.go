@@ -0,0 +1,119 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// heartbeatFeedbackReply is a support reply to feedback filed from this
+// machine, pushed with a heartbeat.
+type heartbeatFeedbackReply struct {
+	FeedbackID string        `json:"feedback_id"`
+	Reply      FeedbackReply `json:"reply"`
+}
+
+// GetFeedback returns a single feedback item with its replies.
+func (g *Guard) GetFeedback(ctx context.Context, id string) (*FeedbackItem, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, fmt.Errorf("%w: id", ErrMissingParameter)
+	}
+
+	query := url.Values{}
+	g.setLicenseQuery(query)
+	query.Set("project_slug", g.cfg.ProjectSlug)
+
+	if err := g.requireCapability(CapabilityFeedback); err != nil {
+		return nil, err
+	}
+
+	var item FeedbackItem
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
+	raw, err := g.getJSON(ctx, "/api/v1/feedbacks/"+url.PathEscape(id), query)
+	if err != nil {
+		return nil, fmt.Errorf("get feedback: %w", g.capabilityErr(CapabilityFeedback, err))
+	}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	return &item, nil
+}
+
+// WatchFeedback polls a feedback item every Config.FeedbackPollInterval and
+// delivers replies posted after the call. Failed polls are logged and
+// retried. The channel is closed when ctx is done or the item is closed.
+func (g *Guard) WatchFeedback(ctx context.Context, id string) (<-chan FeedbackReply, error) {
+	item, err := g.GetFeedback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(item.Replies))
+	for _, reply := range item.Replies {
+		seen[reply.ID] = struct{}{}
+	}
+
+	ch := make(chan FeedbackReply, 8)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(g.cfg.FeedbackPollInterval)
+		defer ticker.Stop()
+		status := item.Status
+		for status != FeedbackClosed {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			item, err := g.GetFeedback(ctx, id)
+			if err != nil {
+				if ctx.Err() == nil {
+					g.logger.Warn("feedback poll failed", "feedback_id", id, "error", err)
+				}
+				continue
+			}
+			for _, reply := range item.Replies {
+				if _, ok := seen[reply.ID]; ok {
+					continue
+				}
+				seen[reply.ID] = struct{}{}
+				select {
+				case ch <- reply:
+				case <-ctx.Done():
+					return
+				}
+			}
+			status = item.Status
+		}
+	}()
+	return ch, nil
+}
+
+// applyFeedbackReplies hands replies pushed with a heartbeat to
+// Config.OnFeedbackReply, once per reply.
+func (g *Guard) applyFeedbackReplies(replies []heartbeatFeedbackReply) {
+	if len(replies) == 0 || g.cfg.OnFeedbackReply == nil {
+		return
+	}
+	var fresh []heartbeatFeedbackReply
+	g.mu.Lock()
+	if g.feedbackRepliesSeen == nil {
+		g.feedbackRepliesSeen = make(map[string]struct{})
+	}
+	for _, r := range replies {
+		if _, ok := g.feedbackRepliesSeen[r.Reply.ID]; ok {
+			continue
+		}
+		g.feedbackRepliesSeen[r.Reply.ID] = struct{}{}
+		fresh = append(fresh, r)
+	}
+	g.mu.Unlock()
+	for _, r := range fresh {
+		g.cfg.OnFeedbackReply(r.FeedbackID, r.Reply)
+	}
+}
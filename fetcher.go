@@ -0,0 +1,134 @@
+package sdk
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FetchRequest is the download metadata the server returned for one update
+// artifact. DownloadURL is where the SDK would fetch it from; a Fetcher may
+// ignore it and look the artifact up by component, version and platform.
+// DigestAlgorithm and Digest name the hash the artifact is checked against
+// (see DigestSHA256); SHA256 is also set when that algorithm is SHA-256.
+type FetchRequest struct {
+	Component       string
+	Version         string
+	OS              string
+	Arch            string
+	DownloadURL     string
+	SHA256          string
+	DigestAlgorithm string
+	Digest          string
+	Signature       string
+}
+
+// digest returns the algorithm and expected hex digest of the artifact;
+// requests built with only SHA256 mean SHA-256.
+func (req FetchRequest) digest() (algorithm, digest string) {
+	if req.DigestAlgorithm == "" {
+		return DigestSHA256, req.SHA256
+	}
+	return req.DigestAlgorithm, req.Digest
+}
+
+// Fetcher sources update artifact bytes on behalf of the SDK, e.g. from a
+// customer's artifact mirror. It takes precedence over
+// Transport.FetchArtifact. The SDK still enforces the size limit and
+// checks the digest and signature of whatever the reader yields, so a
+// Fetcher cannot install an artifact the server did not sign.
+type Fetcher interface {
+	Fetch(ctx context.Context, req FetchRequest) (io.ReadCloser, error)
+}
+
+// fetchArtifact downloads an update artifact into a temporary file and
+// returns its hex digest in req's algorithm. With the artifact cache it
+// looks there first, then with LANSharing asks peers, and keeps what passes
+// verification; otherwise it opens the artifact with openArtifact.
+func (g *Guard) fetchArtifact(ctx context.Context, req FetchRequest, dir string, maxBytes int64) (tmpPath, digest string, err error) {
+	if tmpPath, digest, ok := g.takePrefetchedArtifact(req); ok {
+		return tmpPath, digest, nil
+	}
+	algorithm, want := req.digest()
+	if !g.artifactCacheEnabled() || want == "" {
+		return g.fetchArtifactFromSource(ctx, req, dir, maxBytes)
+	}
+	if tmpPath, ok := g.cachedArtifact(algorithm, want, dir, maxBytes); ok {
+		g.logger.Info("using cached artifact", "component", req.Component, "version", req.Version)
+		return tmpPath, want, nil
+	}
+
+	err = errors.New("lan sharing disabled")
+	if g.cfg.OTA.LANSharing.Enabled {
+		tmpPath, digest, err = g.fetchFromPeer(ctx, req, dir, maxBytes)
+		if err == nil && digest != want {
+			g.logger.Warn("LAN peer sent a different artifact, downloading instead", "component", req.Component, "digest", digest)
+			os.Remove(tmpPath)
+			err = ErrUpdateVerify
+		}
+	}
+	if err != nil {
+		if g.cfg.OTA.LANSharing.Enabled {
+			g.logger.Debug("artifact not available on LAN", "component", req.Component, "error", err)
+		}
+		if tmpPath, digest, err = g.fetchArtifactFromSource(ctx, req, dir, maxBytes); err != nil {
+			return "", "", err
+		}
+	}
+	if digest == want && g.verifySignature(signedDigest(algorithm, digest), req.Signature) == nil {
+		g.cacheArtifact(tmpPath, algorithm, digest)
+	}
+	return tmpPath, digest, nil
+}
+
+// fetchArtifactFromSource spools req's artifact from openArtifact into a
+// staging file in dir.
+func (g *Guard) fetchArtifactFromSource(ctx context.Context, req FetchRequest, dir string, maxBytes int64) (tmpPath, digest string, err error) {
+	algorithm, _ := req.digest()
+	maxBytes = normalizeArtifactMaxBytes(maxBytes)
+
+	ctx, cancel := withTimeout(ctx, g.otaDownloadTimeout())
+	defer cancel()
+
+	body, size, err := g.openArtifact(ctx, req)
+	if err != nil {
+		return "", "", err
+	}
+	defer body.Close()
+	if size > maxBytes {
+		return "", "", artifactTooLargeError(maxBytes)
+	}
+	return spoolArtifact(body, algorithm, dir, maxBytes)
+}
+
+// spoolArtifact copies at most maxBytes from r into a staging file in dir
+// (the system temp dir when empty), hashing with algorithm as the bytes
+// stream through, and returns its path and hex digest. The file is synced
+// to disk, and a write the file system reports only on sync or close fails
+// the spool rather than leaving a short file behind a matching digest.
+func spoolArtifact(r io.Reader, algorithm, dir string, maxBytes int64) (tmpPath, digest string, err error) {
+	hasher, err := newDigest(algorithm)
+	if err != nil {
+		return "", "", err
+	}
+	tmpFile, err := createStagingFile(dir, "update")
+	if err != nil {
+		return "", "", fmt.Errorf("create temp file: %w", err)
+	}
+
+	_, err = io.Copy(io.MultiWriter(tmpFile, hasher), newArtifactLimitReader(r, maxBytes))
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", "", fmt.Errorf("copy failed: %w", err)
+	}
+	return tmpFile.Name(), hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,84 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fetcherFunc func(ctx context.Context, req FetchRequest) (io.ReadCloser, error)
+
+func (f fetcherFunc) Fetch(ctx context.Context, req FetchRequest) (io.ReadCloser, error) {
+	return f(ctx, req)
+}
+
+func newFetcherTestGuard(t *testing.T, pubKey ed25519.PublicKey, hashHex, signature string) *Guard {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/frontend.tar.gz",
+				"sha256":       hashHex,
+				"signature":    signature,
+			})
+		default:
+			t.Errorf("artifact must come from the fetcher, got request for %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+}
+
+func TestUpdateFrontend_UsesFetcher(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	archive := buildTarGz(t, map[string]string{"index.html": "mirrored"})
+	hashHex := sha256Hex(archive)
+
+	g := newFetcherTestGuard(t, pubKey, hashHex, signUpdateHash(t, privKey, hashHex))
+	var got FetchRequest
+	g.cfg.OTA.Fetcher = fetcherFunc(func(ctx context.Context, req FetchRequest) (io.ReadCloser, error) {
+		got = req
+		return io.NopCloser(bytes.NewReader(archive)), nil
+	})
+
+	dir := filepath.Join(t.TempDir(), "live")
+	mc := ManagedComponent{Slug: "frontend", Dir: dir}
+	if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+		t.Fatalf("updateFrontend: %v", err)
+	}
+	if got.Component != "frontend" || got.Version != "2.0.0" || got.SHA256 != hashHex || got.DownloadURL != "/download/frontend.tar.gz" {
+		t.Fatalf("unexpected fetch request: %#v", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "index.html")); err != nil || string(data) != "mirrored" {
+		t.Fatalf("expected the fetched archive to be installed, got %q, %v", data, err)
+	}
+}
+
+func TestUpdateFrontend_FetcherCannotBypassVerification(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	archive := buildTarGz(t, map[string]string{"index.html": "genuine"})
+	hashHex := sha256Hex(archive)
+
+	g := newFetcherTestGuard(t, pubKey, hashHex, signUpdateHash(t, privKey, hashHex))
+	tampered := buildTarGz(t, map[string]string{"index.html": "tampered"})
+	g.cfg.OTA.Fetcher = fetcherFunc(func(ctx context.Context, req FetchRequest) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(tampered)), nil
+	})
+
+	mc := ManagedComponent{Slug: "frontend", Dir: filepath.Join(t.TempDir(), "live")}
+	err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"})
+	if !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("expected ErrUpdateVerify for a tampered mirror artifact, got %v", err)
+	}
+}
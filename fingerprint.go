@@ -1,7 +1,9 @@
 package sdk
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"os/exec"
@@ -11,9 +13,40 @@ import (
 	"github.com/denisbrodbeck/machineid"
 )
 
+// FingerprintPrivacy selects how much of the aux signals leaves the machine.
+type FingerprintPrivacy int
+
+const (
+	// FingerprintPrivacyFull sends aux signals as collected (default).
+	FingerprintPrivacyFull FingerprintPrivacy = iota
+	// FingerprintPrivacyHashed sends every aux signal except os, arch and
+	// instance_counter as a keyed hash, so the server can still detect
+	// changes. The counter stays readable so regressions show.
+	FingerprintPrivacyHashed
+	// FingerprintPrivacyMinimal sends only os and arch.
+	FingerprintPrivacyMinimal
+)
+
+// FingerprintConfig controls which aux signals ("mac_addresses",
+// "cpu_model", "cpu_cores", "total_ram_mb", "virtualization",
+// "instance_counter") are sent to the server. os and
+// arch are always sent in the clear because updates are selected by them.
+// Deny wins over Hash, and both apply on top of PrivacyMode.
+type FingerprintConfig struct {
+	PrivacyMode FingerprintPrivacy
+	// Allow, when set, drops every aux signal not listed.
+	Allow []string
+	// Deny drops the listed aux signals.
+	Deny []string
+	// Hash sends the listed aux signals as keyed hashes instead of raw
+	// values. MAC addresses are hashed one by one.
+	Hash []string
+}
+
 type Fingerprint struct {
-	machineID  string
-	auxSignals map[string]string
+	machineID      string
+	auxSignals     map[string]string
+	virtualization Virtualization
 }
 
 func collectFingerprint() (*Fingerprint, error) {
@@ -32,11 +65,18 @@ func collectFingerprint() (*Fingerprint, error) {
 	populateCPUInfo(aux)
 	populateMemoryInfo(aux)
 
-	if macs := getMACAddresses(); len(macs) > 0 {
+	macs := getMACAddresses()
+	if len(macs) > 0 {
 		aux["mac_addresses"] = strings.Join(macs, ",")
 	}
 
-	return &Fingerprint{machineID: hashedID, auxSignals: aux}, nil
+	virt := collectVirtualization(macs)
+	aux["virtualization"] = "none"
+	if virt.Virtual {
+		aux["virtualization"] = virt.Hypervisor
+	}
+
+	return &Fingerprint{machineID: hashedID, auxSignals: aux, virtualization: virt}, nil
 }
 
 func (f *Fingerprint) MachineID() string {
@@ -47,6 +87,50 @@ func (f *Fingerprint) AuxSignals() map[string]string {
 	return f.auxSignals
 }
 
+// withPrivacy returns fp with its aux signals filtered and hashed per cfg.
+// Hashes are keyed by the machine ID, so equal values on different machines
+// cannot be correlated.
+func (f *Fingerprint) withPrivacy(cfg FingerprintConfig) *Fingerprint {
+	aux := make(map[string]string, len(f.auxSignals))
+	for name, value := range f.auxSignals {
+		if name == "os" || name == "arch" {
+			aux[name] = value
+			continue
+		}
+		if cfg.PrivacyMode == FingerprintPrivacyMinimal || containsSignal(cfg.Deny, name) ||
+			(len(cfg.Allow) > 0 && !containsSignal(cfg.Allow, name)) {
+			continue
+		}
+		if (cfg.PrivacyMode == FingerprintPrivacyHashed && name != "instance_counter") || containsSignal(cfg.Hash, name) {
+			value = hashSignal(f.machineID, name, value)
+		}
+		aux[name] = value
+	}
+	return &Fingerprint{machineID: f.machineID, auxSignals: aux, virtualization: f.virtualization}
+}
+
+func hashSignal(machineID, name, value string) string {
+	parts := []string{value}
+	if name == "mac_addresses" {
+		parts = strings.Split(value, ",")
+	}
+	for i, part := range parts {
+		mac := hmac.New(sha256.New, []byte(machineID))
+		mac.Write([]byte(name + "|" + part))
+		parts[i] = "hmac:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	return strings.Join(parts, ",")
+}
+
+func containsSignal(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
 func getMACAddresses() []string {
 	ifaces, err := net.Interfaces()
 	if err != nil {
@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -55,3 +56,48 @@ func TestFingerprint_AuxSignals(t *testing.T) {
 		t.Errorf("expected arch amd64, got %s", signals["arch"])
 	}
 }
+
+func TestFingerprintWithPrivacy(t *testing.T) {
+	fp := &Fingerprint{
+		machineID: "sha256:machine",
+		auxSignals: map[string]string{
+			"os":            "linux",
+			"arch":          "amd64",
+			"cpu_model":     "Xeon",
+			"cpu_cores":     "8",
+			"mac_addresses": "aa:bb,cc:dd",
+		},
+	}
+
+	filtered := fp.withPrivacy(FingerprintConfig{Deny: []string{"cpu_cores"}, Hash: []string{"mac_addresses"}})
+	signals := filtered.AuxSignals()
+	if _, ok := signals["cpu_cores"]; ok {
+		t.Error("expected denied cpu_cores to be dropped")
+	}
+	if signals["cpu_model"] != "Xeon" || signals["os"] != "linux" {
+		t.Errorf("expected unlisted signals to stay raw, got %v", signals)
+	}
+	macs := strings.Split(signals["mac_addresses"], ",")
+	if len(macs) != 2 || !strings.HasPrefix(macs[0], "hmac:") || strings.Contains(signals["mac_addresses"], "aa:bb") {
+		t.Errorf("expected MAC addresses hashed one by one, got %q", signals["mac_addresses"])
+	}
+	if fp.auxSignals["cpu_cores"] != "8" {
+		t.Error("expected the original fingerprint to be left unchanged")
+	}
+
+	hashed := fp.withPrivacy(FingerprintConfig{PrivacyMode: FingerprintPrivacyHashed}).AuxSignals()
+	if hashed["arch"] != "amd64" || !strings.HasPrefix(hashed["cpu_model"], "hmac:") {
+		t.Errorf("expected all but os and arch hashed, got %v", hashed)
+	}
+	if again := fp.withPrivacy(FingerprintConfig{PrivacyMode: FingerprintPrivacyHashed}).AuxSignals(); again["cpu_model"] != hashed["cpu_model"] {
+		t.Error("expected hashes to be stable for drift detection")
+	}
+
+	minimal := fp.withPrivacy(FingerprintConfig{PrivacyMode: FingerprintPrivacyMinimal}).AuxSignals()
+	if len(minimal) != 2 || minimal["os"] != "linux" || minimal["arch"] != "amd64" {
+		t.Errorf("expected only os and arch in minimal mode, got %v", minimal)
+	}
+	if allowed := fp.withPrivacy(FingerprintConfig{Allow: []string{"cpu_cores"}}).AuxSignals(); len(allowed) != 3 {
+		t.Errorf("expected os, arch and cpu_cores only, got %v", allowed)
+	}
+}
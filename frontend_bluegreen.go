@@ -0,0 +1,200 @@
+package sdk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// frontendCurrentLink is the symlink under a blue/green Dir pointing at
+	// the live release.
+	frontendCurrentLink = "current"
+	defaultKeepVersions = 2
+)
+
+func (mc ManagedComponent) keepVersions() int {
+	if mc.KeepVersions > 0 {
+		return mc.KeepVersions
+	}
+	return defaultKeepVersions
+}
+
+// frontendStagingParent is where a frontend update is downloaded and
+// extracted: next to the releases in blue/green mode and next to Dir
+// otherwise, so moving it into place is a rename on the same filesystem.
+func frontendStagingParent(mc ManagedComponent) (string, error) {
+	dir := filepath.Dir(filepath.Clean(mc.Dir))
+	if mc.BlueGreen {
+		dir = mc.Dir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// activateFrontendRelease moves the extracted stagedDir to Dir/<version>,
+// points the current symlink at it and prunes releases beyond
+// KeepVersions. Live traffic sees either the old or the new release, never
+// a missing directory.
+func (g *Guard) activateFrontendRelease(mc ManagedComponent, stagedDir, version string) error {
+	if strings.ContainsAny(version, `/\`) || version == "." || version == ".." || version == frontendCurrentLink {
+		return fmt.Errorf("version %q cannot name a release directory", version)
+	}
+	releaseDir := filepath.Join(mc.Dir, version)
+	if err := os.RemoveAll(releaseDir); err != nil {
+		return fmt.Errorf("remove stale release %s: %w", version, err)
+	}
+	if err := os.Rename(stagedDir, releaseDir); err != nil {
+		return fmt.Errorf("move release %s into place: %w", version, err)
+	}
+	// The release directory's mtime records when it was activated; see
+	// frontendReleases.
+	now := time.Now()
+	if err := os.Chtimes(releaseDir, now, now); err != nil {
+		return fmt.Errorf("stamp release %s: %w", version, err)
+	}
+	if err := switchFrontendRelease(mc.Dir, version); err != nil {
+		return err
+	}
+	g.pruneFrontendReleases(mc, version)
+	return nil
+}
+
+// switchFrontendRelease atomically repoints Dir/current at the release
+// directory version by renaming a fresh symlink over it.
+func switchFrontendRelease(dir, version string) error {
+	tmp := filepath.Join(dir, ".current-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	if err := os.Symlink(version, tmp); err != nil {
+		return fmt.Errorf("create current link: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, frontendCurrentLink)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("switch current link: %w", err)
+	}
+	return syncDir(dir)
+}
+
+// currentFrontendRelease returns the release the current symlink points at.
+func currentFrontendRelease(dir string) (string, error) {
+	target, err := os.Readlink(filepath.Join(dir, frontendCurrentLink))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
+
+// frontendReleases lists the release directories under dir in the order
+// they were activated, oldest first, by the mtime activateFrontendRelease
+// stamps. Version tags need not be semver, so they only break ties.
+// Staging directories and the current link are skipped.
+func frontendReleases(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type release struct {
+		name        string
+		activatedAt time.Time
+	}
+	var releases []release
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, release{name: name, activatedAt: info.ModTime()})
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		if !releases[i].activatedAt.Equal(releases[j].activatedAt) {
+			return releases[i].activatedAt.Before(releases[j].activatedAt)
+		}
+		return releases[i].name < releases[j].name
+	})
+	names := make([]string, len(releases))
+	for i, r := range releases {
+		names[i] = r.name
+	}
+	return names, nil
+}
+
+// pruneFrontendReleases keeps the live release and the KeepVersions most
+// recently activated other releases.
+func (g *Guard) pruneFrontendReleases(mc ManagedComponent, live string) {
+	releases, err := frontendReleases(mc.Dir)
+	if err != nil {
+		g.logger.Warn("list frontend releases failed", "component", mc.Slug, "error", err)
+		return
+	}
+	kept := 0
+	for i := len(releases) - 1; i >= 0; i-- {
+		if releases[i] == live {
+			continue
+		}
+		if kept < mc.keepVersions() {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(mc.Dir, releases[i])); err != nil {
+			g.logger.Warn("remove old frontend release failed", "component", mc.Slug, "version", releases[i], "error", err)
+		}
+	}
+}
+
+// RollbackFrontend switches a blue/green frontend component back to the
+// kept release activated last before the live one. The switch is a single
+// symlink rename, so it takes effect at once, and it does not count as an
+// activation, so rolling back again goes further back. It returns
+// ErrNoPreviousVersion when no earlier release is kept.
+func (g *Guard) RollbackFrontend(slug string) error {
+	if err := g.requireState(); err != nil {
+		return err
+	}
+	mc, ok := g.findManagedComponent(slug)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrComponentNotFound, slug)
+	}
+	if !mc.BlueGreen {
+		return fmt.Errorf("rollback %s: component does not use blue/green releases", slug)
+	}
+	if !g.updateMu.TryLock() {
+		return ErrUpdateConcurrent
+	}
+	defer g.updateMu.Unlock()
+
+	live, err := currentFrontendRelease(mc.Dir)
+	if err != nil {
+		return fmt.Errorf("rollback %s: %w", slug, err)
+	}
+	releases, err := frontendReleases(mc.Dir)
+	if err != nil {
+		return fmt.Errorf("rollback %s: %w", slug, err)
+	}
+	previous := ""
+	for i, release := range releases {
+		if release == live && i > 0 {
+			previous = releases[i-1]
+		}
+	}
+	if previous == "" {
+		return fmt.Errorf("%w: %s %s", ErrNoPreviousVersion, slug, live)
+	}
+	if err := switchFrontendRelease(mc.Dir, previous); err != nil {
+		return fmt.Errorf("rollback %s: %w", slug, err)
+	}
+
+	g.mu.Lock()
+	g.managedVersions[slug] = previous
+	g.mu.Unlock()
+	g.logger.Info("frontend rolled back", "component", slug, "from", live, "to", previous)
+	return nil
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBlueGreenFrontendSwitchesCurrentLinkAndRollsBack(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	var archive []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			hashHex := sha256Hex(archive)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/frontend.tar.gz",
+				"sha256":       hashHex,
+				"signature":    signUpdateHash(t, privKey, hashHex),
+			})
+		case "/download/frontend.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.cfg.CacheStore = NewMemoryCacheStore()
+	g.sm = newStateMachine()
+	mc := ManagedComponent{Slug: "frontend", Dir: filepath.Join(t.TempDir(), "www"), Strategy: UpdateFrontend, BlueGreen: true, KeepVersions: 1}
+	g.cfg.ManagedComponents = []ManagedComponent{mc}
+
+	for _, version := range []string{"2.0.0", "3.0.0", "4.0.0"} {
+		archive = buildTarGz(t, map[string]string{"index.html": version})
+		if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: version}); err != nil {
+			t.Fatalf("update to %s: %v", version, err)
+		}
+		data, err := os.ReadFile(filepath.Join(mc.Dir, "current", "index.html"))
+		if err != nil || string(data) != version {
+			t.Fatalf("current/index.html after %s = %q, %v", version, data, err)
+		}
+	}
+	if releases, _ := frontendReleases(mc.Dir); !reflect.DeepEqual(releases, []string{"3.0.0", "4.0.0"}) {
+		t.Fatalf("releases = %v, want the live one and one previous", releases)
+	}
+
+	if err := g.RollbackFrontend("frontend"); err != nil {
+		t.Fatal(err)
+	}
+	if live, _ := currentFrontendRelease(mc.Dir); live != "3.0.0" {
+		t.Fatalf("live release after rollback = %s, want 3.0.0", live)
+	}
+	if v := g.currentManagedVersion("frontend"); v != "3.0.0" {
+		t.Fatalf("version after rollback = %s, want 3.0.0", v)
+	}
+	if err := g.RollbackFrontend("frontend"); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Fatalf("second rollback: err = %v, want ErrNoPreviousVersion", err)
+	}
+}
+
+func TestBlueGreenFrontendOrdersReleasesByActivation(t *testing.T) {
+	g := newLifecycleTestGuard(t, "http://127.0.0.1", nil, "1.0.0")
+	g.sm = newStateMachine()
+	mc := ManagedComponent{Slug: "frontend", Dir: filepath.Join(t.TempDir(), "www"), Strategy: UpdateFrontend, BlueGreen: true, KeepVersions: 1}
+	g.cfg.ManagedComponents = []ManagedComponent{mc}
+	if err := os.MkdirAll(mc.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Build tags are not semver, so only the activation order ranks them.
+	for _, version := range []string{"build-b", "build-a", "build-c"} {
+		staged := filepath.Join(mc.Dir, ".staged-"+version)
+		if err := os.MkdirAll(staged, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.activateFrontendRelease(mc, staged, version); err != nil {
+			t.Fatalf("activate %s: %v", version, err)
+		}
+	}
+	if releases, _ := frontendReleases(mc.Dir); !reflect.DeepEqual(releases, []string{"build-a", "build-c"}) {
+		t.Fatalf("releases = %v, want the previous and the live one", releases)
+	}
+
+	if err := g.RollbackFrontend("frontend"); err != nil {
+		t.Fatal(err)
+	}
+	if live, _ := currentFrontendRelease(mc.Dir); live != "build-a" {
+		t.Fatalf("live release after rollback = %s, want build-a", live)
+	}
+	if err := g.RollbackFrontend("frontend"); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Fatalf("second rollback: err = %v, want ErrNoPreviousVersion", err)
+	}
+}
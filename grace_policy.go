@@ -0,0 +1,95 @@
+package sdk
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// FailureCategory classifies why a heartbeat failed so GracePolicy can give
+// vendor-side outages a longer offline budget than genuine revocation.
+type FailureCategory int
+
+const (
+	// FailureNetwork covers transport errors: DNS, no route to host,
+	// timeouts, and 408 and 429 replies.
+	FailureNetwork FailureCategory = iota
+	// FailureServer covers 5xx responses and unreadable server replies.
+	FailureServer
+	// FailureLicense covers the server rejecting this license or machine, or
+	// replies that fail signature and binding checks.
+	FailureLicense
+)
+
+func (c FailureCategory) String() string {
+	switch c {
+	case FailureNetwork:
+		return "network"
+	case FailureServer:
+		return "server"
+	case FailureLicense:
+		return "license"
+	default:
+		return "unknown"
+	}
+}
+
+func classifyFailure(err error) FailureCategory {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusRequestTimeout, apiErr.StatusCode == http.StatusTooManyRequests:
+			return FailureNetwork
+		case apiErr.StatusCode >= 500:
+			return FailureServer
+		}
+		return FailureLicense
+	}
+	switch {
+	case errors.Is(err, ErrLicenseInvalid),
+		errors.Is(err, ErrLicenseExpired),
+		errors.Is(err, ErrLeaseRevoked),
+		errors.Is(err, ErrMachineNotRegistered),
+		errors.Is(err, ErrLeaseBindingMismatch),
+		errors.Is(err, ErrMachineMismatch),
+		errors.Is(err, ErrHeartbeatInvalid),
+		errors.Is(err, ErrHeartbeatNonceMismatch),
+		errors.Is(err, ErrVerifyResponseInvalid),
+		errors.Is(err, ErrResponseStale),
+		errors.Is(err, ErrClockRollback):
+		return FailureLicense
+	case errors.Is(err, ErrNetworkError):
+		return FailureNetwork
+	case errors.Is(err, ErrInvalidServerResponse):
+		return FailureServer
+	default:
+		return FailureNetwork
+	}
+}
+
+// maxOfflineFor returns the offline budget for one failure category; unset
+// categories fall back to MaxOfflineDuration.
+func (p GracePolicy) maxOfflineFor(category FailureCategory) time.Duration {
+	var budget time.Duration
+	switch category {
+	case FailureNetwork:
+		budget = p.NetworkMaxOffline
+	case FailureServer:
+		budget = p.ServerErrorMaxOffline
+	case FailureLicense:
+		budget = p.LicenseErrorMaxOffline
+	}
+	if budget <= 0 {
+		return p.MaxOfflineDuration
+	}
+	return budget
+}
+
+// escalationThreshold is how many license failures it takes for the
+// license budget to apply to the current offline episode.
+func (p GracePolicy) escalationThreshold() int {
+	if p.LicenseEscalateAfter <= 0 {
+		return 1
+	}
+	return p.LicenseEscalateAfter
+}
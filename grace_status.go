@@ -0,0 +1,56 @@
+package sdk
+
+import "time"
+
+// GraceStatus describes the offline grace period while heartbeats fail, so
+// applications can tell users how long they have to reconnect.
+type GraceStatus struct {
+	// EnteredAt is when the first heartbeat of this offline episode failed.
+	EnteredAt time.Time
+	// Deadline is when the guard locks unless a heartbeat succeeds. It moves
+	// earlier if a failure category with a tighter budget is seen.
+	Deadline time.Time
+	// Remaining is the time left until Deadline, never negative.
+	Remaining time.Duration
+	// Offline is how long heartbeats have been failing.
+	Offline time.Duration
+}
+
+// GraceInfo reports the current grace period. It returns false when the
+// guard is not in GRACE or no failed heartbeat has been seen since start.
+func (g *Guard) GraceInfo() (GraceStatus, bool) {
+	if g.State() != StateGrace {
+		return GraceStatus{}, false
+	}
+	return g.graceSnapshot(time.Now())
+}
+
+func (g *Guard) graceSnapshot(now time.Time) (GraceStatus, bool) {
+	g.graceMu.Lock()
+	enteredAt, deadline := g.graceEnteredAt, g.graceDeadline
+	g.graceMu.Unlock()
+	if enteredAt.IsZero() {
+		return GraceStatus{}, false
+	}
+
+	status := GraceStatus{EnteredAt: enteredAt, Deadline: deadline}
+	if remaining := deadline.Sub(now); remaining > 0 {
+		status.Remaining = remaining
+	}
+	if offline := now.Sub(enteredAt); offline > 0 {
+		status.Offline = offline
+	}
+	return status, true
+}
+
+// setGraceWindow records the current offline episode; advanceGrace calls it
+// on every failed heartbeat.
+func (g *Guard) setGraceWindow(enteredAt, deadline time.Time) {
+	g.graceMu.Lock()
+	g.graceEnteredAt, g.graceDeadline = enteredAt, deadline
+	g.graceMu.Unlock()
+}
+
+func (g *Guard) clearGraceWindow() {
+	g.setGraceWindow(time.Time{}, time.Time{})
+}
@@ -0,0 +1,48 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGraceInfoTracksDeadlineAcrossStatusAndEvents(t *testing.T) {
+	guard, _, _, _ := newActiveHeartbeatGuard(t)
+	guard.cfg.GracePolicy = GracePolicy{MaxOfflineDuration: 14 * time.Hour, WarningInterval: time.Hour}
+	events := guard.States()
+
+	if _, ok := guard.GraceInfo(); ok {
+		t.Fatal("ACTIVE guard must not report a grace period")
+	}
+
+	start := time.Now()
+	var grace graceTracker
+	guard.advanceGrace(&grace, start, ErrNetworkError)
+	guard.sm.OnHeartbeatFail()
+
+	event := <-events
+	if event.To != StateGrace || event.Grace == nil || !event.Grace.Deadline.Equal(start.Add(14*time.Hour)) {
+		t.Fatalf("grace event = %#v", event)
+	}
+
+	info, ok := guard.GraceInfo()
+	if !ok || !info.EnteredAt.Equal(start) || info.Remaining <= 13*time.Hour || info.Remaining > 14*time.Hour {
+		t.Fatalf("GraceInfo = %#v, %v", info, ok)
+	}
+	if status := guard.Status(); status.Grace == nil || !status.Grace.Deadline.Equal(info.Deadline) {
+		t.Fatalf("Status().Grace = %#v", status.Grace)
+	}
+
+	guard.cfg.GracePolicy.LicenseErrorMaxOffline = 2 * time.Hour
+	guard.advanceGrace(&grace, start.Add(time.Hour), ErrLicenseInvalid)
+	if info, _ := guard.GraceInfo(); !info.Deadline.Equal(start.Add(2 * time.Hour)) {
+		t.Fatalf("a tighter budget should move the deadline earlier, got %v", info.Deadline)
+	}
+
+	guard.sm.OnHeartbeatOK()
+	if _, ok := guard.GraceInfo(); ok {
+		t.Fatal("grace period should end once a heartbeat succeeds")
+	}
+	if status := guard.Status(); status.Grace != nil {
+		t.Fatalf("Status().Grace after recovery = %#v", status.Grace)
+	}
+}
@@ -19,8 +19,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -31,36 +34,152 @@ type Guard struct {
 	fingerprint *Fingerprint
 	sm          *stateMachine
 	httpClient  *http.Client
+	api         Transport
+	limiter     *rateLimiter
 	store       *persistentStateStore
-
-	version         string
+	secrets     *secretStore
+	redactor    *redactor
+	// devMode is set by NewDevMode; see devmode.go.
+	devMode bool
+	// testingDir is the temporary state directory of a NewForTesting guard,
+	// removed by Close.
+	testingDir string
+
+	version         atomic.Pointer[string]
 	managedVersions map[string]string
+	configVersions  map[string]string
+	versionSource   VersionSource
+	// reportedVersions are report-only components: listed in heartbeats but
+	// never updated.
+	reportedVersions map[string]string
+
+	pendingUpdateStats    []UpdateStats
+	updateHistory         []UpdateStats
+	pendingCommandResults []commandResult
+	errorReports          errorReporter
+	usage                 usageMeter
+	seats                 map[string]Seat
+	componentStarted      map[string]time.Time
+	componentStarts       map[string]int
+	supervisors           map[string]*supervisor
+	assetManifests        map[string]AssetManifest
+	versionPins           map[string]string
+	// systemd overrides the D-Bus systemd client in tests.
+	systemd systemdManager
+	// lanDiscover overrides mDNS peer discovery in tests.
+	lanDiscover     func(ctx context.Context, sha256Hash string) (string, error)
+	metrics         guardMetrics
+	commandHandlers map[string]CommandHandler
 
 	cancel        context.CancelFunc
 	heartbeatDone chan struct{}
-	mu            sync.RWMutex
-	updateMu      sync.Mutex
-	lifecycleMu   sync.Mutex
-	running       bool
-	logger        *slog.Logger
+	// workers tracks the push channel, background updates and command
+	// handlers started under Start's context; see StopAndWait.
+	workers sync.WaitGroup
+	// startCtx is the context given to Start; loops restarted without a
+	// caller, such as for an unban appeal, derive from it.
+	startCtx    context.Context
+	mu          sync.RWMutex
+	updateMu    sync.Mutex
+	lifecycleMu sync.Mutex
+	// enforceMu guards the enforcement hook queue and shutdown timer; see
+	// enforcement.go.
+	enforceMu       sync.Mutex
+	enforceHooks    []func()
+	enforceDraining bool
+	shutdownTimer   *time.Timer
+	responseLogMu   sync.Mutex
+	inflightMu      sync.Mutex
+	inflight        map[string]*inflightCall
+	auditMu         sync.Mutex
+	kvMu            sync.Mutex
+	kv              kvStore
+	running         bool
+	logger          *slog.Logger
+
+	clock             clockMonitor
+	codecNegotiated   atomic.Bool
+	serverProtocol    atomic.Int32
+	serverEndpoints   atomic.Pointer[serverEndpoints]
+	clientCert        atomic.Pointer[tls.Certificate]
+	heartbeatInterval time.Duration
+
+	killDeadline atomic.Pointer[time.Time]
+	killReason   string
+	killTimer    *time.Timer
+
+	appealID     string
+	appealStatus AppealStatus
+
+	feedbackRepliesSeen map[string]struct{}
+
+	announcements     []Announcement
+	announcementsSeen map[string]struct{}
+	// announcementsRead is nil until loaded from the cache on first use.
+	announcementsRead []string
+
+	// heartbeatWake lets push events run the next heartbeat early.
+	heartbeatWake     chan struct{}
+	lastHeartbeatWake atomic.Pointer[time.Time]
+	pushConnected     atomic.Bool
+
+	capabilities map[Capability]bool
+
+	availableVersions map[string]string
+	pluginUpdates     map[string]heartbeatPluginUpdate
+	// offeredUpdates holds the last update the server offered per
+	// component, for PlanUpdate.
+	offeredUpdates map[string]updateInfo
+	// announcedUpdates holds the version last announced as available per
+	// component, so each heartbeat does not announce it again.
+	announcedUpdates map[string]string
+	// prefetched holds artifacts a release group downloaded and verified
+	// before applying its members. groupMu lets one release group at a time
+	// prefetch and apply; updateMu is taken per member, so it cannot.
+	prefetchMu sync.Mutex
+	prefetched map[string]prefetchedArtifact
+	groupMu    sync.Mutex
+
+	entitledFeatures map[string]bool
+	featureFlags     map[string]bool
+
+	// remoteConfig is nil until loaded from the cache on first use.
+	remoteConfig          *RemoteConfig
+	remoteConfigCallbacks []func(old, new RemoteConfig)
+
+	updateWaitersMu sync.Mutex
+	updateWaiters   map[chan UpdateNotification]struct{}
+
+	defaultsOnce sync.Once
+
+	expiryNotice licenseExpiryNotice
+	// drift is how the fingerprint differs from the last recorded binding;
+	// nil once a lease for the current fingerprint has been accepted.
+	drift *FingerprintDrift
+
+	graceMu        sync.Mutex
+	graceEnteredAt time.Time
+	graceDeadline  time.Time
+
+	stateSubsMu    sync.Mutex
+	stateCallbacks []func(old, new State, reason string)
+	stateChannels  []chan StateTransition
 }
 
 func New(cfg Config) (*Guard, error) {
+	return newGuard(cfg, nil)
+}
+
+// newGuard builds a Guard from cfg. fp, when set, stands in for this
+// machine's fingerprint, as NewForTesting needs.
+func newGuard(cfg Config, fp *Fingerprint) (*Guard, error) {
+	// Validate before setDefaults, which replaces negative durations.
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	cfg.setDefaults()
 
 	// After setDefaults(), ServerURL is guaranteed to have a value
-	if cfg.LicenseKey == "" {
-		return nil, fmt.Errorf("license_key is required")
-	}
-	if cfg.PublicKeyPEM == nil {
-		return nil, fmt.Errorf("public_key_pem is required")
-	}
-	if cfg.ProjectSlug == "" {
-		return nil, fmt.Errorf("project_slug is required")
-	}
-	if cfg.ComponentSlug == "" {
-		return nil, fmt.Errorf("component_slug is required")
-	}
 	normalizedServerURL, err := normalizeServerURL(cfg.ServerURL)
 	if err != nil {
 		return nil, err
@@ -72,25 +191,51 @@ func New(cfg Config) (*Guard, error) {
 		return nil, err
 	}
 
-	fp, err := collectFingerprint()
+	if fp == nil {
+		collected, err := collectFingerprint()
+		if err != nil {
+			return nil, fmt.Errorf("collect fingerprint: %w", err)
+		}
+		if counter, err := nextInstanceCounter(newSecretStore(cfg, collected)); err == nil {
+			collected.auxSignals["instance_counter"] = strconv.FormatUint(counter, 10)
+		}
+		fp = collected
+	}
+	fp = fp.withPrivacy(cfg.Fingerprint)
+
+	redactor, err := newRedactor(cfg, fp.MachineID())
 	if err != nil {
-		return nil, fmt.Errorf("collect fingerprint: %w", err)
+		return nil, err
 	}
 
-	httpClient, err := newPinnedHTTPClient(cfg)
+	httpClient, err := newHTTPClient(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	drift := detectFingerprintDrift(cfg, fp)
 	store := newPersistentStateStore(cfg, fp)
-	loadedState, err := store.Load()
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
+	loadedState, loadErr := store.Load()
+	switch {
+	case loadErr == nil || errors.Is(loadErr, os.ErrNotExist):
+		loadErr = nil
+	case errors.Is(loadErr, errLicenseKeyChanged):
+		// Another license key was configured: the lease, flags and secrets
+		// of the previous one must not vouch for it.
+		_ = store.Clear()
+		_ = wipeLicenseCache(cfg)
+		loadedState, loadErr = nil, nil
+	case drift != nil && drift.MachineIDChanged:
+		// The state was sealed under the previous machine ID, so it is
+		// unreadable rather than tampered with; start over and let the
+		// server decide on a rebind.
+		loadedState, loadErr = nil, nil
+	default:
 		loadedState = &persistedState{
 			LockFlag:  true,
 			UpdatedAt: time.Now().UTC().Format(time.RFC3339),
 		}
 		_ = store.Save(loadedState)
-		err = nil
 	}
 
 	managedVersions := make(map[string]string, len(cfg.ManagedComponents))
@@ -103,7 +248,7 @@ func New(cfg Config) (*Guard, error) {
 		sm.restore(loadedState)
 	}
 
-	return &Guard{
+	g := &Guard{
 		cfg:             cfg,
 		publicKey:       pubKeys[0],
 		publicKeys:      pubKeys,
@@ -111,37 +256,103 @@ func New(cfg Config) (*Guard, error) {
 		sm:              sm,
 		httpClient:      httpClient,
 		store:           store,
-		version:         "unknown",
+		secrets:         newSecretStore(cfg, fp),
 		managedVersions: managedVersions,
-		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
-	}, nil
+		configVersions:  make(map[string]string),
+		redactor:        redactor,
+		limiter:         newRateLimiter(cfg.RateLimit),
+		logger:          newRedactingLogger(slog.New(slog.NewTextHandler(io.Discard, nil)), redactor),
+		drift:           drift,
+	}
+	g.fillDefaults()
+	g.reportError(errorKindCacheCorrupt, cfg.ComponentSlug, loadErr)
+	g.updateHistory = loadUpdateHistory(g.secrets)
+	g.loadUsage()
+	g.loadConfigVersions()
+	g.recordComponentStart(cfg.ComponentSlug, time.Now())
+	sm.onChange = g.publishStateTransition
+	g.clock.reset(time.Now())
+	if loadedState != nil && sm.Current() != StateBanned {
+		g.restoreScheduledKill(loadedState)
+	}
+	if cfg.ClientCert.Enabled {
+		g.restoreClientCertificate()
+		g.attachClientCertificate()
+	}
+	if cfg.CustomTransport != nil {
+		g.api = cfg.CustomTransport
+	} else if cfg.Transport.Protocol == TransportGRPC {
+		api, err := newGRPCTransport(g)
+		if err != nil {
+			return nil, fmt.Errorf("grpc transport: %w", err)
+		}
+		g.api = api
+	}
+	return g, nil
 }
 
+// Start verifies the license and launches the heartbeat loop and, when
+// enabled, the push channel. It returns ErrAlreadyStarted while the guard is
+// running. After Stop, or after the heartbeat loop ended on its own, Start
+// may be called again and verifies afresh.
 func (g *Guard) Start(ctx context.Context) error {
+	if err := g.requireInitialized(true, true); err != nil {
+		return err
+	}
 	g.lifecycleMu.Lock()
 	defer g.lifecycleMu.Unlock()
 
 	if g.running {
+		return ErrAlreadyStarted
+	}
+	if g.devMode {
+		g.logger.Warn(devModeWarning, "call", "Start")
+		g.running = true
 		return nil
 	}
 
+	g.startCtx = ctx
 	ctx, cancel := context.WithCancel(ctx)
 
 	if err := g.verifyLicense(ctx); err != nil {
 		cancel()
 		return fmt.Errorf("license verification failed: %w", err)
 	}
+	g.probeCapabilities(ctx)
+	if err := g.ensureClientCertificate(ctx); err != nil {
+		cancel()
+		return fmt.Errorf("client certificate enrollment: %w", err)
+	}
+
+	g.removeStagingOrphans()
 
 	done := make(chan struct{})
 	g.cancel = cancel
 	g.heartbeatDone = done
 	g.running = true
 	g.startHeartbeat(ctx, done)
+	g.startSupervisors(ctx)
+	if g.cfg.Push.Enabled {
+		g.goBackground(func() { g.runPush(ctx) })
+	}
+	if g.cfg.OTA.LANSharing.Enabled {
+		g.goBackground(func() { g.runLANSharing(ctx) })
+	}
 
 	return nil
 }
 
+// Stop cancels the context of everything Start launched and waits for the
+// heartbeat loop to exit, leaving the guard ready for another Start. It
+// also cancels a shutdown Enforcement scheduled and closes the gRPC
+// connection, and is otherwise a no-op on a guard that is not running. Use
+// StopAndWait to also wait for background updates.
 func (g *Guard) Stop() {
+	if g == nil {
+		return
+	}
+	g.cancelShutdown()
+	defer g.closeAPIConn()
 	g.lifecycleMu.Lock()
 	if !g.running {
 		g.lifecycleMu.Unlock()
@@ -161,6 +372,64 @@ func (g *Guard) Stop() {
 	if done != nil {
 		<-done
 	}
+	g.saveUsage()
+}
+
+// Close stops the guard like Stop and then removes the temporary state
+// directory of a NewForTesting or NewDevMode guard; for other guards it is
+// just Stop. Unlike Stop, the guard must not be started again afterwards.
+// Tests typically register it with t.Cleanup.
+func (g *Guard) Close() {
+	if g == nil {
+		return
+	}
+	g.Stop()
+	g.removeTestingDir()
+}
+
+// closeAPIConn closes the gRPC transport's connection; the next call opens
+// a new one.
+func (g *Guard) closeAPIConn() {
+	if grpcAPI, ok := g.api.(*grpcAPITransport); ok {
+		if err := grpcAPI.closeConn(); err != nil {
+			g.logger.Debug("close grpc connection failed", "error", err)
+		}
+	}
+}
+
+// StopAndWait stops the guard like Stop and then waits until the push
+// channel, background updates, server command handlers and supervised
+// component processes have returned.
+// Their context is cancelled, so downloads abort, but an update that is
+// already replacing files finishes first so the install is not left half
+// written. It returns ctx.Err() if ctx ends before they do; call it before
+// the process exits.
+func (g *Guard) StopAndWait(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	g.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		g.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// goBackground runs fn in a goroutine StopAndWait waits for.
+func (g *Guard) goBackground(fn func()) {
+	g.workers.Add(1)
+	go func() {
+		defer g.workers.Done()
+		fn()
+	}()
 }
 
 func (g *Guard) finishHeartbeat(done chan struct{}) {
@@ -169,6 +438,11 @@ func (g *Guard) finishHeartbeat(done chan struct{}) {
 	g.lifecycleMu.Lock()
 	defer g.lifecycleMu.Unlock()
 	if g.heartbeatDone == done {
+		// The loop ended on its own, e.g. after a ban; cancel the push
+		// channel it shared a context with so a later Start begins clean.
+		if g.cancel != nil {
+			g.cancel()
+		}
 		g.running = false
 		g.cancel = nil
 		g.heartbeatDone = nil
@@ -176,6 +450,16 @@ func (g *Guard) finishHeartbeat(done chan struct{}) {
 }
 
 func (g *Guard) Check() error {
+	if err := g.requireState(); err != nil {
+		return err
+	}
+	if g.devMode {
+		g.logger.Debug(devModeWarning, "call", "Check")
+		return nil
+	}
+	if g.killDeadline.Load() != nil {
+		g.enforceScheduledKill(time.Now())
+	}
 	switch g.sm.Current() {
 	case StateActive, StateGrace:
 		return nil
@@ -183,6 +467,8 @@ func (g *Guard) Check() error {
 		return ErrLocked
 	case StateBanned:
 		return ErrBanned
+	case StateDeactivated:
+		return ErrDeactivated
 	case StateInit:
 		return ErrNotActivated
 	default:
@@ -229,34 +515,132 @@ func (g *Guard) FeatureToken(name string) (string, error) {
 }
 
 func (g *Guard) State() State {
+	if g == nil || g.sm == nil {
+		return StateInit
+	}
 	return g.sm.Current()
 }
 
+// Status is a point-in-time view of the guard's enforcement state.
+type Status struct {
+	State State
+	// KillDeadline is set when the server scheduled a delayed kill; Check
+	// keeps succeeding until then. KillIn is the remaining countdown.
+	KillDeadline time.Time
+	KillIn       time.Duration
+	KillReason   string
+	// AppealStatus tracks an unban appeal filed with RequestUnban.
+	AppealStatus AppealStatus
+	// Grace is set while the guard is in GRACE; see Guard.GraceInfo.
+	Grace *GraceStatus
+	// Version is the version reported in heartbeats and VersionSource how
+	// it was determined.
+	Version       string
+	VersionSource VersionSource
+}
+
+// Status reports the current state together with any pending kill countdown.
+func (g *Guard) Status() Status {
+	if g.requireState() != nil {
+		return Status{State: StateInit}
+	}
+	now := time.Now()
+	g.enforceScheduledKill(now)
+
+	status := Status{State: g.sm.Current()}
+	g.mu.RLock()
+	if deadline := g.scheduledKillDeadline(); !deadline.IsZero() {
+		status.KillDeadline = deadline
+		status.KillReason = g.killReason
+		if remaining := deadline.Sub(now); remaining > 0 {
+			status.KillIn = remaining
+		}
+	}
+	status.AppealStatus = g.appealStatus
+	status.VersionSource = g.versionSource
+	g.mu.RUnlock()
+	status.Version = g.currentVersion()
+	if status.State == StateGrace {
+		if grace, ok := g.graceSnapshot(now); ok {
+			status.Grace = &grace
+		}
+	}
+	return status
+}
+
+// SetVersion sets the version reported in heartbeats, overriding any
+// resolved one.
 func (g *Guard) SetVersion(v string) {
+	g.setVersion(v, VersionSourceManual)
+}
+
+func (g *Guard) setVersion(v string, source VersionSource) {
+	g.version.Store(&v)
 	g.mu.Lock()
-	defer g.mu.Unlock()
-	g.version = v
+	g.versionSource = source
+	g.mu.Unlock()
 }
 
-// AutoResolveVersion automatically resolves the version by querying the
-// Centralized Release System (中央发版系统).
+// AutoResolveVersion automatically resolves the version reported in
+// heartbeats, trying in order:
 //
-// It calculates the binary hash of the current executable and sends it to
-// the central server, which returns the corresponding version information.
-// This eliminates the need to manually set version numbers.
+//  1. the Version injected at build time via ldflags, unless it is "dev";
+//  2. the Centralized Release System (中央发版系统), which maps the SHA-256
+//     of the running executable to a released version;
+//  3. the local version manifest (Config.VersionManifestPath).
 //
-// The central server maintains a hash-to-version mapping table for all
-// released artifacts across all projects, components, and platforms.
+// The source that succeeded is reported in Status().VersionSource. If every
+// step fails the version is left unchanged and the errors are returned.
 //
 // Usage:
 //
 //	guard, _ := sdk.New(cfg)
-//	if err := guard.AutoResolveVersion(); err != nil {
+//	if err := guard.AutoResolveVersionContext(ctx); err != nil {
 //	    // Fallback to default version
 //	    guard.SetVersion("unknown")
 //	}
-//	guard.Start(context.Background())
+//	guard.Start(ctx)
 func (g *Guard) AutoResolveVersion() error {
+	return g.AutoResolveVersionContext(context.Background())
+}
+
+// AutoResolveVersionContext is AutoResolveVersion with a caller context that
+// bounds the server lookup in addition to its own 10 second timeout.
+func (g *Guard) AutoResolveVersionContext(ctx context.Context) error {
+	if err := g.requireClient(); err != nil {
+		return err
+	}
+
+	if Version != "" && Version != "dev" {
+		g.setVersion(Version, VersionSourceLdflags)
+		g.logger.Info("version resolved automatically", "version", Version, "source", string(VersionSourceLdflags))
+		return nil
+	}
+
+	hashErr := g.resolveVersionByHash(ctx)
+	if hashErr == nil {
+		return nil
+	}
+
+	manifest, manifestErr := g.readVersionManifest()
+	if manifestErr != nil {
+		return fmt.Errorf("resolve version: %w", errors.Join(hashErr, manifestErr))
+	}
+	g.setVersion(manifest.Version, VersionSourceManifest)
+	g.logger.Info("version resolved automatically",
+		"version", manifest.Version,
+		"source", string(VersionSourceManifest),
+		"binary_hash_error", hashErr)
+	return nil
+}
+
+// resolveVersionByHash asks the central server for the version of the
+// running executable's binary hash.
+func (g *Guard) resolveVersionByHash(ctx context.Context) error {
+	if err := g.requireCapability(CapabilityVersionResolve); err != nil {
+		return err
+	}
+
 	// Calculate binary hash
 	binaryHash, err := GetBinaryHash()
 	if err != nil {
@@ -264,7 +648,7 @@ func (g *Guard) AutoResolveVersion() error {
 	}
 
 	reqBody := versionResolveRequest{
-		LicenseKey:  g.cfg.LicenseKey,
+		LicenseKey:  g.bodyLicenseKey(),
 		MachineID:   g.fingerprint.MachineID(),
 		ProjectSlug: g.cfg.ProjectSlug,
 		Component:   g.cfg.ComponentSlug,
@@ -276,34 +660,37 @@ func (g *Guard) AutoResolveVersion() error {
 		GitCommit string `json:"git_commit"`
 		BuildTime string `json:"build_time"`
 		Error     string `json:"error"`
+		Message   string `json:"message"`
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
 	defer cancel()
 
 	reqBodyJSON, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
 	}
-	raw, err := g.postJSON(ctx, "/api/v1/version/resolve", reqBodyJSON)
+	raw, err := g.postIdempotentJSON(ctx, "/api/v1/version/resolve", reqBodyJSON)
 	if err != nil {
-		return fmt.Errorf("request version resolution: %w", err)
+		return fmt.Errorf("request version resolution: %w", g.capabilityErr(CapabilityVersionResolve, err))
 	}
 	if err := json.Unmarshal(raw, &resp); err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
 
 	if resp.Error != "" {
-		return fmt.Errorf("server error: %s", resp.Error)
+		return newAPIError(http.StatusOK, resp.Error, resp.Message, "")
+	}
+	if resp.Version == "" {
+		return fmt.Errorf("%w: empty version", ErrInvalidServerResponse)
 	}
 
 	// Update version
-	g.mu.Lock()
-	g.version = resp.Version
-	g.mu.Unlock()
+	g.setVersion(resp.Version, VersionSourceBinaryHash)
 
 	g.logger.Info("version resolved automatically",
 		"version", resp.Version,
+		"source", string(VersionSourceBinaryHash),
 		"git_commit", resp.GitCommit,
 		"build_time", resp.BuildTime,
 		"binary_hash", binaryHash)
@@ -312,16 +699,41 @@ func (g *Guard) AutoResolveVersion() error {
 }
 
 func (g *Guard) SetManagedVersion(slug, version string) {
+	g.fillDefaults()
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	g.managedVersions[slug] = version
 }
 
+// ReportComponentVersion lists slug at version in heartbeats without making
+// it an OTA target, e.g. for extensions a scripting runtime loads at run
+// time. An empty version stops reporting slug. Slugs of the guard's own or
+// managed components are ignored; use SetVersion and SetManagedVersion for
+// those.
+func (g *Guard) ReportComponentVersion(slug, version string) {
+	if slug == "" || slug == g.cfg.ComponentSlug {
+		return
+	}
+	if _, managed := g.findManagedComponent(slug); managed {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if version == "" {
+		delete(g.reportedVersions, slug)
+		return
+	}
+	if g.reportedVersions == nil {
+		g.reportedVersions = make(map[string]string)
+	}
+	g.reportedVersions[slug] = version
+}
+
 func (g *Guard) SetLogger(logger *slog.Logger) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	if logger != nil {
-		g.logger = logger
+		g.logger = newRedactingLogger(logger, g.redactor)
 	}
 }
 
@@ -333,6 +745,9 @@ func (g *Guard) currentLeaseState() *persistedState {
 }
 
 func (g *Guard) currentActiveLease() (*persistedState, error) {
+	if err := g.requireState(); err != nil {
+		return nil, err
+	}
 	if state := g.currentLeaseState(); state != nil {
 		switch g.sm.Current() {
 		case StateActive, StateGrace:
@@ -355,7 +770,7 @@ func (g *Guard) verificationKeys() []ed25519.PublicKey {
 }
 
 type versionResolveRequest struct {
-	LicenseKey  string `json:"license_key"`
+	LicenseKey  string `json:"license_key,omitempty"`
 	MachineID   string `json:"machine_id"`
 	ProjectSlug string `json:"project_slug"`
 	Component   string `json:"component"`
@@ -364,20 +779,49 @@ type versionResolveRequest struct {
 
 // postJSON sends a bounded JSON POST request and returns the raw response body.
 func (g *Guard) postJSON(ctx context.Context, path string, data []byte) ([]byte, error) {
-	url := serverURLForPath(g.cfg.ServerURL, path)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	return g.sendJSON(ctx, path, data, false)
+}
+
+// postIdempotentJSON is postJSON for requests the server may safely receive
+// twice, such as download metadata; they are retried on transient failures.
+func (g *Guard) postIdempotentJSON(ctx context.Context, path string, data []byte) ([]byte, error) {
+	return g.sendJSON(ctx, path, data, true)
+}
+
+func (g *Guard) sendJSON(ctx context.Context, path string, data []byte, retry bool) ([]byte, error) {
+	return g.callAPI(ctx, TransportRequest{Method: http.MethodPost, Path: path, Body: data, Idempotent: retry})
+}
+
+// getJSON sends a bounded JSON GET request and returns the raw response body.
+func (g *Guard) getJSON(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	return g.callAPI(ctx, TransportRequest{Method: http.MethodGet, Path: path, Query: query, Idempotent: true})
+}
+
+func (g *Guard) postHTTPJSON(ctx context.Context, path string, data []byte, retry bool) ([]byte, error) {
+	url := g.serverURL(path)
+	body, contentType, err := g.encodeRequestBody(data)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
 
-	resp, err := g.httpClient.Do(req)
+	resp, err := g.doRequest(ctx, retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Accept", g.acceptHeader())
+		req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
+		req.Header.Set(headerProtocol, strconv.Itoa(ProtocolVersion))
+		g.signRequest(req, body)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		return nil, g.redactErr(fmt.Errorf("send request: %w", err))
 	}
 	defer resp.Body.Close()
 
+	g.recordServerProtocol(resp.Header.Get(headerProtocol))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, decodeAPIErrorResponse(resp)
 	}
@@ -386,28 +830,36 @@ func (g *Guard) postJSON(ctx context.Context, path string, data []byte) ([]byte,
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
+	raw, err = g.decodeResponseBody(resp.Header.Get("Content-Type"), raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
 	return raw, nil
 }
 
-// getJSON sends a bounded JSON GET request and returns the raw response body.
-func (g *Guard) getJSON(ctx context.Context, path string, query url.Values) ([]byte, error) {
-	fullURL := serverURLForPath(g.cfg.ServerURL, path)
+func (g *Guard) getHTTPJSON(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	fullURL := g.serverURL(path)
 	if len(query) > 0 {
 		fullURL += "?" + query.Encode()
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
-
-	resp, err := g.httpClient.Do(req)
+	resp, err := g.doRequest(ctx, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Accept", g.acceptHeader())
+		req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
+		req.Header.Set(headerProtocol, strconv.Itoa(ProtocolVersion))
+		g.signRequest(req, nil)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		return nil, g.redactErr(fmt.Errorf("send request: %w", err))
 	}
 	defer resp.Body.Close()
 
+	g.recordServerProtocol(resp.Header.Get(headerProtocol))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, decodeAPIErrorResponse(resp)
 	}
@@ -416,6 +868,10 @@ func (g *Guard) getJSON(ctx context.Context, path string, query url.Values) ([]b
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
+	raw, err = g.decodeResponseBody(resp.Header.Get("Content-Type"), raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
 	return raw, nil
 }
 
@@ -459,17 +915,25 @@ func decodePublicKeys(primary []byte, legacy [][]byte) ([]ed25519.PublicKey, err
 	return keys, nil
 }
 
+// newHTTPClient returns Config.HTTPClient when set, otherwise the guard's own
+// pinned client.
+func newHTTPClient(cfg Config) (*http.Client, error) {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient, nil
+	}
+	return newPinnedHTTPClient(cfg)
+}
+
 func newPinnedHTTPClient(cfg Config) (*http.Client, error) {
-	pins := cfg.PinnedSPKIHashes
+	transport, err := newBaseTransport(cfg.Transport)
+	if err != nil {
+		return nil, err
+	}
 	if cfg.AllowSystemTrust {
-		return &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					MinVersion: tls.VersionTLS12,
-				},
-			},
-		}, nil
+		return &http.Client{Transport: transport}, nil
 	}
+
+	pins := cfg.PinnedSPKIHashes
 	normalizedPins := make(map[string]struct{}, len(pins))
 	for _, pin := range pins {
 		normalized := strings.TrimSpace(pin)
@@ -481,37 +945,125 @@ func newPinnedHTTPClient(cfg Config) (*http.Client, error) {
 	if strings.HasPrefix(strings.TrimSpace(cfg.ServerURL), "https://") && len(normalizedPins) == 0 {
 		return nil, ErrTLSPinNotConfigured
 	}
-	tlsCfg := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-		VerifyConnection: func(cs tls.ConnectionState) error {
-			if len(normalizedPins) == 0 {
-				return ErrTLSPinNotConfigured
-			}
-			if len(cs.PeerCertificates) == 0 {
-				return ErrTLSPinMismatch
-			}
-			sum := sha256.Sum256(cs.PeerCertificates[0].RawSubjectPublicKeyInfo)
-			actual := base64.StdEncoding.EncodeToString(sum[:])
-			if _, ok := normalizedPins[actual]; ok {
-				return nil
-			}
-			return fmt.Errorf("%w: got %s", ErrTLSPinMismatch, actual)
-		},
+	transport.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(normalizedPins) == 0 {
+			return ErrTLSPinNotConfigured
+		}
+		if len(cs.PeerCertificates) == 0 {
+			return ErrTLSPinMismatch
+		}
+		sum := sha256.Sum256(cs.PeerCertificates[0].RawSubjectPublicKeyInfo)
+		actual := base64.StdEncoding.EncodeToString(sum[:])
+		if _, ok := normalizedPins[actual]; ok {
+			return nil
+		}
+		return fmt.Errorf("%w: got %s", ErrTLSPinMismatch, actual)
 	}
-
-	if pool, err := x509.SystemCertPool(); err == nil && pool != nil {
-		tlsCfg.RootCAs = pool
+	if transport.TLSClientConfig.RootCAs == nil {
+		if pool, err := x509.SystemCertPool(); err == nil && pool != nil {
+			transport.TLSClientConfig.RootCAs = pool
+		}
 	}
 
 	return &http.Client{
-		Transport: &pinEnforcingTransport{
-			base: &http.Transport{
-				TLSClientConfig: tlsCfg,
-			},
-		},
+		Transport: &pinEnforcingTransport{base: transport},
 	}, nil
 }
 
+// defaultCipherSuites are the TLS 1.2 suites offered unless
+// TransportConfig.CipherSuites says otherwise: forward-secret key exchange
+// with an AEAD cipher only.
+var defaultCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// newBaseTransport applies the proxy, root CA, static client certificate,
+// minimum TLS version and cipher suite options shared by the pinned and
+// system-trust clients.
+func newBaseTransport(opts TransportConfig) (*http.Transport, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:    tls.VersionTLS12,
+		CipherSuites:  defaultCipherSuites,
+		Renegotiation: tls.RenegotiateNever,
+	}
+	if opts.MinTLSVersion != 0 {
+		if opts.MinTLSVersion < tls.VersionTLS12 || opts.MinTLSVersion > tls.VersionTLS13 {
+			return nil, fmt.Errorf("unsupported minimum tls version %#x", opts.MinTLSVersion)
+		}
+		tlsCfg.MinVersion = opts.MinTLSVersion
+	}
+	if len(opts.CipherSuites) > 0 {
+		suites, err := validateCipherSuites(opts.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.CipherSuites = suites
+	}
+	if len(opts.RootCAsPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(opts.RootCAsPEM) {
+			return nil, fmt.Errorf("no valid certificates in root CA PEM")
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if len(opts.ClientCertPEM) > 0 || len(opts.ClientKeyPEM) > 0 {
+		cert, err := tls.X509KeyPair(opts.ClientCertPEM, opts.ClientKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if strings.TrimSpace(opts.ProxyURL) != "" {
+		proxyURL, err := url.Parse(strings.TrimSpace(opts.ProxyURL))
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", opts.ProxyURL)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := &http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsCfg,
+	}
+	if len(opts.HostOverrides) > 0 {
+		transport.DialContext = overridingDialer(opts.HostOverrides)
+	}
+	return transport, nil
+}
+
+// validateCipherSuites accepts only suites Go considers secure and that can
+// be negotiated in TLS 1.2.
+func validateCipherSuites(ids []uint16) ([]uint16, error) {
+	secure := make(map[uint16]*tls.CipherSuite)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.ID] = suite
+	}
+	suites := make([]uint16, 0, len(ids))
+	for _, id := range ids {
+		suite, ok := secure[id]
+		if !ok {
+			return nil, fmt.Errorf("insecure or unknown tls cipher suite %s", tls.CipherSuiteName(id))
+		}
+		if !slices.Contains(suite.SupportedVersions, tls.VersionTLS12) {
+			return nil, fmt.Errorf("tls cipher suite %s is tls 1.3 only and cannot be configured", suite.Name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}
+
 type pinEnforcingTransport struct {
 	base http.RoundTripper
 }
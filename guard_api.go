@@ -0,0 +1,82 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// GuardAPI is the part of *Guard an application usually calls after
+// construction: lifecycle, state, version reporting, updates, plugins and
+// feedback. Depend on it instead of *Guard to mock the SDK in unit tests or
+// swap implementations without touching the concrete struct.
+//
+// Methods may be added to GuardAPI in minor releases as *Guard grows; embed
+// GuardAPI in a mock to keep it compiling.
+type GuardAPI interface {
+	// Lifecycle and state.
+	Start(ctx context.Context) error
+	Stop()
+	StopAndWait(ctx context.Context) error
+	Check() error
+	State() State
+	Status() Status
+	States() <-chan StateTransition
+	OnStateChange(fn func(old, new State, reason string))
+	CheckFeatureMatrix(names ...string) map[string]FeatureStatus
+	RecordUsage(feature string, qty int64) error
+	AcquireSeat(ctx context.Context, userID string) (Seat, error)
+	ReleaseSeat(ctx context.Context, userID string) error
+	LicenseExpiry() (time.Time, bool)
+	LicenseInfo() (LicenseInfo, bool)
+	RefreshLicense(ctx context.Context) error
+	Deactivate(ctx context.Context) error
+
+	// Versions and updates.
+	SetVersion(v string)
+	SetManagedVersion(slug, version string)
+	ReportComponentVersion(slug, version string)
+	RecordComponentStart(slug string) error
+	RestartComponent(slug string) error
+	FrontendManifest(slug string) (AssetManifest, bool)
+	RollbackFrontend(slug string) error
+	IsUpdateDowngrade(component string) bool
+	InstallVersion(ctx context.Context, component, version string) error
+	PlanUpdate(ctx context.Context, component string) (UpdatePlan, error)
+	PlanUpdateWithOptions(ctx context.Context, component string, opts PlanOptions) (UpdatePlan, error)
+	UnpinVersion(component string)
+	RemoteConfig() RemoteConfig
+	OnRemoteConfigChange(fn func(old, new RemoteConfig))
+	WaitForUpdate(ctx context.Context, slug, version string) error
+	ArtifactCacheUsage() (ArtifactCacheUsage, error)
+	PruneArtifactCache(maxBytes int64) (int64, error)
+	DownloadArtifact(ctx context.Context, meta FetchRequest, w io.Writer, progress func(written, total int64)) error
+
+	// Plugins.
+	GetPluginCatalog(ctx context.Context, includeUninstalled bool) (*PluginCatalog, error)
+	ListPlugins(ctx context.Context) ([]PluginInfo, error)
+	CheckPluginUpdates(ctx context.Context) ([]PluginInfo, error)
+	RequestPluginUpdate(ctx context.Context, slug string, options PluginUpdateOptions) (*PluginUpdatePackage, error)
+	UpdatePlugin(ctx context.Context, slug string) error
+	DownloadPluginArtifact(ctx context.Context, slug, version string) (string, ArtifactMeta, error)
+
+	// Feedback and release notes.
+	SubmitFeedback(ctx context.Context, req SubmitFeedbackRequest) (*FeedbackItem, error)
+	ListMyFeedback(ctx context.Context, userID string, page, pageSize int) (*FeedbackListResponse, error)
+	ListMyFeedbackWithQuery(ctx context.Context, q FeedbackQuery) (*FeedbackListResponse, error)
+	CountMyFeedback(ctx context.Context, q FeedbackQuery) (int, error)
+	GetFeedback(ctx context.Context, id string) (*FeedbackItem, error)
+	WatchFeedback(ctx context.Context, id string) (<-chan FeedbackReply, error)
+	UploadFeedbackFile(ctx context.Context, fileName string, contentType string, data io.Reader) (*UploadURLResponse, error)
+	UploadFeedbackFiles(ctx context.Context, files []FeedbackUpload, opts FeedbackUploadOptions) ([]FeedbackAttachment, error)
+	FetchReleaseNotes(ctx context.Context) (*ReleaseNotesResponse, error)
+	FetchReleaseNotesWithQuery(ctx context.Context, q ReleaseNotesQuery) (*ReleaseNotesResponse, error)
+	ReleaseNotesSince(ctx context.Context, lastSeenVersion, locale string) ([]ReleaseNoteEntry, error)
+
+	// Announcements.
+	FetchAnnouncements(ctx context.Context) ([]Announcement, error)
+	Announcements() []Announcement
+	MarkAnnouncementRead(id string)
+}
+
+var _ GuardAPI = (*Guard)(nil)
@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeGuard overrides Check and embeds GuardAPI for everything else, the way
+// downstream tests mock the SDK.
+type fakeGuard struct {
+	GuardAPI
+	checkErr error
+}
+
+func (f fakeGuard) Check() error { return f.checkErr }
+
+func requireLicensed(guard GuardAPI) error {
+	return guard.Check()
+}
+
+func TestGuardAPI_AcceptsGuardAndMocks(t *testing.T) {
+	guard, err := NewDevMode(Config{})
+	if err != nil {
+		t.Fatalf("new dev mode guard: %v", err)
+	}
+	if err := guard.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer guard.Stop()
+	if err := requireLicensed(guard); err != nil {
+		t.Fatalf("guard check: %v", err)
+	}
+	if err := requireLicensed(fakeGuard{checkErr: ErrLocked}); err != ErrLocked {
+		t.Fatalf("expected mocked ErrLocked, got %v", err)
+	}
+}
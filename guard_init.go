@@ -0,0 +1,137 @@
+package sdk
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// testingMachineID is the machine ID NewForTesting reports to the server.
+const testingMachineID = "sha256:testing"
+
+// NewForTesting builds a Guard for unit tests and integration harnesses
+// without reading the real machine fingerprint, requiring TLS pins or
+// touching the user's home directory. It is New with test overrides: missing
+// identity fields get placeholder values, ServerURL defaults to a local
+// address rather than the production endpoint, system roots are trusted
+// unless pins are set, and state is kept in a fresh temporary directory that
+// Close removes; Stop keeps it so the guard can be started again.
+// PublicKeyPEM is optional; without it every signed server response is
+// rejected.
+func NewForTesting(cfg Config) (*Guard, error) {
+	if cfg.ServerURL == "" {
+		cfg.ServerURL = "http://127.0.0.1"
+	}
+	if cfg.LicenseKey == "" {
+		cfg.LicenseKey = "test-license"
+	}
+	if cfg.ProjectSlug == "" {
+		cfg.ProjectSlug = "test-project"
+	}
+	if cfg.ComponentSlug == "" {
+		cfg.ComponentSlug = "test-component"
+	}
+	if cfg.PublicKeyPEM == nil {
+		// A key nobody holds the private half of, so nothing verifies.
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		cfg.PublicKeyPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	}
+	if cfg.HTTPClient == nil && len(cfg.PinnedSPKIHashes) == 0 {
+		cfg.AllowSystemTrust = true
+	}
+	dir, err := os.MkdirTemp("", "banyanhub-sdk-testing-*")
+	if err != nil {
+		return nil, fmt.Errorf("create state dir: %w", err)
+	}
+	cfg.cacheDir = dir
+
+	g, err := newGuard(cfg, &Fingerprint{
+		machineID:  testingMachineID,
+		auxSignals: map[string]string{"os": runtime.GOOS, "arch": runtime.GOARCH},
+	})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	g.testingDir = dir
+	return g, nil
+}
+
+// removeTestingDir deletes the state directory of a NewForTesting guard.
+func (g *Guard) removeTestingDir() {
+	if g.testingDir != "" {
+		_ = os.RemoveAll(g.testingDir)
+	}
+}
+
+// fillDefaults gives a partially constructed Guard the pieces that have a
+// harmless default: a discarding logger and the version maps.
+func (g *Guard) fillDefaults() {
+	g.defaultsOnce.Do(func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.heartbeatWake == nil {
+			g.heartbeatWake = make(chan struct{}, 1)
+		}
+		if g.logger == nil {
+			g.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		}
+		if g.currentVersion() == "" {
+			unknown := "unknown"
+			g.version.Store(&unknown)
+		}
+		if g.managedVersions == nil {
+			g.managedVersions = make(map[string]string)
+		}
+		if g.configVersions == nil {
+			g.configVersions = make(map[string]string)
+		}
+		if g.supervisors == nil {
+			g.supervisors = newSupervisors(g.cfg.ManagedComponents)
+		}
+	})
+}
+
+// requireInitialized reports which subsystems an exported method needs but
+// the Guard lacks, instead of letting the call panic on a nil field. Guards
+// built with New or NewForTesting always pass.
+func (g *Guard) requireInitialized(needState, needClient bool) error {
+	if g == nil {
+		return fmt.Errorf("%w: nil guard", ErrGuardNotInitialized)
+	}
+	g.fillDefaults()
+
+	var missing []string
+	if needState && g.sm == nil {
+		missing = append(missing, "state machine")
+	}
+	if needClient && g.fingerprint == nil {
+		missing = append(missing, "fingerprint")
+	}
+	if needClient && g.httpClient == nil {
+		missing = append(missing, "http client")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrGuardNotInitialized, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// requireState guards methods that read or change the guard state.
+func (g *Guard) requireState() error {
+	return g.requireInitialized(true, false)
+}
+
+// requireClient guards methods that call the server.
+func (g *Guard) requireClient() error {
+	return g.requireInitialized(false, true)
+}
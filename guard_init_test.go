@@ -0,0 +1,133 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPartialGuardReturnsErrorsInsteadOfPanicking(t *testing.T) {
+	g := &Guard{}
+	ctx := context.Background()
+
+	if err := g.Check(); !errors.Is(err, ErrGuardNotInitialized) {
+		t.Fatalf("Check: expected ErrGuardNotInitialized, got %v", err)
+	}
+	if err := g.Start(ctx); !errors.Is(err, ErrGuardNotInitialized) {
+		t.Fatalf("Start: expected ErrGuardNotInitialized, got %v", err)
+	}
+	g.Stop()
+	if got := g.State(); got != StateInit {
+		t.Fatalf("State = %v", got)
+	}
+	if got := g.Status().State; got != StateInit {
+		t.Fatalf("Status().State = %v", got)
+	}
+	g.SetVersion("1.0.0")
+	g.SetManagedVersion("frontend", "1.0.0")
+	if _, err := g.Unseal([]byte("box")); !errors.Is(err, ErrGuardNotInitialized) {
+		t.Fatalf("Unseal: expected ErrGuardNotInitialized, got %v", err)
+	}
+	if _, err := g.SubmitFeedback(ctx, SubmitFeedbackRequest{}); !errors.Is(err, ErrGuardNotInitialized) {
+		t.Fatalf("SubmitFeedback: expected ErrGuardNotInitialized, got %v", err)
+	}
+	if _, err := g.GetPluginCatalog(ctx, true); !errors.Is(err, ErrGuardNotInitialized) {
+		t.Fatalf("GetPluginCatalog: expected ErrGuardNotInitialized, got %v", err)
+	}
+	if err := g.UninstallMarketplaceItem(ctx, "reports"); !errors.Is(err, ErrGuardNotInitialized) {
+		t.Fatalf("UninstallMarketplaceItem: expected ErrGuardNotInitialized, got %v", err)
+	}
+	if got := g.CheckFeatureMatrix("reports")["reports"]; got.Enabled {
+		t.Fatalf("uninitialized guard enabled a feature: %#v", got)
+	}
+
+	var nilGuard *Guard
+	if err := nilGuard.Check(); !errors.Is(err, ErrGuardNotInitialized) {
+		t.Fatalf("nil Check: expected ErrGuardNotInitialized, got %v", err)
+	}
+}
+
+func TestNewForTestingUsesSafeDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(FeedbackItem{ID: "fb-1"})
+	}))
+	defer server.Close()
+
+	g, err := NewForTesting(Config{ServerURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(g.cfg.cacheDir)
+
+	if g.State() != StateInit || g.fingerprint.MachineID() != testingMachineID {
+		t.Fatalf("unexpected defaults: state=%v machine=%s", g.State(), g.fingerprint.MachineID())
+	}
+	item, err := g.SubmitFeedback(context.Background(), SubmitFeedbackRequest{Title: "hello"})
+	if err != nil || item.ID != "fb-1" {
+		t.Fatalf("SubmitFeedback = %#v, %v", item, err)
+	}
+
+	if err := g.store.Save(&persistedState{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(g.cfg.cacheDir, "state.bin")); err != nil {
+		t.Fatalf("state should live in the temporary dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".deploy-guard")); !os.IsNotExist(err) {
+		t.Fatalf("NewForTesting must not write under HOME, stat err = %v", err)
+	}
+}
+
+func TestNewForTestingValidatesAndCloseRemovesState(t *testing.T) {
+	if _, err := NewForTesting(Config{HeartbeatInterval: -time.Second}); err == nil {
+		t.Fatal("expected NewForTesting to validate the config")
+	}
+
+	g, err := NewForTesting(Config{RateLimit: RateLimitPolicy{RequestsPerSecond: 1, Burst: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.limiter == nil {
+		t.Fatal("NewForTesting should build the rate limiter like New")
+	}
+	dir := g.cfg.cacheDir
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("state dir: %v", err)
+	}
+	g.Stop()
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("Stop on a guard that never started must keep the state dir: %v", err)
+	}
+	g.Close()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("Close should remove the state dir, stat err = %v", err)
+	}
+}
+
+func TestDevModeGuardRestartsAfterStop(t *testing.T) {
+	g, err := NewDevMode(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(g.Close)
+	starts := filepath.Join(g.cfg.cacheDir, componentStartsFileName)
+
+	for i := range 2 {
+		if err := g.Start(context.Background()); err != nil {
+			t.Fatalf("start %d: %v", i+1, err)
+		}
+		g.Stop()
+		if _, err := os.Stat(starts); err != nil {
+			t.Fatalf("Stop %d removed the guard state: %v", i+1, err)
+		}
+	}
+}
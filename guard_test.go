@@ -1,13 +1,18 @@
 package sdk
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/pem"
 	"errors"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestNew_Success(t *testing.T) {
@@ -146,6 +151,143 @@ func TestNew_HTTPSRequiresPinOrSystemTrust(t *testing.T) {
 	}
 }
 
+func newTransportTestConfig(t *testing.T, serverURL string) Config {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	return Config{
+		ServerURL:        serverURL,
+		LicenseKey:       "test-key",
+		PublicKeyPEM:     pemEncodePublicKey(pubKey),
+		ProjectSlug:      "test-project",
+		ComponentSlug:    "backend",
+		AllowSystemTrust: true,
+	}
+}
+
+func TestNew_UsesConfiguredHTTPClient(t *testing.T) {
+	cfg := newTransportTestConfig(t, "https://api.example.com")
+	cfg.AllowSystemTrust = false
+	cfg.HTTPClient = &http.Client{}
+
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatalf("a custom client must not require pins: %v", err)
+	}
+	if guard.httpClient != cfg.HTTPClient {
+		t.Fatal("expected Config.HTTPClient to be used as is")
+	}
+}
+
+func TestNew_RootCAsPEMTrustsPrivateCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	cfg := newTransportTestConfig(t, server.URL)
+	cfg.Transport.RootCAsPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	cfg.Transport.MinTLSVersion = tls.VersionTLS13
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.getJSON(context.Background(), "/", nil); err != nil {
+		t.Fatalf("expected the private CA to be trusted, got %v", err)
+	}
+
+	guard, err = New(newTransportTestConfig(t, server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.getJSON(context.Background(), "/", nil); err == nil {
+		t.Fatal("expected the system roots to reject the test certificate")
+	}
+}
+
+func TestNew_CipherSuitePolicy(t *testing.T) {
+	transport, err := newBaseTransport(TransportConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsCfg := transport.TLSClientConfig
+	if tlsCfg.Renegotiation != tls.RenegotiateNever {
+		t.Fatalf("renegotiation = %v", tlsCfg.Renegotiation)
+	}
+	for _, id := range tlsCfg.CipherSuites {
+		for _, weak := range tls.InsecureCipherSuites() {
+			if id == weak.ID {
+				t.Fatalf("default suites include insecure %s", weak.Name)
+			}
+		}
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA}}
+	server.StartTLS()
+	defer server.Close()
+
+	cfg := newTransportTestConfig(t, server.URL)
+	cfg.Transport.RootCAsPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.getJSON(context.Background(), "/", nil); err == nil {
+		t.Fatal("expected a CBC-only server to be refused by default")
+	}
+
+	cfg.Transport.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA}
+	guard, err = New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.getJSON(context.Background(), "/", nil); err != nil {
+		t.Fatalf("expected the explicitly allowed suite to connect, got %v", err)
+	}
+}
+
+func TestNew_ProxyURLRoutesServerCalls(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer proxy.Close()
+
+	cfg := newTransportTestConfig(t, "http://banyanhub.invalid")
+	cfg.Transport.ProxyURL = proxy.URL
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.getJSON(context.Background(), "/api/v1/capabilities", nil); err != nil {
+		t.Fatalf("request through proxy: %v", err)
+	}
+	if proxied != "http://banyanhub.invalid/api/v1/capabilities" {
+		t.Fatalf("proxy saw %q", proxied)
+	}
+}
+
+func TestNew_RejectsInvalidTransportOptions(t *testing.T) {
+	for name, transport := range map[string]TransportConfig{
+		"tls 1.1":      {MinTLSVersion: tls.VersionTLS11},
+		"weak cipher":  {CipherSuites: []uint16{tls.TLS_RSA_WITH_RC4_128_SHA}},
+		"tls 1.3 only": {CipherSuites: []uint16{tls.TLS_AES_128_GCM_SHA256}},
+		"proxy scheme": {ProxyURL: "ftp://proxy.example.com"},
+		"root ca":      {RootCAsPEM: []byte("not a certificate")},
+		"client cert":  {ClientCertPEM: []byte("bad"), ClientKeyPEM: []byte("bad")},
+	} {
+		cfg := newTransportTestConfig(t, "https://api.example.com")
+		cfg.Transport = transport
+		if _, err := New(cfg); err == nil {
+			t.Fatalf("%s: expected New to fail", name)
+		}
+	}
+}
+
 func TestNew_EmptyServerURL_UsesDefault(t *testing.T) {
 	g := &Guard{
 		sm: newStateMachine(),
@@ -202,14 +344,13 @@ func TestSetLogger(t *testing.T) {
 }
 
 func TestSetVersion(t *testing.T) {
-	g := &Guard{
-		version: "1.0.0",
-	}
+	g := &Guard{}
+	g.SetVersion("1.0.0")
 
 	g.SetVersion("2.0.0")
 
-	if g.version != "2.0.0" {
-		t.Errorf("expected version 2.0.0, got %s", g.version)
+	if got := g.currentVersion(); got != "2.0.0" {
+		t.Errorf("expected version 2.0.0, got %s", got)
 	}
 }
 
@@ -234,3 +375,36 @@ func pemEncodePublicKey(pubKey ed25519.PublicKey) []byte {
 		Bytes: pubKey,
 	})
 }
+
+func TestCheckDoesNotWaitForGuardLocks(t *testing.T) {
+	guard, _, _, _ := newActiveHeartbeatGuard(t)
+	guard.scheduleKill(time.Now().Add(time.Hour), "maintenance")
+
+	guard.mu.Lock()
+	guard.sm.mu.Lock()
+	done := make(chan error, 1)
+	go func() { done <- guard.Check() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Check = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Check blocked on a held mutex")
+	}
+	guard.sm.mu.Unlock()
+	guard.mu.Unlock()
+}
+
+func BenchmarkCheckParallel(b *testing.B) {
+	guard := &Guard{sm: newStateMachine()}
+	guard.sm.set(StateActive)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := guard.Check(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
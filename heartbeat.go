@@ -10,19 +10,29 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 )
 
 type heartbeatResponse struct {
-	Status            string          `json:"status"`
-	Lease             json.RawMessage `json:"lease"`
-	LeaseSignature    string          `json:"lease_signature"`
-	ResponseSignature string          `json:"response_signature"`
-	Nonce             string          `json:"nonce"`
-	ServerTime        string          `json:"server_time"`
-	Updates           []updateInfo    `json:"updates"`
-	Reason            string          `json:"reason"`
-	Message           string          `json:"message"`
+	Status            string                   `json:"status"`
+	Lease             json.RawMessage          `json:"lease"`
+	LeaseSignature    string                   `json:"lease_signature"`
+	ResponseSignature string                   `json:"response_signature"`
+	Nonce             string                   `json:"nonce"`
+	ServerTime        string                   `json:"server_time"`
+	Updates           []updateInfo             `json:"updates"`
+	Configs           []componentConfig        `json:"configs,omitempty"`
+	KillAfter         int64                    `json:"kill_after,omitempty"`
+	NextInterval      int64                    `json:"next_interval_s,omitempty"`
+	Appeal            *heartbeatAppeal         `json:"appeal,omitempty"`
+	Commands          []heartbeatCommand       `json:"commands,omitempty"`
+	FeedbackReplies   []heartbeatFeedbackReply `json:"feedback_replies,omitempty"`
+	Flags             map[string]bool          `json:"flags,omitempty"`
+	RemoteConfig      *remoteConfigBlob        `json:"remote_config,omitempty"`
+	Announcements     []Announcement           `json:"announcements,omitempty"`
+	Reason            string                   `json:"reason"`
+	Message           string                   `json:"message"`
 }
 
 type updateInfo struct {
@@ -32,52 +42,98 @@ type updateInfo struct {
 	UpdateAvailable bool   `json:"update_available"`
 	Mandatory       bool   `json:"mandatory"`
 	ReleaseNotes    string `json:"release_notes"`
+	// UpgradePath lists the versions, oldest first, that must be installed
+	// on the way to Latest, e.g. for releases carrying required migrations.
+	UpgradePath []string `json:"upgrade_path,omitempty"`
+	// Group names the release group the update belongs to; updates of one
+	// group are applied together in ascending GroupOrder. See
+	// applyReleaseGroup.
+	Group      string `json:"group,omitempty"`
+	GroupOrder int    `json:"group_order,omitempty"`
 }
 
 type heartbeatComponent struct {
-	Slug    string `json:"slug"`
-	Version string `json:"version"`
+	Slug          string `json:"slug"`
+	Version       string `json:"version"`
+	ConfigVersion string `json:"config_version,omitempty"`
+	// ReportOnly marks components added with ReportComponentVersion, which
+	// the server must not offer updates for.
+	ReportOnly bool `json:"report_only,omitempty"`
+	// Health is set once the component has started or has a HealthCheck.
+	Health *componentHealth `json:"health,omitempty"`
 }
 
 type heartbeatRequestBody struct {
-	LicenseKey    string               `json:"license_key"`
-	MachineID     string               `json:"machine_id"`
-	ProjectSlug   string               `json:"project_slug"`
-	ComponentSlug string               `json:"component_slug"`
-	Components    []heartbeatComponent `json:"components"`
-	Nonce         string               `json:"nonce"`
-	Timestamp     int64                `json:"timestamp"`
-	BinaryHash    string               `json:"binary_hash"`
+	LicenseKey     string               `json:"license_key,omitempty"`
+	MachineID      string               `json:"machine_id"`
+	ProjectSlug    string               `json:"project_slug"`
+	ComponentSlug  string               `json:"component_slug"`
+	Components     []heartbeatComponent `json:"components"`
+	Nonce          string               `json:"nonce"`
+	Timestamp      int64                `json:"timestamp"`
+	BinaryHash     string               `json:"binary_hash"`
+	UpdateStats    []updateStatsReport  `json:"update_stats,omitempty"`
+	CommandResults []commandResult      `json:"command_results,omitempty"`
+	Plugins        []heartbeatPlugin    `json:"plugins,omitempty"`
+	Errors         []errorReport        `json:"errors,omitempty"`
+	Drift          *FingerprintDrift    `json:"drift,omitempty"`
+	Usage          []usageReport        `json:"usage,omitempty"`
+	Seats          []string             `json:"seats,omitempty"`
 }
 
 type heartbeatSignaturePayload struct {
-	Lease          json.RawMessage `json:"lease"`
-	LeaseSignature string          `json:"lease_signature"`
-	Nonce          string          `json:"nonce"`
-	ServerTime     string          `json:"server_time"`
-	Status         string          `json:"status"`
-	UpdatesDigest  string          `json:"updates_digest"`
+	Lease               json.RawMessage `json:"lease"`
+	LeaseSignature      string          `json:"lease_signature"`
+	Nonce               string          `json:"nonce"`
+	ServerTime          string          `json:"server_time"`
+	Status              string          `json:"status"`
+	UpdatesDigest       string          `json:"updates_digest"`
+	CommandsDigest      string          `json:"commands_digest,omitempty"`
+	FlagsDigest         string          `json:"flags_digest,omitempty"`
+	KillAfter           int64           `json:"kill_after,omitempty"`
+	Reason              string          `json:"reason,omitempty"`
+	Message             string          `json:"message,omitempty"`
+	NextInterval        int64           `json:"next_interval_s,omitempty"`
+	AppealDigest        string          `json:"appeal_digest,omitempty"`
+	AnnouncementsDigest string          `json:"announcements_digest,omitempty"`
 }
 
 func (g *Guard) startHeartbeat(ctx context.Context, done chan struct{}) {
-	interval := g.cfg.HeartbeatInterval
-	graceStart := time.Time{}
+	var grace graceTracker
 
 	go func() {
 		defer g.finishHeartbeat(done)
 
 		for {
-			jitter := heartbeatJitter(interval)
+			if g.sm.Current() == StateBanned && !g.appealPending() {
+				return
+			}
+			g.checkLicenseExpiry(time.Now())
+			jitter := heartbeatJitter(g.currentHeartbeatInterval())
 			select {
 			case <-ctx.Done():
 				return
 			case <-time.After(jitter):
+			case <-g.heartbeatWake:
+			}
+
+			if err := g.observeClock(time.Now()); errors.Is(err, ErrClockRollback) {
+				g.sm.OnClockTampered()
+				g.lock("system clock moved backwards, guard locked")
+				if !g.awaitLockRecovery(ctx) {
+					return
+				}
+				grace = graceTracker{}
+				continue
 			}
 
 			err := g.sendHeartbeat(ctx)
 			if err == nil {
 				g.sm.OnHeartbeatOK()
-				graceStart = time.Time{}
+				grace = graceTracker{}
+				if err := g.ensureClientCertificate(ctx); err != nil {
+					g.logger.Error("client certificate renewal failed", "error", err)
+				}
 				continue
 			}
 			if errors.Is(err, context.Canceled) {
@@ -87,23 +143,154 @@ func (g *Guard) startHeartbeat(ctx context.Context, done chan struct{}) {
 			if isFatalError(err) {
 				g.sm.OnKill()
 				_ = g.persistBan()
+				if g.appealPending() {
+					continue
+				}
 				return
 			}
 
+			// The grace window is recorded before entering GRACE so the
+			// state-change event carries the deadline.
+			expired := g.advanceGrace(&grace, time.Now(), err)
 			g.sm.OnHeartbeatFail()
 			_ = g.persistGrace()
-			if graceStart.IsZero() {
-				graceStart = time.Now()
-			}
-			if time.Since(graceStart) > g.cfg.GracePolicy.MaxOfflineDuration {
+			if expired {
 				g.sm.OnGracePeriodExpired()
-				_ = g.persistLock()
-				return
+				g.lock("offline grace period expired, guard locked")
+				if !g.awaitLockRecovery(ctx) {
+					return
+				}
+				grace = graceTracker{}
 			}
 		}
 	}()
 }
 
+// lock persists the LOCKED state and fires OnLocked.
+func (g *Guard) lock(message string) {
+	_ = g.persistLock()
+	g.logger.Error(message)
+	if g.cfg.OnLocked != nil {
+		g.cfg.OnLocked()
+	}
+}
+
+// graceTracker follows one offline episode so grace warnings can be spaced
+// by GracePolicy.WarningInterval and the offline budget can tighten as
+// failures escalate.
+type graceTracker struct {
+	start           time.Time
+	lastWarning     time.Time
+	budget          time.Duration
+	licenseFailures int
+}
+
+// advanceGrace records a failed heartbeat and reports whether the offline
+// grace period has expired. The episode budget is the tightest budget of any
+// failure category seen so far; license failures only count once
+// GracePolicy.LicenseEscalateAfter of them were seen in the episode, and
+// until then leave the budget as it was. Only a verified heartbeat ends the
+// episode. While still in grace it emits OnGraceWarning at most once per
+// WarningInterval, starting with the first failure.
+func (g *Guard) advanceGrace(grace *graceTracker, now time.Time, cause error) bool {
+	policy := g.cfg.GracePolicy
+	if grace.start.IsZero() {
+		grace.start = now
+	}
+
+	category := classifyFailure(cause)
+	var budget time.Duration
+	if category == FailureLicense {
+		grace.licenseFailures++
+		if grace.licenseFailures >= policy.escalationThreshold() {
+			budget = policy.maxOfflineFor(FailureLicense)
+		}
+	} else {
+		budget = policy.maxOfflineFor(category)
+	}
+	switch {
+	case budget > 0 && (grace.budget <= 0 || budget < grace.budget):
+		grace.budget = budget
+	case grace.budget <= 0:
+		grace.budget = policy.MaxOfflineDuration
+	}
+	g.setGraceWindow(grace.start, grace.start.Add(grace.budget))
+
+	elapsed := now.Sub(grace.start)
+	if elapsed > grace.budget {
+		return true
+	}
+	if current := g.sm.Current(); current != StateGrace && current != StateActive {
+		return false
+	}
+	if !grace.lastWarning.IsZero() && now.Sub(grace.lastWarning) < policy.WarningInterval {
+		return false
+	}
+	grace.lastWarning = now
+	remaining := grace.budget - elapsed
+	g.logger.Warn("running in offline grace period", "remaining", remaining.String(), "failure", category.String())
+	if g.cfg.OnGraceWarning != nil {
+		g.cfg.OnGraceWarning(remaining)
+	}
+	return false
+}
+
+// awaitLockRecovery retries online verification every
+// GracePolicy.RecoveryInterval while the guard is LOCKED. It reports whether
+// the heartbeat loop should resume: true once the guard is ACTIVE again, or
+// when the server banned the machine while an appeal is pending.
+func (g *Guard) awaitLockRecovery(ctx context.Context) bool {
+	interval := g.cfg.GracePolicy.RecoveryInterval
+	if interval < 0 {
+		return false
+	}
+	for g.sm.Current() == StateLocked {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(heartbeatJitter(interval)):
+		}
+
+		err := g.recoverFromLock(ctx)
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		if isFatalError(err) {
+			g.sm.OnKill()
+			_ = g.persistBan()
+			return g.appealPending()
+		}
+		g.logger.Warn("locked guard still cannot verify", "error", err)
+	}
+	return g.sm.Current() == StateActive
+}
+
+// recoverFromLock verifies online and, on success, clears the persisted lock
+// and moves the guard back to ACTIVE.
+func (g *Guard) recoverFromLock(ctx context.Context) (err error) {
+	defer func() {
+		if !errors.Is(err, context.Canceled) {
+			g.auditOutcome(AuditVerification, g.cfg.ComponentSlug, err, "unlock")
+		}
+	}()
+	leaseValue, leaseSignature, err := g.verifyOnline(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := g.acceptLease(leaseValue, leaseSignature, false); err != nil {
+		return err
+	}
+	g.sm.OnVerifySuccess()
+	g.logger.Info("license verified again, guard unlocked")
+	if g.cfg.OnUnlocked != nil {
+		g.cfg.OnUnlocked()
+	}
+	return nil
+}
+
 func heartbeatJitter(interval time.Duration) time.Duration {
 	if interval <= 0 {
 		return 0
@@ -124,14 +311,66 @@ func heartbeatJitter(interval time.Duration) time.Duration {
 	return interval - delta + time.Duration(offset.Int64())
 }
 
-func (g *Guard) sendHeartbeat(parent context.Context) error {
+// currentHeartbeatInterval returns the server-suggested interval when one was
+// received, otherwise the configured HeartbeatInterval.
+func (g *Guard) currentHeartbeatInterval() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.heartbeatInterval > 0 {
+		return g.heartbeatInterval
+	}
+	return g.cfg.HeartbeatInterval
+}
+
+// adoptHeartbeatInterval applies the signed next_interval_s of a verified
+// heartbeat, clamped to the configured bounds. A missing value reverts to the
+// default.
+func (g *Guard) adoptHeartbeatInterval(seconds int64) {
+	var interval time.Duration
+	if seconds > 0 {
+		interval = clampHeartbeatInterval(time.Duration(seconds)*time.Second, g.cfg.HeartbeatMinInterval, g.cfg.HeartbeatMaxInterval)
+	}
+	g.mu.Lock()
+	changed := g.heartbeatInterval != interval
+	g.heartbeatInterval = interval
+	g.mu.Unlock()
+	if changed && interval > 0 {
+		g.logger.Info("heartbeat interval adjusted by server", "interval", interval.String())
+	}
+}
+
+func clampHeartbeatInterval(interval, minInterval, maxInterval time.Duration) time.Duration {
+	if minInterval > 0 && interval < minInterval {
+		return minInterval
+	}
+	if maxInterval > 0 && interval > maxInterval {
+		return maxInterval
+	}
+	return interval
+}
+
+func (g *Guard) sendHeartbeat(parent context.Context) (err error) {
+	defer func() {
+		if !errors.Is(err, context.Canceled) {
+			g.metrics.observeHeartbeat(err)
+		}
+	}()
+	currentVersion := g.currentVersion()
 	g.mu.RLock()
-	currentVersion := g.version
 	managedVersionsSnapshot := make(map[string]string, len(g.managedVersions))
 	for k, v := range g.managedVersions {
 		managedVersionsSnapshot[k] = v
 	}
+	configVersionsSnapshot := make(map[string]string, len(g.configVersions))
+	for k, v := range g.configVersions {
+		configVersionsSnapshot[k] = v
+	}
+	reported := make([]heartbeatComponent, 0, len(g.reportedVersions))
+	for slug, version := range g.reportedVersions {
+		reported = append(reported, heartbeatComponent{Slug: slug, Version: version, ReportOnly: true})
+	}
 	g.mu.RUnlock()
+	sort.Slice(reported, func(i, j int) bool { return reported[i].Slug < reported[j].Slug })
 
 	components := []heartbeatComponent{
 		{
@@ -141,10 +380,17 @@ func (g *Guard) sendHeartbeat(parent context.Context) error {
 	}
 	for _, mc := range g.cfg.ManagedComponents {
 		components = append(components, heartbeatComponent{
-			Slug:    mc.Slug,
-			Version: managedVersionsSnapshot[mc.Slug],
+			Slug:          mc.Slug,
+			Version:       managedVersionsSnapshot[mc.Slug],
+			ConfigVersion: configVersionsSnapshot[mc.Slug],
 		})
 	}
+	components = append(components, reported...)
+	probes := g.probeComponents(parent)
+	now := time.Now()
+	for i := range components {
+		components[i].Health = g.componentHealthReport(components[i].Slug, probes, now)
+	}
 
 	binaryHash, err := GetBinaryHash()
 	if err != nil {
@@ -155,24 +401,32 @@ func (g *Guard) sendHeartbeat(parent context.Context) error {
 		return err
 	}
 	reqBody := heartbeatRequestBody{
-		LicenseKey:    g.cfg.LicenseKey,
-		MachineID:     g.fingerprint.MachineID(),
-		ProjectSlug:   g.cfg.ProjectSlug,
-		ComponentSlug: g.cfg.ComponentSlug,
-		Components:    components,
-		Nonce:         nonce,
-		Timestamp:     nowUnix(),
-		BinaryHash:    binaryHash,
+		LicenseKey:     g.bodyLicenseKey(),
+		MachineID:      g.fingerprint.MachineID(),
+		ProjectSlug:    g.cfg.ProjectSlug,
+		ComponentSlug:  g.cfg.ComponentSlug,
+		Components:     components,
+		Nonce:          nonce,
+		Timestamp:      nowUnix(),
+		BinaryHash:     binaryHash,
+		UpdateStats:    g.pendingUpdateStatsSnapshot(),
+		CommandResults: g.pendingCommandResultsSnapshot(),
+		Plugins:        g.pluginReport(probes),
+		Errors:         g.errorReports.snapshot(),
+		Drift:          g.currentDrift(),
+		Usage:          g.usage.snapshot(),
+		Seats:          g.heldSeatIDs(),
 	}
 
 	var resp heartbeatResponse
-	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
+	ctx, cancel := withTimeout(parent, g.cfg.Timeouts.Heartbeat)
 	defer cancel()
 	reqBodyJSON, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 	raw, err := g.postJSON(ctx, "/api/v1/heartbeat", reqBodyJSON)
+	g.recordResponse("heartbeat", raw, err)
 	if err != nil {
 		if ctx.Err() != nil {
 			return ctx.Err()
@@ -186,15 +440,47 @@ func (g *Guard) sendHeartbeat(parent context.Context) error {
 	if err := json.Unmarshal(raw, &resp); err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
+	g.reportUnknownFields("heartbeat", raw, resp)
 
 	if err := g.verifyHeartbeatResponse(resp, nonce); err != nil {
 		return err
 	}
+	if err := checkResponseFreshness(resp.ServerTime, time.Unix(reqBody.Timestamp, 0)); err != nil {
+		return err
+	}
+	// Only a signed, fresh reply to this request proves the server took the
+	// reports; anything else keeps them queued for the next heartbeat.
+	g.dropReportedUpdateStats(len(reqBody.UpdateStats))
+	g.dropReportedCommandResults(len(reqBody.CommandResults))
+	g.errorReports.drop(reqBody.Errors)
+	if len(reqBody.Usage) > 0 {
+		g.usage.drop(reqBody.Usage)
+		g.saveUsage()
+	}
+	if err := g.checkHeartbeatStatus(resp.Status); err != nil {
+		return err
+	}
+	g.applyAppealStatus(resp.Appeal)
+	g.applyFeedbackReplies(resp.FeedbackReplies)
+	g.applyAnnouncements(resp.Announcements, false)
 	if resp.Status == "kill" {
+		if resp.KillAfter != 0 {
+			delay, err := killDelay(resp.KillAfter)
+			if err != nil {
+				return err
+			}
+			g.scheduleKill(time.Now().Add(delay), killReason(resp))
+			return nil
+		}
+		g.mu.Lock()
+		g.killReason = killReason(resp)
+		g.mu.Unlock()
 		g.sm.OnKill()
 		_ = g.persistBan()
+		g.audit(AuditKillExecuted, g.cfg.ComponentSlug, AuditOutcomeSuccess, killReason(resp))
 		return ErrBanned
 	}
+	g.cancelScheduledKill()
 
 	leaseValue, err := parseAndVerifyLease(resp.Lease, resp.LeaseSignature, g.verificationKeys(), g.fingerprint.MachineID(), time.Now(), g.currentWatermark())
 	if err != nil {
@@ -203,12 +489,36 @@ func (g *Guard) sendHeartbeat(parent context.Context) error {
 	if err := g.acceptLease(leaseValue, resp.LeaseSignature, false); err != nil {
 		return err
 	}
+	if g.sm.Current() == StateBanned {
+		g.sm.OnVerifySuccess()
+		g.logger.Info("ban lifted by server", "appeal_status", string(g.AppealStatus()))
+	}
+
+	g.adoptHeartbeatInterval(resp.NextInterval)
+	g.adoptFeatureFlags(resp.Flags)
+	g.applyRemoteConfig(resp.RemoteConfig)
+	g.applyComponentConfigs(resp.Configs)
 
+	var available []updateInfo
 	for _, u := range resp.Updates {
-		if g.cfg.OTA.Enabled && u.UpdateAvailable {
-			g.handleUpdateNotification(u)
+		if u.UpdateAvailable {
+			g.recordAvailableVersion(u.Component, u.Latest)
+			g.recordOfferedUpdate(u)
+			available = append(available, u)
 		}
 	}
+	if g.cfg.OTA.Enabled {
+		single, groups := groupReleaseUpdates(available)
+		for _, u := range single {
+			g.handleUpdateNotification(parent, u)
+		}
+		for group, members := range groups {
+			g.handleReleaseGroup(parent, group, members)
+		}
+	}
+	if len(resp.Commands) > 0 {
+		g.goBackground(func() { g.dispatchCommands(parent, resp.Commands) })
+	}
 
 	return nil
 }
@@ -222,12 +532,20 @@ func (g *Guard) verifyHeartbeatResponse(resp heartbeatResponse, requestNonce str
 	}
 
 	payload := heartbeatSignaturePayload{
-		Lease:          normalizedJSONObject(resp.Lease),
-		LeaseSignature: resp.LeaseSignature,
-		Nonce:          resp.Nonce,
-		ServerTime:     resp.ServerTime,
-		Status:         resp.Status,
-		UpdatesDigest:  updatesDigest(resp.Updates),
+		Lease:               normalizedJSONObject(resp.Lease),
+		LeaseSignature:      resp.LeaseSignature,
+		Nonce:               resp.Nonce,
+		ServerTime:          resp.ServerTime,
+		Status:              resp.Status,
+		UpdatesDigest:       updatesDigest(resp.Updates),
+		CommandsDigest:      commandsDigest(resp.Commands),
+		FlagsDigest:         flagsDigest(resp.Flags),
+		KillAfter:           resp.KillAfter,
+		Reason:              resp.Reason,
+		Message:             resp.Message,
+		NextInterval:        resp.NextInterval,
+		AppealDigest:        appealDigest(resp.Appeal),
+		AnnouncementsDigest: announcementsDigest(resp.Announcements),
 	}
 	raw, err := json.Marshal(payload)
 	if err != nil {
@@ -238,6 +556,7 @@ func (g *Guard) verifyHeartbeatResponse(resp heartbeatResponse, requestNonce str
 		return ErrHeartbeatInvalid
 	}
 	if err := verifyEd25519Digest(canonical, resp.ResponseSignature, g.verificationKeys()); err != nil {
+		g.reportError(errorKindSignatureMismatch, g.cfg.ComponentSlug, fmt.Errorf("heartbeat response: %w", err))
 		return ErrHeartbeatInvalid
 	}
 	return nil
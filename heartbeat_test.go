@@ -0,0 +1,519 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// signHeartbeatResponse fills in nonce, server time and response signature the
+// way the server does for a heartbeat reply.
+func signHeartbeatResponse(t *testing.T, privKey ed25519.PrivateKey, resp heartbeatResponse, nonce string) heartbeatResponse {
+	t.Helper()
+	resp.Nonce = nonce
+	if resp.ServerTime == "" {
+		resp.ServerTime = time.Now().UTC().Format(time.RFC3339)
+	}
+	raw, err := json.Marshal(heartbeatSignaturePayload{
+		Lease:               normalizedJSONObject(resp.Lease),
+		LeaseSignature:      resp.LeaseSignature,
+		Nonce:               resp.Nonce,
+		ServerTime:          resp.ServerTime,
+		Status:              resp.Status,
+		UpdatesDigest:       updatesDigest(resp.Updates),
+		CommandsDigest:      commandsDigest(resp.Commands),
+		FlagsDigest:         flagsDigest(resp.Flags),
+		KillAfter:           resp.KillAfter,
+		Reason:              resp.Reason,
+		Message:             resp.Message,
+		NextInterval:        resp.NextInterval,
+		AppealDigest:        appealDigest(resp.Appeal),
+		AnnouncementsDigest: announcementsDigest(resp.Announcements),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(canonical)
+	resp.ResponseSignature = base64.StdEncoding.EncodeToString(ed25519.Sign(privKey, digest[:]))
+	return resp
+}
+
+// signVerifyResponse binds a verify reply to the request nonce the way the
+// server does.
+func signVerifyResponse(t *testing.T, privKey ed25519.PrivateKey, resp verifyResponse, nonce string) verifyResponse {
+	t.Helper()
+	resp.Nonce = nonce
+	if resp.ServerTime == "" {
+		resp.ServerTime = time.Now().UTC().Format(time.RFC3339)
+	}
+	raw, err := json.Marshal(verifySignaturePayload{
+		Lease:          normalizedJSONObject(resp.Lease),
+		LeaseSignature: resp.LeaseSignature,
+		Nonce:          resp.Nonce,
+		ServerTime:     resp.ServerTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(canonical)
+	resp.ResponseSignature = base64.StdEncoding.EncodeToString(ed25519.Sign(privKey, digest[:]))
+	return resp
+}
+
+// newHeartbeatTestServer answers every heartbeat with respond(nonce), signed.
+func newHeartbeatTestServer(t *testing.T, privKey ed25519.PrivateKey, respond func() heartbeatResponse) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, respond(), body.Nonce))
+	}))
+}
+
+// newTamperedHeartbeatServer signs each reply and then lets tamper alter it,
+// the way a man in the middle would.
+func newTamperedHeartbeatServer(t *testing.T, privKey ed25519.PrivateKey, respond func() heartbeatResponse, tamper func(*heartbeatResponse)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := signHeartbeatResponse(t, privKey, respond(), body.Nonce)
+		tamper(&resp)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func newActiveHeartbeatGuard(t *testing.T) (*Guard, ed25519.PrivateKey, []byte, string) {
+	t.Helper()
+	guard, privKey := newTestGuard(t, nil)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+	if err := guard.acceptLease(mustParseLease(t, leaseJSON), sig, false); err != nil {
+		t.Fatal(err)
+	}
+	guard.sm.OnVerifySuccess()
+	return guard, privKey, leaseJSON, sig
+}
+
+func TestHeartbeat_DelayedKillKeepsCheckUntilDeadline(t *testing.T) {
+	guard, privKey, _, _ := newActiveHeartbeatGuard(t)
+
+	var scheduled time.Time
+	var scheduledReason string
+	guard.cfg.OnKillScheduled = func(deadline time.Time, reason string) {
+		scheduled = deadline
+		scheduledReason = reason
+	}
+
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{Status: "kill", KillAfter: 3600, Reason: "license_revoked"}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("delayed kill heartbeat should not fail: %v", err)
+	}
+	if scheduled.IsZero() || scheduledReason != "license_revoked" {
+		t.Fatalf("expected OnKillScheduled callback, got deadline=%v reason=%q", scheduled, scheduledReason)
+	}
+	if err := guard.Check(); err != nil {
+		t.Fatalf("Check should pass before the deadline, got %v", err)
+	}
+	status := guard.Status()
+	if status.State != StateActive || status.KillIn <= 0 || !status.KillDeadline.Equal(scheduled) {
+		t.Fatalf("unexpected status before deadline: %#v", status)
+	}
+
+	guard.enforceScheduledKill(scheduled.Add(time.Second))
+	if err := guard.Check(); err != ErrBanned {
+		t.Fatalf("expected ErrBanned after deadline, got %v", err)
+	}
+	if state := guard.currentLeaseState(); state == nil || !state.BanFlag {
+		t.Fatalf("expected ban to be persisted, got %#v", state)
+	}
+}
+
+func TestHeartbeat_TamperedKillAfterFailsVerification(t *testing.T) {
+	for name, tamper := range map[string]func(*heartbeatResponse){
+		"kill_after": func(resp *heartbeatResponse) { resp.KillAfter = 1 },
+		"reason":     func(resp *heartbeatResponse) { resp.Reason = "forged" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			guard, privKey, _, _ := newActiveHeartbeatGuard(t)
+			scheduled := false
+			guard.cfg.OnKillScheduled = func(time.Time, string) { scheduled = true }
+
+			server := newTamperedHeartbeatServer(t, privKey, func() heartbeatResponse {
+				return heartbeatResponse{Status: "kill", KillAfter: 3600, Reason: "license_revoked"}
+			}, tamper)
+			defer server.Close()
+			guard.cfg.ServerURL = server.URL
+			guard.httpClient = server.Client()
+
+			if err := guard.sendHeartbeat(context.Background()); !errors.Is(err, ErrHeartbeatInvalid) {
+				t.Fatalf("expected ErrHeartbeatInvalid, got %v", err)
+			}
+			if scheduled || !guard.Status().KillDeadline.IsZero() {
+				t.Fatal("a tampered kill must not be scheduled")
+			}
+		})
+	}
+}
+
+func TestKillDelay(t *testing.T) {
+	if d, err := killDelay(60); err != nil || d != time.Minute {
+		t.Fatalf("killDelay(60) = %v, %v", d, err)
+	}
+	if d, err := killDelay(int64(30 * 24 * time.Hour / time.Second)); err != nil || d != maxKillDelay {
+		t.Fatalf("expected a long delay to be clamped to %v, got %v, %v", maxKillDelay, d, err)
+	}
+	for _, seconds := range []int64{-1, 1e10, math.MaxInt64} {
+		if _, err := killDelay(seconds); !errors.Is(err, ErrHeartbeatInvalid) {
+			t.Fatalf("killDelay(%d) should be rejected, got %v", seconds, err)
+		}
+	}
+}
+
+func TestHeartbeat_OKResponseCancelsScheduledKill(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.scheduleKill(time.Now().Add(time.Hour), "maintenance")
+
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if status := guard.Status(); !status.KillDeadline.IsZero() {
+		t.Fatalf("expected scheduled kill to be canceled, got %#v", status)
+	}
+	if state := guard.currentLeaseState(); state == nil || state.KillDeadline != "" {
+		t.Fatalf("expected persisted kill deadline to be cleared, got %#v", state)
+	}
+}
+
+func TestScheduledKillPastDeadlineRestoresAsBanned(t *testing.T) {
+	sm := newStateMachine()
+	sm.restore(&persistedState{
+		KillDeadline: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+	})
+	if sm.Current() != StateBanned {
+		t.Fatalf("expected banned after restart past kill deadline, got %v", sm.Current())
+	}
+}
+
+func TestHeartbeat_AdoptsServerIntervalWithinClamps(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.cfg.HeartbeatInterval = time.Hour
+	guard.cfg.HeartbeatMinInterval = 5 * time.Minute
+	guard.cfg.HeartbeatMaxInterval = 6 * time.Hour
+
+	var next int64
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig, NextInterval: next}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	for _, tt := range []struct {
+		next int64
+		want time.Duration
+	}{
+		{600, 10 * time.Minute},
+		{10, 5 * time.Minute},
+		{86400, 6 * time.Hour},
+		{0, time.Hour},
+	} {
+		next = tt.next
+		if err := guard.sendHeartbeat(context.Background()); err != nil {
+			t.Fatalf("heartbeat with next_interval_s=%d: %v", tt.next, err)
+		}
+		if got := guard.currentHeartbeatInterval(); got != tt.want {
+			t.Fatalf("next_interval_s=%d: interval = %v, want %v", tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestHeartbeat_TamperedIntervalFailsVerification(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.cfg.HeartbeatInterval = time.Hour
+
+	server := newTamperedHeartbeatServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig, NextInterval: 600}
+	}, func(resp *heartbeatResponse) { resp.NextInterval = 86400 })
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); !errors.Is(err, ErrHeartbeatInvalid) {
+		t.Fatalf("expected ErrHeartbeatInvalid, got %v", err)
+	}
+	if got := guard.currentHeartbeatInterval(); got != time.Hour {
+		t.Fatalf("a tampered next_interval_s must not be adopted, interval = %v", got)
+	}
+}
+
+func TestAdvanceGrace_WarnsPerIntervalAndExpires(t *testing.T) {
+	guard, _, _, _ := newActiveHeartbeatGuard(t)
+	guard.cfg.GracePolicy = GracePolicy{MaxOfflineDuration: 10 * time.Hour, WarningInterval: 4 * time.Hour}
+
+	var warnings []time.Duration
+	guard.cfg.OnGraceWarning = func(remaining time.Duration) {
+		warnings = append(warnings, remaining)
+	}
+
+	guard.sm.OnHeartbeatFail()
+	var grace graceTracker
+	start := time.Now()
+	for _, offset := range []time.Duration{0, time.Hour, 3 * time.Hour, 4 * time.Hour, 9 * time.Hour} {
+		if guard.advanceGrace(&grace, start.Add(offset), ErrNetworkError) {
+			t.Fatalf("grace should not expire at +%s", offset)
+		}
+	}
+	want := []time.Duration{10 * time.Hour, 6 * time.Hour, time.Hour}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %v, want %v", warnings, want)
+	}
+	for i := range want {
+		if warnings[i] != want[i] {
+			t.Fatalf("warnings = %v, want %v", warnings, want)
+		}
+	}
+
+	if !guard.advanceGrace(&grace, start.Add(11*time.Hour), ErrNetworkError) {
+		t.Fatal("expected grace to expire after MaxOfflineDuration")
+	}
+}
+
+func TestAdvanceGrace_PerCategoryBudgets(t *testing.T) {
+	guard, _, _, _ := newActiveHeartbeatGuard(t)
+	guard.cfg.GracePolicy = GracePolicy{
+		MaxOfflineDuration:     72 * time.Hour,
+		WarningInterval:        time.Hour,
+		ServerErrorMaxOffline:  7 * 24 * time.Hour,
+		LicenseErrorMaxOffline: 2 * time.Hour,
+		LicenseEscalateAfter:   2,
+	}
+	guard.sm.OnHeartbeatFail()
+	start := time.Now()
+	serverDown := &APIError{StatusCode: 503, Code: "internal_error", Cause: ErrInvalidServerResponse}
+	revoked := &APIError{StatusCode: 403, Code: "license_revoked", Cause: ErrLicenseInvalid}
+
+	var outage graceTracker
+	if guard.advanceGrace(&outage, start, serverDown) || guard.advanceGrace(&outage, start.Add(100*time.Hour), serverDown) {
+		t.Fatal("server outage must not lock within its 7 day budget")
+	}
+
+	var revocation graceTracker
+	if guard.advanceGrace(&revocation, start, revoked) {
+		t.Fatal("a single license failure must not escalate")
+	}
+	if !guard.advanceGrace(&revocation, start.Add(3*time.Hour), revoked) {
+		t.Fatal("repeated license failures should lock on the 2h license budget")
+	}
+
+	if got := classifyFailure(fmt.Errorf("%w: dial tcp: no route to host", ErrNetworkError)); got != FailureNetwork {
+		t.Fatalf("expected network category, got %v", got)
+	}
+}
+
+func TestAdvanceGrace_LicenseFailuresBelowThresholdKeepBudget(t *testing.T) {
+	guard, _, _, _ := newActiveHeartbeatGuard(t)
+	guard.cfg.GracePolicy = GracePolicy{
+		MaxOfflineDuration:     72 * time.Hour,
+		WarningInterval:        time.Hour,
+		NetworkMaxOffline:      7 * 24 * time.Hour,
+		LicenseErrorMaxOffline: 2 * time.Hour,
+		LicenseEscalateAfter:   2,
+	}
+	guard.sm.OnHeartbeatFail()
+	start := time.Now()
+	revoked := &APIError{StatusCode: 403, Code: "license_revoked", Cause: ErrLicenseInvalid}
+
+	var grace graceTracker
+	if guard.advanceGrace(&grace, start, ErrNetworkError) || guard.advanceGrace(&grace, start.Add(100*time.Hour), revoked) {
+		t.Fatal("a single license failure must not cut the network budget")
+	}
+	if guard.advanceGrace(&grace, start.Add(101*time.Hour), ErrNetworkError) {
+		t.Fatal("network failure locked within its budget")
+	}
+	if !guard.advanceGrace(&grace, start.Add(102*time.Hour), revoked) {
+		t.Fatal("license failures separated by network failures should still escalate")
+	}
+
+	for _, status := range []int{http.StatusRequestTimeout, http.StatusTooManyRequests} {
+		if got := classifyFailure(&APIError{StatusCode: status, Code: "rate_limited"}); got != FailureNetwork {
+			t.Fatalf("status %d classified as %v, want network", status, got)
+		}
+	}
+}
+
+func TestAwaitLockRecovery_ReturnsToActiveOnceVerifySucceeds(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	guard.sm.set(StateLocked)
+	if err := guard.persistLock(); err != nil {
+		t.Fatal(err)
+	}
+	guard.cfg.GracePolicy.RecoveryInterval = time.Millisecond
+	unlocked := 0
+	guard.cfg.OnUnlocked = func() { unlocked++ }
+
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var body licenseVerifyRequestBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_ = json.NewEncoder(w).Encode(signVerifyResponse(t, privKey, verifyResponse{Lease: leaseJSON, LeaseSignature: sig}, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if !guard.awaitLockRecovery(context.Background()) {
+		t.Fatal("expected recovery to resume the heartbeat loop")
+	}
+	if guard.State() != StateActive || attempts != 3 || unlocked != 1 {
+		t.Fatalf("state=%v attempts=%d unlocked=%d", guard.State(), attempts, unlocked)
+	}
+	if state := guard.currentLeaseState(); state == nil || state.LockFlag {
+		t.Fatal("persisted lock flag should be cleared after recovery")
+	}
+}
+
+func TestAwaitLockRecovery_DisabledByNegativeInterval(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.sm.set(StateLocked)
+	guard.cfg.GracePolicy.RecoveryInterval = -1
+
+	if guard.awaitLockRecovery(context.Background()) {
+		t.Fatal("recovery should be disabled")
+	}
+	if guard.State() != StateLocked {
+		t.Fatalf("state = %v, want LOCKED", guard.State())
+	}
+}
+
+func TestHeartbeat_IncludesReportOnlyComponents(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.cfg.ManagedComponents = []ManagedComponent{{Slug: "frontend"}}
+	guard.ReportComponentVersion("lua-json", "1.4.0")
+	guard.ReportComponentVersion("lua-http", "0.9.2")
+	guard.ReportComponentVersion("lua-old", "1.0.0")
+	guard.ReportComponentVersion("lua-old", "")
+	guard.ReportComponentVersion("frontend", "9.9.9")
+	guard.ReportComponentVersion(guard.cfg.ComponentSlug, "9.9.9")
+
+	var components []heartbeatComponent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+			return
+		}
+		components = body.Components
+		resp := heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, resp, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if len(components) != 4 {
+		t.Fatalf("expected own, managed and two report-only components, got %#v", components)
+	}
+	if components[1].Slug != "frontend" || components[1].Version == "9.9.9" || components[1].ReportOnly {
+		t.Fatalf("managed component must not be overridden by ReportComponentVersion: %#v", components[1])
+	}
+	want := []heartbeatComponent{
+		{Slug: "lua-http", Version: "0.9.2", ReportOnly: true},
+		{Slug: "lua-json", Version: "1.4.0", ReportOnly: true},
+	}
+	for i, c := range want {
+		if components[2+i] != c {
+			t.Fatalf("component %d = %#v, want %#v", 2+i, components[2+i], c)
+		}
+	}
+}
+
+func TestHeartbeat_KeepsQueuedReportsUntilVerifiedReply(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.usage.record("api_calls", 5, time.Now())
+	guard.reportError("panic", guard.cfg.ComponentSlug, fmt.Errorf("boom"))
+	guard.pendingUpdateStats = append(guard.pendingUpdateStats, UpdateStats{Component: "backend", NewVersion: "2.0.0"})
+
+	reply := "garbage"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch reply {
+		case "garbage":
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		case "replayed":
+			_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}, "old-nonce"))
+		default:
+			_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}, body.Nonce))
+		}
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	queued := func() (int, int, int) {
+		return len(guard.usage.snapshot()), len(guard.errorReports.snapshot()), len(guard.pendingUpdateStatsSnapshot())
+	}
+	for _, reply = range []string{"garbage", "replayed"} {
+		if err := guard.sendHeartbeat(context.Background()); err == nil {
+			t.Fatalf("%s reply accepted", reply)
+		}
+		if usage, errs, stats := queued(); usage != 1 || errs != 1 || stats != 1 {
+			t.Fatalf("after a %s reply: usage=%d errors=%d stats=%d, want all kept", reply, usage, errs, stats)
+		}
+	}
+
+	reply = "signed"
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if usage, errs, stats := queued(); usage != 0 || errs != 0 || stats != 0 {
+		t.Fatalf("after a signed reply: usage=%d errors=%d stats=%d, want all dropped", usage, errs, stats)
+	}
+}
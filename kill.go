@@ -0,0 +1,150 @@
+package sdk
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxKillDelay caps how far ahead a server-requested kill can be scheduled.
+const maxKillDelay = 7 * 24 * time.Hour
+
+// scheduleKill records a server-requested delayed kill. Check keeps
+// succeeding until the deadline so in-flight work can be saved; the earliest
+// deadline received always wins.
+func (g *Guard) scheduleKill(deadline time.Time, reason string) {
+	g.mu.Lock()
+	if current := g.scheduledKillDeadline(); !current.IsZero() && !deadline.Before(current) {
+		g.mu.Unlock()
+		return
+	}
+	g.killDeadline.Store(&deadline)
+	g.killReason = reason
+	g.armKillTimerLocked(deadline)
+	g.mu.Unlock()
+
+	if err := g.persistKillSchedule(deadline, reason); err != nil {
+		g.logger.Error("failed to persist scheduled kill", "error", err)
+	}
+	g.logger.Warn("server scheduled kill", "deadline", deadline.UTC().Format(time.RFC3339), "reason", reason)
+	g.audit(AuditKillScheduled, g.cfg.ComponentSlug, AuditOutcomeSuccess, fmt.Sprintf("deadline %s: %s", deadline.UTC().Format(time.RFC3339), reason))
+	if g.cfg.OnKillScheduled != nil {
+		g.cfg.OnKillScheduled(deadline, reason)
+	}
+}
+
+// cancelScheduledKill clears a pending kill once the server stops asking for it.
+func (g *Guard) cancelScheduledKill() {
+	g.mu.Lock()
+	if g.killDeadline.Load() == nil {
+		g.mu.Unlock()
+		return
+	}
+	g.killDeadline.Store(nil)
+	g.killReason = ""
+	if g.killTimer != nil {
+		g.killTimer.Stop()
+		g.killTimer = nil
+	}
+	g.mu.Unlock()
+
+	if err := g.persistKillSchedule(time.Time{}, ""); err != nil {
+		g.logger.Error("failed to clear scheduled kill", "error", err)
+	}
+	g.logger.Info("scheduled kill canceled by server")
+}
+
+func (g *Guard) restoreScheduledKill(state *persistedState) {
+	deadline, err := parseRFC3339(state.KillDeadline)
+	if state.KillDeadline == "" || err != nil {
+		return
+	}
+	g.mu.Lock()
+	g.killDeadline.Store(&deadline)
+	g.killReason = state.KillReason
+	g.armKillTimerLocked(deadline)
+	g.mu.Unlock()
+}
+
+func (g *Guard) armKillTimerLocked(deadline time.Time) {
+	if g.killTimer != nil {
+		g.killTimer.Stop()
+	}
+	g.killTimer = time.AfterFunc(time.Until(deadline), func() {
+		g.enforceScheduledKill(time.Now())
+	})
+}
+
+// enforceScheduledKill bans the guard once a scheduled kill deadline passes.
+// Check calls it on every request, so the common no-kill path takes no lock.
+func (g *Guard) enforceScheduledKill(now time.Time) {
+	deadline := g.scheduledKillDeadline()
+	if deadline.IsZero() || now.Before(deadline) || g.sm.Current() == StateBanned {
+		return
+	}
+	g.sm.OnKill()
+	if err := g.persistBan(); err != nil {
+		g.logger.Error("failed to persist ban", "error", err)
+	}
+	g.logger.Warn("scheduled kill executed", "reason", g.killReasonSnapshot())
+	g.audit(AuditKillExecuted, g.cfg.ComponentSlug, AuditOutcomeSuccess, g.killReasonSnapshot())
+}
+
+// scheduledKillDeadline returns the pending kill deadline, or the zero time.
+// Writers still hold g.mu so the deadline, reason and timer change together.
+func (g *Guard) scheduledKillDeadline() time.Time {
+	if deadline := g.killDeadline.Load(); deadline != nil {
+		return *deadline
+	}
+	return time.Time{}
+}
+
+func (g *Guard) killReasonSnapshot() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.killReason
+}
+
+func (g *Guard) persistKillSchedule(deadline time.Time, reason string) error {
+	if g.store == nil {
+		return nil
+	}
+	state := g.currentLeaseState()
+	if state == nil {
+		state = &persistedState{}
+	}
+	state.KillDeadline = ""
+	if !deadline.IsZero() {
+		state.KillDeadline = deadline.UTC().Format(time.RFC3339)
+	}
+	state.KillReason = reason
+	return g.store.Save(state)
+}
+
+func killDeadlinePassed(value string, now time.Time) bool {
+	if value == "" {
+		return false
+	}
+	deadline, err := parseRFC3339(value)
+	if err != nil {
+		return false
+	}
+	return !now.Before(deadline)
+}
+
+func killReason(resp heartbeatResponse) string {
+	if resp.Reason != "" {
+		return resp.Reason
+	}
+	return resp.Message
+}
+
+// killDelay converts a signed kill_after value into a delay. Negative values
+// and values that overflow a time.Duration are rejected; anything beyond
+// maxKillDelay is clamped to it.
+func killDelay(seconds int64) (time.Duration, error) {
+	if seconds < 0 || seconds > math.MaxInt64/int64(time.Second) {
+		return 0, ErrHeartbeatInvalid
+	}
+	return min(time.Duration(seconds)*time.Second, maxKillDelay), nil
+}
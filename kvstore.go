@@ -0,0 +1,265 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	kvStoreFile            = "store.log"
+	kvRecordHeaderSize     = 8
+	kvMaxRecordBytes       = 16 * 1024 * 1024
+	kvCompactMinGarbage    = 256
+	kvCompactGarbageFactor = 2
+)
+
+// kvStore is the durable key-value layer shared by SDK subsystems that need
+// to keep small records across restarts (queues, history, seen-state), so
+// each one does not grow its own ad-hoc file format.
+type kvStore interface {
+	Get(bucket, key string) ([]byte, bool, error)
+	Put(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+	// List returns the bucket's entries ordered by key.
+	List(bucket string) ([]kvEntry, error)
+}
+
+type kvEntry struct {
+	Key   string
+	Value []byte
+}
+
+type kvRecord struct {
+	Op     string `json:"op"`
+	Bucket string `json:"b"`
+	Key    string `json:"k"`
+	Value  []byte `json:"v,omitempty"`
+}
+
+// logKVStore is an append-only record log. Every record carries a length and
+// CRC32 header; on open, replay stops at the first torn or corrupt record and
+// the file is truncated there, so a crash mid-write loses at most that write.
+// The log is rewritten from the live set once superseded records dominate.
+type logKVStore struct {
+	mu      sync.Mutex
+	path    string
+	data    map[string]map[string][]byte
+	live    int
+	garbage int
+}
+
+func openLogKVStore(path string) (*logKVStore, error) {
+	s := &logKVStore{path: path, data: make(map[string]map[string][]byte)}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *logKVStore) replay() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var goodOffset int64
+	for {
+		record, n, err := readKVRecord(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Torn tail or corruption: keep everything before it.
+			return os.Truncate(s.path, goodOffset)
+		}
+		goodOffset += n
+		s.apply(record)
+	}
+}
+
+func readKVRecord(r io.Reader) (kvRecord, int64, error) {
+	var header [kvRecordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			return kvRecord{}, 0, io.EOF
+		}
+		return kvRecord{}, 0, io.ErrUnexpectedEOF
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	checksum := binary.BigEndian.Uint32(header[4:])
+	if size == 0 || size > kvMaxRecordBytes {
+		return kvRecord{}, 0, fmt.Errorf("invalid record size %d", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return kvRecord{}, 0, io.ErrUnexpectedEOF
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return kvRecord{}, 0, errors.New("record checksum mismatch")
+	}
+	var record kvRecord
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return kvRecord{}, 0, err
+	}
+	return record, int64(kvRecordHeaderSize) + int64(size), nil
+}
+
+func encodeKVRecord(buf *bytes.Buffer, record kvRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	var header [kvRecordHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+	buf.Write(header[:])
+	buf.Write(payload)
+	return nil
+}
+
+func (s *logKVStore) apply(record kvRecord) {
+	bucket := s.data[record.Bucket]
+	_, existed := bucket[record.Key]
+	switch record.Op {
+	case "put":
+		if bucket == nil {
+			bucket = make(map[string][]byte)
+			s.data[record.Bucket] = bucket
+		}
+		bucket[record.Key] = record.Value
+		if existed {
+			s.garbage++
+		} else {
+			s.live++
+		}
+	case "del":
+		if existed {
+			delete(bucket, record.Key)
+			s.live--
+			s.garbage++
+		}
+		s.garbage++
+	}
+}
+
+func (s *logKVStore) Get(bucket, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.data[bucket][key]
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), value...), true, nil
+}
+
+func (s *logKVStore) Put(bucket, key string, value []byte) error {
+	return s.write(kvRecord{Op: "put", Bucket: bucket, Key: key, Value: append([]byte(nil), value...)})
+}
+
+func (s *logKVStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	_, ok := s.data[bucket][key]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.write(kvRecord{Op: "del", Bucket: bucket, Key: key})
+}
+
+func (s *logKVStore) List(bucket string) ([]kvEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]kvEntry, 0, len(s.data[bucket]))
+	for key, value := range s.data[bucket] {
+		entries = append(entries, kvEntry{Key: key, Value: append([]byte(nil), value...)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+func (s *logKVStore) write(record kvRecord) error {
+	var buf bytes.Buffer
+	if err := encodeKVRecord(&buf, record); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	s.apply(record)
+
+	if s.garbage >= kvCompactMinGarbage && s.garbage > s.live*kvCompactGarbageFactor {
+		return s.compactLocked()
+	}
+	return nil
+}
+
+// compactLocked rewrites the log with one put per live key.
+func (s *logKVStore) compactLocked() error {
+	buckets := make([]string, 0, len(s.data))
+	for bucket := range s.data {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+
+	var buf bytes.Buffer
+	for _, bucket := range buckets {
+		for key, value := range s.data[bucket] {
+			if err := encodeKVRecord(&buf, kvRecord{Op: "put", Bucket: bucket, Key: key, Value: value}); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writeFileAtomic(s.path, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	s.garbage = 0
+	return nil
+}
+
+// storage returns the guard's shared key-value store, opening it on first use.
+func (g *Guard) storage() (kvStore, error) {
+	g.kvMu.Lock()
+	defer g.kvMu.Unlock()
+	if g.kv != nil {
+		return g.kv, nil
+	}
+	store, err := openLogKVStore(filepath.Join(guardCacheDir(g.cfg), kvStoreFile))
+	if err != nil {
+		return nil, fmt.Errorf("open sdk store: %w", err)
+	}
+	g.kv = store
+	return store, nil
+}
@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogKVStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), kvStoreFile)
+	store, err := openLogKVStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("queue", "b", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("queue", "a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("history", "a", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("queue", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := openLogKVStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := reopened.List("queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Key != "a" || string(entries[0].Value) != "1" {
+		t.Fatalf("unexpected queue after reopen: %#v", entries)
+	}
+	if value, ok, _ := reopened.Get("history", "a"); !ok || string(value) != "x" {
+		t.Fatalf("history entry lost: %q %v", value, ok)
+	}
+}
+
+func TestLogKVStore_RecoversFromTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), kvStoreFile)
+	store, err := openLogKVStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("b", "k1", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("b", "k2", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := openLogKVStore(path)
+	if err != nil {
+		t.Fatalf("open after torn write: %v", err)
+	}
+	if _, ok, _ := recovered.Get("b", "k1"); !ok {
+		t.Fatal("intact record lost during recovery")
+	}
+	if _, ok, _ := recovered.Get("b", "k2"); ok {
+		t.Fatal("torn record should be discarded")
+	}
+	if err := recovered.Put("b", "k3", []byte("v3")); err != nil {
+		t.Fatal(err)
+	}
+	again, err := openLogKVStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := again.Get("b", "k3"); !ok {
+		t.Fatal("write after recovery must be readable")
+	}
+}
+
+func TestLogKVStore_CompactsSupersededRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), kvStoreFile)
+	store, err := openLogKVStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= kvCompactMinGarbage; i++ {
+		if err := store.Put("counter", "value", []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 256 {
+		t.Fatalf("expected compaction to bound log size, got %d bytes", info.Size())
+	}
+	reopened, err := openLogKVStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, _ := reopened.Get("counter", "value"); !ok || string(value) != fmt.Sprint(kvCompactMinGarbage) {
+		t.Fatalf("latest value lost across compaction: %q", value)
+	}
+}
@@ -0,0 +1,334 @@
+package sdk
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	lanShareService      = "_banyanhub-artifact._tcp.local."
+	lanShareArtifactPath = "/banyanhub/artifacts/"
+	// headerLANToken carries lanShareToken on artifact requests.
+	headerLANToken = "X-BanyanHub-LAN-Token"
+)
+
+// mdnsGroup is the IPv4 mDNS multicast address.
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// LANSharingConfig lets machines on one network hand update artifacts to
+// each other, so a site behind a thin uplink downloads each release once.
+// A guard keeps the artifacts it has verified in the artifact cache (see
+// ArtifactCacheConfig), answers mDNS queries for them by SHA-256 and serves
+// them over HTTP on Port. Before downloading,
+// it asks the network for the artifact and fetches it from the first peer
+// that answers, falling back to the server. A peer's artifact passes the
+// same SHA-256 and signature checks as a download, so a peer cannot supply
+// anything the server did not sign.
+//
+// Sharing opens an HTTP port that serves licensed release artifacts without
+// going through the server. It listens only on private (RFC 1918, IPv6 ULA)
+// and IPv4 link-local addresses, never on public ones, and serves only while
+// the guard holds a valid lease. Every request must carry a token derived
+// from the license key and project, so only machines configured with the
+// same license can fetch; anyone who knows that key can, which is no more
+// than they could download from the server. Leave sharing off on hosts whose
+// private network is shared with untrusted machines.
+type LANSharingConfig struct {
+	Enabled bool
+	// Port is the TCP port artifacts are served on (default 47813).
+	Port int
+	// DiscoveryTimeout bounds the wait for a peer to answer (default 1s).
+	DiscoveryTimeout time.Duration
+}
+
+// lanClient fetches from peers directly, never through a proxy.
+var lanClient = &http.Client{Transport: &http.Transport{}}
+
+// fetchFromPeer sources req from a peer on the LAN.
+func (g *Guard) fetchFromPeer(ctx context.Context, req FetchRequest, dir string, maxBytes int64) (tmpPath, digest string, err error) {
+	algorithm, want := req.digest()
+	key := artifactKey(algorithm, want)
+	discoverCtx, cancel := context.WithTimeout(ctx, g.cfg.OTA.LANSharing.DiscoveryTimeout)
+	addr, err := g.discoverPeer(discoverCtx, key)
+	cancel()
+	if err != nil {
+		return "", "", err
+	}
+	ctx, cancel = withTimeout(ctx, g.otaDownloadTimeout())
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+lanShareArtifactPath+key, nil)
+	if err != nil {
+		return "", "", err
+	}
+	httpReq.Header.Set(headerLANToken, g.lanShareToken(key))
+	resp, err := lanClient.Do(httpReq)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("peer %s: status %d", addr, resp.StatusCode)
+	}
+	g.logger.Info("fetching artifact from LAN peer", "component", req.Component, "version", req.Version, "peer", addr)
+	return spoolArtifact(resp.Body, algorithm, dir, maxBytes)
+}
+
+// runLANSharing serves shared artifacts on the private addresses of this
+// machine and answers mDNS queries for them until ctx ends.
+func (g *Guard) runLANSharing(ctx context.Context) {
+	port := g.cfg.OTA.LANSharing.Port
+	listeners, err := listenPrivate(port)
+	if err != nil {
+		g.logger.Warn("LAN sharing disabled: cannot listen", "port", port, "error", err)
+		return
+	}
+	server := &http.Server{Handler: g.lanShareHandler(), ReadHeaderTimeout: 10 * time.Second}
+	for _, listener := range listeners {
+		go func() { _ = server.Serve(listener) }()
+	}
+	defer server.Close()
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		g.logger.Warn("LAN sharing: mDNS unavailable, serving without discovery", "error", err)
+		<-ctx.Done()
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				g.logger.Warn("LAN sharing: mDNS read failed", "error", err)
+			}
+			return
+		}
+		if reply, ok := g.answerPeerQuery(buf[:n], port); ok {
+			_, _ = conn.WriteToUDP(reply, src)
+		}
+	}
+}
+
+// lanShareHandler serves cached artifacts by key at
+// /banyanhub/artifacts/{key}.
+func (g *Guard) lanShareHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+lanShareArtifactPath+"{key}", g.serveSharedArtifact)
+	return mux
+}
+
+func (g *Guard) serveSharedArtifact(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if _, _, ok := parseArtifactKey(key); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	token := g.lanShareToken(key)
+	if token == "" || !hmac.Equal([]byte(r.Header.Get(headerLANToken)), []byte(token)) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if state := g.sm.Current(); state != StateActive && state != StateGrace {
+		http.Error(w, "not licensed", http.StatusForbidden)
+		return
+	}
+	http.ServeFile(w, r, filepath.Join(artifactCacheDir(g.cfg), key))
+}
+
+// lanShareToken authorizes fetching the artifact key from a peer: an
+// HMAC-SHA256 keyed by the license key over the project and key. It is
+// empty when the guard has no license key.
+func (g *Guard) lanShareToken(key string) string {
+	licenseKey := g.cfg.LicenseKey
+	if licenseKey == "" {
+		if state := g.currentLeaseState(); state != nil && state.Lease != nil {
+			licenseKey = state.Lease.LicenseKey
+		}
+	}
+	if licenseKey == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(licenseKey))
+	mac.Write([]byte("lan-share\n" + g.cfg.ProjectSlug + "\n" + key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isLANAddress reports whether ip is a private or IPv4 link-local address,
+// the only ones artifacts are shared on.
+func isLANAddress(ip net.IP) bool {
+	return ip.IsPrivate() || (ip.To4() != nil && ip.IsLinkLocalUnicast())
+}
+
+// listenPrivate listens on port on every LAN address of this machine.
+func listenPrivate(port int) ([]net.Listener, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	var listeners []net.Listener
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !isLANAddress(ipNet.IP) {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort(ipNet.IP.String(), strconv.Itoa(port)))
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("no private network address")
+	}
+	return listeners, nil
+}
+
+// peerQueryName is the mDNS name asked for an artifact: its hex digest in
+// 32-character labels, as a digest is longer than one DNS label allows,
+// then the algorithm.
+func peerQueryName(key string) (dnsmessage.Name, error) {
+	algorithm, digest, ok := parseArtifactKey(key)
+	if !ok {
+		return dnsmessage.Name{}, errors.New("invalid artifact digest")
+	}
+	var labels []string
+	for len(digest) > 32 {
+		labels = append(labels, digest[:32])
+		digest = digest[32:]
+	}
+	labels = append(labels, digest, algorithm)
+	return dnsmessage.NewName(strings.Join(labels, ".") + "." + lanShareService)
+}
+
+func buildPeerQuery(key string) ([]byte, error) {
+	name, err := peerQueryName(key)
+	if err != nil {
+		return nil, err
+	}
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET}},
+	}
+	return msg.Pack()
+}
+
+// answerPeerQuery replies to a query for an artifact this machine shares
+// with a TXT record naming the port it is served on.
+func (g *Guard) answerPeerQuery(query []byte, port int) ([]byte, bool) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || msg.Header.Response {
+		return nil, false
+	}
+	for _, q := range msg.Questions {
+		name := strings.ToLower(q.Name.String())
+		labels, ok := strings.CutSuffix(name, "."+lanShareService)
+		if q.Type != dnsmessage.TypeTXT || !ok {
+			continue
+		}
+		dot := strings.LastIndex(labels, ".")
+		if dot < 0 {
+			continue
+		}
+		key := artifactKey(labels[dot+1:], strings.ReplaceAll(labels[:dot], ".", ""))
+		if _, _, ok := parseArtifactKey(key); !ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(artifactCacheDir(g.cfg), key)); err != nil {
+			continue
+		}
+		reply := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: msg.Header.ID, Response: true, Authoritative: true},
+			Questions: []dnsmessage.Question{q},
+			Answers: []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 10},
+				Body:   &dnsmessage.TXTResource{TXT: []string{"port=" + strconv.Itoa(port)}},
+			}},
+		}
+		packed, err := reply.Pack()
+		return packed, err == nil
+	}
+	return nil, false
+}
+
+// parsePeerAnswer returns the port from a peer's answer for key.
+func parsePeerAnswer(reply []byte, key string) (int, bool) {
+	want, err := peerQueryName(key)
+	if err != nil {
+		return 0, false
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(reply); err != nil || !msg.Header.Response {
+		return 0, false
+	}
+	for _, answer := range msg.Answers {
+		txt, ok := answer.Body.(*dnsmessage.TXTResource)
+		if !ok || !strings.EqualFold(answer.Header.Name.String(), want.String()) {
+			continue
+		}
+		for _, field := range txt.TXT {
+			if value, ok := strings.CutPrefix(field, "port="); ok {
+				if port, err := strconv.Atoi(value); err == nil && port > 0 && port < 65536 {
+					return port, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// discoverPeer multicasts a query for an artifact and returns the address of
+// the first peer that answers. The query goes out from an ephemeral port,
+// so peers answer it directly (RFC 6762 one-shot query).
+func (g *Guard) discoverPeer(ctx context.Context, key string) (string, error) {
+	if g.lanDiscover != nil {
+		return g.lanDiscover(ctx, key)
+	}
+	query, err := buildPeerQuery(key)
+	if err != nil {
+		return "", err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.WriteToUDP(query, mdnsGroup); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return "", fmt.Errorf("no LAN peer has %s: %w", key, err)
+		}
+		if !isLANAddress(src.IP) {
+			continue
+		}
+		if port, ok := parsePeerAnswer(buf[:n], key); ok {
+			return net.JoinHostPort(src.IP.String(), strconv.Itoa(port)), nil
+		}
+	}
+}
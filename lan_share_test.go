@@ -0,0 +1,202 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLANTestGuard(t *testing.T, serverURL string, pubKey ed25519.PublicKey) *Guard {
+	t.Helper()
+	g := newLifecycleTestGuard(t, serverURL, pubKey, "1.0.0")
+	g.cfg.CacheDir = t.TempDir()
+	g.cfg.OTA.LANSharing = LANSharingConfig{Enabled: true, Port: 47813, DiscoveryTimeout: time.Second}
+	g.cfg.OTA.ArtifactCache.MaxBytes = 1 << 20
+	g.sm = newStateMachine()
+	return g
+}
+
+func TestLANSharing_FetchesVerifiedArtifactFromPeer(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	artifact := []byte("release 2.0.0")
+	sum := sha256Hex(artifact)
+	req := FetchRequest{Component: "worker", Version: "2.0.0", DownloadURL: "/download/worker", SHA256: sum, Signature: signUpdateHash(t, privKey, sum)}
+
+	var downloads int
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write(artifact)
+	}))
+	defer origin.Close()
+
+	// The first machine downloads from the server and keeps the verified
+	// artifact for its peers.
+	seed := newLANTestGuard(t, origin.URL, pubKey)
+	seed.lanDiscover = func(ctx context.Context, sha256Hash string) (string, error) {
+		return "", errors.New("no peers")
+	}
+	path, got, err := seed.fetchArtifact(context.Background(), req, "", 1<<20)
+	if err != nil || got != sum {
+		t.Fatalf("seed fetch = %s, %v", got, err)
+	}
+	os.Remove(path)
+	if downloads != 1 {
+		t.Fatalf("server downloads = %d, want 1", downloads)
+	}
+
+	seed.sm.OnVerifySuccess()
+	peer := httptest.NewServer(seed.lanShareHandler())
+	defer peer.Close()
+
+	// The second machine gets it from the first and never asks the server.
+	g := newLANTestGuard(t, origin.URL, pubKey)
+	g.lanDiscover = func(ctx context.Context, sha256Hash string) (string, error) {
+		return strings.TrimPrefix(peer.URL, "http://"), nil
+	}
+	path, got, err = g.fetchArtifact(context.Background(), req, "", 1<<20)
+	if err != nil || got != sum {
+		t.Fatalf("peer fetch = %s, %v", got, err)
+	}
+	os.Remove(path)
+	if downloads != 1 {
+		t.Fatalf("server downloads = %d, want the peer to serve the artifact", downloads)
+	}
+	if _, err := os.Stat(filepath.Join(artifactCacheDir(g.cfg), sum)); err != nil {
+		t.Fatalf("artifact from a peer not shared onwards: %v", err)
+	}
+
+	// A peer serving other bytes is ignored in favour of the server.
+	if err := os.WriteFile(filepath.Join(artifactCacheDir(seed.cfg), sum), []byte("tampered"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	other := newLANTestGuard(t, origin.URL, pubKey)
+	other.lanDiscover = func(ctx context.Context, sha256Hash string) (string, error) {
+		return strings.TrimPrefix(peer.URL, "http://"), nil
+	}
+	path, got, err = other.fetchArtifact(context.Background(), req, "", 1<<20)
+	if err != nil || got != sum {
+		t.Fatalf("fetch after tampered peer = %s, %v", got, err)
+	}
+	os.Remove(path)
+	if downloads != 2 {
+		t.Fatalf("server downloads = %d, want a fallback download", downloads)
+	}
+}
+
+func TestLANSharing_UnsignedArtifactsAreNotShared(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	artifact := []byte("unsigned")
+	sum := sha256Hex(artifact)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(artifact)
+	}))
+	defer origin.Close()
+
+	g := newLANTestGuard(t, origin.URL, pubKey)
+	g.lanDiscover = func(ctx context.Context, sha256Hash string) (string, error) {
+		return "", errors.New("no peers")
+	}
+	path, _, err := g.fetchArtifact(context.Background(), FetchRequest{DownloadURL: "/a", SHA256: sum, Signature: signUpdateHash(t, otherKey, sum)}, "", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+	if _, err := os.Stat(filepath.Join(artifactCacheDir(g.cfg), sum)); !os.IsNotExist(err) {
+		t.Fatalf("artifact with a bad signature was shared: %v", err)
+	}
+}
+
+func TestLANSharing_MDNSQueryRoundTrip(t *testing.T) {
+	g := newLANTestGuard(t, "", nil)
+	src := filepath.Join(t.TempDir(), "artifact")
+	if err := os.WriteFile(src, []byte("one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	shared, missing := sha256Hex([]byte("one")), sha256Hex([]byte("two"))
+	g.cacheArtifact(src, DigestSHA256, shared)
+
+	query, err := buildPeerQuery(shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, ok := g.answerPeerQuery(query, 47813)
+	if !ok {
+		t.Fatal("no answer for a shared artifact")
+	}
+	if port, ok := parsePeerAnswer(reply, shared); !ok || port != 47813 {
+		t.Fatalf("answer port = %d, %v", port, ok)
+	}
+	if _, ok := parsePeerAnswer(reply, missing); ok {
+		t.Fatal("answer accepted for another artifact")
+	}
+
+	query, _ = buildPeerQuery(missing)
+	if _, ok := g.answerPeerQuery(query, 47813); ok {
+		t.Fatal("answered for an artifact that is not shared")
+	}
+}
+
+func TestLANSharing_ServesOnlyTokenHoldersWhileLicensed(t *testing.T) {
+	g := newLANTestGuard(t, "", nil)
+	src := filepath.Join(t.TempDir(), "artifact")
+	if err := os.WriteFile(src, []byte("one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key := sha256Hex([]byte("one"))
+	g.cacheArtifact(src, DigestSHA256, key)
+	peer := httptest.NewServer(g.lanShareHandler())
+	defer peer.Close()
+
+	get := func(token string) int {
+		req, _ := http.NewRequest(http.MethodGet, peer.URL+lanShareArtifactPath+key, nil)
+		if token != "" {
+			req.Header.Set(headerLANToken, token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := get(g.lanShareToken(key)); status != http.StatusForbidden {
+		t.Fatalf("status without a lease = %d, want 403", status)
+	}
+	g.sm.OnVerifySuccess()
+	if status := get(""); status != http.StatusForbidden {
+		t.Fatalf("status without a token = %d, want 403", status)
+	}
+	if status := get(g.lanShareToken(sha256Hex([]byte("two")))); status != http.StatusForbidden {
+		t.Fatalf("status with another artifact's token = %d, want 403", status)
+	}
+	if status := get(g.lanShareToken(key)); status != http.StatusOK {
+		t.Fatalf("status with a token = %d, want 200", status)
+	}
+}
+
+func TestIsLANAddress(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.0.7": true,
+		"169.254.1.1": true,
+		"fd00::1":     true,
+		"8.8.8.8":     false,
+		"127.0.0.1":   false,
+		"2001:db8::1": false,
+	} {
+		if got := isLANAddress(net.ParseIP(addr)); got != want {
+			t.Errorf("isLANAddress(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"time"
 
 	"golang.org/x/crypto/hkdf"
@@ -21,7 +22,7 @@ import (
 
 const (
 	defaultLeaseClockSkew = 5 * time.Minute
-	verifyTimeout         = 30 * time.Second
+	maxResponseAge        = 10 * time.Minute
 )
 
 type lease struct {
@@ -36,18 +37,37 @@ type lease struct {
 	ProjectSlug string   `json:"project_slug"`
 	ServerTime  string   `json:"server_time"`
 	Tier        string   `json:"tier"`
+	// ComponentSlug scopes the lease to one component of the project. Empty
+	// means the lease covers every component.
+	ComponentSlug string `json:"component_slug,omitempty"`
+	// MachinesInUse is how many machines the license is bound to, when the
+	// server reports it.
+	MachinesInUse int `json:"machines_in_use,omitempty"`
+
+	// raw is the signed canonical form, kept so fields this SDK does not
+	// model survive persistence and re-verification.
+	raw json.RawMessage
 }
 
 type verifyResponse struct {
-	Lease          json.RawMessage `json:"lease"`
-	LeaseSignature string          `json:"lease_signature"`
-	ServerTime     string          `json:"server_time"`
-	Error          string          `json:"error"`
-	Message        string          `json:"message"`
+	Lease             json.RawMessage `json:"lease"`
+	LeaseSignature    string          `json:"lease_signature"`
+	ServerTime        string          `json:"server_time"`
+	Nonce             string          `json:"nonce"`
+	ResponseSignature string          `json:"response_signature"`
+	// RequestKeyID and RequestSecret are the per-machine request signing
+	// key the server issues on verify; see signRequest.
+	RequestKeyID  string `json:"request_key_id,omitempty"`
+	RequestSecret string `json:"request_secret,omitempty"`
+	// RemoteConfig carries its own signature, so it is not covered by
+	// ResponseSignature.
+	RemoteConfig *remoteConfigBlob `json:"remote_config,omitempty"`
+	Error        string            `json:"error"`
+	Message      string            `json:"message"`
 }
 
 type licenseVerifyRequestBody struct {
-	LicenseKey    string            `json:"license_key"`
+	LicenseKey    string            `json:"license_key,omitempty"`
 	MachineID     string            `json:"machine_id"`
 	AuxSignals    map[string]string `json:"aux_signals"`
 	ProjectSlug   string            `json:"project_slug"`
@@ -58,9 +78,13 @@ type licenseVerifyRequestBody struct {
 	Nonce         string            `json:"nonce"`
 	Timestamp     int64             `json:"timestamp"`
 	BinaryHash    string            `json:"binary_hash"`
+	// Drift is set when the fingerprint moved away from the one the license
+	// was last bound to on this machine.
+	Drift *FingerprintDrift `json:"drift,omitempty"`
 }
 
-func (g *Guard) verifyLicense(ctx context.Context) error {
+func (g *Guard) verifyLicense(ctx context.Context) (err error) {
+	defer func() { g.auditOutcome(AuditVerification, g.cfg.ComponentSlug, err, "") }()
 	now := time.Now()
 	if err := g.validatePersistedLease(now); err == nil {
 		g.sm.OnVerifySuccess()
@@ -90,7 +114,7 @@ func (g *Guard) verifyOnline(parent context.Context, now time.Time) (*lease, str
 	}
 
 	reqBody := licenseVerifyRequestBody{
-		LicenseKey:    g.cfg.LicenseKey,
+		LicenseKey:    g.bodyLicenseKey(),
 		MachineID:     g.fingerprint.MachineID(),
 		AuxSignals:    g.fingerprint.AuxSignals(),
 		ProjectSlug:   g.cfg.ProjectSlug,
@@ -101,17 +125,24 @@ func (g *Guard) verifyOnline(parent context.Context, now time.Time) (*lease, str
 		Nonce:         nonce,
 		Timestamp:     now.Unix(),
 		BinaryHash:    binaryHash,
+		Drift:         g.currentDrift(),
 	}
-
-	var resp verifyResponse
-	ctx, cancel := context.WithTimeout(parent, verifyTimeout)
-	defer cancel()
-
 	reqBodyJSON, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, "", fmt.Errorf("marshal request: %w", err)
 	}
-	raw, err := g.postJSON(ctx, "/api/v1/verify", reqBodyJSON)
+	return g.requestLease(parent, "/api/v1/verify", "verify", reqBodyJSON, nonce, now)
+}
+
+// requestLease posts a lease request carrying nonce and returns the verified
+// lease from the signed reply. endpoint labels the recorded response.
+func (g *Guard) requestLease(parent context.Context, path, endpoint string, body []byte, nonce string, now time.Time) (*lease, string, error) {
+	var resp verifyResponse
+	ctx, cancel := withTimeout(parent, g.cfg.Timeouts.Verify)
+	defer cancel()
+
+	raw, err := g.postJSON(ctx, path, body)
+	g.recordResponse(endpoint, raw, err)
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
@@ -122,21 +153,91 @@ func (g *Guard) verifyOnline(parent context.Context, now time.Time) (*lease, str
 	if err := json.Unmarshal(raw, &resp); err != nil {
 		return nil, "", fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
+	g.reportUnknownFields(endpoint, raw, resp)
 	if resp.Error != "" {
-		return nil, "", mapVerifyError(resp.Error)
+		apiErr := newAPIError(http.StatusOK, resp.Error, resp.Message, "")
+		apiErr.Cause = mapVerifyError(resp.Error)
+		return nil, "", apiErr
 	}
 	if len(resp.Lease) == 0 || resp.LeaseSignature == "" {
 		return nil, "", ErrInvalidServerResponse
 	}
 
+	if err := g.verifyLicenseResponse(resp, nonce, now); err != nil {
+		return nil, "", err
+	}
+
 	leaseValue, err := parseAndVerifyLease(resp.Lease, resp.LeaseSignature, g.verificationKeys(), g.fingerprint.MachineID(), now, g.currentWatermark())
 	if err != nil {
 		return nil, "", err
 	}
+	g.applyRemoteConfig(resp.RemoteConfig)
+	if err := g.rememberRequestKey(resp.RequestKeyID, resp.RequestSecret); err != nil {
+		g.logger.Warn("failed to persist request signing key", "error", err)
+	}
 
 	return leaseValue, resp.LeaseSignature, nil
 }
 
+// verifySignaturePayload is the part of a verify reply the server signs; it
+// binds the lease to this request's nonce and the server clock.
+type verifySignaturePayload struct {
+	Lease          json.RawMessage `json:"lease"`
+	LeaseSignature string          `json:"lease_signature"`
+	Nonce          string          `json:"nonce"`
+	ServerTime     string          `json:"server_time"`
+	RequestKeyID   string          `json:"request_key_id,omitempty"`
+	RequestSecret  string          `json:"request_secret,omitempty"`
+}
+
+// verifyLicenseResponse rejects verify replies that are unsigned, answer a
+// different request, or were issued too long before it.
+func (g *Guard) verifyLicenseResponse(resp verifyResponse, requestNonce string, requestedAt time.Time) error {
+	if resp.ResponseSignature == "" || resp.Nonce != requestNonce {
+		return ErrVerifyResponseInvalid
+	}
+	if err := verifyResponseSignature(resp, g.verificationKeys()); err != nil {
+		g.reportError(errorKindSignatureMismatch, g.cfg.ComponentSlug, fmt.Errorf("verify response: %w", err))
+		return ErrVerifyResponseInvalid
+	}
+	return checkResponseFreshness(resp.ServerTime, requestedAt)
+}
+
+// verifyResponseSignature checks the server signature over the lease, nonce
+// and server time of a verify reply.
+func verifyResponseSignature(resp verifyResponse, publicKeys []ed25519.PublicKey) error {
+	raw, err := json.Marshal(verifySignaturePayload{
+		Lease:          normalizedJSONObject(resp.Lease),
+		LeaseSignature: resp.LeaseSignature,
+		Nonce:          resp.Nonce,
+		ServerTime:     resp.ServerTime,
+		RequestKeyID:   resp.RequestKeyID,
+		RequestSecret:  resp.RequestSecret,
+	})
+	if err != nil {
+		return err
+	}
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		return err
+	}
+	return verifyEd25519Digest(canonical, resp.ResponseSignature, publicKeys)
+}
+
+// checkResponseFreshness rejects a signed reply whose server time is older
+// than the request by more than maxResponseAge, so a captured response cannot
+// be held back and released later.
+func checkResponseFreshness(serverTime string, requestedAt time.Time) error {
+	issued, err := parseRFC3339(serverTime)
+	if err != nil {
+		return ErrResponseStale
+	}
+	if issued.Before(requestedAt.Add(-maxResponseAge)) {
+		return ErrResponseStale
+	}
+	return nil
+}
+
 func (g *Guard) validatePersistedLease(now time.Time) error {
 	state := g.currentLeaseState()
 	if state == nil || state.Lease == nil || state.LeaseSignature == "" {
@@ -150,6 +251,9 @@ func (g *Guard) validatePersistedLease(now time.Time) error {
 		g.sm.OnKill()
 		return ErrBanned
 	}
+	if licenseCacheStale(state.VerifiedAt, now, g.cfg.LicenseCacheTTL) {
+		return ErrLicenseCacheStale
+	}
 	if _, err := parseAndVerifyLease(state.LeaseCanonical, state.LeaseSignature, g.verificationKeys(), g.fingerprint.MachineID(), now, state.Watermark); err != nil {
 		return err
 	}
@@ -158,9 +262,27 @@ func (g *Guard) validatePersistedLease(now time.Time) error {
 			return ErrClockRollback
 		}
 	}
+	if highWater, err := parseRFC3339(state.ClockHighWater); err == nil {
+		if now.Before(highWater.Add(-clockTamperTolerance)) {
+			return ErrClockRollback
+		}
+	}
 	return nil
 }
 
+// licenseCacheStale reports whether a cached lease was last confirmed online
+// more than ttl ago. Caches written before VerifiedAt existed count as stale.
+func licenseCacheStale(verifiedAt string, now time.Time, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	verified, err := parseRFC3339(verifiedAt)
+	if err != nil {
+		return true
+	}
+	return now.Sub(verified) > ttl
+}
+
 func (g *Guard) acceptLease(leaseValue *lease, leaseSignature string, keepCurrentState bool) error {
 	canonical, err := canonicalJSONFromLease(leaseValue)
 	if err != nil {
@@ -174,6 +296,9 @@ func (g *Guard) acceptLease(leaseValue *lease, leaseSignature string, keepCurren
 	state.LeaseCanonical = canonical
 	state.LeaseSignature = leaseSignature
 	state.Watermark = maxTimestamp(state.Watermark, leaseValue.ServerTime)
+	verifiedAt := time.Now()
+	state.VerifiedAt = verifiedAt.UTC().Format(time.RFC3339)
+	state.ClockHighWater = state.VerifiedAt
 	if !keepCurrentState {
 		state.LockFlag = false
 		state.BanFlag = false
@@ -181,6 +306,9 @@ func (g *Guard) acceptLease(leaseValue *lease, leaseSignature string, keepCurren
 	if err := g.store.Save(state); err != nil {
 		return err
 	}
+	g.clock.reset(verifiedAt)
+	g.invalidateFeatureCache()
+	g.recordBinding()
 	return nil
 }
 
@@ -192,6 +320,9 @@ func (g *Guard) currentWatermark() string {
 }
 
 func canonicalJSONFromLease(value *lease) (json.RawMessage, error) {
+	if len(value.raw) > 0 {
+		return value.raw, nil
+	}
 	raw, err := json.Marshal(value)
 	if err != nil {
 		return nil, err
@@ -217,6 +348,7 @@ func parseAndVerifyLease(raw json.RawMessage, signature string, publicKeys []ed2
 	if err := json.Unmarshal(canonical, &value); err != nil {
 		return nil, ErrInvalidServerResponse
 	}
+	value.raw = canonical
 
 	if value.MachineID != machineID {
 		return nil, ErrLeaseBindingMismatch
@@ -368,6 +500,10 @@ func mapVerifyError(code string) error {
 		return ErrMachineBanned
 	case "binary_not_recognized":
 		return ErrBinaryNotRecognized
+	case "machine_mismatch":
+		return ErrMachineMismatch
+	case "rebind_rejected":
+		return ErrRebindRejected
 	default:
 		return fmt.Errorf("%w: %s", ErrLicenseInvalid, code)
 	}
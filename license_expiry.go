@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"context"
+	"time"
+)
+
+// defaultLicenseExpiryWarnings are the OnLicenseExpiring thresholds used
+// when Config.LicenseExpiryWarnings is empty.
+var defaultLicenseExpiryWarnings = []time.Duration{30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour}
+
+// licenseExpiryNotice remembers which expiry callbacks have fired for the
+// lease's current expiry; a renewal that moves the expiry re-arms them.
+type licenseExpiryNotice struct {
+	expiresAt time.Time
+	threshold time.Duration
+	expired   bool
+}
+
+// LicenseExpiry returns when the license expires, as stated by the last
+// verified lease. It returns false before a lease has been verified.
+func (g *Guard) LicenseExpiry() (time.Time, bool) {
+	state := g.currentLeaseState()
+	if state == nil || state.Lease == nil {
+		return time.Time{}, false
+	}
+	expiresAt, err := parseRFC3339(state.Lease.ExpiresAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// RefreshLicense verifies the license online now instead of waiting for the
+// next heartbeat, e.g. right after the customer renewed, so an extended
+// expiry or new entitlements take effect immediately. A LOCKED guard that
+// verifies again is unlocked as by the recovery loop.
+func (g *Guard) RefreshLicense(ctx context.Context) (err error) {
+	if err := g.requireInitialized(true, true); err != nil {
+		return err
+	}
+	switch g.sm.Current() {
+	case StateDeactivated:
+		return ErrDeactivated
+	case StateLocked:
+		return g.recoverFromLock(ctx)
+	}
+
+	defer func() { g.auditOutcome(AuditVerification, g.cfg.ComponentSlug, err, "refresh") }()
+	leaseValue, leaseSignature, err := g.verifyOnline(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := g.acceptLease(leaseValue, leaseSignature, false); err != nil {
+		return err
+	}
+	g.sm.OnVerifySuccess()
+	return nil
+}
+
+// checkLicenseExpiry fires OnLicenseExpiring once per crossed threshold of
+// Config.LicenseExpiryWarnings and OnLicenseExpired once the expiry passes.
+// The heartbeat loop calls it on every round.
+func (g *Guard) checkLicenseExpiry(now time.Time) {
+	expiresAt, ok := g.LicenseExpiry()
+	if !ok {
+		return
+	}
+	remaining := expiresAt.Sub(now)
+
+	var expiring, expired bool
+	g.mu.Lock()
+	if !g.expiryNotice.expiresAt.Equal(expiresAt) {
+		g.expiryNotice = licenseExpiryNotice{expiresAt: expiresAt}
+	}
+	if remaining <= 0 {
+		expired = !g.expiryNotice.expired
+		g.expiryNotice.expired = true
+	} else if threshold, ok := expiryThreshold(g.cfg.LicenseExpiryWarnings, remaining); ok {
+		if g.expiryNotice.threshold == 0 || threshold < g.expiryNotice.threshold {
+			g.expiryNotice.threshold = threshold
+			expiring = true
+		}
+	}
+	g.mu.Unlock()
+
+	switch {
+	case expired:
+		g.logger.Error("license expired", "expires_at", expiresAt.Format(time.RFC3339))
+		if g.cfg.OnLicenseExpired != nil {
+			g.cfg.OnLicenseExpired()
+		}
+	case expiring:
+		g.logger.Warn("license expiring soon", "remaining", remaining.String())
+		if g.cfg.OnLicenseExpiring != nil {
+			g.cfg.OnLicenseExpiring(remaining)
+		}
+	}
+}
+
+// expiryThreshold returns the smallest threshold that remaining has fallen
+// within, if any.
+func expiryThreshold(thresholds []time.Duration, remaining time.Duration) (time.Duration, bool) {
+	var best time.Duration
+	for _, threshold := range thresholds {
+		if remaining <= threshold && (best == 0 || threshold < best) {
+			best = threshold
+		}
+	}
+	return best, best > 0
+}
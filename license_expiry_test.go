@@ -0,0 +1,87 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRefreshLicensePicksUpExtension(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	extended := testLease(guard.fingerprint.MachineID())
+	extended.ExpiresAt = time.Now().Add(365 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, extended)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body licenseVerifyRequestBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_ = json.NewEncoder(w).Encode(signVerifyResponse(t, privKey, verifyResponse{Lease: json.RawMessage(leaseJSON), LeaseSignature: sig}, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if _, ok := guard.LicenseExpiry(); ok {
+		t.Fatal("expected no expiry before the first verification")
+	}
+	if err := guard.RefreshLicense(context.Background()); err != nil {
+		t.Fatalf("RefreshLicense: %v", err)
+	}
+	expiresAt, ok := guard.LicenseExpiry()
+	if !ok || expiresAt.Format(time.RFC3339) != extended.ExpiresAt {
+		t.Fatalf("expected expiry %s, got %v (%v)", extended.ExpiresAt, expiresAt, ok)
+	}
+	if guard.State() != StateActive {
+		t.Fatalf("expected ACTIVE after refresh, got %s", guard.State())
+	}
+}
+
+func TestLicenseExpiryCallbacksFireOncePerThreshold(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {})
+	var expiring []time.Duration
+	var expired int
+	g.cfg.OnLicenseExpiring = func(remaining time.Duration) { expiring = append(expiring, remaining) }
+	g.cfg.OnLicenseExpired = func() { expired++ }
+
+	now := time.Now()
+	setExpiry := func(at time.Time) {
+		t.Helper()
+		leaseValue := testLease(testingMachineID)
+		leaseValue.ExpiresAt = at.UTC().Format(time.RFC3339)
+		if err := g.store.Save(&persistedState{Lease: leaseValue}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setExpiry(now.Add(60 * 24 * time.Hour))
+	g.checkLicenseExpiry(now)
+	if len(expiring) != 0 {
+		t.Fatalf("expected no warning 60 days out, got %v", expiring)
+	}
+
+	setExpiry(now.Add(5 * 24 * time.Hour))
+	g.checkLicenseExpiry(now)
+	g.checkLicenseExpiry(now.Add(time.Hour))
+	if len(expiring) != 1 {
+		t.Fatalf("expected one warning within the 7 day threshold, got %v", expiring)
+	}
+	g.checkLicenseExpiry(now.Add(4*24*time.Hour + time.Hour))
+	if len(expiring) != 2 {
+		t.Fatalf("expected a second warning within the 1 day threshold, got %v", expiring)
+	}
+
+	g.checkLicenseExpiry(now.Add(6 * 24 * time.Hour))
+	g.checkLicenseExpiry(now.Add(7 * 24 * time.Hour))
+	if expired != 1 {
+		t.Fatalf("expected OnLicenseExpired once, got %d", expired)
+	}
+
+	setExpiry(now.Add(10 * 24 * time.Hour))
+	g.checkLicenseExpiry(now.Add(6 * 24 * time.Hour))
+	if len(expiring) != 3 {
+		t.Fatalf("expected a renewal to re-arm the warnings, got %v", expiring)
+	}
+}
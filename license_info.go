@@ -0,0 +1,60 @@
+package sdk
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// LicenseInfo describes the license as stated by the last verified lease,
+// for an "About / License" screen.
+type LicenseInfo struct {
+	ProjectSlug string
+	// Plan is the lease tier, e.g. "commercial" or "trial".
+	Plan     string
+	Features []string
+	IssuedAt time.Time
+	// ExpiresAt is when the license expires and GraceUntil when the guard
+	// stops honoring the lease offline after that.
+	ExpiresAt  time.Time
+	GraceUntil time.Time
+	// MaxMachines is the machine limit; MachinesInUse is zero when the
+	// server does not report it.
+	MaxMachines   int
+	MachinesInUse int
+	// VerifiedAt is when the server last confirmed the lease.
+	VerifiedAt time.Time
+	State      State
+	// Grace is set while the guard is in GRACE; see Guard.GraceInfo.
+	Grace *GraceStatus
+	// Raw is the signed lease JSON, including fields this SDK does not
+	// model. It contains the license key.
+	Raw json.RawMessage
+}
+
+// LicenseInfo returns what the last verified lease says about the license,
+// without a server call. It returns false before a lease has been verified.
+func (g *Guard) LicenseInfo() (LicenseInfo, bool) {
+	state := g.currentLeaseState()
+	if state == nil || state.Lease == nil {
+		return LicenseInfo{}, false
+	}
+	l := state.Lease
+	info := LicenseInfo{
+		ProjectSlug:   l.ProjectSlug,
+		Plan:          l.Tier,
+		Features:      slices.Clone(l.Features),
+		MaxMachines:   l.MaxMachines,
+		MachinesInUse: l.MachinesInUse,
+		State:         g.State(),
+		Raw:           slices.Clone(state.LeaseCanonical),
+	}
+	info.IssuedAt, _ = parseRFC3339(l.IssuedAt)
+	info.ExpiresAt, _ = parseRFC3339(l.ExpiresAt)
+	info.GraceUntil, _ = parseRFC3339(l.GraceUntil)
+	info.VerifiedAt, _ = parseRFC3339(state.VerifiedAt)
+	if grace, ok := g.GraceInfo(); ok {
+		info.Grace = &grace
+	}
+	return info, true
+}
@@ -0,0 +1,58 @@
+package sdk
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLicenseInfoKeepsUnmodeledLeaseFields(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	if _, ok := guard.LicenseInfo(); ok {
+		t.Fatal("LicenseInfo before a lease should report false")
+	}
+
+	leaseValue := testLease(guard.fingerprint.MachineID())
+	leaseValue.MaxMachines = 5
+	leaseValue.MachinesInUse = 2
+	raw, _ := json.Marshal(leaseValue)
+	var fields map[string]any
+	_ = json.Unmarshal(raw, &fields)
+	fields["customer"] = "Acme Corp"
+	raw, _ = json.Marshal(fields)
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(canonical)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(privKey, digest[:]))
+
+	verified, err := parseAndVerifyLease(canonical, sig, guard.verificationKeys(), guard.fingerprint.MachineID(), time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.acceptLease(verified, sig, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.validatePersistedLease(time.Now()); err != nil {
+		t.Fatalf("persisted lease with an unmodeled field must still verify, got %v", err)
+	}
+
+	info, ok := guard.LicenseInfo()
+	if !ok {
+		t.Fatal("LicenseInfo after a lease should report true")
+	}
+	if info.Plan != "commercial" || info.MaxMachines != 5 || info.MachinesInUse != 2 || info.ProjectSlug != "test-project" {
+		t.Fatalf("info = %+v", info)
+	}
+	if time.Until(info.ExpiresAt) < 23*time.Hour || !info.GraceUntil.After(info.ExpiresAt) || info.VerifiedAt.IsZero() {
+		t.Fatalf("times = issued %v, expires %v, grace %v, verified %v", info.IssuedAt, info.ExpiresAt, info.GraceUntil, info.VerifiedAt)
+	}
+	if !bytes.Contains(info.Raw, []byte(`"customer":"Acme Corp"`)) {
+		t.Fatalf("raw lease = %s, want the unmodeled field", info.Raw)
+	}
+}
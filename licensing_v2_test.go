@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"bytes"
 	"context"
 	"crypto/ed25519"
 	"crypto/rand"
@@ -130,6 +131,95 @@ func TestClockRollbackBelowWatermarkForcesRecheck(t *testing.T) {
 	}
 }
 
+func TestStaleLicenseCacheForcesOnlineVerification(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+	if err := guard.acceptLease(mustParseLease(t, leaseJSON), sig, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.validatePersistedLease(time.Now()); err != nil {
+		t.Fatalf("fresh cache should be trusted, got %v", err)
+	}
+	stale := time.Now().Add(guard.cfg.LicenseCacheTTL + time.Minute)
+	if err := guard.validatePersistedLease(stale); err != ErrLicenseCacheStale {
+		t.Fatalf("expected ErrLicenseCacheStale, got %v", err)
+	}
+
+	state := guard.currentLeaseState()
+	state.VerifiedAt = ""
+	if err := guard.store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.validatePersistedLease(time.Now()); err != ErrLicenseCacheStale {
+		t.Fatalf("cache without verification time should be stale, got %v", err)
+	}
+}
+
+func TestVerifyOnlineRejectsReplayedOrStaleResponses(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+	stale := time.Now().Add(-maxResponseAge - time.Minute).UTC().Format(time.RFC3339)
+
+	for _, tt := range []struct {
+		name    string
+		respond func(nonce string) verifyResponse
+		wantErr error
+	}{
+		{"unsigned", func(nonce string) verifyResponse {
+			return verifyResponse{Lease: json.RawMessage(leaseJSON), LeaseSignature: sig, Nonce: nonce, ServerTime: time.Now().UTC().Format(time.RFC3339)}
+		}, ErrVerifyResponseInvalid},
+		{"replayed nonce", func(string) verifyResponse {
+			return signVerifyResponse(t, privKey, verifyResponse{Lease: json.RawMessage(leaseJSON), LeaseSignature: sig}, "captured")
+		}, ErrVerifyResponseInvalid},
+		{"stale", func(nonce string) verifyResponse {
+			return signVerifyResponse(t, privKey, verifyResponse{Lease: json.RawMessage(leaseJSON), LeaseSignature: sig, ServerTime: stale}, nonce)
+		}, ErrResponseStale},
+		{"fresh", func(nonce string) verifyResponse {
+			return signVerifyResponse(t, privKey, verifyResponse{Lease: json.RawMessage(leaseJSON), LeaseSignature: sig}, nonce)
+		}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body licenseVerifyRequestBody
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				_ = json.NewEncoder(w).Encode(tt.respond(body.Nonce))
+			}))
+			defer server.Close()
+
+			guard.cfg.ServerURL = server.URL
+			guard.httpClient = server.Client()
+			if _, _, err := guard.verifyOnline(context.Background(), time.Now()); err != tt.wantErr {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHeartbeatRejectsStaleServerTime(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+	if err := guard.acceptLease(mustParseLease(t, leaseJSON), sig, false); err != nil {
+		t.Fatal(err)
+	}
+	guard.sm.OnVerifySuccess()
+
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{
+			Status:         "ok",
+			Lease:          json.RawMessage(leaseJSON),
+			LeaseSignature: sig,
+			ServerTime:     time.Now().Add(-maxResponseAge - time.Minute).UTC().Format(time.RFC3339),
+		}
+	})
+	defer server.Close()
+
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+	if err := guard.sendHeartbeat(context.Background()); err != ErrResponseStale {
+		t.Fatalf("expected ErrResponseStale, got %v", err)
+	}
+}
+
 func TestHardBindingAPIsRequireLeaseAndThenSucceed(t *testing.T) {
 	guard, privKey := newTestGuard(t, nil)
 	if _, err := guard.Unseal([]byte("bad")); err != ErrLeaseUnavailable {
@@ -206,13 +296,13 @@ func TestTLSPinMismatchRefusesConnectionUnlessAllowSystemTrust(t *testing.T) {
 }
 
 func TestUpdateRejectsNonStrictlyGreaterVersion(t *testing.T) {
-	if isStrictlyNewerVersion("1.2.3", "1.2.3") {
+	if IsNewer("1.2.3", "1.2.3") {
 		t.Fatal("equal version should not be newer")
 	}
-	if isStrictlyNewerVersion("1.2.3", "1.2.2") {
+	if IsNewer("1.2.3", "1.2.2") {
 		t.Fatal("downgrade should not be newer")
 	}
-	if !isStrictlyNewerVersion("1.2.3", "1.2.4") {
+	if !IsNewer("1.2.3", "1.2.4") {
 		t.Fatal("greater version should be newer")
 	}
 }
@@ -340,6 +430,41 @@ func TestStateFileWritten(t *testing.T) {
 	}
 }
 
+func TestStateFileIsSealedAndAcceptsLegacyPlaintext(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+	if err := guard.acceptLease(mustParseLease(t, leaseJSON), sig, false); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(guard.store.cacheDir(), "state.bin")
+	raw, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("test-license")) || bytes.Contains(raw, []byte("lease-123")) {
+		t.Fatal("state.bin should not contain lease material in plaintext")
+	}
+
+	payload, err := json.Marshal(guard.currentLeaseState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := guard.store.signPayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := json.Marshal(persistedEnvelope{Payload: payload, Signature: signature})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(statePath, legacy, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := guard.store.Load(); err != nil || loaded.Lease == nil {
+		t.Fatalf("legacy state should load, state=%#v err=%v", loaded, err)
+	}
+}
+
 func TestStateSaveCleansTempFileOnRenameFailure(t *testing.T) {
 	guard, _ := newTestGuard(t, nil)
 	dir := guard.store.cacheDir()
@@ -379,7 +504,7 @@ func TestStartUsesPersistedLease(t *testing.T) {
 	guard.Stop()
 }
 
-func TestStartIsIdempotent(t *testing.T) {
+func TestStartRejectsSecondStartAndRestartsAfterStop(t *testing.T) {
 	guard, privKey := newTestGuard(t, nil)
 	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
 	if err := guard.acceptLease(mustParseLease(t, leaseJSON), sig, false); err != nil {
@@ -391,14 +516,24 @@ func TestStartIsIdempotent(t *testing.T) {
 	}
 	firstDone := guard.heartbeatDone
 
-	if err := guard.Start(context.Background()); err != nil {
-		t.Fatalf("second Start failed: %v", err)
+	if err := guard.Start(context.Background()); err != ErrAlreadyStarted {
+		t.Fatalf("expected ErrAlreadyStarted from second Start, got %v", err)
 	}
 	if guard.heartbeatDone != firstDone {
-		t.Fatal("expected second Start to reuse existing heartbeat loop")
+		t.Fatal("expected second Start to keep the existing heartbeat loop")
 	}
 
 	guard.Stop()
+	if guard.running || guard.cancel != nil || guard.heartbeatDone != nil {
+		t.Fatal("expected Stop to reset the lifecycle")
+	}
+	if err := guard.Start(context.Background()); err != nil {
+		t.Fatalf("Start after Stop failed: %v", err)
+	}
+	if guard.heartbeatDone == nil || guard.heartbeatDone == firstDone {
+		t.Fatal("expected Start after Stop to launch a new heartbeat loop")
+	}
+	guard.Stop()
 }
 
 func TestStopCancelsInFlightHeartbeat(t *testing.T) {
@@ -465,3 +600,30 @@ type roundTripperFunc func(*http.Request) (*http.Response, error)
 func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
+
+func TestStopAndWaitWaitsForBackgroundWork(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	guard.goBackground(func() {
+		<-release
+		close(finished)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := guard.StopAndWait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected StopAndWait to time out while work is running, got %v", err)
+	}
+
+	close(release)
+	if err := guard.StopAndWait(context.Background()); err != nil {
+		t.Fatalf("StopAndWait: %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatal("StopAndWait returned before background work finished")
+	}
+}
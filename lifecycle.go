@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+)
+
+// LifecycleEvent describes the component transition a ManagedComponent hook
+// is invoked for.
+type LifecycleEvent struct {
+	Component  string
+	OldVersion string
+	NewVersion string
+}
+
+func (g *Guard) runPreUpdateHook(ctx context.Context, mc ManagedComponent, event LifecycleEvent) error {
+	if mc.PreUpdate == nil {
+		return nil
+	}
+	if err := mc.PreUpdate(ctx, event); err != nil {
+		return fmt.Errorf("%w: pre-update: %v", ErrHookVetoed, err)
+	}
+	return nil
+}
+
+func (g *Guard) runPreUninstallHook(ctx context.Context, mc ManagedComponent, event LifecycleEvent) error {
+	if mc.PreUninstall == nil {
+		return nil
+	}
+	if err := mc.PreUninstall(ctx, event); err != nil {
+		return fmt.Errorf("%w: pre-uninstall: %v", ErrHookVetoed, err)
+	}
+	return nil
+}
+
+func (g *Guard) runPostInstallHook(ctx context.Context, mc ManagedComponent, event LifecycleEvent) {
+	if mc.PostInstall == nil || !isUnknownVersion(event.OldVersion) {
+		return
+	}
+	if err := mc.PostInstall(ctx, event); err != nil {
+		g.logger.Error("post install hook failed", "component", mc.Slug, "error", err)
+	}
+}
+
+func (g *Guard) runRollbackHook(ctx context.Context, mc ManagedComponent, event LifecycleEvent, cause error) {
+	g.audit(AuditRollback, event.Component, AuditOutcomeSuccess, fmt.Sprintf("restored %s after failed update to %s: %v", event.OldVersion, event.NewVersion, cause))
+	if mc.OnRollback == nil {
+		return
+	}
+	mc.OnRollback(ctx, event, cause)
+}
+
+func isUnknownVersion(version string) bool {
+	return version == "" || version == "unknown"
+}
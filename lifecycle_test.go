@@ -0,0 +1,131 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newLifecycleTestGuard(t *testing.T, serverURL string, pubKey ed25519.PublicKey, version string) *Guard {
+	t.Helper()
+	return &Guard{
+		cfg: Config{
+			ServerURL:     serverURL,
+			LicenseKey:    "test-key",
+			ProjectSlug:   "test-project",
+			ComponentSlug: "backend",
+			OTA:           OTAConfig{MaxArtifactBytes: 10 * 1024 * 1024},
+		},
+		publicKey:       pubKey,
+		fingerprint:     &Fingerprint{machineID: "test-machine"},
+		httpClient:      &http.Client{Timeout: 5 * time.Second},
+		managedVersions: map[string]string{"frontend": version},
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestPreUpdateHookVetoesBeforeDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("vetoed update must not contact the server: %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, pubKeyFromRandom(t), "1.0.0")
+
+	var got LifecycleEvent
+	mc := ManagedComponent{
+		Slug: "frontend",
+		Dir:  t.TempDir(),
+		PreUpdate: func(ctx context.Context, event LifecycleEvent) error {
+			got = event
+			return errors.New("users are active")
+		},
+	}
+
+	err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"})
+	if !errors.Is(err, ErrHookVetoed) {
+		t.Fatalf("expected ErrHookVetoed, got %v", err)
+	}
+	if got.Component != "frontend" || got.OldVersion != "1.0.0" || got.NewVersion != "2.0.0" {
+		t.Fatalf("unexpected hook event: %#v", got)
+	}
+	if v := g.currentManagedVersion("frontend"); v != "1.0.0" {
+		t.Fatalf("vetoed update must keep version, got %s", v)
+	}
+}
+
+func TestPostInstallHookRunsOnlyForFirstInstall(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	archive := buildTarGz(t, map[string]string{"index.html": "hello"})
+	hashHex := sha256Hex(archive)
+	signature := signUpdateHash(t, privKey, hashHex)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/frontend.tar.gz",
+				"sha256":       hashHex,
+				"signature":    signature,
+			})
+		case "/download/frontend.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		oldVersion string
+		wantCalls  int
+	}{
+		{"unknown", 1},
+		{"1.0.0", 0},
+	} {
+		g := newLifecycleTestGuard(t, server.URL, pubKey, tt.oldVersion)
+		calls := 0
+		mc := ManagedComponent{
+			Slug: "frontend",
+			Dir:  filepath.Join(t.TempDir(), "live"),
+			PostInstall: func(ctx context.Context, event LifecycleEvent) error {
+				calls++
+				return nil
+			},
+		}
+		if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+			t.Fatalf("updateFrontend from %s: %v", tt.oldVersion, err)
+		}
+		if calls != tt.wantCalls {
+			t.Fatalf("from %s: expected %d PostInstall calls, got %d", tt.oldVersion, tt.wantCalls, calls)
+		}
+	}
+}
+
+func TestPreUninstallHookVetoesMarketplaceUninstall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("vetoed uninstall must not contact the server: %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	g := newMarketplaceTestGuard(t, server.URL)
+	g.cfg.ManagedComponents = []ManagedComponent{{
+		Slug: "reports",
+		PreUninstall: func(ctx context.Context, event LifecycleEvent) error {
+			return errors.New("still in use")
+		},
+	}}
+
+	if err := g.UninstallMarketplaceItem(context.Background(), "reports"); !errors.Is(err, ErrHookVetoed) {
+		t.Fatalf("expected ErrHookVetoed, got %v", err)
+	}
+}
@@ -1,12 +1,10 @@
 package sdk
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -123,7 +121,7 @@ type MarketplaceBrowseOptions struct {
 }
 
 type marketplaceAccessBody struct {
-	LicenseKey  string `json:"license_key"`
+	LicenseKey  string `json:"license_key,omitempty"`
 	MachineID   string `json:"machine_id"`
 	ProjectSlug string `json:"project_slug"`
 	OS          string `json:"os"`
@@ -165,7 +163,7 @@ func IsMarketplaceError(err error, code string) bool {
 
 func (g *Guard) marketplaceAccessBody() marketplaceAccessBody {
 	return marketplaceAccessBody{
-		LicenseKey:  g.cfg.LicenseKey,
+		LicenseKey:  g.bodyLicenseKey(),
 		MachineID:   g.fingerprint.MachineID(),
 		ProjectSlug: g.cfg.ProjectSlug,
 		OS:          g.cfg.OTA.OS,
@@ -173,43 +171,19 @@ func (g *Guard) marketplaceAccessBody() marketplaceAccessBody {
 	}
 }
 
+// marketplaceRequest sends a marketplace call through the API transport,
+// like every other JSON API call.
 func (g *Guard) marketplaceRequest(ctx context.Context, method, path string, query url.Values, data []byte) ([]byte, error) {
-	fullURL := serverURLForPath(g.cfg.ServerURL, path)
-	if len(query) > 0 {
-		fullURL += "?" + query.Encode()
-	}
-
-	var payload io.Reader
-	if data != nil {
-		payload = bytes.NewReader(data)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, payload)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
-	if data != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-
-	resp, err := g.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrNetworkError, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, decodeAPIErrorResponse(resp)
-	}
-	raw, err := readAPIJSONResponse(resp)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
-	}
-	return raw, nil
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
+	return g.callAPI(ctx, TransportRequest{Method: method, Path: path, Query: query, Body: data, Idempotent: method == http.MethodGet})
 }
 
 func (g *Guard) GetMarketplaceCatalog(ctx context.Context, options MarketplaceBrowseOptions) (*MarketplaceCatalog, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	query := url.Values{}
 	if options.Type != "" {
 		query.Set("type", options.Type)
@@ -262,12 +236,16 @@ func (g *Guard) GetMarketplaceCatalog(ctx context.Context, options MarketplaceBr
 }
 
 func (g *Guard) GetMarketplaceItem(ctx context.Context, slug string) (*MarketplaceDetail, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	if slug == "" {
 		return nil, fmt.Errorf("marketplace slug is required")
 	}
 
 	query := url.Values{}
-	query.Set("license_key", g.cfg.LicenseKey)
+	g.setLicenseQuery(query)
 	query.Set("machine_id", g.fingerprint.MachineID())
 	query.Set("project_slug", g.cfg.ProjectSlug)
 
@@ -284,6 +262,10 @@ func (g *Guard) GetMarketplaceItem(ctx context.Context, slug string) (*Marketpla
 }
 
 func (g *Guard) GetMarketplaceReviews(ctx context.Context, slug string, page, pageSize int) (*MarketplaceReviewList, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	if slug == "" {
 		return nil, fmt.Errorf("marketplace slug is required")
 	}
@@ -311,6 +293,10 @@ func (g *Guard) GetMarketplaceReviews(ctx context.Context, slug string, page, pa
 }
 
 func (g *Guard) InstallMarketplaceItem(ctx context.Context, slug string) (*MarketplaceInstallPackage, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	if slug == "" {
 		return nil, fmt.Errorf("marketplace slug is required")
 	}
@@ -332,10 +318,21 @@ func (g *Guard) InstallMarketplaceItem(ctx context.Context, slug string) (*Marke
 }
 
 func (g *Guard) UninstallMarketplaceItem(ctx context.Context, slug string) error {
+	if err := g.requireClient(); err != nil {
+		return err
+	}
+
 	if slug == "" {
 		return fmt.Errorf("marketplace slug is required")
 	}
 
+	if mc, ok := g.findManagedComponent(slug); ok {
+		event := LifecycleEvent{Component: slug, OldVersion: g.currentManagedVersion(slug)}
+		if err := g.runPreUninstallHook(ctx, mc, event); err != nil {
+			return err
+		}
+	}
+
 	path := "/api/v1/marketplace/" + url.PathEscape(slug) + "/uninstall"
 	bodyJSON, err := json.Marshal(g.marketplaceAccessBody())
 	if err != nil {
@@ -348,6 +345,10 @@ func (g *Guard) UninstallMarketplaceItem(ctx context.Context, slug string) error
 }
 
 func (g *Guard) ConfigureMarketplaceItem(ctx context.Context, slug string, config MarketplaceConfig) error {
+	if err := g.requireClient(); err != nil {
+		return err
+	}
+
 	if slug == "" {
 		return fmt.Errorf("marketplace slug is required")
 	}
@@ -372,6 +373,10 @@ func (g *Guard) ConfigureMarketplaceItem(ctx context.Context, slug string, confi
 }
 
 func (g *Guard) ReportMarketplaceStatus(ctx context.Context, slug string, isActive bool, errorMessage string) error {
+	if err := g.requireClient(); err != nil {
+		return err
+	}
+
 	if slug == "" {
 		return fmt.Errorf("marketplace slug is required")
 	}
@@ -402,6 +407,10 @@ func (g *Guard) SubmitMarketplaceReview(
 	title string,
 	content string,
 ) (*MarketplaceReviewSubmitResult, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	if slug == "" {
 		return nil, fmt.Errorf("marketplace slug is required")
 	}
@@ -0,0 +1,132 @@
+package sdk
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics is a snapshot of the guard's operational counters. Counters are
+// cumulative since New; the metrics subpackage exports them as a
+// prometheus.Collector.
+type Metrics struct {
+	State              State
+	HeartbeatSuccesses uint64
+	HeartbeatFailures  uint64
+	// LastVerified is when the server last confirmed the lease, by verify or
+	// heartbeat, including before a restart. It is zero if never.
+	LastVerified   time.Time
+	UpdateAttempts uint64
+	UpdateFailures uint64
+	// UpdateDuration is the total time spent in update attempts.
+	UpdateDuration time.Duration
+	// UpdateBytes is the total size of downloaded update artifacts.
+	UpdateBytes uint64
+	// APILatency holds request latencies by endpoint, such as "heartbeat",
+	// "update/download" or "artifact" for downloads from storage hosts.
+	APILatency map[string]LatencySummary
+}
+
+// LatencySummary aggregates request latencies for one endpoint.
+type LatencySummary struct {
+	Count uint64
+	Total time.Duration
+}
+
+type guardMetrics struct {
+	heartbeatSuccesses atomic.Uint64
+	heartbeatFailures  atomic.Uint64
+	updateAttempts     atomic.Uint64
+	updateFailures     atomic.Uint64
+	updateNanos        atomic.Int64
+	updateBytes        atomic.Uint64
+
+	latencyMu sync.Mutex
+	latency   map[string]LatencySummary
+}
+
+func (m *guardMetrics) observeHeartbeat(err error) {
+	if err == nil {
+		m.heartbeatSuccesses.Add(1)
+		return
+	}
+	m.heartbeatFailures.Add(1)
+}
+
+func (m *guardMetrics) observeUpdate(stats *UpdateStats) {
+	m.updateAttempts.Add(1)
+	if !stats.Success {
+		m.updateFailures.Add(1)
+	}
+	m.updateNanos.Add(int64(stats.TotalDuration))
+	if stats.Bytes > 0 {
+		m.updateBytes.Add(uint64(stats.Bytes))
+	}
+}
+
+func (m *guardMetrics) observeLatency(endpoint string, d time.Duration) {
+	m.latencyMu.Lock()
+	defer m.latencyMu.Unlock()
+	if m.latency == nil {
+		m.latency = make(map[string]LatencySummary)
+	}
+	summary := m.latency[endpoint]
+	summary.Count++
+	summary.Total += d
+	m.latency[endpoint] = summary
+}
+
+// observeRequest records the latency of one HTTP round trip, labelled by
+// endpoint so slugs and download tokens do not create new series.
+func (g *Guard) observeRequest(u *url.URL, start time.Time) {
+	g.metrics.observeLatency(g.metricsEndpoint(u), time.Since(start))
+}
+
+func (g *Guard) metricsEndpoint(u *url.URL) string {
+	if !g.isServerURL(u) {
+		return "artifact"
+	}
+	_, path, ok := strings.Cut(u.Path, "/api/v1/")
+	if !ok || path == "" {
+		return "other"
+	}
+	segments := strings.Split(path, "/")
+	if len(segments) > 1 {
+		switch segments[1] {
+		case "download", "fetch", "resolve", "catalog", "browse", "enroll", "upload", "upload-url", "release-notes":
+			return segments[0] + "/" + segments[1]
+		}
+	}
+	return segments[0]
+}
+
+// Metrics returns a snapshot of the guard's operational counters.
+func (g *Guard) Metrics() Metrics {
+	// Failures are loaded before attempts, which observeUpdate bumps first,
+	// so a snapshot never has more failures than attempts.
+	updateFailures := g.metrics.updateFailures.Load()
+	m := Metrics{
+		HeartbeatSuccesses: g.metrics.heartbeatSuccesses.Load(),
+		HeartbeatFailures:  g.metrics.heartbeatFailures.Load(),
+		UpdateAttempts:     g.metrics.updateAttempts.Load(),
+		UpdateFailures:     updateFailures,
+		UpdateDuration:     time.Duration(g.metrics.updateNanos.Load()),
+		UpdateBytes:        g.metrics.updateBytes.Load(),
+	}
+	if g.sm != nil {
+		m.State = g.sm.Current()
+	}
+	if state := g.currentLeaseState(); state != nil && state.VerifiedAt != "" {
+		m.LastVerified, _ = time.Parse(time.RFC3339, state.VerifiedAt)
+	}
+
+	g.metrics.latencyMu.Lock()
+	m.APILatency = make(map[string]LatencySummary, len(g.metrics.latency))
+	for endpoint, summary := range g.metrics.latency {
+		m.APILatency[endpoint] = summary
+	}
+	g.metrics.latencyMu.Unlock()
+	return m
+}
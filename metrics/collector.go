@@ -0,0 +1,86 @@
+// Package metrics exports a Guard's operational counters to Prometheus.
+//
+//	prometheus.MustRegister(metrics.NewCollector(guard))
+//
+// It lives in its own package so applications that do not use Prometheus do
+// not link the client library.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+const namespace = "banyanhub_guard"
+
+var states = []sdk.State{sdk.StateInit, sdk.StateActive, sdk.StateGrace, sdk.StateLocked, sdk.StateBanned, sdk.StateDeactivated}
+
+var (
+	heartbeatsDesc = prometheus.NewDesc(namespace+"_heartbeats_total",
+		"Heartbeats sent, by result.", []string{"result"}, nil)
+	stateDesc = prometheus.NewDesc(namespace+"_state",
+		"Current license state; the series for the current state is 1.", []string{"state"}, nil)
+	lastVerifiedDesc = prometheus.NewDesc(namespace+"_seconds_since_last_verification",
+		"Seconds since the server last confirmed the lease.", nil, nil)
+	updatesDesc = prometheus.NewDesc(namespace+"_update_attempts_total",
+		"OTA update attempts, by result.", []string{"result"}, nil)
+	updateDurationDesc = prometheus.NewDesc(namespace+"_update_duration_seconds",
+		"Time spent in OTA update attempts.", nil, nil)
+	updateBytesDesc = prometheus.NewDesc(namespace+"_update_downloaded_bytes_total",
+		"Bytes of update artifacts downloaded.", nil, nil)
+	apiLatencyDesc = prometheus.NewDesc(namespace+"_api_request_duration_seconds",
+		"Latency of requests to the license server and artifact storage, by endpoint.", []string{"endpoint"}, nil)
+)
+
+type collector struct {
+	guard *sdk.Guard
+	now   func() time.Time
+}
+
+// NewCollector returns a prometheus.Collector reading guard.Metrics on every
+// scrape. Wrap the registerer with prometheus.WrapRegistererWith to add
+// labels such as the component when several guards share a process.
+func NewCollector(guard *sdk.Guard) prometheus.Collector {
+	return &collector{guard: guard, now: time.Now}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- heartbeatsDesc
+	ch <- stateDesc
+	ch <- lastVerifiedDesc
+	ch <- updatesDesc
+	ch <- updateDurationDesc
+	ch <- updateBytesDesc
+	ch <- apiLatencyDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	m := c.guard.Metrics()
+
+	ch <- prometheus.MustNewConstMetric(heartbeatsDesc, prometheus.CounterValue, float64(m.HeartbeatSuccesses), "success")
+	ch <- prometheus.MustNewConstMetric(heartbeatsDesc, prometheus.CounterValue, float64(m.HeartbeatFailures), "failure")
+
+	for _, state := range states {
+		value := 0.0
+		if state == m.State {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, value, state.String())
+	}
+
+	if !m.LastVerified.IsZero() {
+		ch <- prometheus.MustNewConstMetric(lastVerifiedDesc, prometheus.GaugeValue, c.now().Sub(m.LastVerified).Seconds())
+	}
+
+	ch <- prometheus.MustNewConstMetric(updatesDesc, prometheus.CounterValue, float64(m.UpdateAttempts-m.UpdateFailures), "success")
+	ch <- prometheus.MustNewConstMetric(updatesDesc, prometheus.CounterValue, float64(m.UpdateFailures), "failure")
+	ch <- prometheus.MustNewConstSummary(updateDurationDesc, m.UpdateAttempts, m.UpdateDuration.Seconds(), nil)
+	ch <- prometheus.MustNewConstMetric(updateBytesDesc, prometheus.CounterValue, float64(m.UpdateBytes))
+
+	for endpoint, latency := range m.APILatency {
+		ch <- prometheus.MustNewConstSummary(apiLatencyDesc, latency.Count, latency.Total.Seconds(), nil, endpoint)
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+func TestCollectorExportsGuardMetrics(t *testing.T) {
+	guard, err := sdk.NewForTesting(sdk.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(NewCollector(guard)); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]bool)
+	for _, family := range families {
+		found[family.GetName()] = true
+		if family.GetName() != namespace+"_state" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			state := metric.GetLabel()[0].GetValue()
+			want := 0.0
+			if state == guard.State().String() {
+				want = 1
+			}
+			if got := metric.GetGauge().GetValue(); got != want {
+				t.Errorf("state %s = %v, want %v", state, got, want)
+			}
+		}
+	}
+	for _, name := range []string{"_heartbeats_total", "_state", "_update_attempts_total", "_update_duration_seconds", "_update_downloaded_bytes_total"} {
+		if !found[namespace+name] {
+			t.Errorf("missing metric %s", namespace+name)
+		}
+	}
+	if found[namespace+"_seconds_since_last_verification"] {
+		t.Error("a never-verified guard must not report time since verification")
+	}
+}
@@ -0,0 +1,87 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMetrics_HeartbeatsAndLastVerified(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body heartbeatRequestBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		resp := heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, resp, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err == nil {
+		t.Fatal("expected the first heartbeat to fail")
+	}
+	fail = false
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+
+	m := guard.Metrics()
+	if m.HeartbeatSuccesses != 1 || m.HeartbeatFailures != 1 {
+		t.Fatalf("heartbeats = %d ok / %d failed, want 1 / 1", m.HeartbeatSuccesses, m.HeartbeatFailures)
+	}
+	if m.State != StateActive {
+		t.Fatalf("state = %s", m.State)
+	}
+	if m.LastVerified.IsZero() || time.Since(m.LastVerified) > time.Minute {
+		t.Fatalf("LastVerified = %v", m.LastVerified)
+	}
+	if latency := m.APILatency["heartbeat"]; latency.Count != 2 {
+		t.Fatalf("heartbeat latency = %#v, want 2 observations", latency)
+	}
+}
+
+func TestMetrics_UpdateCounters(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.finishUpdateStats(&UpdateStats{Component: "a", Bytes: 100}, time.Now(), nil)
+	guard.finishUpdateStats(&UpdateStats{Component: "a", Bytes: 50}, time.Now(), ErrUpdateApply)
+
+	m := guard.Metrics()
+	if m.UpdateAttempts != 2 || m.UpdateFailures != 1 || m.UpdateBytes != 150 {
+		t.Fatalf("unexpected update metrics: %#v", m)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	guard, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {})
+	base, _ := url.Parse(guard.cfg.ServerURL)
+
+	cases := map[string]string{
+		"/api/v1/heartbeat":              "heartbeat",
+		"/api/v1/update/fetch/secret":    "update/fetch",
+		"/api/v1/plugins/reports/update": "plugins",
+		"/api/v1/version/resolve":        "version/resolve",
+		"/healthz":                       "other",
+	}
+	for path, want := range cases {
+		u := *base
+		u.Path = path
+		if got := guard.metricsEndpoint(&u); got != want {
+			t.Errorf("metricsEndpoint(%s) = %q, want %q", path, got, want)
+		}
+	}
+	storage, _ := url.Parse("https://storage.example.com/bucket/artifact.bin")
+	if got := guard.metricsEndpoint(storage); got != "artifact" {
+		t.Errorf("storage endpoint = %q", got)
+	}
+}
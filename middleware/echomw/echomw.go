@@ -0,0 +1,36 @@
+// Package echomw adapts the middleware package to echo.
+//
+//	e.Use(echomw.Require(guard))
+package echomw
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/iwen-conf/BanyanHub-SDK/middleware"
+)
+
+// Require rejects requests while guard.Check fails; see middleware.Require.
+func Require(guard middleware.Guard) echo.MiddlewareFunc {
+	return RequireWithOptions(guard, middleware.Options{})
+}
+
+// RequireWithOptions is Require with a custom skip rule or deny response.
+func RequireWithOptions(guard middleware.Guard, opts middleware.Options) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			if opts.Skip != nil && opts.Skip(r) {
+				return next(c)
+			}
+			if err := middleware.Gate(guard, c.Response().Header()); err != nil {
+				if opts.Deny != nil {
+					opts.Deny(c.Response(), r, err)
+				} else {
+					middleware.WriteDenied(c.Response(), err)
+				}
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
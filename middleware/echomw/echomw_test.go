@@ -0,0 +1,43 @@
+package echomw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+	"github.com/iwen-conf/BanyanHub-SDK/middleware"
+)
+
+type fakeGuard struct {
+	err   error
+	state sdk.State
+}
+
+func (g fakeGuard) Check() error       { return g.err }
+func (g fakeGuard) Status() sdk.Status { return sdk.Status{State: g.state} }
+
+func TestRequireRejectsWhileBanned(t *testing.T) {
+	for _, tc := range []struct {
+		guard fakeGuard
+		code  int
+	}{
+		{fakeGuard{state: sdk.StateActive}, http.StatusNoContent},
+		{fakeGuard{err: sdk.ErrBanned, state: sdk.StateBanned}, http.StatusPaymentRequired},
+	} {
+		e := echo.New()
+		e.Use(Require(tc.guard))
+		e.GET("/api", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api", nil))
+		if rec.Code != tc.code {
+			t.Fatalf("%s: status = %d, want %d", tc.guard.state, rec.Code, tc.code)
+		}
+		if got := rec.Header().Get(middleware.HeaderState); got != tc.guard.state.String() {
+			t.Fatalf("%s: state header = %q", tc.guard.state, got)
+		}
+	}
+}
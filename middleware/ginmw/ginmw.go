@@ -0,0 +1,35 @@
+// Package ginmw adapts the middleware package to gin.
+//
+//	router.Use(ginmw.Require(guard))
+package ginmw
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/iwen-conf/BanyanHub-SDK/middleware"
+)
+
+// Require aborts requests while guard.Check fails; see middleware.Require.
+func Require(guard middleware.Guard) gin.HandlerFunc {
+	return RequireWithOptions(guard, middleware.Options{})
+}
+
+// RequireWithOptions is Require with a custom skip rule or deny response.
+func RequireWithOptions(guard middleware.Guard, opts middleware.Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Skip != nil && opts.Skip(c.Request) {
+			c.Next()
+			return
+		}
+		if err := middleware.Gate(guard, c.Writer.Header()); err != nil {
+			if opts.Deny != nil {
+				opts.Deny(c.Writer, c.Request, err)
+			} else {
+				middleware.WriteDenied(c.Writer, err)
+			}
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package ginmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+	"github.com/iwen-conf/BanyanHub-SDK/middleware"
+)
+
+type fakeGuard struct {
+	err   error
+	state sdk.State
+}
+
+func (g fakeGuard) Check() error       { return g.err }
+func (g fakeGuard) Status() sdk.Status { return sdk.Status{State: g.state} }
+
+func TestRequireAbortsWhileLocked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		guard fakeGuard
+		code  int
+	}{
+		{fakeGuard{state: sdk.StateActive}, http.StatusNoContent},
+		{fakeGuard{err: sdk.ErrLocked, state: sdk.StateLocked}, http.StatusLocked},
+	} {
+		router := gin.New()
+		router.Use(Require(tc.guard))
+		router.GET("/api", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api", nil))
+		if rec.Code != tc.code {
+			t.Fatalf("%s: status = %d, want %d", tc.guard.state, rec.Code, tc.code)
+		}
+		if got := rec.Header().Get(middleware.HeaderState); got != tc.guard.state.String() {
+			t.Fatalf("%s: state header = %q", tc.guard.state, got)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package grpcmw gates gRPC server calls on a Guard's license state and
+// entitlements.
+//
+//	srv := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(grpcmw.UnaryServerInterceptor(guard, opts)),
+//		grpc.ChainStreamInterceptor(grpcmw.StreamServerInterceptor(guard, opts)),
+//	)
+//
+// Calls proceed while Guard.Check succeeds and the guard grants the feature
+// the method requires, if any. Rejected calls fail with FailedPrecondition
+// for a locked license, Unavailable before the first verification and
+// PermissionDenied for a banned, deactivated or unlicensed guard or a
+// missing feature; the x-license-error trailer names the problem. Every
+// call gets the license state in its header metadata, like the HTTP
+// middleware headers.
+package grpcmw
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+	"github.com/iwen-conf/BanyanHub-SDK/middleware"
+)
+
+// Metadata keys set by the interceptors.
+const (
+	MetadataState          = "x-license-state"
+	MetadataGraceDeadline  = "x-license-grace-deadline"
+	MetadataGraceRemaining = "x-license-grace-remaining"
+	MetadataError          = "x-license-error"
+	MetadataFeature        = "x-license-feature"
+)
+
+// Values of the x-license-error trailer.
+const (
+	ErrorLocked             = "locked"
+	ErrorBanned             = "banned"
+	ErrorDeactivated        = "deactivated"
+	ErrorNotActivated       = "not_activated"
+	ErrorUnlicensed         = "unlicensed"
+	ErrorFeatureNotEntitled = "feature_not_entitled"
+)
+
+// Guard is the part of sdk.GuardAPI the interceptors need.
+type Guard interface {
+	middleware.Guard
+	CheckFeatureMatrix(names ...string) map[string]sdk.FeatureStatus
+}
+
+// Options configures the interceptors. The zero value gates every method on
+// Guard.Check alone.
+type Options struct {
+	// Features maps a full method name ("/pkg.Service/Method") or a service
+	// prefix ending in a slash ("/pkg.Service/") to the feature a call
+	// requires. An exact method entry wins over its service entry.
+	Features map[string]string
+	// Skip lets a method through without a check, e.g. the health service.
+	Skip func(fullMethod string) bool
+}
+
+// UnaryServerInterceptor rejects unary calls while the guard is unlicensed
+// or does not grant the method's feature.
+func UnaryServerInterceptor(guard Guard, opts Options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if opts.Skip != nil && opts.Skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		header, err := authorize(guard, opts, info.FullMethod)
+		_ = grpc.SetHeader(ctx, header)
+		if err != nil {
+			_ = grpc.SetTrailer(ctx, errorTrailer(err))
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls. The
+// check runs once, when the stream opens.
+func StreamServerInterceptor(guard Guard, opts Options) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if opts.Skip != nil && opts.Skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		header, err := authorize(guard, opts, info.FullMethod)
+		_ = ss.SetHeader(header)
+		if err != nil {
+			ss.SetTrailer(errorTrailer(err))
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// licenseError is a rejection with the trailer values that describe it.
+type licenseError struct {
+	status  *status.Status
+	code    string
+	feature string
+}
+
+func (e *licenseError) Error() string              { return e.status.Err().Error() }
+func (e *licenseError) GRPCStatus() *status.Status { return e.status }
+
+// authorize decides a call and returns the state metadata for its header.
+func authorize(guard Guard, opts Options, fullMethod string) (metadata.MD, error) {
+	err := guard.Check()
+	header := stateMetadata(guard.Status())
+	if err != nil {
+		code, name := checkCode(err)
+		return header, &licenseError{status: status.New(code, "license: "+err.Error()), code: name}
+	}
+	feature := requiredFeature(opts.Features, fullMethod)
+	if feature == "" {
+		return header, nil
+	}
+	if fs := guard.CheckFeatureMatrix(feature)[feature]; !fs.Enabled {
+		msg := "license: feature " + feature + " is not enabled (" + string(fs.Reason) + ")"
+		return header, &licenseError{status: status.New(codes.PermissionDenied, msg), code: ErrorFeatureNotEntitled, feature: feature}
+	}
+	return header, nil
+}
+
+func checkCode(err error) (codes.Code, string) {
+	switch {
+	case errors.Is(err, sdk.ErrLocked):
+		return codes.FailedPrecondition, ErrorLocked
+	case errors.Is(err, sdk.ErrNotActivated):
+		return codes.Unavailable, ErrorNotActivated
+	case errors.Is(err, sdk.ErrBanned):
+		return codes.PermissionDenied, ErrorBanned
+	case errors.Is(err, sdk.ErrDeactivated):
+		return codes.PermissionDenied, ErrorDeactivated
+	default:
+		return codes.PermissionDenied, ErrorUnlicensed
+	}
+}
+
+func requiredFeature(features map[string]string, fullMethod string) string {
+	if feature, ok := features[fullMethod]; ok {
+		return feature
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		return features[fullMethod[:i+1]]
+	}
+	return ""
+}
+
+func stateMetadata(status sdk.Status) metadata.MD {
+	md := metadata.Pairs(MetadataState, status.State.String())
+	if status.Grace != nil {
+		md.Set(MetadataGraceDeadline, status.Grace.Deadline.UTC().Format(time.RFC3339))
+		md.Set(MetadataGraceRemaining, strconv.FormatInt(int64(status.Grace.Remaining/time.Second), 10))
+	}
+	return md
+}
+
+func errorTrailer(err error) metadata.MD {
+	var le *licenseError
+	if !errors.As(err, &le) {
+		return nil
+	}
+	md := metadata.Pairs(MetadataError, le.code)
+	if le.feature != "" {
+		md.Set(MetadataFeature, le.feature)
+	}
+	return md
+}
@@ -0,0 +1,168 @@
+package grpcmw
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+type fakeGuard struct {
+	err      error
+	status   sdk.Status
+	features map[string]bool
+}
+
+func (g fakeGuard) Check() error       { return g.err }
+func (g fakeGuard) Status() sdk.Status { return g.status }
+
+func (g fakeGuard) CheckFeatureMatrix(names ...string) map[string]sdk.FeatureStatus {
+	matrix := make(map[string]sdk.FeatureStatus, len(names))
+	for _, name := range names {
+		if g.features[name] {
+			matrix[name] = sdk.FeatureStatus{Enabled: true, Reason: sdk.FeatureEntitled}
+		} else {
+			matrix[name] = sdk.FeatureStatus{Reason: sdk.FeatureNotEntitled}
+		}
+	}
+	return matrix
+}
+
+// transportStream records the metadata an interceptor sets.
+type transportStream struct {
+	method  string
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *transportStream) Method() string { return s.method }
+func (s *transportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+func (s *transportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+func (s *transportStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func callUnary(guard Guard, opts Options, method string) (*transportStream, bool, error) {
+	stream := &transportStream{method: method}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	called := false
+	_, err := UnaryServerInterceptor(guard, opts)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req any) (any, error) {
+			called = true
+			return nil, nil
+		})
+	return stream, called, err
+}
+
+func TestUnaryInterceptorRejectsUnlicensedStates(t *testing.T) {
+	cases := []struct {
+		err  error
+		code codes.Code
+		name string
+	}{
+		{sdk.ErrLocked, codes.FailedPrecondition, ErrorLocked},
+		{sdk.ErrBanned, codes.PermissionDenied, ErrorBanned},
+		{sdk.ErrDeactivated, codes.PermissionDenied, ErrorDeactivated},
+		{sdk.ErrNotActivated, codes.Unavailable, ErrorNotActivated},
+	}
+	for _, tc := range cases {
+		stream, called, err := callUnary(fakeGuard{err: tc.err}, Options{}, "/shop.Orders/List")
+		if called {
+			t.Fatalf("%v: handler ran for a rejected call", tc.err)
+		}
+		if got := status.Code(err); got != tc.code {
+			t.Fatalf("%v: code = %s, want %s", tc.err, got, tc.code)
+		}
+		if got := stream.trailer.Get(MetadataError); len(got) != 1 || got[0] != tc.name {
+			t.Fatalf("%v: %s trailer = %v, want %s", tc.err, MetadataError, got, tc.name)
+		}
+	}
+}
+
+func TestUnaryInterceptorChecksMethodFeatures(t *testing.T) {
+	guard := fakeGuard{
+		status: sdk.Status{
+			State: sdk.StateGrace,
+			Grace: &sdk.GraceStatus{Deadline: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Remaining: time.Minute},
+		},
+		features: map[string]bool{"reports": true},
+	}
+	opts := Options{Features: map[string]string{
+		"/shop.Reports/":       "reports",
+		"/shop.Reports/Export": "export",
+	}}
+
+	stream, called, err := callUnary(guard, opts, "/shop.Reports/Daily")
+	if err != nil || !called {
+		t.Fatalf("entitled call: called = %v, err = %v", called, err)
+	}
+	if got := stream.header.Get(MetadataState); len(got) != 1 || got[0] != "GRACE" {
+		t.Fatalf("%s header = %v, want GRACE", MetadataState, got)
+	}
+	if got := stream.header.Get(MetadataGraceRemaining); len(got) != 1 || got[0] != "60" {
+		t.Fatalf("%s header = %v, want 60", MetadataGraceRemaining, got)
+	}
+
+	stream, called, err = callUnary(guard, opts, "/shop.Reports/Export")
+	if called || status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("unentitled call: called = %v, err = %v", called, err)
+	}
+	if got := stream.trailer.Get(MetadataFeature); len(got) != 1 || got[0] != "export" {
+		t.Fatalf("%s trailer = %v, want export", MetadataFeature, got)
+	}
+
+	if _, called, err = callUnary(guard, opts, "/shop.Orders/List"); err != nil || !called {
+		t.Fatalf("ungated call: called = %v, err = %v", called, err)
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *serverStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *serverStream) SetTrailer(md metadata.MD) { s.trailer = metadata.Join(s.trailer, md) }
+
+func TestStreamInterceptorSkipsAndRejects(t *testing.T) {
+	guard := fakeGuard{err: sdk.ErrLocked, status: sdk.Status{State: sdk.StateLocked}}
+	opts := Options{Skip: func(method string) bool { return method == "/grpc.health.v1.Health/Watch" }}
+	interceptor := StreamServerInterceptor(guard, opts)
+
+	for _, tc := range []struct {
+		method string
+		called bool
+		code   codes.Code
+	}{
+		{"/grpc.health.v1.Health/Watch", true, codes.OK},
+		{"/shop.Orders/Watch", false, codes.FailedPrecondition},
+	} {
+		ss := &serverStream{}
+		called := false
+		err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: tc.method}, func(srv any, stream grpc.ServerStream) error {
+			called = true
+			return nil
+		})
+		if called != tc.called || status.Code(err) != tc.code {
+			t.Fatalf("%s: called = %v, err = %v", tc.method, called, err)
+		}
+		if !tc.called && ss.header.Get(MetadataState)[0] != "LOCKED" {
+			t.Fatalf("%s: header = %v", tc.method, ss.header)
+		}
+	}
+}
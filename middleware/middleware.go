@@ -0,0 +1,120 @@
+// Package middleware gates HTTP handlers on a Guard's license state.
+//
+//	mux.Handle("/api/", middleware.Require(guard)(api))
+//
+// Requests pass while Guard.Check succeeds (ACTIVE or GRACE). Otherwise the
+// handler is not called and the client gets 423 Locked for a locked
+// license, 503 Service Unavailable before the first verification and
+// 402 Payment Required for a banned, deactivated or otherwise unlicensed
+// guard. Every response carries the license state in headers, so clients can
+// warn users during the grace period. Adapters for gin and echo live in the
+// ginmw and echomw subpackages, so net/http users do not link either
+// framework.
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+// Response headers describing the license state.
+const (
+	HeaderState          = "X-License-State"
+	HeaderGraceDeadline  = "X-License-Grace-Deadline"
+	HeaderGraceRemaining = "X-License-Grace-Remaining"
+)
+
+// Guard is the part of sdk.GuardAPI the middleware needs.
+type Guard interface {
+	Check() error
+	Status() sdk.Status
+}
+
+// Options customizes Require. The zero value gates every request and
+// writes a JSON error body.
+type Options struct {
+	// Skip lets a request through without a check, e.g. health probes or
+	// the endpoint that shows the license page.
+	Skip func(r *http.Request) bool
+	// Deny writes the response for a failed check. The license headers are
+	// already set; the default writes DeniedBody as JSON with StatusCode.
+	Deny func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// DeniedBody is the default JSON body of a rejected request.
+type DeniedBody struct {
+	Error string `json:"error"`
+	State string `json:"state"`
+}
+
+// Require returns middleware that rejects requests while guard.Check fails.
+func Require(guard Guard) func(http.Handler) http.Handler {
+	return RequireWithOptions(guard, Options{})
+}
+
+// RequireWithOptions is Require with a custom skip rule or deny response.
+func RequireWithOptions(guard Guard, opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Skip != nil && opts.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			err := Gate(guard, w.Header())
+			if err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if opts.Deny != nil {
+				opts.Deny(w, r, err)
+				return
+			}
+			WriteDenied(w, err)
+		})
+	}
+}
+
+// Gate runs guard.Check and sets the license headers on h. Framework
+// adapters use it to share the decision and headers with Require.
+func Gate(guard Guard, h http.Header) error {
+	err := guard.Check()
+	SetHeaders(h, guard.Status())
+	return err
+}
+
+// SetHeaders describes status in h: the state, and while in GRACE the lock
+// deadline (RFC 3339) and the whole seconds left until it.
+func SetHeaders(h http.Header, status sdk.Status) {
+	h.Set(HeaderState, status.State.String())
+	if status.Grace == nil {
+		return
+	}
+	h.Set(HeaderGraceDeadline, status.Grace.Deadline.UTC().Format(time.RFC3339))
+	h.Set(HeaderGraceRemaining, strconv.FormatInt(int64(status.Grace.Remaining/time.Second), 10))
+}
+
+// StatusCode maps a Check error to the HTTP status of a rejected request.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrLocked):
+		return http.StatusLocked
+	case errors.Is(err, sdk.ErrNotActivated):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusPaymentRequired
+	}
+}
+
+// WriteDenied writes the default rejection: StatusCode(err) with a
+// DeniedBody. The state comes from the X-License-State header if set.
+func WriteDenied(w http.ResponseWriter, err error) {
+	body := DeniedBody{Error: err.Error(), State: w.Header().Get(HeaderState)}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(StatusCode(err))
+	_ = json.NewEncoder(w).Encode(body)
+}
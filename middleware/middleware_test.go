@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+type fakeGuard struct {
+	err    error
+	status sdk.Status
+}
+
+func (g fakeGuard) Check() error       { return g.err }
+func (g fakeGuard) Status() sdk.Status { return g.status }
+
+func serve(t *testing.T, guard Guard, opts Options) *httptest.ResponseRecorder {
+	t.Helper()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	rec := httptest.NewRecorder()
+	RequireWithOptions(guard, opts)(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+	return rec
+}
+
+func TestRequirePassesInGraceWithHeaders(t *testing.T) {
+	deadline := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rec := serve(t, fakeGuard{status: sdk.Status{
+		State: sdk.StateGrace,
+		Grace: &sdk.GraceStatus{Deadline: deadline, Remaining: 90*time.Second + 500*time.Millisecond},
+	}}, Options{})
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if got := rec.Header().Get(HeaderState); got != "GRACE" {
+		t.Fatalf("%s = %q, want GRACE", HeaderState, got)
+	}
+	if got := rec.Header().Get(HeaderGraceDeadline); got != "2026-01-02T03:04:05Z" {
+		t.Fatalf("%s = %q", HeaderGraceDeadline, got)
+	}
+	if got := rec.Header().Get(HeaderGraceRemaining); got != "90" {
+		t.Fatalf("%s = %q, want 90", HeaderGraceRemaining, got)
+	}
+}
+
+func TestRequireRejectsUnlicensedStates(t *testing.T) {
+	cases := []struct {
+		err   error
+		state sdk.State
+		code  int
+	}{
+		{sdk.ErrLocked, sdk.StateLocked, http.StatusLocked},
+		{sdk.ErrBanned, sdk.StateBanned, http.StatusPaymentRequired},
+		{sdk.ErrDeactivated, sdk.StateDeactivated, http.StatusPaymentRequired},
+		{sdk.ErrNotActivated, sdk.StateInit, http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		rec := serve(t, fakeGuard{err: tc.err, status: sdk.Status{State: tc.state}}, Options{})
+		if rec.Code != tc.code {
+			t.Fatalf("%v: status = %d, want %d", tc.err, rec.Code, tc.code)
+		}
+		var body DeniedBody
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.State != tc.state.String() || body.Error != tc.err.Error() {
+			t.Fatalf("%v: body = %+v", tc.err, body)
+		}
+	}
+}
+
+func TestRequireWithOptionsSkipAndDeny(t *testing.T) {
+	guard := fakeGuard{err: sdk.ErrLocked, status: sdk.Status{State: sdk.StateLocked}}
+
+	rec := serve(t, guard, Options{Skip: func(r *http.Request) bool { return true }})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("skipped request status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	rec = serve(t, guard, Options{Deny: func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Redirect(w, r, "/license", http.StatusFound)
+	}})
+	if rec.Code != http.StatusFound || rec.Header().Get(HeaderState) != "LOCKED" {
+		t.Fatalf("custom deny: status = %d, state header = %q", rec.Code, rec.Header().Get(HeaderState))
+	}
+}
@@ -0,0 +1,58 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+)
+
+// MigrationPolicy decides what happens to an update whose Migrate step
+// fails.
+type MigrationPolicy int
+
+const (
+	// MigrationAbort stops the update before the binary is swapped, so the
+	// previous version keeps running against the unmigrated data.
+	MigrationAbort MigrationPolicy = iota
+	// MigrationContinue logs the failure and installs the new version
+	// anyway, for migrations the new version can finish on its own.
+	MigrationContinue
+)
+
+type migrationProgressKey struct{}
+
+// ReportMigrationProgress reports how far a running Migrate hook got, as a
+// fraction from 0 to 1, through OTAConfig.OnUpdateProgress in the
+// "migrating" stage. It does nothing outside a Migrate hook.
+func ReportMigrationProgress(ctx context.Context, fraction float64) {
+	if report, ok := ctx.Value(migrationProgressKey{}).(func(float64)); ok {
+		report(min(max(fraction, 0), 1))
+	}
+}
+
+// runMigration runs mc.Migrate between verification and apply. It returns
+// an error wrapping ErrUpdateMigrate when the update must stop there.
+func (g *Guard) runMigration(ctx context.Context, mc ManagedComponent, event LifecycleEvent) error {
+	if mc.Migrate == nil {
+		return nil
+	}
+	progress := func(fraction float64) {
+		if g.cfg.OTA.OnUpdateProgress != nil {
+			g.cfg.OTA.OnUpdateProgress(mc.Slug, "migrating", 0.7+0.1*fraction)
+		}
+	}
+	progress(0)
+
+	g.logger.Info("running migration", "component", mc.Slug, "from", event.OldVersion, "to", event.NewVersion)
+	err := mc.Migrate(context.WithValue(ctx, migrationProgressKey{}, progress), event.OldVersion, event.NewVersion)
+	if err == nil {
+		progress(1)
+		return nil
+	}
+	wrapped := fmt.Errorf("%w: %s -> %s: %v", ErrUpdateMigrate, event.OldVersion, event.NewVersion, err)
+	if mc.MigrationFailure == MigrationContinue {
+		g.logger.Warn("migration failed, installing anyway", "component", mc.Slug, "error", err)
+		g.reportUpdateFailure(mc.Slug, wrapped)
+		return nil
+	}
+	return wrapped
+}
@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFailedMigrationAbortsBeforeBinarySwap(t *testing.T) {
+	server, pubKey := newBinaryUpdateServer(t, []byte("new binary"))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.managedVersions["worker"] = "1.0.0"
+	var stages []string
+	g.cfg.OTA.OnUpdateProgress = func(component, stage string, progress float64) {
+		stages = append(stages, stage)
+	}
+
+	target := filepath.Join(t.TempDir(), "worker")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var from, to string
+	mc := ManagedComponent{Slug: "worker", Dir: target, Migrate: func(ctx context.Context, fromVersion, toVersion string) error {
+		from, to = fromVersion, toVersion
+		return errors.New("column already exists")
+	}}
+	err := g.updateManagedBackend(context.Background(), mc, updateInfo{Component: "worker", Latest: "2.0.0"})
+	if !errors.Is(err, ErrUpdateMigrate) {
+		t.Fatalf("err = %v, want ErrUpdateMigrate", err)
+	}
+	if from != "1.0.0" || to != "2.0.0" {
+		t.Fatalf("Migrate got %s -> %s", from, to)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old binary" {
+		t.Fatalf("target = %q, want the binary left in place", data)
+	}
+	if v := g.currentManagedVersion("worker"); v != "1.0.0" {
+		t.Fatalf("version = %s, want 1.0.0", v)
+	}
+	for _, stage := range stages {
+		if stage == "applying" {
+			t.Fatalf("stages = %v, the update must stop before applying", stages)
+		}
+	}
+	if len(g.updateHistory) != 1 || g.updateHistory[0].MigrateDuration <= 0 {
+		t.Fatalf("update history = %+v, want the migration timed", g.updateHistory)
+	}
+}
+
+func TestMigrationContinuePolicyInstallsAndReportsProgress(t *testing.T) {
+	server, pubKey := newBinaryUpdateServer(t, []byte("new binary"))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.managedVersions["worker"] = "1.0.0"
+	var migrating []float64
+	g.cfg.OTA.OnUpdateProgress = func(component, stage string, progress float64) {
+		if stage == "migrating" {
+			migrating = append(migrating, progress)
+		}
+	}
+
+	target := filepath.Join(t.TempDir(), "worker")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mc := ManagedComponent{
+		Slug: "worker",
+		Dir:  target,
+		Migrate: func(ctx context.Context, fromVersion, toVersion string) error {
+			ReportMigrationProgress(ctx, 0.5)
+			return errors.New("index build deferred")
+		},
+		MigrationFailure: MigrationContinue,
+	}
+	if err := g.updateManagedBackend(context.Background(), mc, updateInfo{Component: "worker", Latest: "2.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new binary" {
+		t.Fatalf("target = %q, want the new binary", data)
+	}
+	if len(migrating) != 2 || migrating[0] != 0.7 || migrating[1] != 0.75 {
+		t.Fatalf("migrating progress = %v, want [0.7 0.75]", migrating)
+	}
+}
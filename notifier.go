@@ -0,0 +1,106 @@
+package sdk
+
+import "fmt"
+
+// UpdateNotificationKind identifies the update milestone being announced.
+type UpdateNotificationKind int
+
+const (
+	UpdateNotificationAvailable UpdateNotificationKind = iota
+	UpdateNotificationInstalled
+	UpdateNotificationFailed
+)
+
+func (k UpdateNotificationKind) String() string {
+	switch k {
+	case UpdateNotificationAvailable:
+		return "available"
+	case UpdateNotificationInstalled:
+		return "installed"
+	case UpdateNotificationFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// UpdateNotification describes one update milestone for a component.
+type UpdateNotification struct {
+	Kind       UpdateNotificationKind
+	Component  string
+	OldVersion string
+	NewVersion string
+	Mandatory  bool
+	Err        error
+}
+
+// UpdateNotifier surfaces update milestones to the end user, typically as a
+// native desktop notification. See NewDesktopNotifier for the reference
+// implementations.
+type UpdateNotifier interface {
+	Notify(n UpdateNotification) error
+}
+
+// UpdateNotifierFunc adapts a plain function to UpdateNotifier.
+type UpdateNotifierFunc func(n UpdateNotification) error
+
+func (f UpdateNotifierFunc) Notify(n UpdateNotification) error {
+	return f(n)
+}
+
+func (g *Guard) notifyUpdate(n UpdateNotification) {
+	g.publishUpdateEvent(n)
+	g.recordPluginUpdate(n)
+	if g.cfg.OTA.Notifier == nil {
+		return
+	}
+	if err := g.cfg.OTA.Notifier.Notify(n); err != nil {
+		g.logger.Warn("update notifier failed", "component", n.Component, "kind", n.Kind.String(), "error", err)
+	}
+}
+
+// notifyUpdateAvailable announces u unless the version it offers was
+// already announced for its component.
+func (g *Guard) notifyUpdateAvailable(u updateInfo) {
+	g.mu.Lock()
+	if SameVersion(g.announcedUpdates[u.Component], u.Latest) {
+		g.mu.Unlock()
+		return
+	}
+	if g.announcedUpdates == nil {
+		g.announcedUpdates = make(map[string]string)
+	}
+	g.announcedUpdates[u.Component] = u.Latest
+	g.mu.Unlock()
+
+	g.notifyUpdate(UpdateNotification{
+		Kind:       UpdateNotificationAvailable,
+		Component:  u.Component,
+		OldVersion: u.Current,
+		NewVersion: u.Latest,
+		Mandatory:  u.Mandatory,
+	})
+}
+
+// desktopNotificationText renders the title and body shown by the desktop
+// notifiers.
+func desktopNotificationText(appName string, n UpdateNotification) (title, body string) {
+	title = appName
+	if title == "" {
+		title = n.Component
+	}
+	switch n.Kind {
+	case UpdateNotificationAvailable:
+		body = fmt.Sprintf("Update available: %s %s", n.Component, n.NewVersion)
+		if n.Mandatory {
+			body += " (required)"
+		}
+	case UpdateNotificationInstalled:
+		body = fmt.Sprintf("%s updated to %s", n.Component, n.NewVersion)
+	case UpdateNotificationFailed:
+		body = fmt.Sprintf("%s update to %s failed", n.Component, n.NewVersion)
+	default:
+		body = n.Component
+	}
+	return title, body
+}
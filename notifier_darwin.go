@@ -0,0 +1,33 @@
+//go:build darwin
+
+package sdk
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type notificationCenterNotifier struct {
+	appName string
+}
+
+// NewDesktopNotifier returns an UpdateNotifier that posts to the macOS
+// Notification Center through osascript.
+func NewDesktopNotifier(appName string) UpdateNotifier {
+	return &notificationCenterNotifier{appName: appName}
+}
+
+func (n *notificationCenterNotifier) Notify(note UpdateNotification) error {
+	title, body := desktopNotificationText(n.appName, note)
+	script := "display notification " + appleScriptString(body) + " with title " + appleScriptString(title)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, "osascript", "-e", script).Run()
+}
+
+func appleScriptString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
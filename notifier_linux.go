@@ -0,0 +1,36 @@
+//go:build linux
+
+package sdk
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+type libnotifyNotifier struct {
+	appName string
+}
+
+// NewDesktopNotifier returns an UpdateNotifier that raises libnotify
+// notifications through notify-send.
+func NewDesktopNotifier(appName string) UpdateNotifier {
+	return &libnotifyNotifier{appName: appName}
+}
+
+func (n *libnotifyNotifier) Notify(note UpdateNotification) error {
+	title, body := desktopNotificationText(n.appName, note)
+	urgency := "normal"
+	if note.Kind == UpdateNotificationFailed || note.Mandatory {
+		urgency = "critical"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	args := []string{"--urgency=" + urgency}
+	if n.appName != "" {
+		args = append(args, "--app-name="+n.appName)
+	}
+	args = append(args, title, body)
+	return exec.CommandContext(ctx, "notify-send", args...).Run()
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+
+package sdk
+
+import "errors"
+
+var errDesktopNotifierUnsupported = errors.New("desktop notifications are not supported on this platform")
+
+type unsupportedNotifier struct{}
+
+// NewDesktopNotifier returns an UpdateNotifier that always fails on platforms
+// without a reference desktop notification backend.
+func NewDesktopNotifier(appName string) UpdateNotifier {
+	return unsupportedNotifier{}
+}
+
+func (unsupportedNotifier) Notify(UpdateNotification) error {
+	return errDesktopNotifierUnsupported
+}
@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNotifier_ReceivesAvailableAndFailureMilestones(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+
+	var got []UpdateNotification
+	guard.cfg.OTA.Notifier = UpdateNotifierFunc(func(n UpdateNotification) error {
+		got = append(got, n)
+		return nil
+	})
+
+	guard.handleUpdateNotification(context.Background(), updateInfo{Component: "backend", Current: "1.0.0", Latest: "1.1.0", UpdateAvailable: true, Mandatory: true})
+	guard.notifyUpdateFailure("backend", "1.0.0", "1.1.0", ErrUpdateVerify)
+	guard.notifyUpdateFailure("backend", "1.0.0", "1.1.0", ErrUpdateConcurrent)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 notifications, got %#v", got)
+	}
+	if got[0].Kind != UpdateNotificationAvailable || got[0].NewVersion != "1.1.0" || !got[0].Mandatory {
+		t.Fatalf("unexpected available notification: %#v", got[0])
+	}
+	if got[1].Kind != UpdateNotificationFailed || !errors.Is(got[1].Err, ErrUpdateVerify) {
+		t.Fatalf("unexpected failure notification: %#v", got[1])
+	}
+}
+
+func TestNotifier_SkipsPolicyBlockedVersions(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.cfg.OTA.IgnoredVersions = map[string][]string{"backend": {"1.1.0"}}
+
+	called := false
+	guard.cfg.OTA.Notifier = UpdateNotifierFunc(func(UpdateNotification) error {
+		called = true
+		return nil
+	})
+
+	guard.handleUpdateNotification(context.Background(), updateInfo{Component: "backend", Current: "1.0.0", Latest: "1.1.0", UpdateAvailable: true})
+	if called {
+		t.Fatal("ignored version must not be announced")
+	}
+}
+
+func TestDesktopNotificationText(t *testing.T) {
+	title, body := desktopNotificationText("Acme", UpdateNotification{Kind: UpdateNotificationAvailable, Component: "backend", NewVersion: "2.0.0", Mandatory: true})
+	if title != "Acme" || !strings.Contains(body, "2.0.0") || !strings.Contains(body, "required") {
+		t.Fatalf("unexpected text: %q / %q", title, body)
+	}
+	title, _ = desktopNotificationText("", UpdateNotification{Kind: UpdateNotificationInstalled, Component: "backend"})
+	if title != "backend" {
+		t.Fatalf("expected component as fallback title, got %q", title)
+	}
+}
+
+func TestNotifier_AnnouncesEachOfferedVersionOnce(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+
+	var got []string
+	guard.cfg.OTA.Notifier = UpdateNotifierFunc(func(n UpdateNotification) error {
+		if n.Kind == UpdateNotificationAvailable {
+			got = append(got, n.Component+"@"+n.NewVersion)
+		}
+		return nil
+	})
+
+	offer := func(component, version string) updateInfo {
+		return updateInfo{Component: component, Current: "1.0.0", Latest: version, UpdateAvailable: true, Group: "r2"}
+	}
+	for range 3 {
+		guard.handleUpdateNotification(context.Background(), offer("backend", "1.1.0"))
+		guard.handleReleaseGroup(context.Background(), "r2", []updateInfo{offer("site", "2.0.0")})
+	}
+	guard.handleUpdateNotification(context.Background(), offer("backend", "1.2.0"))
+
+	want := "backend@1.1.0 site@2.0.0 backend@1.2.0"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("announced %v, want %s", got, want)
+	}
+}
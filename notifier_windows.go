@@ -0,0 +1,48 @@
+//go:build windows
+
+package sdk
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// toastScript reads its text from the environment so titles and bodies never
+// need PowerShell quoting.
+const toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:BANYANHUB_TOAST_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:BANYANHUB_TOAST_BODY)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:BANYANHUB_TOAST_APP).Show($toast)`
+
+type toastNotifier struct {
+	appName string
+}
+
+// NewDesktopNotifier returns an UpdateNotifier that shows Windows toast
+// notifications through PowerShell.
+func NewDesktopNotifier(appName string) UpdateNotifier {
+	return &toastNotifier{appName: appName}
+}
+
+func (n *toastNotifier) Notify(note UpdateNotification) error {
+	title, body := desktopNotificationText(n.appName, note)
+	appID := n.appName
+	if appID == "" {
+		appID = "BanyanHub"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.Env = append(os.Environ(),
+		"BANYANHUB_TOAST_TITLE="+title,
+		"BANYANHUB_TOAST_BODY="+body,
+		"BANYANHUB_TOAST_APP="+appID,
+	)
+	return cmd.Run()
+}
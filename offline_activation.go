@@ -0,0 +1,186 @@
+package sdk
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	offlineActivationSecretName = "offline-activation"
+	offlineActivationFormat     = 1
+)
+
+// offlineActivationRequest is the file an air-gapped machine hands to the
+// vendor. It is not signed: the machine holds no secret the file does not
+// already carry, so the vendor portal checks the code itself. What protects
+// activation is the response, whose lease and reply are signed with the
+// vendor ed25519 key and bound to this request's sealed nonce.
+type offlineActivationRequest struct {
+	Format        int               `json:"format"`
+	Code          string            `json:"code"`
+	Organization  string            `json:"organization"`
+	Email         string            `json:"email,omitempty"`
+	ProjectSlug   string            `json:"project_slug"`
+	ComponentSlug string            `json:"component_slug"`
+	MachineID     string            `json:"machine_id"`
+	AuxSignals    map[string]string `json:"aux_signals"`
+	Hostname      string            `json:"hostname"`
+	OS            string            `json:"os"`
+	Arch          string            `json:"arch"`
+	Nonce         string            `json:"nonce"`
+	CreatedAt     string            `json:"created_at"`
+}
+
+// GenerateActivationRequest builds an activation request file for a machine
+// without access to the license server. It carries this machine's
+// fingerprint and the activation code for cfg.ProjectSlug and
+// cfg.ComponentSlug; cfg.LicenseKey may be empty. Take the file to the
+// vendor portal and pass the response file it issues to
+// ApplyActivationResponse on the same machine. Generating a new request
+// invalidates any earlier one.
+func GenerateActivationRequest(cfg Config, code, organization, email string) ([]byte, error) {
+	fp, err := collectFingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("collect fingerprint: %w", err)
+	}
+	return generateActivationRequest(cfg, fp.withPrivacy(cfg.Fingerprint), code, organization, email, time.Now())
+}
+
+func generateActivationRequest(cfg Config, fp *Fingerprint, code, organization, email string, now time.Time) ([]byte, error) {
+	if code == "" {
+		return nil, fmt.Errorf("activation code is required")
+	}
+	if organization == "" {
+		return nil, fmt.Errorf("organization is required")
+	}
+	if cfg.ProjectSlug == "" || cfg.ComponentSlug == "" {
+		return nil, fmt.Errorf("project and component slugs are required")
+	}
+
+	nonce, err := randomNonce()
+	if err != nil {
+		return nil, err
+	}
+	req := offlineActivationRequest{
+		Format:        offlineActivationFormat,
+		Code:          code,
+		Organization:  organization,
+		Email:         email,
+		ProjectSlug:   cfg.ProjectSlug,
+		ComponentSlug: cfg.ComponentSlug,
+		MachineID:     fp.MachineID(),
+		AuxSignals:    fp.AuxSignals(),
+		Hostname:      hostname(),
+		OS:            fp.auxSignals["os"],
+		Arch:          fp.auxSignals["arch"],
+		Nonce:         nonce,
+		CreatedAt:     now.UTC().Format(time.RFC3339),
+	}
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	// The nonce is kept sealed on this machine so only the response to this
+	// request can be applied, and only once.
+	if err := newSecretStore(cfg, fp).Save(offlineActivationSecretName, []byte(nonce)); err != nil {
+		return nil, fmt.Errorf("save pending activation: %w", err)
+	}
+	return data, nil
+}
+
+// ApplyActivationResponse installs the signed license in a response file
+// issued for the pending GenerateActivationRequest, completing activation
+// without contacting the server. cfg must carry the same PublicKeyPEM,
+// ProjectSlug and ComponentSlug the guard will use. The returned license key
+// goes into Config.LicenseKey; the guard then starts from the installed
+// lease, which counts as confirmed now for Config.LicenseCacheTTL.
+func ApplyActivationResponse(cfg Config, response []byte) (*ActivationResult, error) {
+	fp, err := collectFingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("collect fingerprint: %w", err)
+	}
+	return applyActivationResponse(cfg, fp, response, time.Now())
+}
+
+func applyActivationResponse(cfg Config, fp *Fingerprint, response []byte, now time.Time) (*ActivationResult, error) {
+	if cfg.PublicKeyPEM == nil {
+		return nil, fmt.Errorf("public_key_pem is required")
+	}
+	if cfg.ProjectSlug == "" || cfg.ComponentSlug == "" {
+		return nil, fmt.Errorf("project and component slugs are required")
+	}
+	publicKeys, err := decodePublicKeys(cfg.PublicKeyPEM, cfg.LegacyPublicKeysPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp verifyResponse
+	if err := json.Unmarshal(response, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	if resp.Error != "" {
+		apiErr := newAPIError(http.StatusOK, resp.Error, resp.Message, "")
+		apiErr.Cause = mapVerifyError(resp.Error)
+		return nil, apiErr
+	}
+	if len(resp.Lease) == 0 || resp.LeaseSignature == "" || resp.ResponseSignature == "" {
+		return nil, ErrInvalidServerResponse
+	}
+
+	secrets := newSecretStore(cfg, fp)
+	pending, err := secrets.Load(offlineActivationSecretName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: no pending activation request", ErrActivationResponseInvalid)
+		}
+		return nil, err
+	}
+	if !hmac.Equal([]byte(resp.Nonce), pending) {
+		return nil, ErrActivationResponseInvalid
+	}
+	if err := verifyResponseSignature(resp, publicKeys); err != nil {
+		return nil, ErrActivationResponseInvalid
+	}
+
+	leaseValue, err := parseAndVerifyLease(resp.Lease, resp.LeaseSignature, publicKeys, fp.MachineID(), now, "")
+	if err != nil {
+		return nil, err
+	}
+	if leaseValue.ProjectSlug != cfg.ProjectSlug {
+		return nil, ErrProjectNotAuthorized
+	}
+	if leaseValue.ComponentSlug != "" && leaseValue.ComponentSlug != cfg.ComponentSlug {
+		return nil, ErrProjectNotAuthorized
+	}
+	canonical, err := canonicalJSONFromLease(leaseValue)
+	if err != nil {
+		return nil, err
+	}
+	verifiedAt := now.UTC().Format(time.RFC3339)
+	state := &persistedState{
+		Lease:          leaseValue,
+		LeaseCanonical: canonical,
+		LeaseSignature: resp.LeaseSignature,
+		Watermark:      leaseValue.ServerTime,
+		VerifiedAt:     verifiedAt,
+		ClockHighWater: verifiedAt,
+	}
+	if err := newPersistentStateStore(cfg, fp).Save(state); err != nil {
+		return nil, err
+	}
+	if err := secrets.Delete(offlineActivationSecretName); err != nil {
+		return nil, err
+	}
+
+	return &ActivationResult{
+		LicenseKey:  leaseValue.LicenseKey,
+		ProjectSlug: leaseValue.ProjectSlug,
+		ExpiresAt:   leaseValue.ExpiresAt,
+	}, nil
+}
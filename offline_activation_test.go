@@ -0,0 +1,138 @@
+package sdk
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newOfflineActivationTest(t *testing.T) (Config, *Fingerprint, ed25519.PrivateKey) {
+	t.Helper()
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{
+		PublicKeyPEM:  pemEncodePublicKey(pubKey),
+		ProjectSlug:   "test-project",
+		ComponentSlug: "backend",
+		cacheDir:      t.TempDir(),
+	}
+	fp := &Fingerprint{machineID: testingMachineID, auxSignals: map[string]string{"os": "linux", "arch": "amd64"}}
+	return cfg, fp, privKey
+}
+
+func offlineActivationResponseFor(t *testing.T, privKey ed25519.PrivateKey, requestFile []byte) []byte {
+	t.Helper()
+	var req offlineActivationRequest
+	if err := json.Unmarshal(requestFile, &req); err != nil {
+		t.Fatal(err)
+	}
+	return offlineActivationResponseWithLease(t, privKey, req.Nonce, testLease(req.MachineID))
+}
+
+func offlineActivationResponseWithLease(t *testing.T, privKey ed25519.PrivateKey, nonce string, leaseValue *lease) []byte {
+	t.Helper()
+	leaseJSON, leaseSig := signedLeaseJSON(t, privKey, leaseValue)
+	resp := signVerifyResponse(t, privKey, verifyResponse{Lease: leaseJSON, LeaseSignature: leaseSig}, nonce)
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestGenerateActivationRequestCarriesFingerprintAndCode(t *testing.T) {
+	cfg, fp, _ := newOfflineActivationTest(t)
+	data, err := generateActivationRequest(cfg, fp, "CDK-A1B2", "Acme Corp", "", time.Now())
+	if err != nil {
+		t.Fatalf("generateActivationRequest: %v", err)
+	}
+	var req offlineActivationRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.MachineID != testingMachineID || req.Code != "CDK-A1B2" || req.ProjectSlug != "test-project" || req.ComponentSlug != "backend" || req.Nonce == "" {
+		t.Fatalf("unexpected request: %#v", req)
+	}
+}
+
+func TestApplyActivationResponseInstallsLease(t *testing.T) {
+	cfg, fp, privKey := newOfflineActivationTest(t)
+	requestFile, err := generateActivationRequest(cfg, fp, "CDK-A1B2", "Acme Corp", "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := offlineActivationResponseFor(t, privKey, requestFile)
+
+	result, err := applyActivationResponse(cfg, fp, response, time.Now())
+	if err != nil {
+		t.Fatalf("applyActivationResponse: %v", err)
+	}
+	if result.LicenseKey != "test-license" || result.ProjectSlug != "test-project" {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+	state, err := newPersistentStateStore(cfg, fp).Load()
+	if err != nil {
+		t.Fatalf("load installed state: %v", err)
+	}
+	if state.Lease == nil || state.Lease.LicenseKey != "test-license" || state.VerifiedAt == "" {
+		t.Fatalf("unexpected installed state: %#v", state)
+	}
+
+	if _, err := applyActivationResponse(cfg, fp, response, time.Now()); !errors.Is(err, ErrActivationResponseInvalid) {
+		t.Fatalf("expected a replayed response to be rejected, got %v", err)
+	}
+}
+
+func TestApplyActivationResponseRejectsOtherRequest(t *testing.T) {
+	cfg, fp, privKey := newOfflineActivationTest(t)
+	first, err := generateActivationRequest(cfg, fp, "CDK-A1B2", "Acme Corp", "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateActivationRequest(cfg, fp, "CDK-A1B2", "Acme Corp", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = applyActivationResponse(cfg, fp, offlineActivationResponseFor(t, privKey, first), time.Now())
+	if !errors.Is(err, ErrActivationResponseInvalid) {
+		t.Fatalf("expected a response to a superseded request to be rejected, got %v", err)
+	}
+}
+
+func TestApplyActivationResponseRejectsForgedSignature(t *testing.T) {
+	cfg, fp, _ := newOfflineActivationTest(t)
+	requestFile, err := generateActivationRequest(cfg, fp, "CDK-A1B2", "Acme Corp", "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	_, err = applyActivationResponse(cfg, fp, offlineActivationResponseFor(t, otherKey, requestFile), time.Now())
+	if !errors.Is(err, ErrActivationResponseInvalid) {
+		t.Fatalf("expected ErrActivationResponseInvalid, got %v", err)
+	}
+}
+
+func TestApplyActivationResponseRejectsOtherComponent(t *testing.T) {
+	cfg, fp, privKey := newOfflineActivationTest(t)
+	requestFile, err := generateActivationRequest(cfg, fp, "CDK-A1B2", "Acme Corp", "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req offlineActivationRequest
+	if err := json.Unmarshal(requestFile, &req); err != nil {
+		t.Fatal(err)
+	}
+	leaseValue := testLease(req.MachineID)
+	leaseValue.ComponentSlug = "frontend"
+
+	_, err = applyActivationResponse(cfg, fp, offlineActivationResponseWithLease(t, privKey, req.Nonce, leaseValue), time.Now())
+	if !errors.Is(err, ErrProjectNotAuthorized) {
+		t.Fatalf("expected ErrProjectNotAuthorized, got %v", err)
+	}
+}
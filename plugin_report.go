@@ -0,0 +1,104 @@
+package sdk
+
+import "time"
+
+// pluginHealthTimeout bounds the health checks run before a heartbeat, which
+// share one deadline, so stuck plugins cannot delay it.
+const pluginHealthTimeout = 5 * time.Second
+
+// Plugin health values reported in the heartbeat plugins section.
+const (
+	pluginHealthy       = "healthy"
+	pluginUnhealthy     = "unhealthy"
+	pluginHealthUnknown = "unknown"
+)
+
+// heartbeatPlugin is one managed component as the plugin console sees it:
+// what is installed, what the server last offered, how the last update went
+// and whether the plugin reports itself healthy.
+type heartbeatPlugin struct {
+	Slug             string                 `json:"slug"`
+	InstalledVersion string                 `json:"installed_version"`
+	AvailableVersion string                 `json:"available_version,omitempty"`
+	LastUpdate       *heartbeatPluginUpdate `json:"last_update,omitempty"`
+	Health           string                 `json:"health"`
+	HealthError      string                 `json:"health_error,omitempty"`
+}
+
+type heartbeatPluginUpdate struct {
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+	FinishedAt  string `json:"finished_at"`
+}
+
+// recordPluginUpdate remembers the outcome of the latest install or failed
+// update for the heartbeat plugins section.
+func (g *Guard) recordPluginUpdate(n UpdateNotification) {
+	if n.Kind != UpdateNotificationInstalled && n.Kind != UpdateNotificationFailed {
+		return
+	}
+	result := heartbeatPluginUpdate{
+		FromVersion: n.OldVersion,
+		ToVersion:   n.NewVersion,
+		Success:     n.Kind == UpdateNotificationInstalled,
+		FinishedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	if n.Err != nil {
+		result.Error = g.redactErr(n.Err).Error()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pluginUpdates == nil {
+		g.pluginUpdates = make(map[string]heartbeatPluginUpdate)
+	}
+	g.pluginUpdates[n.Component] = result
+}
+
+// recordAvailableVersion remembers the newest version the server advertised
+// for slug, from heartbeat updates or the plugin catalog.
+func (g *Guard) recordAvailableVersion(slug, version string) {
+	if slug == "" || version == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.availableVersions == nil {
+		g.availableVersions = make(map[string]string)
+	}
+	g.availableVersions[slug] = version
+}
+
+// pluginReport builds the heartbeat plugins section from the health checks
+// probeComponents ran for this heartbeat.
+func (g *Guard) pluginReport(probes map[string]componentProbe) []heartbeatPlugin {
+	if len(g.cfg.ManagedComponents) == 0 {
+		return nil
+	}
+
+	g.mu.RLock()
+	plugins := make([]heartbeatPlugin, 0, len(g.cfg.ManagedComponents))
+	for _, mc := range g.cfg.ManagedComponents {
+		plugin := heartbeatPlugin{
+			Slug:             mc.Slug,
+			InstalledVersion: g.managedVersions[mc.Slug],
+			AvailableVersion: g.availableVersions[mc.Slug],
+			Health:           pluginHealthUnknown,
+		}
+		if result, ok := g.pluginUpdates[mc.Slug]; ok {
+			plugin.LastUpdate = &result
+		}
+		plugins = append(plugins, plugin)
+	}
+	g.mu.RUnlock()
+
+	for i, mc := range g.cfg.ManagedComponents {
+		if probe, ok := probes[mc.Slug]; ok {
+			plugins[i].Health = probe.health
+			plugins[i].HealthError = probe.err
+		}
+	}
+	return plugins
+}
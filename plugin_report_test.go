@@ -0,0 +1,68 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeartbeatReportsPluginSection(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.cfg.ManagedComponents = []ManagedComponent{
+		{Slug: "reports", HealthCheck: func(ctx context.Context) error { return nil }},
+		{Slug: "export", HealthCheck: func(ctx context.Context) error { return errors.New("worker down") }},
+		{Slug: "theme"},
+	}
+	guard.SetManagedVersion("reports", "1.0.0")
+	guard.SetManagedVersion("export", "2.0.0")
+
+	var bodies []heartbeatRequestBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+		}
+		bodies = append(bodies, body)
+		resp := heartbeatResponse{
+			Status:         "ok",
+			Lease:          leaseJSON,
+			LeaseSignature: sig,
+			Updates:        []updateInfo{{Component: "reports", Latest: "1.1.0", UpdateAvailable: true}},
+		}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, resp, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	guard.notifyUpdate(UpdateNotification{Kind: UpdateNotificationFailed, Component: "export", OldVersion: "2.0.0", NewVersion: "2.1.0", Err: ErrUpdateVerify})
+	for i := 0; i < 2; i++ {
+		if err := guard.sendHeartbeat(context.Background()); err != nil {
+			t.Fatalf("heartbeat %d: %v", i, err)
+		}
+	}
+
+	plugins := map[string]heartbeatPlugin{}
+	for _, plugin := range bodies[1].Plugins {
+		plugins[plugin.Slug] = plugin
+	}
+	if len(plugins) != 3 {
+		t.Fatalf("expected 3 plugins, got %#v", bodies[1].Plugins)
+	}
+	if got := plugins["reports"]; got.InstalledVersion != "1.0.0" || got.AvailableVersion != "1.1.0" || got.Health != pluginHealthy || got.LastUpdate != nil {
+		t.Fatalf("reports = %#v", got)
+	}
+	export := plugins["export"]
+	if export.Health != pluginUnhealthy || export.HealthError != "worker down" {
+		t.Fatalf("export health = %#v", export)
+	}
+	if export.LastUpdate == nil || export.LastUpdate.Success || export.LastUpdate.ToVersion != "2.1.0" || export.LastUpdate.Error == "" {
+		t.Fatalf("export last update = %#v", export.LastUpdate)
+	}
+	if got := plugins["theme"]; got.Health != pluginHealthUnknown {
+		t.Fatalf("theme without HealthCheck = %#v", got)
+	}
+}
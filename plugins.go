@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
 	"strings"
 )
 
@@ -24,6 +25,20 @@ type PluginInfo struct {
 	TargetArch       *string `json:"target_arch"`
 }
 
+// HasNewerVersion reports whether LatestVersion is newer than
+// InstalledVersion by IsNewer, independently of the server's
+// UpdateAvailable.
+func (p PluginInfo) HasNewerVersion() bool {
+	if p.LatestVersion == nil {
+		return false
+	}
+	installed := ""
+	if p.InstalledVersion != nil {
+		installed = *p.InstalledVersion
+	}
+	return IsNewer(installed, *p.LatestVersion)
+}
+
 type PluginCatalog struct {
 	ProjectSlug  string       `json:"project_slug"`
 	MachineID    string       `json:"machine_id"`
@@ -51,10 +66,13 @@ type PluginUpdatePackage struct {
 	SizeBytes       int64   `json:"size_bytes"`
 	ReleaseNotes    *string `json:"release_notes"`
 	ExpiresIn       int     `json:"expires_in"`
+	// MetadataSignature covers the plugin, target version, platform,
+	// download URL and SHA-256, as for update download metadata.
+	MetadataSignature string `json:"metadata_signature,omitempty"`
 }
 
 type pluginUpdateRequestBody struct {
-	LicenseKey  string `json:"license_key"`
+	LicenseKey  string `json:"license_key,omitempty"`
 	MachineID   string `json:"machine_id"`
 	ProjectSlug string `json:"project_slug"`
 	OS          string `json:"os"`
@@ -64,8 +82,16 @@ type pluginUpdateRequestBody struct {
 
 // GetPluginCatalog fetches discoverable plugins and update availability for this machine.
 func (g *Guard) GetPluginCatalog(ctx context.Context, includeUninstalled bool) (*PluginCatalog, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
+	if err := g.requireCapability(CapabilityPlugins); err != nil {
+		return nil, err
+	}
+
 	query := url.Values{}
-	query.Set("license_key", g.cfg.LicenseKey)
+	g.setLicenseQuery(query)
 	query.Set("machine_id", g.fingerprint.MachineID())
 	query.Set("project_slug", g.cfg.ProjectSlug)
 	query.Set("os", g.cfg.OTA.OS)
@@ -75,13 +101,20 @@ func (g *Guard) GetPluginCatalog(ctx context.Context, includeUninstalled bool) (
 	}
 
 	var resp PluginCatalog
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
 	raw, err := g.getJSON(ctx, "/api/v1/plugins/catalog", query)
 	if err != nil {
-		return nil, fmt.Errorf("request plugin catalog: %w", err)
+		return nil, fmt.Errorf("request plugin catalog: %w", g.capabilityErr(CapabilityPlugins, err))
 	}
 	if err := json.Unmarshal(raw, &resp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
+	for _, plugin := range resp.Plugins {
+		if plugin.LatestVersion != nil {
+			g.recordAvailableVersion(plugin.Slug, *plugin.LatestVersion)
+		}
+	}
 
 	return &resp, nil
 }
@@ -113,13 +146,20 @@ func (g *Guard) CheckPluginUpdates(ctx context.Context) ([]PluginInfo, error) {
 
 // RequestPluginUpdate asks the server for a short-lived download package for one plugin.
 func (g *Guard) RequestPluginUpdate(ctx context.Context, slug string, options PluginUpdateOptions) (*PluginUpdatePackage, error) {
+	if err := g.requireClient(); err != nil {
+		return nil, err
+	}
+
 	if slug == "" {
 		return nil, fmt.Errorf("plugin slug is required")
 	}
+	if err := g.requireCapability(CapabilityPlugins); err != nil {
+		return nil, err
+	}
 
 	osValue, archValue := g.resolveOTAPlatform(options.OS, options.Arch)
 	body := pluginUpdateRequestBody{
-		LicenseKey:  g.cfg.LicenseKey,
+		LicenseKey:  g.bodyLicenseKey(),
 		MachineID:   g.fingerprint.MachineID(),
 		ProjectSlug: g.cfg.ProjectSlug,
 		OS:          osValue,
@@ -135,18 +175,39 @@ func (g *Guard) RequestPluginUpdate(ctx context.Context, slug string, options Pl
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
-	raw, err := g.postJSON(ctx, path, bodyJSON)
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
+	raw, err := g.postIdempotentJSON(ctx, path, bodyJSON)
 	if err != nil {
-		return nil, fmt.Errorf("request plugin update: %w", err)
+		return nil, fmt.Errorf("request plugin update: %w", g.capabilityErr(CapabilityPlugins, err))
 	}
 	if err := json.Unmarshal(raw, &resp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
+	if resp.DownloadURL != "" && (resp.MetadataSignature != "" || g.cfg.OTA.RequireSignedMetadata) {
+		payload := downloadMetaSignaturePayload{
+			Component:   slug,
+			Version:     resp.TargetVersion,
+			OS:          osValue,
+			Arch:        archValue,
+			DownloadURL: resp.DownloadURL,
+			SHA256:      resp.SHA256,
+		}
+		if err := g.verifyDownloadMeta(payload, resp.MetadataSignature); err != nil {
+			return nil, err
+		}
+	}
 	return &resp, nil
 }
 
-// UpdatePlugin performs a manual update for one plugin.
+// UpdatePlugin performs a manual update for one plugin. ctx bounds the
+// metadata request, the download and the pre-update hook; once applying has
+// started the update runs to completion.
 func (g *Guard) UpdatePlugin(ctx context.Context, slug string) error {
+	if err := g.requireClient(); err != nil {
+		return err
+	}
+
 	if slug == "" {
 		return fmt.Errorf("plugin slug is required")
 	}
@@ -181,6 +242,9 @@ func (g *Guard) UpdatePlugin(ctx context.Context, slug string) error {
 	if target.LatestVersion == nil || *target.LatestVersion == "" {
 		return ErrNoPluginUpdate
 	}
+	if err := g.checkVersionPolicy(slug, *target.LatestVersion); err != nil {
+		return err
+	}
 
 	u := updateInfo{
 		Component:       slug,
@@ -190,11 +254,11 @@ func (g *Guard) UpdatePlugin(ctx context.Context, slug string) error {
 
 	if slug == g.cfg.ComponentSlug {
 		oldVersion := g.currentVersion()
-		if oldVersion == u.Latest {
+		if SameVersion(oldVersion, u.Latest) {
 			return nil
 		}
 
-		if err := g.updateBackend(u); err != nil {
+		if err := g.updateBackend(ctx, u); err != nil {
 			return err
 		}
 		return nil
@@ -206,17 +270,17 @@ func (g *Guard) UpdatePlugin(ctx context.Context, slug string) error {
 	}
 
 	oldVersion := g.currentManagedVersion(slug)
-	if oldVersion == u.Latest {
+	if SameVersion(oldVersion, u.Latest) {
 		return nil
 	}
 
 	switch mc.Strategy {
 	case UpdateBackend:
-		if err := g.updateManagedBackend(mc, u); err != nil {
+		if err := g.updateManagedBackend(ctx, mc, u); err != nil {
 			return err
 		}
 	default:
-		if err := g.updateFrontend(mc, u); err != nil {
+		if err := g.updateFrontend(ctx, mc, u); err != nil {
 			return err
 		}
 	}
@@ -224,6 +288,27 @@ func (g *Guard) UpdatePlugin(ctx context.Context, slug string) error {
 	return nil
 }
 
+// checkVersionPolicy enforces the locally configured pin and ignore lists
+// for one component, independent of what the server advertises. A pin set
+// by InstallVersion overrides the configured one.
+func (g *Guard) checkVersionPolicy(slug, version string) error {
+	pinned, ok := g.versionPin(slug)
+	if !ok {
+		pinned, ok = g.cfg.OTA.PinnedVersions[slug]
+	}
+	if ok && strings.TrimSpace(pinned) != "" {
+		if !SameVersion(pinned, version) {
+			return fmt.Errorf("%w: %s is pinned to %s", ErrPluginVersionPinned, slug, pinned)
+		}
+	}
+	for _, ignored := range g.cfg.OTA.IgnoredVersions[slug] {
+		if SameVersion(ignored, version) {
+			return fmt.Errorf("%w: %s %s", ErrPluginVersionIgnored, slug, version)
+		}
+	}
+	return nil
+}
+
 func (g *Guard) resolveOTAPlatform(osOverride string, archOverride string) (string, string) {
 	osValue := strings.TrimSpace(osOverride)
 	archValue := strings.TrimSpace(archOverride)
@@ -250,3 +335,62 @@ func (g *Guard) findManagedComponent(slug string) (ManagedComponent, bool) {
 	}
 	return ManagedComponent{}, false
 }
+
+// ArtifactMeta describes a verified plugin artifact downloaded by
+// DownloadPluginArtifact.
+type ArtifactMeta struct {
+	Slug         string
+	Version      string
+	SHA256       string
+	Signature    string
+	SizeBytes    int64
+	ReleaseNotes *string
+}
+
+// DownloadPluginArtifact requests, downloads and verifies one plugin artifact
+// without applying it. An empty version selects the latest release. The
+// caller owns the returned file and is responsible for removing it.
+func (g *Guard) DownloadPluginArtifact(ctx context.Context, slug, version string) (string, ArtifactMeta, error) {
+	if err := g.requireClient(); err != nil {
+		return "", ArtifactMeta{}, err
+	}
+
+	pkg, err := g.RequestPluginUpdate(ctx, slug, PluginUpdateOptions{Version: version})
+	if err != nil {
+		return "", ArtifactMeta{}, err
+	}
+	if pkg.DownloadURL == "" || pkg.SHA256 == "" {
+		return "", ArtifactMeta{}, fmt.Errorf("%w: plugin update package missing download metadata", ErrInvalidServerResponse)
+	}
+
+	osValue, archValue := g.resolveOTAPlatform("", "")
+	path, actualSHA256, err := g.fetchArtifact(ctx, FetchRequest{
+		Component:   slug,
+		Version:     pkg.TargetVersion,
+		OS:          osValue,
+		Arch:        archValue,
+		DownloadURL: pkg.DownloadURL,
+		SHA256:      pkg.SHA256,
+		Signature:   pkg.Signature,
+	}, "", g.otaMaxArtifactBytes())
+	if err != nil {
+		return "", ArtifactMeta{}, fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+	}
+	if actualSHA256 != pkg.SHA256 {
+		_ = os.Remove(path)
+		return "", ArtifactMeta{}, fmt.Errorf("%w: hash mismatch: expected %s, got %s", ErrUpdateVerify, pkg.SHA256, actualSHA256)
+	}
+	if err := g.verifySignature(pkg.SHA256, pkg.Signature); err != nil {
+		_ = os.Remove(path)
+		return "", ArtifactMeta{}, fmt.Errorf("%w: %v", ErrUpdateVerify, err)
+	}
+
+	return path, ArtifactMeta{
+		Slug:         slug,
+		Version:      pkg.TargetVersion,
+		SHA256:       pkg.SHA256,
+		Signature:    pkg.Signature,
+		SizeBytes:    pkg.SizeBytes,
+		ReleaseNotes: pkg.ReleaseNotes,
+	}, nil
+}
@@ -7,11 +7,15 @@ import (
 	"context"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -424,3 +428,195 @@ func TestUpdatePlugin_ErrorCases(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdatePlugin_VersionPolicy(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/plugins/catalog" {
+			t.Fatalf("unexpected request to %s: policy should block before download", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(PluginCatalog{
+			ProjectSlug: "myproj",
+			Plugins: []PluginInfo{
+				{
+					Slug:             "admin-frontend",
+					OTAEnabled:       true,
+					InstalledVersion: testString("1.0.0"),
+					LatestVersion:    testString("1.1.0"),
+					UpdateAvailable:  true,
+					CanUpdate:        true,
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		ota     OTAConfig
+		wantErr error
+	}{
+		{"pinned", OTAConfig{PinnedVersions: map[string]string{"admin-frontend": "1.0.0"}}, ErrPluginVersionPinned},
+		{"ignored", OTAConfig{IgnoredVersions: map[string][]string{"admin-frontend": {"v1.1.0"}}}, ErrPluginVersionIgnored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(Config{
+				ServerURL:         srv.URL,
+				LicenseKey:        "LIC-1",
+				PublicKeyPEM:      pemEncodePublicKey(pubKey),
+				ProjectSlug:       "myproj",
+				ComponentSlug:     "backend",
+				OTA:               tt.ota,
+				ManagedComponents: []ManagedComponent{{Slug: "admin-frontend", Dir: t.TempDir(), Strategy: UpdateFrontend}},
+			})
+			if err != nil {
+				t.Fatalf("new guard: %v", err)
+			}
+			g.SetManagedVersion("admin-frontend", "1.0.0")
+
+			err = g.UpdatePlugin(context.Background(), "admin-frontend")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckVersionPolicy_AllowsPinnedTarget(t *testing.T) {
+	g := &Guard{cfg: Config{OTA: OTAConfig{
+		PinnedVersions:  map[string]string{"backend": "v2.0.0"},
+		IgnoredVersions: map[string][]string{"frontend": {"3.1.0"}},
+	}}}
+
+	if err := g.checkVersionPolicy("backend", "2.0.0"); err != nil {
+		t.Fatalf("pinned target should be allowed, got %v", err)
+	}
+	if err := g.checkVersionPolicy("frontend", "3.2.0"); err != nil {
+		t.Fatalf("non-ignored version should be allowed, got %v", err)
+	}
+	if err := g.checkVersionPolicy("other", "9.9.9"); err != nil {
+		t.Fatalf("components without policy should be allowed, got %v", err)
+	}
+}
+
+func TestDownloadPluginArtifact_VerifiesWithoutApplying(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	artifact := []byte("plugin-image-bytes")
+	hashHex := sha256Hex(artifact)
+	signature := signUpdateHash(t, privKey, hashHex)
+
+	tamper := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/plugins/reports/update":
+			_ = json.NewEncoder(w).Encode(PluginUpdatePackage{
+				Plugin:          "reports",
+				TargetVersion:   "3.1.0",
+				UpdateAvailable: true,
+				DownloadURL:     "/api/v1/update/fetch/token-9",
+				SHA256:          hashHex,
+				Signature:       signature,
+				SizeBytes:       int64(len(artifact)),
+			})
+		case "/api/v1/update/fetch/token-9":
+			if tamper {
+				_, _ = w.Write([]byte("tampered"))
+				return
+			}
+			_, _ = w.Write(artifact)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g, err := New(Config{
+		ServerURL:     srv.URL,
+		LicenseKey:    "LIC-1",
+		PublicKeyPEM:  pemEncodePublicKey(pubKey),
+		ProjectSlug:   "myproj",
+		ComponentSlug: "backend",
+	})
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+
+	path, meta, err := g.DownloadPluginArtifact(context.Background(), "reports", "")
+	if err != nil {
+		t.Fatalf("download plugin artifact: %v", err)
+	}
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read artifact: %v", err)
+	}
+	if !bytes.Equal(data, artifact) {
+		t.Fatalf("unexpected artifact content: %q", data)
+	}
+	if meta.Slug != "reports" || meta.Version != "3.1.0" || meta.SHA256 != hashHex {
+		t.Fatalf("unexpected artifact meta: %#v", meta)
+	}
+
+	tamper = true
+	if _, _, err := g.DownloadPluginArtifact(context.Background(), "reports", ""); !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("expected ErrUpdateVerify for tampered artifact, got %v", err)
+	}
+}
+
+func TestRequestPluginUpdate_StrictMetadataSignature(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	hash := strings.Repeat("b", 64)
+	sign := func(payload downloadMetaSignaturePayload) string {
+		raw, _ := json.Marshal(payload)
+		canonical, err := canonicalJSON(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest := sha256.Sum256(canonical)
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(privKey, digest[:]))
+	}
+	signed := downloadMetaSignaturePayload{Component: "reports", Version: "2.0.0", OS: "linux", Arch: "amd64", DownloadURL: "/download/reports", SHA256: hash}
+
+	for _, tt := range []struct {
+		name      string
+		url       string
+		signature string
+		strict    bool
+		wantErr   bool
+	}{
+		{"unsigned allowed without strict mode", "/download/reports", "", false, false},
+		{"unsigned rejected in strict mode", "/download/reports", "", true, true},
+		{"signed accepted", "/download/reports", sign(signed), true, false},
+		{"redirected url rejected", "https://evil.example/reports", sign(signed), false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(PluginUpdatePackage{
+					Plugin:            "reports",
+					TargetVersion:     "2.0.0",
+					UpdateAvailable:   true,
+					DownloadURL:       tt.url,
+					SHA256:            hash,
+					Signature:         "sig",
+					MetadataSignature: tt.signature,
+				})
+			}))
+			defer server.Close()
+
+			g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+			g.cfg.OTA.OS, g.cfg.OTA.Arch = "linux", "amd64"
+			g.cfg.OTA.RequireSignedMetadata = tt.strict
+			g.capabilities = map[Capability]bool{CapabilityPlugins: true}
+			_, err := g.RequestPluginUpdate(context.Background(), "reports", PluginUpdateOptions{})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("wantErr=%v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrUpdateVerify) {
+				t.Fatalf("expected ErrUpdateVerify, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProtocolVersion is the newest server response schema this SDK
+// understands. It is sent with every API request in the
+// X-BanyanHub-Protocol header; servers answer with the version they used.
+const ProtocolVersion = 2
+
+const headerProtocol = "X-BanyanHub-Protocol"
+
+// knownHeartbeatStatuses are the heartbeat status values this SDK acts on.
+var knownHeartbeatStatuses = []string{"ok", "kill"}
+
+// ServerProtocolVersion returns the response schema version the server
+// reported on its last reply, or 0 when it has not reported one.
+func (g *Guard) ServerProtocolVersion() int {
+	return int(g.serverProtocol.Load())
+}
+
+// recordServerProtocol keeps the version from a response header and logs
+// once when the server speaks a newer schema than this SDK, whose new
+// fields are then ignored.
+func (g *Guard) recordServerProtocol(header string) {
+	version, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || version <= 0 {
+		return
+	}
+	if previous := g.serverProtocol.Swap(int32(version)); previous != int32(version) && version > ProtocolVersion {
+		g.logger.Warn("server uses a newer response protocol, upgrade the SDK", "server_protocol", version, "sdk_protocol", ProtocolVersion)
+	}
+}
+
+// checkHeartbeatStatus rejects unknown heartbeat status values with
+// DebugConfig.StrictDecoding; otherwise they are logged and treated as ok.
+func (g *Guard) checkHeartbeatStatus(status string) error {
+	if slices.Contains(knownHeartbeatStatuses, status) {
+		return nil
+	}
+	if g.cfg.Debug.StrictDecoding {
+		return fmt.Errorf("%w: unknown heartbeat status %q", ErrInvalidServerResponse, status)
+	}
+	g.logger.Warn("unknown heartbeat status treated as ok", "status", status)
+	return nil
+}
+
+// reportUnknownFields passes the top-level fields of raw that v does not
+// declare to DebugConfig.OnUnknownFields.
+func (g *Guard) reportUnknownFields(endpoint string, raw []byte, v any) {
+	if g.cfg.Debug.OnUnknownFields == nil {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return
+	}
+	known := jsonFieldNames(reflect.TypeOf(v))
+	var unknown []string
+	for name := range fields {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		g.cfg.Debug.OnUnknownFields(endpoint, unknown)
+	}
+}
+
+var jsonFieldNamesCache sync.Map // reflect.Type -> map[string]struct{}
+
+// jsonFieldNames returns the JSON names of the exported fields of struct
+// type t.
+func jsonFieldNames(t reflect.Type) map[string]struct{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if cached, ok := jsonFieldNamesCache.Load(t); ok {
+		return cached.(map[string]struct{})
+	}
+	names := make(map[string]struct{}, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = struct{}{}
+	}
+	jsonFieldNamesCache.Store(t, names)
+	return names
+}
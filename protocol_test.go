@@ -0,0 +1,54 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestHeartbeat_ProtocolVersionAndDecodeModes(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(headerProtocol); got != strconv.Itoa(ProtocolVersion) {
+			t.Errorf("%s = %q, want %d", headerProtocol, got, ProtocolVersion)
+		}
+		var body heartbeatRequestBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		resp := signHeartbeatResponse(t, privKey, heartbeatResponse{Status: "paused", Lease: leaseJSON, LeaseSignature: sig}, body.Nonce)
+		raw, _ := json.Marshal(resp)
+		var fields map[string]any
+		_ = json.Unmarshal(raw, &fields)
+		fields["maintenance_window"] = "sunday"
+		w.Header().Set(headerProtocol, strconv.Itoa(ProtocolVersion+1))
+		_ = json.NewEncoder(w).Encode(fields)
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	var unknown []string
+	guard.cfg.Debug.OnUnknownFields = func(endpoint string, fields []string) {
+		if endpoint == "heartbeat" {
+			unknown = fields
+		}
+	}
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("lenient heartbeat with an unknown status: %v", err)
+	}
+	if !reflect.DeepEqual(unknown, []string{"maintenance_window"}) {
+		t.Fatalf("unknown fields = %v", unknown)
+	}
+	if v := guard.ServerProtocolVersion(); v != ProtocolVersion+1 {
+		t.Fatalf("server protocol = %d, want %d", v, ProtocolVersion+1)
+	}
+
+	guard.cfg.Debug.StrictDecoding = true
+	if err := guard.sendHeartbeat(context.Background()); !errors.Is(err, ErrInvalidServerResponse) {
+		t.Fatalf("strict heartbeat: err = %v, want ErrInvalidServerResponse", err)
+	}
+}
@@ -0,0 +1,205 @@
+package sdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultPushPath = "/api/v1/events"
+
+// PushConfig enables a persistent Server-Sent Events channel over which the
+// server announces update notifications, kill/freeze commands and feedback
+// replies as they happen. Events only wake the heartbeat early: commands and
+// updates are still taken from the signed heartbeat response, and heartbeats
+// keep their normal schedule, so losing the channel only costs latency.
+type PushConfig struct {
+	Enabled bool
+	// Path is the event stream endpoint (default /api/v1/events).
+	Path string
+	// MinReconnectDelay and MaxReconnectDelay bound the exponential backoff
+	// between reconnects (default 1s and 5m).
+	MinReconnectDelay time.Duration
+	MaxReconnectDelay time.Duration
+	// MinHeartbeatInterval is the shortest gap between heartbeats woken by
+	// events (default 10s).
+	MinHeartbeatInterval time.Duration
+}
+
+func (p *PushConfig) setDefaults() {
+	if p.Path == "" {
+		p.Path = defaultPushPath
+	}
+	if p.MinReconnectDelay <= 0 {
+		p.MinReconnectDelay = time.Second
+	}
+	if p.MaxReconnectDelay <= 0 {
+		p.MaxReconnectDelay = 5 * time.Minute
+	}
+	if p.MaxReconnectDelay < p.MinReconnectDelay {
+		p.MaxReconnectDelay = p.MinReconnectDelay
+	}
+	if p.MinHeartbeatInterval <= 0 {
+		p.MinHeartbeatInterval = 10 * time.Second
+	}
+}
+
+// Push event types. Anything else is ignored.
+const (
+	pushEventUpdate        = "update"
+	pushEventCommand       = "command"
+	pushEventKill          = "kill"
+	pushEventHeartbeat     = "heartbeat"
+	pushEventFeedbackReply = "feedback_reply"
+)
+
+type pushEvent struct {
+	name string
+	data string
+}
+
+// PushConnected reports whether the push channel is currently connected.
+func (g *Guard) PushConnected() bool {
+	return g.pushConnected.Load()
+}
+
+// runPush keeps the push channel connected until ctx is done. A server
+// without the endpoint (404 or 501) disables push for the rest of the run.
+func (g *Guard) runPush(ctx context.Context) {
+	cfg := g.cfg.Push
+	delay := cfg.MinReconnectDelay
+	for {
+		connected, err := g.streamPushEvents(ctx)
+		g.pushConnected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusNotImplemented) {
+			g.logger.Info("server has no push channel, relying on heartbeats")
+			return
+		}
+		if connected {
+			delay = cfg.MinReconnectDelay
+		}
+		g.logger.Warn("push channel disconnected", "error", err, "retry_in", delay.String())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > cfg.MaxReconnectDelay {
+			delay = cfg.MaxReconnectDelay
+		}
+	}
+}
+
+// streamPushEvents holds one connection open and dispatches its events. It
+// reports whether the connection was established.
+func (g *Guard) streamPushEvents(ctx context.Context) (bool, error) {
+	query := url.Values{}
+	g.setLicenseQuery(query)
+	query.Set("machine_id", g.fingerprint.MachineID())
+	query.Set("project_slug", g.cfg.ProjectSlug)
+	query.Set("component_slug", g.cfg.ComponentSlug)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.serverURL(g.cfg.Push.Path)+"?"+query.Encode(), nil)
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
+	g.signRequest(req, nil)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return false, g.redactErr(fmt.Errorf("%w: %v", ErrNetworkError, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, decodeAPIErrorResponse(resp)
+	}
+	if mediaType := resp.Header.Get("Content-Type"); !strings.HasPrefix(mediaType, "text/event-stream") {
+		return false, fmt.Errorf("%w: push channel content type %q", ErrInvalidServerResponse, mediaType)
+	}
+
+	g.pushConnected.Store(true)
+	g.logger.Info("push channel connected")
+	err = readPushEvents(resp.Body, g.handlePushEvent)
+	if err == nil {
+		err = errors.New("push channel closed by server")
+	}
+	return true, err
+}
+
+// readPushEvents parses a text/event-stream body, calling handle for every
+// complete event, until the stream ends.
+func readPushEvents(body io.Reader, handle func(pushEvent)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	var event pushEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 || event.name != "" {
+				event.data = strings.Join(data, "\n")
+				if event.name == "" {
+					event.name = "message"
+				}
+				handle(event)
+			}
+			event, data = pushEvent{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return scanner.Err()
+}
+
+func (g *Guard) handlePushEvent(event pushEvent) {
+	switch event.name {
+	case pushEventUpdate, pushEventCommand, pushEventKill, pushEventHeartbeat:
+		g.wakeHeartbeat()
+	case pushEventFeedbackReply:
+		var reply heartbeatFeedbackReply
+		if err := json.Unmarshal([]byte(event.data), &reply); err != nil || reply.Reply.ID == "" {
+			g.logger.Warn("ignoring malformed push event", "event", event.name)
+			return
+		}
+		g.applyFeedbackReplies([]heartbeatFeedbackReply{reply})
+	}
+}
+
+// wakeHeartbeat asks the heartbeat loop to run now, at most once per
+// PushConfig.MinHeartbeatInterval.
+func (g *Guard) wakeHeartbeat() {
+	now := time.Now()
+	if last := g.lastHeartbeatWake.Load(); last != nil && now.Sub(*last) < g.cfg.Push.MinHeartbeatInterval {
+		return
+	}
+	g.lastHeartbeatWake.Store(&now)
+	select {
+	case g.heartbeatWake <- struct{}{}:
+	default:
+	}
+}
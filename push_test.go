@@ -0,0 +1,75 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadPushEvents(t *testing.T) {
+	stream := ": keep-alive\n\nevent: update\ndata: {\"component\":\"backend\"}\n\ndata: line one\ndata: line two\n\nevent: kill\n\n"
+	var events []pushEvent
+	if err := readPushEvents(strings.NewReader(stream), func(e pushEvent) { events = append(events, e) }); err != nil {
+		t.Fatal(err)
+	}
+	want := []pushEvent{
+		{name: "update", data: `{"component":"backend"}`},
+		{name: "message", data: "line one\nline two"},
+		{name: "kill"},
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+}
+
+func TestPushEventsWakeHeartbeatAndDeliverFeedbackReplies(t *testing.T) {
+	var connects atomic.Int32
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/events" || r.Header.Get("Accept") != "text/event-stream" {
+			http.NotFound(w, r)
+			return
+		}
+		if connects.Add(1) > 1 {
+			// Reconnects after the first stream ends find push gone.
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: update\ndata: {}\n\n")
+		fmt.Fprint(w, "event: command\ndata: {}\n\n")
+		fmt.Fprint(w, "event: feedback_reply\ndata: {\"feedback_id\":\"fb-1\",\"reply\":{\"id\":\"r-1\",\"content\":\"fixed\"}}\n\n")
+	})
+	g.cfg.Push = PushConfig{Enabled: true}
+	g.cfg.Push.setDefaults()
+	g.cfg.Push.MinReconnectDelay = time.Millisecond
+	var replies []string
+	g.cfg.OnFeedbackReply = func(feedbackID string, reply FeedbackReply) { replies = append(replies, feedbackID+"/"+reply.ID) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	g.runPush(ctx)
+
+	if connects.Load() != 2 {
+		t.Fatalf("expected a reconnect that finds push unsupported, got %d connects", connects.Load())
+	}
+	select {
+	case <-g.heartbeatWake:
+	default:
+		t.Fatal("expected the update event to wake the heartbeat")
+	}
+	select {
+	case <-g.heartbeatWake:
+		t.Fatal("expected the command event within MinHeartbeatInterval to be coalesced")
+	default:
+	}
+	if len(replies) != 1 || replies[0] != "fb-1/r-1" {
+		t.Fatalf("unexpected feedback replies: %v", replies)
+	}
+	if g.PushConnected() {
+		t.Fatal("expected push to be disconnected")
+	}
+}
@@ -0,0 +1,120 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy throttles the guard's API calls on the client, so a UI
+// that fires catalog, update and plugin calls in quick succession cannot
+// flood the server. Calls over the limit wait for a slot; they are not
+// rejected. Artifact downloads are not limited.
+type RateLimitPolicy struct {
+	// RequestsPerSecond is the sustained call rate (default 10); negative
+	// disables the limiter.
+	RequestsPerSecond float64
+	// Burst is how many calls may go out back to back before the rate
+	// applies (default 20).
+	Burst int
+}
+
+// rateLimiter is a token bucket. A nil limiter lets every call through.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(policy RateLimitPolicy) *rateLimiter {
+	if policy.RequestsPerSecond <= 0 || policy.Burst <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:   policy.RequestsPerSecond,
+		burst:  float64(policy.Burst),
+		tokens: float64(policy.Burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token, sleeping until one is available or ctx ends. A call
+// abandoned by its context returns the token it reserved.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens = min(l.burst, l.tokens+1)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// inflightCall is a GET shared by every caller that asks for the same URL
+// while it runs.
+type inflightCall struct {
+	done chan struct{}
+	raw  []byte
+	err  error
+}
+
+// coalesceGET runs fetch once for concurrent identical GETs and hands each
+// caller its own copy of the reply. If the call that ran was cancelled by
+// its own caller, the others retry rather than inherit that error.
+func (g *Guard) coalesceGET(ctx context.Context, req TransportRequest, fetch func() ([]byte, error)) ([]byte, error) {
+	if req.Method != http.MethodGet {
+		return fetch()
+	}
+	key := req.Path + "?" + req.Query.Encode()
+	for {
+		g.inflightMu.Lock()
+		if call, ok := g.inflight[key]; ok {
+			g.inflightMu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded) {
+				continue
+			}
+			return bytes.Clone(call.raw), call.err
+		}
+		call := &inflightCall{done: make(chan struct{})}
+		if g.inflight == nil {
+			g.inflight = make(map[string]*inflightCall)
+		}
+		g.inflight[key] = call
+		g.inflightMu.Unlock()
+
+		call.raw, call.err = fetch()
+		g.inflightMu.Lock()
+		delete(g.inflight, key)
+		g.inflightMu.Unlock()
+		close(call.done)
+		return bytes.Clone(call.raw), call.err
+	}
+}
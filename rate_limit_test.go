@@ -0,0 +1,89 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_BurstThenWaits(t *testing.T) {
+	if newRateLimiter(RateLimitPolicy{RequestsPerSecond: -1, Burst: 20}) != nil {
+		t.Fatal("negative rate must disable the limiter")
+	}
+
+	l := newRateLimiter(RateLimitPolicy{RequestsPerSecond: 20, Burst: 2})
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("burst took %s, want no wait", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled while over the limit", err)
+	}
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("third call after %s, want it held to the rate", elapsed)
+	}
+}
+
+func TestGetJSON_CoalescesIdenticalInFlightRequests(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"plugins":[]}`))
+	}))
+	defer server.Close()
+
+	guard, _ := newTestGuard(t, nil)
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := guard.getJSON(context.Background(), "/api/v1/plugins/catalog", nil)
+			errs <- err
+		}()
+	}
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("server saw %d requests, want 1 shared by all callers", n)
+	}
+
+	if _, err := guard.getJSON(context.Background(), "/api/v1/plugins/catalog", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("server saw %d requests, want a fresh call once the first finished", n)
+	}
+}
@@ -0,0 +1,283 @@
+package sdk
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bindingFileName records the fingerprint the license was last bound to. It
+// is kept outside the machine-sealed state so it stays readable after the
+// machine ID changes.
+const bindingFileName = "binding.json"
+
+// driftSignalWeights weighs aux signals for FingerprintDrift.Score; signals
+// not listed weigh 1.
+var driftSignalWeights = map[string]float64{
+	"mac_addresses": 3,
+	"cpu_model":     2,
+	"os":            2,
+	"arch":          2,
+}
+
+// FingerprintDrift describes how this machine's fingerprint differs from the
+// one the license was last bound to here, e.g. after a NIC swap or an OS
+// reinstall.
+type FingerprintDrift struct {
+	// Score is the weighted share of aux signals that changed, from 0 to 1.
+	Score float64 `json:"score"`
+	// Changed names the aux signals that differ, e.g. "mac_addresses".
+	Changed []string `json:"changed,omitempty"`
+	// MachineIDChanged means the server sees a new machine; verification
+	// fails with ErrMachineMismatch until Rebind moves the seat over.
+	MachineIDChanged bool `json:"machine_id_changed"`
+	// PreviousMachineID is the machine ID of the last binding.
+	PreviousMachineID string `json:"previous_machine_id"`
+}
+
+type bindingRecord struct {
+	MachineID  string            `json:"machine_id"`
+	AuxSignals map[string]string `json:"aux_signals"`
+	Signature  string            `json:"signature,omitempty"`
+}
+
+type rebindRequestBody struct {
+	LicenseKey        string            `json:"license_key,omitempty"`
+	PreviousMachineID string            `json:"previous_machine_id"`
+	MachineID         string            `json:"machine_id"`
+	AuxSignals        map[string]string `json:"aux_signals"`
+	ProjectSlug       string            `json:"project_slug"`
+	ComponentSlug     string            `json:"component_slug"`
+	Hostname          string            `json:"hostname"`
+	Drift             *FingerprintDrift `json:"drift"`
+	Nonce             string            `json:"nonce"`
+	Timestamp         int64             `json:"timestamp"`
+}
+
+// FingerprintDrift reports how the fingerprint changed since the license was
+// last bound on this machine. It returns false when nothing changed or no
+// earlier binding is recorded.
+func (g *Guard) FingerprintDrift() (FingerprintDrift, bool) {
+	drift := g.currentDrift()
+	if drift == nil {
+		return FingerprintDrift{}, false
+	}
+	return *drift, true
+}
+
+// Rebind asks the server to move this license's seat from the previously
+// bound machine ID to the current one, after verification failed with
+// ErrMachineMismatch. The server decides from the reported drift whether
+// this is the same machine; ErrRebindRejected means it is not. On success
+// the new lease is installed and Start can be called again.
+func (g *Guard) Rebind(ctx context.Context) (err error) {
+	if err := g.requireInitialized(true, true); err != nil {
+		return err
+	}
+	if g.sm.Current() == StateDeactivated {
+		return ErrDeactivated
+	}
+	drift := g.currentDrift()
+	if drift == nil || !drift.MachineIDChanged {
+		return fmt.Errorf("machine ID unchanged since the last binding; nothing to rebind")
+	}
+
+	defer func() { g.auditOutcome(AuditVerification, g.cfg.ComponentSlug, err, "rebind") }()
+	nonce, err := randomNonce()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	body, err := json.Marshal(rebindRequestBody{
+		LicenseKey:        g.bodyLicenseKey(),
+		PreviousMachineID: drift.PreviousMachineID,
+		MachineID:         g.fingerprint.MachineID(),
+		AuxSignals:        g.fingerprint.AuxSignals(),
+		ProjectSlug:       g.cfg.ProjectSlug,
+		ComponentSlug:     g.cfg.ComponentSlug,
+		Hostname:          hostname(),
+		Drift:             drift,
+		Nonce:             nonce,
+		Timestamp:         now.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	leaseValue, leaseSignature, err := g.requestLease(ctx, "/api/v1/rebind", "rebind", body, nonce, now)
+	if err != nil {
+		return err
+	}
+
+	wasLocked := g.sm.Current() == StateLocked
+	if err := g.acceptLease(leaseValue, leaseSignature, false); err != nil {
+		return err
+	}
+	g.sm.OnVerifySuccess()
+	g.logger.Info("license rebound to this machine")
+	if wasLocked && g.cfg.OnUnlocked != nil {
+		g.cfg.OnUnlocked()
+	}
+	return nil
+}
+
+func (g *Guard) currentDrift() *FingerprintDrift {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.drift
+}
+
+// recordBinding remembers the current fingerprint as the one the license is
+// bound to, once a lease for it has been accepted, and clears any drift.
+func (g *Guard) recordBinding() {
+	g.mu.Lock()
+	g.drift = nil
+	g.mu.Unlock()
+
+	current := bindingRecord{MachineID: g.fingerprint.MachineID(), AuxSignals: stableSignals(g.fingerprint.AuxSignals())}
+	if previous := loadBindingRecord(g.cfg); previous != nil && previous.MachineID == current.MachineID && equalSignals(previous.AuxSignals, current.AuxSignals) {
+		return
+	}
+	if err := saveBindingRecord(g.cfg, current); err != nil {
+		g.logger.Warn("failed to record fingerprint binding", "error", err)
+	}
+}
+
+// detectFingerprintDrift compares fp with the recorded binding. It returns
+// nil when there is no binding or nothing changed.
+func detectFingerprintDrift(cfg Config, fp *Fingerprint) *FingerprintDrift {
+	previous := loadBindingRecord(cfg)
+	if previous == nil {
+		return nil
+	}
+	score, changed := fingerprintDriftScore(stableSignals(previous.AuxSignals), stableSignals(fp.AuxSignals()))
+	machineIDChanged := previous.MachineID != fp.MachineID()
+	if !machineIDChanged && len(changed) == 0 {
+		return nil
+	}
+	return &FingerprintDrift{
+		Score:             score,
+		Changed:           changed,
+		MachineIDChanged:  machineIDChanged,
+		PreviousMachineID: previous.MachineID,
+	}
+}
+
+// fingerprintDriftScore returns the weighted share of aux signals that
+// differ and their names. MAC addresses count partially, by the share of the
+// combined set that is not common to both.
+func fingerprintDriftScore(previous, current map[string]string) (float64, []string) {
+	keys := make(map[string]struct{}, len(previous)+len(current))
+	for key := range previous {
+		keys[key] = struct{}{}
+	}
+	for key := range current {
+		keys[key] = struct{}{}
+	}
+
+	var total, drifted float64
+	var changed []string
+	for key := range keys {
+		weight := driftSignalWeights[key]
+		if weight == 0 {
+			weight = 1
+		}
+		total += weight
+		diff := signalDifference(key, previous[key], current[key])
+		if diff > 0 {
+			drifted += weight * diff
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	if total == 0 {
+		return 0, nil
+	}
+	return drifted / total, changed
+}
+
+func signalDifference(key, previous, current string) float64 {
+	if previous == current {
+		return 0
+	}
+	if key != "mac_addresses" || previous == "" || current == "" {
+		return 1
+	}
+	union := make(map[string]int)
+	for _, mac := range strings.Split(previous, ",") {
+		union[mac] |= 1
+	}
+	for _, mac := range strings.Split(current, ",") {
+		union[mac] |= 2
+	}
+	common := 0
+	for _, seen := range union {
+		if seen == 3 {
+			common++
+		}
+	}
+	return 1 - float64(common)/float64(len(union))
+}
+
+func equalSignals(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+func loadBindingRecord(cfg Config) *bindingRecord {
+	data, err := cacheStoreFor(cfg).Load(bindingFileName)
+	if err != nil {
+		return nil
+	}
+	var record bindingRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil
+	}
+	expected, err := bindingSignature(cfg, record)
+	if err != nil || !hmac.Equal([]byte(expected), []byte(record.Signature)) {
+		return nil
+	}
+	return &record
+}
+
+func saveBindingRecord(cfg Config, record bindingRecord) error {
+	signature, err := bindingSignature(cfg, record)
+	if err != nil {
+		return err
+	}
+	record.Signature = signature
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return cacheStoreFor(cfg).Save(bindingFileName, data)
+}
+
+// bindingSignature is the hex HMAC-SHA256, keyed by the license key, of the
+// canonical record without its signature.
+func bindingSignature(cfg Config, record bindingRecord) (string, error) {
+	record.Signature = ""
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.LicenseKey+"|"+cfg.ProjectSlug))
+	mac.Write(canonical)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,115 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFingerprintDriftScoreCountsMACsPartially(t *testing.T) {
+	previous := map[string]string{"os": "linux", "arch": "amd64", "cpu_cores": "8", "mac_addresses": "aa,bb"}
+	current := map[string]string{"os": "linux", "arch": "amd64", "cpu_cores": "8", "mac_addresses": "aa,cc"}
+
+	score, changed := fingerprintDriftScore(previous, current)
+	if len(changed) != 1 || changed[0] != "mac_addresses" {
+		t.Fatalf("unexpected changed signals: %v", changed)
+	}
+	// Weights: os 2, arch 2, cpu_cores 1, mac_addresses 3; two of three MACs differ.
+	if want := 3 * (2.0 / 3) / 8; math.Abs(score-want) > 1e-9 {
+		t.Fatalf("score = %v, want %v", score, want)
+	}
+	if score, changed := fingerprintDriftScore(previous, previous); score != 0 || changed != nil {
+		t.Fatalf("expected no drift, got %v %v", score, changed)
+	}
+}
+
+func TestNewStartsFreshWhenMachineIDDrifted(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	cfg := Config{
+		ServerURL:        "https://example.invalid",
+		LicenseKey:       "test-license",
+		PublicKeyPEM:     pemEncodePublicKey(pubKey),
+		ProjectSlug:      "test-project",
+		ComponentSlug:    "backend",
+		PinnedSPKIHashes: []string{"test-pin"},
+	}
+	oldMachine := &Fingerprint{machineID: "sha256:previous", auxSignals: map[string]string{"os": "linux"}}
+	if err := newPersistentStateStore(cfg, oldMachine).Save(&persistedState{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveBindingRecord(cfg, bindingRecord{MachineID: oldMachine.machineID, AuxSignals: oldMachine.auxSignals}); err != nil {
+		t.Fatal(err)
+	}
+
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guard.State() != StateInit {
+		t.Fatalf("expected INIT for state sealed under a previous machine ID, got %s", guard.State())
+	}
+	drift, ok := guard.FingerprintDrift()
+	if !ok || !drift.MachineIDChanged || drift.PreviousMachineID != "sha256:previous" {
+		t.Fatalf("unexpected drift: %#v, %v", drift, ok)
+	}
+}
+
+func TestRebindMovesSeatToCurrentMachine(t *testing.T) {
+	guard, privKey := newTestGuard(t, nil)
+	if err := saveBindingRecord(guard.cfg, bindingRecord{MachineID: "sha256:previous", AuxSignals: guard.fingerprint.AuxSignals()}); err != nil {
+		t.Fatal(err)
+	}
+	guard.drift = detectFingerprintDrift(guard.cfg, guard.fingerprint)
+	leaseJSON, sig := signedLeaseJSON(t, privKey, testLease(guard.fingerprint.MachineID()))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/verify":
+			var body licenseVerifyRequestBody
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Drift == nil || !body.Drift.MachineIDChanged {
+				t.Errorf("expected drift in verify request, got %#v", body.Drift)
+			}
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "machine_mismatch"})
+		case "/api/v1/rebind":
+			var body rebindRequestBody
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.PreviousMachineID != "sha256:previous" || body.MachineID != guard.fingerprint.MachineID() {
+				t.Errorf("unexpected rebind request: %#v", body)
+			}
+			_ = json.NewEncoder(w).Encode(signVerifyResponse(t, privKey, verifyResponse{Lease: json.RawMessage(leaseJSON), LeaseSignature: sig}, body.Nonce))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.verifyLicense(context.Background()); !errors.Is(err, ErrMachineMismatch) {
+		t.Fatalf("expected ErrMachineMismatch, got %v", err)
+	}
+	if err := guard.Rebind(context.Background()); err != nil {
+		t.Fatalf("Rebind: %v", err)
+	}
+	if guard.State() != StateActive {
+		t.Fatalf("expected ACTIVE after rebind, got %s", guard.State())
+	}
+	if _, ok := guard.FingerprintDrift(); ok {
+		t.Fatal("expected drift to be cleared after rebind")
+	}
+	if record := loadBindingRecord(guard.cfg); record == nil || record.MachineID != guard.fingerprint.MachineID() {
+		t.Fatalf("expected binding to move to the current machine, got %#v", record)
+	}
+	if err := guard.Rebind(context.Background()); err == nil {
+		t.Fatal("expected a second rebind to have nothing to do")
+	}
+}
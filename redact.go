@@ -0,0 +1,152 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redactedMarker = "[REDACTED]"
+
+// builtinRedactPatterns mask credentials that end up in URLs and headers:
+// token/signature/key query parameters on download and upload links, and
+// bearer tokens.
+var builtinRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)([?&][^=&\s"]*(?:token|sig|signature|key|secret|credential|auth)[^=&\s"]*=)[^&\s"]+`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+}
+
+// redactor masks license keys, machine IDs and credential-bearing URL parts
+// in text the SDK hands to loggers and callers.
+type redactor struct {
+	literals []string
+	patterns []*regexp.Regexp
+}
+
+func newRedactor(cfg Config, machineID string) (*redactor, error) {
+	r := &redactor{patterns: append([]*regexp.Regexp{}, builtinRedactPatterns...)}
+	for _, literal := range []string{cfg.LicenseKey, machineID} {
+		if strings.TrimSpace(literal) != "" {
+			r.literals = append(r.literals, literal)
+		}
+	}
+	for _, pattern := range cfg.RedactPatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, compiled)
+	}
+	return r, nil
+}
+
+// String returns s with every sensitive value replaced. Patterns with a
+// capture group keep the first group (e.g. the "?token=" prefix) and mask the
+// rest of the match.
+func (r *redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, literal := range r.literals {
+		s = strings.ReplaceAll(s, literal, redactedMarker)
+	}
+	for _, pattern := range r.patterns {
+		if pattern.NumSubexp() > 0 {
+			s = pattern.ReplaceAllString(s, "${1}"+redactedMarker)
+		} else {
+			s = pattern.ReplaceAllLiteralString(s, redactedMarker)
+		}
+	}
+	return s
+}
+
+// redactedError keeps the original chain for errors.Is/As while presenting a
+// masked message.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactErr masks err's message; it is applied where transport errors that
+// embed request URLs leave the SDK.
+func (g *Guard) redactErr(err error) error {
+	if err == nil || g.redactor == nil {
+		return err
+	}
+	msg := err.Error()
+	masked := g.redactor.String(msg)
+	if masked == msg {
+		return err
+	}
+	return &redactedError{err: err, msg: masked}
+}
+
+// redactingHandler masks the message and every string-like attribute before
+// passing records to the application's handler.
+type redactingHandler struct {
+	next     slog.Handler
+	redactor *redactor
+}
+
+func newRedactingLogger(logger *slog.Logger, r *redactor) *slog.Logger {
+	if r == nil {
+		return logger
+	}
+	return slog.New(&redactingHandler{next: logger.Handler(), redactor: r})
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	masked := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		masked.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, masked)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		masked[i] = h.redactAttr(attr)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(masked), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *redactingHandler) redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.String(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		masked := make([]any, len(group))
+		for i, child := range group {
+			masked[i] = h.redactAttr(child)
+		}
+		return slog.Group(attr.Key, masked...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, h.redactor.String(err.Error()))
+		}
+		text := value.String()
+		if masked := h.redactor.String(text); masked != text {
+			return slog.String(attr.Key, masked)
+		}
+		return attr
+	default:
+		return attr
+	}
+}
@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactor_MasksKeysMachineIDAndURLCredentials(t *testing.T) {
+	r, err := newRedactor(Config{LicenseKey: "LIC-SECRET-1", RedactPatterns: []string{`(customer=)\w+`}}, "machine-abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := `license LIC-SECRET-1 on machine-abc: GET https://cdn.example.com/a.tar.gz?X-Amz-Signature=deadbeef&v=2&customer=acme Authorization: Bearer eyJ.abc`
+	out := r.String(in)
+	for _, leaked := range []string{"LIC-SECRET-1", "machine-abc", "deadbeef", "acme", "eyJ.abc"} {
+		if strings.Contains(out, leaked) {
+			t.Fatalf("%q leaked in %q", leaked, out)
+		}
+	}
+	if !strings.Contains(out, "X-Amz-Signature="+redactedMarker) || !strings.Contains(out, "v=2") {
+		t.Fatalf("expected parameter names and harmless values kept, got %q", out)
+	}
+
+	if _, err := newRedactor(Config{RedactPatterns: []string{"("}}, ""); err == nil {
+		t.Fatal("expected invalid pattern to be rejected")
+	}
+}
+
+func TestRedaction_AppliesToLoggerAndTransportErrors(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	var buf bytes.Buffer
+	guard.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	guard.logger.Info("verify "+guard.cfg.LicenseKey, "machine", guard.fingerprint.MachineID(), "error", errors.New("key="+guard.cfg.LicenseKey))
+	if strings.Contains(buf.String(), guard.cfg.LicenseKey) || strings.Contains(buf.String(), guard.fingerprint.MachineID()) {
+		t.Fatalf("log output not redacted: %s", buf.String())
+	}
+
+	guard.cfg.ServerURL = "http://127.0.0.1:1"
+	guard.cfg.Retry.MaxAttempts = 1
+	_, err := guard.getJSON(context.Background(), "/api/v1/plugins", url.Values{"license_key": {guard.cfg.LicenseKey}})
+	if err == nil {
+		t.Fatal("expected transport error")
+	}
+	if strings.Contains(err.Error(), guard.cfg.LicenseKey) {
+		t.Fatalf("transport error leaks license key: %v", err)
+	}
+}
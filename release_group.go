@@ -0,0 +1,330 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A release group is a set of updates the server marks with the same
+// updateInfo.Group, e.g. a backend and the two frontends built against it.
+// Applying them one by one as heartbeats arrive could leave a frontend
+// talking to a backend it does not match, so the guard handles the group as
+// one transaction: every member is downloaded and verified first, then the
+// members are applied in GroupOrder, and if one fails the members already
+// applied are restored to the versions they had before the group started.
+// Members install straight at their version; an upgrade_path on a member is
+// not followed.
+
+// groupMember is one update of a release group with what applying and
+// restoring it needs.
+type groupMember struct {
+	update    updateInfo
+	mc        ManagedComponent
+	install   func(u updateInfo) error
+	installed func() string
+	// targetPath is the binary a backend member replaces.
+	targetPath string
+	// stageDir is where the member's artifact is prefetched; the update
+	// stages there too, so it can pick the artifact up.
+	stageDir string
+	// previous is the version installed before the group was applied.
+	previous string
+}
+
+// prefetchedArtifact is an artifact a release group downloaded and verified
+// ahead of applying it. requestDownloadMeta and fetchArtifact return it
+// instead of asking the server again.
+type prefetchedArtifact struct {
+	meta downloadMeta
+	path string
+}
+
+func prefetchKey(component, version string) string {
+	return component + "@" + version
+}
+
+// groupReleaseUpdates splits updates into ungrouped ones and release
+// groups.
+func groupReleaseUpdates(updates []updateInfo) (single []updateInfo, groups map[string][]updateInfo) {
+	for _, u := range updates {
+		group := strings.TrimSpace(u.Group)
+		if group == "" {
+			single = append(single, u)
+			continue
+		}
+		if groups == nil {
+			groups = make(map[string][]updateInfo)
+		}
+		groups[group] = append(groups[group], u)
+	}
+	return single, groups
+}
+
+// handleReleaseGroup is handleUpdateNotification for a release group. A
+// member that the local version policy rejects holds back the whole group.
+func (g *Guard) handleReleaseGroup(ctx context.Context, group string, updates []updateInfo) {
+	for _, u := range updates {
+		if err := g.checkVersionPolicy(u.Component, u.Latest); err != nil {
+			g.logger.Info("skipping release group by local version policy", "group", group, "component", u.Component, "version", u.Latest, "reason", err)
+			return
+		}
+	}
+	for _, u := range updates {
+		g.notifyUpdateAvailable(u)
+	}
+	if g.cfg.OTA.AutoUpdate {
+		g.goBackground(func() { _ = g.applyReleaseGroup(ctx, group, updates) })
+	}
+}
+
+// applyReleaseGroup applies updates as one transaction, in ascending
+// GroupOrder. Components this guard does not manage are left out. While
+// one group is being applied, another fails with ErrUpdateConcurrent.
+func (g *Guard) applyReleaseGroup(ctx context.Context, group string, updates []updateInfo) error {
+	if !g.groupMu.TryLock() {
+		g.logger.Info("release group not applied, another group is in progress", "group", group)
+		return ErrUpdateConcurrent
+	}
+	defer g.groupMu.Unlock()
+
+	updates = slices.Clone(updates)
+	slices.SortStableFunc(updates, func(a, b updateInfo) int { return a.GroupOrder - b.GroupOrder })
+	var members []*groupMember
+	for _, u := range updates {
+		m, ok := g.releaseGroupMember(ctx, u)
+		if !ok {
+			g.logger.Debug("release group member not managed here", "group", group, "component", u.Component)
+			continue
+		}
+		if SameVersion(m.previous, u.Latest) {
+			continue
+		}
+		members = append(members, m)
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	defer g.dropPrefetched(members)
+
+	g.logger.Info("preparing release group", "group", group, "members", len(members))
+	for _, m := range members {
+		if err := g.prefetchGroupMember(ctx, m); err != nil {
+			err = fmt.Errorf("release group %s: %s %s: %w", group, m.update.Component, m.update.Latest, err)
+			g.logger.Error("release group not applied", "group", group, "error", err)
+			for _, other := range members {
+				g.notifyUpdateFailure(other.update.Component, other.previous, other.update.Latest, err)
+			}
+			return err
+		}
+	}
+
+	for i, m := range members {
+		u := m.update
+		u.Current = m.installed()
+		if err := m.install(u); err != nil {
+			err = fmt.Errorf("release group %s: %s %s: %w", group, u.Component, u.Latest, err)
+			g.logger.Error("release group member failed, rolling back group", "group", group, "component", u.Component, "error", err)
+			if rerr := g.rollbackReleaseGroup(ctx, members[:i], err); rerr != nil {
+				err = errors.Join(err, rerr)
+			}
+			return err
+		}
+	}
+	g.logger.Info("release group applied", "group", group, "members", len(members))
+	return nil
+}
+
+// releaseGroupMember resolves u to a component this guard updates.
+func (g *Guard) releaseGroupMember(ctx context.Context, u updateInfo) (*groupMember, bool) {
+	if u.Component == g.cfg.ComponentSlug {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, false
+		}
+		return &groupMember{
+			update:     u,
+			mc:         ManagedComponent{Slug: u.Component, Migrate: g.cfg.OTA.Migrate, MigrationFailure: g.cfg.OTA.MigrationFailure},
+			install:    func(step updateInfo) error { return g.updateBackend(ctx, step) },
+			installed:  g.currentVersion,
+			targetPath: exe,
+			stageDir:   filepath.Dir(exe),
+			previous:   g.currentVersion(),
+		}, true
+	}
+	mc, ok := g.findManagedComponent(u.Component)
+	if !ok {
+		return nil, false
+	}
+	m := &groupMember{
+		update:    u,
+		mc:        mc,
+		installed: func() string { return g.currentManagedVersion(mc.Slug) },
+		previous:  g.currentManagedVersion(mc.Slug),
+	}
+	if mc.Strategy == UpdateBackend {
+		m.install = func(step updateInfo) error { return g.updateManagedBackend(ctx, mc, step) }
+		m.targetPath = strings.TrimSpace(mc.Dir)
+		m.stageDir = filepath.Dir(m.targetPath)
+	} else {
+		m.install = func(step updateInfo) error { return g.updateFrontend(ctx, mc, step) }
+		dir, err := frontendStagingParent(mc)
+		if err != nil {
+			return nil, false
+		}
+		m.stageDir = dir
+	}
+	return m, true
+}
+
+// prefetchGroupMember downloads and verifies m's artifact and keeps it for
+// the member's update.
+func (g *Guard) prefetchGroupMember(ctx context.Context, m *groupMember) error {
+	component, version := m.update.Component, m.update.Latest
+	osValue, archValue := g.resolveOTAPlatform("", "")
+	meta, err := g.requestDownloadMeta(ctx, component, version, osValue, archValue)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+	}
+	path, digest, err := g.fetchArtifact(ctx, meta.fetchRequest(component, version, osValue, archValue), m.stageDir, g.otaMaxArtifactBytes())
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+	}
+	if digest != meta.Digest {
+		os.Remove(path)
+		return fmt.Errorf("%w: %s mismatch: expected %s, got %s", ErrUpdateVerify, meta.Algorithm, meta.Digest, digest)
+	}
+	if err := g.verifySignature(signedDigest(meta.Algorithm, meta.Digest), meta.Signature); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("%w: %v", ErrUpdateVerify, err)
+	}
+
+	g.prefetchMu.Lock()
+	defer g.prefetchMu.Unlock()
+	if g.prefetched == nil {
+		g.prefetched = make(map[string]prefetchedArtifact)
+	}
+	g.prefetched[prefetchKey(component, version)] = prefetchedArtifact{meta: meta, path: path}
+	return nil
+}
+
+// prefetchedMeta returns the download metadata a release group fetched for
+// component at version.
+func (g *Guard) prefetchedMeta(component, version string) (downloadMeta, bool) {
+	g.prefetchMu.Lock()
+	defer g.prefetchMu.Unlock()
+	p, ok := g.prefetched[prefetchKey(component, version)]
+	return p.meta, ok
+}
+
+// takePrefetchedArtifact hands the prefetched artifact for req over to the
+// caller, who then owns the file.
+func (g *Guard) takePrefetchedArtifact(req FetchRequest) (path, digest string, ok bool) {
+	_, want := req.digest()
+	g.prefetchMu.Lock()
+	defer g.prefetchMu.Unlock()
+	key := prefetchKey(req.Component, req.Version)
+	p, ok := g.prefetched[key]
+	if !ok || p.path == "" || p.meta.Digest != want {
+		return "", "", false
+	}
+	path = p.path
+	p.path = ""
+	g.prefetched[key] = p
+	return path, want, true
+}
+
+// dropPrefetched forgets the group's prefetched artifacts and removes the
+// files no update took.
+func (g *Guard) dropPrefetched(members []*groupMember) {
+	g.prefetchMu.Lock()
+	defer g.prefetchMu.Unlock()
+	for _, m := range members {
+		key := prefetchKey(m.update.Component, m.update.Latest)
+		if p, ok := g.prefetched[key]; ok && p.path != "" {
+			os.Remove(p.path)
+		}
+		delete(g.prefetched, key)
+	}
+}
+
+// rollbackReleaseGroup restores applied, newest first, to the versions they
+// had before the group, after cause failed a later member.
+func (g *Guard) rollbackReleaseGroup(ctx context.Context, applied []*groupMember, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+	for _, m := range slices.Backward(applied) {
+		if err := g.restoreGroupMember(ctx, m); err != nil {
+			err = fmt.Errorf("%w: %s back to %s: %v", ErrUpdateRollback, m.update.Component, m.previous, err)
+			g.logger.Error("release group rollback failed", "component", m.update.Component, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		event := LifecycleEvent{Component: m.update.Component, OldVersion: m.previous, NewVersion: m.update.Latest}
+		g.runRollbackHook(ctx, m.mc, event, cause)
+		g.notifyUpdateFailure(m.update.Component, m.previous, m.update.Latest, cause)
+		g.logger.Info("release group member rolled back", "component", m.update.Component, "version", m.previous)
+	}
+	return errors.Join(errs...)
+}
+
+// restoreGroupMember puts back what m's update replaced: the binary it saved
+// as .bak, the previous blue/green release, or the directory it moved to
+// .bak, and the version the guard reports. A restored binary is restarted,
+// through its systemd unit or its supervisor, so the process that runs
+// matches the version reported.
+func (g *Guard) restoreGroupMember(ctx context.Context, m *groupMember) error {
+	switch {
+	case m.targetPath != "":
+		if err := renameAtomic(m.targetPath+".bak", m.targetPath); err != nil {
+			return err
+		}
+		if err := syncDir(filepath.Dir(m.targetPath)); err != nil {
+			return err
+		}
+		if m.mc.SystemdUnit != "" {
+			if err := g.restartSystemdUnit(ctx, g.systemdManager(), m.mc); err != nil {
+				return fmt.Errorf("restart unit %s: %w", m.mc.SystemdUnit, err)
+			}
+		}
+		g.restartSupervised(m.update.Component)
+	case m.mc.BlueGreen:
+		if err := switchFrontendRelease(m.mc.Dir, m.previous); err != nil {
+			return err
+		}
+	default:
+		backup := m.mc.Dir + ".bak"
+		if _, err := os.Stat(backup); err != nil {
+			if !errors.Is(err, os.ErrNotExist) || !isUnknownVersion(m.previous) {
+				return err
+			}
+			// First install: there was nothing before it.
+			if err := os.RemoveAll(m.mc.Dir); err != nil {
+				return err
+			}
+			break
+		}
+		if err := os.RemoveAll(m.mc.Dir); err != nil {
+			return err
+		}
+		if err := os.Rename(backup, m.mc.Dir); err != nil {
+			return err
+		}
+		if err := syncDir(filepath.Dir(m.mc.Dir)); err != nil {
+			return err
+		}
+	}
+
+	if m.update.Component == g.cfg.ComponentSlug {
+		g.setVersion(m.previous, VersionSourceOTA)
+		return nil
+	}
+	g.mu.Lock()
+	g.managedVersions[m.update.Component] = m.previous
+	g.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,208 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// newReleaseGroupTestGuard serves a signed archive per component and counts
+// metadata requests and downloads per component.
+func newReleaseGroupTestGuard(t *testing.T, archives map[string][]byte, badSignature string) (*Guard, map[string]int) {
+	t.Helper()
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/v1/update/download" {
+			var body downloadMetaRequestBody
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			hits["meta:"+body.ComponentSlug]++
+			hashHex := sha256Hex(archives[body.ComponentSlug])
+			key := privKey
+			if body.ComponentSlug == badSignature {
+				key = otherKey
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/" + body.ComponentSlug,
+				"sha256":       hashHex,
+				"signature":    signUpdateHash(t, key, hashHex),
+			})
+			return
+		}
+		slug := filepath.Base(r.URL.Path)
+		hits["download:"+slug]++
+		_, _ = w.Write(archives[slug])
+	}))
+	t.Cleanup(server.Close)
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	root := t.TempDir()
+	g.managedVersions = make(map[string]string)
+	for slug := range archives {
+		dir := filepath.Join(root, slug)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		g.cfg.ManagedComponents = append(g.cfg.ManagedComponents, ManagedComponent{Slug: slug, Dir: dir, Strategy: UpdateFrontend})
+		g.managedVersions[slug] = "1.0.0"
+	}
+	return g, hits
+}
+
+func groupFile(t *testing.T, g *Guard, slug string) string {
+	t.Helper()
+	mc, _ := g.findManagedComponent(slug)
+	data, err := os.ReadFile(filepath.Join(mc.Dir, "index.html"))
+	if err != nil {
+		t.Fatalf("read %s: %v", slug, err)
+	}
+	return string(data)
+}
+
+func releaseGroupUpdates() []updateInfo {
+	return []updateInfo{
+		{Component: "site", Latest: "2.0.0", UpdateAvailable: true, Group: "r2", GroupOrder: 2},
+		{Component: "admin", Latest: "2.0.0", UpdateAvailable: true, Group: "r2", GroupOrder: 1},
+	}
+}
+
+func TestGroupReleaseUpdates(t *testing.T) {
+	single, groups := groupReleaseUpdates(append(releaseGroupUpdates(), updateInfo{Component: "worker", Latest: "3.0.0"}))
+	if len(single) != 1 || single[0].Component != "worker" {
+		t.Fatalf("single = %+v", single)
+	}
+	if members := groups["r2"]; len(members) != 2 {
+		t.Fatalf("group r2 = %+v, want admin and site", members)
+	}
+}
+
+func TestApplyReleaseGroup_AppliesAllMembersFromPrefetchedArtifacts(t *testing.T) {
+	g, hits := newReleaseGroupTestGuard(t, map[string][]byte{
+		"admin": buildTarGz(t, map[string]string{"index.html": "admin 2"}),
+		"site":  buildTarGz(t, map[string]string{"index.html": "site 2"}),
+	}, "")
+
+	if err := g.applyReleaseGroup(context.Background(), "r2", releaseGroupUpdates()); err != nil {
+		t.Fatalf("applyReleaseGroup: %v", err)
+	}
+	if groupFile(t, g, "admin") != "admin 2" || groupFile(t, g, "site") != "site 2" {
+		t.Fatalf("admin = %q, site = %q", groupFile(t, g, "admin"), groupFile(t, g, "site"))
+	}
+	for _, slug := range []string{"admin", "site"} {
+		if got := g.currentManagedVersion(slug); got != "2.0.0" {
+			t.Fatalf("%s version = %s", slug, got)
+		}
+		if hits["meta:"+slug] != 1 || hits["download:"+slug] != 1 {
+			t.Fatalf("%s fetched %d times with %d metadata requests, want the prefetched artifact reused", slug, hits["download:"+slug], hits["meta:"+slug])
+		}
+	}
+	if len(g.prefetched) != 0 {
+		t.Fatalf("prefetched artifacts left: %v", g.prefetched)
+	}
+}
+
+func TestApplyReleaseGroup_VerifiesAllBeforeApplying(t *testing.T) {
+	g, _ := newReleaseGroupTestGuard(t, map[string][]byte{
+		"admin": buildTarGz(t, map[string]string{"index.html": "admin 2"}),
+		"site":  buildTarGz(t, map[string]string{"index.html": "site 2"}),
+	}, "site")
+
+	err := g.applyReleaseGroup(context.Background(), "r2", releaseGroupUpdates())
+	if !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("err = %v, want ErrUpdateVerify", err)
+	}
+	if groupFile(t, g, "admin") != "old" || g.currentManagedVersion("admin") != "1.0.0" {
+		t.Fatal("admin was applied although site failed verification")
+	}
+}
+
+func TestApplyReleaseGroup_RollsBackAppliedMembers(t *testing.T) {
+	g, _ := newReleaseGroupTestGuard(t, map[string][]byte{
+		"admin": buildTarGz(t, map[string]string{"index.html": "admin 2"}),
+		"site":  buildTarGz(t, map[string]string{"a/b/c/index.html": "too deep"}),
+	}, "")
+	g.cfg.OTA.Extraction = ExtractionLimits{MaxDepth: 2}
+	var rolledBack []string
+	for i := range g.cfg.ManagedComponents {
+		g.cfg.ManagedComponents[i].OnRollback = func(ctx context.Context, event LifecycleEvent, cause error) {
+			rolledBack = append(rolledBack, event.Component)
+		}
+	}
+
+	err := g.applyReleaseGroup(context.Background(), "r2", releaseGroupUpdates())
+	if !errors.Is(err, ErrExtractionLimit) {
+		t.Fatalf("err = %v, want the site failure", err)
+	}
+	if groupFile(t, g, "admin") != "old" || g.currentManagedVersion("admin") != "1.0.0" {
+		t.Fatalf("admin = %q at %s, want it rolled back", groupFile(t, g, "admin"), g.currentManagedVersion("admin"))
+	}
+	if len(rolledBack) != 1 || rolledBack[0] != "admin" {
+		t.Fatalf("rollback hooks ran for %v", rolledBack)
+	}
+}
+
+func TestRestoreGroupMember_RestartsSupervisedBinary(t *testing.T) {
+	g := newLifecycleTestGuard(t, "http://127.0.0.1:1", nil, "1.0.0")
+	g.managedVersions = map[string]string{"worker": "2.0.0"}
+	target := filepath.Join(t.TempDir(), "worker")
+	if err := os.WriteFile(target, []byte("new"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target+".bak", []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mc := ManagedComponent{Slug: "worker", Exec: target, Strategy: UpdateBackend}
+	g.supervisors = newSupervisors([]ManagedComponent{mc})
+
+	m := &groupMember{update: updateInfo{Component: "worker"}, mc: mc, targetPath: target, previous: "1.0.0"}
+	if err := g.restoreGroupMember(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old" || g.currentManagedVersion("worker") != "1.0.0" {
+		t.Fatalf("binary = %q at %s, want the previous one", data, g.currentManagedVersion("worker"))
+	}
+	select {
+	case <-g.supervisors["worker"].restart:
+	default:
+		t.Fatal("supervised worker not restarted onto the restored binary")
+	}
+}
+
+func TestApplyReleaseGroup_OneGroupAtATime(t *testing.T) {
+	g, hits := newReleaseGroupTestGuard(t, map[string][]byte{
+		"admin": buildTarGz(t, map[string]string{"index.html": "admin 2"}),
+		"site":  buildTarGz(t, map[string]string{"index.html": "site 2"}),
+	}, "")
+
+	g.groupMu.Lock()
+	err := g.applyReleaseGroup(context.Background(), "r2", releaseGroupUpdates())
+	g.groupMu.Unlock()
+	if !errors.Is(err, ErrUpdateConcurrent) {
+		t.Fatalf("err = %v, want ErrUpdateConcurrent", err)
+	}
+	if len(hits) != 0 || groupFile(t, g, "admin") != "old" {
+		t.Fatalf("group ran while another was in progress: %v", hits)
+	}
+
+	if err := g.applyReleaseGroup(context.Background(), "r2", releaseGroupUpdates()); err != nil {
+		t.Fatal(err)
+	}
+	if groupFile(t, g, "admin") != "admin 2" || groupFile(t, g, "site") != "site 2" {
+		t.Fatal("group not applied once the other finished")
+	}
+}
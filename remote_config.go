@@ -0,0 +1,184 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// remoteConfigFileName is the cache entry keeping the last verified remote
+// configuration blob.
+const remoteConfigFileName = "remote_config.json"
+
+// RemoteConfig is the key-value configuration the server attaches to verify
+// and heartbeat responses for this license, such as feature toggles, limits
+// and endpoints. The zero value has no version and no values.
+type RemoteConfig struct {
+	Version string
+	// Values maps each key to its JSON value. It is shared with the guard
+	// and must not be modified.
+	Values map[string]json.RawMessage
+}
+
+// Decode unmarshals the value of key into v and reports whether key was
+// present and decoded.
+func (c RemoteConfig) Decode(key string, v any) bool {
+	raw, ok := c.Values[key]
+	return ok && json.Unmarshal(raw, v) == nil
+}
+
+// GetString returns the string value of key, or fallback when key is
+// missing or not a string.
+func (c RemoteConfig) GetString(key, fallback string) string {
+	var v string
+	if c.Decode(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// GetBool returns the boolean value of key, or fallback when key is missing
+// or not a boolean.
+func (c RemoteConfig) GetBool(key string, fallback bool) bool {
+	var v bool
+	if c.Decode(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// GetInt returns the integer value of key, or fallback when key is missing
+// or not an integer.
+func (c RemoteConfig) GetInt(key string, fallback int64) int64 {
+	var v int64
+	if c.Decode(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// remoteConfigBlob is the signed configuration in a verify or heartbeat
+// response.
+type remoteConfigBlob struct {
+	Version   string          `json:"version"`
+	Values    json.RawMessage `json:"values"`
+	Signature string          `json:"signature"`
+}
+
+// remoteConfigSignaturePayload is what the server signs for a remote
+// configuration: binding it to the machine and project keeps a blob issued
+// for one license from being replayed onto another.
+type remoteConfigSignaturePayload struct {
+	MachineID   string          `json:"machine_id"`
+	ProjectSlug string          `json:"project_slug"`
+	Version     string          `json:"version"`
+	Values      json.RawMessage `json:"values"`
+}
+
+// RemoteConfig returns the remote configuration last delivered by the
+// server. It is cached, so it is available offline and before the first
+// heartbeat after a restart.
+func (g *Guard) RemoteConfig() RemoteConfig {
+	g.loadRemoteConfig()
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return *g.remoteConfig
+}
+
+// OnRemoteConfigChange registers a callback for every newer remote
+// configuration the server delivers. Callbacks run synchronously on the
+// goroutine that received it, in registration order, and must not block.
+func (g *Guard) OnRemoteConfigChange(fn func(old, new RemoteConfig)) {
+	if fn == nil {
+		return
+	}
+	g.mu.Lock()
+	g.remoteConfigCallbacks = append(g.remoteConfigCallbacks, fn)
+	g.mu.Unlock()
+}
+
+func (g *Guard) loadRemoteConfig() {
+	g.mu.RLock()
+	loaded := g.remoteConfig != nil
+	g.mu.RUnlock()
+	if loaded {
+		return
+	}
+
+	config := RemoteConfig{}
+	if data, err := cacheStoreFor(g.cfg).Load(remoteConfigFileName); err == nil {
+		var blob remoteConfigBlob
+		if err := json.Unmarshal(data, &blob); err == nil {
+			if verified, err := g.verifyRemoteConfig(blob); err == nil {
+				config = verified
+			} else {
+				g.logger.Warn("discarding cached remote config", "error", err)
+			}
+		}
+	}
+	g.mu.Lock()
+	if g.remoteConfig == nil {
+		g.remoteConfig = &config
+	}
+	g.mu.Unlock()
+}
+
+// applyRemoteConfig adopts blob from a verified response when it is newer
+// than the current configuration. Responses without a blob keep it.
+func (g *Guard) applyRemoteConfig(blob *remoteConfigBlob) {
+	if blob == nil {
+		return
+	}
+	current := g.RemoteConfig()
+	if blob.Version == current.Version {
+		return
+	}
+	if current.Version != "" && !IsNewer(current.Version, blob.Version) {
+		g.logger.Warn("ignoring older remote config", "current_version", current.Version, "version", blob.Version)
+		return
+	}
+	next, err := g.verifyRemoteConfig(*blob)
+	if err != nil {
+		g.reportError(errorKindSignatureMismatch, g.cfg.ComponentSlug, fmt.Errorf("remote config: %w", err))
+		g.logger.Error("rejecting remote config", "version", blob.Version, "error", err)
+		return
+	}
+
+	g.mu.Lock()
+	g.remoteConfig = &next
+	callbacks := append([]func(RemoteConfig, RemoteConfig){}, g.remoteConfigCallbacks...)
+	g.mu.Unlock()
+
+	if data, err := json.Marshal(blob); err == nil {
+		if err := cacheStoreFor(g.cfg).Save(remoteConfigFileName, data); err != nil {
+			g.logger.Warn("save remote config failed", "error", err)
+		}
+	}
+	g.logger.Info("remote config applied", "old_version", current.Version, "new_version", next.Version, "keys", len(next.Values))
+	for _, fn := range callbacks {
+		fn(current, next)
+	}
+}
+
+func (g *Guard) verifyRemoteConfig(blob remoteConfigBlob) (RemoteConfig, error) {
+	raw, err := json.Marshal(remoteConfigSignaturePayload{
+		MachineID:   g.fingerprint.MachineID(),
+		ProjectSlug: g.cfg.ProjectSlug,
+		Version:     blob.Version,
+		Values:      normalizedJSONObject(blob.Values),
+	})
+	if err != nil {
+		return RemoteConfig{}, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		return RemoteConfig{}, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	if err := verifyEd25519Digest(canonical, blob.Signature, g.verificationKeys()); err != nil {
+		return RemoteConfig{}, err
+	}
+	config := RemoteConfig{Version: blob.Version}
+	if err := json.Unmarshal(normalizedJSONObject(blob.Values), &config.Values); err != nil {
+		return RemoteConfig{}, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	return config, nil
+}
@@ -0,0 +1,47 @@
+package sdk
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+func signRemoteConfig(t *testing.T, privKey ed25519.PrivateKey, machineID, version string, values json.RawMessage) *remoteConfigBlob {
+	t.Helper()
+	raw, _ := json.Marshal(remoteConfigSignaturePayload{MachineID: machineID, ProjectSlug: "test-project", Version: version, Values: values})
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &remoteConfigBlob{Version: version, Values: values, Signature: signUpdateHash(t, privKey, string(canonical))}
+}
+
+func TestApplyRemoteConfigRejectsForeignAndOlderBlobs(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	g := newLifecycleTestGuard(t, "", pubKey, "1.0.0")
+	g.cfg.CacheStore = NewMemoryCacheStore()
+	var changes int
+	g.OnRemoteConfigChange(func(old, new RemoteConfig) { changes++ })
+
+	g.applyRemoteConfig(signRemoteConfig(t, privKey, "other-machine", "1", json.RawMessage(`{"limit":1}`)))
+	if v := g.RemoteConfig().Version; v != "" {
+		t.Fatalf("version = %q, a blob signed for another machine must be rejected", v)
+	}
+
+	g.applyRemoteConfig(signRemoteConfig(t, privKey, "test-machine", "2", json.RawMessage(`{"limit":2}`)))
+	g.applyRemoteConfig(signRemoteConfig(t, privKey, "test-machine", "1", json.RawMessage(`{"limit":1}`)))
+	if config := g.RemoteConfig(); config.Version != "2" || config.GetInt("limit", 0) != 2 {
+		t.Fatalf("config = %+v, want version 2 kept", config)
+	}
+	if changes != 1 {
+		t.Fatalf("callbacks ran %d times, want 1", changes)
+	}
+
+	tampered := signRemoteConfig(t, privKey, "test-machine", "3", json.RawMessage(`{"limit":3}`))
+	tampered.Values = json.RawMessage(`{"limit":300}`)
+	g.applyRemoteConfig(tampered)
+	if v := g.RemoteConfig().Version; v != "2" {
+		t.Fatalf("version = %q after a tampered blob, want 2", v)
+	}
+}
@@ -0,0 +1,148 @@
+package sdk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Request authentication headers. Every request to the central server
+// carries an HMAC-SHA256 signature over the method, path, query, timestamp
+// and body digest, so a captured request cannot be altered or replayed later.
+//
+// The signature is keyed by the request secret the server issues on verify
+// and names it in X-BanyanHub-Key-Id. The secret only travels in the signed
+// verify reply and is kept in the sealed state, so seeing requests is not
+// enough to forge new ones. Until a secret is issued, e.g. on the very first
+// verify or against older servers, requests fall back to signing with the
+// license key, which they also carry in Authorization and which therefore
+// protects nothing beyond transport integrity. Once the guard holds a secret
+// and the server advertises CapabilityHeaderAuth, the license key is left out
+// of Authorization too and the key id alone identifies the machine.
+const (
+	headerTimestamp     = "X-BanyanHub-Timestamp"
+	headerContentSHA256 = "X-BanyanHub-Content-SHA256"
+	headerSignature     = "X-BanyanHub-Signature"
+	headerKeyID         = "X-BanyanHub-Key-Id"
+
+	authorizationScheme = "License"
+	// signedScheme names the request key id instead of the license key.
+	signedScheme = "Signed"
+	// unsignedPayload stands in for the body digest of streamed uploads,
+	// whose body is not known when the headers are written.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// requestKey is the key requests are signed with.
+type requestKey struct {
+	licenseKey string
+	// id and secret are empty until the server issued a request secret.
+	id     string
+	secret string
+	// headerOnly leaves the license key out of Authorization.
+	headerOnly bool
+}
+
+// signRequest adds the authentication headers to req. body is the payload
+// exactly as sent; pass nil for requests without one. Requests to hosts other
+// than Config.ServerURL, such as presigned storage URLs, are left untouched
+// so the license key never leaves for a third party.
+func (g *Guard) signRequest(req *http.Request, body []byte) {
+	digest := sha256.Sum256(body)
+	g.signRequestDigest(req, hex.EncodeToString(digest[:]))
+}
+
+func (g *Guard) signRequestDigest(req *http.Request, contentDigest string) {
+	if g.cfg.LicenseKey == "" || !g.isServerURL(req.URL) {
+		return
+	}
+	setRequestAuthHeaders(req, g.requestKey(), contentDigest)
+}
+
+// requestKey returns the current signing key.
+func (g *Guard) requestKey() requestKey {
+	key := requestKey{licenseKey: g.cfg.LicenseKey}
+	if state := g.currentLeaseState(); state != nil && state.RequestKeyID != "" && state.RequestSecret != "" {
+		key.id, key.secret = state.RequestKeyID, state.RequestSecret
+		g.mu.RLock()
+		key.headerOnly = g.capabilities[CapabilityHeaderAuth]
+		g.mu.RUnlock()
+	}
+	return key
+}
+
+// rememberRequestKey stores the request secret from a verified verify reply
+// in the sealed state. An empty secret keeps the one already held.
+func (g *Guard) rememberRequestKey(id, secret string) error {
+	if id == "" || secret == "" || g.store == nil {
+		return nil
+	}
+	state := g.currentLeaseState()
+	if state == nil {
+		state = &persistedState{}
+	}
+	if state.RequestKeyID == id && state.RequestSecret == secret {
+		return nil
+	}
+	state.RequestKeyID, state.RequestSecret = id, secret
+	return g.store.Save(state)
+}
+
+// setRequestAuthHeaders signs req with key; callers make sure req goes to
+// the license server.
+func setRequestAuthHeaders(req *http.Request, key requestKey, contentDigest string) {
+	timestamp := strconv.FormatInt(nowUnix(), 10)
+	secret := key.licenseKey
+	if key.secret != "" {
+		secret = key.secret
+		req.Header.Set(headerKeyID, key.id)
+	}
+	if key.secret != "" && key.headerOnly {
+		req.Header.Set("Authorization", signedScheme+" "+key.id)
+	} else {
+		req.Header.Set("Authorization", authorizationScheme+" "+key.licenseKey)
+	}
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerContentSHA256, contentDigest)
+	req.Header.Set(headerSignature, requestSignature(secret, req.Method, req.URL, timestamp, contentDigest))
+}
+
+// requestSignature returns the hex HMAC-SHA256, keyed by secret, of the
+// canonical request: method, escaped path, raw query, timestamp and body
+// digest joined by newlines.
+func requestSignature(secret, method string, u *url.URL, timestamp, contentDigest string) string {
+	canonical := strings.Join([]string{method, u.EscapedPath(), u.RawQuery, timestamp, contentDigest}, "\n")
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (g *Guard) isServerURL(u *url.URL) bool {
+	return g.endpoints().owns(u)
+}
+
+// bodyLicenseKey returns the license key to embed in request bodies and
+// query strings. It is empty once the server has advertised
+// CapabilityHeaderAuth, so the key stays out of access logs; older servers
+// still receive it in both places.
+func (g *Guard) bodyLicenseKey() string {
+	g.mu.RLock()
+	headerOnly := g.capabilities[CapabilityHeaderAuth]
+	g.mu.RUnlock()
+	if headerOnly {
+		return ""
+	}
+	return g.cfg.LicenseKey
+}
+
+// setLicenseQuery adds license_key to query unless the server authenticates
+// by header.
+func (g *Guard) setLicenseQuery(query url.Values) {
+	if key := g.bodyLicenseKey(); key != "" {
+		query.Set("license_key", key)
+	}
+}
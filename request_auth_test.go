@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRequestsCarrySignedAuthorizationHeaders(t *testing.T) {
+	requests := 0
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		digest := sha256.Sum256(body)
+		if got := r.Header.Get("Authorization"); got != "License test-license" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get(headerContentSHA256); got != hex.EncodeToString(digest[:]) {
+			t.Errorf("content digest = %q", got)
+		}
+		want := requestSignature("test-license", r.Method, r.URL, r.Header.Get(headerTimestamp), r.Header.Get(headerContentSHA256))
+		if got := r.Header.Get(headerSignature); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		requests++
+		_, _ = w.Write([]byte(`{"capabilities":["header_auth"]}`))
+	})
+	ctx := context.Background()
+
+	if _, err := g.postJSON(ctx, "/api/v1/update/download", []byte(`{"license_key":"test-license"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.getJSON(ctx, "/api/v1/plugins/catalog", url.Values{"project_slug": {"p"}}); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests)
+	}
+
+	if g.bodyLicenseKey() != "test-license" {
+		t.Fatal("license key must stay in bodies until the server advertises header auth")
+	}
+	g.probeCapabilities(ctx)
+	if key := g.bodyLicenseKey(); key != "" {
+		t.Fatalf("bodyLicenseKey after header_auth = %q", key)
+	}
+	query := url.Values{}
+	g.setLicenseQuery(query)
+	if query.Has("license_key") {
+		t.Fatalf("license key leaked into query: %s", query.Encode())
+	}
+}
+
+func TestSignRequestSkipsOtherHosts(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	req, err := http.NewRequest(http.MethodGet, "https://storage.example.com/artifact.bin?sig=abc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.signRequest(req, nil)
+	for name := range req.Header {
+		if strings.EqualFold(name, "Authorization") || strings.HasPrefix(name, "X-Banyanhub-") {
+			t.Fatalf("presigned download got auth header %s", name)
+		}
+	}
+}
+
+func TestRequestsSignWithIssuedSecret(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"capabilities":["header_auth"]}`))
+	})
+	if g.store == nil {
+		t.Skip("guard has no state store")
+	}
+	if err := g.rememberRequestKey("key-1", "machine-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, g.cfg.ServerURL+"/api/v1/plugins/catalog", nil)
+	g.signRequest(req, nil)
+	if got := req.Header.Get("Authorization"); got != "License test-license" {
+		t.Fatalf("Authorization before header auth = %q", got)
+	}
+	want := requestSignature("machine-secret", req.Method, req.URL, req.Header.Get(headerTimestamp), req.Header.Get(headerContentSHA256))
+	if got := req.Header.Get(headerSignature); got != want || req.Header.Get(headerKeyID) != "key-1" {
+		t.Fatalf("signature = %q with key id %q, want one keyed by the issued secret", got, req.Header.Get(headerKeyID))
+	}
+
+	g.probeCapabilities(context.Background())
+	req, _ = http.NewRequest(http.MethodGet, g.cfg.ServerURL+"/api/v1/plugins/catalog", nil)
+	g.signRequest(req, nil)
+	for name, values := range req.Header {
+		for _, v := range values {
+			if strings.Contains(v, "test-license") {
+				t.Fatalf("license key sent in %s after header auth", name)
+			}
+		}
+	}
+	if got := req.Header.Get("Authorization"); got != "Signed key-1" {
+		t.Fatalf("Authorization = %q", got)
+	}
+}
@@ -0,0 +1,136 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	responseLogBucket     = "responses"
+	defaultResponseLogMax = 20
+	redactedValue         = "[redacted]"
+)
+
+// RecordedResponse is one redacted server reply kept for offline diagnosis
+// when DebugConfig.RecordResponses is enabled.
+type RecordedResponse struct {
+	Time     time.Time       `json:"time"`
+	Endpoint string          `json:"endpoint"`
+	Error    string          `json:"error,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"`
+}
+
+// RecordedResponses returns the retained heartbeat/verify responses, oldest
+// first. It returns an empty slice when recording is disabled or nothing has
+// been captured yet.
+func (g *Guard) RecordedResponses() ([]RecordedResponse, error) {
+	store, err := g.storage()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := store.List(responseLogBucket)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]RecordedResponse, 0, len(stored))
+	for _, item := range stored {
+		var entry RecordedResponse
+		if err := json.Unmarshal(item.Value, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// recordResponse stores a redacted copy of a server reply (or the error that
+// replaced it) and trims the bucket to the newest MaxRecordedResponses.
+// Failures are logged, never fatal.
+func (g *Guard) recordResponse(endpoint string, raw []byte, callErr error) {
+	if !g.cfg.Debug.RecordResponses {
+		return
+	}
+	entry := RecordedResponse{
+		Time:     time.Now().UTC(),
+		Endpoint: endpoint,
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+	if len(bytes.TrimSpace(raw)) > 0 {
+		entry.Body = redactJSON(raw, isSensitiveResponseKey)
+	}
+	value, err := json.Marshal(entry)
+	if err != nil {
+		g.logger.Warn("failed to encode response log entry", "error", err)
+		return
+	}
+
+	g.responseLogMu.Lock()
+	defer g.responseLogMu.Unlock()
+	store, err := g.storage()
+	if err != nil {
+		g.logger.Warn("response log unavailable", "error", err)
+		return
+	}
+	key := fmt.Sprintf("%020d", entry.Time.UnixNano())
+	if err := store.Put(responseLogBucket, key, value); err != nil {
+		g.logger.Warn("failed to write response log", "error", err)
+		return
+	}
+	stored, err := store.List(responseLogBucket)
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(stored)-g.cfg.Debug.MaxRecordedResponses; i++ {
+		if err := store.Delete(responseLogBucket, stored[i].Key); err != nil {
+			g.logger.Warn("failed to trim response log", "error", err)
+			return
+		}
+	}
+}
+
+// redactJSON masks the values of sensitive keys so the log can be shared
+// with support without leaking license material.
+func redactJSON(raw []byte, sensitive func(key string) bool) json.RawMessage {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		quoted, _ := json.Marshal(redactedValue)
+		return quoted
+	}
+	redacted, err := json.Marshal(redactJSONValue(value, sensitive))
+	if err != nil {
+		quoted, _ := json.Marshal(redactedValue)
+		return quoted
+	}
+	return redacted
+}
+
+func redactJSONValue(value any, sensitive func(key string) bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if sensitive(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactJSONValue(child, sensitive)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactJSONValue(child, sensitive)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitiveResponseKey(key string) bool {
+	key = strings.ToLower(key)
+	return key == "license_key" || key == "content" || strings.Contains(key, "signature") || strings.Contains(key, "token") || strings.Contains(key, "secret")
+}
@@ -0,0 +1,65 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRecordResponse_DisabledByDefault(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.recordResponse("heartbeat", []byte(`{"status":"ok"}`), nil)
+
+	entries, err := guard.RecordedResponses()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected no recorded responses, got %v, %v", entries, err)
+	}
+}
+
+func TestRecordResponse_RedactsAndKeepsLastN(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	guard.cfg.Debug = DebugConfig{RecordResponses: true, MaxRecordedResponses: 2}
+
+	guard.recordResponse("verify", []byte(`{"lease":{"license_key":"LIC-1","tier":"pro"},"lease_signature":"c2ln"}`), nil)
+	guard.recordResponse("heartbeat", nil, errors.New("machine banned"))
+	guard.recordResponse("heartbeat", []byte(`{"status":"kill","response_signature":"c2ln","reason":"abuse"}`), nil)
+
+	entries, err := guard.RecordedResponses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the last 2 entries, got %d", len(entries))
+	}
+	if entries[0].Endpoint != "heartbeat" || entries[0].Error != "machine banned" {
+		t.Fatalf("unexpected first entry: %#v", entries[0])
+	}
+	body := string(entries[1].Body)
+	if strings.Contains(body, "c2ln") || !strings.Contains(body, `"reason":"abuse"`) {
+		t.Fatalf("expected signature redacted and reason kept, got %s", body)
+	}
+}
+
+func TestHeartbeat_RecordsResponseWhenEnabled(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.cfg.Debug = DebugConfig{RecordResponses: true, MaxRecordedResponses: 5}
+
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	entries, err := guard.RecordedResponses()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one recorded heartbeat, got %v, %v", entries, err)
+	}
+	if strings.Contains(string(entries[0].Body), "test-license") || strings.Contains(string(entries[0].Body), sig) {
+		t.Fatalf("license material leaked into response log: %s", entries[0].Body)
+	}
+}
@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how idempotent API calls are retried after transient
+// failures: connection resets, timeouts, 429 and 5xx responses. Verify,
+// heartbeat and submissions are never retried because the server must not
+// see them twice.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries per call (default 3); 1
+	// disables retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry (default 500ms); it
+	// doubles on every further attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts (default 10s). A Retry-After
+	// longer than this ends retrying and returns the server's response.
+	MaxBackoff time.Duration
+}
+
+// doRequest sends the request built by newRequest, retrying transient
+// failures per Config.Retry when retry is set. newRequest is called once per
+// attempt so the body can be replayed.
+func (g *Guard) doRequest(ctx context.Context, retry bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	attempts := 1
+	if retry && g.cfg.Retry.MaxAttempts > 1 {
+		attempts = g.cfg.Retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		resp, err := g.httpClient.Do(req)
+		g.observeRequest(req.URL, start)
+		if attempt >= attempts || ctx.Err() != nil {
+			return resp, err
+		}
+		wait, ok := retryDelay(g.cfg.Retry, attempt, resp, err)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxAPIErrorBodyBytes))
+			resp.Body.Close()
+		}
+		g.logger.Debug("retrying transient request failure", "path", req.URL.Path, "attempt", attempt, "wait", wait.String())
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay decides whether an attempt's outcome is transient and how long
+// to wait before the next one.
+func retryDelay(policy RetryPolicy, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		return retryBackoff(policy, attempt), isTransientNetError(err)
+	}
+	if !retryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if wait > policy.MaxBackoff {
+			return 0, false
+		}
+		return wait, true
+	}
+	return retryBackoff(policy, attempt), true
+}
+
+// retryableStatus reports rate limiting and server failures other than 501,
+// which says the endpoint does not exist.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+}
+
+func retryBackoff(policy RetryPolicy, attempt int) time.Duration {
+	wait := policy.InitialBackoff
+	for i := 1; i < attempt && wait < policy.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > policy.MaxBackoff {
+		wait = policy.MaxBackoff
+	}
+	return wait
+}
+
+// parseRetryAfter accepts both forms of the header: delay seconds and an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := at.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// isTransientNetError reports connection resets, refusals, timeouts and
+// truncated responses. TLS pin and certificate failures are permanent.
+func isTransientNetError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrTLSPinMismatch) || errors.Is(err, ErrTLSPinNotConfigured) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryTestGuard(t *testing.T, handler http.HandlerFunc) (*Guard, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	g, err := NewForTesting(Config{
+		ServerURL: server.URL,
+		Retry:     RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(g.cfg.cacheDir) })
+	return g, &calls
+}
+
+func TestGetJSONRetriesTransientServerErrors(t *testing.T) {
+	var failures atomic.Int32
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		if failures.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})
+
+	if _, err := g.getJSON(context.Background(), "/api/v1/capabilities", nil); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryGivesUpWhenRetryAfterExceedsMaxBackoff(t *testing.T) {
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := g.getJSON(context.Background(), "/api/v1/capabilities", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the 429 to be returned, got %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestNonIdempotentPostIsNotRetried(t *testing.T) {
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	if _, err := g.postJSON(context.Background(), "/api/v1/heartbeat", []byte(`{}`)); err == nil {
+		t.Fatal("expected the 502 to be returned")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("heartbeat must not be retried, got %d attempts", got)
+	}
+
+	calls.Store(0)
+	if _, err := g.postIdempotentJSON(context.Background(), "/api/v1/update/download", []byte(`{}`)); err == nil {
+		t.Fatal("expected the 502 to be returned")
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("download metadata should be retried, got %d attempts", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{"-1", 0, false},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{"soon", 0, false},
+	} {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAPITimeoutBoundsStalledServer(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	g.cfg.Timeouts.API = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := g.GetPluginCatalog(context.Background(), true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the API timeout to fire, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("API timeout ignored, call took %v", elapsed)
+	}
+}
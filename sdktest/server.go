@@ -0,0 +1,941 @@
+// Package sdktest runs a fake BanyanHub server for testing applications that
+// embed the SDK guard. It issues signed leases on verify and heartbeat,
+// offers published releases and plugins with signed download metadata, and
+// keeps feedback in memory:
+//
+//	srv := sdktest.NewServer(t)
+//	srv.AddLicense(sdktest.License{Key: "LIC-TEST"})
+//	t.Setenv("HOME", t.TempDir()) // keep the guard cache out of the real home
+//	guard, err := sdk.New(srv.Config("LIC-TEST"))
+//
+// The server speaks plain HTTP, so no SPKI pins are needed. It advertises
+// header authentication and checks the license key in the Authorization
+// header of every request; request signatures are not verified.
+package sdktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+const (
+	// DefaultProjectSlug and DefaultComponentSlug are used by Config and by
+	// licenses that do not name a project.
+	DefaultProjectSlug   = "demo-project"
+	DefaultComponentSlug = "backend"
+
+	defaultLeaseTTL = 24 * time.Hour
+)
+
+// License is a license key the server accepts.
+type License struct {
+	Key string
+	// ProjectSlug defaults to DefaultProjectSlug.
+	ProjectSlug string
+	Tier        string
+	Features    []string
+	// MaxMachines bounds the machines that may verify (default 1).
+	MaxMachines int
+	// Seats bounds the user seats held at once; zero means unlimited.
+	Seats int
+	// ExpiresAt ends the license; zero never expires. Leases run for a day
+	// or until ExpiresAt, whichever is sooner.
+	ExpiresAt time.Time
+}
+
+// Release is an update artifact published for a component.
+type Release struct {
+	Component    string
+	Version      string
+	Artifact     []byte
+	Mandatory    bool
+	ReleaseNotes string
+	// Required releases must be installed on the way to any later version:
+	// heartbeats list them as the upgrade path of guards below them.
+	Required bool
+}
+
+// Plugin is a plugin in the catalog. Artifact is served for its
+// LatestVersion.
+type Plugin struct {
+	Info     sdk.PluginInfo
+	Artifact []byte
+}
+
+type feedbackRecord struct {
+	userID    string
+	machineID string
+	item      sdk.FeedbackItem
+}
+
+type feedbackReply struct {
+	FeedbackID string            `json:"feedback_id"`
+	Reply      sdk.FeedbackReply `json:"reply"`
+}
+
+// Server is a fake BanyanHub server. Its methods are safe for concurrent use
+// with the guard under test.
+type Server struct {
+	// URL is the base URL for Config.ServerURL.
+	URL    string
+	Signer *Signer
+
+	srv *httptest.Server
+
+	mu             sync.Mutex
+	licenses       map[string]License
+	machines       map[string]map[string]bool
+	killed         map[string]string
+	releases       map[string]map[string]Release
+	plugins        []Plugin
+	feedbacks      []*feedbackRecord
+	pendingReplies map[string][]feedbackReply
+	heartbeats     int
+	usage          map[string]map[string]int64
+	seats          map[string]map[string]string
+	seatSeq        int
+	remoteConfigs  map[string]remoteConfig
+	announcements  []sdk.Announcement
+}
+
+type remoteConfig struct {
+	version string
+	values  map[string]any
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	signer, err := NewSigner()
+	if err != nil {
+		tb.Fatalf("sdktest: %v", err)
+	}
+	s := &Server{
+		Signer:         signer,
+		licenses:       make(map[string]License),
+		machines:       make(map[string]map[string]bool),
+		killed:         make(map[string]string),
+		releases:       make(map[string]map[string]Release),
+		pendingReplies: make(map[string][]feedbackReply),
+		usage:          make(map[string]map[string]int64),
+		seats:          make(map[string]map[string]string),
+		remoteConfigs:  make(map[string]remoteConfig),
+	}
+	s.srv = httptest.NewServer(s.routes())
+	s.URL = s.srv.URL
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Config returns a guard configuration for licenseKey pointed at the
+// server, for DefaultProjectSlug and DefaultComponentSlug.
+func (s *Server) Config(licenseKey string) sdk.Config {
+	return sdk.Config{
+		ServerURL:     s.URL,
+		LicenseKey:    licenseKey,
+		PublicKeyPEM:  s.Signer.PublicKeyPEM(),
+		ProjectSlug:   DefaultProjectSlug,
+		ComponentSlug: DefaultComponentSlug,
+	}
+}
+
+// AddLicense makes the server accept license.Key.
+func (s *Server) AddLicense(license License) {
+	if license.ProjectSlug == "" {
+		license.ProjectSlug = DefaultProjectSlug
+	}
+	if license.MaxMachines <= 0 {
+		license.MaxMachines = 1
+	}
+	if license.Tier == "" {
+		license.Tier = "commercial"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.licenses[license.Key] = license
+}
+
+// RevokeLicense makes further verify and heartbeat calls for key fail with
+// license_revoked.
+func (s *Server) RevokeLicense(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.licenses, key)
+}
+
+// Kill answers the next heartbeats for key with a kill command.
+func (s *Server) Kill(key, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.killed[key] = reason
+}
+
+// SetRemoteConfig attaches a signed remote configuration to the verify and
+// heartbeat responses for key. Guards adopt it when version is newer than
+// the one they have.
+func (s *Server) SetRemoteConfig(key, version string, values map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remoteConfigs[key] = remoteConfig{version: version, values: values}
+}
+
+// Announce delivers announcement with every heartbeat and lists it on the
+// announcements endpoint. Targeting fields are left to the guard to apply.
+func (s *Server) Announce(announcement sdk.Announcement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.announcements = append(s.announcements, announcement)
+}
+
+// PublishRelease offers release to guards reporting an older version of its
+// component in their heartbeats. The newest published version of a component
+// is the one offered; older ones stay downloadable.
+func (s *Server) PublishRelease(release Release) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.releases[release.Component] == nil {
+		s.releases[release.Component] = make(map[string]Release)
+	}
+	s.releases[release.Component][release.Version] = release
+}
+
+// latestRelease returns the newest published release of component.
+func (s *Server) latestRelease(component string) (Release, bool) {
+	var latest Release
+	found := false
+	for _, release := range s.releases[component] {
+		if !found || sdk.IsNewer(latest.Version, release.Version) {
+			latest, found = release, true
+		}
+	}
+	return latest, found
+}
+
+// upgradePath lists the required releases of component newer than from and
+// older than latest, oldest first.
+func (s *Server) upgradePath(component, from, latest string) []string {
+	var path []string
+	for _, release := range s.releases[component] {
+		if release.Required && sdk.IsNewer(from, release.Version) && sdk.IsNewer(release.Version, latest) {
+			path = append(path, release.Version)
+		}
+	}
+	sort.Slice(path, func(i, j int) bool { return sdk.IsNewer(path[i], path[j]) })
+	return path
+}
+
+// AddPlugin adds a plugin to the catalog.
+func (s *Server) AddPlugin(plugin Plugin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plugins = append(s.plugins, plugin)
+}
+
+// Heartbeats returns how many heartbeats the server has answered.
+func (s *Server) Heartbeats() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.heartbeats
+}
+
+// Usage returns the usage quantities reported in heartbeats for key, summed
+// per feature.
+func (s *Server) Usage(key string) map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage := make(map[string]int64, len(s.usage[key]))
+	for feature, qty := range s.usage[key] {
+		usage[feature] = qty
+	}
+	return usage
+}
+
+// Seats returns the users holding a seat of key, sorted.
+func (s *Server) Seats(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]string, 0, len(s.seats[key]))
+	for _, user := range s.seats[key] {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// Feedback returns the submitted feedback items, oldest first.
+func (s *Server) Feedback() []sdk.FeedbackItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := make([]sdk.FeedbackItem, 0, len(s.feedbacks))
+	for _, record := range s.feedbacks {
+		items = append(items, record.item)
+	}
+	return items
+}
+
+// ReplyToFeedback adds a support reply to a feedback item and delivers it
+// with the next heartbeat. It reports false when the item does not exist.
+func (s *Server) ReplyToFeedback(feedbackID, author, content string) (sdk.FeedbackReply, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range s.feedbacks {
+		if record.item.ID != feedbackID {
+			continue
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		reply := sdk.FeedbackReply{
+			ID:         fmt.Sprintf("%s-r%d", feedbackID, len(record.item.Replies)+1),
+			Author:     author,
+			AuthorRole: "support",
+			Content:    content,
+			CreatedAt:  now,
+		}
+		record.item.Replies = append(record.item.Replies, reply)
+		record.item.Status = sdk.FeedbackProcessing
+		record.item.UpdatedAt = now
+		s.pendingReplies[record.machineID] = append(s.pendingReplies[record.machineID], feedbackReply{FeedbackID: feedbackID, Reply: reply})
+		return reply, true
+	}
+	return sdk.FeedbackReply{}, false
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/capabilities", s.handleCapabilities)
+	mux.HandleFunc("POST /api/v1/verify", s.handleVerify)
+	mux.HandleFunc("POST /api/v1/heartbeat", s.handleHeartbeat)
+	mux.HandleFunc("POST /api/v1/deactivate", s.handleDeactivate)
+	mux.HandleFunc("POST /api/v1/seats/acquire", s.handleAcquireSeat)
+	mux.HandleFunc("POST /api/v1/seats/release", s.handleReleaseSeat)
+	mux.HandleFunc("POST /api/v1/update/download", s.handleDownloadMeta)
+	mux.HandleFunc("GET /api/v1/plugins/catalog", s.handlePluginCatalog)
+	mux.HandleFunc("POST /api/v1/plugins/{slug}/update", s.handlePluginUpdate)
+	mux.HandleFunc("POST /api/v1/feedbacks", s.handleSubmitFeedback)
+	mux.HandleFunc("GET /api/v1/feedbacks", s.handleListFeedback)
+	mux.HandleFunc("GET /api/v1/feedbacks/{id}", s.handleGetFeedback)
+	mux.HandleFunc("GET /api/v1/announcements", s.handleAnnouncements)
+	mux.HandleFunc("GET /artifacts/{kind}/{name}/{version}", s.handleArtifact)
+	return mux
+}
+
+func (s *Server) handleCapabilities(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"capabilities": []sdk.Capability{sdk.CapabilityPlugins, sdk.CapabilityFeedback, sdk.CapabilityAnnouncements, sdk.CapabilityHeaderAuth},
+	})
+}
+
+type verifyRequest struct {
+	MachineID string `json:"machine_id"`
+	Nonce     string `json:"nonce"`
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	var body verifyRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	license, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	bound := s.machines[license.Key]
+	if !bound[body.MachineID] {
+		if len(bound) >= license.MaxMachines {
+			writeError(w, http.StatusForbidden, "max_machines_exceeded", "machine limit reached")
+			return
+		}
+		if bound == nil {
+			bound = make(map[string]bool)
+			s.machines[license.Key] = bound
+		}
+		bound[body.MachineID] = true
+	}
+	resp, err := s.leaseResponse(license, body.MachineID, body.Nonce)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type heartbeatRequest struct {
+	MachineID  string `json:"machine_id"`
+	Nonce      string `json:"nonce"`
+	Components []struct {
+		Slug       string `json:"slug"`
+		Version    string `json:"version"`
+		ReportOnly bool   `json:"report_only"`
+	} `json:"components"`
+	Usage []struct {
+		Feature  string `json:"feature"`
+		Quantity int64  `json:"quantity"`
+	} `json:"usage"`
+}
+
+// updateInfo mirrors the update entries of a heartbeat reply, whose digest
+// the response signature covers.
+type updateInfo struct {
+	Component       string   `json:"component"`
+	Current         string   `json:"current"`
+	Latest          string   `json:"latest"`
+	UpdateAvailable bool     `json:"update_available"`
+	Mandatory       bool     `json:"mandatory"`
+	ReleaseNotes    string   `json:"release_notes"`
+	UpgradePath     []string `json:"upgrade_path,omitempty"`
+}
+
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	var body heartbeatRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	license, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	if !s.machines[license.Key][body.MachineID] {
+		writeError(w, http.StatusForbidden, "machine_not_registered", "machine has not verified")
+		return
+	}
+	s.heartbeats++
+	for _, u := range body.Usage {
+		if s.usage[license.Key] == nil {
+			s.usage[license.Key] = make(map[string]int64)
+		}
+		s.usage[license.Key][u.Feature] += u.Quantity
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if reason, killed := s.killed[license.Key]; killed {
+		resp := map[string]any{"status": "kill", "reason": reason, "nonce": body.Nonce, "server_time": now}
+		payload := map[string]any{
+			"lease":           json.RawMessage("{}"),
+			"lease_signature": "",
+			"nonce":           body.Nonce,
+			"server_time":     now,
+			"status":          "kill",
+			"updates_digest":  digest([]updateInfo{}),
+		}
+		if reason != "" {
+			payload["reason"] = reason
+		}
+		signature, err := s.Signer.SignJSON(payload)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		resp["response_signature"] = signature
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	updates := []updateInfo{}
+	for _, component := range body.Components {
+		release, ok := s.latestRelease(component.Slug)
+		if !ok || component.ReportOnly {
+			continue
+		}
+		updates = append(updates, updateInfo{
+			Component:       component.Slug,
+			Current:         component.Version,
+			Latest:          release.Version,
+			UpdateAvailable: sdk.IsNewer(component.Version, release.Version),
+			Mandatory:       release.Mandatory,
+			ReleaseNotes:    release.ReleaseNotes,
+			UpgradePath:     s.upgradePath(component.Slug, component.Version, release.Version),
+		})
+	}
+	leaseJSON, leaseSignature, err := s.Signer.SignLease(s.lease(license, body.MachineID))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	payload := map[string]any{
+		"lease":           leaseJSON,
+		"lease_signature": leaseSignature,
+		"nonce":           body.Nonce,
+		"server_time":     now,
+		"status":          "ok",
+		"updates_digest":  digest(updates),
+	}
+	if len(s.announcements) > 0 {
+		payload["announcements_digest"] = digest(s.announcements)
+	}
+	signature, err := s.Signer.SignJSON(payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	remoteConfig, err := s.remoteConfigBlob(license, body.MachineID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	replies := s.pendingReplies[body.MachineID]
+	delete(s.pendingReplies, body.MachineID)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":             "ok",
+		"lease":              leaseJSON,
+		"lease_signature":    leaseSignature,
+		"response_signature": signature,
+		"nonce":              body.Nonce,
+		"server_time":        now,
+		"updates":            updates,
+		"feedback_replies":   replies,
+		"remote_config":      remoteConfig,
+		"announcements":      s.announcements,
+	})
+}
+
+func (s *Server) handleDeactivate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MachineID string `json:"machine_id"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	license, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	delete(s.machines[license.Key], body.MachineID)
+	writeJSON(w, http.StatusOK, map[string]any{"status": "deactivated"})
+}
+
+type seatRequest struct {
+	UserID string `json:"user_id"`
+	SeatID string `json:"seat_id"`
+}
+
+func (s *Server) handleAcquireSeat(w http.ResponseWriter, r *http.Request) {
+	var body seatRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	license, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	if body.UserID == "" {
+		writeError(w, http.StatusBadRequest, "missing_user_id", "user_id is required")
+		return
+	}
+	held := s.seats[license.Key]
+	if held == nil {
+		held = make(map[string]string)
+		s.seats[license.Key] = held
+	}
+	if license.Seats > 0 && len(held) >= license.Seats {
+		writeError(w, http.StatusConflict, "seats_exhausted", "all seats are in use")
+		return
+	}
+	s.seatSeq++
+	seatID := "seat-" + strconv.Itoa(s.seatSeq)
+	held[seatID] = body.UserID
+	writeJSON(w, http.StatusOK, map[string]any{
+		"seat_id":     seatID,
+		"user_id":     body.UserID,
+		"acquired_at": time.Now().UTC().Format(time.RFC3339),
+		"seats_used":  len(held),
+		"seats_total": license.Seats,
+	})
+}
+
+func (s *Server) handleReleaseSeat(w http.ResponseWriter, r *http.Request) {
+	var body seatRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	license, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	delete(s.seats[license.Key], body.SeatID)
+	writeJSON(w, http.StatusOK, map[string]any{"status": "released"})
+}
+
+func (s *Server) handleDownloadMeta(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ComponentSlug string `json:"component_slug"`
+		Version       string `json:"version"`
+		OS            string `json:"os"`
+		Arch          string `json:"arch"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authorize(w, r); !ok {
+		return
+	}
+	release, ok := s.releases[body.ComponentSlug][body.Version]
+	if !ok {
+		writeError(w, http.StatusNotFound, "version_not_found", "no such release")
+		return
+	}
+	downloadURL := artifactPath("releases", release.Component, release.Version)
+	hash, signature := s.Signer.SignArtifact(release.Artifact)
+	metadataSignature, err := s.Signer.SignJSON(map[string]string{
+		"component":    body.ComponentSlug,
+		"version":      body.Version,
+		"os":           body.OS,
+		"arch":         body.Arch,
+		"download_url": downloadURL,
+		"sha256":       hash,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"download_url":       downloadURL,
+		"sha256":             hash,
+		"signature":          signature,
+		"metadata_signature": metadataSignature,
+	})
+}
+
+func (s *Server) handlePluginCatalog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	license, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	catalog := sdk.PluginCatalog{
+		ProjectSlug: license.ProjectSlug,
+		MachineID:   query.Get("machine_id"),
+		SourceOS:    query.Get("os"),
+		SourceArch:  query.Get("arch"),
+		Plugins:     []sdk.PluginInfo{},
+	}
+	for _, plugin := range s.plugins {
+		catalog.Plugins = append(catalog.Plugins, plugin.Info)
+	}
+	writeJSON(w, http.StatusOK, catalog)
+}
+
+func (s *Server) handlePluginUpdate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		OS   string `json:"os"`
+		Arch string `json:"arch"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authorize(w, r); !ok {
+		return
+	}
+	slug := r.PathValue("slug")
+	for _, plugin := range s.plugins {
+		if plugin.Info.Slug != slug || plugin.Info.LatestVersion == nil {
+			continue
+		}
+		version := *plugin.Info.LatestVersion
+		hash, signature := s.Signer.SignArtifact(plugin.Artifact)
+		downloadURL := artifactPath("plugins", slug, version)
+		metadataSignature, err := s.Signer.SignJSON(map[string]string{
+			"component":    slug,
+			"version":      version,
+			"os":           body.OS,
+			"arch":         body.Arch,
+			"download_url": downloadURL,
+			"sha256":       hash,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, sdk.PluginUpdatePackage{
+			Plugin:            slug,
+			CurrentVersion:    plugin.Info.InstalledVersion,
+			TargetVersion:     version,
+			UpdateAvailable:   plugin.Info.UpdateAvailable,
+			DownloadURL:       downloadURL,
+			SHA256:            hash,
+			Signature:         signature,
+			SizeBytes:         int64(len(plugin.Artifact)),
+			ReleaseNotes:      plugin.Info.ReleaseNotes,
+			MetadataSignature: metadataSignature,
+		})
+		return
+	}
+	writeError(w, http.StatusNotFound, "plugin_not_found", "no such plugin")
+}
+
+func (s *Server) handleArtifact(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var data []byte
+	found := false
+	name, version := r.PathValue("name"), r.PathValue("version")
+	switch r.PathValue("kind") {
+	case "releases":
+		if release, ok := s.releases[name][version]; ok {
+			data, found = release.Artifact, true
+		}
+	case "plugins":
+		for _, plugin := range s.plugins {
+			if plugin.Info.Slug == name && plugin.Info.LatestVersion != nil && *plugin.Info.LatestVersion == version {
+				data, found = plugin.Artifact, true
+			}
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "artifact_not_found", "no such artifact")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	_, _ = w.Write(data)
+}
+
+func (s *Server) handleAnnouncements(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authorize(w, r); !ok {
+		return
+	}
+	announcements := append([]sdk.Announcement{}, s.announcements...)
+	writeJSON(w, http.StatusOK, map[string]any{"announcements": announcements})
+}
+
+func (s *Server) handleSubmitFeedback(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MachineID  string               `json:"machine_id"`
+		UserID     string               `json:"user_id"`
+		Category   sdk.FeedbackCategory `json:"category"`
+		Title      string               `json:"title"`
+		Content    string               `json:"content"`
+		AppVersion string               `json:"app_version"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.UserID == "" || body.Title == "" {
+		writeError(w, http.StatusBadRequest, "missing_params", "user_id and title are required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authorize(w, r); !ok {
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	record := &feedbackRecord{
+		userID:    body.UserID,
+		machineID: body.MachineID,
+		item: sdk.FeedbackItem{
+			ID:         fmt.Sprintf("fb-%d", len(s.feedbacks)+1),
+			Category:   body.Category,
+			Status:     sdk.FeedbackPending,
+			Title:      body.Title,
+			Content:    body.Content,
+			AppVersion: body.AppVersion,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+	}
+	s.feedbacks = append(s.feedbacks, record)
+	writeJSON(w, http.StatusCreated, record.item)
+}
+
+func (s *Server) handleListFeedback(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authorize(w, r); !ok {
+		return
+	}
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	userID, statuses := query.Get("user_id"), query.Get("status")
+
+	var matched []sdk.FeedbackItem
+	for i := len(s.feedbacks) - 1; i >= 0; i-- {
+		record := s.feedbacks[i]
+		if userID != "" && record.userID != userID {
+			continue
+		}
+		item := record.item
+		if statuses != "" && !containsValue(statuses, string(item.Status)) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+	resp := sdk.FeedbackListResponse{
+		Feedbacks:  []sdk.FeedbackItem{},
+		Pagination: sdk.FeedbackListPagination{Total: len(matched), Page: page, PageSize: pageSize},
+	}
+	if start := (page - 1) * pageSize; start < len(matched) {
+		resp.Feedbacks = matched[start:min(start+pageSize, len(matched))]
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGetFeedback(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authorize(w, r); !ok {
+		return
+	}
+	for _, record := range s.feedbacks {
+		if record.item.ID == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, record.item)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found", "no such feedback")
+}
+
+// authorize resolves the license of the Authorization header. It writes the
+// error response and reports false when there is none. s.mu must be held.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) (License, bool) {
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "License ")
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "missing_license_key", "authorization header required")
+		return License{}, false
+	}
+	license, ok := s.licenses[key]
+	if !ok {
+		writeError(w, http.StatusForbidden, "license_revoked", "license not found or revoked")
+		return License{}, false
+	}
+	if !license.ExpiresAt.IsZero() && time.Now().After(license.ExpiresAt) {
+		writeError(w, http.StatusForbidden, "license_expired", "license expired")
+		return License{}, false
+	}
+	return license, true
+}
+
+func (s *Server) lease(license License, machineID string) Lease {
+	ttl := defaultLeaseTTL
+	if !license.ExpiresAt.IsZero() {
+		ttl = min(ttl, time.Until(license.ExpiresAt))
+	}
+	lease := NewLease(license.Key, license.ProjectSlug, machineID, ttl)
+	lease.Features = license.Features
+	lease.MaxMachines = license.MaxMachines
+	lease.Tier = license.Tier
+	lease.MachinesInUse = len(s.machines[license.Key])
+	return lease
+}
+
+func (s *Server) leaseResponse(license License, machineID, nonce string) (map[string]any, error) {
+	leaseJSON, leaseSignature, err := s.Signer.SignLease(s.lease(license, machineID))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	signature, err := s.Signer.SignJSON(map[string]any{
+		"lease":           leaseJSON,
+		"lease_signature": leaseSignature,
+		"nonce":           nonce,
+		"server_time":     now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	remoteConfig, err := s.remoteConfigBlob(license, machineID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"lease":              leaseJSON,
+		"lease_signature":    leaseSignature,
+		"server_time":        now,
+		"nonce":              nonce,
+		"response_signature": signature,
+		"remote_config":      remoteConfig,
+	}, nil
+}
+
+// remoteConfigBlob signs the remote configuration of license for machineID,
+// or returns nil when none is set.
+func (s *Server) remoteConfigBlob(license License, machineID string) (map[string]any, error) {
+	config, ok := s.remoteConfigs[license.Key]
+	if !ok {
+		return nil, nil
+	}
+	values := config.values
+	if values == nil {
+		values = map[string]any{}
+	}
+	signature, err := s.Signer.SignJSON(map[string]any{
+		"machine_id":   machineID,
+		"project_slug": license.ProjectSlug,
+		"version":      config.version,
+		"values":       values,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"version": config.version, "values": values, "signature": signature}, nil
+}
+
+func artifactPath(kind, name, version string) string {
+	return "/artifacts/" + kind + "/" + name + "/" + version
+}
+
+func containsValue(list, value string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-BanyanHub-Protocol", strconv.Itoa(sdk.ProtocolVersion))
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"error": code, "message": message})
+}
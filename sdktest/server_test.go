@@ -0,0 +1,312 @@
+package sdktest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/iwen-conf/BanyanHub-SDK"
+)
+
+func startGuard(t *testing.T, srv *Server, configure func(*sdk.Config)) *sdk.Guard {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := srv.Config("LIC-TEST")
+	cfg.HeartbeatInterval = 20 * time.Millisecond
+	if configure != nil {
+		configure(&cfg)
+	}
+	guard, err := sdk.New(cfg)
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if err := guard.Start(context.Background()); err != nil {
+		t.Fatalf("start guard: %v", err)
+	}
+	t.Cleanup(guard.Stop)
+	return guard
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_VerifyHeartbeatAndKill(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST", Features: []string{"reports"}})
+	srv.PublishRelease(Release{Component: DefaultComponentSlug, Version: "9.9.9", Artifact: []byte("new-binary")})
+	guard := startGuard(t, srv, nil)
+
+	if guard.State() != sdk.StateActive {
+		t.Fatalf("expected active guard, got %s", guard.State())
+	}
+	if err := guard.Check(); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	waitFor(t, "heartbeats", func() bool { return srv.Heartbeats() >= 2 })
+	if guard.State() != sdk.StateActive {
+		t.Fatalf("signed heartbeats with updates should keep the guard active, got %s", guard.State())
+	}
+
+	srv.Kill("LIC-TEST", "refunded")
+	waitFor(t, "kill", func() bool { return guard.State() == sdk.StateBanned })
+}
+
+func TestServer_CollectsUsageFromHeartbeats(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST", Features: []string{"api_calls"}})
+	guard := startGuard(t, srv, nil)
+
+	for i := 0; i < 3; i++ {
+		if err := guard.RecordUsage("api_calls", 5); err != nil {
+			t.Fatalf("record usage: %v", err)
+		}
+	}
+	if err := guard.RecordUsage("exports", 1); !errors.Is(err, sdk.ErrFeatureNotEntitled) {
+		t.Fatalf("expected ErrFeatureNotEntitled, got %v", err)
+	}
+	waitFor(t, "usage", func() bool { return srv.Usage("LIC-TEST")["api_calls"] == 15 })
+	waitFor(t, "usage drained", func() bool { return len(guard.PendingUsage()) == 0 })
+}
+
+func TestServer_EnforcesSeatLimit(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST", Seats: 1})
+	var exhausted []string
+	guard := startGuard(t, srv, func(cfg *sdk.Config) {
+		cfg.OnSeatsExhausted = func(userID string) { exhausted = append(exhausted, userID) }
+	})
+	ctx := context.Background()
+
+	if _, err := guard.AcquireSeat(ctx, "alice"); err != nil {
+		t.Fatalf("acquire alice: %v", err)
+	}
+	if _, err := guard.AcquireSeat(ctx, "bob"); !errors.Is(err, sdk.ErrSeatsExhausted) {
+		t.Fatalf("expected ErrSeatsExhausted, got %v", err)
+	}
+	if len(exhausted) != 1 || exhausted[0] != "bob" {
+		t.Fatalf("OnSeatsExhausted calls = %v", exhausted)
+	}
+	if err := guard.ReleaseSeat(ctx, "alice"); err != nil {
+		t.Fatalf("release alice: %v", err)
+	}
+	if _, err := guard.AcquireSeat(ctx, "bob"); err != nil {
+		t.Fatalf("acquire bob after release: %v", err)
+	}
+	if users := srv.Seats("LIC-TEST"); len(users) != 1 || users[0] != "bob" {
+		t.Fatalf("server seats = %v", users)
+	}
+}
+
+func TestServer_RejectsUnknownAndExcessMachines(t *testing.T) {
+	srv := NewServer(t)
+	t.Setenv("HOME", t.TempDir())
+
+	guard, err := sdk.New(srv.Config("LIC-UNKNOWN"))
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if err := guard.Start(context.Background()); !errors.Is(err, sdk.ErrLicenseInvalid) {
+		t.Fatalf("expected invalid license, got %v", err)
+	}
+
+	srv.AddLicense(License{Key: "LIC-FULL", MaxMachines: 1})
+	srv.mu.Lock()
+	srv.machines["LIC-FULL"] = map[string]bool{"sha256:other-machine": true}
+	srv.mu.Unlock()
+	guard, err = sdk.New(srv.Config("LIC-FULL"))
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if err := guard.Start(context.Background()); !errors.Is(err, sdk.ErrMaxMachinesExceeded) {
+		t.Fatalf("expected machine limit, got %v", err)
+	}
+}
+
+func TestServer_FeedbackRepliesReachTheGuard(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST"})
+	replies := make(chan sdk.FeedbackReply, 1)
+	guard := startGuard(t, srv, func(cfg *sdk.Config) {
+		cfg.OnFeedbackReply = func(_ string, reply sdk.FeedbackReply) { replies <- reply }
+	})
+
+	item, err := guard.SubmitFeedback(context.Background(), sdk.SubmitFeedbackRequest{
+		UserID:   "user-1",
+		Category: sdk.FeedbackBug,
+		Title:    "Export fails",
+		Content:  "Exporting a report shows an error.",
+	})
+	if err != nil {
+		t.Fatalf("submit feedback: %v", err)
+	}
+	if got := srv.Feedback(); len(got) != 1 || got[0].Title != "Export fails" {
+		t.Fatalf("unexpected stored feedback %+v", got)
+	}
+	list, err := guard.ListMyFeedback(context.Background(), "user-1", 1, 10)
+	if err != nil || list.Total() != 1 {
+		t.Fatalf("list feedback: %+v, %v", list, err)
+	}
+
+	if _, ok := srv.ReplyToFeedback(item.ID, "Support", "Fixed in 1.2.1"); !ok {
+		t.Fatal("reply to feedback")
+	}
+	select {
+	case reply := <-replies:
+		if reply.Content != "Fixed in 1.2.1" {
+			t.Fatalf("unexpected reply %+v", reply)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reply not delivered with a heartbeat")
+	}
+	got, err := guard.GetFeedback(context.Background(), item.ID)
+	if err != nil || len(got.Replies) != 1 {
+		t.Fatalf("get feedback: %+v, %v", got, err)
+	}
+}
+
+func TestServer_PluginArtifactsAreSigned(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST"})
+	latest := "1.1.0"
+	srv.AddPlugin(Plugin{
+		Info:     sdk.PluginInfo{Slug: "sso", Name: "SSO", LatestVersion: &latest, UpdateAvailable: true, CanUpdate: true},
+		Artifact: []byte("plugin-binary"),
+	})
+	guard := startGuard(t, srv, nil)
+
+	catalog, err := guard.GetPluginCatalog(context.Background(), true)
+	if err != nil || len(catalog.Plugins) != 1 || catalog.Plugins[0].Slug != "sso" {
+		t.Fatalf("plugin catalog: %+v, %v", catalog, err)
+	}
+	path, meta, err := guard.DownloadPluginArtifact(context.Background(), "sso", latest)
+	if err != nil {
+		t.Fatalf("download plugin: %v", err)
+	}
+	defer os.Remove(path)
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "plugin-binary" || meta.Version != latest {
+		t.Fatalf("unexpected artifact %q %+v %v", data, meta, err)
+	}
+}
+
+func TestServer_UpgradesThroughRequiredReleases(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST"})
+	srv.PublishRelease(Release{Component: "worker", Version: "1.1.0", Artifact: []byte("worker-1.1.0"), Required: true})
+	srv.PublishRelease(Release{Component: "worker", Version: "1.2.0", Artifact: []byte("worker-1.2.0")})
+	srv.PublishRelease(Release{Component: "worker", Version: "2.0.0", Artifact: []byte("worker-2.0.0")})
+
+	binary := filepath.Join(t.TempDir(), "worker")
+	if err := os.WriteFile(binary, []byte("worker-1.0.0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var installed []string
+	startGuard(t, srv, func(cfg *sdk.Config) {
+		cfg.OTA = sdk.OTAConfig{Enabled: true, AutoUpdate: true}
+		cfg.ManagedComponents = []sdk.ManagedComponent{{Slug: "worker", Dir: binary}}
+		cfg.OTA.OnUpdateResult = func(component, oldVersion, newVersion string, success bool, err error) {
+			if success {
+				mu.Lock()
+				installed = append(installed, newVersion)
+				mu.Unlock()
+			}
+		}
+	})
+
+	waitFor(t, "upgrade to 2.0.0", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(installed) > 0 && installed[len(installed)-1] == "2.0.0"
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(installed) != 2 || installed[0] != "1.1.0" {
+		t.Fatalf("installed %v, want 1.1.0 then 2.0.0", installed)
+	}
+	if data, _ := os.ReadFile(binary); string(data) != "worker-2.0.0" {
+		t.Fatalf("binary = %q, want worker-2.0.0", data)
+	}
+}
+
+func TestServer_DeliversRemoteConfig(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST"})
+	srv.SetRemoteConfig("LIC-TEST", "1", map[string]any{"max_users": 10, "beta_ui": false})
+	guard := startGuard(t, srv, nil)
+
+	config := guard.RemoteConfig()
+	if config.Version != "1" || config.GetInt("max_users", 0) != 10 || config.GetBool("beta_ui", true) {
+		t.Fatalf("remote config after verify = %+v", config)
+	}
+
+	changed := make(chan sdk.RemoteConfig, 1)
+	guard.OnRemoteConfigChange(func(old, new sdk.RemoteConfig) { changed <- new })
+	srv.SetRemoteConfig("LIC-TEST", "2", map[string]any{"max_users": 25, "endpoint": "https://eu.example.com"})
+	select {
+	case config = <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the remote config change")
+	}
+	if config.Version != "2" || config.GetInt("max_users", 0) != 25 || config.GetString("endpoint", "") != "https://eu.example.com" {
+		t.Fatalf("changed remote config = %+v", config)
+	}
+	guard.Stop()
+
+	restarted, err := sdk.New(srv.Config("LIC-TEST"))
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	if cached := restarted.RemoteConfig(); cached.Version != "2" || cached.GetInt("max_users", 0) != 25 {
+		t.Fatalf("cached remote config = %+v", cached)
+	}
+}
+
+func TestServer_DeliversAnnouncements(t *testing.T) {
+	srv := NewServer(t)
+	srv.AddLicense(License{Key: "LIC-TEST"})
+	announced := make(chan sdk.Announcement, 4)
+	guard := startGuard(t, srv, func(cfg *sdk.Config) {
+		cfg.OnAnnouncement = func(a sdk.Announcement) { announced <- a }
+	})
+
+	srv.Announce(sdk.Announcement{ID: "enterprise-only", Title: "Enterprise webinar", Tiers: []string{"enterprise"}})
+	srv.Announce(sdk.Announcement{ID: "maintenance", Title: "Maintenance on Sunday", Severity: sdk.AnnouncementWarning, Category: "maintenance"})
+	select {
+	case a := <-announced:
+		if a.ID != "maintenance" {
+			t.Fatalf("announced %q, want only the one targeting this tier", a.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the heartbeat announcement")
+	}
+
+	announcements, err := guard.FetchAnnouncements(context.Background())
+	if err != nil {
+		t.Fatalf("fetch announcements: %v", err)
+	}
+	if len(announcements) != 1 || announcements[0].ID != "maintenance" || announcements[0].Read {
+		t.Fatalf("announcements = %+v, want the unread maintenance notice", announcements)
+	}
+	guard.MarkAnnouncementRead("maintenance")
+	if announcements := guard.Announcements(); len(announcements) != 1 || !announcements[0].Read {
+		t.Fatalf("announcements after read = %+v", announcements)
+	}
+	waitFor(t, "more heartbeats", func() bool { return srv.Heartbeats() >= 4 })
+	if len(announced) != 0 {
+		t.Fatalf("announcement callbacks repeated: %d pending", len(announced))
+	}
+}
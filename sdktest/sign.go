@@ -0,0 +1,183 @@
+package sdktest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Lease is the license grant the server signs for one machine. The guard
+// checks the signature, the machine ID and the expiry and grace times.
+type Lease struct {
+	ExpiresAt   string   `json:"expires_at"`
+	Features    []string `json:"features,omitempty"`
+	GraceUntil  string   `json:"grace_until"`
+	IssuedAt    string   `json:"issued_at"`
+	LeaseID     string   `json:"lease_id"`
+	LicenseKey  string   `json:"license_key"`
+	MachineID   string   `json:"machine_id"`
+	MaxMachines int      `json:"max_machines"`
+	ProjectSlug string   `json:"project_slug"`
+	ServerTime  string   `json:"server_time"`
+	Tier        string   `json:"tier"`
+	// MachinesInUse is how many machines the license is bound to.
+	MachinesInUse int `json:"machines_in_use,omitempty"`
+}
+
+// Signer mints the signatures a BanyanHub server puts on its responses.
+type Signer struct {
+	PrivateKey ed25519.PrivateKey
+}
+
+// NewSigner generates a fresh Ed25519 signing key.
+func NewSigner() (*Signer, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	return &Signer{PrivateKey: priv}, nil
+}
+
+// PublicKeyPEM returns the key for Config.PublicKeyPEM.
+func (s *Signer) PublicKeyPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: s.PrivateKey.Public().(ed25519.PublicKey),
+	})
+}
+
+// SignLease returns the canonical lease JSON and its signature.
+func (s *Signer) SignLease(lease Lease) (json.RawMessage, string, error) {
+	raw, err := json.Marshal(lease)
+	if err != nil {
+		return nil, "", err
+	}
+	canonical, err := CanonicalJSON(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	return canonical, s.sign(canonical), nil
+}
+
+// SignArtifact returns the hex SHA-256 of an update or plugin artifact and
+// the signature the guard checks before installing it.
+func (s *Signer) SignArtifact(data []byte) (sha256Hex, signature string) {
+	sum := sha256.Sum256(data)
+	sha256Hex = hex.EncodeToString(sum[:])
+	return sha256Hex, s.sign([]byte(sha256Hex))
+}
+
+// SignJSON signs the canonical form of v, the scheme used for response,
+// download metadata and component config signatures.
+func (s *Signer) SignJSON(v any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := CanonicalJSON(raw)
+	if err != nil {
+		return "", err
+	}
+	return s.sign(canonical), nil
+}
+
+func (s *Signer) sign(data []byte) string {
+	digest := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.PrivateKey, digest[:]))
+}
+
+// NewLease returns a lease for machineID valid for ttl, with the grace
+// window ending three days after expiry.
+func NewLease(licenseKey, projectSlug, machineID string, ttl time.Duration) Lease {
+	now := time.Now().UTC()
+	return Lease{
+		ExpiresAt:   now.Add(ttl).Format(time.RFC3339),
+		GraceUntil:  now.Add(ttl + 72*time.Hour).Format(time.RFC3339),
+		IssuedAt:    now.Format(time.RFC3339),
+		LeaseID:     "lease-" + machineID,
+		LicenseKey:  licenseKey,
+		MachineID:   machineID,
+		MaxMachines: 1,
+		ProjectSlug: projectSlug,
+		ServerTime:  now.Format(time.RFC3339),
+		Tier:        "commercial",
+	}
+}
+
+// CanonicalJSON re-encodes raw with object keys sorted and no insignificant
+// whitespace, the form every BanyanHub signature covers.
+func CanonicalJSON(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, bytes.TrimSpace(raw)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return fmt.Errorf("empty json")
+	}
+	switch raw[0] {
+	case '{':
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			keyJSON, _ := json.Marshal(key)
+			buf.Write(keyJSON)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, bytes.TrimSpace(object[key])); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		buf.WriteByte('[')
+		for i, item := range items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, bytes.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return json.Compact(buf, raw)
+	}
+	return nil
+}
+
+// digest is the hex SHA-256 of the canonical JSON of v.
+func digest(v any) string {
+	raw, _ := json.Marshal(v)
+	canonical, err := CanonicalJSON(raw)
+	if err != nil {
+		canonical = raw
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
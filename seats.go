@@ -0,0 +1,162 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Seat is a user session holding one of the license's concurrent or named
+// user seats.
+type Seat struct {
+	ID     string
+	UserID string
+	// AcquiredAt is when the server granted the seat.
+	AcquiredAt time.Time
+	// ExpiresAt is when the server reclaims the seat unless a heartbeat
+	// renews it; zero means it is held until released.
+	ExpiresAt time.Time
+	// Used and Total describe the seat pool after this seat was granted;
+	// Total is zero when the license has no seat limit.
+	Used  int
+	Total int
+}
+
+type seatRequestBody struct {
+	LicenseKey    string `json:"license_key,omitempty"`
+	MachineID     string `json:"machine_id"`
+	ProjectSlug   string `json:"project_slug"`
+	ComponentSlug string `json:"component_slug"`
+	UserID        string `json:"user_id"`
+	SeatID        string `json:"seat_id,omitempty"`
+}
+
+type seatResponse struct {
+	SeatID     string `json:"seat_id"`
+	UserID     string `json:"user_id"`
+	AcquiredAt string `json:"acquired_at"`
+	ExpiresAt  string `json:"expires_at"`
+	SeatsUsed  int    `json:"seats_used"`
+	SeatsTotal int    `json:"seats_total"`
+}
+
+// AcquireSeat takes a seat for userID, for products licensed per named or
+// concurrent user. The server enforces the seat entitlement and answers
+// ErrSeatsExhausted when the pool is full, after which Config.OnSeatsExhausted
+// fires. A user who already holds a seat on this guard gets it back without
+// a request. Held seats are renewed by heartbeats until ReleaseSeat.
+func (g *Guard) AcquireSeat(ctx context.Context, userID string) (Seat, error) {
+	if err := g.requireInitialized(true, true); err != nil {
+		return Seat{}, err
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return Seat{}, fmt.Errorf("%w: user_id", ErrMissingParameter)
+	}
+	if err := g.Check(); err != nil {
+		return Seat{}, err
+	}
+	if seat, ok := g.heldSeat(userID); ok {
+		return seat, nil
+	}
+
+	raw, err := g.seatRequest(ctx, "/api/v1/seats/acquire", userID, "")
+	if err != nil {
+		if errors.Is(err, ErrSeatsExhausted) && g.cfg.OnSeatsExhausted != nil {
+			g.cfg.OnSeatsExhausted(userID)
+		}
+		return Seat{}, err
+	}
+	var resp seatResponse
+	if err := json.Unmarshal(raw, &resp); err != nil || resp.SeatID == "" {
+		return Seat{}, fmt.Errorf("%w: seat response without seat_id", ErrInvalidServerResponse)
+	}
+	seat := Seat{ID: resp.SeatID, UserID: userID, Used: resp.SeatsUsed, Total: resp.SeatsTotal}
+	seat.AcquiredAt, _ = time.Parse(time.RFC3339, resp.AcquiredAt)
+	seat.ExpiresAt, _ = time.Parse(time.RFC3339, resp.ExpiresAt)
+
+	g.mu.Lock()
+	if g.seats == nil {
+		g.seats = make(map[string]Seat)
+	}
+	g.seats[userID] = seat
+	g.mu.Unlock()
+	g.logger.Info("seat acquired", "user_id", userID, "seat_id", seat.ID, "used", seat.Used, "total", seat.Total)
+	return seat, nil
+}
+
+// ReleaseSeat returns userID's seat to the pool. It returns ErrSeatNotHeld
+// when this guard holds no seat for the user. The seat is forgotten locally
+// even if the server cannot be reached; the server then reclaims it once
+// heartbeats stop renewing it.
+func (g *Guard) ReleaseSeat(ctx context.Context, userID string) error {
+	if err := g.requireInitialized(true, true); err != nil {
+		return err
+	}
+	userID = strings.TrimSpace(userID)
+	g.mu.Lock()
+	seat, ok := g.seats[userID]
+	delete(g.seats, userID)
+	g.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSeatNotHeld, userID)
+	}
+	if _, err := g.seatRequest(ctx, "/api/v1/seats/release", userID, seat.ID); err != nil {
+		return fmt.Errorf("release seat %s: %w", seat.ID, err)
+	}
+	g.logger.Info("seat released", "user_id", userID, "seat_id", seat.ID)
+	return nil
+}
+
+// Seats returns the seats this guard holds, ordered by user ID.
+func (g *Guard) Seats() []Seat {
+	g.mu.RLock()
+	seats := make([]Seat, 0, len(g.seats))
+	for _, seat := range g.seats {
+		seats = append(seats, seat)
+	}
+	g.mu.RUnlock()
+	sort.Slice(seats, func(i, j int) bool { return seats[i].UserID < seats[j].UserID })
+	return seats
+}
+
+func (g *Guard) heldSeat(userID string) (Seat, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	seat, ok := g.seats[userID]
+	return seat, ok
+}
+
+// heldSeatIDs lists the seats a heartbeat renews.
+func (g *Guard) heldSeatIDs() []string {
+	seats := g.Seats()
+	if len(seats) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(seats))
+	for _, seat := range seats {
+		ids = append(ids, seat.ID)
+	}
+	return ids
+}
+
+func (g *Guard) seatRequest(ctx context.Context, path, userID, seatID string) ([]byte, error) {
+	body, err := json.Marshal(seatRequestBody{
+		LicenseKey:    g.bodyLicenseKey(),
+		MachineID:     g.fingerprint.MachineID(),
+		ProjectSlug:   g.cfg.ProjectSlug,
+		ComponentSlug: g.cfg.ComponentSlug,
+		UserID:        userID,
+		SeatID:        seatID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	reqCtx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
+	defer cancel()
+	return g.postJSON(reqCtx, path, body)
+}
@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAcquireSeatReusesHeldSeatAndReportsExhaustion(t *testing.T) {
+	var exhausted []string
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		var body seatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		switch {
+		case r.URL.Path == "/api/v1/seats/acquire" && body.UserID == "alice":
+			_, _ = w.Write([]byte(`{"seat_id":"seat-1","user_id":"alice","acquired_at":"2026-03-01T10:00:00Z","seats_used":1,"seats_total":1}`))
+		case r.URL.Path == "/api/v1/seats/acquire":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"seats_exhausted"}`))
+		case r.URL.Path == "/api/v1/seats/release" && body.SeatID == "seat-1":
+			_, _ = w.Write([]byte(`{"status":"released"}`))
+		default:
+			t.Errorf("unexpected request %s %+v", r.URL.Path, body)
+		}
+	})
+	g.cfg.OnSeatsExhausted = func(userID string) { exhausted = append(exhausted, userID) }
+	g.sm.OnVerifySuccess()
+	ctx := context.Background()
+
+	seat, err := g.AcquireSeat(ctx, "alice")
+	if err != nil {
+		t.Fatalf("AcquireSeat: %v", err)
+	}
+	if seat.ID != "seat-1" || seat.Used != 1 || seat.Total != 1 || seat.AcquiredAt.IsZero() {
+		t.Fatalf("unexpected seat %+v", seat)
+	}
+	if again, err := g.AcquireSeat(ctx, "alice"); err != nil || again.ID != seat.ID {
+		t.Fatalf("second AcquireSeat = %+v, %v", again, err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("held seat should not be requested again, got %d requests", calls.Load())
+	}
+	if ids := g.heldSeatIDs(); len(ids) != 1 || ids[0] != "seat-1" {
+		t.Fatalf("heartbeat seats = %v", ids)
+	}
+
+	if _, err := g.AcquireSeat(ctx, "bob"); !errors.Is(err, ErrSeatsExhausted) {
+		t.Fatalf("expected ErrSeatsExhausted, got %v", err)
+	}
+	if len(exhausted) != 1 || exhausted[0] != "bob" {
+		t.Fatalf("OnSeatsExhausted calls = %v", exhausted)
+	}
+
+	if err := g.ReleaseSeat(ctx, "alice"); err != nil {
+		t.Fatalf("ReleaseSeat: %v", err)
+	}
+	if len(g.Seats()) != 0 {
+		t.Fatalf("seats after release = %+v", g.Seats())
+	}
+	if err := g.ReleaseSeat(ctx, "alice"); !errors.Is(err, ErrSeatNotHeld) {
+		t.Fatalf("expected ErrSeatNotHeld, got %v", err)
+	}
+}
+
+func TestAcquireSeatRequiresLicensedGuard(t *testing.T) {
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	})
+	g.sm.OnVerifySuccess()
+	g.sm.OnGracePeriodExpired()
+	if _, err := g.AcquireSeat(context.Background(), "alice"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("locked guard sent %d requests", calls.Load())
+	}
+}
@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// secretStore keeps small confidential blobs (private keys, certificates)
+// next to the persisted state, sealed with a key bound to this machine.
+type secretStore struct {
+	cfg         Config
+	fingerprint *Fingerprint
+}
+
+func newSecretStore(cfg Config, fingerprint *Fingerprint) *secretStore {
+	return &secretStore{cfg: cfg, fingerprint: fingerprint}
+}
+
+func (s *secretStore) Load(name string) ([]byte, error) {
+	return s.open(secretEntryName(name), name)
+}
+
+func (s *secretStore) Save(name string, plaintext []byte) error {
+	return s.seal(secretEntryName(name), name, plaintext)
+}
+
+// LoadEntry opens a cache entry written by SaveEntry. An entry that was
+// edited, or written unsealed by an older SDK, fails with ErrStateTampered.
+func (s *secretStore) LoadEntry(entry string) ([]byte, error) {
+	return s.open(entry, entry)
+}
+
+// SaveEntry seals plaintext as the cache entry named entry, for guard
+// bookkeeping such as unreported usage that must not be edited on disk.
+func (s *secretStore) SaveEntry(entry string, plaintext []byte) error {
+	return s.seal(entry, entry, plaintext)
+}
+
+// open reads entry and opens it with label as additional data.
+func (s *secretStore) open(entry, label string) ([]byte, error) {
+	data, err := cacheStoreFor(s.cfg).Load(entry)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := s.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, ErrStateTampered
+	}
+	plaintext, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(label))
+	if err != nil {
+		return nil, ErrStateTampered
+	}
+	return plaintext, nil
+}
+
+func (s *secretStore) seal(entry, label string, plaintext []byte) error {
+	aead, err := s.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(label))
+
+	return cacheStoreFor(s.cfg).Save(entry, sealed)
+}
+
+func (s *secretStore) Delete(name string) error {
+	return cacheStoreFor(s.cfg).Delete(secretEntryName(name))
+}
+
+func (s *secretStore) aead() (cipher.AEAD, error) {
+	reader := hkdf.New(sha256.New, []byte(s.fingerprint.MachineID()), []byte(s.cfg.ProjectSlug), []byte(s.cfg.ComponentSlug+"|secrets"))
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, fmt.Errorf("derive secret key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secretNames lists every secret, so deactivation can wipe them all.
+var secretNames = []string{clientCertSecretName, offlineActivationSecretName}
+
+// secretEntryName is the cache entry holding a sealed secret.
+func secretEntryName(name string) string {
+	return "secrets/" + name + ".bin"
+}
+
+// sealedEntries returns the store sealing the guard's cache entries.
+func (g *Guard) sealedEntries() *secretStore {
+	if g.secrets != nil {
+		return g.secrets
+	}
+	return newSecretStore(g.cfg, g.fingerprint)
+}
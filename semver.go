@@ -0,0 +1,119 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// SemVer is a parsed semantic version as used for OTA decisions. It is named
+// SemVer because Version holds the ldflags-injected build version.
+type SemVer struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease string
+	Metadata   string
+}
+
+// ParseSemVer parses a semantic version. A leading "v" is accepted and
+// missing minor or patch parts default to zero, so "v1.2" equals "1.2.0".
+func ParseSemVer(s string) (SemVer, error) {
+	parsed, err := semver.NewVersion(normalizeVersionTag(s))
+	if err != nil {
+		return SemVer{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	return SemVer{
+		Major:      parsed.Major(),
+		Minor:      parsed.Minor(),
+		Patch:      parsed.Patch(),
+		Prerelease: parsed.Prerelease(),
+		Metadata:   parsed.Metadata(),
+	}, nil
+}
+
+// String formats v without a "v" prefix.
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Metadata != "" {
+		s += "+" + v.Metadata
+	}
+	return s
+}
+
+// IsPrerelease reports whether v carries a pre-release tag such as "rc.1".
+func (v SemVer) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// Channel returns the release channel of v: "stable" for a release, or the
+// first pre-release identifier, lower-cased and without a trailing number,
+// such as "beta" for "2.0.0-beta.3" and "rc" for "2.0.0-rc1".
+func (v SemVer) Channel() string {
+	if v.Prerelease == "" {
+		return "stable"
+	}
+	channel, _, _ := strings.Cut(v.Prerelease, ".")
+	if trimmed := strings.TrimRight(channel, "0123456789"); trimmed != "" {
+		channel = trimmed
+	}
+	return strings.ToLower(channel)
+}
+
+// Compare returns -1, 0 or +1 as v sorts before, equal to or after o.
+// Pre-releases sort before their release and build metadata is ignored, as
+// the semantic versioning spec requires.
+func (v SemVer) Compare(o SemVer) int {
+	return v.semver().Compare(o.semver())
+}
+
+func (v SemVer) LessThan(o SemVer) bool    { return v.Compare(o) < 0 }
+func (v SemVer) GreaterThan(o SemVer) bool { return v.Compare(o) > 0 }
+func (v SemVer) Equal(o SemVer) bool       { return v.Compare(o) == 0 }
+
+func (v SemVer) semver() *semver.Version {
+	return semver.New(v.Major, v.Minor, v.Patch, v.Prerelease, v.Metadata)
+}
+
+// IsNewer reports whether latest should replace installed. Versions that do
+// not parse as semver are compared as opaque tags: any different, non-empty
+// latest counts as newer, so a server using build numbers still updates.
+func IsNewer(installed, latest string) bool {
+	installedVersion, installedErr := ParseSemVer(installed)
+	latestVersion, latestErr := ParseSemVer(latest)
+	if installedErr != nil || latestErr != nil {
+		return latest != "" && normalizeVersionTag(latest) != normalizeVersionTag(installed)
+	}
+	return latestVersion.GreaterThan(installedVersion)
+}
+
+// IsDowngrade reports whether offered is an older release than installed.
+// Unlike IsNewer it never guesses: versions that do not parse as semver are
+// not ordered, so they are never a downgrade.
+func IsDowngrade(installed, offered string) bool {
+	installedVersion, installedErr := ParseSemVer(installed)
+	offeredVersion, offeredErr := ParseSemVer(offered)
+	if installedErr != nil || offeredErr != nil {
+		return false
+	}
+	return offeredVersion.LessThan(installedVersion)
+}
+
+// SameVersion reports whether a and b name the same release, e.g. "v1.2"
+// and "1.2.0". Unparsable versions must match as tags.
+func SameVersion(a, b string) bool {
+	aVersion, aErr := ParseSemVer(a)
+	bVersion, bErr := ParseSemVer(b)
+	if aErr != nil || bErr != nil {
+		return normalizeVersionTag(a) == normalizeVersionTag(b)
+	}
+	return aVersion.Equal(bVersion)
+}
+
+func normalizeVersionTag(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
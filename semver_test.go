@@ -0,0 +1,138 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	v, err := ParseSemVer("v1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != (SemVer{Major: 1, Minor: 2}) || v.String() != "1.2.0" {
+		t.Fatalf("ParseSemVer(v1.2) = %#v (%s)", v, v)
+	}
+
+	v, err = ParseSemVer("2.0.0-rc.1+build.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.IsPrerelease() || v.Metadata != "build.7" || v.String() != "2.0.0-rc.1+build.7" {
+		t.Fatalf("unexpected pre-release parse: %#v", v)
+	}
+
+	if _, err := ParseSemVer("nightly"); !errors.Is(err, ErrInvalidVersion) {
+		t.Fatalf("expected ErrInvalidVersion, got %v", err)
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	order := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-beta", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.10.0", "2.0.0"}
+	for i := 1; i < len(order); i++ {
+		lower, _ := ParseSemVer(order[i-1])
+		higher, _ := ParseSemVer(order[i])
+		if !lower.LessThan(higher) || !higher.GreaterThan(lower) {
+			t.Errorf("expected %s < %s", order[i-1], order[i])
+		}
+	}
+	a, _ := ParseSemVer("1.0.0+build.1")
+	b, _ := ParseSemVer("1.0.0+build.2")
+	if !a.Equal(b) {
+		t.Error("build metadata must not affect precedence")
+	}
+}
+
+func TestIsNewerAndSameVersion(t *testing.T) {
+	cases := []struct {
+		installed, latest string
+		newer             bool
+	}{
+		{"1.2.3", "1.2.4", true},
+		{"v1.2.3", "1.2.3", false},
+		{"1.2.3", "1.2.3-rc.1", false},
+		{"1.2.3-rc.1", "1.2.3", true},
+		{"unknown", "1.0.0", true},
+		{"build-41", "build-42", true},
+		{"1.0.0", "", false},
+	}
+	for _, tc := range cases {
+		if got := IsNewer(tc.installed, tc.latest); got != tc.newer {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tc.installed, tc.latest, got, tc.newer)
+		}
+	}
+
+	if !SameVersion("v1.2", "1.2.0") || SameVersion("1.2.0", "1.2.1") || !SameVersion("build-7", "build-7") {
+		t.Error("SameVersion mismatch")
+	}
+}
+
+func TestVersionPolicyMatchesEquivalentVersions(t *testing.T) {
+	g := &Guard{cfg: Config{OTA: OTAConfig{
+		PinnedVersions:  map[string]string{"reports": "v2.1"},
+		IgnoredVersions: map[string][]string{"charts": {"3.0"}},
+	}}}
+	if err := g.checkVersionPolicy("reports", "2.1.0"); err != nil {
+		t.Fatalf("pin v2.1 must accept 2.1.0: %v", err)
+	}
+	if err := g.checkVersionPolicy("charts", "v3.0.0"); !errors.Is(err, ErrPluginVersionIgnored) {
+		t.Fatalf("ignoring 3.0 must skip v3.0.0, got %v", err)
+	}
+}
+
+func TestIsDowngradeAndChannel(t *testing.T) {
+	for _, tc := range []struct {
+		installed, offered string
+		downgrade          bool
+	}{
+		{"2.0.0", "1.9.9", true},
+		{"v2.0", "2.0.0-rc.1", true},
+		{"2.0.0-rc.1", "2.0.0", false},
+		{"2.0.0", "2.0.0", false},
+		{"build-9", "build-7", false},
+	} {
+		if got := IsDowngrade(tc.installed, tc.offered); got != tc.downgrade {
+			t.Errorf("IsDowngrade(%q, %q) = %v, want %v", tc.installed, tc.offered, got, tc.downgrade)
+		}
+	}
+
+	for version, want := range map[string]string{"1.2.0": "stable", "2.0.0-Beta.3": "beta", "2.0.0-rc1": "rc"} {
+		v, err := ParseSemVer(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Channel(); got != want {
+			t.Errorf("%s channel = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestAllowDowngradeInstallsOlderOfferedVersion(t *testing.T) {
+	server, pubKey := newBinaryUpdateServer(t, []byte("old release"))
+	for _, allow := range []bool{false, true} {
+		g := newLifecycleTestGuard(t, server.URL, pubKey, "2.0.0")
+		g.cfg.OTA.AllowDowngrade = allow
+		g.managedVersions["worker"] = "2.0.0"
+		g.recordAvailableVersion("worker", "1.5.0")
+		if !g.IsUpdateDowngrade("worker") {
+			t.Fatal("offered 1.5.0 over 2.0.0 should be a downgrade")
+		}
+
+		target := filepath.Join(t.TempDir(), "worker")
+		if err := os.WriteFile(target, []byte("current"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		err := g.updateManagedBackend(context.Background(), ManagedComponent{Slug: "worker", Dir: target}, updateInfo{Component: "worker", Latest: "1.5.0"})
+		switch {
+		case !allow && !errors.Is(err, ErrUpdateDowngrade):
+			t.Fatalf("without AllowDowngrade: err = %v, want ErrUpdateDowngrade", err)
+		case allow && err != nil:
+			t.Fatalf("with AllowDowngrade: %v", err)
+		case allow && g.currentManagedVersion("worker") != "1.5.0":
+			t.Fatalf("version = %s, want 1.5.0", g.currentManagedVersion("worker"))
+		}
+	}
+}
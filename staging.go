@@ -0,0 +1,115 @@
+package sdk
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Update files and directories are staged next to the path they replace,
+// so moving them into place is a rename on the same filesystem, and carry
+// stagingPrefix so Start can remove what a crash left behind.
+const stagingPrefix = ".deploy-guard-"
+
+// stagingOrphanAge is how long a staging entry must have been untouched
+// before Start treats it as an orphan rather than another process's
+// update in progress.
+const stagingOrphanAge = time.Hour
+
+// createStagingFile creates a staging file in dir, or in the system temp
+// dir when dir is empty.
+func createStagingFile(dir, kind string) (*os.File, error) {
+	return os.CreateTemp(dir, stagingPrefix+kind+"-*")
+}
+
+// createStagingDir creates a staging directory in dir, or in the system
+// temp dir when dir is empty.
+func createStagingDir(dir, kind string) (string, error) {
+	return os.MkdirTemp(dir, stagingPrefix+kind+"-*")
+}
+
+// syncDir flushes dir's entries, making a rename into or out of it
+// durable. Windows cannot sync directories and needs no such step.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// syncDirTree syncs every directory under root, so a staged tree is on disk
+// before it is renamed into place. Files are synced as they are written.
+func syncDirTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return syncDir(path)
+	})
+}
+
+// stagingDirs lists the directories updates stage into: next to the
+// executable, next to each managed component and, for blue/green frontends,
+// inside it, plus the artifact cache.
+func (g *Guard) stagingDirs() []string {
+	var dirs []string
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	for _, mc := range g.cfg.ManagedComponents {
+		if strings.TrimSpace(mc.Dir) == "" {
+			continue
+		}
+		dirs = append(dirs, filepath.Dir(filepath.Clean(mc.Dir)))
+		if mc.Strategy == UpdateFrontend && mc.BlueGreen {
+			dirs = append(dirs, mc.Dir)
+		}
+	}
+	if g.artifactCacheEnabled() {
+		dirs = append(dirs, artifactCacheDir(g.cfg))
+	}
+	return dirs
+}
+
+// removeStagingOrphans deletes staging entries an interrupted update left
+// in the staging dirs.
+func (g *Guard) removeStagingOrphans() {
+	seen := make(map[string]bool)
+	cutoff := time.Now().Add(-stagingOrphanAge)
+	for _, dir := range g.stagingDirs() {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), stagingPrefix) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if err := os.RemoveAll(path); err != nil {
+				g.logger.Warn("failed to remove orphaned staging entry", "path", path, "error", err)
+				continue
+			}
+			g.logger.Info("removed orphaned staging entry", "path", path)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpoolArtifact_StagesInGivenDir(t *testing.T) {
+	dir := t.TempDir()
+	path, _, err := spoolArtifact(strings.NewReader("payload"), DigestSHA256, dir, 1<<20)
+	if err != nil {
+		t.Fatalf("spoolArtifact: %v", err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), stagingPrefix) {
+		t.Fatalf("staged at %s, want a %s* file in %s", path, stagingPrefix, dir)
+	}
+}
+
+func TestUpdateFrontend_StagesNextToDir(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	archive := buildTarGz(t, map[string]string{"index.html": "staged"})
+	hashHex := sha256Hex(archive)
+	g := newFetcherTestGuard(t, pubKey, hashHex, signUpdateHash(t, privKey, hashHex))
+	g.cfg.OTA.Fetcher = fetcherFunc(func(ctx context.Context, req FetchRequest) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(archive)), nil
+	})
+
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "live")
+	var staged []string
+	g.cfg.OTA.OnUpdateProgress = func(component, stage string, progress float64) {
+		if stage != "applying" {
+			return
+		}
+		entries, _ := os.ReadDir(parent)
+		for _, entry := range entries {
+			staged = append(staged, entry.Name())
+		}
+	}
+	if err := g.updateFrontend(context.Background(), ManagedComponent{Slug: "frontend", Dir: dir}, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+		t.Fatalf("updateFrontend: %v", err)
+	}
+
+	var stagedDirs int
+	for _, name := range staged {
+		if strings.HasPrefix(name, stagingPrefix+"frontend-") {
+			stagedDirs++
+		}
+	}
+	if stagedDirs != 1 {
+		t.Fatalf("entries next to Dir while applying = %v, want one staging dir", staged)
+	}
+	entries, _ := os.ReadDir(parent)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), stagingPrefix) {
+			t.Fatalf("staging entry %s left behind", entry.Name())
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "index.html")); err != nil || string(data) != "staged" {
+		t.Fatalf("index.html = %q, %v", data, err)
+	}
+}
+
+func TestRemoveStagingOrphans(t *testing.T) {
+	parent := t.TempDir()
+	g := newLifecycleTestGuard(t, "http://127.0.0.1:1", nil, "1.0.0")
+	g.cfg.ManagedComponents = []ManagedComponent{{Slug: "frontend", Dir: filepath.Join(parent, "live"), Strategy: UpdateFrontend}}
+
+	orphanDir := filepath.Join(parent, stagingPrefix+"frontend-1")
+	orphanFile := filepath.Join(parent, stagingPrefix+"update-2")
+	fresh := filepath.Join(parent, stagingPrefix+"update-3")
+	unrelated := filepath.Join(parent, "notes.txt")
+	if err := os.MkdirAll(filepath.Join(orphanDir, "assets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{orphanFile, fresh, unrelated} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * stagingOrphanAge)
+	for _, path := range []string{orphanDir, orphanFile, unrelated} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g.removeStagingOrphans()
+
+	for _, path := range []string{orphanDir, orphanFile} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("orphan %s not removed: %v", path, err)
+		}
+	}
+	for _, path := range []string{fresh, unrelated} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%s must be kept: %v", path, err)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package sdk
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +16,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/hkdf"
@@ -25,8 +30,17 @@ const (
 	StateGrace
 	StateLocked
 	StateBanned
+	// StateDeactivated is terminal: the seat was released with Deactivate.
+	StateDeactivated
 )
 
+// stateFileName is the persisted license state in the guard's cache dir.
+const stateFileName = "state.bin"
+
+// errLicenseKeyChanged is returned by Load when the persisted state belongs
+// to a different license key than the configured one.
+var errLicenseKeyChanged = errors.New("cached state belongs to another license key")
+
 func (s State) String() string {
 	switch s {
 	case StateInit:
@@ -39,6 +53,8 @@ func (s State) String() string {
 		return "LOCKED"
 	case StateBanned:
 		return "BANNED"
+	case StateDeactivated:
+		return "DEACTIVATED"
 	default:
 		return "UNKNOWN"
 	}
@@ -51,11 +67,30 @@ type persistedState struct {
 	Watermark      string          `json:"watermark,omitempty"`
 	LockFlag       bool            `json:"lock_flag"`
 	BanFlag        bool            `json:"ban_flag"`
-	UpdatedAt      string          `json:"updated_at"`
+	KillDeadline   string          `json:"kill_deadline,omitempty"`
+	KillReason     string          `json:"kill_reason,omitempty"`
+	// VerifiedAt is when the lease was last confirmed by the server; the
+	// cached lease is only trusted offline for Config.LicenseCacheTTL after it.
+	VerifiedAt string `json:"verified_at,omitempty"`
+	// ClockHighWater is the latest local wall-clock time observed; a clock
+	// earlier than this is treated as tampering.
+	ClockHighWater string `json:"clock_high_water,omitempty"`
+	// LicenseKeyHash ties the state to the license key it was verified
+	// with, so switching keys on one machine does not reuse it.
+	LicenseKeyHash string `json:"license_key_hash,omitempty"`
+	// RequestKeyID and RequestSecret are the request signing key the server
+	// issued to this machine; the secret never goes over the wire again.
+	RequestKeyID  string `json:"request_key_id,omitempty"`
+	RequestSecret string `json:"request_secret,omitempty"`
+	UpdatedAt     string `json:"updated_at"`
 }
 
+// persistedEnvelope holds the state sealed with a machine-derived key.
+// Payload is only set by older SDKs that stored the state as signed
+// plaintext; such files are still accepted and sealed on the next save.
 type persistedEnvelope struct {
-	Payload   json.RawMessage `json:"payload"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Sealed    []byte          `json:"sealed,omitempty"`
 	Signature string          `json:"signature"`
 }
 
@@ -86,8 +121,7 @@ func (ps *persistentStateStore) Snapshot() *persistedState {
 }
 
 func (ps *persistentStateStore) Load() (*persistedState, error) {
-	path := filepath.Join(ps.cacheDir(), "state.bin")
-	data, err := os.ReadFile(path)
+	data, err := cacheStoreFor(ps.cfg).Load(stateFileName)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +131,11 @@ func (ps *persistentStateStore) Load() (*persistedState, error) {
 		return nil, ErrStateTampered
 	}
 
-	valid, err := ps.verifySignature(envelope.Payload, envelope.Signature)
+	signed := envelope.Payload
+	if len(envelope.Sealed) > 0 {
+		signed = envelope.Sealed
+	}
+	valid, err := ps.verifySignature(signed, envelope.Signature)
 	if err != nil {
 		return nil, err
 	}
@@ -105,10 +143,20 @@ func (ps *persistentStateStore) Load() (*persistedState, error) {
 		return nil, ErrStateTampered
 	}
 
+	payload := envelope.Payload
+	if len(envelope.Sealed) > 0 {
+		payload, err = ps.open(envelope.Sealed)
+		if err != nil {
+			return nil, err
+		}
+	}
 	var state persistedState
-	if err := json.Unmarshal(envelope.Payload, &state); err != nil {
+	if err := json.Unmarshal(payload, &state); err != nil {
 		return nil, ErrStateTampered
 	}
+	if !ps.matchesLicenseKey(&state) {
+		return nil, errLicenseKeyChanged
+	}
 
 	ps.mu.Lock()
 	ps.current = &state
@@ -121,17 +169,24 @@ func (ps *persistentStateStore) Save(state *persistedState) error {
 		return nil
 	}
 	state.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if key := ps.stateLicenseKey(state); key != "" {
+		state.LicenseKeyHash = licenseKeyHash(key)
+	}
 
 	payload, err := json.Marshal(state)
 	if err != nil {
 		return err
 	}
-	signature, err := ps.signPayload(payload)
+	sealed, err := ps.seal(payload)
+	if err != nil {
+		return err
+	}
+	signature, err := ps.signPayload(sealed)
 	if err != nil {
 		return err
 	}
 	envelope := persistedEnvelope{
-		Payload:   payload,
+		Sealed:    sealed,
 		Signature: signature,
 	}
 	data, err := json.Marshal(envelope)
@@ -139,11 +194,7 @@ func (ps *persistentStateStore) Save(state *persistedState) error {
 		return err
 	}
 
-	dir := ps.cacheDir()
-	if err := os.MkdirAll(dir, 0o700); err != nil {
-		return err
-	}
-	if err := writeFileAtomic(filepath.Join(dir, "state.bin"), data, 0o600); err != nil {
+	if err := cacheStoreFor(ps.cfg).Save(stateFileName, data); err != nil {
 		return err
 	}
 
@@ -159,6 +210,36 @@ func (ps *persistentStateStore) Save(state *persistedState) error {
 	return nil
 }
 
+// stateLicenseKey is the configured license key, or the one in the lease
+// for guards that run without a key, such as after offline activation.
+func (ps *persistentStateStore) stateLicenseKey(state *persistedState) string {
+	if ps.cfg.LicenseKey != "" {
+		return ps.cfg.LicenseKey
+	}
+	if state.Lease != nil {
+		return state.Lease.LicenseKey
+	}
+	return ""
+}
+
+// matchesLicenseKey reports whether state may be used with the configured
+// license key. States from older SDKs carry no hash and are checked against
+// their lease instead; without a configured key there is nothing to compare.
+func (ps *persistentStateStore) matchesLicenseKey(state *persistedState) bool {
+	if ps.cfg.LicenseKey == "" {
+		return true
+	}
+	if state.LicenseKeyHash != "" {
+		return hmac.Equal([]byte(state.LicenseKeyHash), []byte(licenseKeyHash(ps.cfg.LicenseKey)))
+	}
+	return state.Lease == nil || state.Lease.LicenseKey == "" || state.Lease.LicenseKey == ps.cfg.LicenseKey
+}
+
+func licenseKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
 func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
 	dir := filepath.Dir(path)
 	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
@@ -240,72 +321,171 @@ func (ps *persistentStateStore) deriveStateKey() ([]byte, error) {
 	return key, nil
 }
 
+// seal encrypts the state payload so lease material and flags are not
+// readable, or portable, off this machine.
+func (ps *persistentStateStore) seal(payload []byte) ([]byte, error) {
+	aead, err := ps.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, payload, []byte(ps.cfg.ComponentSlug)), nil
+}
+
+func (ps *persistentStateStore) open(sealed []byte) ([]byte, error) {
+	aead, err := ps.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, ErrStateTampered
+	}
+	payload, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(ps.cfg.ComponentSlug))
+	if err != nil {
+		return nil, ErrStateTampered
+	}
+	return payload, nil
+}
+
+func (ps *persistentStateStore) aead() (cipher.AEAD, error) {
+	reader := hkdf.New(sha256.New, []byte(ps.fingerprint.MachineID()), []byte(ps.cfg.ProjectSlug), []byte(ps.cfg.ComponentSlug+"|state-seal"))
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, fmt.Errorf("derive state seal key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Clear deletes the persisted state and forgets the in-memory copy.
+func (ps *persistentStateStore) Clear() error {
+	ps.mu.Lock()
+	ps.current = nil
+	ps.mu.Unlock()
+	return cacheStoreFor(ps.cfg).Delete(stateFileName)
+}
+
 func (ps *persistentStateStore) cacheDir() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".deploy-guard", ps.cfg.ProjectSlug, ps.cfg.ComponentSlug)
+	return guardCacheDir(ps.cfg)
+}
+
+// Reasons reported with a StateTransition.
+const (
+	TransitionVerified     = "verified"
+	TransitionHeartbeatOK  = "heartbeat_ok"
+	TransitionHeartbeatErr = "heartbeat_failed"
+	TransitionGraceExpired = "grace_expired"
+	TransitionKilled       = "killed"
+	TransitionClockTamper  = "clock_tampered"
+	TransitionDeactivated  = "deactivated"
+)
+
+// StateTransition describes one change of the guard state.
+type StateTransition struct {
+	From   State
+	To     State
+	Reason string
+	At     time.Time
+	// Grace is set on transitions into GRACE with the new offline deadline.
+	Grace *GraceStatus
 }
 
+// stateMachine keeps the current state in an atomic so Check and State never
+// block on a transition; mu only serializes writers.
 type stateMachine struct {
-	mu    sync.RWMutex
-	state State
+	mu       sync.Mutex
+	state    atomic.Int32
+	onChange func(StateTransition)
 }
 
 func newStateMachine() *stateMachine {
-	return &stateMachine{state: StateInit}
+	sm := &stateMachine{}
+	sm.state.Store(int32(StateInit))
+	return sm
 }
 
 func (sm *stateMachine) restore(state *persistedState) {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	next := StateInit
 	switch {
 	case state == nil:
-		sm.state = StateInit
 	case state.BanFlag:
-		sm.state = StateBanned
+		next = StateBanned
+	case killDeadlinePassed(state.KillDeadline, time.Now()):
+		next = StateBanned
 	case state.LockFlag:
-		sm.state = StateLocked
+		next = StateLocked
 	case state.Lease != nil:
-		sm.state = StateActive
-	default:
-		sm.state = StateInit
+		next = StateActive
 	}
+	sm.mu.Lock()
+	sm.state.Store(int32(next))
+	sm.mu.Unlock()
 }
 
 func (sm *stateMachine) Current() State {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
-	return sm.state
+	return State(sm.state.Load())
 }
 
 func (sm *stateMachine) set(state State) {
+	sm.transition(state, "")
+}
+
+// transition moves to state and, if it changed, reports it to onChange
+// outside the lock. Nothing leaves StateDeactivated.
+func (sm *stateMachine) transition(state State, reason string) {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
-	sm.state = state
+	if State(sm.state.Load()) == StateDeactivated {
+		sm.mu.Unlock()
+		return
+	}
+	old := State(sm.state.Swap(int32(state)))
+	onChange := sm.onChange
+	sm.mu.Unlock()
+	if old != state && onChange != nil {
+		onChange(StateTransition{From: old, To: state, Reason: reason, At: time.Now()})
+	}
 }
 
 func (sm *stateMachine) OnVerifySuccess() {
-	sm.set(StateActive)
+	sm.transition(StateActive, TransitionVerified)
 }
 
 func (sm *stateMachine) OnHeartbeatOK() {
 	current := sm.Current()
 	if current == StateGrace || current == StateActive {
-		sm.set(StateActive)
+		sm.transition(StateActive, TransitionHeartbeatOK)
 	}
 }
 
 func (sm *stateMachine) OnHeartbeatFail() {
 	if sm.Current() == StateActive {
-		sm.set(StateGrace)
+		sm.transition(StateGrace, TransitionHeartbeatErr)
 	}
 }
 
 func (sm *stateMachine) OnKill() {
-	sm.set(StateBanned)
+	sm.transition(StateBanned, TransitionKilled)
+}
+
+func (sm *stateMachine) OnDeactivated() {
+	sm.transition(StateDeactivated, TransitionDeactivated)
 }
 
 func (sm *stateMachine) OnGracePeriodExpired() {
 	if sm.Current() == StateGrace || sm.Current() == StateActive {
-		sm.set(StateLocked)
+		sm.transition(StateLocked, TransitionGraceExpired)
+	}
+}
+
+func (sm *stateMachine) OnClockTampered() {
+	if sm.Current() == StateGrace || sm.Current() == StateActive {
+		sm.transition(StateLocked, TransitionClockTamper)
 	}
 }
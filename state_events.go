@@ -0,0 +1,58 @@
+package sdk
+
+// stateSubscriberBuffer is the capacity of each States channel. Transitions
+// are rare, so a subscriber that falls this far behind only misses
+// intermediate states; State() always has the current one.
+const stateSubscriberBuffer = 16
+
+// OnStateChange registers a callback for every guard state transition, e.g.
+// ACTIVE→GRACE on a failed heartbeat or GRACE→LOCKED when the grace period
+// expires. Callbacks run synchronously on the goroutine that caused the
+// transition, in registration order, and must not block.
+func (g *Guard) OnStateChange(fn func(old, new State, reason string)) {
+	if fn == nil {
+		return
+	}
+	g.stateSubsMu.Lock()
+	g.stateCallbacks = append(g.stateCallbacks, fn)
+	g.stateSubsMu.Unlock()
+}
+
+// States returns a channel that receives every subsequent state transition.
+// Each call creates a new buffered subscription; transitions are dropped for
+// a subscriber whose buffer is full rather than stalling the guard.
+func (g *Guard) States() <-chan StateTransition {
+	ch := make(chan StateTransition, stateSubscriberBuffer)
+	g.stateSubsMu.Lock()
+	g.stateChannels = append(g.stateChannels, ch)
+	g.stateSubsMu.Unlock()
+	return ch
+}
+
+func (g *Guard) publishStateTransition(t StateTransition) {
+	if t.To == StateGrace {
+		if grace, ok := g.graceSnapshot(t.At); ok {
+			t.Grace = &grace
+		}
+	} else {
+		g.clearGraceWindow()
+	}
+
+	g.stateSubsMu.Lock()
+	callbacks := append([]func(State, State, string){}, g.stateCallbacks...)
+	channels := append([]chan StateTransition{}, g.stateChannels...)
+	g.stateSubsMu.Unlock()
+
+	g.logger.Info("guard state changed", "from", t.From.String(), "to", t.To.String(), "reason", t.Reason)
+	g.audit(AuditStateChange, g.cfg.ComponentSlug, AuditOutcomeSuccess, t.From.String()+" -> "+t.To.String()+" ("+t.Reason+")")
+	for _, fn := range callbacks {
+		fn(t.From, t.To, t.Reason)
+	}
+	for _, ch := range channels {
+		select {
+		case ch <- t:
+		default:
+		}
+	}
+	g.enforceTransition(t)
+}
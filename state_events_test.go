@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateChange_NotifiesCallbacksAndChannels(t *testing.T) {
+	guard, _, _, _ := newActiveHeartbeatGuard(t)
+
+	var seen []string
+	guard.OnStateChange(func(old, new State, reason string) {
+		seen = append(seen, old.String()+"->"+new.String()+":"+reason)
+	})
+	states := guard.States()
+
+	guard.sm.OnHeartbeatFail()
+	guard.sm.OnHeartbeatFail()
+	guard.sm.OnGracePeriodExpired()
+
+	want := []string{"ACTIVE->GRACE:heartbeat_failed", "GRACE->LOCKED:grace_expired"}
+	if len(seen) != len(want) || seen[0] != want[0] || seen[1] != want[1] {
+		t.Fatalf("callback transitions = %v, want %v", seen, want)
+	}
+	for _, to := range []State{StateGrace, StateLocked} {
+		select {
+		case transition := <-states:
+			if transition.To != to || transition.At.IsZero() {
+				t.Fatalf("unexpected transition %#v", transition)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no transition to %v on channel", to)
+		}
+	}
+	select {
+	case extra := <-states:
+		t.Fatalf("repeated failure must not emit a transition, got %#v", extra)
+	default:
+	}
+}
+
+func TestStates_SlowSubscriberDoesNotBlock(t *testing.T) {
+	guard, _ := newTestGuard(t, nil)
+	_ = guard.States()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < stateSubscriberBuffer*2; i++ {
+			guard.sm.OnVerifySuccess()
+			guard.sm.OnKill()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("state transitions blocked on a full subscriber")
+	}
+}
@@ -0,0 +1,256 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RestartMode decides when a supervised component process is restarted
+// after it exits on its own.
+type RestartMode int
+
+const (
+	// RestartOnFailure restarts the process when it exits with an error.
+	RestartOnFailure RestartMode = iota
+	// RestartAlways restarts the process whenever it exits.
+	RestartAlways
+	// RestartNever leaves an exited process down until the next update or
+	// RestartComponent.
+	RestartNever
+)
+
+// RestartPolicy configures how a supervised component process is restarted.
+type RestartPolicy struct {
+	Mode RestartMode
+	// MaxRestarts is how many restarts in a row the supervisor attempts
+	// before giving up until the next update or RestartComponent; 0 means no
+	// limit. A process that stays up for a minute resets the count.
+	MaxRestarts int
+	// Delay is the wait before the first restart, doubled after every
+	// further crash up to one minute (default: 1s).
+	Delay time.Duration
+	// StopTimeout is how long a process gets to exit after SIGTERM before it
+	// is killed (default: 10s).
+	StopTimeout time.Duration
+}
+
+const (
+	defaultRestartDelay = time.Second
+	maxRestartDelay     = time.Minute
+	defaultStopTimeout  = 10 * time.Second
+	// restartStableAfter is how long a process must run for a later crash
+	// to count as the first one again.
+	restartStableAfter = time.Minute
+)
+
+func (p RestartPolicy) delay() time.Duration {
+	if p.Delay > 0 {
+		return p.Delay
+	}
+	return defaultRestartDelay
+}
+
+func (p RestartPolicy) stopTimeout() time.Duration {
+	if p.StopTimeout > 0 {
+		return p.StopTimeout
+	}
+	return defaultStopTimeout
+}
+
+func (p RestartPolicy) restarts(exitErr error) bool {
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartNever:
+		return false
+	default:
+		return exitErr != nil
+	}
+}
+
+// supervisor runs the process of one managed component with Exec set.
+type supervisor struct {
+	mc ManagedComponent
+	// restart asks the running loop to stop the process and start it again.
+	restart chan struct{}
+	// runMu keeps a loop from a previous Start from overlapping a new one.
+	runMu sync.Mutex
+}
+
+func newSupervisors(components []ManagedComponent) map[string]*supervisor {
+	supervisors := make(map[string]*supervisor)
+	for _, mc := range components {
+		if strings.TrimSpace(mc.Exec) == "" {
+			continue
+		}
+		supervisors[mc.Slug] = &supervisor{mc: mc, restart: make(chan struct{}, 1)}
+	}
+	return supervisors
+}
+
+func (s *supervisor) requestRestart() {
+	select {
+	case s.restart <- struct{}{}:
+	default:
+	}
+}
+
+// startSupervisors launches the supervised component processes under ctx;
+// cancelling it stops them.
+func (g *Guard) startSupervisors(ctx context.Context) {
+	for _, s := range g.supervisors {
+		g.goBackground(func() { g.supervise(ctx, s) })
+	}
+}
+
+// RestartComponent gracefully restarts the process of a managed component
+// that has Exec set, or starts it again after it stayed down under its
+// RestartPolicy. Supervised processes also restart after every installed
+// update. It returns ErrComponentNotFound for components without Exec.
+func (g *Guard) RestartComponent(slug string) error {
+	if err := g.requireState(); err != nil {
+		return err
+	}
+	s, ok := g.supervisors[slug]
+	if !ok {
+		return fmt.Errorf("%w: %s is not supervised", ErrComponentNotFound, slug)
+	}
+	s.requestRestart()
+	return nil
+}
+
+// restartSupervised restarts the process of slug after an update, if it is
+// supervised.
+func (g *Guard) restartSupervised(slug string) {
+	if s, ok := g.supervisors[slug]; ok {
+		g.logger.Info("restarting updated component", "component", slug)
+		s.requestRestart()
+	}
+}
+
+// supervise keeps the process of s running until ctx ends, following its
+// RestartPolicy.
+func (g *Guard) supervise(ctx context.Context, s *supervisor) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	// A restart requested while nothing ran is served by this start.
+	select {
+	case <-s.restart:
+	default:
+	}
+
+	slug := s.mc.Slug
+	policy := s.mc.RestartPolicy
+	delay := policy.delay()
+	crashes := 0
+	for {
+		started := time.Now()
+		exitErr, stopped := g.runSupervised(ctx, s)
+		if ctx.Err() != nil {
+			return
+		}
+		if stopped {
+			// Restart requested: start again at once.
+			delay, crashes = policy.delay(), 0
+			continue
+		}
+		if time.Since(started) >= restartStableAfter {
+			delay, crashes = policy.delay(), 0
+		}
+
+		hold := false
+		switch {
+		case !policy.restarts(exitErr):
+			g.logger.Info("supervised component exited", "component", slug, "error", exitErr)
+			hold = true
+		case policy.MaxRestarts > 0 && crashes >= policy.MaxRestarts:
+			g.logger.Error("supervised component keeps exiting, giving up", "component", slug, "restarts", crashes, "error", exitErr)
+			hold = true
+		default:
+			g.logger.Warn("supervised component exited, restarting", "component", slug, "delay", delay, "error", exitErr)
+		}
+		if hold {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.restart:
+			}
+			delay, crashes = policy.delay(), 0
+			continue
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.restart:
+			timer.Stop()
+			delay, crashes = policy.delay(), 0
+			continue
+		case <-timer.C:
+		}
+		crashes++
+		delay = min(delay*2, maxRestartDelay)
+	}
+}
+
+// runSupervised starts the process once and waits for it. stopped is true
+// when a restart request ended it; when ctx ends the process is stopped as
+// well.
+func (g *Guard) runSupervised(ctx context.Context, s *supervisor) (exitErr error, stopped bool) {
+	cmd := exec.Command(s.mc.Exec, s.mc.Args...)
+	cmd.Env = append(os.Environ(), s.mc.Env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", s.mc.Exec, err), false
+	}
+	g.recordComponentStart(s.mc.Slug, time.Now())
+	g.logger.Info("supervised component started", "component", s.mc.Slug, "pid", cmd.Process.Pid)
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err, false
+	case <-s.restart:
+		g.stopSupervised(cmd, done, s.mc)
+		return nil, true
+	case <-ctx.Done():
+		g.stopSupervised(cmd, done, s.mc)
+		return ctx.Err(), false
+	}
+}
+
+// stopSupervised sends SIGTERM and kills the process if it is still running
+// after the StopTimeout. Where SIGTERM cannot be delivered, as on Windows,
+// the process is killed at once.
+func (g *Guard) stopSupervised(cmd *exec.Cmd, done <-chan error, mc ManagedComponent) {
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			<-done
+			return
+		}
+		_ = cmd.Process.Kill()
+		<-done
+		return
+	}
+	timer := time.NewTimer(mc.RestartPolicy.stopTimeout())
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		g.logger.Warn("supervised component did not stop in time, killing it", "component", mc.Slug)
+		_ = cmd.Process.Kill()
+		<-done
+	}
+}
@@ -0,0 +1,137 @@
+package sdk
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestSupervisorHelperProcess is the supervised process of the tests below;
+// it does nothing unless run by them.
+func TestSupervisorHelperProcess(t *testing.T) {
+	switch os.Getenv("SDK_SUPERVISOR_HELPER") {
+	case "crash":
+		os.Exit(3)
+	case "serve":
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGTERM)
+		select {
+		case <-sigs:
+			os.Exit(0)
+		case <-time.After(time.Minute):
+			os.Exit(1)
+		}
+	}
+}
+
+func newSupervisedGuard(t *testing.T, mode string, policy RestartPolicy) *Guard {
+	t.Helper()
+	guard, err := NewForTesting(Config{
+		CacheStore: NewMemoryCacheStore(),
+		ManagedComponents: []ManagedComponent{{
+			Slug:          "worker",
+			Dir:           os.Args[0],
+			Exec:          os.Args[0],
+			Args:          []string{"-test.run=^TestSupervisorHelperProcess$"},
+			Env:           []string{"SDK_SUPERVISOR_HELPER=" + mode},
+			RestartPolicy: policy,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return guard
+}
+
+func (g *Guard) startsOf(slug string) int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.componentStarts[slug]
+}
+
+func waitForStarts(t *testing.T, g *Guard, slug string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for g.startsOf(slug) < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s started %d times, want %d", slug, g.startsOf(slug), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSupervisorRestartsCrashedProcessUpToMaxRestarts(t *testing.T) {
+	guard := newSupervisedGuard(t, "crash", RestartPolicy{MaxRestarts: 2, Delay: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	guard.startSupervisors(ctx)
+	defer func() {
+		cancel()
+		guard.workers.Wait()
+	}()
+
+	waitForStarts(t, guard, "worker", 3)
+	time.Sleep(200 * time.Millisecond)
+	if got := guard.startsOf("worker"); got != 3 {
+		t.Fatalf("worker started %d times after giving up, want 3", got)
+	}
+
+	if err := guard.RestartComponent("worker"); err != nil {
+		t.Fatal(err)
+	}
+	waitForStarts(t, guard, "worker", 4)
+}
+
+func TestSupervisorRestartsGracefullyOnRequestAndStopsWithContext(t *testing.T) {
+	guard := newSupervisedGuard(t, "serve", RestartPolicy{Mode: RestartNever, StopTimeout: 5 * time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	guard.startSupervisors(ctx)
+
+	waitForStarts(t, guard, "worker", 1)
+	// An installed update restarts the process even under RestartNever.
+	guard.restartSupervised("worker")
+	waitForStarts(t, guard, "worker", 2)
+	if health := guard.componentHealthReport("worker", nil, time.Now()); health == nil || health.Restarts != 1 {
+		t.Fatalf("health = %+v, want one restart", health)
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		guard.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervisor did not stop the process after the context ended")
+	}
+}
+
+func TestRestartComponentRequiresSupervisedComponent(t *testing.T) {
+	guard := newSupervisedGuard(t, "serve", RestartPolicy{})
+	if err := guard.RestartComponent("frontend"); err == nil {
+		t.Fatal("restarting an unsupervised component should fail")
+	}
+}
+
+func TestValidateRejectsUnsupportedSupervision(t *testing.T) {
+	cfg := validTestConfig()
+	cfg.ManagedComponents = []ManagedComponent{
+		{Slug: "ui", Dir: "/srv/ui", Strategy: UpdateFrontend, Exec: "/srv/ui/server"},
+		{Slug: "api", Dir: "/srv/api", Exec: "/srv/api", RestartPolicy: RestartPolicy{Mode: RestartMode(9)}},
+		{Slug: "jobs", Dir: "/srv/jobs", Args: []string{"--queue"}},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"exec requires the backend strategy", "unknown restart mode 9", "args and env require exec"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
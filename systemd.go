@@ -0,0 +1,89 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	defaultSystemdActivationTimeout = time.Minute
+	systemdPollInterval             = 500 * time.Millisecond
+)
+
+// systemdManager restarts units and reads their state; the Linux build talks
+// to systemd over D-Bus.
+type systemdManager interface {
+	// RestartUnit restarts unit and returns once systemd finished the job.
+	RestartUnit(ctx context.Context, unit string) error
+	// ActiveState returns the unit's ActiveState, such as "active" or
+	// "failed".
+	ActiveState(ctx context.Context, unit string) (string, error)
+}
+
+func (g *Guard) systemdManager() systemdManager {
+	if g.systemd != nil {
+		return g.systemd
+	}
+	return newSystemdManager()
+}
+
+func (mc ManagedComponent) systemdActivationTimeout() time.Duration {
+	if mc.SystemdActivationTimeout > 0 {
+		return mc.SystemdActivationTimeout
+	}
+	return defaultSystemdActivationTimeout
+}
+
+// activateSystemdUnit restarts the unit of mc after its binary at targetPath
+// was replaced and waits for it to become active. If it does not, the
+// previous binary saved by the apply step is restored and the unit restarted
+// on it; the returned error then wraps ErrUpdateApply, or ErrUpdateRollback
+// when the previous binary could not be restored.
+func (g *Guard) activateSystemdUnit(ctx context.Context, mc ManagedComponent, targetPath string) error {
+	systemd := g.systemdManager()
+	err := g.restartSystemdUnit(ctx, systemd, mc)
+	if err == nil {
+		g.logger.Info("systemd unit restarted on new version", "component", mc.Slug, "unit", mc.SystemdUnit)
+		return nil
+	}
+	g.logger.Error("systemd unit failed to activate, rolling back", "component", mc.Slug, "unit", mc.SystemdUnit, "error", err)
+
+	if rerr := os.Rename(targetPath+".bak", targetPath); rerr != nil {
+		return fmt.Errorf("%w: unit %s: %v; restoring previous binary: %v", ErrUpdateRollback, mc.SystemdUnit, err, rerr)
+	}
+	if rerr := g.restartSystemdUnit(ctx, systemd, mc); rerr != nil {
+		return fmt.Errorf("%w: unit %s: %v; restart on previous binary: %v", ErrUpdateRollback, mc.SystemdUnit, err, rerr)
+	}
+	return fmt.Errorf("%w: unit %s did not become active: %v", ErrUpdateApply, mc.SystemdUnit, err)
+}
+
+// restartSystemdUnit restarts the unit and polls until it is active, failed,
+// or the activation timeout passes.
+func (g *Guard) restartSystemdUnit(ctx context.Context, systemd systemdManager, mc ManagedComponent) error {
+	ctx, cancel := context.WithTimeout(ctx, mc.systemdActivationTimeout())
+	defer cancel()
+	if err := systemd.RestartUnit(ctx, mc.SystemdUnit); err != nil {
+		return fmt.Errorf("restart: %w", err)
+	}
+	ticker := time.NewTicker(systemdPollInterval)
+	defer ticker.Stop()
+	for {
+		state, err := systemd.ActiveState(ctx, mc.SystemdUnit)
+		if err != nil {
+			return fmt.Errorf("read state: %w", err)
+		}
+		switch state {
+		case "active":
+			return nil
+		case "failed", "inactive":
+			return fmt.Errorf("unit is %s", state)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("still %s after %s", state, mc.systemdActivationTimeout())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+//go:build linux
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+)
+
+// dbusSystemd talks to systemd over the system bus, opening a connection
+// per call since units are only restarted after updates.
+type dbusSystemd struct{}
+
+func newSystemdManager() systemdManager {
+	return dbusSystemd{}
+}
+
+func (dbusSystemd) RestartUnit(ctx context.Context, unit string) error {
+	conn, err := dbus.NewWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to systemd: %w", err)
+	}
+	defer conn.Close()
+
+	done := make(chan string, 1)
+	if _, err := conn.RestartUnitContext(ctx, unit, "replace", done); err != nil {
+		return err
+	}
+	select {
+	case result := <-done:
+		if result != "done" {
+			return fmt.Errorf("restart job %s", result)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (dbusSystemd) ActiveState(ctx context.Context, unit string) (string, error) {
+	conn, err := dbus.NewWithContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("connect to systemd: %w", err)
+	}
+	defer conn.Close()
+
+	prop, err := conn.GetUnitPropertyContext(ctx, unit, "ActiveState")
+	if err != nil {
+		return "", err
+	}
+	state, ok := prop.Value.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected ActiveState %v", prop.Value)
+	}
+	return state, nil
+}
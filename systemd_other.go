@@ -0,0 +1,24 @@
+//go:build !linux
+
+package sdk
+
+import (
+	"context"
+	"errors"
+)
+
+var errSystemdUnsupported = errors.New("systemd is only available on Linux")
+
+type unsupportedSystemd struct{}
+
+func newSystemdManager() systemdManager {
+	return unsupportedSystemd{}
+}
+
+func (unsupportedSystemd) RestartUnit(context.Context, string) error {
+	return errSystemdUnsupported
+}
+
+func (unsupportedSystemd) ActiveState(context.Context, string) (string, error) {
+	return "", errSystemdUnsupported
+}
@@ -0,0 +1,125 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fakeSystemd reports the states queued for each restart; the last one
+// repeats.
+type fakeSystemd struct {
+	mu       sync.Mutex
+	states   [][]string
+	restarts int
+	polls    int
+}
+
+func (f *fakeSystemd) RestartUnit(ctx context.Context, unit string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restarts++
+	f.polls = 0
+	return nil
+}
+
+func (f *fakeSystemd) ActiveState(ctx context.Context, unit string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	states := f.states[min(f.restarts, len(f.states))-1]
+	state := states[min(f.polls, len(states)-1)]
+	f.polls++
+	return state, nil
+}
+
+func newBinaryUpdateServer(t *testing.T, binary []byte) (*httptest.Server, ed25519.PublicKey) {
+	t.Helper()
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	hashHex := sha256Hex(binary)
+	signature := signUpdateHash(t, privKey, hashHex)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/worker",
+				"sha256":       hashHex,
+				"signature":    signature,
+			})
+		case "/download/worker":
+			_, _ = w.Write(binary)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, pubKey
+}
+
+func TestSystemdUnitRestartedAfterBinarySwap(t *testing.T) {
+	server, pubKey := newBinaryUpdateServer(t, []byte("new binary"))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.managedVersions["worker"] = "1.0.0"
+	systemd := &fakeSystemd{states: [][]string{{"activating", "active"}}}
+	g.systemd = systemd
+
+	target := filepath.Join(t.TempDir(), "worker")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mc := ManagedComponent{Slug: "worker", Dir: target, SystemdUnit: "worker.service"}
+	if err := g.updateManagedBackend(context.Background(), mc, updateInfo{Component: "worker", Latest: "2.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if systemd.restarts != 1 {
+		t.Fatalf("unit restarted %d times, want 1", systemd.restarts)
+	}
+	if v := g.currentManagedVersion("worker"); v != "2.0.0" {
+		t.Fatalf("version = %s, want 2.0.0", v)
+	}
+}
+
+func TestSystemdActivationFailureRestoresPreviousBinary(t *testing.T) {
+	server, pubKey := newBinaryUpdateServer(t, []byte("new binary"))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.managedVersions["worker"] = "1.0.0"
+	systemd := &fakeSystemd{states: [][]string{{"failed"}, {"active"}}}
+	g.systemd = systemd
+
+	target := filepath.Join(t.TempDir(), "worker")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var rolledBack error
+	mc := ManagedComponent{
+		Slug:        "worker",
+		Dir:         target,
+		SystemdUnit: "worker.service",
+		OnRollback: func(ctx context.Context, event LifecycleEvent, cause error) {
+			rolledBack = cause
+		},
+	}
+	err := g.updateManagedBackend(context.Background(), mc, updateInfo{Component: "worker", Latest: "2.0.0"})
+	if !errors.Is(err, ErrUpdateApply) {
+		t.Fatalf("err = %v, want ErrUpdateApply", err)
+	}
+	if rolledBack == nil {
+		t.Fatal("OnRollback did not run")
+	}
+	if systemd.restarts != 2 {
+		t.Fatalf("unit restarted %d times, want 2 (new and previous binary)", systemd.restarts)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old binary" {
+		t.Fatalf("target = %q, want the previous binary", data)
+	}
+	if v := g.currentManagedVersion("worker"); v != "1.0.0" {
+		t.Fatalf("version = %s, want 1.0.0", v)
+	}
+}
@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"context"
+	"time"
+)
+
+// TimeoutConfig bounds each kind of server call separately, so a short
+// heartbeat timeout can detect an outage quickly while large downloads and
+// uploads still get the time they need. Each bound also honours the caller's
+// context deadline, whichever comes first.
+type TimeoutConfig struct {
+	// Verify bounds license verification (default 30s).
+	Verify time.Duration
+	// Heartbeat bounds each heartbeat round trip (default 30s).
+	Heartbeat time.Duration
+	// API bounds other API calls: capabilities, version resolve, download
+	// metadata, plugins, feedback and marketplace (default 30s).
+	API time.Duration
+	// Download bounds artifact downloads (default OTAConfig.DownloadTimeout,
+	// itself 10m).
+	Download time.Duration
+	// Upload bounds feedback attachment uploads (default 10m).
+	Upload time.Duration
+}
+
+func (t *TimeoutConfig) setDefaults(downloadTimeout time.Duration) {
+	if t.Verify <= 0 {
+		t.Verify = 30 * time.Second
+	}
+	if t.Heartbeat <= 0 {
+		t.Heartbeat = 30 * time.Second
+	}
+	if t.API <= 0 {
+		t.API = 30 * time.Second
+	}
+	if t.Download <= 0 {
+		t.Download = downloadTimeout
+	}
+	if t.Upload <= 0 {
+		t.Upload = 10 * time.Minute
+	}
+}
+
+// withTimeout bounds ctx by timeout; a zero timeout, as in a Guard built
+// without New, leaves ctx as is.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
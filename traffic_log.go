@@ -0,0 +1,109 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const defaultTrafficBodyBytes = 2048
+
+// callAPI sends req through the configured transport, within
+// Config.RateLimit and sharing identical in-flight GETs, and, when
+// DebugConfig.LogTraffic is set, logs the exchange.
+func (g *Guard) callAPI(ctx context.Context, req TransportRequest) ([]byte, error) {
+	return g.coalesceGET(ctx, req, func() ([]byte, error) {
+		if err := g.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		if g.cfg.CustomTransport != nil {
+			req.Header = g.signedHeaders(req)
+		}
+		start := time.Now()
+		raw, err := g.apiTransport().Call(ctx, req)
+		g.logTraffic(req, raw, err, time.Since(start))
+		return raw, err
+	})
+}
+
+// logTraffic logs one API exchange: method, path, status, latency and both
+// bodies, redacted and cut to MaxLoggedBodyBytes. The status is 200 for a
+// successful call, the server's status for an APIError and 0 when no reply
+// arrived.
+func (g *Guard) logTraffic(req TransportRequest, raw []byte, callErr error, latency time.Duration) {
+	debug := g.cfg.Debug
+	if !debug.LogTraffic {
+		return
+	}
+	endpoint := "other"
+	if u, err := url.Parse(g.serverURL(req.Path)); err == nil {
+		endpoint = g.metricsEndpoint(u)
+	}
+	if len(debug.TrafficEndpoints) > 0 && !slices.Contains(debug.TrafficEndpoints, endpoint) {
+		return
+	}
+
+	path := req.Path
+	if len(req.Query) > 0 {
+		path += "?" + req.Query.Encode()
+	}
+	status := http.StatusOK
+	if callErr != nil {
+		status = 0
+		var apiErr *APIError
+		if errors.As(callErr, &apiErr) {
+			status = apiErr.StatusCode
+		}
+	}
+	attrs := []any{
+		"method", req.Method,
+		"path", g.redactor.String(path),
+		"endpoint", endpoint,
+		"status", status,
+		"latency", latency.String(),
+	}
+	if len(req.Body) > 0 {
+		attrs = append(attrs, "request", g.trafficBody(req.Body))
+	}
+	if len(raw) > 0 {
+		attrs = append(attrs, "response", g.trafficBody(raw))
+	}
+	if callErr != nil {
+		attrs = append(attrs, "error", callErr.Error())
+	}
+	g.logger.Info("api exchange", attrs...)
+}
+
+// trafficBody redacts a logged body: credentials, signatures and machine
+// IDs in JSON keys, then the license key and machine ID wherever else they
+// appear.
+func (g *Guard) trafficBody(raw []byte) string {
+	body := string(redactJSON(raw, isSensitiveTrafficKey))
+	body = g.redactor.String(body)
+	limit := g.cfg.Debug.MaxLoggedBodyBytes
+	if len(body) <= limit {
+		return body
+	}
+	return truncateUTF8(body, limit) + "...(truncated)"
+}
+
+// truncateUTF8 cuts s to at most limit bytes without splitting a rune.
+func truncateUTF8(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+func isSensitiveTrafficKey(key string) bool {
+	return isSensitiveResponseKey(key) || strings.Contains(strings.ToLower(key), "machine_id")
+}
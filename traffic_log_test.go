@@ -0,0 +1,62 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogTraffic_RedactsAndFiltersByEndpoint(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	server := newHeartbeatTestServer(t, privKey, func() heartbeatResponse {
+		return heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+	})
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+	var buf bytes.Buffer
+	guard.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if strings.Contains(buf.String(), "api exchange") {
+		t.Fatalf("traffic logged while disabled: %s", buf.String())
+	}
+
+	guard.cfg.Debug = DebugConfig{LogTraffic: true, MaxLoggedBodyBytes: 4096}
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"api exchange", "method=POST", "path=/api/v1/heartbeat", "endpoint=heartbeat", "status=200", "latency=", "request=", "response="} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("log missing %q: %s", want, out)
+		}
+	}
+	for _, leaked := range []string{guard.cfg.LicenseKey, guard.fingerprint.MachineID(), sig} {
+		if strings.Contains(out, leaked) {
+			t.Fatalf("%q leaked into traffic log: %s", leaked, out)
+		}
+	}
+
+	buf.Reset()
+	guard.cfg.Debug.MaxLoggedBodyBytes = 16
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if !strings.Contains(buf.String(), "...(truncated)") {
+		t.Fatalf("expected bodies cut to the limit: %s", buf.String())
+	}
+
+	buf.Reset()
+	guard.cfg.Debug.TrafficEndpoints = []string{"verify"}
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if strings.Contains(buf.String(), "api exchange") {
+		t.Fatalf("heartbeat logged although only verify is selected: %s", buf.String())
+	}
+}
@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// TransportRequest is one JSON API call. GET calls carry their parameters in
+// Query, POST calls a JSON document in Body.
+type TransportRequest struct {
+	// Method is http.MethodGet or http.MethodPost.
+	Method string
+	// Path is the API route, e.g. "/api/v1/heartbeat".
+	Path  string
+	Query url.Values
+	Body  []byte
+	// Idempotent reports that the server may safely receive the call twice,
+	// so it can be retried on transient failures.
+	Idempotent bool
+	// Header holds the authentication headers the plain HTTP API would
+	// carry: Authorization, timestamp, body digest and the request
+	// signature over Method, Path, Query and Body. It is set for
+	// Config.CustomTransport, which forwards it with the call.
+	Header http.Header
+}
+
+// Transport carries the guard's calls to the license server: verify,
+// heartbeat, download metadata, plugins, feedback, marketplace and artifact
+// downloads. Set Config.CustomTransport to route them through an internal
+// relay, add corporate authentication or stub the server in tests. Feedback
+// uploads, activation and the push channel always use Config.HTTPClient.
+//
+// Responses are verified as usual, so a Transport cannot forge a lease or an
+// update. To only add headers, wrapping the RoundTripper of
+// Config.HTTPClient is simpler.
+type Transport interface {
+	// Call sends req and returns the raw JSON response. Errors the server
+	// reports should be returned as *APIError (see NewTransportError) so
+	// state handling and capability detection keep working.
+	Call(ctx context.Context, req TransportRequest) ([]byte, error)
+	// FetchArtifact opens an update artifact download and returns its body
+	// and length, or -1 when unknown. url is absolute. It is not called
+	// when OTAConfig.Fetcher is set, which then serves every artifact.
+	FetchArtifact(ctx context.Context, url string) (io.ReadCloser, int64, error)
+}
+
+// NewTransportError builds the error a Transport returns for a failure the
+// server reported. statusCode is the HTTP status of the same failure on the
+// JSON API and code the API error code, e.g. "license_revoked". A 404 with
+// an empty code, or the code "route_not_found", means the server lacks the
+// endpoint.
+func NewTransportError(statusCode int, code, message string) error {
+	return newAPIError(statusCode, code, message, "")
+}
+
+// httpAPITransport sends calls as plain HTTP requests through the guard's
+// HTTP client.
+type httpAPITransport struct {
+	g *Guard
+}
+
+func (t httpAPITransport) Call(ctx context.Context, req TransportRequest) ([]byte, error) {
+	if req.Method == http.MethodGet {
+		return t.g.getHTTPJSON(ctx, req.Path, req.Query)
+	}
+	return t.g.postHTTPJSON(ctx, req.Path, req.Body, req.Idempotent)
+}
+
+func (t httpAPITransport) FetchArtifact(ctx context.Context, url string) (io.ReadCloser, int64, error) {
+	resp, err := t.g.doRequest(ctx, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
+		t.g.signRequest(req, nil)
+		return req, nil
+	})
+	if err != nil {
+		return nil, 0, t.g.redactErr(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, 0, decodeAPIErrorResponse(resp)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// signedHeaders returns the authentication headers req would carry as a
+// plain HTTP request.
+func (g *Guard) signedHeaders(req TransportRequest) http.Header {
+	fullURL := g.serverURL(req.Path)
+	if len(req.Query) > 0 {
+		fullURL += "?" + req.Query.Encode()
+	}
+	signed, err := http.NewRequest(req.Method, fullURL, nil)
+	if err != nil {
+		return http.Header{}
+	}
+	g.signRequest(signed, req.Body)
+	signed.Header.Set(headerProtocol, strconv.Itoa(ProtocolVersion))
+	return signed.Header
+}
+
+func (g *Guard) apiTransport() Transport {
+	if g.api != nil {
+		return g.api
+	}
+	return httpAPITransport{g: g}
+}
@@ -0,0 +1,382 @@
+package sdk
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/mem"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcService prefixes the services of the gRPC API.
+const grpcService = "banyanhub.sdk.v1."
+
+// Trailer and header keys the gRPC server uses for the fields an HTTP error
+// carries in its JSON envelope and X-Request-ID header.
+const (
+	grpcErrorCodeKey = "x-banyanhub-error"
+	grpcRequestIDKey = "x-request-id"
+)
+
+// grpcRoutes maps JSON API routes to gRPC methods. A {name} segment is sent
+// as a field of the request message. Calls without a route fall back to HTTP.
+var grpcRoutes = []struct {
+	method, path, rpc string
+}{
+	{http.MethodPost, "/api/v1/verify", "License/Verify"},
+	{http.MethodPost, "/api/v1/rebind", "License/Rebind"},
+	{http.MethodPost, "/api/v1/heartbeat", "License/Heartbeat"},
+	{http.MethodPost, "/api/v1/deactivate", "License/Deactivate"},
+	{http.MethodPost, "/api/v1/seats/acquire", "Seats/Acquire"},
+	{http.MethodPost, "/api/v1/seats/release", "Seats/Release"},
+	{http.MethodPost, "/api/v1/mtls/enroll", "License/EnrollClientCertificate"},
+	{http.MethodGet, "/api/v1/capabilities", "License/GetCapabilities"},
+	{http.MethodPost, "/api/v1/version/resolve", "Updates/ResolveVersion"},
+	{http.MethodPost, "/api/v1/update/download", "Updates/GetDownload"},
+	{http.MethodGet, "/api/v1/plugins/catalog", "Plugins/GetCatalog"},
+	{http.MethodPost, "/api/v1/plugins/{slug}/update", "Plugins/GetUpdate"},
+	{http.MethodPost, "/api/v1/feedbacks", "Feedback/Submit"},
+	{http.MethodGet, "/api/v1/feedbacks", "Feedback/List"},
+	{http.MethodPost, "/api/v1/feedbacks/upload-url", "Feedback/PrepareUpload"},
+	{http.MethodGet, "/api/v1/feedbacks/release-notes", "Feedback/ListReleaseNotes"},
+	{http.MethodGet, "/api/v1/feedbacks/{id}", "Feedback/Get"},
+}
+
+// grpcHTTPStatus gives gRPC failures the HTTP status the same failure has on
+// the JSON API, so APIError and the sentinel mapping behave alike.
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.Aborted:            http.StatusConflict,
+	codes.FailedPrecondition: http.StatusPreconditionFailed,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unimplemented:      http.StatusNotImplemented,
+}
+
+// grpcJSONCodec sends messages as the JSON documents of the HTTP API
+// (content type application/grpc+json), so no generated code is needed on
+// either side. Messages are *[]byte.
+type grpcJSONCodec struct{}
+
+func (grpcJSONCodec) Marshal(v any) (mem.BufferSlice, error) {
+	msg, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("grpc json codec: unsupported message type %T", v)
+	}
+	return mem.BufferSlice{mem.SliceBuffer(*msg)}, nil
+}
+
+func (grpcJSONCodec) Unmarshal(data mem.BufferSlice, v any) error {
+	msg, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("grpc json codec: unsupported message type %T", v)
+	}
+	*msg = data.Materialize()
+	return nil
+}
+
+func (grpcJSONCodec) Name() string {
+	return "json"
+}
+
+// grpcAPITransport sends JSON API calls as unary gRPC calls. Requests carry
+// the same Authorization and signature headers as over HTTP, as metadata;
+// the signature covers a POST of the message to the gRPC method path. The
+// connection is opened on the first call and closed by Guard.Stop.
+type grpcAPITransport struct {
+	g        *Guard
+	target   string
+	opts     []grpc.DialOption
+	fallback Transport
+
+	mu   sync.Mutex
+	conn *grpc.ClientConn
+}
+
+// newGRPCTransport prepares calls to Config.Transport.GRPCTarget, or the
+// ServerURL host. An https ServerURL enables TLS with the guard's settings:
+// pins, root CAs and the static or enrolled client certificate for mTLS.
+func newGRPCTransport(g *Guard) (*grpcAPITransport, error) {
+	serverURL, err := url.Parse(g.cfg.ServerURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse server url: %w", err)
+	}
+	target := strings.TrimSpace(g.cfg.Transport.GRPCTarget)
+	if target == "" {
+		port := serverURL.Port()
+		if port == "" {
+			port = "80"
+			if serverURL.Scheme == "https" {
+				port = "443"
+			}
+		}
+		target = "dns:///" + net.JoinHostPort(overrideHost(g.cfg.Transport.HostOverrides, serverURL.Hostname(), port))
+	}
+
+	creds := insecure.NewCredentials()
+	if serverURL.Scheme == "https" {
+		tlsCfg, err := g.grpcTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	t := &grpcAPITransport{
+		g:      g,
+		target: target,
+		opts: []grpc.DialOption{
+			grpc.WithTransportCredentials(creds),
+			grpc.WithUserAgent("BanyanHub-SDK/" + Version),
+			grpc.WithDefaultCallOptions(grpc.ForceCodecV2(grpcJSONCodec{})),
+		},
+		fallback: httpAPITransport{g: g},
+	}
+	// Check the target and options now rather than on the first call.
+	if _, err := t.connection(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// connection returns the client connection, opening it if closeConn
+// dropped it.
+func (t *grpcAPITransport) connection() (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		conn, err := grpc.NewClient(t.target, t.opts...)
+		if err != nil {
+			return nil, err
+		}
+		t.conn = conn
+	}
+	return t.conn, nil
+}
+
+// closeConn closes the client connection; the next call opens a new one.
+func (t *grpcAPITransport) closeConn() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+// grpcTLSConfig copies the TLS settings of the guard's own HTTP client. A
+// custom Config.HTTPClient has none to share, so they are built from Config
+// as for the guard's own client, pins included.
+func (g *Guard) grpcTLSConfig() (*tls.Config, error) {
+	client := g.httpClient
+	if g.cfg.HTTPClient != nil || client == nil {
+		pinned, err := newPinnedHTTPClient(g.cfg)
+		if err != nil {
+			return nil, err
+		}
+		client = pinned
+	}
+	base := client.Transport
+	if pinned, ok := base.(*pinEnforcingTransport); ok {
+		base = pinned.base
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		return nil, errors.New("http client has no tls settings to share")
+	}
+	tlsCfg := transport.TLSClientConfig.Clone()
+	tlsCfg.NextProtos = nil
+	return tlsCfg, nil
+}
+
+func (t *grpcAPITransport) Call(ctx context.Context, req TransportRequest) ([]byte, error) {
+	rpc, params, ok := grpcRoute(req.Method, req.Path)
+	if !ok {
+		return t.fallback.Call(ctx, req)
+	}
+	msg, err := grpcMessage(req, params)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := 1
+	if req.Idempotent && t.g.cfg.Retry.MaxAttempts > 1 {
+		attempts = t.g.cfg.Retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		raw, transient, err := t.invoke(ctx, req.Path, "/"+grpcService+rpc, msg)
+		if err == nil || !transient || attempt >= attempts || ctx.Err() != nil {
+			return raw, err
+		}
+		wait := retryBackoff(t.g.cfg.Retry, attempt)
+		t.g.logger.Debug("retrying transient request failure", "path", req.Path, "attempt", attempt, "wait", wait.String())
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// FetchArtifact downloads over HTTP; artifacts are not served over gRPC.
+func (t *grpcAPITransport) FetchArtifact(ctx context.Context, url string) (io.ReadCloser, int64, error) {
+	return t.fallback.FetchArtifact(ctx, url)
+}
+
+// invoke makes one call and reports whether a failure is worth retrying.
+func (t *grpcAPITransport) invoke(ctx context.Context, path, method string, msg []byte) ([]byte, bool, error) {
+	signed, err := http.NewRequestWithContext(ctx, http.MethodPost, t.g.serverURL(method), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("create request: %w", err)
+	}
+	t.g.signRequest(signed, msg)
+	signed.Header.Set(headerProtocol, strconv.Itoa(ProtocolVersion))
+	md := metadata.MD{}
+	for name, values := range signed.Header {
+		md.Set(name, values...)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	conn, err := t.connection()
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrNetworkError, err)
+	}
+	var reply []byte
+	var header, trailer metadata.MD
+	start := time.Now()
+	err = conn.Invoke(ctx, method, &msg, &reply, grpc.Header(&header), grpc.Trailer(&trailer))
+	if observed, parseErr := url.Parse(t.g.serverURL(path)); parseErr == nil {
+		t.g.observeRequest(observed, start)
+	}
+	t.g.recordServerProtocol(firstMetadata(header, trailer, headerProtocol))
+	if err == nil {
+		return reply, false, nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil, false, t.g.redactErr(fmt.Errorf("%w: send request: %w", ErrNetworkError, err))
+	}
+	switch st.Code() {
+	case codes.Canceled:
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		return nil, false, t.g.redactErr(fmt.Errorf("%w: send request: %w", ErrNetworkError, err))
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return nil, true, t.g.redactErr(fmt.Errorf("%w: send request: %w", ErrNetworkError, err))
+	}
+	statusCode, ok := grpcHTTPStatus[st.Code()]
+	if !ok {
+		statusCode = http.StatusInternalServerError
+	}
+	code := firstMetadata(trailer, header, grpcErrorCodeKey)
+	apiErr := newAPIError(statusCode, code, st.Message(), firstMetadata(header, trailer, grpcRequestIDKey))
+	return nil, apiErr.Retryable, apiErr
+}
+
+// grpcRoute finds the gRPC method for a JSON API route and the values of its
+// {name} segments.
+func grpcRoute(method, path string) (string, map[string]string, bool) {
+	segments := strings.Split(path, "/")
+	for _, route := range grpcRoutes {
+		if route.path == path && route.method == method {
+			return route.rpc, nil, true
+		}
+	}
+	for _, route := range grpcRoutes {
+		routeSegments := strings.Split(route.path, "/")
+		if route.method != method || len(routeSegments) != len(segments) {
+			continue
+		}
+		params := map[string]string{}
+		matched := true
+		for i, segment := range routeSegments {
+			if name, ok := strings.CutPrefix(segment, "{"); ok {
+				value, err := url.PathUnescape(segments[i])
+				if err != nil || value == "" {
+					matched = false
+					break
+				}
+				params[strings.TrimSuffix(name, "}")] = value
+				continue
+			}
+			if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route.rpc, params, true
+		}
+	}
+	return "", nil, false
+}
+
+// grpcMessage builds the request message: the POST body, or for GET calls a
+// JSON object of the query parameters, with the route's path parameters
+// added as fields. Repeated query parameters become arrays.
+func grpcMessage(req TransportRequest, params map[string]string) ([]byte, error) {
+	if req.Method != http.MethodGet && len(params) == 0 {
+		return req.Body, nil
+	}
+	fields := map[string]any{}
+	if req.Method == http.MethodGet {
+		for name, values := range req.Query {
+			if len(values) == 1 {
+				fields[name] = values[0]
+			} else {
+				fields[name] = values
+			}
+		}
+	} else if len(req.Body) > 0 {
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			return nil, fmt.Errorf("build grpc message: %w", err)
+		}
+		for name, value := range body {
+			fields[name] = value
+		}
+	}
+	for name, value := range params {
+		fields[name] = value
+	}
+	msg, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("build grpc message: %w", err)
+	}
+	return msg, nil
+}
+
+func firstMetadata(primary, secondary metadata.MD, key string) string {
+	for _, md := range []metadata.MD{primary, secondary} {
+		if values := md.Get(key); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return ""
+}
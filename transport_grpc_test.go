@@ -0,0 +1,238 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type grpcTestCall struct {
+	method string
+	md     metadata.MD
+	msg    map[string]any
+}
+
+// newGRPCTestGuard starts a gRPC server answering every method with handler
+// and returns a guard using the gRPC transport against it.
+func newGRPCTestGuard(t *testing.T, handler func(method string, msg map[string]any) ([]byte, error)) (*Guard, *[]grpcTestCall) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []grpcTestCall
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer(
+		grpc.ForceServerCodecV2(grpcJSONCodec{}),
+		grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			var raw []byte
+			if err := stream.RecvMsg(&raw); err != nil {
+				return err
+			}
+			var msg map[string]any
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			mu.Lock()
+			calls = append(calls, grpcTestCall{method: method, md: md, msg: msg})
+			mu.Unlock()
+			reply, err := handler(method, msg)
+			if err != nil {
+				return err
+			}
+			return stream.SendMsg(&reply)
+		}),
+	)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	guard, err := New(Config{
+		ServerURL:     "http://" + lis.Addr().String(),
+		LicenseKey:    "LIC-GRPC-001",
+		PublicKeyPEM:  pemEncodePublicKey(pubKey),
+		ProjectSlug:   "demo-project",
+		ComponentSlug: "backend",
+		Transport:     TransportConfig{Protocol: TransportGRPC},
+	})
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	return guard, &calls
+}
+
+func TestGRPCTransport_RoutesCallsWithSignedMetadata(t *testing.T) {
+	guard, calls := newGRPCTestGuard(t, func(method string, msg map[string]any) ([]byte, error) {
+		return []byte(`{"id":"fb-1","title":"Broken"}`), nil
+	})
+
+	item, err := guard.GetFeedback(context.Background(), "fb-1")
+	if err != nil {
+		t.Fatalf("get feedback: %v", err)
+	}
+	if item.ID != "fb-1" || item.Title != "Broken" {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+	if _, err := guard.postJSON(context.Background(), "/api/v1/heartbeat", []byte(`{"machine_id":"m-1"}`)); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+
+	if len(*calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(*calls))
+	}
+	get := (*calls)[0]
+	if get.method != "/banyanhub.sdk.v1.Feedback/Get" {
+		t.Fatalf("unexpected method %q", get.method)
+	}
+	if get.msg["id"] != "fb-1" || get.msg["project_slug"] != "demo-project" {
+		t.Fatalf("expected path and query fields in message, got %v", get.msg)
+	}
+	if auth := get.md.Get("authorization"); len(auth) != 1 || auth[0] != authorizationScheme+" LIC-GRPC-001" {
+		t.Fatalf("unexpected authorization metadata %v", auth)
+	}
+	if len(get.md.Get(headerSignature)) != 1 || len(get.md.Get(headerTimestamp)) != 1 {
+		t.Fatalf("expected signature metadata, got %v", get.md)
+	}
+	heartbeat := (*calls)[1]
+	if heartbeat.method != "/banyanhub.sdk.v1.License/Heartbeat" || heartbeat.msg["machine_id"] != "m-1" {
+		t.Fatalf("unexpected heartbeat call %+v", heartbeat)
+	}
+}
+
+func TestGRPCTransport_MapsStatusToAPIError(t *testing.T) {
+	guard, _ := newGRPCTestGuard(t, func(method string, msg map[string]any) ([]byte, error) {
+		return nil, status.Error(codes.PermissionDenied, "license revoked")
+	})
+
+	_, err := guard.postJSON(context.Background(), "/api/v1/verify", []byte(`{}`))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "license revoked" {
+		t.Fatalf("unexpected api error %+v", apiErr)
+	}
+}
+
+func TestGRPCRoute(t *testing.T) {
+	tests := []struct {
+		method, path, rpc string
+		params            map[string]string
+		ok                bool
+	}{
+		{http.MethodGet, "/api/v1/feedbacks/release-notes", "Feedback/ListReleaseNotes", nil, true},
+		{http.MethodGet, "/api/v1/feedbacks/" + url.PathEscape("a b"), "Feedback/Get", map[string]string{"id": "a b"}, true},
+		{http.MethodPost, "/api/v1/plugins/sso/update", "Plugins/GetUpdate", map[string]string{"slug": "sso"}, true},
+		{http.MethodPost, "/api/v1/feedbacks/fb-1", "", nil, false},
+		{http.MethodGet, "/api/v1/marketplace/browse", "", nil, false},
+	}
+	for _, tt := range tests {
+		rpc, params, ok := grpcRoute(tt.method, tt.path)
+		if rpc != tt.rpc || ok != tt.ok || len(params) != len(tt.params) {
+			t.Fatalf("%s %s: got %q %v %v", tt.method, tt.path, rpc, params, ok)
+		}
+		for name, value := range tt.params {
+			if params[name] != value {
+				t.Fatalf("%s %s: param %s = %q, want %q", tt.method, tt.path, name, params[name], value)
+			}
+		}
+	}
+}
+
+func TestGRPCTransport_StopClosesConnection(t *testing.T) {
+	guard, calls := newGRPCTestGuard(t, func(method string, msg map[string]any) ([]byte, error) {
+		return []byte(`{}`), nil
+	})
+	api := guard.api.(*grpcAPITransport)
+	if _, err := guard.postJSON(context.Background(), "/api/v1/heartbeat", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	conn := api.conn
+
+	guard.Stop()
+	if api.conn != nil || conn.GetState() != connectivity.Shutdown {
+		t.Fatal("Stop left the gRPC connection open")
+	}
+	if _, err := guard.postJSON(context.Background(), "/api/v1/heartbeat", []byte(`{}`)); err != nil || len(*calls) != 2 {
+		t.Fatalf("call after Stop: %v, %d calls", err, len(*calls))
+	}
+	guard.Stop()
+}
+
+func TestGRPCTransport_UnreachableServerIsNetworkError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	guard, err := New(Config{
+		ServerURL:     "http://" + addr,
+		LicenseKey:    "LIC-GRPC-001",
+		PublicKeyPEM:  pemEncodePublicKey(pubKey),
+		ProjectSlug:   "demo-project",
+		ComponentSlug: "backend",
+		Transport:     TransportConfig{Protocol: TransportGRPC},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer guard.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := guard.postJSON(ctx, "/api/v1/heartbeat", []byte(`{}`)); !errors.Is(err, ErrNetworkError) {
+		t.Fatalf("err = %v, want ErrNetworkError", err)
+	}
+}
+
+func TestGRPCTLSConfig_PinsApplyWithCustomHTTPClient(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	cfg := Config{
+		ServerURL:     "https://license.example.com",
+		LicenseKey:    "LIC-GRPC-001",
+		PublicKeyPEM:  pemEncodePublicKey(pubKey),
+		ProjectSlug:   "demo-project",
+		ComponentSlug: "backend",
+		HTTPClient:    &http.Client{},
+		Transport:     TransportConfig{Protocol: TransportGRPC},
+	}
+	if _, err := New(cfg); !errors.Is(err, ErrTLSPinNotConfigured) {
+		t.Fatalf("err = %v, want ErrTLSPinNotConfigured", err)
+	}
+
+	cfg.PinnedSPKIHashes = []string{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}
+	guard, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer guard.Stop()
+	tlsCfg, err := guard.grpcTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsCfg.VerifyConnection == nil {
+		t.Fatal("gRPC TLS config does not check the pins")
+	}
+}
@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+type stubTransport struct {
+	calls   []TransportRequest
+	fetched []string
+	reply   []byte
+	err     error
+}
+
+func (s *stubTransport) Call(_ context.Context, req TransportRequest) ([]byte, error) {
+	s.calls = append(s.calls, req)
+	return s.reply, s.err
+}
+
+func (s *stubTransport) FetchArtifact(_ context.Context, url string) (io.ReadCloser, int64, error) {
+	s.fetched = append(s.fetched, url)
+	return io.NopCloser(strings.NewReader("artifact")), int64(len("artifact")), nil
+}
+
+func newStubTransportGuard(t *testing.T, transport Transport) *Guard {
+	t.Helper()
+
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	guard, err := New(Config{
+		ServerURL:        "https://license.example.com",
+		LicenseKey:       "LIC-STUB-001",
+		PublicKeyPEM:     pemEncodePublicKey(pubKey),
+		ProjectSlug:      "demo-project",
+		ComponentSlug:    "backend",
+		AllowSystemTrust: true,
+		CustomTransport:  transport,
+	})
+	if err != nil {
+		t.Fatalf("new guard: %v", err)
+	}
+	return guard
+}
+
+func TestCustomTransport_CarriesAPICallsAndDownloads(t *testing.T) {
+	stub := &stubTransport{reply: []byte(`{"id":"fb-1","title":"Broken"}`)}
+	guard := newStubTransportGuard(t, stub)
+
+	item, err := guard.GetFeedback(context.Background(), "fb-1")
+	if err != nil {
+		t.Fatalf("get feedback: %v", err)
+	}
+	if item.ID != "fb-1" {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+	if len(stub.calls) != 1 {
+		t.Fatalf("expected 1 call, got %d", len(stub.calls))
+	}
+	call := stub.calls[0]
+	if call.Method != http.MethodGet || call.Path != "/api/v1/feedbacks/fb-1" || call.Query.Get("project_slug") != "demo-project" || !call.Idempotent {
+		t.Fatalf("unexpected request %+v", call)
+	}
+	if call.Header.Get("Authorization") != "License LIC-STUB-001" || call.Header.Get(headerSignature) == "" {
+		t.Fatalf("call carries no signed headers: %v", call.Header)
+	}
+	signedURL, _ := url.Parse("https://license.example.com/api/v1/feedbacks/fb-1?" + call.Query.Encode())
+	want := requestSignature("LIC-STUB-001", http.MethodGet, signedURL, call.Header.Get(headerTimestamp), call.Header.Get(headerContentSHA256))
+	if call.Header.Get(headerSignature) != want {
+		t.Fatal("signed headers do not match the call")
+	}
+
+	tmpPath, hash, err := guard.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: "/download/app.bin"}, "", 1024)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer os.Remove(tmpPath)
+	sum := sha256.Sum256([]byte("artifact"))
+	if hash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+	if len(stub.fetched) != 1 || stub.fetched[0] != "https://license.example.com/download/app.bin" {
+		t.Fatalf("unexpected fetches %v", stub.fetched)
+	}
+}
+
+func TestCustomTransport_FetcherServesArtifacts(t *testing.T) {
+	stub := &stubTransport{}
+	guard := newStubTransportGuard(t, stub)
+	guard.cfg.OTA.Fetcher = fetcherFunc(func(context.Context, FetchRequest) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("mirrored")), nil
+	})
+
+	tmpPath, _, err := guard.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: "/download/app.bin"}, "", 1024)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer os.Remove(tmpPath)
+	if data, _ := os.ReadFile(tmpPath); string(data) != "mirrored" || len(stub.fetched) != 0 {
+		t.Fatalf("artifact = %q, transport fetches %v; want the Fetcher to serve it", data, stub.fetched)
+	}
+}
+
+func TestCustomTransport_ErrorsKeepAPIErrorSemantics(t *testing.T) {
+	stub := &stubTransport{err: NewTransportError(http.StatusNotFound, "", "no route")}
+	guard := newStubTransportGuard(t, stub)
+
+	_, err := guard.GetFeedback(context.Background(), "fb-1")
+	if !errors.Is(err, ErrFeatureUnsupportedByServer) {
+		t.Fatalf("expected unsupported feature error, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// trialTier is the lease tier the server issues for trial licenses.
+const trialTier = "trial"
+
+// TrialOptions configures a StartTrialWithOptions call.
+type TrialOptions struct {
+	ServerURL   string
+	ProjectSlug string
+	Email       string
+	// Fingerprint identifies the machine the trial is for; nil means this
+	// machine. The server allows one trial per machine and project.
+	Fingerprint *Fingerprint
+	// FingerprintConfig limits the aux signals sent, as Config.Fingerprint
+	// does for the guard.
+	FingerprintConfig FingerprintConfig
+	// Context bounds the request; the default times out after 30 seconds.
+	Context          context.Context
+	HTTPClient       *http.Client
+	AllowSystemTrust bool
+	PinnedSPKIHashes []string
+	Transport        TransportConfig
+	UserAgent        string
+}
+
+type trialRequestBody struct {
+	ProjectSlug string            `json:"project_slug"`
+	Email       string            `json:"email"`
+	MachineID   string            `json:"machine_id"`
+	AuxSignals  map[string]string `json:"aux_signals"`
+	Hostname    string            `json:"hostname"`
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+}
+
+// StartTrial requests a time-limited trial license for projectSlug without
+// an activation code. The returned LicenseKey goes into Config.LicenseKey and
+// ExpiresAt is when the trial ends. If fingerprint is nil, this machine's is
+// used; if serverURL is empty, DefaultServerURL is used.
+func StartTrial(ctx context.Context, serverURL, projectSlug, email string, fingerprint *Fingerprint) (*ActivationResult, error) {
+	return StartTrialWithOptions(TrialOptions{
+		ServerURL:        serverURL,
+		ProjectSlug:      projectSlug,
+		Email:            email,
+		Fingerprint:      fingerprint,
+		Context:          ctx,
+		AllowSystemTrust: true,
+	})
+}
+
+// StartTrialWithOptions is StartTrial with explicit transport and request
+// controls.
+func StartTrialWithOptions(opts TrialOptions) (*ActivationResult, error) {
+	if opts.ProjectSlug == "" {
+		return nil, fmt.Errorf("project slug is required")
+	}
+	if opts.Email == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+	serverURL, err := normalizeServerURL(opts.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), defaultActivationTimeout)
+		defer cancel()
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client, err = newPinnedHTTPClient(Config{
+			ServerURL:        serverURL,
+			AllowSystemTrust: opts.AllowSystemTrust,
+			PinnedSPKIHashes: opts.PinnedSPKIHashes,
+			Transport:        opts.Transport,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fp := opts.Fingerprint
+	if fp == nil {
+		fp, err = collectFingerprint()
+		if err != nil {
+			return nil, fmt.Errorf("collect fingerprint: %w", err)
+		}
+	}
+	fp = fp.withPrivacy(opts.FingerprintConfig)
+	data, err := json.Marshal(trialRequestBody{
+		ProjectSlug: opts.ProjectSlug,
+		Email:       opts.Email,
+		MachineID:   fp.MachineID(),
+		AuxSignals:  fp.AuxSignals(),
+		Hostname:    hostname(),
+		OS:          fp.auxSignals["os"],
+		Arch:        fp.auxSignals["arch"],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL+"/api/v1/trial", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", activationUserAgent(opts.UserAgent))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNetworkError, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, decodeAPIErrorResponse(resp)
+	}
+
+	raw, err := readAPIJSONResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	var result ActivationResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+	}
+	if result.LicenseKey == "" {
+		return nil, ErrInvalidServerResponse
+	}
+	return &result, nil
+}
+
+// TrialStatus describes a trial license as granted by the current lease.
+type TrialStatus struct {
+	// ExpiresAt is when the trial ends.
+	ExpiresAt time.Time
+	// Remaining is the time left until ExpiresAt, never negative.
+	Remaining time.Duration
+	// DaysRemaining is Remaining in whole days, rounded up, so the last day
+	// of a trial reports 1 and an expired trial 0.
+	DaysRemaining int
+}
+
+// TrialInfo reports the remaining trial time from the lease entitlements. It
+// returns false when the guard holds no lease or the license is not a trial.
+func (g *Guard) TrialInfo() (TrialStatus, bool) {
+	state := g.currentLeaseState()
+	if state == nil || state.Lease == nil || state.Lease.Tier != trialTier {
+		return TrialStatus{}, false
+	}
+	expiresAt, err := parseRFC3339(state.Lease.ExpiresAt)
+	if err != nil {
+		return TrialStatus{}, false
+	}
+	return trialStatus(expiresAt, time.Now()), true
+}
+
+func trialStatus(expiresAt, now time.Time) TrialStatus {
+	status := TrialStatus{ExpiresAt: expiresAt}
+	if remaining := expiresAt.Sub(now); remaining > 0 {
+		status.Remaining = remaining
+		status.DaysRemaining = int(math.Ceil(remaining.Hours() / 24))
+	}
+	return status
+}
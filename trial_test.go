@@ -0,0 +1,92 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStartTrialSendsFingerprint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/trial" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var body trialRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body.ProjectSlug != "test-project" || body.Email != "dev@example.com" || body.MachineID != testingMachineID {
+			t.Errorf("unexpected request body: %#v", body)
+		}
+		_ = json.NewEncoder(w).Encode(ActivationResult{
+			LicenseKey:  "trial-key",
+			ProjectSlug: "test-project",
+			ExpiresAt:   "2026-11-01T00:00:00Z",
+		})
+	}))
+	defer server.Close()
+
+	fp := &Fingerprint{machineID: testingMachineID, auxSignals: map[string]string{"os": "linux", "arch": "amd64"}}
+	result, err := StartTrial(context.Background(), server.URL, "test-project", "dev@example.com", fp)
+	if err != nil {
+		t.Fatalf("StartTrial: %v", err)
+	}
+	if result.LicenseKey != "trial-key" || result.ExpiresAt != "2026-11-01T00:00:00Z" {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
+
+func TestStartTrialMapsAlreadyUsed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "trial_already_used"})
+	}))
+	defer server.Close()
+
+	fp := &Fingerprint{machineID: testingMachineID, auxSignals: map[string]string{}}
+	_, err := StartTrial(context.Background(), server.URL, "test-project", "dev@example.com", fp)
+	if !errors.Is(err, ErrTrialAlreadyUsed) {
+		t.Fatalf("expected ErrTrialAlreadyUsed, got %v", err)
+	}
+}
+
+func TestTrialInfoReportsRemainingDays(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {})
+	if _, ok := g.TrialInfo(); ok {
+		t.Fatal("expected no trial without a lease")
+	}
+
+	trialLease := testLease(testingMachineID)
+	trialLease.Tier = "trial"
+	trialLease.ExpiresAt = time.Now().Add(36 * time.Hour).UTC().Format(time.RFC3339)
+	if err := g.store.Save(&persistedState{Lease: trialLease}); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := g.TrialInfo()
+	if !ok {
+		t.Fatal("expected trial info for a trial lease")
+	}
+	if info.DaysRemaining != 2 || info.Remaining <= 0 {
+		t.Fatalf("unexpected trial info: %#v", info)
+	}
+
+	trialLease.Tier = "commercial"
+	if err := g.store.Save(&persistedState{Lease: trialLease}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.TrialInfo(); ok {
+		t.Fatal("expected no trial info for a commercial lease")
+	}
+}
+
+func TestTrialStatusAfterExpiry(t *testing.T) {
+	now := time.Now()
+	status := trialStatus(now.Add(-time.Hour), now)
+	if status.DaysRemaining != 0 || status.Remaining != 0 {
+		t.Fatalf("expected an expired trial to report nothing left, got %#v", status)
+	}
+}
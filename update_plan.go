@@ -0,0 +1,189 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// UpdatePlan describes what installing a component's pending update would
+// involve, worked out by PlanUpdate without changing anything, e.g. for an
+// operator confirmation dialog. Install it with InstallVersion(ctx,
+// Component, TargetVersion); that pins the component to TargetVersion, so
+// auto-update leaves it alone until UnpinVersion is called.
+type UpdatePlan struct {
+	Component      string
+	CurrentVersion string
+	// TargetVersion is the version InstallVersion pinned, else the one the
+	// server last offered; empty when no update is known.
+	TargetVersion string
+	// UpgradePath lists the versions installed first, oldest first.
+	UpgradePath []string
+	Mandatory   bool
+	// Downgrade is set when TargetVersion is older than CurrentVersion.
+	Downgrade bool
+	// RequiresRestart is set for binary components, which run the new
+	// version only once restarted.
+	RequiresRestart bool
+	// SizeBytes is the artifact size, from the download metadata or the
+	// download; 0 when unknown.
+	SizeBytes int64
+	// RequiredBytes estimates the disk space the update needs where it is
+	// staged: twice the artifact for a binary, five times for an archive,
+	// which is also extracted. FreeBytes is the space available there, -1
+	// when it cannot be determined.
+	RequiredBytes int64
+	FreeBytes     int64
+	// Verified is set when the plan downloaded the artifact and its digest
+	// and signature checked out.
+	Verified bool
+	// Blockers are why the update would not install: errors matching
+	// ErrPluginVersionPinned, ErrPluginVersionIgnored, ErrUpdateDowngrade or
+	// ErrInsufficientDiskSpace.
+	Blockers []error
+}
+
+// Ready reports whether an update is known and nothing blocks it.
+func (p UpdatePlan) Ready() bool {
+	return p.TargetVersion != "" && len(p.Blockers) == 0
+}
+
+// PlanOptions tune PlanUpdateWithOptions.
+type PlanOptions struct {
+	// Download fetches and verifies the artifact as well. With
+	// OTAConfig.ArtifactCache the verified artifact is kept, so the install
+	// does not download it again.
+	Download bool
+}
+
+// PlanUpdate is PlanUpdateWithOptions without downloading the artifact.
+func (g *Guard) PlanUpdate(ctx context.Context, component string) (UpdatePlan, error) {
+	return g.PlanUpdateWithOptions(ctx, component, PlanOptions{})
+}
+
+// PlanUpdateWithOptions fetches the download metadata of component's
+// pending update, checks the local version policy, downgrades and free
+// disk space and, with opts.Download, downloads and verifies the artifact,
+// but installs nothing. A plan without TargetVersion means no update is known yet.
+// Metadata, download and verification failures are returned as errors;
+// conditions an operator could resolve are listed in UpdatePlan.Blockers.
+func (g *Guard) PlanUpdateWithOptions(ctx context.Context, component string, opts PlanOptions) (UpdatePlan, error) {
+	if err := g.requireClient(); err != nil {
+		return UpdatePlan{}, err
+	}
+	plan := UpdatePlan{Component: component, FreeBytes: -1}
+	var stageDir string
+	if component == g.cfg.ComponentSlug {
+		plan.CurrentVersion = g.currentVersion()
+		plan.RequiresRestart = true
+		if exe, err := os.Executable(); err == nil {
+			stageDir = filepath.Dir(exe)
+		}
+	} else if mc, ok := g.findManagedComponent(component); ok {
+		plan.CurrentVersion = g.currentManagedVersion(component)
+		plan.RequiresRestart = mc.Strategy == UpdateBackend
+		stageDir = filepath.Dir(filepath.Clean(mc.Dir))
+		if mc.Strategy == UpdateFrontend && mc.BlueGreen {
+			stageDir = mc.Dir
+		}
+	} else {
+		return UpdatePlan{}, fmt.Errorf("%w: %s", ErrComponentNotFound, component)
+	}
+
+	g.mu.RLock()
+	offered, hasOffer := g.offeredUpdates[component]
+	g.mu.RUnlock()
+	if pinned, ok := g.versionPin(component); ok {
+		plan.TargetVersion = pinned
+	} else if hasOffer {
+		plan.TargetVersion = offered.Latest
+		plan.UpgradePath = slices.Clone(offered.UpgradePath)
+		plan.Mandatory = offered.Mandatory
+	}
+	if plan.TargetVersion == "" || SameVersion(plan.CurrentVersion, plan.TargetVersion) {
+		plan.TargetVersion, plan.UpgradePath, plan.Mandatory = "", nil, false
+		return plan, nil
+	}
+	plan.Downgrade = IsDowngrade(plan.CurrentVersion, plan.TargetVersion)
+	if err := g.checkVersionPolicy(component, plan.TargetVersion); err != nil {
+		plan.Blockers = append(plan.Blockers, err)
+	}
+	if ok, _ := g.versionAllowed(component, plan.CurrentVersion, plan.TargetVersion); !ok {
+		plan.Blockers = append(plan.Blockers, fmt.Errorf("%w: %s %s to %s", ErrUpdateDowngrade, component, plan.CurrentVersion, plan.TargetVersion))
+	}
+
+	osValue, archValue := g.resolveOTAPlatform("", "")
+	meta, err := g.requestDownloadMeta(ctx, component, plan.TargetVersion, osValue, archValue)
+	if err != nil {
+		return plan, fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+	}
+	plan.SizeBytes = meta.SizeBytes
+
+	if opts.Download {
+		tmpPath, digest, err := g.fetchArtifact(ctx, meta.fetchRequest(component, plan.TargetVersion, osValue, archValue), existingDir(stageDir), g.otaMaxArtifactBytes())
+		if err != nil {
+			return plan, fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+		}
+		plan.SizeBytes = artifactSize(tmpPath)
+		os.Remove(tmpPath)
+		if digest != meta.Digest {
+			return plan, fmt.Errorf("%w: %s mismatch: expected %s, got %s", ErrUpdateVerify, meta.Algorithm, meta.Digest, digest)
+		}
+		if err := g.verifySignature(signedDigest(meta.Algorithm, meta.Digest), meta.Signature); err != nil {
+			return plan, fmt.Errorf("%w: %v", ErrUpdateVerify, err)
+		}
+		plan.Verified = true
+	}
+
+	if free, err := diskFreeBytes(existingDir(stageDir)); err == nil {
+		plan.FreeBytes = free
+	}
+	if plan.SizeBytes > 0 {
+		plan.RequiredBytes = 2 * plan.SizeBytes
+		if !plan.RequiresRestart {
+			plan.RequiredBytes = 5 * plan.SizeBytes
+		}
+		if plan.FreeBytes >= 0 && plan.FreeBytes < plan.RequiredBytes {
+			plan.Blockers = append(plan.Blockers, fmt.Errorf("%w: %s needs about %d bytes, %d free", ErrInsufficientDiskSpace, component, plan.RequiredBytes, plan.FreeBytes))
+		}
+	}
+	return plan, nil
+}
+
+// recordOfferedUpdate remembers an update the server offered, for
+// PlanUpdate.
+func (g *Guard) recordOfferedUpdate(u updateInfo) {
+	if u.Component == "" || u.Latest == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.offeredUpdates == nil {
+		g.offeredUpdates = make(map[string]updateInfo)
+	}
+	g.offeredUpdates[u.Component] = u
+}
+
+// existingDir returns dir or its nearest existing ancestor, so a
+// component that was never installed can still be planned.
+func existingDir(dir string) string {
+	if strings.TrimSpace(dir) == "" {
+		return os.TempDir()
+	}
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,142 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func newPlanTestGuard(t *testing.T, artifact []byte, sizeBytes int64, signature func(hashHex string) string) (*Guard, *atomic.Int32) {
+	t.Helper()
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	hashHex := sha256Hex(artifact)
+	sig := signUpdateHash(t, privKey, hashHex)
+	if signature != nil {
+		sig = signature(hashHex)
+	}
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"download_url": "/download/worker",
+				"sha256":       hashHex,
+				"signature":    sig,
+				"size_bytes":   sizeBytes,
+			})
+		case "/download/worker":
+			downloads.Add(1)
+			_, _ = w.Write(artifact)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.cfg.ManagedComponents = []ManagedComponent{{Slug: "worker", Dir: filepath.Join(t.TempDir(), "bin", "worker"), Strategy: UpdateBackend}}
+	g.managedVersions = map[string]string{"worker": "1.0.0"}
+	return g, &downloads
+}
+
+func TestPlanUpdate_DescribesOfferedUpdate(t *testing.T) {
+	g, downloads := newPlanTestGuard(t, []byte("worker 2.0.0"), 12, nil)
+	g.recordOfferedUpdate(updateInfo{Component: "worker", Latest: "2.0.0", UpdateAvailable: true, Mandatory: true, UpgradePath: []string{"1.5.0"}})
+
+	plan, err := g.PlanUpdate(context.Background(), "worker")
+	if err != nil {
+		t.Fatalf("PlanUpdate: %v", err)
+	}
+	if plan.CurrentVersion != "1.0.0" || plan.TargetVersion != "2.0.0" || !plan.Mandatory || !plan.RequiresRestart || plan.Downgrade {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if len(plan.UpgradePath) != 1 || plan.SizeBytes != 12 || plan.RequiredBytes != 24 || plan.Verified {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if !plan.Ready() {
+		t.Fatalf("plan not ready: %v", plan.Blockers)
+	}
+	if downloads.Load() != 0 {
+		t.Fatal("PlanUpdate must not download the artifact")
+	}
+	if got := g.currentManagedVersion("worker"); got != "1.0.0" {
+		t.Fatalf("installed version changed to %s", got)
+	}
+}
+
+func TestPlanUpdate_WithoutOfferHasNoTarget(t *testing.T) {
+	g, _ := newPlanTestGuard(t, []byte("worker"), 0, nil)
+	plan, err := g.PlanUpdate(context.Background(), "worker")
+	if err != nil || plan.TargetVersion != "" || plan.Ready() {
+		t.Fatalf("plan = %+v, %v", plan, err)
+	}
+	if _, err := g.PlanUpdate(context.Background(), "unknown"); !errors.Is(err, ErrComponentNotFound) {
+		t.Fatalf("err = %v, want ErrComponentNotFound", err)
+	}
+}
+
+func TestPlanUpdate_ListsBlockers(t *testing.T) {
+	g, _ := newPlanTestGuard(t, []byte("worker"), 1<<50, nil)
+	g.cfg.OTA.IgnoredVersions = map[string][]string{"worker": {"2.0.0"}}
+	g.recordOfferedUpdate(updateInfo{Component: "worker", Latest: "2.0.0", UpdateAvailable: true})
+
+	plan, err := g.PlanUpdate(context.Background(), "worker")
+	if err != nil {
+		t.Fatalf("PlanUpdate: %v", err)
+	}
+	if plan.Ready() || !errors.Is(errors.Join(plan.Blockers...), ErrPluginVersionIgnored) {
+		t.Fatalf("blockers = %v, want ignored version", plan.Blockers)
+	}
+	if plan.FreeBytes >= 0 && !errors.Is(errors.Join(plan.Blockers...), ErrInsufficientDiskSpace) {
+		t.Fatalf("blockers = %v, want insufficient disk space with %d free", plan.Blockers, plan.FreeBytes)
+	}
+}
+
+func TestPlanUpdate_BlocksPinnedAndDowngradedVersions(t *testing.T) {
+	g, _ := newPlanTestGuard(t, []byte("worker"), 0, nil)
+	g.cfg.OTA.PinnedVersions = map[string]string{"worker": "1.0.0"}
+	g.recordOfferedUpdate(updateInfo{Component: "worker", Latest: "2.0.0", UpdateAvailable: true})
+	plan, err := g.PlanUpdate(context.Background(), "worker")
+	if err != nil || plan.Ready() || !errors.Is(errors.Join(plan.Blockers...), ErrPluginVersionPinned) {
+		t.Fatalf("blockers = %v, %v, want pinned version", plan.Blockers, err)
+	}
+
+	g.cfg.OTA.PinnedVersions = nil
+	g.recordOfferedUpdate(updateInfo{Component: "worker", Latest: "0.9.0", UpdateAvailable: true})
+	plan, err = g.PlanUpdate(context.Background(), "worker")
+	if err != nil || !plan.Downgrade || plan.Ready() || !errors.Is(errors.Join(plan.Blockers...), ErrUpdateDowngrade) {
+		t.Fatalf("plan = %+v, %v, want a blocked downgrade", plan, err)
+	}
+	g.cfg.OTA.AllowDowngrade = true
+	if plan, err = g.PlanUpdate(context.Background(), "worker"); err != nil || !plan.Ready() {
+		t.Fatalf("blockers = %v, %v, want the downgrade allowed", plan.Blockers, err)
+	}
+}
+
+func TestPlanUpdateWithOptions_DownloadsAndVerifies(t *testing.T) {
+	artifact := []byte("worker 2.0.0")
+	g, downloads := newPlanTestGuard(t, artifact, 0, nil)
+	g.recordOfferedUpdate(updateInfo{Component: "worker", Latest: "2.0.0", UpdateAvailable: true})
+
+	plan, err := g.PlanUpdateWithOptions(context.Background(), "worker", PlanOptions{Download: true})
+	if err != nil {
+		t.Fatalf("PlanUpdateWithOptions: %v", err)
+	}
+	if !plan.Verified || plan.SizeBytes != int64(len(artifact)) || downloads.Load() != 1 {
+		t.Fatalf("plan = %+v after %d downloads", plan, downloads.Load())
+	}
+
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	g, _ = newPlanTestGuard(t, artifact, 0, func(hashHex string) string { return signUpdateHash(t, otherKey, hashHex) })
+	g.recordOfferedUpdate(updateInfo{Component: "worker", Latest: "2.0.0", UpdateAvailable: true})
+	if _, err := g.PlanUpdateWithOptions(context.Background(), "worker", PlanOptions{Download: true}); !errors.Is(err, ErrUpdateVerify) {
+		t.Fatalf("err = %v, want ErrUpdateVerify", err)
+	}
+}
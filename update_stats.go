@@ -0,0 +1,160 @@
+package sdk
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const (
+	maxPendingUpdateStats = 20
+	// maxUpdateHistory bounds the recent attempts kept for crash reports.
+	maxUpdateHistory = 10
+	// updateHistoryFileName is the cache entry keeping updateHistory across
+	// restarts.
+	updateHistoryFileName = "update_history.json"
+)
+
+// UpdateStats captures delivery performance for a single OTA attempt.
+type UpdateStats struct {
+	Component        string
+	OldVersion       string
+	NewVersion       string
+	Success          bool
+	Bytes            int64
+	DownloadDuration time.Duration
+	VerifyDuration   time.Duration
+	MigrateDuration  time.Duration
+	ApplyDuration    time.Duration
+	TotalDuration    time.Duration
+}
+
+// Throughput returns the download throughput in bytes per second.
+func (s UpdateStats) Throughput() float64 {
+	if s.Bytes <= 0 || s.DownloadDuration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.DownloadDuration.Seconds()
+}
+
+type updateStatsReport struct {
+	Component     string  `json:"component"`
+	OldVersion    string  `json:"old_version"`
+	NewVersion    string  `json:"new_version"`
+	Success       bool    `json:"success"`
+	Bytes         int64   `json:"bytes"`
+	DownloadMs    int64   `json:"download_ms"`
+	VerifyMs      int64   `json:"verify_ms"`
+	MigrateMs     int64   `json:"migrate_ms,omitempty"`
+	ApplyMs       int64   `json:"apply_ms"`
+	TotalMs       int64   `json:"total_ms"`
+	ThroughputBps float64 `json:"throughput_bps"`
+}
+
+func newUpdateStats(component, oldVersion, newVersion string) *UpdateStats {
+	return &UpdateStats{Component: component, OldVersion: oldVersion, NewVersion: newVersion}
+}
+
+// finishUpdateStats closes out an attempt, hands it to OnUpdateStats and
+// queues it for the next heartbeat.
+func (g *Guard) finishUpdateStats(stats *UpdateStats, start time.Time, err error) {
+	stats.Success = err == nil
+	stats.TotalDuration = time.Since(start)
+
+	g.mu.Lock()
+	g.pendingUpdateStats = append(g.pendingUpdateStats, *stats)
+	if len(g.pendingUpdateStats) > maxPendingUpdateStats {
+		g.pendingUpdateStats = g.pendingUpdateStats[len(g.pendingUpdateStats)-maxPendingUpdateStats:]
+	}
+	g.updateHistory = append(g.updateHistory, *stats)
+	if len(g.updateHistory) > maxUpdateHistory {
+		g.updateHistory = g.updateHistory[len(g.updateHistory)-maxUpdateHistory:]
+	}
+	history, _ := json.Marshal(g.updateHistory)
+	g.mu.Unlock()
+	if err := g.sealedEntries().SaveEntry(updateHistoryFileName, history); err != nil {
+		g.logger.Warn("save update history failed", "error", err)
+	}
+	g.metrics.observeUpdate(stats)
+	g.auditOutcome(AuditUpdate, stats.Component, err, stats.OldVersion+" -> "+stats.NewVersion)
+
+	g.logger.Info("update stats",
+		"component", stats.Component,
+		"success", stats.Success,
+		"bytes", stats.Bytes,
+		"throughput_bps", stats.Throughput(),
+		"download", stats.DownloadDuration.String(),
+		"verify", stats.VerifyDuration.String(),
+		"migrate", stats.MigrateDuration.String(),
+		"apply", stats.ApplyDuration.String(),
+		"total", stats.TotalDuration.String(),
+	)
+	if g.cfg.OTA.OnUpdateStats != nil {
+		g.cfg.OTA.OnUpdateStats(*stats)
+	}
+}
+
+// loadUpdateHistory restores the attempts saved by earlier runs; a missing or
+// unreadable entry starts an empty history.
+func loadUpdateHistory(secrets *secretStore) []UpdateStats {
+	data, err := secrets.LoadEntry(updateHistoryFileName)
+	if err != nil {
+		return nil
+	}
+	var history []UpdateStats
+	if json.Unmarshal(data, &history) != nil || len(history) > maxUpdateHistory {
+		return nil
+	}
+	return history
+}
+
+func (g *Guard) pendingUpdateStatsSnapshot() []updateStatsReport {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.pendingUpdateStats) == 0 {
+		return nil
+	}
+	return updateStatsReports(g.pendingUpdateStats)
+}
+
+func updateStatsReports(stats []UpdateStats) []updateStatsReport {
+	reports := make([]updateStatsReport, 0, len(stats))
+	for _, s := range stats {
+		reports = append(reports, updateStatsReport{
+			Component:     s.Component,
+			OldVersion:    s.OldVersion,
+			NewVersion:    s.NewVersion,
+			Success:       s.Success,
+			Bytes:         s.Bytes,
+			DownloadMs:    s.DownloadDuration.Milliseconds(),
+			VerifyMs:      s.VerifyDuration.Milliseconds(),
+			MigrateMs:     s.MigrateDuration.Milliseconds(),
+			ApplyMs:       s.ApplyDuration.Milliseconds(),
+			TotalMs:       s.TotalDuration.Milliseconds(),
+			ThroughputBps: s.Throughput(),
+		})
+	}
+	return reports
+}
+
+// dropReportedUpdateStats removes the first n entries once the server has
+// accepted them; attempts finished meanwhile stay queued.
+func (g *Guard) dropReportedUpdateStats(n int) {
+	if n == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if n > len(g.pendingUpdateStats) {
+		n = len(g.pendingUpdateStats)
+	}
+	g.pendingUpdateStats = append([]UpdateStats(nil), g.pendingUpdateStats[n:]...)
+}
+
+func artifactSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,90 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateFrontend_ReportsStats(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	archive := buildTarGz(t, map[string]string{"index.html": "hello"})
+	hashHex := sha256Hex(archive)
+	signature := signUpdateHash(t, privKey, hashHex)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"download_url": "/download/frontend.tar.gz",
+				"sha256":       hashHex,
+				"signature":    signature,
+			})
+		case "/download/frontend.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	var got []UpdateStats
+	g.cfg.OTA.OnUpdateStats = func(stats UpdateStats) { got = append(got, stats) }
+
+	mc := ManagedComponent{Slug: "frontend", Dir: filepath.Join(t.TempDir(), "live")}
+	if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+		t.Fatalf("updateFrontend: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected one stats callback, got %d", len(got))
+	}
+	stats := got[0]
+	if !stats.Success || stats.Bytes != int64(len(archive)) || stats.NewVersion != "2.0.0" {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+	if stats.TotalDuration <= 0 || stats.TotalDuration < stats.DownloadDuration+stats.VerifyDuration+stats.ApplyDuration {
+		t.Fatalf("inconsistent durations: %#v", stats)
+	}
+
+	reports := g.pendingUpdateStatsSnapshot()
+	if len(reports) != 1 || reports[0].Component != "frontend" || reports[0].Bytes != int64(len(archive)) {
+		t.Fatalf("expected stats queued for heartbeat, got %#v", reports)
+	}
+}
+
+func TestHeartbeat_SendsAndClearsUpdateStats(t *testing.T) {
+	guard, privKey, leaseJSON, sig := newActiveHeartbeatGuard(t)
+	guard.pendingUpdateStats = []UpdateStats{{Component: "frontend", NewVersion: "2.0.0", Success: true, Bytes: 1024}}
+
+	var received []updateStatsReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body heartbeatRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode heartbeat body: %v", err)
+			return
+		}
+		received = body.UpdateStats
+		resp := heartbeatResponse{Status: "ok", Lease: leaseJSON, LeaseSignature: sig}
+		_ = json.NewEncoder(w).Encode(signHeartbeatResponse(t, privKey, resp, body.Nonce))
+	}))
+	defer server.Close()
+	guard.cfg.ServerURL = server.URL
+	guard.httpClient = server.Client()
+
+	if err := guard.sendHeartbeat(context.Background()); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if len(received) != 1 || received[0].Component != "frontend" || received[0].Bytes != 1024 {
+		t.Fatalf("expected update stats in heartbeat, got %#v", received)
+	}
+	if reports := guard.pendingUpdateStatsSnapshot(); len(reports) != 0 {
+		t.Fatalf("expected reported stats to be cleared, got %#v", reports)
+	}
+}
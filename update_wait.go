@@ -0,0 +1,77 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+)
+
+// WaitForUpdate blocks until slug (the main component or a managed one)
+// reaches version, the update to that version fails, or ctx ends. It returns
+// immediately if the component already runs version. Pair it with a
+// server-side release push to await the rollout on this node.
+func (g *Guard) WaitForUpdate(ctx context.Context, slug, version string) error {
+	if err := g.requireCapability(CapabilityPush); err != nil {
+		return err
+	}
+	if slug != g.cfg.ComponentSlug {
+		if _, ok := g.findManagedComponent(slug); !ok {
+			return fmt.Errorf("%w: %s", ErrComponentNotFound, slug)
+		}
+	}
+
+	events := make(chan UpdateNotification, 8)
+	g.updateWaitersMu.Lock()
+	if g.updateWaiters == nil {
+		g.updateWaiters = make(map[chan UpdateNotification]struct{})
+	}
+	g.updateWaiters[events] = struct{}{}
+	g.updateWaitersMu.Unlock()
+	defer func() {
+		g.updateWaitersMu.Lock()
+		delete(g.updateWaiters, events)
+		g.updateWaitersMu.Unlock()
+	}()
+
+	// Checked after subscribing so an update finishing in between is not missed.
+	if normalizeVersionTag(g.componentVersion(slug)) == normalizeVersionTag(version) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-events:
+			if n.Component != slug || normalizeVersionTag(n.NewVersion) != normalizeVersionTag(version) {
+				continue
+			}
+			switch n.Kind {
+			case UpdateNotificationInstalled:
+				return nil
+			case UpdateNotificationFailed:
+				return n.Err
+			}
+		}
+	}
+}
+
+func (g *Guard) componentVersion(slug string) string {
+	if slug == g.cfg.ComponentSlug {
+		return g.currentVersion()
+	}
+	return g.currentManagedVersion(slug)
+}
+
+// publishUpdateEvent fans an update milestone out to WaitForUpdate callers.
+// Waiters that are not draining their buffer miss the event rather than
+// stalling the updater.
+func (g *Guard) publishUpdateEvent(n UpdateNotification) {
+	g.updateWaitersMu.Lock()
+	defer g.updateWaitersMu.Unlock()
+	for events := range g.updateWaiters {
+		select {
+		case events <- n:
+		default:
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func startUpdateWaiter(t *testing.T, g *Guard, slug, version string) <-chan error {
+	t.Helper()
+	result := make(chan error, 1)
+	go func() { result <- g.WaitForUpdate(context.Background(), slug, version) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		g.updateWaitersMu.Lock()
+		registered := len(g.updateWaiters)
+		g.updateWaitersMu.Unlock()
+		if registered > 0 {
+			return result
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("waiter did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWaitForUpdate_ReturnsWhenInstalled(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	archive := buildTarGz(t, map[string]string{"index.html": "hello"})
+	hashHex := sha256Hex(archive)
+	signature := signUpdateHash(t, privKey, hashHex)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/update/download":
+			_ = json.NewEncoder(w).Encode(map[string]string{"download_url": "/download/frontend.tar.gz", "sha256": hashHex, "signature": signature})
+		case "/download/frontend.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	mc := ManagedComponent{Slug: "frontend", Dir: filepath.Join(t.TempDir(), "live")}
+	g.cfg.ManagedComponents = []ManagedComponent{mc}
+
+	result := startUpdateWaiter(t, g, "frontend", "v2.0.0")
+	if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+		t.Fatalf("updateFrontend: %v", err)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("WaitForUpdate = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WaitForUpdate did not return after install")
+	}
+
+	if err := g.WaitForUpdate(context.Background(), "frontend", "2.0.0"); err != nil {
+		t.Fatalf("already at target version should return immediately, got %v", err)
+	}
+}
+
+func TestWaitForUpdate_ReportsFailureAndUnknownComponent(t *testing.T) {
+	g := newLifecycleTestGuard(t, "http://127.0.0.1:1", pubKeyFromRandom(t), "1.0.0")
+	g.cfg.ManagedComponents = []ManagedComponent{{Slug: "frontend"}}
+
+	result := startUpdateWaiter(t, g, "frontend", "2.0.0")
+	g.notifyUpdateFailure("frontend", "1.0.0", "1.5.0", ErrUpdateVerify)
+	g.notifyUpdateFailure("frontend", "1.0.0", "2.0.0", ErrUpdateDownload)
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrUpdateDownload) {
+			t.Fatalf("expected failure for the awaited version, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WaitForUpdate did not return after failure")
+	}
+
+	if err := g.WaitForUpdate(context.Background(), "unknown", "1.0.0"); !errors.Is(err, ErrComponentNotFound) {
+		t.Fatalf("expected ErrComponentNotFound, got %v", err)
+	}
+}
@@ -7,23 +7,35 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/creativeprojects/go-selfupdate/update"
 )
 
-func (g *Guard) handleUpdateNotification(u updateInfo) {
+// handleUpdateNotification announces an available update, once per offered
+// version, and, with AutoUpdate, applies it in the background under ctx so
+// Stop or cancelling Start's context aborts the download.
+func (g *Guard) handleUpdateNotification(ctx context.Context, u updateInfo) {
+	if err := g.checkVersionPolicy(u.Component, u.Latest); err != nil {
+		g.logger.Info("skipping update by local version policy", "component", u.Component, "version", u.Latest, "reason", err)
+		return
+	}
+	g.notifyUpdateAvailable(u)
+
 	// Find matching component config
 	if u.Component == g.cfg.ComponentSlug {
 		if g.cfg.OTA.AutoUpdate {
-			go func() { _ = g.updateBackend(u) }()
+			g.goBackground(func() {
+				_ = g.runUpgradePath(u, g.currentVersion, func(step updateInfo) error { return g.updateBackend(ctx, step) })
+			})
 		}
 		return
 	}
@@ -32,21 +44,19 @@ func (g *Guard) handleUpdateNotification(u updateInfo) {
 		if mc.Slug == u.Component {
 			if g.cfg.OTA.AutoUpdate {
 				// Route based on strategy
-				switch mc.Strategy {
-				case UpdateBackend:
-					go func() { _ = g.updateManagedBackend(mc, u) }()
-				case UpdateFrontend:
-					go func() { _ = g.updateFrontend(mc, u) }()
-				default:
-					go func() { _ = g.updateFrontend(mc, u) }()
+				install := func(step updateInfo) error { return g.updateFrontend(ctx, mc, step) }
+				if mc.Strategy == UpdateBackend {
+					install = func(step updateInfo) error { return g.updateManagedBackend(ctx, mc, step) }
 				}
+				installed := func() string { return g.currentManagedVersion(mc.Slug) }
+				g.goBackground(func() { _ = g.runUpgradePath(u, installed, install) })
 			}
 			return
 		}
 	}
 }
 
-func (g *Guard) updateBackend(u updateInfo) error {
+func (g *Guard) updateBackend(ctx context.Context, u updateInfo) error {
 	exe, err := os.Executable()
 	if err != nil {
 		wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
@@ -55,14 +65,13 @@ func (g *Guard) updateBackend(u updateInfo) error {
 		return wrapped
 	}
 
-	return g.updateBinaryComponent(g.cfg.ComponentSlug, u, exe, g.currentVersion, func(newVersion string) {
-		g.mu.Lock()
-		g.version = newVersion
-		g.mu.Unlock()
+	mc := ManagedComponent{Slug: g.cfg.ComponentSlug, Migrate: g.cfg.OTA.Migrate, MigrationFailure: g.cfg.OTA.MigrationFailure}
+	return g.updateBinaryComponent(ctx, mc, u, exe, g.currentVersion, func(newVersion string) {
+		g.setVersion(newVersion, VersionSourceOTA)
 	})
 }
 
-func (g *Guard) updateManagedBackend(mc ManagedComponent, u updateInfo) error {
+func (g *Guard) updateManagedBackend(ctx context.Context, mc ManagedComponent, u updateInfo) error {
 	targetPath := strings.TrimSpace(mc.Dir)
 	if targetPath == "" {
 		err := fmt.Errorf("managed backend component %q requires Dir as target binary path", mc.Slug)
@@ -72,7 +81,7 @@ func (g *Guard) updateManagedBackend(mc ManagedComponent, u updateInfo) error {
 		return wrapped
 	}
 
-	return g.updateBinaryComponent(mc.Slug, u, targetPath, func() string {
+	return g.updateBinaryComponent(ctx, mc, u, targetPath, func() string {
 		return g.currentManagedVersion(mc.Slug)
 	}, func(newVersion string) {
 		g.mu.Lock()
@@ -82,24 +91,37 @@ func (g *Guard) updateManagedBackend(mc ManagedComponent, u updateInfo) error {
 }
 
 func (g *Guard) updateBinaryComponent(
-	componentSlug string,
+	ctx context.Context,
+	mc ManagedComponent,
 	u updateInfo,
 	targetPath string,
 	getCurrentVersion func() string,
 	setVersion func(newVersion string),
-) error {
+) (retErr error) {
+	componentSlug := mc.Slug
 	if err := g.tryLockUpdate(componentSlug, getCurrentVersion(), u.Latest); err != nil {
 		return err
 	}
 	defer g.updateMu.Unlock()
 
 	oldVersion := getCurrentVersion()
-	if !isStrictlyNewerVersion(oldVersion, u.Latest) {
+	if !g.acceptsVersion(componentSlug, oldVersion, u.Latest) {
 		err := ErrUpdateDowngrade
 		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, err)
 		return err
 	}
 
+	event := LifecycleEvent{Component: componentSlug, OldVersion: oldVersion, NewVersion: u.Latest}
+	if err := g.runPreUpdateHook(ctx, mc, event); err != nil {
+		g.logger.Warn("update vetoed by pre-update hook", "component", componentSlug, "error", err)
+		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, err)
+		return err
+	}
+
+	stats := newUpdateStats(componentSlug, oldVersion, u.Latest)
+	startedAt := time.Now()
+	defer func() { g.finishUpdateStats(stats, startedAt, retErr) }()
+
 	g.logger.Info("starting backend update", "component", componentSlug, "old_version", oldVersion, "new_version", u.Latest)
 
 	if g.cfg.OTA.OnUpdateProgress != nil {
@@ -108,9 +130,9 @@ func (g *Guard) updateBinaryComponent(
 
 	// Stage 1: Request download metadata
 	osValue, archValue := g.resolveOTAPlatform("", "")
-	url, sha256Hash, signature, err := g.requestDownloadMeta(componentSlug, u.Latest, osValue, archValue)
+	meta, err := g.requestDownloadMeta(ctx, componentSlug, u.Latest, osValue, archValue)
 	if err != nil {
-		wrapped := fmt.Errorf("%w: %v", ErrUpdateDownload, err)
+		wrapped := fmt.Errorf("%w: %w", ErrUpdateDownload, err)
 		g.logger.Error("failed to request download metadata", "component", componentSlug, "error", err.Error())
 		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, wrapped)
 		return wrapped
@@ -121,22 +143,27 @@ func (g *Guard) updateBinaryComponent(
 	}
 
 	// Stage 2: Download artifact with progress
-	tmpPath, actualSHA256, err := g.downloadArtifactWithProgress(url, g.otaMaxArtifactBytes())
+	downloadStart := time.Now()
+	tmpPath, actualDigest, err := g.fetchArtifact(ctx, meta.fetchRequest(componentSlug, u.Latest, osValue, archValue), filepath.Dir(targetPath), g.otaMaxArtifactBytes())
+	stats.DownloadDuration = time.Since(downloadStart)
 	if err != nil {
-		wrapped := fmt.Errorf("%w: %v", ErrUpdateDownload, err)
-		g.logger.Error("failed to download artifact", "component", componentSlug, "error", err.Error(), "download_url", url)
+		wrapped := fmt.Errorf("%w: %w", ErrUpdateDownload, err)
+		g.logger.Error("failed to download artifact", "component", componentSlug, "error", err.Error(), "download_url", meta.URL)
 		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, wrapped)
 		return wrapped
 	}
 	defer os.Remove(tmpPath)
+	stats.Bytes = artifactSize(tmpPath)
 
 	if g.cfg.OTA.OnUpdateProgress != nil {
 		g.cfg.OTA.OnUpdateProgress(componentSlug, "verifying", 0.6)
 	}
 
-	// Verify SHA256
-	if actualSHA256 != sha256Hash {
-		err := fmt.Errorf("hash mismatch: expected %s, got %s", sha256Hash, actualSHA256)
+	verifyStart := time.Now()
+
+	// Verify the digest
+	if actualDigest != meta.Digest {
+		err := fmt.Errorf("%s mismatch: expected %s, got %s", meta.Algorithm, meta.Digest, actualDigest)
 		wrapped := fmt.Errorf("%w: %v", ErrUpdateVerify, err)
 		g.logger.Error("hash verification failed", "component", componentSlug, "error", err)
 		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, wrapped)
@@ -144,32 +171,69 @@ func (g *Guard) updateBinaryComponent(
 	}
 
 	// Verify signature
-	if err := g.verifySignature(sha256Hash, signature); err != nil {
+	if err := g.verifySignature(signedDigest(meta.Algorithm, meta.Digest), meta.Signature); err != nil {
 		wrapped := fmt.Errorf("%w: %v", ErrUpdateVerify, err)
 		g.logger.Error("signature verification failed", "component", componentSlug, "error", err)
 		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, wrapped)
 		return wrapped
 	}
+	stats.VerifyDuration = time.Since(verifyStart)
+
+	// Applying is not interruptible, so honour cancellation before it starts.
+	if err := ctx.Err(); err != nil {
+		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, err)
+		return err
+	}
+
+	// Stage 3: Migrate data for the new version; a failed migration stops
+	// the update before anything is swapped.
+	migrateStart := time.Now()
+	err = g.runMigration(ctx, mc, event)
+	stats.MigrateDuration = time.Since(migrateStart)
+	if err != nil {
+		g.logger.Error("migration failed, update aborted", "component", componentSlug, "error", err)
+		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, err)
+		return err
+	}
 
 	if g.cfg.OTA.OnUpdateProgress != nil {
 		g.cfg.OTA.OnUpdateProgress(componentSlug, "applying", 0.8)
 	}
 
-	// Stage 3: Apply binary update using go-selfupdate
-	if err := g.applyBackendBinaryWithSelfupdate(tmpPath, targetPath); err != nil {
+	// Stage 4: Apply binary update using go-selfupdate
+	applyStart := time.Now()
+	err = g.applyBackendBinaryWithSelfupdate(tmpPath, targetPath)
+	stats.ApplyDuration = time.Since(applyStart)
+	if err != nil {
 		wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
 		g.logger.Error("failed to apply update", "component", componentSlug, "error", err)
+		if !errors.Is(err, ErrUpdateRollback) {
+			g.runRollbackHook(context.WithoutCancel(ctx), mc, event, wrapped)
+		}
 		g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, wrapped)
 		return wrapped
 	}
 
+	if mc.SystemdUnit != "" {
+		if err := g.activateSystemdUnit(context.WithoutCancel(ctx), mc, targetPath); err != nil {
+			if !errors.Is(err, ErrUpdateRollback) {
+				g.runRollbackHook(context.WithoutCancel(ctx), mc, event, err)
+			}
+			g.notifyUpdateFailure(componentSlug, oldVersion, u.Latest, err)
+			return err
+		}
+	}
+
 	setVersion(u.Latest)
+	g.runPostInstallHook(context.WithoutCancel(ctx), mc, event)
 
 	g.logger.Info("backend update completed", "component", componentSlug, "old_version", oldVersion, "new_version", u.Latest)
 
 	if g.cfg.OTA.OnUpdateResult != nil {
 		g.cfg.OTA.OnUpdateResult(componentSlug, oldVersion, u.Latest, true, nil)
 	}
+	g.notifyUpdate(UpdateNotification{Kind: UpdateNotificationInstalled, Component: componentSlug, OldVersion: oldVersion, NewVersion: u.Latest})
+	g.restartSupervised(componentSlug)
 
 	if g.cfg.OTA.OnUpdateProgress != nil {
 		g.cfg.OTA.OnUpdateProgress(componentSlug, "completed", 1.0)
@@ -179,9 +243,50 @@ func (g *Guard) updateBinaryComponent(
 }
 
 func (g *Guard) currentVersion() string {
+	if v := g.version.Load(); v != nil {
+		return *v
+	}
+	return ""
+}
+
+// acceptsVersion reports whether an update from oldVersion to latest may be
+// installed: it must be newer, the version InstallVersion pinned, or an
+// older release with OTA.AllowDowngrade.
+func (g *Guard) acceptsVersion(component, oldVersion, latest string) bool {
+	ok, downgrade := g.versionAllowed(component, oldVersion, latest)
+	if downgrade {
+		g.logger.Warn("installing server-offered downgrade", "component", component, "old_version", oldVersion, "new_version", latest)
+	}
+	return ok
+}
+
+// versionAllowed is acceptsVersion without logging; downgrade is set when
+// only OTA.AllowDowngrade admits latest.
+func (g *Guard) versionAllowed(component, oldVersion, latest string) (ok, downgrade bool) {
+	if IsNewer(oldVersion, latest) {
+		return true, false
+	}
+	if pinned, ok := g.versionPin(component); ok && SameVersion(pinned, latest) {
+		return true, false
+	}
+	if g.cfg.OTA.AllowDowngrade && IsDowngrade(oldVersion, latest) {
+		return true, true
+	}
+	return false, false
+}
+
+// IsUpdateDowngrade reports whether the version the server last offered for
+// component is older than the installed one. Such updates are only
+// installed with OTAConfig.AllowDowngrade.
+func (g *Guard) IsUpdateDowngrade(component string) bool {
+	installed := g.currentManagedVersion(component)
+	if component == g.cfg.ComponentSlug {
+		installed = g.currentVersion()
+	}
 	g.mu.RLock()
-	defer g.mu.RUnlock()
-	return g.version
+	offered := g.availableVersions[component]
+	g.mu.RUnlock()
+	return offered != "" && IsDowngrade(installed, offered)
 }
 
 func (g *Guard) currentManagedVersion(slug string) string {
@@ -191,97 +296,155 @@ func (g *Guard) currentManagedVersion(slug string) string {
 }
 
 type downloadMetaRequestBody struct {
-	LicenseKey    string `json:"license_key"`
+	LicenseKey    string `json:"license_key,omitempty"`
 	MachineID     string `json:"machine_id"`
 	ProjectSlug   string `json:"project_slug"`
 	ComponentSlug string `json:"component_slug"`
 	Version       string `json:"version"`
 	OS            string `json:"os"`
 	Arch          string `json:"arch"`
+	// DigestAlgorithms are the artifact digests this SDK can check, most
+	// preferred first; servers that predate them answer with SHA-256.
+	DigestAlgorithms []string `json:"digest_algorithms,omitempty"`
+}
+
+// downloadMeta is the server's download metadata for one artifact: where
+// to fetch it, its digest in the negotiated algorithm and the signature
+// over signedDigest(Algorithm, Digest).
+type downloadMeta struct {
+	URL       string
+	Algorithm string
+	Digest    string
+	Signature string
+	// SizeBytes is the artifact size when the server reports it, else 0.
+	SizeBytes int64
 }
 
-func (g *Guard) requestDownloadMeta(component, version, os, arch string) (url, sha256, signature string, err error) {
+func (m downloadMeta) fetchRequest(component, version, os, arch string) FetchRequest {
+	req := FetchRequest{
+		Component:       component,
+		Version:         version,
+		OS:              os,
+		Arch:            arch,
+		DownloadURL:     m.URL,
+		DigestAlgorithm: m.Algorithm,
+		Digest:          m.Digest,
+		Signature:       m.Signature,
+	}
+	if m.Algorithm == DigestSHA256 {
+		req.SHA256 = m.Digest
+	}
+	return req
+}
+
+func (g *Guard) requestDownloadMeta(ctx context.Context, component, version, os, arch string) (downloadMeta, error) {
 	reqBody := downloadMetaRequestBody{
-		LicenseKey:    g.cfg.LicenseKey,
-		MachineID:     g.fingerprint.MachineID(),
-		ProjectSlug:   g.cfg.ProjectSlug,
-		ComponentSlug: component,
-		Version:       version,
-		OS:            os,
-		Arch:          arch,
+		LicenseKey:       g.bodyLicenseKey(),
+		MachineID:        g.fingerprint.MachineID(),
+		ProjectSlug:      g.cfg.ProjectSlug,
+		ComponentSlug:    component,
+		Version:          version,
+		OS:               os,
+		Arch:             arch,
+		DigestAlgorithms: supportedDigestAlgorithms(),
+	}
+
+	if meta, ok := g.prefetchedMeta(component, version); ok {
+		return meta, nil
 	}
 
 	var resp struct {
 		DownloadURL string `json:"download_url"`
 		SHA256      string `json:"sha256"`
-		Signature   string `json:"signature"`
-		Error       string `json:"error"`
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), g.otaDownloadTimeout())
+		// DigestAlgorithm and Digest are set when the server picked an
+		// algorithm other than SHA-256 from DigestAlgorithms.
+		DigestAlgorithm string `json:"digest_algorithm"`
+		Digest          string `json:"digest"`
+		Signature       string `json:"signature"`
+		SizeBytes       int64  `json:"size_bytes"`
+		// MetadataSignature covers downloadMetaSignaturePayload.
+		MetadataSignature string `json:"metadata_signature"`
+		Error             string `json:"error"`
+		Message           string `json:"message"`
+	}
+
+	ctx, cancel := withTimeout(ctx, g.cfg.Timeouts.API)
 	defer cancel()
 
 	reqBodyJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", "", "", fmt.Errorf("marshal request: %w", err)
+		return downloadMeta{}, fmt.Errorf("marshal request: %w", err)
 	}
-	raw, err := g.postJSON(ctx, "/api/v1/update/download", reqBodyJSON)
+	raw, err := g.postIdempotentJSON(ctx, "/api/v1/update/download", reqBodyJSON)
 	if err != nil {
-		return "", "", "", err
+		return downloadMeta{}, err
 	}
 	if err := json.Unmarshal(raw, &resp); err != nil {
-		return "", "", "", fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
+		return downloadMeta{}, fmt.Errorf("%w: %v", ErrInvalidServerResponse, err)
 	}
 
 	if resp.Error != "" {
-		return "", "", "", fmt.Errorf("server error: %s", resp.Error)
+		return downloadMeta{}, newAPIError(http.StatusOK, resp.Error, resp.Message, "")
+	}
+	meta := downloadMeta{URL: resp.DownloadURL, Algorithm: DigestSHA256, Digest: resp.SHA256, Signature: resp.Signature, SizeBytes: resp.SizeBytes}
+	if algorithm := strings.ToLower(resp.DigestAlgorithm); algorithm != "" && algorithm != DigestSHA256 {
+		if !slices.Contains(reqBody.DigestAlgorithms, algorithm) {
+			return downloadMeta{}, fmt.Errorf("%w: server chose digest algorithm %q, which was not offered", ErrUpdateVerify, resp.DigestAlgorithm)
+		}
+		meta.Algorithm, meta.Digest = algorithm, resp.Digest
+	}
+	if resp.MetadataSignature != "" || g.cfg.OTA.RequireSignedMetadata {
+		payload := downloadMetaSignaturePayload{
+			Component:   component,
+			Version:     version,
+			OS:          os,
+			Arch:        arch,
+			DownloadURL: resp.DownloadURL,
+			SHA256:      resp.SHA256,
+		}
+		if meta.Algorithm != DigestSHA256 {
+			payload.DigestAlgorithm, payload.Digest = meta.Algorithm, meta.Digest
+		}
+		if err := g.verifyDownloadMeta(payload, resp.MetadataSignature); err != nil {
+			return downloadMeta{}, err
+		}
 	}
 
-	return resp.DownloadURL, resp.SHA256, resp.Signature, nil
+	return meta, nil
 }
 
-func (g *Guard) downloadArtifactWithProgress(downloadURL string, maxBytes int64) (tmpPath, sha256Hash string, err error) {
-	fullURL := serverURLForPath(g.cfg.ServerURL, downloadURL)
-	maxBytes = normalizeArtifactMaxBytes(maxBytes)
-
-	ctx, cancel := context.WithTimeout(context.Background(), g.otaDownloadTimeout())
-	defer cancel()
+// downloadMetaSignaturePayload is what the server signs in update download
+// metadata: the artifact hash alone does not stop a forged response from
+// pointing an older or foreign artifact at this request.
+type downloadMetaSignaturePayload struct {
+	Component   string `json:"component"`
+	Version     string `json:"version"`
+	OS          string `json:"os"`
+	Arch        string `json:"arch"`
+	DownloadURL string `json:"download_url"`
+	SHA256      string `json:"sha256"`
+	// DigestAlgorithm and Digest are only present for algorithms other
+	// than SHA-256, so existing signatures stay valid.
+	DigestAlgorithm string `json:"digest_algorithm,omitempty"`
+	Digest          string `json:"digest,omitempty"`
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
-	if err != nil {
-		return "", "", fmt.Errorf("create request: %w", err)
+func (g *Guard) verifyDownloadMeta(payload downloadMetaSignaturePayload, signatureB64 string) error {
+	if signatureB64 == "" {
+		return fmt.Errorf("%w: download metadata is not signed", ErrUpdateVerify)
 	}
-	req.Header.Set("User-Agent", "BanyanHub-SDK/"+Version)
-
-	httpResp, err := g.httpClient.Do(req)
+	raw, err := json.Marshal(payload)
 	if err != nil {
-		return "", "", fmt.Errorf("download failed: %w", err)
-	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("download failed with status %d", httpResp.StatusCode)
+		return fmt.Errorf("%w: %v", ErrUpdateVerify, err)
 	}
-	if httpResp.ContentLength > maxBytes {
-		return "", "", artifactTooLargeError(maxBytes)
-	}
-
-	tmpFile, err := os.CreateTemp("", "deploy-guard-update-*")
+	canonical, err := canonicalJSON(raw)
 	if err != nil {
-		return "", "", fmt.Errorf("create temp file: %w", err)
+		return fmt.Errorf("%w: %v", ErrUpdateVerify, err)
 	}
-	defer tmpFile.Close()
-
-	hasher := sha256.New()
-	limitedReader := newArtifactLimitReader(httpResp.Body, maxBytes)
-
-	if _, err := io.Copy(io.MultiWriter(tmpFile, hasher), limitedReader); err != nil {
-		os.Remove(tmpFile.Name())
-		return "", "", fmt.Errorf("copy failed: %w", err)
+	if err := verifyEd25519Digest(canonical, signatureB64, g.verificationKeys()); err != nil {
+		return fmt.Errorf("%w: download metadata signature invalid", ErrUpdateVerify)
 	}
-
-	actualHash := hex.EncodeToString(hasher.Sum(nil))
-	return tmpFile.Name(), actualHash, nil
+	return nil
 }
 
 func (g *Guard) verifySignature(data, signatureB64 string) error {
@@ -306,11 +469,10 @@ func (g *Guard) applyBackendBinaryWithSelfupdate(tmpPath, targetPath string) err
 		}
 		return err
 	}
-
-	return nil
+	return syncDir(filepath.Dir(targetPath))
 }
 
-func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
+func (g *Guard) updateFrontend(ctx context.Context, mc ManagedComponent, u updateInfo) (retErr error) {
 	oldVersion := g.currentManagedVersion(mc.Slug)
 	if err := g.tryLockUpdate(mc.Slug, oldVersion, u.Latest); err != nil {
 		return err
@@ -319,19 +481,30 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 
 	g.logger.Info("starting frontend update", "component", mc.Slug, "version", u.Latest)
 
-	if !isStrictlyNewerVersion(oldVersion, u.Latest) {
+	if !g.acceptsVersion(mc.Slug, oldVersion, u.Latest) {
 		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, ErrUpdateDowngrade)
 		return ErrUpdateDowngrade
 	}
 
+	event := LifecycleEvent{Component: mc.Slug, OldVersion: oldVersion, NewVersion: u.Latest}
+	if err := g.runPreUpdateHook(ctx, mc, event); err != nil {
+		g.logger.Warn("update vetoed by pre-update hook", "component", mc.Slug, "error", err)
+		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, err)
+		return err
+	}
+
+	stats := newUpdateStats(mc.Slug, oldVersion, u.Latest)
+	startedAt := time.Now()
+	defer func() { g.finishUpdateStats(stats, startedAt, retErr) }()
+
 	if g.cfg.OTA.OnUpdateProgress != nil {
 		g.cfg.OTA.OnUpdateProgress(mc.Slug, "requesting", 0.0)
 	}
 
 	osValue, archValue := g.resolveOTAPlatform("", "")
-	downloadURL, expectedSHA256, signature, err := g.requestDownloadMeta(mc.Slug, u.Latest, osValue, archValue)
+	meta, err := g.requestDownloadMeta(ctx, mc.Slug, u.Latest, osValue, archValue)
 	if err != nil {
-		wrapped := fmt.Errorf("%w: %v", ErrUpdateDownload, err)
+		wrapped := fmt.Errorf("%w: %w", ErrUpdateDownload, err)
 		g.logger.Error("failed to request download", "component", mc.Slug, "error", err)
 		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 		return wrapped
@@ -341,33 +514,54 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 		g.cfg.OTA.OnUpdateProgress(mc.Slug, "downloading", 0.3)
 	}
 
-	archivePath, actualHash, err := g.downloadArtifactWithProgress(downloadURL, g.otaMaxArtifactBytes())
+	stagingParent, err := frontendStagingParent(mc)
+	if err != nil {
+		wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
+		g.logger.Error("failed to create release dir", "component", mc.Slug, "error", err)
+		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
+		return wrapped
+	}
+
+	downloadStart := time.Now()
+	archivePath, actualHash, err := g.fetchArtifact(ctx, meta.fetchRequest(mc.Slug, u.Latest, osValue, archValue), stagingParent, g.otaMaxArtifactBytes())
+	stats.DownloadDuration = time.Since(downloadStart)
 	if err != nil {
-		wrapped := fmt.Errorf("%w: %v", ErrUpdateDownload, err)
+		wrapped := fmt.Errorf("%w: %w", ErrUpdateDownload, err)
 		g.logger.Error("failed to download", "component", mc.Slug, "error", err)
 		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 		return wrapped
 	}
 	defer os.Remove(archivePath)
+	stats.Bytes = artifactSize(archivePath)
 
 	if g.cfg.OTA.OnUpdateProgress != nil {
 		g.cfg.OTA.OnUpdateProgress(mc.Slug, "verifying", 0.45)
 	}
 
-	if actualHash != expectedSHA256 {
+	verifyStart := time.Now()
+
+	if actualHash != meta.Digest {
 		wrapped := fmt.Errorf("%w: hash mismatch", ErrUpdateVerify)
-		g.logger.Error("hash mismatch", "component", mc.Slug, "expected", expectedSHA256, "actual", actualHash)
+		g.logger.Error("hash mismatch", "component", mc.Slug, "algorithm", meta.Algorithm, "expected", meta.Digest, "actual", actualHash)
 		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 		return wrapped
 	}
-	if err := g.verifySignature(expectedSHA256, signature); err != nil {
+	if err := g.verifySignature(signedDigest(meta.Algorithm, meta.Digest), meta.Signature); err != nil {
 		wrapped := fmt.Errorf("%w: %v", ErrUpdateVerify, err)
 		g.logger.Error("signature verification failed", "component", mc.Slug, "error", err)
 		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 		return wrapped
 	}
+	stats.VerifyDuration = time.Since(verifyStart)
+
+	// Applying is not interruptible, so honour cancellation before it starts.
+	if err := ctx.Err(); err != nil {
+		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, err)
+		return err
+	}
+	applyStart := time.Now()
 
-	tmpDir, err := os.MkdirTemp("", "deploy-guard-frontend-*")
+	tmpDir, err := createStagingDir(stagingParent, "frontend")
 	if err != nil {
 		wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
 		g.logger.Error("failed to create temp dir", "component", mc.Slug, "error", err)
@@ -398,6 +592,8 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 	}
 	defer gz.Close()
 
+	manifest := AssetManifest{Component: mc.Slug, Version: u.Latest, Assets: make(map[string]string)}
+	budget := newExtractionBudget(g.cfg.OTA.Extraction)
 	tr := tar.NewReader(gz)
 	for {
 		hdr, err := tr.Next()
@@ -418,6 +614,14 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 			g.logger.Warn("path traversal attempt detected", "component", mc.Slug, "path", hdr.Name)
 			continue
 		}
+		if rel, err := filepath.Rel(tmpDir, cleanedTarget); err == nil {
+			if err := budget.admit(hdr, filepath.ToSlash(rel)); err != nil {
+				wrapped := fmt.Errorf("%w: %w", ErrUpdateVerify, err)
+				g.logger.Error("archive exceeds extraction limits", "component", mc.Slug, "error", err)
+				g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
+				return wrapped
+			}
+		}
 
 		switch hdr.Typeflag {
 		case tar.TypeDir:
@@ -441,7 +645,8 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 				g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 				return wrapped
 			}
-			if _, err := io.Copy(f, tr); err != nil {
+			h := sha256.New()
+			if _, err := io.Copy(io.MultiWriter(f, h), tr); err != nil {
 				if closeErr := f.Close(); closeErr != nil {
 					g.logger.Warn("failed to close partial file after write error", "component", mc.Slug, "file", target, "error", closeErr)
 				}
@@ -450,39 +655,73 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 				g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 				return wrapped
 			}
+			if err := f.Sync(); err != nil {
+				_ = f.Close()
+				wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
+				g.logger.Error("failed to sync file", "component", mc.Slug, "file", target, "error", err)
+				g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
+				return wrapped
+			}
 			if err := f.Close(); err != nil {
 				wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
 				g.logger.Error("failed to close file", "component", mc.Slug, "file", target, "error", err)
 				g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 				return wrapped
 			}
+			if rel, err := filepath.Rel(tmpDir, cleanedTarget); err == nil {
+				manifest.Assets[filepath.ToSlash(rel)] = hex.EncodeToString(h.Sum(nil))
+			}
 		}
 	}
 
+	if err := syncDirTree(tmpDir); err != nil {
+		wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
+		g.logger.Error("failed to sync extracted release", "component", mc.Slug, "error", err)
+		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
+		return wrapped
+	}
+
 	if g.cfg.OTA.OnUpdateProgress != nil {
 		g.cfg.OTA.OnUpdateProgress(mc.Slug, "applying", 0.9)
 	}
 
-	// Atomic swap: old → .bak, new → target
-	backupDir := mc.Dir + ".bak"
-	os.RemoveAll(backupDir)
+	if mc.BlueGreen {
+		if err := g.activateFrontendRelease(mc, tmpDir, u.Latest); err != nil {
+			wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
+			g.logger.Error("failed to activate release", "component", mc.Slug, "error", err)
+			g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
+			return wrapped
+		}
+	} else {
+		// Atomic swap: old → .bak, new → target
+		backupDir := mc.Dir + ".bak"
+		os.RemoveAll(backupDir)
 
-	if _, err := os.Stat(mc.Dir); err == nil {
-		if err := os.Rename(mc.Dir, backupDir); err != nil {
+		if _, err := os.Stat(mc.Dir); err == nil {
+			if err := os.Rename(mc.Dir, backupDir); err != nil {
+				wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
+				g.logger.Error("failed to backup old dir", "component", mc.Slug, "error", err)
+				g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
+				return wrapped
+			}
+		}
+
+		if err := os.Rename(tmpDir, mc.Dir); err != nil {
 			wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
-			g.logger.Error("failed to backup old dir", "component", mc.Slug, "error", err)
+			g.logger.Error("failed to move new dir", "component", mc.Slug, "error", err)
+			if rollbackErr := os.Rename(backupDir, mc.Dir); rollbackErr == nil {
+				g.runRollbackHook(context.WithoutCancel(ctx), mc, event, wrapped)
+			}
 			g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
 			return wrapped
 		}
+		if err := syncDir(stagingParent); err != nil {
+			g.logger.Warn("failed to sync frontend parent dir", "component", mc.Slug, "error", err)
+		}
 	}
 
-	if err := os.Rename(tmpDir, mc.Dir); err != nil {
-		os.Rename(backupDir, mc.Dir) // rollback
-		wrapped := fmt.Errorf("%w: %v", ErrUpdateApply, err)
-		g.logger.Error("failed to move new dir", "component", mc.Slug, "error", err)
-		g.notifyUpdateFailure(mc.Slug, oldVersion, u.Latest, wrapped)
-		return wrapped
-	}
+	g.invalidateCaches(context.WithoutCancel(ctx), mc, oldVersion, manifest)
+	stats.ApplyDuration = time.Since(applyStart)
 
 	// Update version under lock
 	g.mu.Lock()
@@ -494,11 +733,14 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 	if g.cfg.OTA.OnUpdateResult != nil {
 		g.cfg.OTA.OnUpdateResult(mc.Slug, oldVersion, u.Latest, true, nil)
 	}
+	g.notifyUpdate(UpdateNotification{Kind: UpdateNotificationInstalled, Component: mc.Slug, OldVersion: oldVersion, NewVersion: u.Latest})
 
 	if g.cfg.OTA.OnUpdateProgress != nil {
 		g.cfg.OTA.OnUpdateProgress(mc.Slug, "completed", 1.0)
 	}
 
+	g.runPostInstallHook(context.WithoutCancel(ctx), mc, event)
+
 	// Post-update hook
 	if mc.PostUpdate != nil {
 		if err := mc.PostUpdate(); err != nil {
@@ -509,15 +751,6 @@ func (g *Guard) updateFrontend(mc ManagedComponent, u updateInfo) error {
 	return nil
 }
 
-func isStrictlyNewerVersion(current, target string) bool {
-	currentVersion, currentErr := semver.NewVersion(strings.TrimSpace(strings.TrimPrefix(current, "v")))
-	targetVersion, targetErr := semver.NewVersion(strings.TrimSpace(strings.TrimPrefix(target, "v")))
-	if currentErr != nil || targetErr != nil {
-		return target != "" && target != current
-	}
-	return targetVersion.GreaterThan(currentVersion)
-}
-
 func (g *Guard) tryLockUpdate(component, oldVersion, newVersion string) error {
 	if g.updateMu.TryLock() {
 		return nil
@@ -528,15 +761,24 @@ func (g *Guard) tryLockUpdate(component, oldVersion, newVersion string) error {
 }
 
 func (g *Guard) notifyUpdateFailure(component, oldVersion, newVersion string, err error) {
+	g.reportUpdateFailure(component, err)
 	if g.cfg.OTA.OnUpdateFailure != nil {
 		g.cfg.OTA.OnUpdateFailure(component, err)
 	}
 	if g.cfg.OTA.OnUpdateResult != nil {
 		g.cfg.OTA.OnUpdateResult(component, oldVersion, newVersion, false, err)
 	}
+	// A concurrent attempt is not a user-visible failure; the running update
+	// will report its own outcome.
+	if !errors.Is(err, ErrUpdateConcurrent) {
+		g.notifyUpdate(UpdateNotification{Kind: UpdateNotificationFailed, Component: component, OldVersion: oldVersion, NewVersion: newVersion, Err: err})
+	}
 }
 
 func (g *Guard) otaDownloadTimeout() time.Duration {
+	if g.cfg.Timeouts.Download > 0 {
+		return g.cfg.Timeouts.Download
+	}
 	if g.cfg.OTA.DownloadTimeout > 0 {
 		return g.cfg.OTA.DownloadTimeout
 	}
@@ -1,9 +1,11 @@
 package sdk
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
@@ -32,7 +34,7 @@ func TestHandleUpdateNotification_NoUpdate(t *testing.T) {
 	}
 
 	// Should not crash even if component doesn't match
-	g.handleUpdateNotification(u)
+	g.handleUpdateNotification(context.Background(), u)
 }
 
 // TestUpdateBackend_RequestDownloadFailure tests updateBackend when request fails
@@ -76,11 +78,11 @@ func TestUpdateBackend_RequestDownloadFailure(t *testing.T) {
 			machineID: "test-machine",
 		},
 		httpClient: &http.Client{Timeout: 30 * time.Second},
-		version:    "1.0.0",
 		updateMu:   sync.Mutex{},
 		mu:         sync.RWMutex{},
 		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
+	g.SetVersion("1.0.0")
 
 	u := updateInfo{
 		Component:       "backend",
@@ -88,7 +90,7 @@ func TestUpdateBackend_RequestDownloadFailure(t *testing.T) {
 		UpdateAvailable: true,
 	}
 
-	g.updateBackend(u)
+	g.updateBackend(context.Background(), u)
 
 	if !progressCalled {
 		t.Error("expected OnUpdateProgress to be called")
@@ -159,7 +161,7 @@ func TestUpdateFrontend_Success(t *testing.T) {
 		Dir:  tempDir,
 	}
 
-	if err := g.updateFrontend(mc, u); err != nil {
+	if err := g.updateFrontend(context.Background(), mc, u); err != nil {
 		t.Fatalf("updateFrontend failed: %v", err)
 	}
 }
@@ -206,7 +208,7 @@ func TestUpdateFrontend_NetworkError(t *testing.T) {
 		Dir:  tempDir,
 	}
 
-	_ = g.updateFrontend(mc, u)
+	_ = g.updateFrontend(context.Background(), mc, u)
 
 	if !failureCalled {
 		t.Error("expected OnUpdateFailure to be called")
@@ -235,7 +237,7 @@ func TestHandleUpdateNotification_ManagedComponentBackend(t *testing.T) {
 	}
 
 	// Should not crash
-	g.handleUpdateNotification(u)
+	g.handleUpdateNotification(context.Background(), u)
 }
 
 // TestApplyBackendBinaryWithSelfupdate_FileNotFound tests error when temp file not found
@@ -252,3 +254,26 @@ func TestApplyBackendBinaryWithSelfupdate_FileNotFoundExtended(t *testing.T) {
 		t.Error("expected error for non-existent file")
 	}
 }
+
+func TestAutoUpdateAbortsWhenContextIsCancelled(t *testing.T) {
+	g, _ := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	failures := make(chan error, 1)
+	g.cfg.OTA.Enabled = true
+	g.cfg.OTA.AutoUpdate = true
+	g.cfg.OTA.OnUpdateFailure = func(component string, err error) { failures <- err }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.handleUpdateNotification(ctx, updateInfo{Component: g.cfg.ComponentSlug, Current: "1.0.0", Latest: "2.0.0", UpdateAvailable: true})
+	cancel()
+
+	select {
+	case err := <-failures:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the cancellation to surface, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("background update ignored the cancelled context")
+	}
+}
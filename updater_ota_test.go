@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
@@ -97,7 +98,7 @@ func TestUpdateFrontend_SuccessFullCoverage(t *testing.T) {
 	u := updateInfo{Component: "frontend", Latest: "2.0.0", UpdateAvailable: true}
 	mc := ManagedComponent{Slug: "frontend", Dir: targetDir}
 
-	if err := g.updateFrontend(mc, u); err != nil {
+	if err := g.updateFrontend(context.Background(), mc, u); err != nil {
 		t.Fatalf("updateFrontend failed: %v", err)
 	}
 
@@ -183,7 +184,7 @@ func TestUpdateBackend_SignatureFailurePath(t *testing.T) {
 	}
 
 	u := updateInfo{Component: "backend", Latest: "2.0.0", UpdateAvailable: true}
-	g.updateBackend(u)
+	g.updateBackend(context.Background(), u)
 
 	if !progressCalled {
 		t.Error("expected progress callback")
@@ -253,7 +254,7 @@ func TestUpdateFrontend_HashMismatch(t *testing.T) {
 	}
 
 	mc := ManagedComponent{Slug: "frontend", Dir: tempDir}
-	g.updateFrontend(mc, updateInfo{Component: "frontend", Latest: "2.0.0"})
+	g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"})
 
 	if !failureCalled {
 		t.Error("expected failure callback on hash mismatch")
@@ -311,7 +312,7 @@ func TestUpdateFrontend_RejectsOversizeArchive(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
-	err := g.updateFrontend(ManagedComponent{Slug: "frontend", Dir: targetDir}, updateInfo{
+	err := g.updateFrontend(context.Background(), ManagedComponent{Slug: "frontend", Dir: targetDir}, updateInfo{
 		Component:       "frontend",
 		Latest:          "2.0.0",
 		UpdateAvailable: true,
@@ -402,7 +403,7 @@ func TestUpdateFrontend_FailsOnConflictingDirectoryEntry(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
-	err := g.updateFrontend(ManagedComponent{Slug: "frontend", Dir: targetDir}, updateInfo{
+	err := g.updateFrontend(context.Background(), ManagedComponent{Slug: "frontend", Dir: targetDir}, updateInfo{
 		Component:       "frontend",
 		Latest:          "2.0.0",
 		UpdateAvailable: true,
@@ -493,7 +494,7 @@ func TestUpdateFrontend_DuplicateFileEntryTruncatesPreviousContent(t *testing.T)
 		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
-	if err := g.updateFrontend(ManagedComponent{Slug: "frontend", Dir: targetDir}, updateInfo{
+	if err := g.updateFrontend(context.Background(), ManagedComponent{Slug: "frontend", Dir: targetDir}, updateInfo{
 		Component:       "frontend",
 		Latest:          "2.0.0",
 		UpdateAvailable: true,
@@ -561,7 +562,7 @@ func TestUpdateFrontend_PathTraversalBlocked(t *testing.T) {
 	}
 
 	mc := ManagedComponent{Slug: "frontend", Dir: tempDir}
-	if err := g.updateFrontend(mc, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
+	if err := g.updateFrontend(context.Background(), mc, updateInfo{Component: "frontend", Latest: "2.0.0"}); err != nil {
 		t.Fatalf("updateFrontend failed: %v", err)
 	}
 
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
@@ -11,6 +12,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -140,10 +143,11 @@ func TestUpdateBackend_Success(t *testing.T) {
 			machineID: "test-machine",
 		},
 		httpClient: &http.Client{Timeout: 30 * time.Second},
-		version:    "1.0.0",
 	}
+	g.SetVersion("1.0.0")
 
-	downloadURL, sha256Hash, signatureStr, err := g.requestDownloadMeta("backend", "2.0.0", g.cfg.OTA.OS, g.cfg.OTA.Arch)
+	meta, err := g.requestDownloadMeta(context.Background(), "backend", "2.0.0", g.cfg.OTA.OS, g.cfg.OTA.Arch)
+	downloadURL, sha256Hash, signatureStr := meta.URL, meta.Digest, meta.Signature
 	if err != nil {
 		t.Fatalf("requestDownloadMeta failed: %v", err)
 	}
@@ -201,14 +205,15 @@ func TestUpdateBackend_HashMismatch(t *testing.T) {
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
-	url, expectedHash, _, err := g.requestDownloadMeta("backend", "2.0.0", g.cfg.OTA.OS, g.cfg.OTA.Arch)
+	meta, err := g.requestDownloadMeta(context.Background(), "backend", "2.0.0", g.cfg.OTA.OS, g.cfg.OTA.Arch)
+	url, expectedHash := meta.URL, meta.Digest
 	if err != nil {
 		t.Fatalf("requestDownloadMeta failed: %v", err)
 	}
 
-	tmpPath, actualHash, err := g.downloadArtifactWithProgress(url, g.cfg.OTA.MaxArtifactBytes)
+	tmpPath, actualHash, err := g.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: url}, "", g.cfg.OTA.MaxArtifactBytes)
 	if err != nil {
-		t.Fatalf("downloadArtifactWithProgress failed: %v", err)
+		t.Fatalf("fetchArtifactFromSource failed: %v", err)
 	}
 	defer os.Remove(tmpPath)
 
@@ -237,7 +242,7 @@ func TestDownloadArtifactWithProgress_NetworkError(t *testing.T) {
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
-	_, _, err := g.downloadArtifactWithProgress("/download/test.bin", g.cfg.OTA.MaxArtifactBytes)
+	_, _, err := g.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: "/download/test.bin"}, "", g.cfg.OTA.MaxArtifactBytes)
 	if err == nil {
 		t.Error("expected error for non-200 status code")
 	}
@@ -268,9 +273,9 @@ func TestDownloadArtifactWithProgress_AbsoluteURL(t *testing.T) {
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
-	tmpPath, actualHash, err := g.downloadArtifactWithProgress(server.URL+"/download/absolute.bin", g.cfg.OTA.MaxArtifactBytes)
+	tmpPath, actualHash, err := g.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: server.URL + "/download/absolute.bin"}, "", g.cfg.OTA.MaxArtifactBytes)
 	if err != nil {
-		t.Fatalf("downloadArtifactWithProgress failed: %v", err)
+		t.Fatalf("fetchArtifactFromSource failed: %v", err)
 	}
 	defer os.Remove(tmpPath)
 
@@ -304,7 +309,7 @@ func TestDownloadArtifactWithProgress_ExceedsMaxBytes(t *testing.T) {
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
-	tmpPath, _, err := g.downloadArtifactWithProgress("/download/test.bin", g.cfg.OTA.MaxArtifactBytes)
+	tmpPath, _, err := g.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: "/download/test.bin"}, "", g.cfg.OTA.MaxArtifactBytes)
 	if err == nil {
 		defer os.Remove(tmpPath)
 		t.Fatal("expected oversized artifact error")
@@ -390,7 +395,7 @@ func TestUpdateBackend_ConcurrentUpdate(t *testing.T) {
 	}
 
 	g.updateMu.Lock()
-	err = g.updateFrontend(ManagedComponent{Slug: "frontend", Dir: t.TempDir()}, updateInfo{
+	err = g.updateFrontend(context.Background(), ManagedComponent{Slug: "frontend", Dir: t.TempDir()}, updateInfo{
 		Component:       "frontend",
 		Latest:          "2.0.0",
 		UpdateAvailable: true,
@@ -480,7 +485,8 @@ func TestUpdateFrontend_TarGzExtraction(t *testing.T) {
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
-	url, _, gotSignature, err := g.requestDownloadMeta("frontend", "2.0.0", "universal", "universal")
+	meta, err := g.requestDownloadMeta(context.Background(), "frontend", "2.0.0", "universal", "universal")
+	url, gotSignature := meta.URL, meta.Signature
 	if err != nil {
 		t.Fatalf("requestDownloadMeta failed: %v", err)
 	}
@@ -488,9 +494,9 @@ func TestUpdateFrontend_TarGzExtraction(t *testing.T) {
 		t.Fatalf("expected signature %s, got %s", signature, gotSignature)
 	}
 
-	tmpPath, actualHash, err := g.downloadArtifactWithProgress(url, g.cfg.OTA.MaxArtifactBytes)
+	tmpPath, actualHash, err := g.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: url}, "", g.cfg.OTA.MaxArtifactBytes)
 	if err != nil {
-		t.Fatalf("downloadArtifactWithProgress failed: %v", err)
+		t.Fatalf("fetchArtifactFromSource failed: %v", err)
 	}
 	defer os.Remove(tmpPath)
 
@@ -526,12 +532,62 @@ func TestRequestDownloadMeta_ServerError(t *testing.T) {
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
-	_, _, _, err := g.requestDownloadMeta("backend", "2.0.0", "linux", "amd64")
+	_, err := g.requestDownloadMeta(context.Background(), "backend", "2.0.0", "linux", "amd64")
 	if err == nil {
 		t.Error("expected error for server error response")
 	}
 }
 
+func TestRequestDownloadMeta_StrictMetadataSignature(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	hash := strings.Repeat("a", 64)
+	sign := func(payload downloadMetaSignaturePayload) string {
+		raw, _ := json.Marshal(payload)
+		canonical, err := canonicalJSON(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest := sha256.Sum256(canonical)
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(privKey, digest[:]))
+	}
+	signed := downloadMetaSignaturePayload{Component: "backend", Version: "2.0.0", OS: "linux", Arch: "amd64", DownloadURL: "/download/backend", SHA256: hash}
+
+	for _, tt := range []struct {
+		name      string
+		url       string
+		signature string
+		strict    bool
+		wantErr   bool
+	}{
+		{"unsigned allowed without strict mode", "/download/backend", "", false, false},
+		{"unsigned rejected in strict mode", "/download/backend", "", true, true},
+		{"signed accepted", "/download/backend", sign(signed), true, false},
+		{"redirected url rejected", "https://evil.example/backend", sign(signed), false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"download_url":       tt.url,
+					"sha256":             hash,
+					"signature":          "sig",
+					"metadata_signature": tt.signature,
+				})
+			}))
+			defer server.Close()
+
+			g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+			g.cfg.OTA.RequireSignedMetadata = tt.strict
+			_, err := g.requestDownloadMeta(context.Background(), "backend", "2.0.0", "linux", "amd64")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("wantErr=%v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrUpdateVerify) {
+				t.Fatalf("expected ErrUpdateVerify, got %v", err)
+			}
+		})
+	}
+}
+
 func TestUpdateCallbacks(t *testing.T) {
 	progressCalled := false
 	resultCalled := false
@@ -610,12 +666,53 @@ func TestDownloadArtifactWithProgress_ContextTimeout(t *testing.T) {
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
-	_, _, err := g.downloadArtifactWithProgress("/download/test.bin", g.cfg.OTA.MaxArtifactBytes)
+	_, _, err := g.fetchArtifactFromSource(context.Background(), FetchRequest{DownloadURL: "/download/test.bin"}, "", g.cfg.OTA.MaxArtifactBytes)
 	if err == nil {
 		t.Error("expected error for timeout")
 	}
 }
 
+func TestUpdateFrontend_HonoursCallerDeadline(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/update/download" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"download_url": "/download/slow.zip", "sha256": "x", "signature": "y"})
+			return
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	g := &Guard{
+		cfg: Config{
+			ServerURL: server.URL,
+			OTA:       OTAConfig{DownloadTimeout: time.Minute, MaxArtifactBytes: 1024 * 1024},
+		},
+		publicKey:       pubKey,
+		httpClient:      server.Client(),
+		fingerprint:     &Fingerprint{machineID: "machine-1"},
+		managedVersions: map[string]string{"frontend": "1.0.0"},
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err := g.updateFrontend(ctx, ManagedComponent{Slug: "frontend", Dir: t.TempDir()}, updateInfo{Component: "frontend", Latest: "2.0.0"})
+	if !errors.Is(err, ErrUpdateDownload) {
+		t.Fatalf("expected ErrUpdateDownload, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("update outlived the caller deadline by %v", elapsed)
+	}
+}
+
 func TestVerifySignature_EdgeCases(t *testing.T) {
 	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
 
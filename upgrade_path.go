@@ -0,0 +1,64 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// upgradeSteps returns the versions to install, in order, to take a
+// component from installed to u.Latest: the versions of u.UpgradePath newer
+// than the one before them, then u.Latest unless the path already ends there.
+func upgradeSteps(installed string, u updateInfo) []string {
+	var steps []string
+	last := installed
+	for _, version := range u.UpgradePath {
+		version = strings.TrimSpace(version)
+		if version == "" || !IsNewer(last, version) {
+			continue
+		}
+		steps = append(steps, version)
+		last = version
+	}
+	if u.Latest != "" && IsNewer(last, u.Latest) {
+		steps = append(steps, u.Latest)
+	}
+	return steps
+}
+
+// runUpgradePath installs u through its upgrade path, one full update per
+// step, so every intermediate version is downloaded, verified, migrated and
+// applied before the next. The first failing step stops the path; the
+// component stays at the last version that installed. An intermediate
+// version on the ignore list stops the path before it starts, since skipping
+// a required step is what the path exists to prevent.
+func (g *Guard) runUpgradePath(u updateInfo, installed func() string, install func(step updateInfo) error) error {
+	steps := upgradeSteps(installed(), u)
+	if len(steps) == 0 {
+		// Nothing newer: let the updater reject it as a downgrade.
+		return install(u)
+	}
+	for _, version := range steps[:len(steps)-1] {
+		if err := g.checkVersionPolicy(u.Component, version); errors.Is(err, ErrPluginVersionIgnored) {
+			g.logger.Warn("upgrade path blocked by ignored version", "component", u.Component, "version", version)
+			return fmt.Errorf("upgrade path to %s: %w", u.Latest, err)
+		}
+	}
+
+	for i, version := range steps {
+		step := u
+		step.Current = installed()
+		step.Latest = version
+		step.UpgradePath = nil
+		if len(steps) > 1 {
+			g.logger.Info("upgrade path step", "component", u.Component, "step", i+1, "steps", len(steps), "version", version)
+		}
+		if err := install(step); err != nil {
+			if i < len(steps)-1 {
+				g.logger.Error("upgrade path stopped", "component", u.Component, "failed_version", version, "target", u.Latest, "error", err)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package sdk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUpgradeStepsOrdersPathAndEndsAtLatest(t *testing.T) {
+	cases := []struct {
+		installed string
+		path      []string
+		latest    string
+		want      []string
+	}{
+		{"1.0.0", nil, "2.0.0", []string{"2.0.0"}},
+		{"1.0.0", []string{"1.0.0", "1.2.0", "1.1.0", "2.0.0"}, "2.1.0", []string{"1.2.0", "2.0.0", "2.1.0"}},
+		{"1.3.0", []string{"1.2.0", "2.0.0", "2.1.0"}, "2.1.0", []string{"2.0.0", "2.1.0"}},
+		{"2.1.0", []string{"2.0.0"}, "2.1.0", nil},
+	}
+	for _, tc := range cases {
+		got := upgradeSteps(tc.installed, updateInfo{Latest: tc.latest, UpgradePath: tc.path})
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("upgradeSteps(%s, %v, %s) = %v, want %v", tc.installed, tc.path, tc.latest, got, tc.want)
+		}
+	}
+}
+
+func TestRunUpgradePathInstallsStepsInOrderAndStopsOnFailure(t *testing.T) {
+	guard, err := NewForTesting(Config{OTA: OTAConfig{IgnoredVersions: map[string][]string{"api": {"1.5.0"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installed := "1.0.0"
+	var steps []updateInfo
+	install := func(step updateInfo) error {
+		steps = append(steps, step)
+		if step.Latest == "3.0.0" {
+			return ErrUpdateMigrate
+		}
+		installed = step.Latest
+		return nil
+	}
+	u := updateInfo{Component: "api", Latest: "4.0.0", UpgradePath: []string{"2.0.0", "3.0.0"}}
+	err = guard.runUpgradePath(u, func() string { return installed }, install)
+	if !errors.Is(err, ErrUpdateMigrate) {
+		t.Fatalf("err = %v, want the failing step's error", err)
+	}
+	if len(steps) != 2 || steps[0].Latest != "2.0.0" || steps[1].Latest != "3.0.0" || steps[1].Current != "2.0.0" {
+		t.Fatalf("steps = %+v, want 2.0.0 then 3.0.0 from 2.0.0", steps)
+	}
+	if installed != "2.0.0" {
+		t.Fatalf("installed = %s, want the last step that succeeded", installed)
+	}
+
+	steps = nil
+	u = updateInfo{Component: "api", Latest: "2.5.0", UpgradePath: []string{"1.5.0"}}
+	installed = "1.0.0"
+	if err := guard.runUpgradePath(u, func() string { return installed }, install); !errors.Is(err, ErrPluginVersionIgnored) {
+		t.Fatalf("err = %v, want ErrPluginVersionIgnored for an ignored required step", err)
+	}
+	if len(steps) != 0 {
+		t.Fatalf("steps = %+v, an ignored step must block the whole path", steps)
+	}
+}
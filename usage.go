@@ -0,0 +1,168 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// usageFileName is the cache entry keeping unreported usage across restarts.
+const usageFileName = "usage.json"
+
+// usageReport is the usage of one feature since it was last reported.
+type usageReport struct {
+	Feature  string `json:"feature"`
+	Quantity int64  `json:"quantity"`
+	Events   int64  `json:"events"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// usageMeter aggregates RecordUsage calls per feature until a heartbeat
+// delivers them, so metering a hot path costs a map update, not a request.
+type usageMeter struct {
+	mu      sync.Mutex
+	pending map[string]*usageReport
+}
+
+func (m *usageMeter) record(feature string, qty int64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stamp := now.UTC().Format(time.RFC3339)
+	if report, ok := m.pending[feature]; ok {
+		report.Quantity += qty
+		report.Events++
+		report.To = stamp
+		return
+	}
+	if m.pending == nil {
+		m.pending = make(map[string]*usageReport)
+	}
+	m.pending[feature] = &usageReport{Feature: feature, Quantity: qty, Events: 1, From: stamp, To: stamp}
+}
+
+// snapshot returns the pending usage sorted by feature.
+func (m *usageMeter) snapshot() []usageReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) == 0 {
+		return nil
+	}
+	reports := make([]usageReport, 0, len(m.pending))
+	for _, report := range m.pending {
+		reports = append(reports, *report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Feature < reports[j].Feature })
+	return reports
+}
+
+// drop removes what the server accepted. Usage recorded after the snapshot
+// stays queued and is reported from the end of the sent period.
+func (m *usageMeter) drop(sent []usageReport) {
+	if len(sent) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range sent {
+		report, ok := m.pending[s.Feature]
+		if !ok {
+			continue
+		}
+		report.Quantity -= s.Quantity
+		report.Events -= s.Events
+		if report.Events <= 0 {
+			delete(m.pending, s.Feature)
+			continue
+		}
+		report.From = s.To
+	}
+}
+
+// restore merges usage saved by an earlier run.
+func (m *usageMeter) restore(reports []usageReport) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range reports {
+		if r.Feature == "" || r.Quantity <= 0 || r.Events <= 0 {
+			continue
+		}
+		if m.pending == nil {
+			m.pending = make(map[string]*usageReport)
+		}
+		report := r
+		m.pending[r.Feature] = &report
+	}
+}
+
+// RecordUsage adds qty units of a metered feature, such as API calls, seats
+// or bytes processed, to the usage reported with the next heartbeat. Usage
+// is aggregated per feature in memory and saved to the cache when the guard
+// stops, so a clean restart does not lose it. It is only accepted while the
+// guard grants the feature: otherwise RecordUsage returns the Check error or
+// ErrFeatureNotEntitled and records nothing.
+func (g *Guard) RecordUsage(feature string, qty int64) error {
+	if err := g.requireState(); err != nil {
+		return err
+	}
+	feature = strings.TrimSpace(feature)
+	if feature == "" {
+		return fmt.Errorf("record usage: feature is required")
+	}
+	if qty <= 0 {
+		return fmt.Errorf("record usage %s: quantity must be positive, got %d", feature, qty)
+	}
+	if err := g.Check(); err != nil {
+		return err
+	}
+	if status := g.CheckFeatureMatrix(feature)[feature]; !status.Enabled {
+		return fmt.Errorf("%w: %s (%s)", ErrFeatureNotEntitled, feature, status.Reason)
+	}
+	g.usage.record(feature, qty, time.Now())
+	return nil
+}
+
+// PendingUsage returns the recorded quantity per feature that has not been
+// reported to the server yet.
+func (g *Guard) PendingUsage() map[string]int64 {
+	reports := g.usage.snapshot()
+	usage := make(map[string]int64, len(reports))
+	for _, r := range reports {
+		usage[r.Feature] = r.Quantity
+	}
+	return usage
+}
+
+// saveUsage persists the unreported usage, or removes the entry when
+// everything was reported.
+func (g *Guard) saveUsage() {
+	store := cacheStoreFor(g.cfg)
+	reports := g.usage.snapshot()
+	var err error
+	if len(reports) == 0 {
+		err = store.Delete(usageFileName)
+	} else {
+		data, _ := json.Marshal(reports)
+		err = g.sealedEntries().SaveEntry(usageFileName, data)
+	}
+	if err != nil {
+		g.logger.Warn("save usage failed", "error", err)
+	}
+}
+
+// loadUsage restores usage saved by an earlier run; a missing or unreadable
+// entry starts empty.
+func (g *Guard) loadUsage() {
+	data, err := g.sealedEntries().LoadEntry(usageFileName)
+	if err != nil {
+		return
+	}
+	var reports []usageReport
+	if json.Unmarshal(data, &reports) != nil {
+		return
+	}
+	g.usage.restore(reports)
+}
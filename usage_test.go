@@ -0,0 +1,97 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUsageMeterAggregatesAndKeepsUsageRecordedAfterSnapshot(t *testing.T) {
+	var m usageMeter
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	m.record("api_calls", 2, start)
+	m.record("api_calls", 3, start.Add(time.Minute))
+	m.record("gb_processed", 7, start.Add(2*time.Minute))
+
+	sent := m.snapshot()
+	if len(sent) != 2 || sent[0].Feature != "api_calls" || sent[0].Quantity != 5 || sent[0].Events != 2 {
+		t.Fatalf("snapshot = %+v", sent)
+	}
+	if sent[0].From != "2026-03-01T10:00:00Z" || sent[0].To != "2026-03-01T10:01:00Z" {
+		t.Fatalf("api_calls period = %s..%s", sent[0].From, sent[0].To)
+	}
+
+	m.record("api_calls", 4, start.Add(3*time.Minute))
+	m.drop(sent)
+	left := m.snapshot()
+	if len(left) != 1 || left[0].Feature != "api_calls" || left[0].Quantity != 4 || left[0].Events != 1 {
+		t.Fatalf("after drop = %+v", left)
+	}
+	if left[0].From != sent[0].To {
+		t.Fatalf("remaining usage starts at %s, want %s", left[0].From, sent[0].To)
+	}
+}
+
+func TestRecordUsageRequiresEntitledFeature(t *testing.T) {
+	guard, err := NewForTesting(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.RecordUsage("api_calls", 1); !errors.Is(err, ErrNotActivated) {
+		t.Fatalf("usage before activation: err = %v, want ErrNotActivated", err)
+	}
+
+	guard.mu.Lock()
+	guard.entitledFeatures = map[string]bool{"api_calls": true}
+	guard.mu.Unlock()
+	guard.sm.OnVerifySuccess()
+	if err := guard.RecordUsage("exports", 1); !errors.Is(err, ErrFeatureNotEntitled) {
+		t.Fatalf("unentitled usage: err = %v, want ErrFeatureNotEntitled", err)
+	}
+	if err := guard.RecordUsage("api_calls", 0); err == nil {
+		t.Fatal("zero quantity should be rejected")
+	}
+	if err := guard.RecordUsage("api_calls", 10); err != nil {
+		t.Fatal(err)
+	}
+	if got := guard.PendingUsage(); len(got) != 1 || got["api_calls"] != 10 {
+		t.Fatalf("pending usage = %v", got)
+	}
+}
+
+func TestUsageSurvivesRestartThroughCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore()
+	first, err := NewForTesting(Config{CacheStore: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.usage.record("seats", 3, time.Now())
+	first.saveUsage()
+
+	second, err := NewForTesting(Config{CacheStore: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.loadUsage()
+	if got := second.PendingUsage(); got["seats"] != 3 {
+		t.Fatalf("restored usage = %v, want seats=3", got)
+	}
+
+	if err := store.Save(usageFileName, []byte(`[{"feature":"seats","quantity":0}]`)); err != nil {
+		t.Fatal(err)
+	}
+	third, err := NewForTesting(Config{CacheStore: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	third.loadUsage()
+	if got := third.PendingUsage(); len(got) != 0 {
+		t.Fatalf("usage from an edited entry = %v, want it discarded", got)
+	}
+
+	second.usage.drop(second.usage.snapshot())
+	second.saveUsage()
+	if _, err := store.Load(usageFileName); err == nil {
+		t.Fatal("usage entry should be removed once everything is reported")
+	}
+}
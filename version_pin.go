@@ -0,0 +1,108 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// versionPinsFileName is the cache entry keeping the versions pinned by
+// InstallVersion.
+const versionPinsFileName = "version_pins.json"
+
+// InstallVersion installs a specific, possibly older, version of component
+// through the normal download, verify and apply pipeline, then pins the
+// component to it so auto-update does not move it again. The pin is kept in
+// the cache, survives restarts and takes precedence over
+// OTAConfig.PinnedVersions until UnpinVersion clears it. A failed install
+// leaves the previous pin in place.
+func (g *Guard) InstallVersion(ctx context.Context, component, version string) error {
+	if err := g.requireState(); err != nil {
+		return err
+	}
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return fmt.Errorf("install %s: version is required", component)
+	}
+
+	var install func(u updateInfo) error
+	installed := g.currentManagedVersion(component)
+	if component == g.cfg.ComponentSlug {
+		installed = g.currentVersion()
+		install = func(u updateInfo) error { return g.updateBackend(ctx, u) }
+	} else if mc, ok := g.findManagedComponent(component); ok {
+		install = func(u updateInfo) error { return g.updateFrontend(ctx, mc, u) }
+		if mc.Strategy == UpdateBackend {
+			install = func(u updateInfo) error { return g.updateManagedBackend(ctx, mc, u) }
+		}
+	} else {
+		return fmt.Errorf("%w: %s", ErrComponentNotFound, component)
+	}
+	for _, ignored := range g.cfg.OTA.IgnoredVersions[component] {
+		if SameVersion(ignored, version) {
+			return fmt.Errorf("%w: %s %s", ErrPluginVersionIgnored, component, version)
+		}
+	}
+
+	prev, hadPin := g.versionPin(component)
+	g.setVersionPin(component, version)
+	if SameVersion(installed, version) {
+		g.logger.Info("component pinned to installed version", "component", component, "version", version)
+		return nil
+	}
+	g.logger.Warn("installing pinned version", "component", component, "old_version", installed, "new_version", version)
+	if err := install(updateInfo{Component: component, Current: installed, Latest: version}); err != nil {
+		if hadPin {
+			g.setVersionPin(component, prev)
+		} else {
+			g.setVersionPin(component, "")
+		}
+		return err
+	}
+	return nil
+}
+
+// UnpinVersion clears the pin InstallVersion set on component, so the next
+// update the server offers is installed as usual.
+func (g *Guard) UnpinVersion(component string) {
+	g.setVersionPin(component, "")
+}
+
+func (g *Guard) loadVersionPins() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.versionPins != nil {
+		return
+	}
+	g.versionPins = make(map[string]string)
+	if data, err := g.sealedEntries().LoadEntry(versionPinsFileName); err == nil {
+		_ = json.Unmarshal(data, &g.versionPins)
+	}
+}
+
+func (g *Guard) versionPin(component string) (string, bool) {
+	g.loadVersionPins()
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	version, ok := g.versionPins[component]
+	return version, ok
+}
+
+// setVersionPin pins component to version, or clears its pin when version
+// is empty, and persists the result.
+func (g *Guard) setVersionPin(component, version string) {
+	g.loadVersionPins()
+	g.mu.Lock()
+	if version == "" {
+		delete(g.versionPins, component)
+	} else {
+		g.versionPins[component] = version
+	}
+	data, _ := json.Marshal(g.versionPins)
+	g.mu.Unlock()
+
+	if err := g.sealedEntries().SaveEntry(versionPinsFileName, data); err != nil {
+		g.logger.Warn("save version pins failed", "component", component, "error", err)
+	}
+}
@@ -0,0 +1,49 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallVersionDowngradesAndPinsComponent(t *testing.T) {
+	server, pubKey := newBinaryUpdateServer(t, []byte("known good binary"))
+	g := newLifecycleTestGuard(t, server.URL, pubKey, "1.0.0")
+	g.sm = newStateMachine()
+	g.cfg.CacheStore = NewMemoryCacheStore()
+	g.managedVersions["worker"] = "2.0.0"
+
+	target := filepath.Join(t.TempDir(), "worker")
+	if err := os.WriteFile(target, []byte("bad binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	g.cfg.ManagedComponents = []ManagedComponent{{Slug: "worker", Dir: target, Strategy: UpdateBackend}}
+
+	if err := g.InstallVersion(context.Background(), "worker", "1.5.0"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "known good binary" {
+		t.Fatalf("target = %q, want the requested version", data)
+	}
+	if v := g.currentManagedVersion("worker"); v != "1.5.0" {
+		t.Fatalf("version = %s, want 1.5.0", v)
+	}
+	if err := g.checkVersionPolicy("worker", "2.0.0"); !errors.Is(err, ErrPluginVersionPinned) {
+		t.Fatalf("policy for 2.0.0 = %v, want ErrPluginVersionPinned", err)
+	}
+
+	g.versionPins = nil // reload from the cache, as after a restart
+	if pinned, ok := g.versionPin("worker"); !ok || pinned != "1.5.0" {
+		t.Fatalf("pin after reload = %q, %v", pinned, ok)
+	}
+	g.UnpinVersion("worker")
+	if err := g.checkVersionPolicy("worker", "2.0.0"); err != nil {
+		t.Fatalf("policy after unpin = %v", err)
+	}
+
+	if err := g.InstallVersion(context.Background(), "missing", "1.0.0"); !errors.Is(err, ErrComponentNotFound) {
+		t.Fatalf("unknown component: err = %v, want ErrComponentNotFound", err)
+	}
+}
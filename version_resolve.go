@@ -0,0 +1,68 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VersionSource records how the version reported in heartbeats was
+// determined.
+type VersionSource string
+
+const (
+	// VersionSourceManual means the version was set with SetVersion.
+	VersionSourceManual VersionSource = "manual"
+	// VersionSourceLdflags means the Version variable injected at build time.
+	VersionSourceLdflags VersionSource = "ldflags"
+	// VersionSourceBinaryHash means the central server recognized the
+	// executable's hash.
+	VersionSourceBinaryHash VersionSource = "binary_hash"
+	// VersionSourceManifest means the local version manifest.
+	VersionSourceManifest VersionSource = "manifest"
+	// VersionSourceOTA means the version installed by the last OTA update.
+	VersionSourceOTA VersionSource = "ota"
+)
+
+const defaultVersionManifestName = "version.json"
+
+// versionManifest is the local version manifest shipped next to the binary,
+// e.g. {"version": "1.2.3", "git_commit": "abc123"}.
+type versionManifest struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+}
+
+func (g *Guard) versionManifestPath() (string, error) {
+	if g.cfg.VersionManifestPath != "" {
+		return g.cfg.VersionManifestPath, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(exe), defaultVersionManifestName), nil
+}
+
+func (g *Guard) readVersionManifest() (*versionManifest, error) {
+	path, err := g.versionManifestPath()
+	if err != nil {
+		return nil, fmt.Errorf("locate version manifest: %w", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read version manifest: %w", err)
+	}
+	var manifest versionManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("parse version manifest %s: %w", path, err)
+	}
+	manifest.Version = strings.TrimSpace(manifest.Version)
+	if manifest.Version == "" {
+		return nil, fmt.Errorf("version manifest %s has no version", path)
+	}
+	return &manifest, nil
+}
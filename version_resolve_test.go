@@ -0,0 +1,58 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAutoResolveVersionFallsBackToManifest(t *testing.T) {
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"version_not_found"}`))
+	})
+	g.cfg.VersionManifestPath = filepath.Join(t.TempDir(), "version.json")
+
+	err := g.AutoResolveVersionContext(context.Background())
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected both failures to be reported, got %v", err)
+	}
+	if status := g.Status(); status.Version != "unknown" || status.VersionSource != "" {
+		t.Fatalf("a failed resolution must leave the version alone: %#v", status)
+	}
+
+	if err := os.WriteFile(g.cfg.VersionManifestPath, []byte(`{"version":" 1.4.2 ","git_commit":"abc123"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AutoResolveVersionContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if status := g.Status(); status.Version != "1.4.2" || status.VersionSource != VersionSourceManifest {
+		t.Fatalf("Status = %#v", status)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("the server should be asked first on every call, got %d requests", calls.Load())
+	}
+}
+
+func TestAutoResolveVersionPrefersLdflags(t *testing.T) {
+	g, calls := newRetryTestGuard(t, func(w http.ResponseWriter, r *http.Request) {})
+	old := Version
+	Version = "2.0.0"
+	t.Cleanup(func() { Version = old })
+
+	if err := g.AutoResolveVersionContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if status := g.Status(); status.Version != "2.0.0" || status.VersionSource != VersionSourceLdflags || calls.Load() != 0 {
+		t.Fatalf("Status = %#v, requests = %d", status, calls.Load())
+	}
+
+	g.SetVersion("2.0.1")
+	if status := g.Status(); status.VersionSource != VersionSourceManual {
+		t.Fatalf("SetVersion should mark the version manual, got %q", status.VersionSource)
+	}
+}
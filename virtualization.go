@@ -0,0 +1,194 @@
+package sdk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// instanceCounterFileName holds the per-install start counter reported as the
+// "instance_counter" aux signal.
+const instanceCounterFileName = "instance.counter"
+
+// volatileSignals change on every start by design and are left out of
+// fingerprint drift.
+var volatileSignals = map[string]bool{
+	"instance_counter": true,
+}
+
+// hypervisorDMIStrings maps lower-cased DMI vendor/product substrings to the
+// hypervisor they identify.
+var hypervisorDMIStrings = []struct {
+	match, hypervisor string
+}{
+	{"vmware", "vmware"},
+	{"virtualbox", "virtualbox"},
+	{"innotek", "virtualbox"},
+	{"qemu", "kvm"},
+	{"kvm", "kvm"},
+	{"xen", "xen"},
+	{"microsoft corporation virtual", "hyperv"},
+	{"virtual machine", "hyperv"},
+	{"parallels", "parallels"},
+	{"bhyve", "bhyve"},
+	{"amazon ec2", "aws"},
+	{"google compute engine", "gce"},
+}
+
+// hypervisorMACPrefixes maps vendor OUIs assigned to virtual NICs.
+var hypervisorMACPrefixes = map[string]string{
+	"00:05:69": "vmware",
+	"00:0c:29": "vmware",
+	"00:1c:14": "vmware",
+	"00:50:56": "vmware",
+	"08:00:27": "virtualbox",
+	"52:54:00": "kvm",
+	"00:16:3e": "xen",
+	"00:15:5d": "hyperv",
+	"00:1c:42": "parallels",
+}
+
+// Virtualization is the local verdict on whether this machine is a virtual
+// machine. Apps can use it to warn that a license bound to a VM image may be
+// flagged when the image is cloned.
+type Virtualization struct {
+	// Virtual reports that at least one signal points at a hypervisor.
+	Virtual bool
+	// Hypervisor names the detected hypervisor, e.g. "vmware" or "kvm", or
+	// "unknown" when only the CPU hypervisor flag is set.
+	Hypervisor string
+	// Evidence lists the signals that matched: "cpu_flag", "dmi" or "mac".
+	Evidence []string
+}
+
+// Virtualization reports the hypervisor detection made when the fingerprint
+// was collected.
+func (f *Fingerprint) Virtualization() Virtualization {
+	return f.virtualization
+}
+
+// Fingerprint returns the fingerprint the guard reports to the server, with
+// Config.Fingerprint applied to its aux signals.
+func (g *Guard) Fingerprint() *Fingerprint {
+	return g.fingerprint
+}
+
+// detectVirtualization combines the CPU hypervisor flag (CPUID leaf 1, ECX
+// bit 31, as the OS exposes it), DMI vendor strings and virtual NIC OUIs.
+func detectVirtualization(cpuHypervisorFlag bool, dmi []string, macs []string) Virtualization {
+	var v Virtualization
+	if cpuHypervisorFlag {
+		v.Evidence = append(v.Evidence, "cpu_flag")
+	}
+	for _, value := range dmi {
+		if name := hypervisorFromDMI(value); name != "" {
+			v.Hypervisor = name
+			v.Evidence = append(v.Evidence, "dmi")
+			break
+		}
+	}
+	for _, mac := range macs {
+		if len(mac) < 8 {
+			continue
+		}
+		if name, ok := hypervisorMACPrefixes[strings.ToLower(mac[:8])]; ok {
+			if v.Hypervisor == "" {
+				v.Hypervisor = name
+			}
+			v.Evidence = append(v.Evidence, "mac")
+			break
+		}
+	}
+	v.Virtual = len(v.Evidence) > 0
+	if v.Virtual && v.Hypervisor == "" {
+		v.Hypervisor = "unknown"
+	}
+	sort.Strings(v.Evidence)
+	return v
+}
+
+func hypervisorFromDMI(value string) string {
+	value = strings.ToLower(value)
+	for _, candidate := range hypervisorDMIStrings {
+		if strings.Contains(value, candidate.match) {
+			return candidate.hypervisor
+		}
+	}
+	return ""
+}
+
+func collectVirtualization(macs []string) Virtualization {
+	var flag bool
+	var dmi []string
+	switch runtime.GOOS {
+	case "linux":
+		if data, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+			flag = cpuinfoHasHypervisorFlag(string(data))
+		}
+		for _, name := range []string{"sys_vendor", "product_name", "bios_vendor", "board_vendor"} {
+			if data, err := os.ReadFile(filepath.Join("/sys/class/dmi/id", name)); err == nil {
+				dmi = append(dmi, strings.TrimSpace(string(data)))
+			}
+		}
+	case "darwin":
+		if present, err := runCommand("sysctl", "-n", "kern.hv_vmm_present"); err == nil {
+			flag = present == "1"
+		}
+		if model, err := runCommand("sysctl", "-n", "hw.model"); err == nil {
+			dmi = append(dmi, model)
+		}
+	}
+	return detectVirtualization(flag, dmi, macs)
+}
+
+func cpuinfoHasHypervisorFlag(cpuinfo string) bool {
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != "flags" {
+			continue
+		}
+		for _, flag := range strings.Fields(value) {
+			if flag == "hypervisor" {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// nextInstanceCounter increments and returns the start counter kept sealed
+// in the guard cache. Every copy of a cloned image continues from the same
+// value, so the server sees one machine ID reporting repeated or regressing
+// counters. An edited counter is discarded and starts over, which the server
+// sees as a regression too.
+func nextInstanceCounter(secrets *secretStore) (uint64, error) {
+	var counter uint64
+	data, err := secrets.LoadEntry(instanceCounterFileName)
+	switch {
+	case err == nil:
+		counter, _ = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	case !errors.Is(err, os.ErrNotExist) && !errors.Is(err, ErrStateTampered):
+		return 0, err
+	}
+	counter++
+	if err := secrets.SaveEntry(instanceCounterFileName, []byte(strconv.FormatUint(counter, 10))); err != nil {
+		return 0, err
+	}
+	return counter, nil
+}
+
+// stableSignals returns aux without the volatile signals.
+func stableSignals(aux map[string]string) map[string]string {
+	stable := make(map[string]string, len(aux))
+	for name, value := range aux {
+		if !volatileSignals[name] {
+			stable[name] = value
+		}
+	}
+	return stable
+}
@@ -0,0 +1,94 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectVirtualization(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       bool
+		dmi        []string
+		macs       []string
+		hypervisor string
+		evidence   []string
+	}{
+		{name: "bare metal", dmi: []string{"Dell Inc.", "PowerEdge R640"}, macs: []string{"3c:ec:ef:00:00:01"}},
+		{name: "dmi and mac", flag: true, dmi: []string{"VMware, Inc."}, macs: []string{"00:50:56:aa:bb:cc"}, hypervisor: "vmware", evidence: []string{"cpu_flag", "dmi", "mac"}},
+		{name: "mac only", macs: []string{"52:54:00:12:34:56"}, hypervisor: "kvm", evidence: []string{"mac"}},
+		{name: "flag only", flag: true, hypervisor: "unknown", evidence: []string{"cpu_flag"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectVirtualization(tt.flag, tt.dmi, tt.macs)
+			if got.Virtual != (tt.hypervisor != "") || got.Hypervisor != tt.hypervisor || !reflect.DeepEqual(got.Evidence, tt.evidence) {
+				t.Fatalf("detectVirtualization() = %#v", got)
+			}
+		})
+	}
+}
+
+func TestCpuinfoHasHypervisorFlag(t *testing.T) {
+	if !cpuinfoHasHypervisorFlag("processor\t: 0\nflags\t\t: fpu vme sse2 hypervisor lahf_lm\n") {
+		t.Error("expected hypervisor flag to be found")
+	}
+	if cpuinfoHasHypervisorFlag("processor\t: 0\nflags\t\t: fpu vme sse2\n") {
+		t.Error("expected no hypervisor flag")
+	}
+}
+
+func TestNextInstanceCounterIncrements(t *testing.T) {
+	cfg := Config{cacheDir: t.TempDir(), ProjectSlug: "project", ComponentSlug: "backend"}
+	secrets := newSecretStore(cfg, &Fingerprint{machineID: "sha256:m"})
+	for want := uint64(1); want <= 3; want++ {
+		got, err := nextInstanceCounter(secrets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("counter = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestInstanceCounterIsSealed(t *testing.T) {
+	cfg := Config{cacheDir: t.TempDir(), ProjectSlug: "project", ComponentSlug: "backend"}
+	secrets := newSecretStore(cfg, &Fingerprint{machineID: "sha256:m"})
+	if _, err := nextInstanceCounter(secrets); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := cacheStoreFor(cfg).Load(instanceCounterFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) == "1" {
+		t.Fatalf("counter stored in the clear: %q", raw)
+	}
+
+	// Resetting the counter by rewriting the file only starts it over.
+	if err := cacheStoreFor(cfg).Save(instanceCounterFileName, []byte("1000")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := nextInstanceCounter(secrets); err != nil || got != 1 {
+		t.Fatalf("counter after edit = %d, %v; want 1", got, err)
+	}
+
+	fp := (&Fingerprint{machineID: "sha256:m", auxSignals: map[string]string{"instance_counter": "7", "cpu_model": "x"}}).
+		withPrivacy(FingerprintConfig{PrivacyMode: FingerprintPrivacyHashed})
+	if fp.auxSignals["instance_counter"] != "7" || fp.auxSignals["cpu_model"] == "x" {
+		t.Fatalf("hashed privacy signals = %v", fp.auxSignals)
+	}
+}
+
+func TestInstanceCounterIsNotDrift(t *testing.T) {
+	cfg := Config{cacheDir: t.TempDir(), LicenseKey: "key", ProjectSlug: "project"}
+	signals := map[string]string{"os": "linux", "instance_counter": "1"}
+	if err := saveBindingRecord(cfg, bindingRecord{MachineID: "sha256:m", AuxSignals: stableSignals(signals)}); err != nil {
+		t.Fatal(err)
+	}
+	fp := &Fingerprint{machineID: "sha256:m", auxSignals: map[string]string{"os": "linux", "instance_counter": "2"}}
+	if drift := detectFingerprintDrift(cfg, fp); drift != nil {
+		t.Fatalf("expected no drift from the instance counter, got %#v", drift)
+	}
+}